@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jestAdapter reads Jest JSON, as written by jest --json
+type jestAdapter struct{}
+
+func (jestAdapter) Format() string { return "jest" }
+
+func (jestAdapter) Detect(head []byte) bool {
+	return bytes.HasPrefix(head, []byte("{")) &&
+		(bytes.Contains(head, []byte(`"numTotalTests"`)) || bytes.Contains(head, []byte(`"testResults"`)))
+}
+
+// jestReport is the part of jest --json output that is reported
+type jestReport struct {
+	TestResults []struct {
+		Name             string `json:"name"`    // Absolute path of the test file
+		Status           string `json:"status"`  // "passed" or "failed"
+		Message          string `json:"message"` // Failure of the file itself
+		StartTime        int64  `json:"startTime"`
+		EndTime          int64  `json:"endTime"`
+		AssertionResults []struct {
+			AncestorTitles  []string `json:"ancestorTitles"`
+			Title           string   `json:"title"`
+			Status          string   `json:"status"` // "passed", "failed", "pending", "skipped", "todo" or "disabled"
+			Duration        float64  `json:"duration"`
+			FailureMessages []string `json:"failureMessages"`
+		} `json:"assertionResults"`
+	} `json:"testResults"`
+}
+
+// Suites returns a suite per test file, named relative to root, with the file
+// as root test and its describe blocks as subtests
+func (j jestAdapter) Suites(r io.Reader, root string) ([]*Suite, error) {
+	var report jestReport
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return nil, fmt.Errorf("error decoding Jest JSON: %v", err)
+	}
+	var suites []*Suite
+	for _, file := range report.TestResults {
+		name := file.Name
+		if rel, err := filepath.Rel(root, name); err == nil && !strings.HasPrefix(rel, "..") {
+			name = rel
+		}
+		name = filepath.ToSlash(name)
+		suite := &Suite{
+			Package: j.Format() + ":" + name,
+			Failed:  file.Status == "failed",
+		}
+		if file.StartTime > 0 {
+			suite.Start = time.UnixMilli(file.StartTime)
+			suite.Duration = float64(file.EndTime-file.StartTime) / 1000
+		}
+		if file.Message != "" && len(file.AssertionResults) == 0 {
+			suite.Output = strings.Split(strings.TrimRight(file.Message, "\n"), "\n")
+		}
+		for _, a := range file.AssertionResults {
+			c := Case{
+				Path:     append(append([]string{path.Base(name)}, a.AncestorTitles...), a.Title),
+				Duration: a.Duration / 1000,
+			}
+			switch a.Status {
+			case "passed":
+				c.Status = "PASS"
+			case "failed":
+				c.Status = "FAIL"
+				for _, message := range a.FailureMessages {
+					c.Output = append(c.Output, strings.Split(strings.TrimRight(message, "\n"), "\n")...)
+				}
+			default:
+				c.Status = "SKIP"
+			}
+			suite.Cases = append(suite.Cases, c)
+		}
+		suites = append(suites, suite)
+	}
+	return suites, nil
+}
+
+// junitAttachment matches the attachments of a JUnit test case, given as
+// [[ATTACHMENT|PATH]] in its output like the Jenkins JUnit Attachments plugin
+var junitAttachment = regexp.MustCompile(`\[\[ATTACHMENT\|([^\]]+)\]\]`)
+
+// junitAdapter reads JUnit XML, as written by pytest --junitxml and most
+// other test runners
+type junitAdapter struct{}
+
+func (junitAdapter) Format() string { return "junit" }
+
+func (junitAdapter) Detect(head []byte) bool {
+	return bytes.HasPrefix(head, []byte("<"))
+}
+
+// junitResult is a failure, error or skip element of a JUnit test case
+type junitResult struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitCase struct {
+	Name      string       `xml:"name,attr"`
+	ClassName string       `xml:"classname,attr"`
+	Time      string       `xml:"time,attr"`
+	Failure   *junitResult `xml:"failure"`
+	Error     *junitResult `xml:"error"`
+	Skipped   *junitResult `xml:"skipped"`
+	SystemOut string       `xml:"system-out"`
+}
+
+type junitSuite struct {
+	Name      string       `xml:"name,attr"`
+	Timestamp string       `xml:"timestamp,attr"`
+	Cases     []junitCase  `xml:"testcase"`
+	Suites    []junitSuite `xml:"testsuite"`
+}
+
+// lines splits the text of a JUnit element into output lines
+func (r *junitResult) lines() []string {
+	var lines []string
+	if r.Message != "" {
+		lines = append(lines, r.Message)
+	}
+	if text := strings.TrimSpace(r.Text); text != "" && text != r.Message {
+		lines = append(lines, strings.Split(text, "\n")...)
+	}
+	return lines
+}
+
+// Suites groups the cases by class name into suites tagged with the name of
+// their test suite, e.g. "pytest:tests.test_login", with the last part of
+// the class name as the root test
+func (ju junitAdapter) Suites(r io.Reader, _ string) ([]*Suite, error) {
+	decoder := xml.NewDecoder(r)
+	var suites []junitSuite
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("error decoding JUnit XML: %v", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "testsuite" {
+			continue
+		}
+		var suite junitSuite
+		if err := decoder.DecodeElement(&suite, &start); err != nil {
+			return nil, fmt.Errorf("error decoding JUnit XML: %v", err)
+		}
+		suites = append(suites, suite)
+	}
+
+	var result []*Suite
+	byPackage := make(map[string]*Suite)
+	var add func(suite junitSuite)
+	add = func(suite junitSuite) {
+		tag := suite.Name
+		if tag == "" {
+			tag = ju.Format()
+		}
+		start, _ := time.Parse("2006-01-02T15:04:05.999999", suite.Timestamp)
+		for _, tc := range suite.Cases {
+			class := tc.ClassName
+			if class == "" {
+				class = suite.Name
+			}
+			pkg := tag + ":" + class
+			fs, ok := byPackage[pkg]
+			if !ok {
+				fs = &Suite{Package: pkg, Start: start}
+				result = append(result, fs)
+				byPackage[pkg] = fs
+			}
+			duration, _ := strconv.ParseFloat(tc.Time, 64)
+			c := Case{Path: []string{class[strings.LastIndex(class, ".")+1:], tc.Name}, Status: "PASS", Duration: duration}
+			switch {
+			case tc.Failure != nil:
+				c.Status, c.Output = "FAIL", tc.Failure.lines()
+			case tc.Error != nil:
+				c.Status, c.Output = "FAIL", tc.Error.lines()
+			case tc.Skipped != nil:
+				c.Status, c.Output = "SKIP", tc.Skipped.lines()
+			}
+			systemOut := junitAttachment.ReplaceAllStringFunc(tc.SystemOut, func(m string) string {
+				file := strings.TrimSpace(junitAttachment.FindStringSubmatch(m)[1])
+				c.Attachments = append(c.Attachments, Attachment{Name: path.Base(filepath.ToSlash(file)), Path: file})
+				return ""
+			})
+			if c.Status == "FAIL" && strings.TrimSpace(systemOut) != "" {
+				c.Output = append(c.Output, strings.Split(strings.TrimSpace(systemOut), "\n")...)
+			}
+			fs.Duration += duration
+			fs.Cases = append(fs.Cases, c)
+		}
+		for _, nested := range suite.Suites {
+			add(nested)
+		}
+	}
+	for _, suite := range suites {
+		add(suite)
+	}
+	return result, nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const jestSample = `{
+  "numTotalTests": 3,
+  "testResults": [{
+    "name": "/work/web/src/login.test.js",
+    "status": "failed",
+    "message": "",
+    "startTime": 1700000000000,
+    "endTime": 1700000001500,
+    "assertionResults": [
+      {"ancestorTitles": ["Login", "form"], "title": "submits", "status": "passed", "duration": 12, "failureMessages": []},
+      {"ancestorTitles": ["Login", "form"], "title": "validates email", "status": "failed", "duration": 30, "failureMessages": ["Error: expect(received).toBe(expected)\n\nExpected: true\nReceived: false"]},
+      {"ancestorTitles": ["Login"], "title": "remembers the user", "status": "pending", "duration": 0, "failureMessages": []}
+    ]
+  }]
+}`
+
+const junitSample = `<?xml version="1.0" encoding="utf-8"?>
+<testsuites>
+  <testsuite name="pytest" timestamp="2024-05-01T12:00:00.123456">
+    <testcase classname="tests.test_api.TestUsers" name="test_create" time="0.25"/>
+    <testcase classname="tests.test_api.TestUsers" name="test_delete" time="0.5">
+      <failure message="AssertionError: assert 404 == 204">def test_delete():
+&gt;       assert response.status == 204</failure>
+      <system-out>DELETE /users/1
+[[ATTACHMENT|/tmp/artifacts/response.json]]</system-out>
+    </testcase>
+    <testcase classname="tests.test_api.TestUsers" name="test_export" time="0">
+      <skipped message="needs S3"/>
+    </testcase>
+  </testsuite>
+</testsuites>`
+
+func TestJestAdapter(t *testing.T) {
+	suites, err := jestAdapter{}.Suites(strings.NewReader(jestSample), "/work/web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(suites) != 1 || suites[0].Package != "jest:src/login.test.js" || !suites[0].Failed || suites[0].Duration != 1.5 {
+		t.Fatalf("Expected the failed file as one suite of 1.5s, got %+v", suites)
+	}
+	cases := suites[0].Cases
+	if len(cases) != 3 {
+		t.Fatalf("Expected 3 cases, got %+v", cases)
+	}
+	if strings.Join(cases[1].Path, "/") != "login.test.js/Login/form/validates email" || cases[1].Status != "FAIL" || cases[1].Duration != 0.03 {
+		t.Errorf("Unexpected failing case %+v", cases[1])
+	}
+	if len(cases[1].Output) != 4 || cases[1].Output[0] != "Error: expect(received).toBe(expected)" {
+		t.Errorf("Expected the failure message as output, got %q", cases[1].Output)
+	}
+	if cases[2].Status != "SKIP" {
+		t.Errorf("Expected the pending test to be skipped, got %+v", cases[2])
+	}
+}
+
+func TestJUnitAdapter(t *testing.T) {
+	suites, err := junitAdapter{}.Suites(strings.NewReader(junitSample), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(suites) != 1 || suites[0].Package != "pytest:tests.test_api.TestUsers" || len(suites[0].Cases) != 3 {
+		t.Fatalf("Expected one suite of 3 cases, got %+v", suites)
+	}
+	cases := suites[0].Cases
+	if cases[0].Status != "PASS" || strings.Join(cases[0].Path, "/") != "TestUsers/test_create" {
+		t.Errorf("Unexpected passing case %+v", cases[0])
+	}
+	expectedOutput := []string{"AssertionError: assert 404 == 204", "def test_delete():", ">       assert response.status == 204", "DELETE /users/1"}
+	if cases[1].Status != "FAIL" || strings.Join(cases[1].Output, "\n") != strings.Join(expectedOutput, "\n") {
+		t.Errorf("Unexpected failing case %+v", cases[1])
+	}
+	if len(cases[1].Attachments) != 1 || cases[1].Attachments[0] != (Attachment{Name: "response.json", Path: "/tmp/artifacts/response.json"}) {
+		t.Errorf("Expected the attachment of the failing case, got %+v", cases[1].Attachments)
+	}
+	if cases[2].Status != "SKIP" || len(cases[2].Output) != 1 || cases[2].Output[0] != "needs S3" {
+		t.Errorf("Unexpected skipped case %+v", cases[2])
+	}
+	if suites[0].Duration != 0.75 || suites[0].Start.IsZero() {
+		t.Errorf("Expected the suite to start at the timestamp and take 0.75s, got %+v", suites[0])
+	}
+}
+
+func TestLoadReportsMixedLanguages(t *testing.T) {
+	dir := t.TempDir()
+	goInput := filepath.Join(dir, "go.json")
+	jestInput := filepath.Join(dir, "jest.json")
+	junitInput := filepath.Join(dir, "pytest.xml")
+	os.WriteFile(goInput, []byte(`{"Action":"pass","Package":"example.com/api","Test":"TestServe","Elapsed":0.1}`+"\n"+`{"Action":"pass","Package":"example.com/api","Elapsed":0.2}`+"\n"), 0644)
+	os.WriteFile(jestInput, []byte(jestSample), 0644)
+	os.WriteFile(junitInput, []byte(junitSample), 0644)
+
+	data, err := loadReports([]string{goInput, jestInput, junitInput}, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, pkg := range map[string]string{
+		"TestServe": "example.com/api",
+		"login.test.js/Login/form/validates email": "jest:/work/web/src/login.test.js",
+		"TestUsers/test_delete":                    "pytest:tests.test_api.TestUsers",
+	} {
+		result, ok := data.Results[testKey(pkg, name)]
+		if !ok || result.Package != pkg {
+			t.Errorf("Expected %s in package %s, got %+v", name, pkg, result)
+		}
+	}
+	// The Jest file and the pytest class are root tests
+	if data.TotalTests != 3 || data.FailedTests != 2 {
+		t.Errorf("Expected 3 root tests with 2 failures, got %d with %d failures", data.TotalTests, data.FailedTests)
+	}
+	if result := data.Results[testKey("jest:/work/web/src/login.test.js", "login.test.js/Login/remembers the user")]; result == nil || result.Status != "SKIP" {
+		t.Errorf("Expected the pending Jest test to be skipped, got %+v", result)
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Annotation is a failure attached to a source location. Annotations are
+// produced once from the report and written for a CI provider by an
+// AnnotationWriter, so they show up inline on the diff or the build.
+type Annotation struct {
+	File     string // Path relative to the repository root, empty when unknown
+	Line     int
+	Severity string // annotationError or annotationWarning
+	Test     string // Failed test, empty for annotations of the whole run
+	Package  string // Package of the test
+	Title    string
+	Message  string
+}
+
+const (
+	annotationError   = "error"
+	annotationWarning = "warning"
+)
+
+// location returns the file of the annotation, or the directory of its
+// package for providers that need a path
+func (a Annotation) location(resolver sourceResolver) string {
+	if a.File != "" || a.Package == "" {
+		return a.File
+	}
+	return resolver.packageDir(a.Package)
+}
+
+var (
+	// testLogLocation matches t.Error/t.Log output, e.g. "    foo_test.go:42: message"
+	testLogLocation = regexp.MustCompile(`^\s*([\w.\-]+\.go):(\d+): ?(.*)$`)
+	// frameLocation matches stack frames and panics, e.g. "\t/src/repo/pkg/foo.go:42 +0x1d",
+	// or "\tD:/a/repo/pkg/foo.go:42 +0x1d" on Windows
+	frameLocation = regexp.MustCompile(`^\s*(/\S+\.go|[A-Za-z]:[\\/]\S+\.go):(\d+)(?: \+0x[0-9a-f]+)?$`)
+)
+
+// readModulePath returns the module path declared in a go.mod file, or an
+// empty string when it cannot be read
+func readModulePath(goMod string) string {
+	content, err := os.ReadFile(goMod)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
+
+// sourceResolver maps locations found in test output to repository paths
+type sourceResolver struct {
+	ModulePath string // Module of the repository, from its go.mod
+	Root       string // Absolute repository root, e.g. $GITHUB_WORKSPACE
+}
+
+// workspaceResolver resolves locations against the GitHub Actions workspace,
+// or the working directory outside of GitHub Actions
+func workspaceResolver() sourceResolver {
+	root := os.Getenv("GITHUB_WORKSPACE")
+	if root == "" {
+		root, _ = os.Getwd()
+	}
+	return sourceResolver{ModulePath: readModulePath(filepath.Join(root, "go.mod")), Root: root}
+}
+
+// testFile resolves a bare file name logged by a test of pkg
+func (r sourceResolver) testFile(pkg, file string) string {
+	if r.ModulePath != "" {
+		if pkg == r.ModulePath {
+			return file
+		}
+		if rel, ok := strings.CutPrefix(pkg, r.ModulePath+"/"); ok {
+			return rel + "/" + file
+		}
+	}
+	return file
+}
+
+// packageDir returns the repository relative directory of pkg, "." for the
+// module root. Packages outside the module are returned unchanged.
+func (r sourceResolver) packageDir(pkg string) string {
+	if r.ModulePath != "" {
+		if pkg == r.ModulePath {
+			return "."
+		}
+		if rel, ok := strings.CutPrefix(pkg, r.ModulePath+"/"); ok {
+			return rel
+		}
+	}
+	return pkg
+}
+
+// absoluteFile resolves an absolute path from a stack frame, returning an
+// empty string for files outside the repository such as the standard library.
+// Windows paths match with either separator and any drive letter case, also
+// when the report is generated on another OS.
+func (r sourceResolver) absoluteFile(file string) string {
+	if r.Root == "" {
+		return ""
+	}
+	root, file := slashPath(r.Root), slashPath(file)
+	if isWindowsPath(file) {
+		if len(file) > len(root) && strings.EqualFold(file[:len(root)], root) && file[len(root)] == '/' {
+			return file[len(root)+1:]
+		}
+		return ""
+	}
+	rel, ok := strings.CutPrefix(file, strings.TrimSuffix(root, "/")+"/")
+	if !ok {
+		return ""
+	}
+	return rel
+}
+
+// slashPath cleans a path and turns Windows backslashes into slashes
+func slashPath(p string) string {
+	return path.Clean(strings.ReplaceAll(p, `\`, "/"))
+}
+
+// isWindowsPath reports whether p starts with a drive letter, e.g. "C:/"
+func isWindowsPath(p string) bool {
+	return len(p) >= 3 && p[1] == ':' && (p[2] == '/' || p[2] == '\\') &&
+		('a' <= p[0] && p[0] <= 'z' || 'A' <= p[0] && p[0] <= 'Z')
+}
+
+// generatedHeader is the comment marking generated Go files, see go help generate
+var generatedHeader = regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.$`)
+
+// ownedFile reports whether a repository file is maintained by the team,
+// rather than vendored dependencies or generated code such as *.pb.go
+func (r sourceResolver) ownedFile(file string) bool {
+	if file == "vendor" || strings.HasPrefix(file, "vendor/") || strings.Contains(file, "/vendor/") {
+		return false
+	}
+	base := path.Base(file)
+	for _, suffix := range []string{".pb.go", ".pb.gw.go", "_gen.go", ".gen.go"} {
+		if strings.HasSuffix(base, suffix) {
+			return false
+		}
+	}
+	if strings.HasPrefix(base, "zz_generated") {
+		return false
+	}
+	// The header has to appear before the package clause
+	content, err := os.ReadFile(filepath.Join(r.Root, filepath.FromSlash(file)))
+	if err != nil {
+		return true
+	}
+	if end := strings.Index(string(content), "\npackage "); end >= 0 {
+		content = content[:end]
+	}
+	return !generatedHeader.Match(content)
+}
+
+// locateFailure extracts the source locations and their messages from the
+// output of a failed test. Continuation lines indented below a t.Error line
+// are part of its message.
+func locateFailure(resolver sourceResolver, result *TestResult) []Annotation {
+	var annotations []Annotation
+	var current *Annotation
+	indent := 0
+	seen := make(map[string]bool)
+	inRepoFrame, ownedFrame := false, false
+	var outside string // Vendored or generated frame the panic surfaced in
+
+	add := func(a Annotation) {
+		key := a.File + ":" + strconv.Itoa(a.Line)
+		if seen[key] {
+			current = nil
+			return
+		}
+		seen[key] = true
+		annotations = append(annotations, a)
+		current = &annotations[len(annotations)-1]
+	}
+
+	for _, line := range result.Output {
+		if m := testLogLocation.FindStringSubmatch(line); m != nil {
+			lineNo, _ := strconv.Atoi(m[2])
+			add(Annotation{File: resolver.testFile(result.Package, m[1]), Line: lineNo, Title: result.Name, Message: m[3]})
+			indent = len(line) - len(strings.TrimLeft(line, " \t"))
+			continue
+		}
+		if m := frameLocation.FindStringSubmatch(line); m != nil {
+			// The first frame inside the repository is where a panic surfaced.
+			// When that is vendored or generated code, the nearest caller the
+			// team owns is annotated as well.
+			file := resolver.absoluteFile(m[1])
+			if file == "" || ownedFrame {
+				continue
+			}
+			lineNo, _ := strconv.Atoi(m[2])
+			owned := resolver.ownedFile(file)
+			switch {
+			case !inRepoFrame:
+				add(Annotation{File: file, Line: lineNo, Title: result.Name, Message: panicMessage(result.Output)})
+				if !owned {
+					outside = fmt.Sprintf("%s:%d", file, lineNo)
+				}
+			case owned:
+				add(Annotation{File: file, Line: lineNo, Title: result.Name, Message: panicMessage(result.Output) + "\nvia " + outside})
+			}
+			inRepoFrame, ownedFrame = true, owned
+			continue
+		}
+		if current != nil && strings.TrimSpace(line) != "" && len(line)-len(strings.TrimLeft(line, " \t")) > indent {
+			current.Message += "\n" + strings.TrimSpace(line)
+			continue
+		}
+		current = nil
+	}
+	return annotations
+}
+
+// panicMessage returns the "panic: ..." line of the output, if any
+func panicMessage(output []string) string {
+	for _, line := range output {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "panic: ") {
+			return trimmed
+		}
+	}
+	return "test panicked"
+}
+
+// failureAnnotations returns an annotation for every failure location of the
+// failed tests. A failed test whose output has no location still gets an
+// annotation without a file so it is listed on the run.
+func failureAnnotations(data *ReportData, resolver sourceResolver) []Annotation {
+	var annotations []Annotation
+	for _, name := range sortedResultNames(data) {
+		result := data.Results[name]
+		if result.Status != "FAIL" || result.Waiver != nil || result.Quarantine != nil {
+			continue
+		}
+		found := locateFailure(resolver, result)
+		if len(found) == 0 {
+			failedChild := false
+			for _, sub := range result.SubTests {
+				if s, ok := data.Results[testKey(result.Package, sub)]; ok && s.Status == "FAIL" {
+					failedChild = true
+				}
+			}
+			if failedChild {
+				// Reported through the subtest
+				continue
+			}
+			found = []Annotation{{Title: result.Name, Message: fmt.Sprintf("%s failed in %s", result.Name, result.Package)}}
+		}
+		for _, a := range found {
+			a.Severity, a.Test, a.Package = annotationError, result.Name, result.Package
+			annotations = append(annotations, a)
+		}
+	}
+	return annotations
+}
+
+// runAnnotations returns the annotations of the run: the failures, and a
+// warning without a location when the suite slowed down
+func runAnnotations(data *ReportData, slowdown *SuiteSlowdown, resolver sourceResolver) []Annotation {
+	annotations := failureAnnotations(data, resolver)
+	if slowdown != nil {
+		annotations = append(annotations, Annotation{Severity: annotationWarning, Title: "Suite slowdown", Message: "The " + slowdown.String()})
+	}
+	return annotations
+}
+
+// sortedResultNames returns the key of every test and subtest in order
+func sortedResultNames(data *ReportData) []string {
+	var names []string
+	for _, root := range data.SortedTestNames {
+		var walk func(name string)
+		walk = func(name string) {
+			names = append(names, name)
+			if result, ok := data.Results[name]; ok {
+				for _, sub := range result.SubTests {
+					walk(testKey(result.Package, sub))
+				}
+			}
+		}
+		walk(root)
+	}
+	return names
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFailureAnnotations(t *testing.T) {
+	resolver := sourceResolver{ModulePath: "example.com/repo", Root: "/src/repo"}
+	data := &ReportData{
+		Results: map[string]*TestResult{
+			"TestParse": {Name: "TestParse", Package: "example.com/repo/parser", Status: "FAIL", Output: []string{
+				"=== RUN   TestParse",
+				"    parser_test.go:42: unexpected result:",
+				"        got:  1",
+				"        want: 2",
+				"    parser_test.go:50: second failure",
+				"--- FAIL: TestParse (0.00s)",
+			}},
+			"TestPanic": {Name: "TestPanic", Package: "example.com/repo", Status: "FAIL", Output: []string{
+				"--- FAIL: TestPanic (0.00s)",
+				"panic: runtime error: index out of range [3] with length 3 [recovered]",
+				"goroutine 7 [running]:",
+				"testing.tRunner.func1.2({0x5f3e20, 0xc000018150})",
+				"\t/usr/local/go/src/testing/testing.go:1631 +0x24a",
+				"example.com/repo.lookup(...)",
+				"\t/src/repo/lookup.go:12 +0x1d",
+				"example.com/repo.TestPanic(0xc0001036c0)",
+				"\t/src/repo/lookup_test.go:8 +0x18",
+			}},
+			"TestSilent": {Name: "TestSilent", Package: "example.com/repo", Status: "FAIL", Output: []string{"--- FAIL: TestSilent (0.00s)"}},
+			"TestWaived": {Name: "TestWaived", Package: "example.com/repo", Status: "FAIL", Output: []string{"    a_test.go:1: known"}, Waiver: &Waiver{}},
+			"TestPass":   {Name: "TestPass", Package: "example.com/repo", Status: "PASS", Output: []string{"    a_test.go:1: log"}},
+		},
+	}
+	summarizeReport(data, nil)
+
+	expected := []Annotation{
+		{File: "lookup.go", Line: 12, Severity: annotationError, Test: "TestPanic", Package: "example.com/repo", Title: "TestPanic", Message: "panic: runtime error: index out of range [3] with length 3 [recovered]"},
+		{File: "parser/parser_test.go", Line: 42, Severity: annotationError, Test: "TestParse", Package: "example.com/repo/parser", Title: "TestParse", Message: "unexpected result:\ngot:  1\nwant: 2"},
+		{File: "parser/parser_test.go", Line: 50, Severity: annotationError, Test: "TestParse", Package: "example.com/repo/parser", Title: "TestParse", Message: "second failure"},
+		{Severity: annotationError, Test: "TestSilent", Package: "example.com/repo", Title: "TestSilent", Message: "TestSilent failed in example.com/repo"},
+	}
+	got := failureAnnotations(data, resolver)
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d annotations, got %+v", len(expected), got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Annotation %d: expected %+v, got %+v", i, expected[i], got[i])
+		}
+	}
+}
+
+func TestReadModulePath(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "go.mod")
+	if err := os.WriteFile(file, []byte("// comment\nmodule example.com/repo\n\ngo 1.23\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := readModulePath(file); got != "example.com/repo" {
+		t.Errorf("Expected example.com/repo, got %q", got)
+	}
+	if got := readModulePath(filepath.Join(t.TempDir(), "missing")); got != "" {
+		t.Errorf("Expected no module path for a missing file, got %q", got)
+	}
+}
+
+func TestWindowsReport(t *testing.T) {
+	// go test -json on a Windows runner: CRLF line endings and output, and
+	// stack frames with drive letters
+	input := strings.Join([]string{
+		`{"Action":"run","Package":"example.com/repo","Test":"TestPanic"}`,
+		`{"Action":"output","Package":"example.com/repo","Test":"TestPanic","Output":"    lookup_test.go:5: Error: lookup failed\r\n"}`,
+		`{"Action":"output","Package":"example.com/repo","Test":"TestPanic","Output":"panic: boom [recovered]\r\n"}`,
+		`{"Action":"output","Package":"example.com/repo","Test":"TestPanic","Output":"\tC:/hostedtoolcache/windows/go/src/testing/testing.go:1631 +0x24a\r\n"}`,
+		`{"Action":"output","Package":"example.com/repo","Test":"TestPanic","Output":"\tD:/a/repo/repo/lookup.go:12 +0x1d\r\n"}`,
+		`{"Action":"fail","Package":"example.com/repo","Test":"TestPanic","Elapsed":0.1}`,
+		`{"Action":"output","Package":"example.com/repo","Output":"FAIL\r\n"}`,
+		`{"Action":"fail","Package":"example.com/repo","Elapsed":0.2}`,
+	}, "\r\n") + "\r\n"
+	data, err := processTestEvents(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	report := generateMarkdownReport(data)
+	if strings.Contains(report, "\r") {
+		t.Errorf("Expected no carriage returns in the report, got %q", report)
+	}
+
+	resolver := sourceResolver{ModulePath: "example.com/repo", Root: `d:\a\repo\repo`}
+	expected := []Annotation{
+		{File: "lookup_test.go", Line: 5, Severity: annotationError, Test: "TestPanic", Package: "example.com/repo", Title: "TestPanic", Message: "Error: lookup failed"},
+		{File: "lookup.go", Line: 12, Severity: annotationError, Test: "TestPanic", Package: "example.com/repo", Title: "TestPanic", Message: "panic: boom [recovered]"},
+	}
+	got := failureAnnotations(data, resolver)
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d annotations, got %+v", len(expected), got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Annotation %d: expected %+v, got %+v", i, expected[i], got[i])
+		}
+	}
+}
+
+func TestAbsoluteFile(t *testing.T) {
+	tests := []struct {
+		root, file, expected string
+	}{
+		{"/src/repo", "/src/repo/pkg/a.go", "pkg/a.go"},
+		{"/src/repo/", "/src/repo/a.go", "a.go"},
+		{"/src/repo", "/src/repository/a.go", ""},
+		{"/src/repo", "/usr/local/go/src/fmt/print.go", ""},
+		{`D:\a\repo\repo`, "D:/a/repo/repo/pkg/a.go", "pkg/a.go"},
+		{`D:\a\repo\repo`, `d:\a\repo\repo\pkg\a.go`, "pkg/a.go"},
+		{`D:\a\repo\repo`, "C:/hostedtoolcache/windows/go/src/fmt/print.go", ""},
+		{"/src/repo", "D:/a/repo/repo/a.go", ""},
+	}
+	for _, tt := range tests {
+		if got := (sourceResolver{Root: tt.root}).absoluteFile(tt.file); got != tt.expected {
+			t.Errorf("absoluteFile(%q) with root %q: expected %q, got %q", tt.file, tt.root, tt.expected, got)
+		}
+	}
+}
+
+func TestFailureAnnotationsOwnedCaller(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "api"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	generated := "// Code generated by mockgen. DO NOT EDIT.\n\npackage api\n"
+	if err := os.WriteFile(filepath.Join(root, "api", "mock_client.go"), []byte(generated), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	resolver := sourceResolver{ModulePath: "example.com/repo", Root: root}
+
+	data := &ReportData{
+		Results: map[string]*TestResult{
+			"TestVendored": {Name: "TestVendored", Package: "example.com/repo", Status: "FAIL", Output: []string{
+				"panic: nil map [recovered]",
+				"github.com/lib/yaml.(*decoder).set(...)",
+				"\t" + root + "/vendor/github.com/lib/yaml/decode.go:88 +0x1d",
+				"github.com/lib/yaml.Unmarshal(...)",
+				"\t" + root + "/vendor/github.com/lib/yaml/yaml.go:12 +0x1d",
+				"example.com/repo/config.Load(...)",
+				"\t" + root + "/config/load.go:30 +0x18",
+				"example.com/repo.TestVendored(0xc0001036c0)",
+				"\t" + root + "/config_test.go:8 +0x18",
+			}},
+			"TestGenerated": {Name: "TestGenerated", Package: "example.com/repo/api", Status: "FAIL", Output: []string{
+				"panic: unexpected call [recovered]",
+				"\t" + root + "/api/mock_client.go:41 +0x1d",
+				"\t" + root + "/api/client.pb.go:7 +0x1d",
+				"\t" + root + "/api/client_test.go:19 +0x18",
+			}},
+		},
+	}
+	summarizeReport(data, nil)
+
+	expected := []Annotation{
+		{File: "api/mock_client.go", Line: 41, Severity: annotationError, Test: "TestGenerated", Package: "example.com/repo/api", Title: "TestGenerated", Message: "panic: unexpected call [recovered]"},
+		{File: "api/client_test.go", Line: 19, Severity: annotationError, Test: "TestGenerated", Package: "example.com/repo/api", Title: "TestGenerated", Message: "panic: unexpected call [recovered]\nvia api/mock_client.go:41"},
+		{File: "vendor/github.com/lib/yaml/decode.go", Line: 88, Severity: annotationError, Test: "TestVendored", Package: "example.com/repo", Title: "TestVendored", Message: "panic: nil map [recovered]"},
+		{File: "config/load.go", Line: 30, Severity: annotationError, Test: "TestVendored", Package: "example.com/repo", Title: "TestVendored", Message: "panic: nil map [recovered]\nvia vendor/github.com/lib/yaml/decode.go:88"},
+	}
+	got := failureAnnotations(data, resolver)
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d annotations, got %+v", len(expected), got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Annotation %d: expected %+v, got %+v", i, expected[i], got[i])
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// AnnotationWriter writes annotations in the format of a CI provider. The
+// annotations are found once by failureAnnotations, so supporting another
+// provider needs no failure-specific code.
+type AnnotationWriter interface {
+	// Name is the -annotations value selecting the writer, e.g. "github"
+	Name() string
+	// Detect reports whether the run is on the provider
+	Detect() bool
+	// DefaultFile is the file the annotations are written to without
+	// -annotations-output, empty for stdout
+	DefaultFile() string
+	// Write writes the annotations, resolving the package directory of those
+	// without a file for providers that need a path
+	Write(w io.Writer, annotations []Annotation, resolver sourceResolver) error
+}
+
+// annotationWriters are detected in order by -annotations auto
+var annotationWriters = []AnnotationWriter{githubAnnotations{}, gitlabCodeQuality{}, buildkiteAnnotations{}, azureAnnotations{}}
+
+// annotationWriter returns the writer selected by -annotations, or nil when
+// auto detects no provider
+func annotationWriter(name string) (AnnotationWriter, error) {
+	var names []string
+	for _, writer := range annotationWriters {
+		if name == writer.Name() || name == "auto" && writer.Detect() {
+			return writer, nil
+		}
+		names = append(names, writer.Name())
+	}
+	if name == "auto" {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported -annotations value %q (supported: %s, auto)", name, strings.Join(names, ", "))
+}
+
+// writeAnnotations writes the annotations to output, the default file of the
+// writer, or stdout. It returns the file written, if any.
+func writeAnnotations(writer AnnotationWriter, output string, annotations []Annotation, resolver sourceResolver) (string, error) {
+	if output == "" {
+		output = writer.DefaultFile()
+	}
+	if output == "" {
+		if _, ok := writer.(buildkiteAnnotations); ok && os.Getenv("BUILDKITE") != "" {
+			if agent, err := exec.LookPath("buildkite-agent"); err == nil {
+				return "", annotateBuildkite(agent, annotations, resolver)
+			}
+		}
+		return "", writer.Write(os.Stdout, annotations, resolver)
+	}
+	var buf bytes.Buffer
+	if err := writer.Write(&buf, annotations, resolver); err != nil {
+		return "", err
+	}
+	return output, os.WriteFile(output, buf.Bytes(), 0o644)
+}
+
+// githubAnnotations prints GitHub Actions workflow commands, shown inline on
+// the PR diff
+type githubAnnotations struct{}
+
+func (githubAnnotations) Name() string        { return "github" }
+func (githubAnnotations) Detect() bool        { return os.Getenv("GITHUB_ACTIONS") != "" }
+func (githubAnnotations) DefaultFile() string { return "" }
+
+func (githubAnnotations) Write(w io.Writer, annotations []Annotation, _ sourceResolver) error {
+	return writeWorkflowCommands(w, annotations)
+}
+
+// escapeWorkflowData escapes a workflow command message
+func escapeWorkflowData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeWorkflowProperty escapes a workflow command property value
+func escapeWorkflowProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// writeWorkflowCommands prints the annotations as ::error and ::warning
+// workflow commands
+func writeWorkflowCommands(w io.Writer, annotations []Annotation) error {
+	for _, a := range annotations {
+		var props []string
+		if a.File != "" {
+			props = append(props, "file="+escapeWorkflowProperty(a.File))
+			if a.Line > 0 {
+				props = append(props, "line="+strconv.Itoa(a.Line))
+			}
+		}
+		props = append(props, "title="+escapeWorkflowProperty(a.Title))
+		command := "error"
+		if a.Severity == annotationWarning {
+			command = "warning"
+		}
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", command, strings.Join(props, ","), escapeWorkflowData(a.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gitlabCodeQuality writes a GitLab code quality report, shown in the merge
+// request widget and on the changed lines of its diff
+type gitlabCodeQuality struct{}
+
+func (gitlabCodeQuality) Name() string        { return "gitlab" }
+func (gitlabCodeQuality) Detect() bool        { return os.Getenv("GITLAB_CI") != "" }
+func (gitlabCodeQuality) DefaultFile() string { return "gl-code-quality-report.json" }
+
+// codeQualityIssue is an issue of the code quality report, see
+// https://docs.gitlab.com/ee/ci/testing/code_quality.html#code-quality-report-format
+type codeQualityIssue struct {
+	Description string              `json:"description"`
+	CheckName   string              `json:"check_name"`
+	Fingerprint string              `json:"fingerprint"`
+	Severity    string              `json:"severity"`
+	Location    codeQualityLocation `json:"location"`
+}
+
+type codeQualityLocation struct {
+	Path  string           `json:"path"`
+	Lines codeQualityLines `json:"lines"`
+}
+
+type codeQualityLines struct {
+	Begin int `json:"begin"`
+}
+
+func (gitlabCodeQuality) Write(w io.Writer, annotations []Annotation, resolver sourceResolver) error {
+	// GitLab needs an array, also without issues
+	issues := []codeQualityIssue{}
+	for _, a := range annotations {
+		issue := codeQualityIssue{
+			Description: a.Title + ": " + a.Message,
+			CheckName:   "gotest-report",
+			Severity:    "critical",
+			Location:    codeQualityLocation{Path: a.location(resolver), Lines: codeQualityLines{Begin: max(a.Line, 1)}},
+		}
+		if a.Severity == annotationWarning {
+			issue.Severity = "minor"
+		}
+		if issue.Location.Path == "" {
+			issue.Location.Path = "."
+		}
+		// The fingerprint tells GitLab which issues are new in the merge request
+		sum := sha256.Sum256([]byte(strings.Join([]string{a.Title, issue.Location.Path, strconv.Itoa(a.Line)}, "\x00")))
+		issue.Fingerprint = hex.EncodeToString(sum[:16])
+		issues = append(issues, issue)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(issues)
+}
+
+// buildkiteAnnotations writes the Markdown body of a Buildkite annotation,
+// which buildkite-agent annotate shows on the build page
+type buildkiteAnnotations struct{}
+
+func (buildkiteAnnotations) Name() string        { return "buildkite" }
+func (buildkiteAnnotations) Detect() bool        { return os.Getenv("BUILDKITE") != "" }
+func (buildkiteAnnotations) DefaultFile() string { return "" }
+
+func (buildkiteAnnotations) Write(w io.Writer, annotations []Annotation, resolver sourceResolver) error {
+	if len(annotations) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	errors := 0
+	for _, a := range annotations {
+		if a.Severity != annotationWarning {
+			errors++
+		}
+	}
+	sb.WriteString(fmt.Sprintf("#### gotest-report: %d failure(s)\n\n", errors))
+	for _, a := range annotations {
+		sb.WriteString(fmt.Sprintf("**%s**", escapeMarkdown(a.Title)))
+		if file := a.location(resolver); file != "" {
+			if a.Line > 0 {
+				file += ":" + strconv.Itoa(a.Line)
+			}
+			sb.WriteString(" at " + codeSpan(file))
+		}
+		sb.WriteString("\n\n")
+		writeCodeBlock(&sb, "", strings.Split(a.Message, "\n"))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// annotateBuildkite creates or replaces the annotation of the build with
+// buildkite-agent, styled as an error when a test failed
+func annotateBuildkite(agent string, annotations []Annotation, resolver sourceResolver) error {
+	if len(annotations) == 0 {
+		return nil
+	}
+	style := "warning"
+	for _, a := range annotations {
+		if a.Severity != annotationWarning {
+			style = "error"
+		}
+	}
+	var body bytes.Buffer
+	if err := (buildkiteAnnotations{}).Write(&body, annotations, resolver); err != nil {
+		return err
+	}
+	cmd := exec.Command(agent, "annotate", "--style", style, "--context", "gotest-report")
+	cmd.Stdin = &body
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("buildkite-agent annotate: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// azureAnnotations prints Azure Pipelines logging commands, listed as errors
+// and warnings of the job
+type azureAnnotations struct{}
+
+func (azureAnnotations) Name() string        { return "azure" }
+func (azureAnnotations) Detect() bool        { return os.Getenv("TF_BUILD") != "" }
+func (azureAnnotations) DefaultFile() string { return "" }
+
+// escapeAzureData escapes a logging command message
+func escapeAzureData(s string) string {
+	return strings.NewReplacer("%", "%AZP25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeAzureProperty escapes a logging command property value
+func escapeAzureProperty(s string) string {
+	return strings.NewReplacer("%", "%AZP25", "\r", "%0D", "\n", "%0A", "]", "%5D", ";", "%3B").Replace(s)
+}
+
+func (azureAnnotations) Write(w io.Writer, annotations []Annotation, _ sourceResolver) error {
+	for _, a := range annotations {
+		props := "type=error;"
+		if a.Severity == annotationWarning {
+			props = "type=warning;"
+		}
+		if a.File != "" {
+			props += "sourcepath=" + escapeAzureProperty(a.File) + ";"
+			if a.Line > 0 {
+				props += "linenumber=" + strconv.Itoa(a.Line) + ";"
+			}
+		}
+		if _, err := fmt.Fprintf(w, "##vso[task.logissue %s]%s\n", props, escapeAzureData(a.Title+": "+a.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
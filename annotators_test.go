@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// annotatorsFixture is a failure with a location, one without, and a
+// warning of the run
+func annotatorsFixture() []Annotation {
+	return []Annotation{
+		{File: "pkg/a_test.go", Line: 3, Severity: annotationError, Test: "TestA/case:1,2", Package: "example.com/repo/pkg", Title: "TestA/case:1,2", Message: "100% wrong\nsecond line"},
+		{Severity: annotationError, Test: "TestB", Package: "example.com/repo/server", Title: "TestB", Message: "failed"},
+		{Severity: annotationWarning, Title: "Suite slowdown", Message: "The run took 2m0s; 20% over the average"},
+	}
+}
+
+func TestWriteWorkflowCommands(t *testing.T) {
+	var sb strings.Builder
+	if err := writeWorkflowCommands(&sb, annotatorsFixture()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "::error file=pkg/a_test.go,line=3,title=TestA/case%3A1%2C2::100%25 wrong%0Asecond line\n" +
+		"::error title=TestB::failed\n" +
+		"::warning title=Suite slowdown::The run took 2m0s; 20%25 over the average\n"
+	if sb.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, sb.String())
+	}
+}
+
+func TestAzureAnnotations(t *testing.T) {
+	var sb strings.Builder
+	if err := (azureAnnotations{}).Write(&sb, annotatorsFixture(), sourceResolver{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "##vso[task.logissue type=error;sourcepath=pkg/a_test.go;linenumber=3;]TestA/case:1,2: 100%AZP25 wrong%0Asecond line\n" +
+		"##vso[task.logissue type=error;]TestB: failed\n" +
+		"##vso[task.logissue type=warning;]Suite slowdown: The run took 2m0s; 20%AZP25 over the average\n"
+	if sb.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, sb.String())
+	}
+}
+
+func TestGitLabCodeQuality(t *testing.T) {
+	var sb strings.Builder
+	resolver := sourceResolver{ModulePath: "example.com/repo"}
+	if err := (gitlabCodeQuality{}).Write(&sb, annotatorsFixture(), resolver); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var issues []codeQualityIssue
+	if err := json.Unmarshal([]byte(sb.String()), &issues); err != nil {
+		t.Fatalf("Expected a JSON array, got %s (%v)", sb.String(), err)
+	}
+	if len(issues) != 3 {
+		t.Fatalf("Expected 3 issues, got %+v", issues)
+	}
+	expected := []struct {
+		path     string
+		line     int
+		severity string
+	}{{"pkg/a_test.go", 3, "critical"}, {"server", 1, "critical"}, {".", 1, "minor"}}
+	for i, e := range expected {
+		issue := issues[i]
+		if issue.Location.Path != e.path || issue.Location.Lines.Begin != e.line || issue.Severity != e.severity || len(issue.Fingerprint) != 32 {
+			t.Errorf("Issue %d: expected %s:%d %s, got %+v", i, e.path, e.line, e.severity, issue)
+		}
+	}
+	if issues[1].Description != "TestB: failed" || issues[0].Fingerprint == issues[1].Fingerprint {
+		t.Errorf("Unexpected description or fingerprints: %+v", issues)
+	}
+
+	sb.Reset()
+	if err := (gitlabCodeQuality{}).Write(&sb, nil, resolver); err != nil || strings.TrimSpace(sb.String()) != "[]" {
+		t.Errorf("Expected an empty array without annotations, got %q (%v)", sb.String(), err)
+	}
+}
+
+func TestBuildkiteAnnotations(t *testing.T) {
+	var sb strings.Builder
+	resolver := sourceResolver{ModulePath: "example.com/repo"}
+	if err := (buildkiteAnnotations{}).Write(&sb, annotatorsFixture(), resolver); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, expected := range []string{
+		"#### gotest-report: 2 failure(s)\n\n",
+		"**TestA/case:1,2** at `pkg/a_test.go:3`\n\n```\n100% wrong\nsecond line\n```\n",
+		"**TestB** at `server`\n\n",
+		"**Suite slowdown**\n\n",
+	} {
+		if !strings.Contains(sb.String(), expected) {
+			t.Errorf("Expected body to contain %q, got:\n%s", expected, sb.String())
+		}
+	}
+}
+
+func TestAnnotationWriter(t *testing.T) {
+	clearCIEnv(t)
+	for _, name := range []string{"GITHUB_ACTIONS", "GITLAB_CI", "BUILDKITE", "TF_BUILD"} {
+		t.Setenv(name, "")
+	}
+	if writer, err := annotationWriter("azure"); err != nil || writer.Name() != "azure" {
+		t.Errorf("Expected the azure writer, got %v (%v)", writer, err)
+	}
+	if writer, err := annotationWriter("auto"); err != nil || writer != nil {
+		t.Errorf("Expected no writer outside of CI, got %v (%v)", writer, err)
+	}
+	t.Setenv("GITLAB_CI", "true")
+	if writer, err := annotationWriter("auto"); err != nil || writer == nil || writer.Name() != "gitlab" {
+		t.Errorf("Expected GitLab to be detected, got %v (%v)", writer, err)
+	}
+	if _, err := annotationWriter("jenkins"); err == nil || !strings.Contains(err.Error(), "github, gitlab, buildkite, azure, auto") {
+		t.Errorf("Expected an error listing the writers, got %v", err)
+	}
+}
+
+func TestWriteAnnotationsFile(t *testing.T) {
+	dir := t.TempDir()
+	if file := (gitlabCodeQuality{}).DefaultFile(); file != "gl-code-quality-report.json" {
+		t.Errorf("Expected the default code quality file, got %q", file)
+	}
+
+	output := filepath.Join(dir, "annotations.txt")
+	if file, err := writeAnnotations(azureAnnotations{}, output, annotatorsFixture(), sourceResolver{}); err != nil || file != output {
+		t.Fatalf("Expected %s, got %q (%v)", output, file, err)
+	}
+	content, _ := os.ReadFile(output)
+	if !strings.HasPrefix(string(content), "##vso[task.logissue type=error;") {
+		t.Errorf("Unexpected content %s", content)
+	}
+}
+
+func TestRunAnnotations(t *testing.T) {
+	data := &ReportData{Results: map[string]*TestResult{}}
+	summarizeReport(data, nil)
+	if got := runAnnotations(data, nil, sourceResolver{}); len(got) != 0 {
+		t.Errorf("Expected no annotations, got %+v", got)
+	}
+	got := runAnnotations(data, &SuiteSlowdown{}, sourceResolver{})
+	if len(got) != 1 || got[0].Severity != annotationWarning || got[0].Title != "Suite slowdown" {
+		t.Errorf("Expected a slowdown warning, got %+v", got)
+	}
+}
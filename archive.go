@@ -0,0 +1,250 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// historyArchiveFile reports whether name belongs in a history archive
+func historyArchiveFile(name string) bool {
+	return name == waiversFile || (strings.HasPrefix(name, historyFilePrefix) && filepath.Ext(name) == ".json")
+}
+
+// exportHistory writes the run files and waivers in dir to w as a gzipped tar
+// archive. A missing directory produces an empty archive.
+func exportHistory(dir string, w io.Writer) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	count := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !historyArchiveFile(entry.Name()) {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return count, err
+		}
+		header := &tar.Header{
+			Name:    entry.Name(),
+			Mode:    0o644,
+			Size:    int64(len(content)),
+			ModTime: time.Now().UTC(),
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return count, err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := tw.Close(); err != nil {
+		return count, err
+	}
+	return count, gz.Close()
+}
+
+// importHistory merges an archive written by exportHistory into dir. Runs
+// already present are kept, and waivers are merged by fingerprint with the
+// local waiver winning.
+func importHistory(dir string, r io.Reader) (added, skipped int, err error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error reading archive: %v", err)
+	}
+	defer gz.Close()
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return 0, 0, err
+	}
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return added, skipped, fmt.Errorf("error reading archive: %v", err)
+		}
+		name := path.Clean(header.Name)
+		if header.Typeflag != tar.TypeReg || strings.Contains(name, "/") || !historyArchiveFile(name) {
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return added, skipped, fmt.Errorf("error reading %s: %v", name, err)
+		}
+
+		if name == waiversFile {
+			if err := mergeWaivers(dir, content); err != nil {
+				return added, skipped, err
+			}
+			continue
+		}
+
+		var record RunRecord
+		if err := json.Unmarshal(content, &record); err != nil {
+			return added, skipped, fmt.Errorf("error parsing history file %s: %v", name, err)
+		}
+		target := filepath.Join(dir, name)
+		if _, err := os.Stat(target); err == nil {
+			skipped++
+			continue
+		}
+		if err := os.WriteFile(target, content, 0o644); err != nil {
+			return added, skipped, err
+		}
+		added++
+	}
+	return added, skipped, nil
+}
+
+// mergeWaivers adds the archived waivers whose fingerprint is not waived locally
+func mergeWaivers(dir string, content []byte) error {
+	var imported []Waiver
+	if err := json.Unmarshal(content, &imported); err != nil {
+		return fmt.Errorf("error parsing %s: %v", waiversFile, err)
+	}
+	local, err := loadWaivers(dir)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(local))
+	for _, w := range local {
+		known[w.Fingerprint] = true
+	}
+	for _, w := range imported {
+		if !known[w.Fingerprint] {
+			local = append(local, w)
+			known[w.Fingerprint] = true
+		}
+	}
+	return saveWaivers(dir, local)
+}
+
+// runHistory implements `gotest-report history export|import|prune`, which
+// moves the history store between machines as a single portable archive and
+// expires old runs
+func runHistory(args []string) int {
+	if len(args) == 0 {
+		logger.Errorf("Usage: gotest-report history export|import|prune [flags]")
+		return 2
+	}
+
+	switch args[0] {
+	case "export":
+		fs := flag.NewFlagSet("history export", flag.ExitOnError)
+		logFlags := registerLogFlags(fs)
+		historyDir := fs.String("history-dir", "", "History directory to export")
+		output := fs.String("output", "history.tar.gz", "Archive file to write (- for stdout)")
+		fs.Parse(args[1:])
+		if err := logFlags.apply(); err != nil {
+			logger.Errorf("Error: %v", err)
+			return 2
+		}
+		if *historyDir == "" {
+			logger.Errorf("Usage: gotest-report history export -history-dir DIR [-output FILE]")
+			return 2
+		}
+
+		var w io.Writer = os.Stdout
+		if *output != "-" {
+			file, err := os.Create(*output)
+			if err != nil {
+				logger.Errorf("Error creating archive: %v", err)
+				return 1
+			}
+			defer file.Close()
+			w = file
+		}
+		count, err := exportHistory(*historyDir, w)
+		if err != nil {
+			logger.Errorf("Error exporting history: %v", err)
+			return 1
+		}
+		if *output != "-" {
+			logger.Printf("Exported %d history files to %s", count, *output)
+		}
+		return 0
+
+	case "import":
+		fs := flag.NewFlagSet("history import", flag.ExitOnError)
+		logFlags := registerLogFlags(fs)
+		historyDir := fs.String("history-dir", "", "History directory to import into")
+		fs.Parse(args[1:])
+		if err := logFlags.apply(); err != nil {
+			logger.Errorf("Error: %v", err)
+			return 2
+		}
+		if *historyDir == "" || fs.NArg() != 1 {
+			logger.Errorf("Usage: gotest-report history import -history-dir DIR ARCHIVE")
+			return 2
+		}
+
+		var r io.Reader = os.Stdin
+		if fs.Arg(0) != "-" {
+			file, err := os.Open(fs.Arg(0))
+			if err != nil {
+				logger.Errorf("Error opening archive: %v", err)
+				return 1
+			}
+			defer file.Close()
+			r = file
+		}
+		added, skipped, err := importHistory(*historyDir, r)
+		if err != nil {
+			logger.Errorf("Error importing history: %v", err)
+			return 1
+		}
+		logger.Printf("Imported %d runs (%d already present)", added, skipped)
+		return 0
+
+	case "prune":
+		fs := flag.NewFlagSet("history prune", flag.ExitOnError)
+		logFlags := registerLogFlags(fs)
+		historyDir := fs.String("history-dir", "", "History directory to prune")
+		historyURL := fs.String("history-url", "", "Remote history to prune")
+		olderThan := fs.Duration("older-than", 0, "Delete runs older than this, e.g. 720h")
+		fs.Parse(args[1:])
+		if err := logFlags.apply(); err != nil {
+			logger.Errorf("Error: %v", err)
+			return 2
+		}
+		if (*historyDir == "") == (*historyURL == "") || *olderThan <= 0 {
+			logger.Errorf("Usage: gotest-report history prune -history-dir DIR|-history-url URL -older-than DURATION")
+			return 2
+		}
+
+		store, err := openHistoryStore(*historyDir, *historyURL)
+		if err != nil {
+			logger.Errorf("Error opening history: %v", err)
+			return 1
+		}
+		defer store.Close()
+		pruned, err := store.Prune(time.Now().Add(-*olderThan))
+		if err != nil {
+			logger.Errorf("Error pruning history: %v", err)
+			return 1
+		}
+		logger.Printf("Pruned %d runs", pruned)
+		return 0
+
+	default:
+		logger.Errorf("Unknown history command %q (supported: export, import, prune)", args[0])
+		return 2
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistoryExportImport(t *testing.T) {
+	source := t.TempDir()
+	start := time.Date(2024, 3, 20, 15, 30, 0, 0, time.UTC)
+	data := &ReportData{TotalTests: 1, PassedTests: 1, Results: map[string]*TestResult{}}
+	for i := 0; i < 3; i++ {
+		if err := saveRunRecord(source, newRunRecord(data, start.Add(time.Duration(i)*time.Hour))); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	saveWaivers(source, []Waiver{
+		{Fingerprint: "aaa", Reason: "remote"},
+		{Fingerprint: "bbb", Reason: "remote"},
+	})
+	os.WriteFile(filepath.Join(source, "notes.txt"), []byte("not exported"), 0o644)
+
+	var archive bytes.Buffer
+	count, err := exportHistory(source, &archive)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if count != 4 {
+		t.Errorf("Expected 3 runs and the waivers to be exported, got %d files", count)
+	}
+
+	target := t.TempDir()
+	saveRunRecord(target, newRunRecord(data, start))
+	saveWaivers(target, []Waiver{{Fingerprint: "aaa", Reason: "local"}})
+
+	added, skipped, err := importHistory(target, bytes.NewReader(archive.Bytes()))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if added != 2 || skipped != 1 {
+		t.Errorf("Expected 2 added and 1 skipped, got %d and %d", added, skipped)
+	}
+
+	records, _ := loadHistory(target, 0)
+	if len(records) != 3 {
+		t.Errorf("Expected 3 runs after import, got %d", len(records))
+	}
+	waivers, _ := loadWaivers(target)
+	if len(waivers) != 2 || waivers[0].Reason != "local" {
+		t.Errorf("Expected local waivers to win and remote ones to be added, got %+v", waivers)
+	}
+	if _, err := os.Stat(filepath.Join(target, "notes.txt")); !os.IsNotExist(err) {
+		t.Error("Unrelated files should not be exported")
+	}
+
+	if _, _, err := importHistory(target, bytes.NewReader([]byte("not an archive"))); err == nil {
+		t.Error("Expected an error for an invalid archive")
+	}
+}
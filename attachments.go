@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// attachDirective matches "::attach file=PATH[,name=LABEL]::" in test
+// output, e.g. logged with t.Logf("::attach file=%s::", screenshot)
+var attachDirective = regexp.MustCompile(`::attach ([^\n]*?)::`)
+
+// imageExtensions are attachments shown inline rather than linked
+var imageExtensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".svg": true}
+
+// Attachment is a file referenced by a test, such as a browser screenshot
+type Attachment struct {
+	Name string `json:"name"`
+	Path string `json:"path"` // As given in the directive
+	Link string `json:"link"` // Path or URL the report links to
+}
+
+// IsImage reports whether the attachment is shown inline
+func (a Attachment) IsImage() bool {
+	return imageExtensions[strings.ToLower(path.Ext(filepath.ToSlash(a.Path)))]
+}
+
+// parseAttachDirective parses the comma separated key=value properties of a
+// directive. Paths containing commas can be given last with no name after.
+func parseAttachDirective(props string) (Attachment, bool) {
+	var a Attachment
+	for props != "" {
+		key, rest, _ := strings.Cut(props, "=")
+		key = strings.TrimSpace(key)
+		var value string
+		if key == "file" && !strings.Contains(rest, ",name=") {
+			value, props = rest, ""
+		} else {
+			value, props, _ = strings.Cut(rest, ",")
+		}
+		switch key {
+		case "file":
+			a.Path = strings.TrimSpace(value)
+		case "name":
+			a.Name = strings.TrimSpace(value)
+		}
+	}
+	if a.Path == "" {
+		return a, false
+	}
+	if a.Name == "" {
+		a.Name = path.Base(filepath.ToSlash(a.Path))
+	}
+	a.Link = a.Path
+	return a, true
+}
+
+// extractAttachments moves attach directives from the output of every test
+// into its attachments, so they are rendered as links or images instead
+func extractAttachments(data *ReportData) {
+	for _, result := range data.Results {
+		var output []string
+		for _, line := range result.Output {
+			matches := attachDirective.FindAllStringSubmatch(line, -1)
+			if matches == nil {
+				output = append(output, line)
+				continue
+			}
+			for _, m := range matches {
+				if a, ok := parseAttachDirective(m[1]); ok {
+					result.Attachments = append(result.Attachments, a)
+				}
+			}
+		}
+		if len(output) != len(result.Output) {
+			if output == nil {
+				output = []string{}
+			}
+			result.Output = output
+		}
+	}
+}
+
+// copyAttachments copies the attached files of failed tests into dir and
+// links them relative to reportDir, so the report can be published with its
+// attachments. Files that cannot be copied keep their original link. The
+// paths of the copies are returned.
+func copyAttachments(data *ReportData, dir, reportDir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating attachments directory: %v", err)
+	}
+	var copied []string
+	for _, name := range sortedResultNames(data) {
+		result := data.Results[name]
+		if result.Status != "FAIL" {
+			continue
+		}
+		for i, a := range result.Attachments {
+			// A counter keeps screenshots with the same name apart
+			target := filepath.Join(dir, fmt.Sprintf("%d-%s", len(copied)+1, filepath.Base(a.Path)))
+			if err := copyFile(a.Path, target); err != nil {
+				logger.Warnf("attachment of %s not copied: %v", result.Name, err)
+				continue
+			}
+			copied = append(copied, target)
+			if rel, err := filepath.Rel(reportDir, target); err == nil {
+				target = rel
+			}
+			result.Attachments[i].Link = filepath.ToSlash(target)
+		}
+	}
+	return copied, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// writeAttachments renders the attachments of a test below its output,
+// images inline and other files as links
+func writeAttachments(sb *strings.Builder, result *TestResult) {
+	if len(result.Attachments) == 0 {
+		return
+	}
+	for _, a := range result.Attachments {
+		// Angle brackets allow spaces in the link destination
+		link := "<" + strings.NewReplacer("<", "%3C", ">", "%3E", " ", "%20").Replace(a.Link) + ">"
+		if a.IsImage() {
+			sb.WriteString(fmt.Sprintf("![%s](%s)\n\n", escapeMarkdown(a.Name), link))
+		} else {
+			sb.WriteString(fmt.Sprintf("📎 [%s](%s)\n\n", escapeMarkdown(a.Name), link))
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseAttachDirective(t *testing.T) {
+	tests := []struct {
+		props string
+		want  Attachment
+		ok    bool
+	}{
+		{"file=/tmp/shot.png", Attachment{Name: "shot.png", Path: "/tmp/shot.png", Link: "/tmp/shot.png"}, true},
+		{"file=/tmp/shot.png,name=Login page", Attachment{Name: "Login page", Path: "/tmp/shot.png", Link: "/tmp/shot.png"}, true},
+		{"name=Trace, file=out/trace.zip", Attachment{Name: "Trace", Path: "out/trace.zip", Link: "out/trace.zip"}, true},
+		{"file=/tmp/a,b.log", Attachment{Name: "a,b.log", Path: "/tmp/a,b.log", Link: "/tmp/a,b.log"}, true},
+		{"name=Nothing", Attachment{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseAttachDirective(tt.props)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("parseAttachDirective(%q) = %+v, %v, expected %+v, %v", tt.props, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestAttachmentsInFailureDetails(t *testing.T) {
+	input := `{"Action":"run","Package":"pkg","Test":"TestLogin"}
+{"Action":"output","Package":"pkg","Test":"TestLogin","Output":"    ui_test.go:12: ::attach file=/tmp/login shot.png,name=Login page::\n"}
+{"Action":"output","Package":"pkg","Test":"TestLogin","Output":"    ui_test.go:13: ::attach file=/tmp/har.json::\n"}
+{"Action":"output","Package":"pkg","Test":"TestLogin","Output":"    ui_test.go:14: button not found\n"}
+{"Action":"fail","Package":"pkg","Test":"TestLogin","Elapsed":1.5}
+`
+	data, err := processTestEvents(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	result := data.Results[testKey("pkg", "TestLogin")]
+	if len(result.Attachments) != 2 {
+		t.Fatalf("Expected two attachments, got %+v", result.Attachments)
+	}
+	for _, line := range result.Output {
+		if strings.Contains(line, "::attach") {
+			t.Errorf("Expected directives to be removed from the output, got %q", line)
+		}
+	}
+
+	report := generateMarkdownReport(data)
+	for _, expected := range []string{
+		"![Login page](</tmp/login%20shot.png>)",
+		"📎 [har.json](</tmp/har.json>)",
+		"button not found",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Expected report to contain %q, got:\n%s", expected, report)
+		}
+	}
+}
+
+func TestCopyAttachments(t *testing.T) {
+	dir := t.TempDir()
+	shot := filepath.Join(dir, "shot.png")
+	if err := os.WriteFile(shot, []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data := &ReportData{SortedTestNames: []string{"TestA", "TestB"}, Results: map[string]*TestResult{
+		"TestA": {Name: "TestA", Status: "FAIL", Attachments: []Attachment{{Name: "shot.png", Path: shot, Link: shot}, {Name: "gone", Path: filepath.Join(dir, "gone.txt")}}},
+		"TestB": {Name: "TestB", Status: "PASS", Attachments: []Attachment{{Name: "shot.png", Path: shot, Link: shot}}},
+	}}
+
+	reportDir := filepath.Join(dir, "report")
+	copied, err := copyAttachments(data, filepath.Join(reportDir, "attachments"), reportDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(copied) != 1 {
+		t.Fatalf("Expected only the readable attachment of the failed test to be copied, got %v", copied)
+	}
+	if content, err := os.ReadFile(copied[0]); err != nil || string(content) != "png" {
+		t.Errorf("Expected the copy to have the original content, got %q, %v", content, err)
+	}
+	if link := data.Results["TestA"].Attachments[0].Link; link != "attachments/1-shot.png" {
+		t.Errorf("Expected a link relative to the report, got %s", link)
+	}
+	if link := data.Results["TestB"].Attachments[0].Link; link != shot {
+		t.Errorf("Expected attachments of passing tests to keep their link, got %s", link)
+	}
+}
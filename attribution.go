@@ -0,0 +1,40 @@
+package main
+
+import "regexp"
+
+// framingLine matches the lines go test prints around a test and captures
+// the name of the test they are about
+var framingLine = regexp.MustCompile(`^\s*(?:=== (?:RUN|PAUSE|CONT|NAME)\s+|--- (?:PASS|FAIL|SKIP|BENCH): )(\S+)`)
+
+// outputOwner returns the test an output line of test belongs to. test2json
+// attributes lines to the test that printed last, which goes wrong around
+// subtests:
+//
+//   - A framing line such as "=== RUN" or "--- FAIL:" belongs to the test it
+//     names.
+//   - A line of a test that already ended was printed by the nearest
+//     ancestor still running, e.g. a t.Log in the parent after its subtests
+//     ran. Go versions before 1.20 print no "=== NAME" line there.
+//
+// Lines of a test whose ancestors all ended, like the indented logs below
+// the "--- FAIL:" line of a non-verbose run, stay with the test. The owner
+// is returned as its key in results.
+func outputOwner(results map[string]*TestResult, pkg, test, line string) string {
+	key := testKey(pkg, test)
+	if m := framingLine.FindStringSubmatch(line); m != nil {
+		if framed := testKey(pkg, m[1]); results[framed] != nil {
+			return framed
+		}
+		return key
+	}
+	result, ok := results[key]
+	if !ok || result.Status == "UNKNOWN" {
+		return key
+	}
+	for parent := results[testKey(pkg, result.ParentTest)]; parent != nil; parent = results[testKey(pkg, parent.ParentTest)] {
+		if parent.Status == "UNKNOWN" {
+			return testKey(pkg, parent.Name)
+		}
+	}
+	return key
+}
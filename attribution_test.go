@@ -0,0 +1,80 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOutputAttribution(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string][]string
+	}{
+		{
+			// Go before 1.20 prints no "=== NAME" line when the parent logs
+			// again, so test2json keeps the line with the last subtest
+			name: "parent log after subtests",
+			input: `{"Action":"run","Package":"pkg","Test":"TestParent"}
+{"Action":"output","Package":"pkg","Test":"TestParent","Output":"=== RUN   TestParent\n"}
+{"Action":"run","Package":"pkg","Test":"TestParent/sub"}
+{"Action":"output","Package":"pkg","Test":"TestParent/sub","Output":"=== RUN   TestParent/sub\n"}
+{"Action":"output","Package":"pkg","Test":"TestParent/sub","Output":"    a_test.go:7: boom\n"}
+{"Action":"output","Package":"pkg","Test":"TestParent/sub","Output":"--- FAIL: TestParent/sub (0.00s)\n"}
+{"Action":"fail","Package":"pkg","Test":"TestParent/sub","Elapsed":0}
+{"Action":"output","Package":"pkg","Test":"TestParent/sub","Output":"    a_test.go:9: after subtests\n"}
+{"Action":"output","Package":"pkg","Test":"TestParent/sub","Output":"--- FAIL: TestParent (0.00s)\n"}
+{"Action":"fail","Package":"pkg","Test":"TestParent","Elapsed":0}
+`,
+			expected: map[string][]string{
+				"TestParent":     {"=== RUN   TestParent", "    a_test.go:9: after subtests", "--- FAIL: TestParent (0.00s)"},
+				"TestParent/sub": {"=== RUN   TestParent/sub", "    a_test.go:7: boom", "--- FAIL: TestParent/sub (0.00s)"},
+			},
+		},
+		{
+			name: "framing line of another test",
+			input: `{"Action":"run","Package":"pkg","Test":"TestParent"}
+{"Action":"run","Package":"pkg","Test":"TestParent/sub"}
+{"Action":"output","Package":"pkg","Test":"TestParent","Output":"=== CONT  TestParent/sub\n"}
+{"Action":"output","Package":"pkg","Test":"TestParent","Output":"    a_test.go:7: in sub\n"}
+{"Action":"pass","Package":"pkg","Test":"TestParent/sub","Elapsed":0}
+{"Action":"pass","Package":"pkg","Test":"TestParent","Elapsed":0}
+`,
+			expected: map[string][]string{
+				"TestParent":     {"    a_test.go:7: in sub"},
+				"TestParent/sub": {"=== CONT  TestParent/sub"},
+			},
+		},
+		{
+			// Non-verbose runs print the logs below the "--- FAIL:" line,
+			// after both tests ended
+			name: "logs below the result line",
+			input: `{"Action":"run","Package":"pkg","Test":"TestParent"}
+{"Action":"run","Package":"pkg","Test":"TestParent/sub"}
+{"Action":"output","Package":"pkg","Test":"TestParent","Output":"--- FAIL: TestParent (0.00s)\n"}
+{"Action":"fail","Package":"pkg","Test":"TestParent","Elapsed":0}
+{"Action":"output","Package":"pkg","Test":"TestParent/sub","Output":"    --- FAIL: TestParent/sub (0.00s)\n"}
+{"Action":"fail","Package":"pkg","Test":"TestParent/sub","Elapsed":0}
+{"Action":"output","Package":"pkg","Test":"TestParent/sub","Output":"        a_test.go:7: boom\n"}
+`,
+			expected: map[string][]string{
+				"TestParent":     {"--- FAIL: TestParent (0.00s)"},
+				"TestParent/sub": {"    --- FAIL: TestParent/sub (0.00s)", "        a_test.go:7: boom"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := processTestEvents(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, expected := range tt.expected {
+				if got := data.Results[testKey("pkg", name)].Output; !reflect.DeepEqual(got, expected) {
+					t.Errorf("%s: expected output %q, got %q", name, expected, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// shieldsEndpoint is the JSON a shields.io endpoint badge is rendered from,
+// see https://shields.io/badges/endpoint-badge
+type shieldsEndpoint struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// badgeFile is an endpoint badge and the file it is written to
+type badgeFile struct {
+	File  string
+	Badge shieldsEndpoint
+}
+
+// badgeColor returns the shields.io color of a run status, honoring the
+// configured status colors
+func badgeColor(status string) string {
+	fallback := map[string]string{"FAILED": "red", "SKIPPED": "yellow", "PASSED": "brightgreen"}[status]
+	// shields.io takes hex colors without the #
+	return strings.TrimPrefix(statusColor(testStatusOf(status), fallback), "#")
+}
+
+// coverageBadgeColor grades statement coverage like common coverage badges
+func coverageBadgeColor(percent float64) string {
+	switch {
+	case percent >= 80:
+		return "brightgreen"
+	case percent >= 60:
+		return "yellow"
+	}
+	return "red"
+}
+
+// badgeFiles returns the test count and pass rate badges of the run, and the
+// coverage badge when a coverprofile was given. Failed quality gates color
+// the test badges as a failed run.
+func badgeFiles(data *ReportData, gates []GateResult) []badgeFile {
+	status := gatedStatus(data, gates)
+	counts := fmt.Sprintf("%d passed", data.PassedTests)
+	if data.FailedTests > 0 {
+		counts += fmt.Sprintf(", %d failed", data.FailedTests)
+	}
+	if data.SkippedTests > 0 {
+		counts += fmt.Sprintf(", %d skipped", data.SkippedTests)
+	}
+	rate := "N/A"
+	if data.TotalTests > 0 {
+		rate = fmt.Sprintf("%.1f%%", passRate(data))
+	}
+	badges := []badgeFile{
+		{File: "tests.json", Badge: shieldsEndpoint{SchemaVersion: 1, Label: "tests", Message: counts, Color: badgeColor(status)}},
+		{File: "pass-rate.json", Badge: shieldsEndpoint{SchemaVersion: 1, Label: "pass rate", Message: rate, Color: badgeColor(status)}},
+	}
+	if data.Coverage != nil {
+		percent := data.Coverage.Percent()
+		badges = append(badges, badgeFile{File: "coverage.json", Badge: shieldsEndpoint{
+			SchemaVersion: 1, Label: "coverage", Message: fmt.Sprintf("%.1f%%", percent), Color: coverageBadgeColor(percent),
+		}})
+	}
+	return badges
+}
+
+// writeBadges writes the endpoint badges into dir and returns the files
+// written
+func writeBadges(data *ReportData, gates []GateResult, dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating badge directory: %v", err)
+	}
+	var files []string
+	for _, badge := range badgeFiles(data, gates) {
+		content, err := json.MarshalIndent(badge.Badge, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		file := filepath.Join(dir, badge.File)
+		if err := os.WriteFile(file, append(content, '\n'), 0o644); err != nil {
+			return nil, fmt.Errorf("error writing badge: %v", err)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
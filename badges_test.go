@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteBadges(t *testing.T) {
+	data := lintFixtures()[1].Data // 2 passed, 1 skipped, with coverage
+	dir := filepath.Join(t.TempDir(), "badges")
+	files, err := writeBadges(data, nil, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("Expected tests, pass rate and coverage badges, got %v", files)
+	}
+	expected := map[string]string{
+		"tests.json":     "{\n  \"schemaVersion\": 1,\n  \"label\": \"tests\",\n  \"message\": \"2 passed, 1 skipped\",\n  \"color\": \"brightgreen\"\n}\n",
+		"pass-rate.json": "{\n  \"schemaVersion\": 1,\n  \"label\": \"pass rate\",\n  \"message\": \"66.7%\",\n  \"color\": \"brightgreen\"\n}\n",
+		"coverage.json":  "{\n  \"schemaVersion\": 1,\n  \"label\": \"coverage\",\n  \"message\": \"80.0%\",\n  \"color\": \"brightgreen\"\n}\n",
+	}
+	for name, content := range expected {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || string(got) != content {
+			t.Errorf("Expected %s to be:\n%s\ngot:\n%s (%v)", name, content, got, err)
+		}
+	}
+
+	failing := badgeFiles(lintFixtures()[2].Data, nil)
+	if len(failing) != 2 || failing[0].Badge.Message != "0 passed, 2 failed" || failing[0].Badge.Color != "red" {
+		t.Errorf("Expected red badges without coverage for a failing run, got %+v", failing)
+	}
+}
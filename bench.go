@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BenchmarkResult holds the measurements of a single benchmark result line
+type BenchmarkResult struct {
+	Name        string
+	Package     string
+	Procs       int // GOMAXPROCS suffix of the benchmark name, 0 when absent
+	Iterations  int64
+	NsPerOp     float64
+	BytesPerOp  float64
+	AllocsPerOp float64
+	HasMemStats bool          // Whether B/op and allocs/op were reported (-benchmem)
+	Metrics     []BenchMetric // Other "<value> <unit>" pairs, e.g. MB/s or b.ReportMetric units
+}
+
+// BenchMetric is a custom benchmark measurement such as "12.5 latency-p99/op"
+type BenchMetric struct {
+	Unit  string
+	Value float64
+}
+
+// benchLinePattern matches "BenchmarkName-8   1000000   1052 ns/op ..."
+var benchLinePattern = regexp.MustCompile(`^(Benchmark\S*?)(?:-(\d+))?\s+(\d+)\s+(.+)$`)
+
+// parseBenchmarkLine parses a benchmark result line, returning nil when the
+// line is not one
+func parseBenchmarkLine(pkg, line string) *BenchmarkResult {
+	m := benchLinePattern.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return nil
+	}
+
+	bench := &BenchmarkResult{Name: m[1], Package: pkg}
+	bench.Procs, _ = strconv.Atoi(m[2])
+	bench.Iterations, _ = strconv.ParseInt(m[3], 10, 64)
+
+	fields := strings.Fields(m[4])
+	hasNs := false
+	for i := 0; i+1 < len(fields); i += 2 {
+		value, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return nil
+		}
+		switch fields[i+1] {
+		case "ns/op":
+			bench.NsPerOp = value
+			hasNs = true
+		case "B/op":
+			bench.BytesPerOp = value
+			bench.HasMemStats = true
+		case "allocs/op":
+			bench.AllocsPerOp = value
+			bench.HasMemStats = true
+		default:
+			bench.Metrics = append(bench.Metrics, BenchMetric{Unit: fields[i+1], Value: value})
+		}
+	}
+	if !hasNs {
+		return nil
+	}
+	return bench
+}
+
+// sortBenchmarks orders benchmarks for display. Measurements sort slowest or
+// largest first; unknown keys fall back to ns/op.
+func sortBenchmarks(benchmarks []*BenchmarkResult, by string) []*BenchmarkResult {
+	sorted := append([]*BenchmarkResult(nil), benchmarks...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		switch by {
+		case "name":
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+			return a.Package < b.Package
+		case "bytes":
+			return a.BytesPerOp > b.BytesPerOp
+		case "allocs":
+			return a.AllocsPerOp > b.AllocsPerOp
+		default:
+			return a.NsPerOp > b.NsPerOp
+		}
+	})
+	return sorted
+}
+
+// metric returns the value of a custom metric
+func (b *BenchmarkResult) metric(unit string) (float64, bool) {
+	for _, m := range b.Metrics {
+		if m.Unit == unit {
+			return m.Value, true
+		}
+	}
+	return 0, false
+}
+
+// metricUnits returns the custom metric units of the benchmarks in the order
+// they first appear
+func metricUnits(benchmarks []*BenchmarkResult) []string {
+	var units []string
+	seen := make(map[string]bool)
+	for _, b := range benchmarks {
+		for _, m := range b.Metrics {
+			if !seen[m.Unit] {
+				seen[m.Unit] = true
+				units = append(units, m.Unit)
+			}
+		}
+	}
+	return units
+}
+
+func formatBenchValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// writeBenchmarkSection renders the Benchmarks section, skipping it entirely
+// when the run had no benchmarks
+func writeBenchmarkSection(sb *strings.Builder, benchmarks []*BenchmarkResult, sortBy string) {
+	if len(benchmarks) == 0 {
+		return
+	}
+
+	sorted := sortBenchmarks(benchmarks, sortBy)
+
+	maxNs := 0.0
+	for _, b := range sorted {
+		if b.NsPerOp > maxNs {
+			maxNs = b.NsPerOp
+		}
+	}
+
+	// Custom metrics get a column each, with "-" for benchmarks without them
+	units := metricUnits(sorted)
+
+	sb.WriteString("## Benchmarks\n\n")
+	sb.WriteString("| Benchmark | Package | Iterations | ns/op | B/op | allocs/op |")
+	for _, unit := range units {
+		sb.WriteString(" " + unit + " |")
+	}
+	sb.WriteString(" Relative Time |\n")
+	sb.WriteString("| --------- | ------- | ---------- | ----- | ---- | --------- |")
+	for _, unit := range units {
+		sb.WriteString(" " + strings.Repeat("-", len(unit)) + " |")
+	}
+	sb.WriteString(" ------------- |\n")
+
+	for _, b := range sorted {
+		name := b.Name
+		if b.Procs > 0 {
+			name = fmt.Sprintf("%s-%d", b.Name, b.Procs)
+		}
+
+		bytesPerOp, allocsPerOp := "-", "-"
+		if b.HasMemStats {
+			bytesPerOp = formatBenchValue(b.BytesPerOp)
+			allocsPerOp = formatBenchValue(b.AllocsPerOp)
+		}
+
+		bar := ""
+		if maxNs > 0 {
+			barLength := int(b.NsPerOp * 20 / maxNs)
+			if barLength < 1 && b.NsPerOp > 0 {
+				barLength = 1
+			}
+			bar = strings.Repeat("█", barLength)
+		}
+
+		metrics := ""
+		for _, unit := range units {
+			value := "-"
+			if v, ok := b.metric(unit); ok {
+				value = formatBenchValue(v)
+			}
+			metrics += value + " | "
+		}
+
+		sb.WriteString(fmt.Sprintf("| **%s** | %s | %d | %s | %s | %s | %s%s |\n",
+			escapeMarkdown(name), escapeMarkdown(b.Package), b.Iterations, formatBenchValue(b.NsPerOp), bytesPerOp, allocsPerOp, metrics, bar))
+	}
+	sb.WriteString("\n")
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseBenchmarkLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected *BenchmarkResult
+	}{
+		{
+			name: "with memory stats",
+			line: "BenchmarkParse-8   \t 1000000\t      1052 ns/op\t     112 B/op\t       3 allocs/op\n",
+			expected: &BenchmarkResult{
+				Name: "BenchmarkParse", Procs: 8, Iterations: 1000000,
+				NsPerOp: 1052, BytesPerOp: 112, AllocsPerOp: 3, HasMemStats: true,
+			},
+		},
+		{
+			name: "sub-benchmark without procs suffix",
+			line: "BenchmarkSort/small \t 5000\t 0.2500 ns/op",
+			expected: &BenchmarkResult{
+				Name: "BenchmarkSort/small", Iterations: 5000, NsPerOp: 0.25,
+			},
+		},
+		{
+			name: "custom metrics",
+			line: "BenchmarkServe-4 \t 200\t 5012345 ns/op\t 12.50 MB/s\t 1.2 latency-p99/op\t 64 B/op\t 2 allocs/op",
+			expected: &BenchmarkResult{
+				Name: "BenchmarkServe", Procs: 4, Iterations: 200,
+				NsPerOp: 5012345, BytesPerOp: 64, AllocsPerOp: 2, HasMemStats: true,
+				Metrics: []BenchMetric{{Unit: "MB/s", Value: 12.5}, {Unit: "latency-p99/op", Value: 1.2}},
+			},
+		},
+		{
+			name:     "benchmark header line",
+			line:     "BenchmarkParse\n",
+			expected: nil,
+		},
+		{
+			name:     "regular test output",
+			line:     "    parse_test.go:12: Benchmark 10 widgets",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseBenchmarkLine("pkg/example", tt.line)
+			if tt.expected == nil {
+				if got != nil {
+					t.Fatalf("Expected no benchmark, got %+v", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("Expected a benchmark result, got nil")
+			}
+			tt.expected.Package = "pkg/example"
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %+v, want %+v", *got, *tt.expected)
+			}
+		})
+	}
+}
+
+func TestBenchmarksInReport(t *testing.T) {
+	jsonInput := `
+{"Time":"2023-04-01T10:00:00Z","Action":"output","Package":"pkg/example","Test":"BenchmarkFast","Output":"BenchmarkFast-4   \t 2000000\t       500 ns/op\t      16 B/op\t       1 allocs/op\n"}
+{"Time":"2023-04-01T10:00:01Z","Action":"output","Package":"pkg/example","Output":"BenchmarkSlow-4   \t    1000\t      5000 ns/op\n"}
+{"Time":"2023-04-01T10:00:02Z","Action":"output","Package":"pkg/example","Output":"PASS\n"}
+`
+	reportData, err := processTestEvents(strings.NewReader(jsonInput))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(reportData.Benchmarks) != 2 {
+		t.Fatalf("Expected 2 benchmarks, got %d", len(reportData.Benchmarks))
+	}
+
+	markdown := renderMarkdownReport(reportData, ReportOptions{BenchSort: "ns"})
+	if !strings.Contains(markdown, "## Benchmarks") {
+		t.Fatal("Benchmarks section not found")
+	}
+	if !strings.Contains(markdown, "| **BenchmarkFast-4** | pkg/example | 2000000 | 500 | 16 | 1 | ██ |") {
+		t.Error("BenchmarkFast row not rendered as expected")
+	}
+	if strings.Index(markdown, "BenchmarkSlow") > strings.Index(markdown, "BenchmarkFast") {
+		t.Error("Benchmarks should be sorted slowest first by default")
+	}
+
+	byName := renderMarkdownReport(reportData, ReportOptions{BenchSort: "name"})
+	if strings.Index(byName, "BenchmarkFast") > strings.Index(byName, "BenchmarkSlow") {
+		t.Error("Benchmarks should be sorted by name when requested")
+	}
+
+	if strings.Contains(generateMarkdownReport(&ReportData{Results: map[string]*TestResult{}}), "## Benchmarks") {
+		t.Error("Benchmarks section should be omitted without benchmarks")
+	}
+}
+
+func TestBenchmarkCustomMetricColumns(t *testing.T) {
+	benchmarks := []*BenchmarkResult{
+		{Name: "BenchmarkA", Package: "pkg", Iterations: 10, NsPerOp: 100, Metrics: []BenchMetric{{Unit: "latency-p99/op", Value: 3}}},
+		{Name: "BenchmarkB", Package: "pkg", Iterations: 10, NsPerOp: 50, Metrics: []BenchMetric{{Unit: "MB/s", Value: 7.5}}},
+	}
+
+	var sb strings.Builder
+	writeBenchmarkSection(&sb, benchmarks, "ns")
+	section := sb.String()
+
+	for _, expected := range []string{
+		"| ns/op | B/op | allocs/op | latency-p99/op | MB/s | Relative Time |",
+		"| **BenchmarkA** | pkg | 10 | 100 | - | - | 3 | - | ████████████████████ |",
+		"| **BenchmarkB** | pkg | 10 | 50 | - | - | - | 7.5 | ██████████ |",
+	} {
+		if !strings.Contains(section, expected) {
+			t.Errorf("Expected the table to contain %q, got:\n%s", expected, section)
+		}
+	}
+
+	report := newJSONReport(&ReportData{Results: map[string]*TestResult{}, Benchmarks: benchmarks}, ReportOptions{}, time.Now())
+	if report.Benchmarks[0].Metrics["latency-p99/op"] != 3 || report.Benchmarks[1].Metrics["MB/s"] != 7.5 {
+		t.Errorf("Expected custom metrics in the JSON report, got %+v", report.Benchmarks)
+	}
+}
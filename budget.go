@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// budgetSections are the sections left out first when a report is over its
+// -max-bytes budget. The summary, status and failure details are kept.
+var budgetSections = []string{
+	"trends", "benchmarks", "function-coverage", "duration-stats", "durations", "throughput", "timeline", "parallelism", "environment", "hygiene", "skipped", "failure-categories",
+}
+
+// budgetSteps shrink the options of a report over its budget, each step on
+// top of the previous ones
+var budgetSteps = []func(opts *ReportOptions){
+	func(opts *ReportOptions) { opts.FailedResultsOnly = true },
+	func(opts *ReportOptions) { opts.MaxOutputLines = budgetOutputLines(opts.MaxOutputLines, 50) },
+	func(opts *ReportOptions) {
+		for _, name := range budgetSections {
+			opts.HiddenSections[name] = true
+		}
+	},
+	func(opts *ReportOptions) { opts.MaxOutputLines = budgetOutputLines(opts.MaxOutputLines, 20) },
+	func(opts *ReportOptions) { opts.MaxOutputLines = budgetOutputLines(opts.MaxOutputLines, 5) },
+	func(opts *ReportOptions) { opts.HiddenSections["results"] = true },
+}
+
+// budgetOutputLines lowers the output line limit to max unless it is lower
+// already
+func budgetOutputLines(current, max int) int {
+	if current > 0 && current < max {
+		return current
+	}
+	return max
+}
+
+// truncationNote ends a report that was shrunk to fit its budget, pointing
+// to the full report
+func truncationNote(maxBytes int, fullReport string) string {
+	return fmt.Sprintf("\n---\n\n> ✂️ Truncated to fit %d bytes; see %s for the full report.\n", maxBytes, fullReport)
+}
+
+// fitReport renders the Markdown report within maxBytes. Passing tests,
+// secondary sections and long outputs are left out step by step until the
+// report fits, and as a last resort it is cut off. The second result reports
+// whether the report was shrunk, in which case it ends with the note.
+func fitReport(data *ReportData, opts ReportOptions, maxBytes int, note string) (string, bool) {
+	report := renderMarkdownReport(data, opts)
+	if maxBytes <= 0 || len(report) <= maxBytes {
+		return report, false
+	}
+
+	hidden := make(map[string]bool)
+	for name := range opts.HiddenSections {
+		hidden[name] = true
+	}
+	opts.HiddenSections = hidden
+	budget := maxBytes - len(note)
+	for _, step := range budgetSteps {
+		step(&opts)
+		if report = renderMarkdownReport(data, opts); len(report) <= budget {
+			return report + note, true
+		}
+	}
+	return cutReport(report, budget) + note, true
+}
+
+// cutReport cuts the report at the last line that fits in budget bytes,
+// closing an open code block and details elements so the note after it
+// renders
+func cutReport(report string, budget int) string {
+	closing := func(s string) string {
+		var end string
+		if strings.Count(s, "```")%2 == 1 {
+			end += "```\n"
+		}
+		if open := strings.Count(s, "<details>") - strings.Count(s, "</details>"); open > 0 {
+			end += strings.Repeat("</details>\n", open)
+		}
+		return end
+	}
+	cut := report
+	for len(cut)+len(closing(cut)) > budget && cut != "" {
+		limit := budget - len(closing(cut))
+		if limit < 0 {
+			limit = 0
+		}
+		if limit >= len(cut) {
+			limit = len(cut) - 1
+		}
+		if i := strings.LastIndex(cut[:limit], "\n"); i >= 0 {
+			cut = cut[:i+1]
+		} else {
+			for limit > 0 && !utf8.RuneStart(cut[limit]) {
+				limit--
+			}
+			cut = cut[:limit]
+		}
+	}
+	return cut + closing(cut)
+}
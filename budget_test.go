@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFitReport(t *testing.T) {
+	huge := lintFixtures()[3].Data
+	note := truncationNote(githubCommentLimit, "`test-report.full.md`")
+
+	if report, shrunk := fitReport(lintFixtures()[2].Data, ReportOptions{}, githubCommentLimit, note); shrunk || strings.Contains(report, "Truncated") {
+		t.Error("Expected a small report to be left alone")
+	}
+
+	report, shrunk := fitReport(huge, ReportOptions{}, githubCommentLimit, note)
+	if !shrunk || len(report) > githubCommentLimit || !strings.HasSuffix(report, note) {
+		t.Fatalf("Expected the report to be shrunk to %d bytes with the note, got %d bytes", githubCommentLimit, len(report))
+	}
+	for _, expected := range []string{"## Summary", "- **Failed:** 50", "### TestGenerated0000", "4950 passed or skipped tests left out"} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Expected the shrunk report to keep %q", expected)
+		}
+	}
+	if strings.Contains(report, "TestGenerated0001") {
+		t.Error("Expected passing tests to be left out")
+	}
+
+	report, _ = fitReport(huge, ReportOptions{}, 3000, note)
+	if len(report) > 3000 || !strings.Contains(report, "## Summary") || !strings.HasSuffix(report, note) {
+		t.Errorf("Expected the report to be cut to 3000 bytes, got %d bytes:\n%s", len(report), report)
+	}
+}
+
+func TestCutReport(t *testing.T) {
+	report := "# Report\n\n<details>\n<summary>Output</summary>\n\n```\nline 1\nline 2\nline 3\n```\n\n</details>\n"
+	got := cutReport(report, 73)
+	expected := "# Report\n\n<details>\n<summary>Output</summary>\n\n```\nline 1\n```\n</details>\n"
+	if got != expected {
+		t.Errorf("Expected:\n%q\ngot:\n%q", expected, got)
+	}
+	if got := cutReport(report, len(report)); got != report {
+		t.Errorf("Expected a report within the budget to be unchanged, got %q", got)
+	}
+}
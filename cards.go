@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// summaryCard is a single Total/Success/Duration tile in the summary
+type summaryCard struct {
+	File  string
+	Title string
+	Value string
+	Color string
+}
+
+// summaryCards builds the cards shown at the top of the summary section
+func summaryCards(data *ReportData) []summaryCard {
+	successRate := "N/A"
+	successColor := "#9f9f9f"
+	if data.TotalTests > 0 {
+		rate := float64(data.PassedTests) / float64(data.TotalTests) * 100
+		successRate = fmt.Sprintf("%.1f%%", rate)
+		switch {
+		case data.FailedTests > 0:
+			successColor = statusColor("FAIL", "#e05d44")
+		case rate == 100:
+			successColor = statusColor("PASS", "#4c1")
+		default:
+			successColor = statusColor("SKIP", "#dfb317")
+		}
+	}
+
+	return []summaryCard{
+		{File: "total.svg", Title: "Total Tests", Value: fmt.Sprintf("%d", data.TotalTests), Color: "#007ec6"},
+		{File: "success.svg", Title: "Success Rate", Value: successRate, Color: successColor},
+		{File: "duration.svg", Title: "Duration", Value: fmt.Sprintf("%.2fs", data.TotalDuration), Color: "#6f42c1"},
+	}
+}
+
+// renderSVGCard draws a card as a standalone SVG image. GitHub strips inline
+// styles from markdown but renders referenced SVG files as-is.
+func renderSVGCard(card summaryCard) string {
+	var sb strings.Builder
+	sb.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" width="180" height="90" viewBox="0 0 180 90">` + "\n")
+	sb.WriteString(`  <rect x="0.5" y="0.5" width="179" height="89" rx="8" fill="#ffffff" stroke="#d0d7de"/>` + "\n")
+	sb.WriteString(fmt.Sprintf(`  <rect x="0.5" y="0.5" width="6" height="89" rx="3" fill="%s"/>`+"\n", card.Color))
+	sb.WriteString(fmt.Sprintf(`  <text x="20" y="32" font-family="-apple-system,Segoe UI,Helvetica,Arial,sans-serif" font-size="13" fill="#57606a">%s</text>`+"\n",
+		html.EscapeString(card.Title)))
+	sb.WriteString(fmt.Sprintf(`  <text x="20" y="66" font-family="-apple-system,Segoe UI,Helvetica,Arial,sans-serif" font-size="26" font-weight="600" fill="%s">%s</text>`+"\n",
+		card.Color, html.EscapeString(card.Value)))
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
+
+// writeSVGCards writes one SVG file per summary card into dir
+func writeSVGCards(data *ReportData, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, card := range summaryCards(data) {
+		if err := os.WriteFile(filepath.Join(dir, card.File), []byte(renderSVGCard(card)), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cardsDirFor returns the cards directory name for a report, relative to the
+// directory the report is written to (test-report.md -> test-report-cards)
+func cardsDirFor(outputFile string) string {
+	base := filepath.Base(outputFile)
+	return strings.TrimSuffix(base, filepath.Ext(base)) + "-cards"
+}
+
+// summaryCardImages returns the markdown referencing the SVG cards in dir
+func summaryCardImages(dir string) string {
+	var images []string
+	for _, card := range summaryCards(&ReportData{}) {
+		images = append(images, fmt.Sprintf("![%s](%s)", card.Title, path.Join(filepath.ToSlash(dir), card.File)))
+	}
+	return strings.Join(images, " ") + "\n\n"
+}
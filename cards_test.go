@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteSVGCards(t *testing.T) {
+	data := &ReportData{
+		TotalTests:    4,
+		PassedTests:   3,
+		FailedTests:   1,
+		TotalDuration: 2.5,
+	}
+
+	dir := filepath.Join(t.TempDir(), "test-report-cards")
+	if err := writeSVGCards(data, dir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"total.svg":    ">4</text>",
+		"success.svg":  ">75.0%</text>",
+		"duration.svg": ">2.50s</text>",
+	}
+	for file, value := range expected {
+		content, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatalf("Expected card %s to be written: %v", file, err)
+		}
+		if !strings.HasPrefix(string(content), "<svg") {
+			t.Errorf("%s is not an SVG document", file)
+		}
+		if !strings.Contains(string(content), value) {
+			t.Errorf("%s should contain %q", file, value)
+		}
+	}
+}
+
+func TestSummaryCardsReferencedInReport(t *testing.T) {
+	if got := cardsDirFor("out/test-report.md"); got != "test-report-cards" {
+		t.Errorf("cardsDirFor() = %q, want %q", got, "test-report-cards")
+	}
+
+	markdown := renderMarkdownReport(&ReportData{Results: map[string]*TestResult{}}, ReportOptions{CardsDir: "test-report-cards"})
+	for _, ref := range []string{
+		"![Total Tests](test-report-cards/total.svg)",
+		"![Success Rate](test-report-cards/success.svg)",
+		"![Duration](test-report-cards/duration.svg)",
+	} {
+		if !strings.Contains(markdown, ref) {
+			t.Errorf("Expected card reference not found: %s", ref)
+		}
+	}
+
+	if strings.Contains(generateMarkdownReport(&ReportData{Results: map[string]*TestResult{}}), ".svg)") {
+		t.Error("Cards should not be referenced unless requested")
+	}
+}
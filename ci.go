@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CIPreset is the metadata a CI service without GitHub style variables
+// exposes through its own environment variables
+type CIPreset struct {
+	Name     string  // "drone" or "woodpecker"
+	Git      GitInfo // Commit, branch, author and build link
+	ForgeURL string  // Base URL of the Git server the build came from, e.g. a Gitea instance
+	Repo     string  // Repository in owner/name form
+	PR       int     // Pull request number, 0 outside of pull requests
+}
+
+// detectCIPreset returns the preset of the CI service running the report, or
+// an empty string when none applies. Woodpecker only marks itself in the
+// value of CI, and is checked first as it descends from Drone.
+func detectCIPreset() string {
+	switch {
+	case os.Getenv("CI") == "woodpecker":
+		return "woodpecker"
+	case os.Getenv("DRONE") == "true":
+		return "drone"
+	}
+	return ""
+}
+
+// loadCIPreset reads the preset named by -ci from the environment. "auto"
+// detects the service and "none" turns presets off; nil is returned when no
+// preset applies.
+func loadCIPreset(name string) (*CIPreset, error) {
+	switch name {
+	case "auto":
+		if name = detectCIPreset(); name == "" {
+			return nil, nil
+		}
+	case "none":
+		return nil, nil
+	case "drone", "woodpecker":
+	default:
+		return nil, fmt.Errorf("unsupported -ci value %q (supported: auto, drone, woodpecker, none)", name)
+	}
+
+	preset := &CIPreset{Name: name}
+	var authorName, authorEmail, repoURL, pr string
+	if name == "drone" {
+		preset.Git = GitInfo{
+			SHA:    firstEnv("DRONE_COMMIT_SHA", "DRONE_COMMIT"),
+			Branch: firstEnv("DRONE_SOURCE_BRANCH", "DRONE_BRANCH"),
+			RunURL: os.Getenv("DRONE_BUILD_LINK"),
+		}
+		authorName, authorEmail = firstEnv("DRONE_COMMIT_AUTHOR_NAME", "DRONE_COMMIT_AUTHOR"), os.Getenv("DRONE_COMMIT_AUTHOR_EMAIL")
+		preset.Repo, repoURL, pr = os.Getenv("DRONE_REPO"), os.Getenv("DRONE_REPO_LINK"), os.Getenv("DRONE_PULL_REQUEST")
+	} else {
+		// CI_PIPELINE_URL and CI_REPO_URL replaced CI_BUILD_LINK and
+		// CI_REPO_LINK in Woodpecker 1.0
+		preset.Git = GitInfo{
+			SHA:    os.Getenv("CI_COMMIT_SHA"),
+			Branch: firstEnv("CI_COMMIT_SOURCE_BRANCH", "CI_COMMIT_BRANCH"),
+			RunURL: firstEnv("CI_PIPELINE_URL", "CI_BUILD_LINK"),
+		}
+		authorName, authorEmail = os.Getenv("CI_COMMIT_AUTHOR"), os.Getenv("CI_COMMIT_AUTHOR_EMAIL")
+		preset.Repo, repoURL, pr = os.Getenv("CI_REPO"), firstEnv("CI_REPO_URL", "CI_REPO_LINK"), os.Getenv("CI_COMMIT_PULL_REQUEST")
+		preset.ForgeURL = os.Getenv("CI_FORGE_URL")
+	}
+
+	preset.Git.Author = authorName
+	if authorName != "" && authorEmail != "" {
+		preset.Git.Author = fmt.Sprintf("%s <%s>", authorName, authorEmail)
+	}
+	// The repository link is the server URL followed by the repository
+	if preset.ForgeURL == "" && preset.Repo != "" {
+		if server, ok := strings.CutSuffix(strings.TrimSuffix(repoURL, "/"), "/"+preset.Repo); ok {
+			preset.ForgeURL = server
+		}
+	}
+	preset.PR, _ = strconv.Atoi(pr)
+	return preset, nil
+}
+
+// forgeDefaults fills the server, repository and pull request of -gitea-pr
+// that were not given with those of the preset, so Drone and Woodpecker
+// builds of Gitea and Forgejo repositories comment on their PR
+func (p *CIPreset) forgeDefaults(serverURL, repo string, pr int) (string, string, int) {
+	if p == nil {
+		return serverURL, repo, pr
+	}
+	if serverURL == "" {
+		serverURL = p.ForgeURL
+	}
+	if repo == "" {
+		repo = p.Repo
+	}
+	if pr == 0 {
+		pr = p.PR
+	}
+	return serverURL, repo, pr
+}
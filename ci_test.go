@@ -0,0 +1,90 @@
+package main
+
+import "testing"
+
+func TestLoadCIPreset(t *testing.T) {
+	clearCIEnv(t)
+	t.Setenv("DRONE_COMMIT_SHA", "0123456789abcdef0123456789abcdef01234567")
+	t.Setenv("DRONE_SOURCE_BRANCH", "fix-cart")
+	t.Setenv("DRONE_BRANCH", "main")
+	t.Setenv("DRONE_COMMIT_AUTHOR_NAME", "Jane Doe")
+	t.Setenv("DRONE_COMMIT_AUTHOR_EMAIL", "jane@example.com")
+	t.Setenv("DRONE_BUILD_LINK", "https://drone.example.com/acme/shop/42")
+	t.Setenv("DRONE_REPO", "acme/shop")
+	t.Setenv("DRONE_REPO_LINK", "https://git.example.com/acme/shop")
+	t.Setenv("DRONE_PULL_REQUEST", "7")
+	t.Setenv("CI_COMMIT_SHA", "89abcdef0123456789abcdef0123456789abcdef")
+	t.Setenv("CI_COMMIT_SOURCE_BRANCH", "")
+	t.Setenv("CI_COMMIT_BRANCH", "main")
+	t.Setenv("CI_COMMIT_AUTHOR", "jdoe")
+	t.Setenv("CI_COMMIT_AUTHOR_EMAIL", "")
+	t.Setenv("CI_PIPELINE_URL", "https://ci.example.com/repos/3/pipeline/12")
+	t.Setenv("CI_REPO", "acme/shop")
+	t.Setenv("CI_FORGE_URL", "https://codeberg.org")
+	t.Setenv("CI_COMMIT_PULL_REQUEST", "")
+
+	tests := []struct {
+		name     string
+		ci       string
+		expected *CIPreset
+	}{
+		{"none", "none", nil},
+		{"auto outside of a preset", "auto", nil},
+		{"drone", "drone", &CIPreset{
+			Name: "drone",
+			Git: GitInfo{SHA: "0123456789abcdef0123456789abcdef01234567", Branch: "fix-cart", Author: "Jane Doe <jane@example.com>",
+				RunURL: "https://drone.example.com/acme/shop/42"},
+			ForgeURL: "https://git.example.com", Repo: "acme/shop", PR: 7,
+		}},
+		{"woodpecker", "woodpecker", &CIPreset{
+			Name:     "woodpecker",
+			Git:      GitInfo{SHA: "89abcdef0123456789abcdef0123456789abcdef", Branch: "main", Author: "jdoe", RunURL: "https://ci.example.com/repos/3/pipeline/12"},
+			ForgeURL: "https://codeberg.org", Repo: "acme/shop",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preset, err := loadCIPreset(tt.ci)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if (preset == nil) != (tt.expected == nil) || preset != nil && *preset != *tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, preset)
+			}
+		})
+	}
+
+	if _, err := loadCIPreset("circleci"); err == nil {
+		t.Error("Expected an error for an unsupported preset")
+	}
+	t.Setenv("CI", "woodpecker")
+	if preset, err := loadCIPreset("auto"); err != nil || preset == nil || preset.Name != "woodpecker" {
+		t.Errorf("Expected Woodpecker to be detected, got %+v (%v)", preset, err)
+	}
+	if provider := ciProvider(); provider != "Woodpecker" {
+		t.Errorf("Expected the Woodpecker provider, got %q", provider)
+	}
+}
+
+func TestDetectGitInfoPreset(t *testing.T) {
+	clearCIEnv(t)
+	t.Setenv("CI_COMMIT_AUTHOR", "jdoe")
+	preset := &CIPreset{Name: "drone", Git: GitInfo{SHA: "abc", Branch: "fix-cart", Author: "Jane Doe <jane@example.com>", RunURL: "https://drone.example.com/acme/shop/42"}}
+	info := detectGitInfo("", "release", preset, t.TempDir())
+	expected := GitInfo{SHA: "abc", Branch: "release", Author: "Jane Doe <jane@example.com>", RunURL: "https://drone.example.com/acme/shop/42"}
+	if info == nil || *info != expected {
+		t.Errorf("Expected %+v, got %+v", expected, info)
+	}
+
+	server, repo, pr := preset.forgeDefaults("", "", 0)
+	if server != "" || repo != "" || pr != 0 {
+		t.Errorf("Expected no defaults from a preset without a forge, got %q %q %d", server, repo, pr)
+	}
+	preset = &CIPreset{ForgeURL: "https://git.example.com", Repo: "acme/shop", PR: 7}
+	if server, repo, pr := preset.forgeDefaults("", "acme/other", 0); server != "https://git.example.com" || repo != "acme/other" || pr != 7 {
+		t.Errorf("Expected the flags to take precedence, got %q %q %d", server, repo, pr)
+	}
+	if server, _, _ := (*CIPreset)(nil).forgeDefaults("https://gitea.example.com", "", 0); server != "https://gitea.example.com" {
+		t.Errorf("Expected the flags without a preset, got %q", server)
+	}
+}
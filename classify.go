@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ClassifierConfig is a failure category given under classifiers in the
+// config file
+type ClassifierConfig struct {
+	Name    string `yaml:"name"`
+	Pattern string `yaml:"pattern"` // Regular expression matched against each output line
+}
+
+// failureClassifier tags failures whose output matches its pattern
+type failureClassifier struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// defaultClassifiers are the built-in categories, checked in this order so
+// a dial timeout is a network error rather than a timeout, and a test timeout
+// is not counted as a panic
+var defaultClassifiers = []ClassifierConfig{
+	{Name: "race", Pattern: `WARNING: DATA RACE|race detected during execution of test`},
+	{Name: "network", Pattern: `(?i)connection refused|connection reset|no such host|network is unreachable|dial (tcp|udp)|tls handshake|broken pipe`},
+	{Name: "timeout", Pattern: `(?i)test timed out after|deadline exceeded|\btimed? ?out\b`},
+	{Name: "panic", Pattern: `^\s*panic: |\[recovered\]`},
+	{Name: "assertion", Pattern: `(?i)error trace:|not equal|expected|\bwant\b|\bgot\b|assert|mismatch`},
+}
+
+// otherCategory is the category of failures no classifier matches
+const otherCategory = "other"
+
+// failureClassifiers compiles the classifiers of the config file followed by
+// the built-in ones. A configured classifier with a built-in name replaces it.
+func failureClassifiers(custom []ClassifierConfig) ([]*failureClassifier, error) {
+	var classifiers []*failureClassifier
+	names := make(map[string]bool)
+	for _, c := range append(append([]ClassifierConfig(nil), custom...), defaultClassifiers...) {
+		if names[c.Name] {
+			continue
+		}
+		if c.Name == "" || c.Name == otherCategory {
+			return nil, fmt.Errorf("classifiers in config file need a name other than %q", otherCategory)
+		}
+		pattern, err := regexp.Compile(c.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern of classifier %s: %v", c.Name, err)
+		}
+		names[c.Name] = true
+		classifiers = append(classifiers, &failureClassifier{Name: c.Name, Pattern: pattern})
+	}
+	return classifiers, nil
+}
+
+// classifyFailure returns the category of the first classifier matching a
+// line of the output, or an empty string when none does
+func classifyFailure(classifiers []*failureClassifier, output []string) string {
+	for _, c := range classifiers {
+		for _, line := range output {
+			if c.Pattern.MatchString(line) {
+				return c.Name
+			}
+		}
+	}
+	return ""
+}
+
+// classifyFailures sets the category of every failed test. A test that only
+// failed through its subtests is left to them; any other unmatched failure
+// is "other".
+func classifyFailures(data *ReportData, classifiers []*failureClassifier) {
+	for _, result := range data.Results {
+		if result.Status != "FAIL" {
+			continue
+		}
+		result.Category = classifyFailure(classifiers, result.Output)
+		if result.Category == "" && !hasFailedSubtest(data, result) {
+			result.Category = otherCategory
+		}
+	}
+}
+
+func hasFailedSubtest(data *ReportData, result *TestResult) bool {
+	for _, name := range result.SubTests {
+		if sub, ok := data.Results[testKey(result.Package, name)]; ok && sub.Status == "FAIL" {
+			return true
+		}
+	}
+	return false
+}
+
+// failureCategory lists the failed tests of a category
+type failureCategory struct {
+	Name  string
+	Tests []string
+}
+
+// failureCategories groups the classified failures by category, the most
+// frequent first
+func failureCategories(data *ReportData) []failureCategory {
+	byName := make(map[string][]string)
+	for _, result := range data.Results {
+		if result.Category != "" {
+			byName[result.Category] = append(byName[result.Category], result.Name)
+		}
+	}
+	var categories []failureCategory
+	for name, tests := range byName {
+		sort.Strings(tests)
+		categories = append(categories, failureCategory{Name: name, Tests: tests})
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if len(categories[i].Tests) != len(categories[j].Tests) {
+			return len(categories[i].Tests) > len(categories[j].Tests)
+		}
+		return categories[i].Name < categories[j].Name
+	})
+	return categories
+}
+
+// categoryMaxTests is the number of test names listed per failure category
+const categoryMaxTests = 5
+
+// writeFailureCategoriesSection charts the failures by category so a large
+// number of failures can be triaged by cause
+func writeFailureCategoriesSection(sb *strings.Builder, data *ReportData) {
+	categories := failureCategories(data)
+	if len(categories) == 0 {
+		return
+	}
+
+	sb.WriteString("## Failure Categories\n\n")
+	lines := []string{"pie showData"}
+	for _, c := range categories {
+		lines = append(lines, fmt.Sprintf("    \"%s\" : %d", escapeMermaid(c.Name), len(c.Tests)))
+	}
+	writeCodeBlock(sb, "mermaid", lines)
+	sb.WriteString("| Category | Failures | Tests |\n")
+	sb.WriteString("| -------- | -------- | ----- |\n")
+	for _, c := range categories {
+		var names []string
+		for i, name := range c.Tests {
+			if i == categoryMaxTests {
+				names = append(names, fmt.Sprintf("+%d more", len(c.Tests)-categoryMaxTests))
+				break
+			}
+			names = append(names, escapeMarkdown(name))
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %d | %s |\n", escapeMarkdown(c.Name), len(c.Tests), strings.Join(names, ", ")))
+	}
+	sb.WriteString("\n")
+}
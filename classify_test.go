@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	classifiers, err := failureClassifiers(nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tests := []struct {
+		output   []string
+		expected string
+	}{
+		{[]string{"    testing.go:1490: race detected during execution of test"}, "race"},
+		{[]string{"    client_test.go:12: Get \"http://localhost:8080\": dial tcp 127.0.0.1:8080: connect: connection refused"}, "network"},
+		{[]string{"    client_test.go:12: dial tcp 10.0.0.1:443: i/o timeout"}, "network"},
+		{[]string{"panic: test timed out after 10m0s"}, "timeout"},
+		{[]string{"    job_test.go:30: context deadline exceeded"}, "timeout"},
+		{[]string{"panic: runtime error: invalid memory address or nil pointer dereference [recovered]"}, "panic"},
+		{[]string{"    Error Trace:\tsum_test.go:10", "    Error:      \tNot equal:"}, "assertion"},
+		{[]string{"    sum_test.go:10: got 3, want 4"}, "assertion"},
+		{[]string{"    sum_test.go:10: something went wrong"}, ""},
+	}
+	for _, tt := range tests {
+		if got := classifyFailure(classifiers, tt.output); got != tt.expected {
+			t.Errorf("classifyFailure(%q): expected %q, got %q", tt.output, tt.expected, got)
+		}
+	}
+}
+
+func TestFailureClassifiersConfig(t *testing.T) {
+	classifiers, err := failureClassifiers([]ClassifierConfig{
+		{Name: "database", Pattern: `pq: |sql: `},
+		{Name: "timeout", Pattern: `took too long`},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := classifyFailure(classifiers, []string{"    store_test.go:5: pq: connection refused"}); got != "database" {
+		t.Errorf("Expected configured classifiers to be checked first, got %q", got)
+	}
+	if got := classifyFailure(classifiers, []string{"    job_test.go:5: took too long"}); got != "timeout" {
+		t.Errorf("Expected the configured timeout pattern, got %q", got)
+	}
+	if got := classifyFailure(classifiers, []string{"    job_test.go:5: context deadline exceeded"}); got != "" {
+		t.Errorf("Expected the built-in timeout pattern to be replaced, got %q", got)
+	}
+
+	for _, invalid := range [][]ClassifierConfig{
+		{{Name: "broken", Pattern: `(`}},
+		{{Pattern: `x`}},
+		{{Name: "other", Pattern: `x`}},
+	} {
+		if _, err := failureClassifiers(invalid); err == nil {
+			t.Errorf("Expected an error for %+v", invalid)
+		}
+	}
+}
+
+func TestFailureCategoriesSection(t *testing.T) {
+	input := `{"Action":"run","Package":"pkg","Test":"TestA"}
+{"Action":"output","Package":"pkg","Test":"TestA","Output":"    a_test.go:5: got 1, want 2\n"}
+{"Action":"fail","Package":"pkg","Test":"TestA","Elapsed":0.1}
+{"Action":"run","Package":"pkg","Test":"TestB"}
+{"Action":"run","Package":"pkg","Test":"TestB/slow"}
+{"Action":"output","Package":"pkg","Test":"TestB/slow","Output":"    b_test.go:5: context deadline exceeded\n"}
+{"Action":"fail","Package":"pkg","Test":"TestB/slow","Elapsed":0.1}
+{"Action":"fail","Package":"pkg","Test":"TestB","Elapsed":0.1}
+{"Action":"run","Package":"pkg","Test":"TestC"}
+{"Action":"output","Package":"pkg","Test":"TestC","Output":"    c_test.go:5: expected 200\n"}
+{"Action":"fail","Package":"pkg","Test":"TestC","Elapsed":0.1}
+{"Action":"run","Package":"pkg","Test":"TestD"}
+{"Action":"fail","Package":"pkg","Test":"TestD","Elapsed":0.1}
+`
+	data, err := processTestEvents(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	classifiers, _ := failureClassifiers(nil)
+	classifyFailures(data, classifiers)
+	if category := data.Results[testKey("pkg", "TestB")].Category; category != "" {
+		t.Errorf("Expected a test failing through its subtest to be left unclassified, got %q", category)
+	}
+
+	report := generateMarkdownReport(data)
+	for _, expected := range []string{
+		"## Failure Categories",
+		"pie showData\n    \"assertion\" : 2\n    \"other\" : 1\n    \"timeout\" : 1\n",
+		"| assertion | 2 | TestA, TestC |",
+		"| timeout | 1 | TestB/slow |",
+		"<sub>Category: **assertion** · Fingerprint: `",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Expected the report to contain %q, got:\n%s", expected, report)
+		}
+	}
+}
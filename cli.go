@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// command is a gotest-report subcommand
+type command struct {
+	Name    string
+	Summary string
+	Run     func(args []string) int
+}
+
+// commands lists the subcommands in the order they are shown in the help
+func commands() []command {
+	return []command{
+		{"generate", "Render a report from go test -json output (the default without a command)", func(args []string) int { return runGenerate("generate", args, 0) }},
+		{"merge", "Render one report from the go test -json output of sharded runs", runMerge},
+		{"serve", "Serve a continuously updated report of a run in progress over HTTP", runServe},
+		{"rerender", "Render a stored JSON report again, e.g. as HTML, without the raw test output", runRerender},
+		{"diff", "Compare two runs for newly failing tests and duration regressions", runDiff},
+		{"compare-env", "Compare the same suite across environments, e.g. staging and production", runCompareEnv},
+		{"release-notes", "Summarize the test health between two git tags for release notes", runReleaseNotes},
+		{"tui", "Browse a run in an interactive terminal viewer", runTUI},
+		{"post", "Publish a rendered report, e.g. to a gist", runPost},
+		{"waive", "Accept a known failure by its fingerprint", runWaive},
+		{"history", "Export, import and prune the run history", runHistory},
+		{"schema", "Print the JSON Schemas of the machine readable outputs", runSchema},
+		{"lint-template", "Render a custom template against sample data", runLintTemplate},
+		{"version", "Show version information", func([]string) int {
+			fmt.Printf("gotest-report version %s\n", version)
+			return 0
+		}},
+	}
+}
+
+func main() {
+	os.Exit(runCLI(os.Args[1:]))
+}
+
+// runCLI dispatches to a subcommand. Invocations starting with a flag, or
+// without arguments, predate the subcommands and run generate.
+func runCLI(args []string) int {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") && args[0] != "-help" && args[0] != "--help" {
+		return runGenerate("gotest-report", args, 0)
+	}
+
+	for _, cmd := range commands() {
+		if cmd.Name == args[0] {
+			return cmd.Run(args[1:])
+		}
+	}
+	if args[0] == "help" || args[0] == "-help" || args[0] == "--help" {
+		writeUsage(os.Stdout)
+		return 0
+	}
+	logger.Errorf("Unknown command %q", args[0])
+	writeUsage(logger.stderr)
+	return 2
+}
+
+// writeUsage lists the subcommands
+func writeUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: gotest-report <command> [flags]")
+	fmt.Fprintln(w, "\nCommands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands() {
+		fmt.Fprintf(tw, "  %s\t%s\n", cmd.Name, cmd.Summary)
+	}
+	tw.Flush()
+	fmt.Fprintln(w, "\nRun `gotest-report <command> -h` for the flags of a command. Flags without a")
+	fmt.Fprintln(w, "command, e.g. `gotest-report -input results.json`, run generate.")
+}
+
+// runMerge implements `gotest-report merge [flags] FILE...`, generate with the
+// shard files as arguments
+func runMerge(args []string) int {
+	return runGenerate("merge", args, 1)
+}
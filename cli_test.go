@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunCLI(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected int
+	}{
+		{[]string{"help"}, 0},
+		{[]string{"version"}, 0},
+		{[]string{"bogus"}, 2},
+		{[]string{"merge"}, 2},
+		{[]string{"diff", "only-one.json"}, 2},
+	}
+	for _, tt := range tests {
+		if got := runCLI(tt.args); got != tt.expected {
+			t.Errorf("%v: expected exit code %d, got %d", tt.args, tt.expected, got)
+		}
+	}
+}
+
+func TestWriteUsage(t *testing.T) {
+	var sb strings.Builder
+	writeUsage(&sb)
+	for _, cmd := range commands() {
+		if !strings.Contains(sb.String(), "  "+cmd.Name+" ") {
+			t.Errorf("Expected usage to list %s, got:\n%s", cmd.Name, sb.String())
+		}
+	}
+}
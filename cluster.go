@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// clusterMinSize is the number of similar failures from which they are
+// rendered once instead of repeating the output for each
+const clusterMinSize = 3
+
+// clusterMaxNames is the number of test names listed for a cluster
+const clusterMaxNames = 20
+
+// failureCluster is a group of failures with similar output
+type failureCluster struct {
+	Tests      []*TestResult // The first one is shown for the group
+	normalized string
+}
+
+// normalizedFailure returns the output of a failure without the test framing
+// and log locations, normalized like in fingerprints, so table-driven cases
+// failing the same way compare equal
+func normalizedFailure(result *TestResult, rules []normalizeRule) string {
+	var lines []string
+	for _, line := range result.Output {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "=== ") || strings.HasPrefix(trimmed, "--- ") {
+			continue
+		}
+		if m := testLogLocation.FindStringSubmatch(line); m != nil {
+			trimmed = m[3]
+		}
+		lines = append(lines, normalizeMessage(trimmed, rules))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// failureSimilarity compares two normalized failures by the words they share,
+// from 0 for nothing in common to 1 for the same words
+func failureSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	words := make(map[string]int)
+	wordsA, wordsB := strings.Fields(a), strings.Fields(b)
+	for _, word := range wordsA {
+		words[word]++
+	}
+	common := 0
+	for _, word := range wordsB {
+		if words[word] > 0 {
+			words[word]--
+			common++
+		}
+	}
+	return 2 * float64(common) / float64(len(wordsA)+len(wordsB))
+}
+
+// clusterFailures groups failures whose similarity to the first failure of a
+// group reaches threshold, keeping the order of their first appearance. A
+// threshold of 1 only groups failures that are equal once normalized.
+func clusterFailures(results []*TestResult, threshold float64, rules []normalizeRule) []*failureCluster {
+	var clusters []*failureCluster
+	for _, result := range results {
+		normalized := normalizedFailure(result, rules)
+		var match *failureCluster
+		for _, c := range clusters {
+			if failureSimilarity(c.normalized, normalized) >= threshold {
+				match = c
+				break
+			}
+		}
+		if match == nil {
+			match = &failureCluster{normalized: normalized}
+			clusters = append(clusters, match)
+		}
+		match.Tests = append(match.Tests, result)
+	}
+	return clusters
+}
+
+// writeFailureCluster renders a group of similar failed subtests once, with
+// the output of the first and the names of all
+func writeFailureCluster(sb *strings.Builder, testName string, cluster *failureCluster, opts ReportOptions) {
+	first := cluster.Tests[0]
+	message := failureMessage(first.Output)
+	if m := testLogLocation.FindStringSubmatch(message); m != nil {
+		message = m[3]
+	}
+	if message == "" {
+		message = "the same output"
+	}
+	sb.WriteString(fmt.Sprintf("#### %d subtests failed with: %s\n\n", len(cluster.Tests), escapeMarkdown(message)))
+
+	var names []string
+	for i, test := range cluster.Tests {
+		if i == clusterMaxNames {
+			names = append(names, fmt.Sprintf("and %d more", len(cluster.Tests)-clusterMaxNames))
+			break
+		}
+		names = append(names, codeSpan(strings.TrimPrefix(test.Name, testName+"/")))
+	}
+	sb.WriteString(strings.Join(names, ", ") + "\n\n")
+
+	writeFailureFingerprint(sb, first)
+	if len(first.Output) > 0 {
+		sb.WriteString(fmt.Sprintf("Output of %s:\n\n", codeSpan(strings.TrimPrefix(first.Name, testName+"/"))))
+		writeFailureOutput(sb, first.Output, opts)
+		writeGoldenDiff(sb, first, opts)
+	}
+	writeAttachments(sb, first)
+}
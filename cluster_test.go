@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFailureSimilarity(t *testing.T) {
+	a := &TestResult{Output: []string{"=== RUN   TestSum/case_1", "    sum_test.go:12: Error: expected 3, got 4", "--- FAIL: TestSum/case_1 (0.00s)"}}
+	b := &TestResult{Output: []string{"    sum_test.go:12: Error: expected 10, got 11"}}
+	c := &TestResult{Output: []string{"    sum_test.go:30: Error: connection refused"}}
+
+	if normalizedFailure(a, nil) != "Error: expected N, got N" {
+		t.Errorf("Unexpected normalized failure %q", normalizedFailure(a, nil))
+	}
+	if s := failureSimilarity(normalizedFailure(a, nil), normalizedFailure(b, nil)); s != 1 {
+		t.Errorf("Expected failures differing in numbers to be identical, got %.2f", s)
+	}
+	if s := failureSimilarity(normalizedFailure(a, nil), normalizedFailure(c, nil)); s >= 0.5 {
+		t.Errorf("Expected different failures to be dissimilar, got %.2f", s)
+	}
+	if s := failureSimilarity("a b c d", "a b c e"); s != 0.75 {
+		t.Errorf("Expected a similarity of 0.75, got %.2f", s)
+	}
+
+	clusters := clusterFailures([]*TestResult{a, c, b}, 1, nil)
+	if len(clusters) != 2 || len(clusters[0].Tests) != 2 || clusters[0].Tests[1] != b {
+		t.Errorf("Expected a and b to be clustered, got %+v", clusters)
+	}
+}
+
+func TestFailedDetailsClustering(t *testing.T) {
+	var input strings.Builder
+	input.WriteString(`{"Action":"run","Package":"pkg","Test":"TestTable"}` + "\n")
+	for i := 1; i <= 25; i++ {
+		name := fmt.Sprintf("TestTable/case_%d", i)
+		input.WriteString(fmt.Sprintf(`{"Action":"run","Package":"pkg","Test":"%s"}`+"\n", name))
+		input.WriteString(fmt.Sprintf(`{"Action":"output","Package":"pkg","Test":"%s","Output":"    table_test.go:20: Error: expected %d, got 0\n"}`+"\n", name, i))
+		input.WriteString(fmt.Sprintf(`{"Action":"fail","Package":"pkg","Test":"%s","Elapsed":0}`+"\n", name))
+	}
+	input.WriteString(`{"Action":"run","Package":"pkg","Test":"TestTable/odd"}
+{"Action":"output","Package":"pkg","Test":"TestTable/odd","Output":"    table_test.go:20: Error: timeout\n"}
+{"Action":"fail","Package":"pkg","Test":"TestTable/odd","Elapsed":0}
+{"Action":"fail","Package":"pkg","Test":"TestTable","Elapsed":0.1}
+`)
+	data, err := processTestEvents(strings.NewReader(input.String()))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	report := renderMarkdownReport(data, ReportOptions{ClusterSimilarity: 1})
+	for _, expected := range []string{
+		"#### 25 subtests failed with: Error: expected 1, got 0",
+		"`case_1`, `case_10`, ",
+		"`case_4`, and 5 more",
+		"Output of `case_1`:",
+		"#### odd",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Expected the report to contain %q, got:\n%s", expected, report)
+		}
+	}
+	if strings.Contains(report, "#### case\\_2\n") {
+		t.Errorf("Expected clustered subtests not to be repeated, got:\n%s", report)
+	}
+
+	if report := renderMarkdownReport(data, ReportOptions{}); !strings.Contains(report, "#### case\\_25\n") {
+		t.Errorf("Expected every subtest without clustering, got:\n%s", report)
+	}
+}
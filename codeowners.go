@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// codeownersLocations are the paths GitHub reads a CODEOWNERS file from, in
+// order of precedence
+var codeownersLocations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// codeownersRule assigns owners to the paths matching a pattern
+type codeownersRule struct {
+	Pattern string
+	Match   *regexp.Regexp
+	Owners  []string
+}
+
+// Codeowners is a parsed CODEOWNERS file
+type Codeowners struct {
+	Rules []codeownersRule
+}
+
+// parseCodeowners parses the content of a CODEOWNERS file. Rules with invalid
+// patterns are skipped like GitHub does.
+func parseCodeowners(content string) *Codeowners {
+	owners := &Codeowners{}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		match, err := codeownersPattern(fields[0])
+		if err != nil {
+			continue
+		}
+		owners.Rules = append(owners.Rules, codeownersRule{Pattern: fields[0], Match: match, Owners: fields[1:]})
+	}
+	return owners
+}
+
+// codeownersPattern translates a gitignore style CODEOWNERS pattern into a
+// regular expression matching the path and everything below it
+func codeownersPattern(pattern string) (*regexp.Regexp, error) {
+	p := strings.TrimSuffix(pattern, "/")
+	// Patterns with a slash other than a trailing one are relative to the root
+	anchored := strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+
+	var re strings.Builder
+	re.WriteString("^")
+	if !anchored {
+		re.WriteString("(.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; {
+		case strings.HasPrefix(p[i:], "**/"):
+			re.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			re.WriteString(".*")
+			i++
+		case c == '*':
+			re.WriteString("[^/]*")
+		case c == '?':
+			re.WriteString("[^/]")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("(/.*)?$")
+	return regexp.Compile(re.String())
+}
+
+// loadCodeowners reads the CODEOWNERS file of the repository at root, or
+// returns nil when it has none
+func loadCodeowners(root string) (*Codeowners, error) {
+	for _, location := range codeownersLocations {
+		content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(location)))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		return parseCodeowners(string(content)), nil
+	}
+	return nil, nil
+}
+
+// OwnersOf returns the owners of a repository relative path. The last
+// matching rule wins, as on GitHub.
+func (c *Codeowners) OwnersOf(path string) []string {
+	if c == nil {
+		return nil
+	}
+	for i := len(c.Rules) - 1; i >= 0; i-- {
+		if c.Rules[i].Match.MatchString(path) {
+			return c.Rules[i].Owners
+		}
+	}
+	return nil
+}
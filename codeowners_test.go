@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCodeownersOwnersOf(t *testing.T) {
+	owners := parseCodeowners(`# Default owners
+*                   @org/core
+
+/internal/payments/ @org/payments @alice
+api/**/v2           @org/api
+docs/               @org/docs # trailing comment
+/cmd/tool-?         @org/tools
+[invalid
+`)
+
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{".", []string{"@org/core"}},
+		{"internal/payments", []string{"@org/payments", "@alice"}},
+		{"internal/payments/refunds", []string{"@org/payments", "@alice"}},
+		{"pkg/internal/payments", []string{"@org/core"}},
+		{"api/v2", []string{"@org/api"}},
+		{"api/users/v2/handlers", []string{"@org/api"}},
+		{"docs", []string{"@org/docs"}},
+		{"website/docs", []string{"@org/docs"}},
+		{"cmd/tool-a", []string{"@org/tools"}},
+		{"cmd/tool-ab", []string{"@org/core"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := owners.OwnersOf(tt.path); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected owners %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	var missing *Codeowners
+	if got := missing.OwnersOf("internal"); got != nil {
+		t.Errorf("Expected no owners without a CODEOWNERS file, got %v", got)
+	}
+}
+
+func TestLoadCodeowners(t *testing.T) {
+	root := t.TempDir()
+	if owners, err := loadCodeowners(root); err != nil || owners != nil {
+		t.Fatalf("Expected no CODEOWNERS, got %v, %v", owners, err)
+	}
+
+	os.WriteFile(filepath.Join(root, "CODEOWNERS"), []byte("* @root\n"), 0644)
+	os.MkdirAll(filepath.Join(root, ".github"), 0755)
+	os.WriteFile(filepath.Join(root, ".github", "CODEOWNERS"), []byte("* @github\n"), 0644)
+	owners, err := loadCodeowners(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := owners.OwnersOf("pkg"); !reflect.DeepEqual(got, []string{"@github"}) {
+		t.Errorf("Expected .github/CODEOWNERS to take precedence, got %v", got)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// commentPartMarker identifies the follow-up comments a report too large for
+// one comment is split into
+const commentPartMarker = "<!-- gotest-report part %d -->"
+
+var commentPartPattern = regexp.MustCompile(`<!-- gotest-report part (\d+) -->`)
+
+// tableSeparator matches the line below the header of a Markdown table
+var tableSeparator = regexp.MustCompile(`^\|(\s*:?-+:?\s*\|)+\s*$`)
+
+// commentHeadroom is kept free in every part for the marker and the lines
+// closing and reopening code blocks and details elements
+const commentHeadroom = 512
+
+// Ways of posting a report too large for a single PR comment
+const (
+	overflowSplit = "split" // Summary comment plus follow-up comments with the full report
+	overflowGist  = "gist"  // Summary comment linking the full report in a secret gist
+)
+
+// splitComment splits a Markdown report into parts of at most limit
+// characters at line boundaries. A code block or details element open at
+// the end of a part is closed and opened again in the next one, and a table
+// continues below its header.
+func splitComment(report string, limit int) []string {
+	limit -= commentHeadroom
+	var parts []string
+	var part strings.Builder
+	size := 0     // Characters in part
+	fence := ""   // Backticks of the open code block
+	opening := "" // Opening line of the open code block
+	details := 0  // Open details elements
+	table := ""   // Header and separator lines of the table being written
+	previous := ""
+
+	write := func(text string) {
+		part.WriteString(text)
+		size += utf8.RuneCountInString(text)
+	}
+	flush := func() {
+		if fence != "" {
+			part.WriteString(fence + "\n")
+		}
+		part.WriteString(strings.Repeat("</details>\n", details))
+		parts = append(parts, part.String())
+		part.Reset()
+		size = 0
+		write(strings.Repeat("<details>\n<summary>Continued</summary>\n\n", details))
+		if fence != "" {
+			write(opening)
+		}
+		write(table)
+	}
+
+	for _, line := range strings.SplitAfter(report, "\n") {
+		if line == "" {
+			continue
+		}
+		for size+utf8.RuneCountInString(line) > limit {
+			// Lines longer than a part are cut by characters
+			if room := limit - size; size == 0 || utf8.RuneCountInString(line) > limit {
+				runes := []rune(line)
+				if room <= 0 {
+					flush()
+					continue
+				}
+				write(string(runes[:room]) + "\n")
+				line = string(runes[room:])
+			}
+			flush()
+		}
+		write(line)
+
+		switch {
+		case !strings.HasPrefix(line, "|"):
+			table = ""
+		case tableSeparator.MatchString(line) && strings.HasPrefix(previous, "|"):
+			table = previous + line
+		}
+		previous = line
+
+		trimmed := strings.TrimSpace(line)
+		ticks := trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, "`"))]
+		switch {
+		case fence == "" && len(ticks) >= 3:
+			fence, opening = ticks, line
+		case fence != "" && trimmed == ticks && len(ticks) >= len(fence):
+			fence, opening = "", ""
+		case fence == "":
+			details += strings.Count(trimmed, "<details>") - strings.Count(trimmed, "</details>")
+			details = max(details, 0)
+		}
+	}
+	if size > 0 {
+		parts = append(parts, part.String())
+	}
+	return parts
+}
+
+// commentParts returns the IDs of the follow-up comments of the PR by part
+// number
+func commentParts(comments []issueComment) map[int]int64 {
+	parts := make(map[int]int64)
+	for _, comment := range comments {
+		if m := commentPartPattern.FindStringSubmatch(comment.Body); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			parts[n] = comment.ID
+		}
+	}
+	return parts
+}
+
+// postPRReport posts the report, its summary followed by its details, as the
+// sticky PR comment. A report over the comment limit is posted as its summary
+// with the details split into follow-up comments, or in a gist linked from
+// the summary. Gitea has no gists, so it always splits the report. Follow-up
+// comments of earlier runs are updated in place, and deleted when no longer
+// needed.
+func (c *GitHubClient) postPRReport(repo string, pr int, data *ReportData, summary, details, overflow string) error {
+	comments, err := c.listPRComments(repo, pr)
+	if err != nil {
+		return err
+	}
+	existing := commentParts(comments)
+
+	var parts []string
+	report := summary + details
+	body := report
+	if utf8.RuneCountInString(stickyCommentMarker+"\n"+report) > githubCommentLimit {
+		switch {
+		case overflow == overflowGist && !c.Gitea:
+			created, err := c.createGist("Go test report", "test-report.md", report)
+			if err != nil {
+				return err
+			}
+			body = gistCommentBody(data, created.HTMLURL)
+		default:
+			parts = splitComment(details, githubCommentLimit)
+			body = summary + fmt.Sprintf("📄 The full report is too large for one comment and continues in the %d comment(s) below.\n", len(parts))
+		}
+	}
+	if _, err := c.upsertPRComment(repo, pr, body); err != nil {
+		return err
+	}
+
+	for i, part := range parts {
+		payload := map[string]string{"body": fmt.Sprintf(commentPartMarker, i+2) + "\n" + part}
+		if id, ok := existing[i+2]; ok {
+			err = c.do(http.MethodPatch, fmt.Sprintf("/repos/%s/issues/comments/%d", repo, id), payload, nil)
+		} else {
+			err = c.do(http.MethodPost, fmt.Sprintf("/repos/%s/issues/%d/comments", repo, pr), payload, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for n, id := range existing {
+		if n > len(parts)+1 {
+			if err := c.do(http.MethodDelete, fmt.Sprintf("/repos/%s/issues/comments/%d", repo, id), nil, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitComment(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("## Failed Tests Details\n\n<details>\n<summary>Click to expand</summary>\n\n```go\n")
+	for i := 0; i < 200; i++ {
+		sb.WriteString(fmt.Sprintf("    output line %03d of a failed test\n", i))
+	}
+	sb.WriteString("```\n\n</details>\n")
+
+	parts := splitComment(sb.String(), commentHeadroom+1000)
+	if len(parts) < 7 {
+		t.Fatalf("Expected the report to be split into several parts, got %d", len(parts))
+	}
+	for i, part := range parts {
+		if n := utf8.RuneCountInString(part); n > commentHeadroom+1000 {
+			t.Errorf("Part %d has %d characters, over the limit", i+1, n)
+		}
+		if strings.Count(part, "```")%2 != 0 || strings.Count(part, "<details>") != strings.Count(part, "</details>") {
+			t.Errorf("Expected part %d to close its code block and details, got:\n%s", i+1, part)
+		}
+	}
+	if !strings.HasPrefix(parts[1], "<details>\n<summary>Continued</summary>\n\n```go\n    output line") {
+		t.Errorf("Expected the second part to reopen the details and code block, got:\n%s", parts[1])
+	}
+	joined := strings.Join(parts, "")
+	for i := 0; i < 200; i++ {
+		if !strings.Contains(joined, fmt.Sprintf("output line %03d ", i)) {
+			t.Fatalf("Expected line %d to be kept", i)
+		}
+	}
+
+	long := strings.Repeat("é", 2500) + "\n"
+	for i, part := range splitComment(long, commentHeadroom+1000) {
+		if n := utf8.RuneCountInString(part); n > commentHeadroom+1000 {
+			t.Errorf("Part %d of a long line has %d characters, over the limit", i+1, n)
+		}
+	}
+}
+
+func TestPostPRReportSplit(t *testing.T) {
+	existing := []issueComment{
+		{ID: 1, Body: stickyCommentMarker + "\nold report"},
+		{ID: 2, Body: fmt.Sprintf(commentPartMarker, 2) + "\nold part"},
+		{ID: 9, Body: fmt.Sprintf(commentPartMarker, 9) + "\nstale part"},
+	}
+	var requests []string
+	bodies := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			json.NewEncoder(w).Encode(existing)
+			return
+		}
+		request := r.Method + " " + r.URL.Path
+		requests = append(requests, request)
+		var payload map[string]string
+		json.NewDecoder(r.Body).Decode(&payload)
+		bodies[request] = payload["body"]
+		json.NewEncoder(w).Encode(issueComment{ID: 100})
+	}))
+	defer server.Close()
+
+	summary := "# Test Summary Report\n\n## Summary\n\n"
+	details := "## Test Results\n\n| Test | Status | Duration | Details |\n| ---- | ------ | -------- | ------- |\n" + strings.Repeat("| **TestGenerated** | ✅ PASS | 0.010s | - |\n", 1500)
+	client := newGitHubClient(server.URL, "token")
+	if err := client.postPRReport("owner/repo", 5, &ReportData{}, summary, details, overflowSplit); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"PATCH /repos/owner/repo/issues/comments/1",
+		"PATCH /repos/owner/repo/issues/comments/2",
+		"POST /repos/owner/repo/issues/5/comments",
+		"DELETE /repos/owner/repo/issues/comments/9",
+	}
+	if strings.Join(requests, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected requests:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(requests, "\n"))
+	}
+	if primary := bodies[expected[0]]; !strings.HasPrefix(primary, stickyCommentMarker+"\n"+summary) || !strings.Contains(primary, "continues in the 2 comment(s) below") {
+		t.Errorf("Expected the summary with a note as the primary comment, got:\n%s", primary)
+	}
+	if part := bodies[expected[1]]; !strings.HasPrefix(part, fmt.Sprintf(commentPartMarker, 2)+"\n## Test Results") {
+		t.Errorf("Expected the second part to continue after the summary, got:\n%.200s", part)
+	}
+	if part := bodies[expected[2]]; !strings.HasPrefix(part, fmt.Sprintf(commentPartMarker, 3)+"\n| Test | Status | Duration | Details |\n| ---- | ------ | -------- | ------- |\n| **TestGenerated**") {
+		t.Errorf("Expected the third part to repeat the table header, got:\n%.200s", part)
+	}
+}
+
+func TestMarkdownSummaryAndDetails(t *testing.T) {
+	data, err := sampleReport("failures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary := renderMarkdownReport(data, ReportOptions{SummaryOnly: true})
+	details := renderMarkdownDetails(data, ReportOptions{})
+	if report := renderMarkdownReport(data, ReportOptions{}); !strings.HasPrefix(report, summary) {
+		t.Errorf("Expected the report to start with its summary, got:\n%s", report)
+	}
+	if !strings.HasPrefix(details, "## Test Results") || strings.Contains(details, "## Summary") {
+		t.Errorf("Expected the details to follow the summary, got:\n%.200s", details)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFile is read from the working directory when -config is not given
+const defaultConfigFile = ".gotest-report.yaml"
+
+// defaultTopDurations is the number of tests listed in the Test Durations section
+const defaultTopDurations = 15
+
+// reportSections lists the sections of the Markdown report that can be hidden
+var reportSections = []string{
+	"cards", "suites", "trends", "regressions", "results", "quarantine", "retries", "skipped", "failure-categories", "failed-details", "data-races", "fuzzing", "diagnostics", "benchmarks", "function-coverage", "duration-stats", "durations", "throughput", "timeline", "parallelism", "environment", "hygiene",
+}
+
+// Config is the content of a .gotest-report.yaml file. Command line flags
+// take precedence over it.
+type Config struct {
+	Sections       map[string]bool  `yaml:"sections"`         // Section name to enabled, all enabled by default
+	GroupByPackage bool             `yaml:"group_by_package"` // Split the Test Results table by package
+	TopDurations   int              `yaml:"top_durations"`    // Tests listed in Test Durations
+	SlowThreshold  time.Duration    `yaml:"slow_threshold"`   // Leave faster tests out of Test Durations
+	MaxOutputLines int              `yaml:"max_output_lines"` // Truncate the output of failed tests
+	FailureOutput  string           `yaml:"failure_output"`   // "full" or "filtered"
+	FlakyAlerts    FlakyAlertConfig `yaml:"flaky_alerts"`     // Alerts when tests become flaky
+
+	Formats  map[string]map[string]string `yaml:"formats"`  // Format options by format and name, see formatOptions
+	Statuses map[string]StatusStyle       `yaml:"statuses"` // Glyph and color overrides by status
+
+	Classifiers []ClassifierConfig `yaml:"classifiers"`  // Failure categories checked before the built-in ones
+	StackFrames StackFrameConfig   `yaml:"stack_frames"` // Stack frames hidden or collapsed in failure output
+	Normalize   []NormalizeRule    `yaml:"normalize"`    // Rewrites of failure messages before they are compared
+
+	StatusGroups []StatusGroup `yaml:"status_groups"` // Commit status contexts set by post github-status
+}
+
+// loadConfig reads a config file. A missing file is only an error when
+// required, so the default config file is optional.
+func loadConfig(file string, required bool) (*Config, error) {
+	content, err := os.ReadFile(file)
+	if os.IsNotExist(err) && !required {
+		return &Config{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("error parsing config file: %v", err)
+	}
+	for name := range config.Sections {
+		if !validSection(name) {
+			return nil, fmt.Errorf("unknown section %q in config file (supported: %s)", name, strings.Join(reportSections, ", "))
+		}
+	}
+	if err := validateFormatConfig(config.Formats); err != nil {
+		return nil, err
+	}
+	if config.TopDurations < 0 || config.MaxOutputLines < 0 || config.SlowThreshold < 0 {
+		return nil, fmt.Errorf("top_durations, max_output_lines and slow_threshold cannot be negative")
+	}
+	return &config, nil
+}
+
+// registerConfigFlag adds -config to a command reading test output other
+// than generate
+func registerConfigFlag(fs *flag.FlagSet) *string {
+	return fs.String("config", "", "YAML config file with normalize rules and status styles (default is "+defaultConfigFile+" when present)")
+}
+
+// commandConfig loads the config file of a command reading test output other
+// than generate, file or the default config file when present, with its
+// normalize rules compiled so failures get the fingerprints generate gives them
+// and its status styles applied
+func commandConfig(file string) (*Config, []normalizeRule, error) {
+	config, err := loadConfig(file, true)
+	if file == "" {
+		config, err = loadConfig(defaultConfigFile, false)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := applyStatusStyles(config.Statuses); err != nil {
+		return nil, nil, err
+	}
+	normalize, err := compileNormalizeRules(config.Normalize)
+	if err != nil {
+		return nil, nil, err
+	}
+	return config, normalize, nil
+}
+
+func validSection(name string) bool {
+	for _, section := range reportSections {
+		if section == name {
+			return true
+		}
+	}
+	return false
+}
+
+// hiddenSections returns the sections disabled in the config together with
+// the comma separated list of the -hide-sections flag
+func hiddenSections(config *Config, flagValue string) (map[string]bool, error) {
+	hidden := make(map[string]bool)
+	for name, enabled := range config.Sections {
+		if !enabled {
+			hidden[name] = true
+		}
+	}
+	for _, name := range strings.Split(flagValue, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !validSection(name) {
+			return nil, fmt.Errorf("unknown section %q in -hide-sections (supported: %s)", name, strings.Join(reportSections, ", "))
+		}
+		hidden[name] = true
+	}
+	return hidden, nil
+}
+
+// showSection reports whether a section of the Markdown report is enabled
+func (o ReportOptions) showSection(name string) bool {
+	return !o.HiddenSections[name]
+}
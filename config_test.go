@@ -0,0 +1,131 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			content: `sections:
+  durations: false
+  cards: true
+group_by_package: true
+top_durations: 5
+slow_threshold: 1.5s
+max_output_lines: 100
+failure_output: filtered
+`,
+		},
+		{
+			name:    "unknown section",
+			content: "sections:\n  coverage-map: false\n",
+			wantErr: `unknown section "coverage-map"`,
+		},
+		{
+			name:    "negative limit",
+			content: "top_durations: -1\n",
+			wantErr: "cannot be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), defaultConfigFile)
+			if err := os.WriteFile(file, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			config, err := loadConfig(file, true)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !config.GroupByPackage || config.TopDurations != 5 || config.SlowThreshold != 1500*time.Millisecond || config.MaxOutputLines != 100 || config.FailureOutput != "filtered" {
+				t.Errorf("Unexpected config %+v", config)
+			}
+			hidden, err := hiddenSections(config, "benchmarks")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !hidden["durations"] || !hidden["benchmarks"] || hidden["cards"] {
+				t.Errorf("Unexpected hidden sections %v", hidden)
+			}
+		})
+	}
+}
+
+func TestLoadConfigMissing(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), defaultConfigFile)
+	if _, err := loadConfig(missing, false); err != nil {
+		t.Errorf("A missing default config should be ignored, got %v", err)
+	}
+	if _, err := loadConfig(missing, true); err == nil {
+		t.Error("Expected an error for a missing -config file")
+	}
+	if _, err := hiddenSections(&Config{}, "summary"); err == nil {
+		t.Error("Expected an error for an unknown -hide-sections entry")
+	}
+}
+
+func TestReportSections(t *testing.T) {
+	data := &ReportData{
+		Results: map[string]*TestResult{
+			"TestA": {Name: "TestA", Package: "pkg/b", Status: "PASS", Duration: 1},
+			"TestB": {Name: "TestB", Package: "pkg/a", Status: "FAIL", Duration: 2, Output: []string{"boom"}},
+			"TestC": {Name: "TestC", Package: "pkg/b", Status: "PASS", Duration: 3},
+		},
+		Packages: map[string]*PackageResult{},
+	}
+	summarizeReport(data, nil)
+
+	report := renderMarkdownReport(data, ReportOptions{
+		HiddenSections: map[string]bool{"durations": true, "failed-details": true},
+	})
+	for _, unexpected := range []string{"## Test Durations", "## Failed Tests Details"} {
+		if strings.Contains(report, unexpected) {
+			t.Errorf("Expected %q to be hidden", unexpected)
+		}
+	}
+	if !strings.Contains(report, "## Test Results") {
+		t.Error("Expected the Test Results section to be kept")
+	}
+
+	report = renderMarkdownReport(data, ReportOptions{GroupByPackage: true, TopDurations: 1})
+	a, b := strings.Index(report, "### pkg/a"), strings.Index(report, "### pkg/b")
+	if a < 0 || b < 0 || a > b {
+		t.Errorf("Expected the results grouped by package in order, got:\n%s", report)
+	}
+	durations := report[strings.Index(report, "## Test Durations"):]
+	if !strings.Contains(durations, "TestC") || strings.Contains(durations, "TestB") {
+		t.Errorf("Expected only the longest test in Test Durations, got:\n%s", durations)
+	}
+
+	report = renderMarkdownReport(data, ReportOptions{SlowThreshold: 1.5})
+	durations = report[strings.Index(report, "## Test Durations"):]
+	for _, expected := range []string{"Tests taking 1.500s or longer.", "| TestC | pkg/b | 3.000s", "| TestB | pkg/a | 2.000s"} {
+		if !strings.Contains(durations, expected) {
+			t.Errorf("Expected %q in Test Durations, got:\n%s", expected, durations)
+		}
+	}
+	if strings.Contains(durations, "TestA") {
+		t.Errorf("Expected tests below the threshold to be left out, got:\n%s", durations)
+	}
+	report = renderMarkdownReport(data, ReportOptions{SlowThreshold: 10})
+	if !strings.Contains(report, "No test took 10.000s or longer.") || strings.Contains(report, "| Test | Package | Duration |") {
+		t.Errorf("Expected no durations table above the slowest test, got:\n%s", report)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CoverageData holds statement coverage parsed from a go coverprofile
+type CoverageData struct {
+	Mode  string
+	Files map[string]*FileCoverage
+}
+
+// FileCoverage holds the statement counts of a single source file
+type FileCoverage struct {
+	Name       string
+	Statements int
+	Covered    int
+	Blocks     []*CoverBlock // In profile order
+}
+
+// Percent returns the covered statement percentage of a file
+func (f *FileCoverage) Percent() float64 {
+	if f.Statements == 0 {
+		return 0
+	}
+	return float64(f.Covered) / float64(f.Statements) * 100
+}
+
+// Totals returns the covered and total statement counts
+func (c *CoverageData) Totals() (covered, statements int) {
+	for _, f := range c.Files {
+		covered += f.Covered
+		statements += f.Statements
+	}
+	return covered, statements
+}
+
+// Percent returns the overall covered statement percentage
+func (c *CoverageData) Percent() float64 {
+	covered, statements := c.Totals()
+	if statements == 0 {
+		return 0
+	}
+	return float64(covered) / float64(statements) * 100
+}
+
+// SortedFiles returns the files sorted by name
+func (c *CoverageData) SortedFiles() []*FileCoverage {
+	files := make([]*FileCoverage, 0, len(c.Files))
+	for _, f := range c.Files {
+		files = append(files, f)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+	return files
+}
+
+// CoverBlock is a single "file:startLine.startCol,endLine.endCol numStmts
+// count" profile line
+type CoverBlock struct {
+	StartLine, StartCol int
+	EndLine, EndCol     int
+	Statements          int
+	Count               int64
+}
+
+// parseBlockRange parses the "startLine.startCol,endLine.endCol" range of a
+// profile line
+func parseBlockRange(s string, block *CoverBlock) error {
+	_, err := fmt.Sscanf(s, "%d.%d,%d.%d", &block.StartLine, &block.StartCol, &block.EndLine, &block.EndCol)
+	return err
+}
+
+// parseCoverProfile reads a profile written by go test -coverprofile.
+// Blocks reported more than once (e.g. with -coverpkg across packages) are
+// merged, counting a block as covered if any run covered it.
+func parseCoverProfile(reader io.Reader) (*CoverageData, error) {
+	scanner := bufio.NewScanner(reader)
+	blocks := make(map[string]*CoverBlock)
+	var order []string
+	data := &CoverageData{Files: make(map[string]*FileCoverage)}
+
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if mode, ok := strings.CutPrefix(line, "mode:"); ok {
+			data.Mode = strings.TrimSpace(mode)
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 3 || !strings.Contains(fields[0], ":") {
+			return nil, fmt.Errorf("invalid coverprofile line %d: %q", lineNo, line)
+		}
+		statements, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid statement count on line %d: %v", lineNo, err)
+		}
+		count, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hit count on line %d: %v", lineNo, err)
+		}
+
+		if existing, ok := blocks[fields[0]]; ok {
+			if count > existing.Count {
+				existing.Count = count
+			}
+			continue
+		}
+		block := &CoverBlock{Statements: statements, Count: count}
+		if err := parseBlockRange(fields[0][strings.LastIndex(fields[0], ":")+1:], block); err != nil {
+			return nil, fmt.Errorf("invalid block range on line %d: %v", lineNo, err)
+		}
+		blocks[fields[0]] = block
+		order = append(order, fields[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, key := range order {
+		block := blocks[key]
+		name := key[:strings.LastIndex(key, ":")]
+		file, ok := data.Files[name]
+		if !ok {
+			file = &FileCoverage{Name: name}
+			data.Files[name] = file
+		}
+		file.Statements += block.Statements
+		if block.Count > 0 {
+			file.Covered += block.Statements
+		}
+		file.Blocks = append(file.Blocks, block)
+	}
+	return data, nil
+}
+
+// loadCoverProfile parses the coverprofile at path
+func loadCoverProfile(path string) (*CoverageData, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseCoverProfile(file)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCoverProfile(t *testing.T) {
+	profile := `mode: set
+example.com/app/a.go:3.10,5.2 2 1
+example.com/app/a.go:7.10,9.2 3 0
+example.com/app/b.go:3.10,5.2 5 0
+example.com/app/b.go:3.10,5.2 5 1
+`
+	coverage, err := parseCoverProfile(strings.NewReader(profile))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if coverage.Mode != "set" {
+		t.Errorf("Mode: got %q, want set", coverage.Mode)
+	}
+	a := coverage.Files["example.com/app/a.go"]
+	if a == nil || a.Statements != 5 || a.Covered != 2 {
+		t.Errorf("a.go: got %+v, want 2/5 statements covered", a)
+	}
+	// Duplicate blocks are merged, covered if any profile covered them
+	b := coverage.Files["example.com/app/b.go"]
+	if b == nil || b.Statements != 5 || b.Covered != 5 {
+		t.Errorf("b.go: got %+v, want 5/5 statements covered", b)
+	}
+	if got := coverage.Percent(); got != 70 {
+		t.Errorf("Percent() = %.1f, want 70.0", got)
+	}
+
+	if _, err := parseCoverProfile(strings.NewReader("mode: set\nnot a profile line\n")); err == nil {
+		t.Error("Expected an error for an invalid profile line")
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/csv"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// csvHeader names the columns of the CSV and TSV exports
+var csvHeader = []string{"package", "name", "status", "duration", "parent", "attempt"}
+
+// renderCSVReport writes a row per run of every test and subtest, sorted by
+// package and name, separated by comma or by tab for TSV. Earlier attempts
+// of a re-run test get rows of their own before the final one.
+func renderCSVReport(data *ReportData, comma rune) (string, error) {
+	var sb strings.Builder
+	writer := csv.NewWriter(&sb)
+	writer.Comma = comma
+	if err := writer.Write(csvHeader); err != nil {
+		return "", err
+	}
+
+	results := make([]*TestResult, 0, len(data.Results))
+	for _, result := range data.Results {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Package != results[j].Package {
+			return results[i].Package < results[j].Package
+		}
+		return results[i].Name < results[j].Name
+	})
+	for _, result := range results {
+		row := func(status string, duration float64, attempt int) []string {
+			return []string{result.Package, result.Name, status, strconv.FormatFloat(duration, 'f', 3, 64), result.ParentTest, strconv.Itoa(attempt)}
+		}
+		for i, attempt := range result.Attempts {
+			if err := writer.Write(row(attempt.Status, attempt.Duration, i+1)); err != nil {
+				return "", err
+			}
+		}
+		if err := writer.Write(row(result.Status, result.Duration, len(result.Attempts)+1)); err != nil {
+			return "", err
+		}
+	}
+	writer.Flush()
+	return sb.String(), writer.Error()
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderCSVReport(t *testing.T) {
+	data := &ReportData{Results: map[string]*TestResult{
+		"TestParse": {Name: "TestParse", Package: "example.com/parser", Status: "FAIL", Duration: 0.3, SubTests: []string{"TestParse/a,b"}},
+		"TestParse/a,b": {Name: "TestParse/a,b", Package: "example.com/parser", Status: "PASS", Duration: 0.1, IsSubTest: true, ParentTest: "TestParse",
+			Attempts: []Attempt{{Status: "FAIL", Duration: 0.2}}},
+		"TestAdd": {Name: "TestAdd", Package: "example.com/calc", Status: "SKIP"},
+	}}
+
+	csv, err := renderCSVReport(data, ',')
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "package,name,status,duration,parent,attempt\n" +
+		"example.com/calc,TestAdd,SKIP,0.000,,1\n" +
+		"example.com/parser,TestParse,FAIL,0.300,,1\n" +
+		"example.com/parser,\"TestParse/a,b\",FAIL,0.200,TestParse,1\n" +
+		"example.com/parser,\"TestParse/a,b\",PASS,0.100,TestParse,2\n"
+	if csv != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, csv)
+	}
+
+	tsv, err := renderCSVReport(data, '\t')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(tsv, "example.com/parser\tTestParse/a,b\tPASS\t0.100\tTestParse\t2\n") {
+		t.Errorf("Expected tab separated rows, got:\n%s", tsv)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxDiagnosticSamples bounds the lines listed in the Input Diagnostics section
+const maxDiagnosticSamples = 20
+
+// maxDiagnosticText is the number of characters of a line shown in the table
+const maxDiagnosticText = 200
+
+// RawLine is an input line that is not a go test -json event, such as
+// output TestMain or a child process wrote straight to stdout
+type RawLine struct {
+	Input   string `json:"input,omitempty"`   // Input file when several were merged
+	Line    int    `json:"line"`              // Input line
+	Package string `json:"package,omitempty"` // Package the line was attached to
+	Test    string `json:"test,omitempty"`    // Test the line was attached to
+	Text    string `json:"text"`
+}
+
+// InputDiagnostics counts the input lines that were not events. They are
+// attached to the output of the test or package running before them.
+type InputDiagnostics struct {
+	RawLines   int       `json:"raw_lines"`
+	Unattached int       `json:"unattached,omitempty"` // Lines before the first event
+	Samples    []RawLine `json:"samples"`              // The first lines
+}
+
+// rawLineTracker follows the test and package of the latest event, which
+// interleaved raw lines most likely belong to
+type rawLineTracker struct {
+	diagnostics InputDiagnostics
+	pkg, test   string
+	time        time.Time
+}
+
+// observe remembers the context of an event
+func (t *rawLineTracker) observe(event TestEvent) {
+	if event.Package == "" {
+		// Build events name the package in ImportPath only
+		return
+	}
+	t.pkg, t.test, t.time = event.Package, event.Test, event.Time
+}
+
+// attach returns a raw line as an output event of the latest context. A
+// test that is no longer running, as told by running from its testKey,
+// leaves the line to its package. ok is false for lines before the first
+// event.
+func (t *rawLineTracker) attach(lineNo int, text string, running func(key string) bool) (event TestEvent, ok bool) {
+	raw := RawLine{Line: lineNo, Text: text}
+	t.diagnostics.RawLines++
+	if t.pkg != "" {
+		raw.Package = t.pkg
+		if t.test != "" && running(testKey(t.pkg, t.test)) {
+			raw.Test = t.test
+		}
+		event, ok = TestEvent{Time: t.time, Action: "output", Package: raw.Package, Test: raw.Test, Output: text + "\n"}, true
+	} else {
+		t.diagnostics.Unattached++
+	}
+	if len(t.diagnostics.Samples) < maxDiagnosticSamples {
+		t.diagnostics.Samples = append(t.diagnostics.Samples, raw)
+	}
+	return event, ok
+}
+
+// finish returns the diagnostics, nil when every line was an event
+func (t *rawLineTracker) finish() *InputDiagnostics {
+	if t.diagnostics.RawLines == 0 {
+		return nil
+	}
+	return &t.diagnostics
+}
+
+// mergeDiagnostics adds the diagnostics of another input
+func mergeDiagnostics(merged, other *InputDiagnostics) *InputDiagnostics {
+	if other == nil {
+		return merged
+	}
+	if merged == nil {
+		merged = &InputDiagnostics{Samples: []RawLine{}}
+	}
+	merged.RawLines += other.RawLines
+	merged.Unattached += other.Unattached
+	for _, raw := range other.Samples {
+		if len(merged.Samples) < maxDiagnosticSamples {
+			merged.Samples = append(merged.Samples, raw)
+		}
+	}
+	return merged
+}
+
+// writeDiagnosticsSection renders the input lines that were not events, so
+// output missing from a test can be found and a broken input noticed
+func writeDiagnosticsSection(sb *strings.Builder, diagnostics *InputDiagnostics) {
+	if diagnostics == nil || diagnostics.RawLines == 0 {
+		return
+	}
+	sb.WriteString("## Input Diagnostics\n\n")
+	sb.WriteString(fmt.Sprintf("⚠️ %d input line(s) were not go test -json events, e.g. output of TestMain or a child process written straight to stdout. They were added to the output of the test or package running before them.", diagnostics.RawLines))
+	if diagnostics.Unattached > 0 {
+		sb.WriteString(fmt.Sprintf(" %d line(s) before the first event were left out.", diagnostics.Unattached))
+	}
+	sb.WriteString("\n\n| Line | Attached To | Text |\n")
+	sb.WriteString("| ---- | ----------- | ---- |\n")
+	for _, raw := range diagnostics.Samples {
+		line := fmt.Sprintf("%d", raw.Line)
+		if raw.Input != "" {
+			line = raw.Input + ":" + line
+		}
+		owner := "—"
+		switch {
+		case raw.Test != "":
+			owner = raw.Test
+		case raw.Package != "":
+			owner = raw.Package
+		}
+		text := raw.Text
+		if runes := []rune(text); len(runes) > maxDiagnosticText {
+			text = string(runes[:maxDiagnosticText]) + "…"
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s |\n", escapeMarkdown(line), escapeMarkdown(owner), escapeMarkdown(text)))
+	}
+	if more := diagnostics.RawLines - len(diagnostics.Samples); more > 0 {
+		sb.WriteString(fmt.Sprintf("\n_%d more line(s) not shown._\n", more))
+	}
+	sb.WriteString("\n")
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRawLinesAttached(t *testing.T) {
+	input := `setting up fixtures
+{"Time":"2023-04-01T10:00:00Z","Action":"start","Package":"pkg/example"}
+{"Time":"2023-04-01T10:00:00Z","Action":"run","Test":"TestServer","Package":"pkg/example"}
+listening on :8080 | ready
+{"Time":"2023-04-01T10:00:01Z","Action":"fail","Test":"TestServer","Package":"pkg/example","Elapsed":0.1}
+{"Time":"2023-04-01T10:00:01Z","Action":"output","Test":"TestServer","Package":"pkg/example","Output":"--- FAIL: TestServer (0.10s)\n"}
+{}
+{"Time":"2023-04-01T10:00:01Z","Action":"fail","Package":"pkg/example","Elapsed":0.2}
+`
+	data, err := parseTestEvents(strings.NewReader(input), ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse test events: %v", err)
+	}
+
+	if output := strings.Join(data.Results[testKey("pkg/example", "TestServer")].Output, "\n"); !strings.Contains(output, "listening on :8080 | ready") {
+		t.Errorf("Expected the raw line in the output of the running test, got %q", output)
+	}
+	expected := []RawLine{
+		{Line: 1, Text: "setting up fixtures"},
+		{Line: 4, Package: "pkg/example", Test: "TestServer", Text: "listening on :8080 | ready"},
+		{Line: 7, Package: "pkg/example", Text: "{}"},
+	}
+	d := data.Diagnostics
+	if d == nil || d.RawLines != 3 || d.Unattached != 1 || len(d.Samples) != len(expected) {
+		t.Fatalf("Unexpected diagnostics %+v", d)
+	}
+	for i, raw := range expected {
+		if d.Samples[i] != raw {
+			t.Errorf("Expected raw line %+v, got %+v", raw, d.Samples[i])
+		}
+	}
+
+	report := renderMarkdownReport(data, ReportOptions{})
+	for _, want := range []string{
+		"## Input Diagnostics",
+		"⚠️ 3 input line(s) were not go test -json events",
+		"1 line(s) before the first event were left out.",
+		"| 4 | TestServer | listening on :8080 \\| ready |",
+		"| 7 | pkg/example | {} |",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected report to contain %q, got:\n%s", want, report)
+		}
+	}
+	if hidden := renderMarkdownReport(data, ReportOptions{HiddenSections: map[string]bool{"diagnostics": true}}); strings.Contains(hidden, "Input Diagnostics") {
+		t.Error("Expected the diagnostics section to be hidden")
+	}
+
+	encoded, err := json.Marshal(newJSONReport(data, ReportOptions{}, time.Now()))
+	if err != nil {
+		t.Fatalf("Failed to encode report: %v", err)
+	}
+	if !strings.Contains(string(encoded), `"input_diagnostics":{"raw_lines":3,"unattached":1`) {
+		t.Errorf("Expected the diagnostics in the JSON report, got %s", encoded)
+	}
+}
+
+func TestRawLinesOnly(t *testing.T) {
+	_, err := parseTestEvents(strings.NewReader("ok  \tpkg/example\t0.01s\nPASS\n"), ParseOptions{})
+	if err == nil || !strings.Contains(err.Error(), "on line 1") {
+		t.Errorf("Expected an error naming line 1 for input without events, got %v", err)
+	}
+}
+
+func TestMergeDiagnostics(t *testing.T) {
+	var merged *InputDiagnostics
+	merged = mergeDiagnostics(merged, nil)
+	if merged != nil {
+		t.Fatalf("Expected no diagnostics, got %+v", merged)
+	}
+	first := &InputDiagnostics{RawLines: 1, Samples: []RawLine{{Input: "a.json", Line: 3, Text: "x"}}}
+	second := &InputDiagnostics{RawLines: maxDiagnosticSamples + 5, Unattached: 2}
+	for i := 0; i < maxDiagnosticSamples; i++ {
+		second.Samples = append(second.Samples, RawLine{Input: "b.json", Line: i + 1})
+	}
+	merged = mergeDiagnostics(mergeDiagnostics(merged, first), second)
+	if merged.RawLines != maxDiagnosticSamples+6 || merged.Unattached != 2 || len(merged.Samples) != maxDiagnosticSamples {
+		t.Errorf("Unexpected merged diagnostics: %d line(s), %d unattached, %d sample(s)", merged.RawLines, merged.Unattached, len(merged.Samples))
+	}
+	if merged.Samples[0].Input != "a.json" {
+		t.Errorf("Expected the samples of the first input first, got %+v", merged.Samples[0])
+	}
+
+	var sb strings.Builder
+	writeDiagnosticsSection(&sb, merged)
+	if !strings.Contains(sb.String(), "| a.json:3 | — | x |") || !strings.Contains(sb.String(), "_6 more line(s) not shown._") {
+		t.Errorf("Unexpected diagnostics section:\n%s", sb.String())
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DurationChange is a test that got slower between two runs
+type DurationChange struct {
+	Name    string
+	Package string
+	Old     float64
+	New     float64
+}
+
+// Increase returns the slowdown in percent of the old duration
+func (c DurationChange) Increase() float64 {
+	if c.Old <= 0 {
+		return 0
+	}
+	return (c.New - c.Old) / c.Old * 100
+}
+
+// RunDiff is the difference between an old and a new run
+type RunDiff struct {
+	NewlyFailing []*TestResult // Failing now, passing, skipped or absent before
+	NewlyPassing []*TestResult // Passing now, failing before
+	Added        []*TestResult
+	Removed      []*TestResult
+	Regressions  []DurationChange // Sorted by slowdown, largest first
+}
+
+// Regressed reports whether the new run is worse than the old one
+func (d *RunDiff) Regressed() bool {
+	return len(d.NewlyFailing) > 0 || len(d.Regressions) > 0
+}
+
+// diffReports compares two runs. A test counts as a duration regression when
+// it passed in both runs, takes at least minDuration seconds now and got
+// slower by more than threshold percent.
+func diffReports(previous, current *ReportData, threshold, minDuration float64) *RunDiff {
+	diff := &RunDiff{}
+
+	for _, name := range sortedResultNames(current) {
+		result := current.Results[name]
+		before, existed := previous.Results[name]
+		if !existed {
+			diff.Added = append(diff.Added, result)
+		}
+		if result.Status == "FAIL" && (!existed || before.Status != "FAIL") {
+			diff.NewlyFailing = append(diff.NewlyFailing, result)
+		}
+		if !existed {
+			continue
+		}
+		if result.Status == "PASS" && before.Status == "FAIL" {
+			diff.NewlyPassing = append(diff.NewlyPassing, result)
+		}
+	}
+
+	for _, name := range sortedResultNames(previous) {
+		if _, ok := current.Results[name]; !ok {
+			diff.Removed = append(diff.Removed, previous.Results[name])
+		}
+	}
+
+	diff.Regressions = durationRegressions(reportBaseline(previous), current, threshold, minDuration)
+	return diff
+}
+
+// renderDiff renders the difference between two runs as Markdown
+func renderDiff(diff *RunDiff, oldName, newName string, threshold float64) string {
+	var sb strings.Builder
+	sb.WriteString("# Test Run Comparison\n\n")
+	sb.WriteString(fmt.Sprintf("Comparing %s (old) with %s (new).\n\n", codeSpan(oldName), codeSpan(newName)))
+
+	sb.WriteString("| Change | Tests |\n")
+	sb.WriteString("| ------ | ----- |\n")
+	sb.WriteString(fmt.Sprintf("| %s Newly failing | %d |\n", statusEmoji("FAIL"), len(diff.NewlyFailing)))
+	sb.WriteString(fmt.Sprintf("| %s Newly passing | %d |\n", statusEmoji("PASS"), len(diff.NewlyPassing)))
+	sb.WriteString(fmt.Sprintf("| ➕ Added | %d |\n", len(diff.Added)))
+	sb.WriteString(fmt.Sprintf("| ➖ Removed | %d |\n", len(diff.Removed)))
+	sb.WriteString(fmt.Sprintf("| 🐢 Slower by more than %.0f%% | %d |\n\n", threshold, len(diff.Regressions)))
+
+	writeList := func(title string, results []*TestResult, withStatus bool) {
+		if len(results) == 0 {
+			return
+		}
+		sb.WriteString(fmt.Sprintf("## %s\n\n", title))
+		for _, result := range results {
+			if withStatus {
+				sb.WriteString(fmt.Sprintf("- %s %s (%s)\n", statusEmoji(result.Status), escapeMarkdown(result.Name), escapeMarkdown(result.Package)))
+			} else {
+				sb.WriteString(fmt.Sprintf("- %s (%s)\n", escapeMarkdown(result.Name), escapeMarkdown(result.Package)))
+			}
+		}
+		sb.WriteString("\n")
+	}
+	writeList("Newly Failing Tests", diff.NewlyFailing, false)
+	writeList("Newly Passing Tests", diff.NewlyPassing, false)
+	writeList("Added Tests", diff.Added, true)
+	writeList("Removed Tests", diff.Removed, true)
+
+	if len(diff.Regressions) > 0 {
+		sb.WriteString("## Duration Regressions\n\n")
+		sb.WriteString("| Test | Package | Old | New | Change |\n")
+		sb.WriteString("| ---- | ------- | --- | --- | ------ |\n")
+		for _, c := range diff.Regressions {
+			sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | +%.0f%% |\n",
+				escapeMarkdown(c.Name), escapeMarkdown(c.Package), formatDuration(c.Old), formatDuration(c.New), c.Increase()))
+		}
+		sb.WriteString("\n")
+	}
+
+	if !diff.Regressed() && len(diff.NewlyPassing) == 0 && len(diff.Added) == 0 && len(diff.Removed) == 0 {
+		sb.WriteString("No changes between the runs.\n")
+	}
+	return sb.String()
+}
+
+// runDiff implements `gotest-report diff OLD NEW`, which compares two
+// go test -json runs for pre-merge quality gating
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	logFlags := registerLogFlags(fs)
+	configFile := registerConfigFlag(fs)
+	output := fs.String("output", "", "Write the comparison to this file instead of stdout")
+	threshold := fs.Float64("threshold", 20, "Percentage a test has to slow down by to count as a duration regression")
+	minDuration := fs.Float64("min-duration", defaultRegressionMinDuration, "Ignore duration regressions of tests faster than this many seconds")
+	failOnRegression := fs.Bool("fail-on-regression", false, "Exit with code 1 when tests newly fail or regress in duration")
+	fs.Parse(args)
+	if err := logFlags.apply(); err != nil {
+		logger.Errorf("Error: %v", err)
+		return 2
+	}
+	if fs.NArg() != 2 {
+		logger.Errorf("Usage: gotest-report diff [-threshold PERCENT] [-min-duration SECONDS] [-output FILE] [-fail-on-regression] OLD NEW")
+		return 2
+	}
+
+	_, normalize, err := commandConfig(*configFile)
+	if err != nil {
+		logger.Errorf("Error loading config: %v", err)
+		return 1
+	}
+	opts := ParseOptions{MaxLineSize: defaultMaxLineSize, Normalize: normalize}
+	previous, err := loadReport(fs.Arg(0), opts)
+	if err != nil {
+		logger.Errorf("Error reading %s: %v", fs.Arg(0), err)
+		return 1
+	}
+	current, err := loadReport(fs.Arg(1), opts)
+	if err != nil {
+		logger.Errorf("Error reading %s: %v", fs.Arg(1), err)
+		return 1
+	}
+
+	diff := diffReports(previous, current, *threshold, *minDuration)
+	report := renderDiff(diff, fs.Arg(0), fs.Arg(1), *threshold)
+	if *output == "" {
+		fmt.Print(report)
+	} else if err := os.WriteFile(*output, []byte(report), 0o644); err != nil {
+		logger.Errorf("Error writing comparison: %v", err)
+		return 1
+	}
+
+	if *failOnRegression && diff.Regressed() {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffReports(t *testing.T) {
+	build := func(results ...*TestResult) *ReportData {
+		data := &ReportData{Results: make(map[string]*TestResult), Packages: make(map[string]*PackageResult)}
+		for _, r := range results {
+			r.Package = "example.com/pkg"
+			data.Results[r.Name] = r
+		}
+		summarizeReport(data, nil)
+		return data
+	}
+
+	previous := build(
+		&TestResult{Name: "TestBroken", Status: "PASS", Duration: 0.2},
+		&TestResult{Name: "TestFixed", Status: "FAIL", Duration: 0.2},
+		&TestResult{Name: "TestStillFailing", Status: "FAIL", Duration: 0.2},
+		&TestResult{Name: "TestSlow", Status: "PASS", Duration: 1},
+		&TestResult{Name: "TestSlower", Status: "PASS", Duration: 1},
+		&TestResult{Name: "TestNoisy", Status: "PASS", Duration: 0.01},
+		&TestResult{Name: "TestSteady", Status: "PASS", Duration: 1},
+		&TestResult{Name: "TestGone", Status: "PASS", Duration: 1},
+	)
+	current := build(
+		&TestResult{Name: "TestBroken", Status: "FAIL", Duration: 0.2},
+		&TestResult{Name: "TestFixed", Status: "PASS", Duration: 0.2},
+		&TestResult{Name: "TestStillFailing", Status: "FAIL", Duration: 0.2},
+		&TestResult{Name: "TestSlow", Status: "PASS", Duration: 1.5},
+		&TestResult{Name: "TestSlower", Status: "PASS", Duration: 3},
+		&TestResult{Name: "TestNoisy", Status: "PASS", Duration: 0.05},
+		&TestResult{Name: "TestSteady", Status: "PASS", Duration: 1.1},
+		&TestResult{Name: "TestNew", Status: "FAIL", Duration: 1},
+	)
+
+	diff := diffReports(previous, current, 20, 0.1)
+
+	names := func(results []*TestResult) string {
+		var list []string
+		for _, r := range results {
+			list = append(list, r.Name)
+		}
+		return strings.Join(list, ",")
+	}
+	checks := []struct {
+		name     string
+		got      string
+		expected string
+	}{
+		{"newly failing", names(diff.NewlyFailing), "TestBroken,TestNew"},
+		{"newly passing", names(diff.NewlyPassing), "TestFixed"},
+		{"added", names(diff.Added), "TestNew"},
+		{"removed", names(diff.Removed), "TestGone"},
+	}
+	for _, c := range checks {
+		if c.got != c.expected {
+			t.Errorf("Expected %s tests %q, got %q", c.name, c.expected, c.got)
+		}
+	}
+
+	if len(diff.Regressions) != 2 || diff.Regressions[0].Name != "TestSlower" || diff.Regressions[1].Name != "TestSlow" {
+		t.Fatalf("Expected TestSlower and TestSlow to regress, got %+v", diff.Regressions)
+	}
+	if diff.Regressions[0].Increase() != 200 {
+		t.Errorf("Expected a 200%% increase, got %.1f", diff.Regressions[0].Increase())
+	}
+	if !diff.Regressed() {
+		t.Error("Expected the diff to be a regression")
+	}
+
+	report := renderDiff(diff, "old.json", "new.json", 20)
+	for _, want := range []string{
+		"| ❌ Newly failing | 2 |",
+		"## Removed Tests\n\n- ✅ TestGone (example.com/pkg)",
+		"| TestSlower | example.com/pkg | 1.000s | 3.000s | +200% |",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected report to contain %q, got:\n%s", want, report)
+		}
+	}
+
+	same := diffReports(previous, previous, 20, 0.1)
+	if same.Regressed() || !strings.Contains(renderDiff(same, "a", "b", 20), "No changes between the runs.") {
+		t.Error("Expected no changes when comparing a run with itself")
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// testFuncPrefixes are the prefixes of the functions go test runs
+var testFuncPrefixes = []string{"Test", "Benchmark", "Fuzz", "Example"}
+
+// TestSource is what the source of a test function tells about it
+type TestSource struct {
+	Doc      string // Doc comment on a single line
+	File     string // Repository relative path
+	Line     int
+	Parallel bool // Calls t.Parallel()
+	SkipLine int  // Line of a t.Skip call outside of any condition, 0 for none
+}
+
+// testSourceKey identifies a test function in the map returned by loadTestSources
+func testSourceKey(pkg, name string) string {
+	return pkg + "." + name
+}
+
+// isTestFunc reports whether a declaration is a function run by go test
+func isTestFunc(decl *ast.FuncDecl) bool {
+	if decl.Recv != nil {
+		return false
+	}
+	for _, prefix := range testFuncPrefixes {
+		if strings.HasPrefix(decl.Name.Name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// testParam returns the name of the *testing.T (or B, F) parameter of a test
+// function, or an empty string when it has none
+func testParam(decl *ast.FuncDecl) string {
+	params := decl.Type.Params.List
+	if len(params) == 0 || len(params[0].Names) == 0 {
+		return ""
+	}
+	return params[0].Names[0].Name
+}
+
+// isTestCall reports whether a call is param.method(...) for one of the methods
+func isTestCall(call *ast.CallExpr, param string, methods ...string) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	if ident, ok := sel.X.(*ast.Ident); !ok || ident.Name != param {
+		return false
+	}
+	for _, method := range methods {
+		if sel.Sel.Name == method {
+			return true
+		}
+	}
+	return false
+}
+
+// inspectTestFunc reads the doc comment of a test function, whether it calls
+// t.Parallel() and where it calls t.Skip unconditionally
+func inspectTestFunc(fset *token.FileSet, decl *ast.FuncDecl, file string) *TestSource {
+	source := &TestSource{File: file, Line: fset.Position(decl.Pos()).Line}
+	if decl.Doc != nil {
+		source.Doc = strings.Join(strings.Fields(decl.Doc.Text()), " ")
+	}
+	param := testParam(decl)
+	if param == "" || param == "_" || decl.Body == nil {
+		return source
+	}
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok && isTestCall(call, param, "Parallel") {
+			source.Parallel = true
+		}
+		// Function literals such as subtests run on their own
+		_, literal := n.(*ast.FuncLit)
+		return !literal
+	})
+	// Only statements of the function body itself run on every call
+	for _, stmt := range decl.Body.List {
+		expr, ok := stmt.(*ast.ExprStmt)
+		if !ok {
+			continue
+		}
+		if call, ok := expr.X.(*ast.CallExpr); ok && isTestCall(call, param, "Skip", "Skipf", "SkipNow") {
+			source.SkipLine = fset.Position(call.Pos()).Line
+			break
+		}
+	}
+	return source
+}
+
+// loadTestSources parses the test files matched by a package pattern such as
+// ./... or ./store, relative to the repository root, and returns their test
+// functions keyed by testSourceKey
+func loadTestSources(pattern string, resolver sourceResolver) (map[string]*TestSource, error) {
+	dir, recursive := strings.CutSuffix(filepath.ToSlash(pattern), "/...")
+	if dir == "..." {
+		dir, recursive = ".", true
+	}
+	start := filepath.Join(resolver.Root, filepath.FromSlash(dir))
+	if _, err := os.Stat(start); err != nil {
+		return nil, fmt.Errorf("error reading test sources: %v", err)
+	}
+
+	sources := make(map[string]*TestSource)
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(start, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			name := entry.Name()
+			if p != start && (!recursive || name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(p, "_test.go") {
+			return nil
+		}
+		file, err := parser.ParseFile(fset, p, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			return fmt.Errorf("error parsing %s: %v", p, err)
+		}
+		rel, err := filepath.Rel(resolver.Root, filepath.Dir(p))
+		if err != nil {
+			return err
+		}
+		pkg := resolver.ModulePath
+		if rel = filepath.ToSlash(rel); rel != "." {
+			pkg = path.Join(resolver.ModulePath, rel)
+		}
+		relFile := filepath.ToSlash(filepath.Join(rel, filepath.Base(p)))
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && isTestFunc(fn) {
+				sources[testSourceKey(pkg, fn.Name.Name)] = inspectTestFunc(fset, fn, relFile)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading test sources: %v", err)
+	}
+	return sources, nil
+}
+
+// applyTestDocs sets the description of the top-level tests with a doc comment
+func applyTestDocs(data *ReportData, sources map[string]*TestSource) {
+	for _, result := range data.Results {
+		if source, ok := sources[testSourceKey(result.Package, result.Name)]; ok && !result.IsSubTest {
+			result.Doc = source.Doc
+		}
+	}
+}
+
+// hasTestDocs reports whether any test has a description
+func hasTestDocs(data *ReportData) bool {
+	for _, result := range data.Results {
+		if result.Doc != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadTestDocs(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "shop_test.go"), `package shop
+
+// TestCheckout verifies that a paid cart
+// becomes an order.
+func TestCheckout(t *testing.T) {}
+
+func TestUndocumented(t *testing.T) {}
+
+// helper is not a test
+func helper() {}
+`)
+	writeFile(t, filepath.Join(root, "store", "store_test.go"), `package store_test
+
+// BenchmarkGet measures cache hits.
+func BenchmarkGet(b *testing.B) {}
+`)
+	writeFile(t, filepath.Join(root, "store", "testdata", "broken_test.go"), `not Go`)
+	resolver := sourceResolver{ModulePath: "github.com/acme/shop", Root: root}
+
+	docs, err := loadTestSources("./...", resolver)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string]string{
+		"github.com/acme/shop.TestCheckout":       "TestCheckout verifies that a paid cart becomes an order.",
+		"github.com/acme/shop/store.BenchmarkGet": "BenchmarkGet measures cache hits.",
+	}
+	if len(docs) != 3 || docs["github.com/acme/shop.TestUndocumented"] == nil {
+		t.Errorf("Expected the 3 test functions, got %v", docs)
+	}
+	for key, doc := range expected {
+		if docs[key] == nil || docs[key].Doc != doc {
+			t.Errorf("Expected %s to be %q, got %+v", key, doc, docs[key])
+		}
+	}
+	if source := docs["github.com/acme/shop/store.BenchmarkGet"]; source.File != "store/store_test.go" || source.Line != 4 {
+		t.Errorf("Expected the location of the function, got %s:%d", source.File, source.Line)
+	}
+
+	// A pattern without /... covers a single directory
+	docs, err = loadTestSources("./store", resolver)
+	if err != nil || len(docs) != 1 {
+		t.Errorf("Expected only the store package, got %v (%v)", docs, err)
+	}
+	if _, err := loadTestSources("./missing/...", resolver); err == nil {
+		t.Error("Expected an error for a missing directory")
+	}
+}
+
+func TestTestResultsDescriptions(t *testing.T) {
+	input := `{"Action":"run","Package":"github.com/acme/shop","Test":"TestCheckout"}
+{"Action":"pass","Package":"github.com/acme/shop","Test":"TestCheckout","Elapsed":0.1}
+{"Action":"run","Package":"github.com/acme/shop","Test":"TestRefund"}
+{"Action":"pass","Package":"github.com/acme/shop","Test":"TestRefund","Elapsed":0.1}
+`
+	data, err := processTestEvents(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if report := generateMarkdownReport(data); strings.Contains(report, "Description") {
+		t.Errorf("Expected no Description column without docs, got:\n%s", report)
+	}
+
+	applyTestDocs(data, map[string]*TestSource{"github.com/acme/shop.TestCheckout": {Doc: "Verifies that a paid cart becomes an order."}})
+	report := generateMarkdownReport(data)
+	for _, expected := range []string{
+		"| Test | Description | Status | Duration | Details |",
+		"| **TestCheckout** | Verifies that a paid cart becomes an order. | ✅ PASS |",
+		"| **TestRefund** |  | ✅ PASS |",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Expected the report to contain %q, got:\n%s", expected, report)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// durationBarWidth is the length of the longest bar in the Test Durations chart
+const durationBarWidth = 25
+
+// logScaleSpread is the ratio between the longest and shortest charted
+// duration above which the chart switches to a logarithmic scale
+const logScaleSpread = 100
+
+// formatDuration formats seconds in the unit that suits the value, e.g.
+// "850µs", "12.5ms", "1.204s" or "2m05.3s"
+func formatDuration(seconds float64) string {
+	// trim rounds to precision decimals and drops trailing zeros
+	trim := func(v float64, precision int) string {
+		p := math.Pow(10, float64(precision))
+		return strconv.FormatFloat(math.Round(v*p)/p, 'f', -1, 64)
+	}
+	switch {
+	case seconds <= 0:
+		return "0s"
+	case seconds < 1e-3:
+		return trim(seconds*1e6, 0) + "µs"
+	case seconds < 1:
+		ms := seconds * 1e3
+		if ms < 10 {
+			return trim(ms, 2) + "ms"
+		}
+		return trim(ms, 1) + "ms"
+	case seconds < 60:
+		return fmt.Sprintf("%.3fs", seconds)
+	}
+	minutes := math.Floor(seconds / 60)
+	return fmt.Sprintf("%.0fm%04.1fs", minutes, seconds-minutes*60)
+}
+
+// durationScale maps durations to bar lengths. Durations spanning several
+// orders of magnitude, such as micro-benchmarked tests next to integration
+// tests, use a logarithmic scale so fast tests do not all collapse into a
+// single block.
+type durationScale struct {
+	min, max    float64 // Shortest non-zero and longest charted duration
+	logarithmic bool
+}
+
+func newDurationScale(durations []float64) durationScale {
+	var scale durationScale
+	for _, d := range durations {
+		if d <= 0 {
+			continue
+		}
+		if scale.min == 0 || d < scale.min {
+			scale.min = d
+		}
+		if d > scale.max {
+			scale.max = d
+		}
+	}
+	scale.logarithmic = scale.min > 0 && scale.max/scale.min >= logScaleSpread
+	return scale
+}
+
+// bar returns the bar of a duration
+func (s durationScale) bar(d float64) string {
+	if d <= 0 || s.max <= 0 {
+		return ""
+	}
+	var length int
+	if s.logarithmic {
+		length = 1 + int(math.Round(math.Log(d/s.min)/math.Log(s.max/s.min)*(durationBarWidth-1)))
+	} else {
+		length = int(math.Round(d / s.max * durationBarWidth))
+	}
+	if length < 1 {
+		length = 1
+	}
+	return strings.Repeat("█", length)
+}
+
+// legend explains how to read the bars
+func (s durationScale) legend() string {
+	if s.max <= 0 {
+		return ""
+	}
+	if s.logarithmic {
+		step := math.Pow(s.max/s.min, 1.0/(durationBarWidth-1))
+		return fmt.Sprintf("Scale: logarithmic from █ = %s to %d █ = %s, each █ is about ×%.2f",
+			formatDuration(s.min), durationBarWidth, formatDuration(s.max), step)
+	}
+	return fmt.Sprintf("Scale: linear, each █ is %s", formatDuration(s.max/durationBarWidth))
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[float64]string{
+		0:         "0s",
+		0.0000125: "13µs",
+		0.00085:   "850µs",
+		0.0042:    "4.2ms",
+		0.1:       "100ms",
+		0.1255:    "125.5ms",
+		1.2043:    "1.204s",
+		125.3:     "2m05.3s",
+	}
+	for seconds, expected := range tests {
+		if got := formatDuration(seconds); got != expected {
+			t.Errorf("formatDuration(%v) = %q, expected %q", seconds, got, expected)
+		}
+	}
+}
+
+func TestDurationScale(t *testing.T) {
+	linear := newDurationScale([]float64{1, 0.5, 0})
+	if linear.logarithmic {
+		t.Error("Expected a linear scale for close durations")
+	}
+	if got := strings.Count(linear.bar(1), "█"); got != durationBarWidth {
+		t.Errorf("Expected the longest bar to have %d blocks, got %d", durationBarWidth, got)
+	}
+	if got := strings.Count(linear.bar(0.5), "█"); got != 13 {
+		t.Errorf("Expected half the duration to have 13 blocks, got %d", got)
+	}
+	if linear.bar(0) != "" {
+		t.Error("Expected no bar for a zero duration")
+	}
+	if legend := linear.legend(); legend != "Scale: linear, each █ is 40ms" {
+		t.Errorf("Unexpected legend %q", legend)
+	}
+
+	// Micro-benchmarked tests next to a slow one get distinct bars
+	log := newDurationScale([]float64{2, 0.001, 0.0001, 0.00001})
+	if !log.logarithmic {
+		t.Fatal("Expected a logarithmic scale for durations spanning orders of magnitude")
+	}
+	fast, faster, fastest := strings.Count(log.bar(0.001), "█"), strings.Count(log.bar(0.0001), "█"), strings.Count(log.bar(0.00001), "█")
+	if fastest != 1 || faster <= fastest || fast <= faster || strings.Count(log.bar(2), "█") != durationBarWidth {
+		t.Errorf("Expected increasing bars, got %d, %d, %d", fastest, faster, fast)
+	}
+	if legend := log.legend(); !strings.Contains(legend, "logarithmic from █ = 10µs to 25 █ = 2.000s") {
+		t.Errorf("Unexpected legend %q", legend)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// durationBuckets are the upper bounds of the histogram buckets in seconds
+// with their labels, a bucket per order of magnitude so unit tests and integration tests both
+// get readable bars. The last bucket is open ended.
+var durationBuckets = []struct {
+	max   float64
+	label string
+}{{0.001, "1ms"}, {0.01, "10ms"}, {0.1, "100ms"}, {1, "1s"}, {10, "10s"}, {60, "1m"}}
+
+// DurationStats summarizes the distribution of the test durations of a run
+type DurationStats struct {
+	Count     int              `json:"count"`
+	Mean      float64          `json:"mean"`    // Seconds
+	StdDev    float64          `json:"std_dev"` // Seconds, population standard deviation
+	P50       float64          `json:"p50"`
+	P90       float64          `json:"p90"`
+	P99       float64          `json:"p99"`
+	Max       float64          `json:"max"`
+	TailShare float64          `json:"tail_share"` // Percent of the summed duration spent in tests slower than p90
+	Buckets   []DurationBucket `json:"buckets"`
+}
+
+// DurationBucket is a bar of the duration histogram
+type DurationBucket struct {
+	Label string  `json:"label"` // e.g. "10ms-100ms"
+	Min   float64 `json:"min"`   // Seconds, inclusive
+	Max   float64 `json:"max"`   // Seconds, exclusive; 0 for the open ended last bucket
+	Count int     `json:"count"`
+}
+
+// percentile returns the nearest-rank percentile p (0-100) of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
+
+// durationStats returns the duration statistics of the top-level tests that
+// passed or failed, or nil when none did. Skipped tests are left out as their
+// near zero durations would pull the percentiles down, and subtests as their
+// time is part of their parent's.
+func durationStats(data *ReportData) *DurationStats {
+	var durations []float64
+	for _, name := range data.SortedTestNames {
+		if result := data.Results[name]; result.Status == "PASS" || result.Status == "FAIL" {
+			durations = append(durations, result.Duration)
+		}
+	}
+	if len(durations) == 0 {
+		return nil
+	}
+	sort.Float64s(durations)
+
+	stats := &DurationStats{
+		Count: len(durations),
+		P50:   percentile(durations, 50),
+		P90:   percentile(durations, 90),
+		P99:   percentile(durations, 99),
+		Max:   durations[len(durations)-1],
+	}
+	total, tail := 0.0, 0.0
+	for _, d := range durations {
+		total += d
+		if d > stats.P90 {
+			tail += d
+		}
+	}
+	stats.Mean = total / float64(len(durations))
+	for _, d := range durations {
+		stats.StdDev += (d - stats.Mean) * (d - stats.Mean)
+	}
+	stats.StdDev = math.Sqrt(stats.StdDev / float64(len(durations)))
+	if total > 0 {
+		stats.TailShare = tail / total * 100
+	}
+
+	for i := 0; i <= len(durationBuckets); i++ {
+		var bucket DurationBucket
+		switch {
+		case i == 0:
+			bucket.Max, bucket.Label = durationBuckets[i].max, "< "+durationBuckets[i].label
+		case i < len(durationBuckets):
+			bucket.Min, bucket.Max = durationBuckets[i-1].max, durationBuckets[i].max
+			bucket.Label = durationBuckets[i-1].label + "-" + durationBuckets[i].label
+		default:
+			bucket.Min, bucket.Label = durationBuckets[i-1].max, "≥ "+durationBuckets[i-1].label
+		}
+		for _, d := range durations {
+			if d >= bucket.Min && (bucket.Max == 0 || d < bucket.Max) {
+				bucket.Count++
+			}
+		}
+		stats.Buckets = append(stats.Buckets, bucket)
+	}
+	// Leading and trailing empty buckets only make the histogram longer
+	first, last := 0, len(stats.Buckets)-1
+	for first < last && stats.Buckets[first].Count == 0 {
+		first++
+	}
+	for last > first && stats.Buckets[last].Count == 0 {
+		last--
+	}
+	stats.Buckets = stats.Buckets[first : last+1]
+	return stats
+}
+
+// PeakBucket returns the largest bucket count of the histogram
+func (s *DurationStats) PeakBucket() int {
+	peak := 0
+	for _, bucket := range s.Buckets {
+		peak = max(peak, bucket.Count)
+	}
+	return peak
+}
+
+// writeDurationStatsSection renders the percentiles and a histogram of the
+// test durations
+func writeDurationStatsSection(sb *strings.Builder, data *ReportData) {
+	stats := durationStats(data)
+	if stats == nil {
+		return
+	}
+	sb.WriteString("## Duration Statistics\n\n")
+	sb.WriteString("| Tests | Mean | Std Dev | p50 | p90 | p99 | Max |\n")
+	sb.WriteString("| ----- | ---- | ------- | --- | --- | --- | --- |\n")
+	sb.WriteString(fmt.Sprintf("| %d | %s | %s | %s | %s | %s | %s |\n\n", stats.Count, formatDuration(stats.Mean), formatDuration(stats.StdDev),
+		formatDuration(stats.P50), formatDuration(stats.P90), formatDuration(stats.P99), formatDuration(stats.Max)))
+	if stats.TailShare > 0 {
+		sb.WriteString(fmt.Sprintf("Tests slower than p90 take %.0f%% of the test time.\n\n", stats.TailShare))
+	}
+
+	peak := stats.PeakBucket()
+	sb.WriteString("| Duration | Tests | |\n")
+	sb.WriteString("| -------- | ----- | - |\n")
+	for _, bucket := range stats.Buckets {
+		bar := strings.Repeat("█", int(math.Ceil(float64(bucket.Count)*durationBarWidth/float64(peak))))
+		sb.WriteString(fmt.Sprintf("| %s | %d | %s |\n", bucket.Label, bucket.Count, bar))
+	}
+	sb.WriteString("\n")
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestPercentile(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		p        float64
+		expected float64
+	}{{0, 1}, {50, 5}, {90, 9}, {99, 10}, {100, 10}}
+	for _, tt := range tests {
+		if got := percentile(values, tt.p); got != tt.expected {
+			t.Errorf("Expected p%v to be %v, got %v", tt.p, tt.expected, got)
+		}
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("Expected 0 without values, got %v", got)
+	}
+}
+
+func durationStatsFixture() *ReportData {
+	data := &ReportData{Results: map[string]*TestResult{
+		"TestSkipped":   {Name: "TestSkipped", Status: "SKIP"},
+		"TestA/subtest": {Name: "TestA/subtest", Status: "PASS", Duration: 30, IsSubTest: true},
+	}}
+	for i, d := range []float64{0.0005, 0.002, 0.003, 0.02, 0.05, 0.05, 0.2, 0.3, 1.5, 12} {
+		name := "Test" + string(rune('A'+i))
+		status := "PASS"
+		if i == 3 {
+			status = "FAIL"
+		}
+		data.Results[name] = &TestResult{Name: name, Status: status, Duration: d}
+		data.SortedTestNames = append(data.SortedTestNames, name)
+	}
+	data.SortedTestNames = append(data.SortedTestNames, "TestSkipped")
+	return data
+}
+
+func TestDurationStats(t *testing.T) {
+	stats := durationStats(durationStatsFixture())
+	if stats == nil {
+		t.Fatal("Expected duration statistics")
+	}
+	if stats.Count != 10 || stats.P50 != 0.05 || stats.P90 != 1.5 || stats.P99 != 12 || stats.Max != 12 {
+		t.Errorf("Unexpected statistics %+v", stats)
+	}
+	if math.Abs(stats.Mean-1.41255) > 1e-9 || math.Abs(stats.StdDev-3.5557) > 1e-3 || math.Abs(stats.TailShare-84.95) > 0.01 {
+		t.Errorf("Unexpected mean %v, standard deviation %v or tail share %v", stats.Mean, stats.StdDev, stats.TailShare)
+	}
+
+	var buckets []string
+	for _, bucket := range stats.Buckets {
+		buckets = append(buckets, bucket.Label+"="+string(rune('0'+bucket.Count)))
+	}
+	expected := "< 1ms=1 1ms-10ms=2 10ms-100ms=3 100ms-1s=2 1s-10s=1 10s-1m=1"
+	if got := strings.Join(buckets, " "); got != expected {
+		t.Errorf("Expected buckets %s, got %s", expected, got)
+	}
+
+	if durationStats(&ReportData{Results: map[string]*TestResult{}}) != nil {
+		t.Error("Expected no statistics without tests")
+	}
+}
+
+func TestWriteDurationStatsSection(t *testing.T) {
+	var sb strings.Builder
+	writeDurationStatsSection(&sb, durationStatsFixture())
+	for _, expected := range []string{
+		"## Duration Statistics\n\n| Tests | Mean | Std Dev | p50 | p90 | p99 | Max |",
+		"| 10 | 1.413s | 3.556s | 50ms | 1.500s | 12.000s | 12.000s |",
+		"Tests slower than p90 take 85% of the test time.",
+		"| 10ms-100ms | 3 | " + strings.Repeat("█", 25) + " |",
+		"| < 1ms | 1 | " + strings.Repeat("█", 9) + " |",
+	} {
+		if !strings.Contains(sb.String(), expected) {
+			t.Errorf("Expected section to contain %q, got:\n%s", expected, sb.String())
+		}
+	}
+}
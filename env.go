@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// EnvEntry is a property of the machine the tests ran on
+type EnvEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// ciProviders maps the variable each CI service sets to its name, checked in
+// order before the generic CI variable
+var ciProviders = []struct{ Env, Name string }{
+	{"GITHUB_ACTIONS", "GitHub Actions"},
+	{"GITLAB_CI", "GitLab CI"},
+	{"JENKINS_URL", "Jenkins"},
+	{"CIRCLECI", "CircleCI"},
+	{"BUILDKITE", "Buildkite"},
+	{"TRAVIS", "Travis CI"},
+	{"TF_BUILD", "Azure Pipelines"},
+	{"BITBUCKET_BUILD_NUMBER", "Bitbucket Pipelines"},
+	{"TEAMCITY_VERSION", "TeamCity"},
+	{"DRONE", "Drone"},
+	{"CI", "unknown"},
+}
+
+// ciProvider returns the name of the CI service running the report, or an
+// empty string outside of CI
+func ciProvider() string {
+	if detectCIPreset() == "woodpecker" {
+		return "Woodpecker"
+	}
+	for _, p := range ciProviders {
+		if os.Getenv(p.Env) != "" {
+			return p.Name
+		}
+	}
+	return ""
+}
+
+// goEnv returns the toolchain settings the tests were built with, from go env
+// in the repository, falling back to those gotest-report was built with when
+// go is not installed
+func goEnv(root string) (version, goos, goarch string) {
+	cmd := exec.Command("go", "env", "GOVERSION", "GOOS", "GOARCH")
+	cmd.Dir = root
+	if out, err := cmd.Output(); err == nil {
+		if fields := strings.Fields(string(out)); len(fields) == 3 {
+			return fields[0], fields[1], fields[2]
+		}
+	}
+	return runtime.Version(), runtime.GOOS, runtime.GOARCH
+}
+
+// detectEnvironment returns the Go version, platform, CPU count, CI provider
+// and hostname of the run
+func detectEnvironment(root string) []EnvEntry {
+	version, goos, goarch := goEnv(root)
+	env := []EnvEntry{
+		{"Go version", version},
+		{"OS/Arch", goos + "/" + goarch},
+		{"CPUs", strconv.Itoa(runtime.NumCPU())},
+	}
+	if provider := ciProvider(); provider != "" {
+		env = append(env, EnvEntry{"CI provider", provider})
+	}
+	if host, err := os.Hostname(); err == nil {
+		env = append(env, EnvEntry{"Hostname", host})
+	}
+	return env
+}
+
+// applyEnvFlags sets the key=value pairs given with -env, replacing detected
+// entries with the same key
+func applyEnvFlags(env []EnvEntry, pairs []string) ([]EnvEntry, error) {
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid -env value %q (expected key=value)", pair)
+		}
+		replaced := false
+		for i := range env {
+			if env[i].Key == key {
+				env[i].Value, replaced = value, true
+			}
+		}
+		if !replaced {
+			env = append(env, EnvEntry{key, value})
+		}
+	}
+	return env, nil
+}
+
+// writeEnvironmentSection renders the environment of the run collapsed
+func writeEnvironmentSection(sb *strings.Builder, env []EnvEntry) {
+	if len(env) == 0 {
+		return
+	}
+	sb.WriteString("## Environment\n\n")
+	sb.WriteString("<details>\n<summary>Click to expand environment</summary>\n\n")
+	sb.WriteString("| Property | Value |\n")
+	sb.WriteString("| -------- | ----- |\n")
+	for _, e := range env {
+		sb.WriteString(fmt.Sprintf("| %s | %s |\n", escapeMarkdown(e.Key), escapeMarkdown(e.Value)))
+	}
+	sb.WriteString("\n</details>\n\n")
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyEnvFlags(t *testing.T) {
+	detected := []EnvEntry{{"Go version", "go1.23.1"}, {"OS/Arch", "linux/amd64"}}
+	env, err := applyEnvFlags(detected, []string{"Go version=go1.22.7", "Database=postgres=16"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []EnvEntry{{"Go version", "go1.22.7"}, {"OS/Arch", "linux/amd64"}, {"Database", "postgres=16"}}
+	if len(env) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, env)
+	}
+	for i := range expected {
+		if env[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], env[i])
+		}
+	}
+
+	for _, invalid := range []string{"novalue", "=value"} {
+		if _, err := applyEnvFlags(nil, []string{invalid}); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestCIProvider(t *testing.T) {
+	for _, p := range ciProviders {
+		t.Setenv(p.Env, "")
+	}
+	if provider := ciProvider(); provider != "" {
+		t.Errorf("Expected no provider outside of CI, got %q", provider)
+	}
+	t.Setenv("CI", "true")
+	t.Setenv("GITLAB_CI", "true")
+	if provider := ciProvider(); provider != "GitLab CI" {
+		t.Errorf("Expected GitLab CI, got %q", provider)
+	}
+}
+
+func TestEnvironmentSection(t *testing.T) {
+	data := &ReportData{Results: map[string]*TestResult{}, Env: []EnvEntry{{"Go version", "go1.23.1"}, {"CPUs", "8"}}}
+	report := renderMarkdownReport(data, ReportOptions{})
+	if !strings.Contains(report, "## Environment\n\n<details>\n<summary>Click to expand environment</summary>\n\n| Property | Value |\n| -------- | ----- |\n| Go version | go1.23.1 |\n| CPUs | 8 |\n") {
+		t.Errorf("Expected the environment table, got:\n%s", report)
+	}
+	hidden := renderMarkdownReport(data, ReportOptions{HiddenSections: map[string]bool{"environment": true}})
+	if strings.Contains(hidden, "## Environment") {
+		t.Error("Expected the environment section to be hidden")
+	}
+}
+
+func TestDetailsSectionBoundaries(t *testing.T) {
+	input := `{"Action":"run","Package":"pkg","Test":"TestSlow"}
+{"Action":"pass","Package":"pkg","Test":"TestSlow","Elapsed":2}
+`
+	data, err := processTestEvents(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data.Env = []EnvEntry{{"Go version", "go1.23.1"}}
+	report := renderMarkdownReport(data, ReportOptions{})
+	if !strings.Contains(report, "</details>\n\n## Environment\n") {
+		t.Errorf("Expected a blank line between Test Durations and Environment, got:\n%s", report)
+	}
+	// GitHub keeps rendering HTML until a blank line, so every collapsible
+	// section has to be followed by one
+	lines := strings.Split(report, "\n")
+	for i, line := range lines[:len(lines)-1] {
+		if line == "</details>" && lines[i+1] != "" {
+			t.Errorf("Expected a blank line after </details> on line %d, got %q", i+1, lines[i+1])
+		}
+	}
+}
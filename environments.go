@@ -0,0 +1,216 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// EnvironmentRun is the run of the suite compared for one environment
+type EnvironmentRun struct {
+	Name string
+	Run  *RunRecord
+}
+
+// EnvironmentDifference is a test that passed in one environment and failed
+// in another
+type EnvironmentDifference struct {
+	Name     string
+	Package  string
+	Statuses []string // Per environment in comparison order, empty when the test did not run
+}
+
+// compareEnvironments returns the tests that passed in at least one of the
+// runs and failed in at least one other, sorted by package and name
+func compareEnvironments(runs []EnvironmentRun) []EnvironmentDifference {
+	names := make(map[string]bool)
+	for _, env := range runs {
+		for name := range env.Run.Tests {
+			names[name] = true
+		}
+	}
+
+	var differences []EnvironmentDifference
+	for name := range names {
+		diff := EnvironmentDifference{Statuses: make([]string, len(runs))}
+		passed, failed := false, false
+		for i, env := range runs {
+			test, ok := env.Run.Tests[name]
+			if !ok {
+				continue
+			}
+			diff.Name, diff.Package, diff.Statuses[i] = recordTestName(name, test), test.Package, test.Status
+			passed = passed || test.Status == "PASS"
+			failed = failed || test.Status == "FAIL"
+		}
+		if passed && failed {
+			differences = append(differences, diff)
+		}
+	}
+	sort.Slice(differences, func(i, j int) bool {
+		if differences[i].Package != differences[j].Package {
+			return differences[i].Package < differences[j].Package
+		}
+		return differences[i].Name < differences[j].Name
+	})
+	return differences
+}
+
+// latestEnvironmentRuns returns the most recent run of every environment in
+// the history, or of the given environments in their order
+func latestEnvironmentRuns(history []*RunRecord, environments []string) ([]EnvironmentRun, error) {
+	latest := make(map[string]*RunRecord)
+	for _, run := range history {
+		if run.Environment != "" {
+			// History is ordered oldest first
+			latest[run.Environment] = run
+		}
+	}
+	if len(environments) == 0 {
+		for name := range latest {
+			environments = append(environments, name)
+		}
+		sort.Strings(environments)
+	}
+
+	var runs []EnvironmentRun
+	for _, name := range environments {
+		run, ok := latest[name]
+		if !ok {
+			return nil, fmt.Errorf("no run of environment %q in the history", name)
+		}
+		runs = append(runs, EnvironmentRun{Name: name, Run: run})
+	}
+	return runs, nil
+}
+
+// renderEnvironmentComparison renders the runs side by side with the tests
+// whose outcome depends on the environment
+func renderEnvironmentComparison(runs []EnvironmentRun, differences []EnvironmentDifference) string {
+	var sb strings.Builder
+	sb.WriteString("# Environment Comparison\n\n")
+	sb.WriteString("| Environment | Run | Tests | Passed | Failed | Skipped |\n")
+	sb.WriteString("| ----------- | --- | ----- | ------ | ------ | ------- |\n")
+	for _, env := range runs {
+		when := "-"
+		if !env.Run.Timestamp.IsZero() {
+			when = env.Run.Timestamp.UTC().Format("2006-01-02 15:04 UTC")
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %d | %d | %d | %d |\n",
+			escapeMarkdown(env.Name), when, env.Run.Total, env.Run.Passed, env.Run.Failed, env.Run.Skipped))
+	}
+	sb.WriteString("\n")
+
+	if len(differences) == 0 {
+		sb.WriteString("No test passed in one environment and failed in another.\n")
+		return sb.String()
+	}
+
+	sb.WriteString("## Tests Passing in One Environment and Failing in Another\n\n")
+	sb.WriteString("| Test | Package |")
+	separator := "| ---- | ------- |"
+	for _, env := range runs {
+		sb.WriteString(" " + escapeMarkdown(env.Name) + " |")
+		separator += " --- |"
+	}
+	sb.WriteString("\n" + separator + "\n")
+	for _, diff := range differences {
+		sb.WriteString(fmt.Sprintf("| %s | %s |", escapeMarkdown(diff.Name), escapeMarkdown(diff.Package)))
+		for _, status := range diff.Statuses {
+			if status == "" {
+				sb.WriteString(" - |")
+			} else {
+				sb.WriteString(fmt.Sprintf(" %s %s |", statusEmoji(status), status))
+			}
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// runCompareEnv implements `gotest-report compare-env`, comparing the same
+// suite across environments given as NAME=FILE arguments or taken from the
+// latest run of each environment in the history
+func runCompareEnv(args []string) int {
+	fs := flag.NewFlagSet("compare-env", flag.ExitOnError)
+	logFlags := registerLogFlags(fs)
+	output := fs.String("output", "", "Write the comparison to this file instead of stdout")
+	historyDir := fs.String("history-dir", "", "Compare the latest runs of each environment stored in this history directory")
+	historyURL := fs.String("history-url", "", "Compare the latest runs of each environment in this remote history")
+	environments := fs.String("environments", "", "Comma separated environments to compare from the history, in this order (default all)")
+	failOnDifference := fs.Bool("fail-on-difference", false, "Exit with code 1 when a test passes in one environment and fails in another")
+	fs.Parse(args)
+	if err := logFlags.apply(); err != nil {
+		logger.Errorf("Error: %v", err)
+		return 2
+	}
+
+	var runs []EnvironmentRun
+	if fs.NArg() > 0 {
+		if *historyDir != "" || *historyURL != "" {
+			logger.Errorf("Error: NAME=FILE arguments cannot be combined with -history-dir or -history-url")
+			return 2
+		}
+		for _, arg := range fs.Args() {
+			name, file, ok := strings.Cut(arg, "=")
+			if !ok || name == "" || file == "" {
+				logger.Errorf("Error: invalid argument %q, expected NAME=FILE", arg)
+				return 2
+			}
+			data, err := loadReport(file, ParseOptions{MaxLineSize: defaultMaxLineSize})
+			if err != nil {
+				logger.Errorf("Error reading %s: %v", file, err)
+				return 1
+			}
+			runs = append(runs, EnvironmentRun{Name: name, Run: newRunRecord(data, data.Start)})
+		}
+	} else {
+		store, err := openHistoryStore(*historyDir, *historyURL)
+		if err != nil {
+			logger.Errorf("Error opening history: %v", err)
+			return 1
+		}
+		if store == nil {
+			logger.Errorf("Usage: gotest-report compare-env [-output FILE] [-fail-on-difference] NAME=FILE NAME=FILE...")
+			logger.Errorf("       gotest-report compare-env -history-dir DIR | -history-url URL [-environments a,b]")
+			return 2
+		}
+		defer store.Close()
+		history, err := store.Query(HistoryQuery{})
+		if err != nil {
+			logger.Errorf("Error loading history: %v", err)
+			return 1
+		}
+		var names []string
+		for _, name := range strings.Split(*environments, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		if runs, err = latestEnvironmentRuns(history, names); err != nil {
+			logger.Errorf("Error: %v", err)
+			return 1
+		}
+	}
+	if len(runs) < 2 {
+		logger.Errorf("Error: at least two environments are needed for a comparison, found %d", len(runs))
+		return 1
+	}
+
+	differences := compareEnvironments(runs)
+	report := renderEnvironmentComparison(runs, differences)
+	if *output == "" {
+		fmt.Print(report)
+	} else if err := os.WriteFile(*output, []byte(report), 0o644); err != nil {
+		logger.Errorf("Error writing comparison: %v", err)
+		return 1
+	}
+
+	if *failOnDifference && len(differences) > 0 {
+		return 1
+	}
+	return 0
+}
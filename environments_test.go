@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompareEnvironments(t *testing.T) {
+	record := func(tests map[string]string) *RunRecord {
+		run := &RunRecord{Tests: map[string]TestRecord{}}
+		for name, status := range tests {
+			run.Tests[testKey("e2e", name)] = TestRecord{Package: "e2e", Status: status}
+		}
+		return run
+	}
+	runs := []EnvironmentRun{
+		{Name: "staging", Run: record(map[string]string{"TestLogin": "PASS", "TestCheckout": "FAIL", "TestSearch": "PASS", "TestBeta": "FAIL"})},
+		{Name: "prod", Run: record(map[string]string{"TestLogin": "FAIL", "TestCheckout": "FAIL", "TestSearch": "PASS"})},
+		{Name: "canary", Run: record(map[string]string{"TestLogin": "PASS", "TestBeta": "PASS", "TestSearch": "SKIP"})},
+	}
+
+	differences := compareEnvironments(runs)
+	var names []string
+	for _, diff := range differences {
+		names = append(names, diff.Name)
+	}
+	if got := strings.Join(names, ","); got != "TestBeta,TestLogin" {
+		t.Fatalf("Expected TestBeta and TestLogin to differ, got %s", got)
+	}
+
+	report := renderEnvironmentComparison(runs, differences)
+	for _, expected := range []string{
+		"| Test | Package | staging | prod | canary |",
+		"| TestBeta | e2e | ❌ FAIL | - | ✅ PASS |",
+		"| TestLogin | e2e | ✅ PASS | ❌ FAIL | ✅ PASS |",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Expected %q in report, got:\n%s", expected, report)
+		}
+	}
+	if report := renderEnvironmentComparison(runs[:1], nil); !strings.Contains(report, "No test passed in one environment and failed in another.") {
+		t.Errorf("Expected a note without differences, got:\n%s", report)
+	}
+}
+
+func TestLatestEnvironmentRuns(t *testing.T) {
+	start := time.Date(2024, 3, 20, 10, 0, 0, 0, time.UTC)
+	var history []*RunRecord
+	for i, env := range []string{"prod", "staging", "", "prod"} {
+		history = append(history, &RunRecord{Timestamp: start.Add(time.Duration(i) * time.Hour), Environment: env})
+	}
+
+	runs, err := latestEnvironmentRuns(history, nil)
+	if err != nil || len(runs) != 2 || runs[0].Name != "prod" || !runs[0].Run.Timestamp.Equal(start.Add(3*time.Hour)) || runs[1].Name != "staging" {
+		t.Errorf("Expected the latest prod and staging runs, got %+v, %v", runs, err)
+	}
+	runs, err = latestEnvironmentRuns(history, []string{"staging", "prod"})
+	if err != nil || runs[0].Name != "staging" {
+		t.Errorf("Expected the requested order, got %+v, %v", runs, err)
+	}
+	if _, err := latestEnvironmentRuns(history, []string{"qa"}); err == nil {
+		t.Error("Expected an error for an environment without runs")
+	}
+}
+
+func TestRunCompareEnv(t *testing.T) {
+	dir := t.TempDir()
+	staging := filepath.Join(dir, "staging.json")
+	prod := filepath.Join(dir, "prod.json")
+	os.WriteFile(staging, []byte(`{"Action":"pass","Package":"e2e","Test":"TestLogin","Elapsed":1}`+"\n"), 0644)
+	os.WriteFile(prod, []byte(`{"Action":"fail","Package":"e2e","Test":"TestLogin","Elapsed":1}`+"\n"), 0644)
+	output := filepath.Join(dir, "envs.md")
+
+	if code := runCompareEnv([]string{"-output", output, "-fail-on-difference", "staging=" + staging, "prod=" + prod}); code != 1 {
+		t.Errorf("Expected exit code 1 for a difference, got %d", code)
+	}
+	report, _ := os.ReadFile(output)
+	if !strings.Contains(string(report), "| TestLogin | e2e | ✅ PASS | ❌ FAIL |") {
+		t.Errorf("Unexpected comparison:\n%s", report)
+	}
+	if code := runCompareEnv([]string{"staging"}); code != 2 {
+		t.Errorf("Expected a usage error for an argument without a file, got %d", code)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// exitSummaryLine is the last line printed to stderr, with the results as
+// key=value pairs so CI systems without artifact support can extract them
+// from the log, e.g.
+//
+//	gotest-report: total=812 passed=800 failed=3 skipped=9 flaky=2 duration=512s report=report.md
+func exitSummaryLine(data *ReportData, gates []GateResult, history []*RunRecord, report string) string {
+	// Wall clock time is what the job waited for, when the input has timestamps
+	duration := data.WallClock()
+	if duration == 0 {
+		duration = data.TotalDuration
+	}
+	fields := []string{
+		fmt.Sprintf("total=%d", data.TotalTests),
+		fmt.Sprintf("passed=%d", data.PassedTests),
+		fmt.Sprintf("failed=%d", data.FailedTests),
+		fmt.Sprintf("skipped=%d", data.SkippedTests),
+		fmt.Sprintf("failed_packages=%d", data.FailedPackages),
+		fmt.Sprintf("flaky=%d", len(currentFlakyTests(data, history))),
+		"duration=" + strconv.FormatFloat(math.Round(duration*10)/10, 'f', -1, 64) + "s",
+		"status=" + gatedStatus(data, gates),
+		"report=" + summaryValue(report),
+	}
+	if data.RunID != "" {
+		fields = append(fields, "run_id="+summaryValue(data.RunID))
+	}
+	return "gotest-report: " + strings.Join(fields, " ")
+}
+
+// summaryValue quotes values that would otherwise break the key=value pairs
+func summaryValue(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
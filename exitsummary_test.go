@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExitSummaryLine(t *testing.T) {
+	data, err := processTestEvents(strings.NewReader(`{"Time":"2024-03-20T15:30:00Z","Action":"run","Package":"pkg","Test":"TestA"}
+{"Time":"2024-03-20T15:30:02Z","Action":"fail","Package":"pkg","Test":"TestA","Elapsed":2}
+{"Time":"2024-03-20T15:30:02Z","Action":"run","Package":"pkg","Test":"TestB"}
+{"Time":"2024-03-20T15:30:03.25Z","Action":"pass","Package":"pkg","Test":"TestB","Elapsed":1.25}
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	history := []*RunRecord{{Timestamp: time.Now(), Tests: map[string]TestRecord{testKey("pkg", "TestA"): {Package: "pkg", Status: "PASS"}}}}
+
+	expected := "gotest-report: total=2 passed=1 failed=1 skipped=0 failed_packages=0 flaky=1 duration=3.3s status=FAILED report=report.md"
+	if line := exitSummaryLine(data, nil, history, "report.md"); line != expected {
+		t.Errorf("Unexpected summary line\nexpected: %s\ngot:      %s", expected, line)
+	}
+
+	data.RunID = "ci 42"
+	if line := exitSummaryLine(data, nil, nil, "my report.md"); !strings.Contains(line, `flaky=0`) ||
+		!strings.Contains(line, `report="my report.md" run_id="ci 42"`) {
+		t.Errorf("Expected values with spaces to be quoted, got %s", line)
+	}
+}
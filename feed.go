@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"time"
+)
+
+// maxFeedEntries is the number of runs kept in the Atom feed
+const maxFeedEntries = 50
+
+// atomFeed is an Atom 1.0 feed of test runs
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"` // Required by RFC 4287 unless every entry has one
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+// feedAuthor names the feed after the repository being tested, or the tool
+// outside of CI
+func feedAuthor() string {
+	if repo := firstEnv("GITHUB_REPOSITORY", "CI_PROJECT_PATH"); repo != "" {
+		return repo
+	}
+	return "gotest-report"
+}
+
+// atomEntry is a single run in the feed
+type atomEntry struct {
+	Title   string    `xml:"title"`
+	ID      string    `xml:"id"`
+	Updated string    `xml:"updated"`
+	Link    *atomLink `xml:"link,omitempty"`
+	Summary string    `xml:"summary"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+// runFeedEntry describes a run as an Atom entry
+func runFeedEntry(data *ReportData, gates []GateResult, reportURL string, now time.Time) atomEntry {
+	entry := atomEntry{
+		Title:   fmt.Sprintf("%s: %d tests, %d failed", gatedStatus(data, gates), data.TotalTests, data.FailedTests),
+		ID:      fmt.Sprintf("urn:gotest-report:run:%d", now.UnixNano()),
+		Updated: now.UTC().Format(time.RFC3339),
+		Summary: fmt.Sprintf("Total: %d, Passed: %d, Failed: %d, Skipped: %d, Duration: %.2fs",
+			data.TotalTests, data.PassedTests, data.FailedTests, data.SkippedTests, data.TotalDuration),
+	}
+	if data.RunID != "" {
+		entry.ID = "urn:gotest-report:run:" + data.RunID
+	}
+	if reportURL != "" {
+		entry.Link = &atomLink{Href: reportURL}
+	}
+	return entry
+}
+
+// updateAtomFeed adds the run to the feed at path, keeping the newest
+// maxFeedEntries entries. The feed file itself is the only state, so no
+// infrastructure beyond somewhere to publish it is needed.
+func updateAtomFeed(path string, data *ReportData, gates []GateResult, reportURL string, now time.Time) error {
+	feed := atomFeed{
+		Title: "Go test runs",
+		ID:    "urn:gotest-report:feed",
+	}
+
+	if content, err := os.ReadFile(path); err == nil {
+		if err := xml.Unmarshal(content, &feed); err != nil {
+			return fmt.Errorf("error parsing existing feed: %v", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if feed.Author.Name == "" {
+		feed.Author.Name = feedAuthor()
+	}
+
+	feed.Entries = append([]atomEntry{runFeedEntry(data, gates, reportURL, now)}, feed.Entries...)
+	if len(feed.Entries) > maxFeedEntries {
+		feed.Entries = feed.Entries[:maxFeedEntries]
+	}
+	feed.Updated = now.UTC().Format(time.RFC3339)
+
+	out, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append([]byte(xml.Header), append(out, '\n')...), 0o644)
+}
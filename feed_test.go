@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUpdateAtomFeed(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "example/shop")
+	path := filepath.Join(t.TempDir(), "feed.xml")
+	start := time.Date(2024, 3, 20, 15, 30, 0, 0, time.UTC)
+
+	passing := &ReportData{TotalTests: 2, PassedTests: 2, TotalDuration: 1.5}
+	failing := &ReportData{TotalTests: 2, PassedTests: 1, FailedTests: 1, TotalDuration: 1.5}
+
+	if err := updateAtomFeed(path, passing, nil, "", start); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := updateAtomFeed(path, failing, nil, "https://example.com/report.md", start.Add(time.Hour)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var feed atomFeed
+	if err := xml.Unmarshal(content, &feed); err != nil {
+		t.Fatalf("Feed is not valid XML: %v", err)
+	}
+
+	if len(feed.Entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(feed.Entries))
+	}
+	if feed.Entries[0].Title != "FAILED: 2 tests, 1 failed" {
+		t.Errorf("Newest entry should come first, got %q", feed.Entries[0].Title)
+	}
+	if feed.Entries[0].Link == nil || feed.Entries[0].Link.Href != "https://example.com/report.md" {
+		t.Errorf("Expected report link on newest entry, got %+v", feed.Entries[0].Link)
+	}
+	if feed.Entries[1].Title != "PASSED: 2 tests, 0 failed" {
+		t.Errorf("Unexpected older entry title %q", feed.Entries[1].Title)
+	}
+	if feed.Updated != "2024-03-20T16:30:00Z" {
+		t.Errorf("Feed updated: got %q", feed.Updated)
+	}
+	if feed.Author.Name != "example/shop" {
+		t.Errorf("Expected the repository as the feed author, got %q", feed.Author.Name)
+	}
+
+	for i := 0; i < maxFeedEntries; i++ {
+		updateAtomFeed(path, passing, nil, "", start.Add(time.Duration(i+2)*time.Hour))
+	}
+	content, _ = os.ReadFile(path)
+	feed = atomFeed{}
+	xml.Unmarshal(content, &feed)
+	if len(feed.Entries) != maxFeedEntries {
+		t.Errorf("Feed should be capped at %d entries, got %d", maxFeedEntries, len(feed.Entries))
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// flakyAlertFormats are the payload formats of -flaky-alert-format
+var flakyAlertFormats = []string{"slack", "teams", "json"}
+
+// FlakyAlertConfig is the flaky_alerts section of the config file
+type FlakyAlertConfig struct {
+	Threshold float64           `yaml:"threshold"` // Flip rate in percent that triggers an alert
+	Format    string            `yaml:"format"`    // One of flakyAlertFormats
+	Webhooks  map[string]string `yaml:"webhooks"`  // CODEOWNERS owner to webhook URL, "*" for everyone else
+}
+
+// FlakyScore is the flakiness of a test across the runs of the history
+type FlakyScore struct {
+	Name    string  `json:"name"`
+	Package string  `json:"package"`
+	Score   float64 `json:"score"` // Percentage of consecutive runs that changed between pass and fail
+	Runs    int     `json:"runs"`  // Runs the test passed or failed in
+}
+
+// FlakyAlert groups the tests that crossed the threshold by their owners
+type FlakyAlert struct {
+	Owners    []string     `json:"owners"` // From CODEOWNERS, empty when nobody owns the tests
+	Threshold float64      `json:"threshold"`
+	Tests     []FlakyScore `json:"tests"`
+	RunID     string       `json:"run_id,omitempty"` // The run the tests crossed the threshold in
+}
+
+// flakinessScores returns the flip rate of every test that passed or failed
+// in at least two runs. Runs must be ordered oldest first.
+func flakinessScores(runs []*RunRecord) map[string]FlakyScore {
+	type observed struct {
+		name  string
+		pkg   string
+		last  string
+		runs  int
+		flips int
+	}
+	tests := make(map[string]*observed)
+	for _, run := range runs {
+		for name, test := range run.Tests {
+			if test.Status != "PASS" && test.Status != "FAIL" {
+				continue
+			}
+			o, ok := tests[name]
+			if !ok {
+				o = &observed{}
+				tests[name] = o
+			}
+			if o.runs > 0 && o.last != test.Status {
+				o.flips++
+			}
+			o.name, o.pkg, o.last = recordTestName(name, test), test.Package, test.Status
+			o.runs++
+		}
+	}
+
+	scores := make(map[string]FlakyScore)
+	for name, o := range tests {
+		if o.runs < 2 {
+			continue
+		}
+		scores[name] = FlakyScore{
+			Name: o.name, Package: o.pkg, Runs: o.runs,
+			Score: float64(o.flips) / float64(o.runs-1) * 100,
+		}
+	}
+	return scores
+}
+
+// flakyThresholdBreaches returns the tests whose flakiness score reaches the
+// threshold with the current run but stayed below it over the history alone,
+// so a test alerts once when it becomes flaky rather than on every run
+func flakyThresholdBreaches(data *ReportData, history []*RunRecord, threshold float64) []FlakyScore {
+	if len(history) == 0 {
+		return nil
+	}
+	before := flakinessScores(history)
+	runs := append(append([]*RunRecord(nil), history...), newRunRecord(data, history[len(history)-1].Timestamp))
+
+	var breaches []FlakyScore
+	for name, score := range flakinessScores(runs) {
+		if score.Score == 0 || score.Score < threshold {
+			continue
+		}
+		if previous, ok := before[name]; ok && previous.Score >= threshold {
+			continue
+		}
+		breaches = append(breaches, score)
+	}
+	sort.Slice(breaches, func(i, j int) bool {
+		if breaches[i].Score != breaches[j].Score {
+			return breaches[i].Score > breaches[j].Score
+		}
+		return breaches[i].Name < breaches[j].Name
+	})
+	return breaches
+}
+
+// groupFlakyAlerts groups breaches by the CODEOWNERS owners of their package
+// directories, keeping the order of the breaches within each group
+func groupFlakyAlerts(breaches []FlakyScore, threshold float64, owners *Codeowners, resolver sourceResolver) []FlakyAlert {
+	var alerts []FlakyAlert
+	index := make(map[string]int)
+	for _, breach := range breaches {
+		testOwners := owners.OwnersOf(resolver.packageDir(breach.Package))
+		key := strings.Join(testOwners, " ")
+		i, ok := index[key]
+		if !ok {
+			i = len(alerts)
+			index[key] = i
+			alerts = append(alerts, FlakyAlert{Owners: testOwners, Threshold: threshold})
+		}
+		alerts[i].Tests = append(alerts[i].Tests, breach)
+	}
+	return alerts
+}
+
+// flakyAlertURLs returns the webhooks an alert is routed to: those of its
+// owners, or the "*" webhook when none of them has one
+func flakyAlertURLs(alert FlakyAlert, webhooks map[string]string) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	for _, owner := range alert.Owners {
+		if url := webhooks[owner]; url != "" && !seen[url] {
+			seen[url] = true
+			urls = append(urls, url)
+		}
+	}
+	if len(urls) == 0 && webhooks["*"] != "" {
+		urls = append(urls, webhooks["*"])
+	}
+	return urls
+}
+
+// flakyAlertText returns the title and the test list of an alert in mrkdwn,
+// which Teams renders as Markdown too
+func flakyAlertText(alert FlakyAlert) (string, string) {
+	title := fmt.Sprintf("%d test(s) crossed the flakiness threshold of %s%%",
+		len(alert.Tests), strconv.FormatFloat(alert.Threshold, 'f', -1, 64))
+	var lines []string
+	for _, test := range alert.Tests {
+		lines = append(lines, fmt.Sprintf("• `%s` (%s) flipped in %.0f%% of %d runs",
+			escapeSlack(test.Name), escapeSlack(test.Package), test.Score, test.Runs))
+	}
+	if len(alert.Owners) > 0 {
+		lines = append(lines, "Owners: "+escapeSlack(strings.Join(alert.Owners, ", ")))
+	}
+	if alert.RunID != "" {
+		lines = append(lines, "Run ID: "+escapeSlack(alert.RunID))
+	}
+	return title, strings.Join(lines, "\n")
+}
+
+// teamsMessage is a Microsoft Teams incoming webhook payload
+type teamsMessage struct {
+	Type    string `json:"@type"`
+	Context string `json:"@context"`
+	Summary string `json:"summary"`
+	Title   string `json:"title"`
+	Text    string `json:"text"`
+}
+
+// buildFlakyAlertPayload builds the webhook payload of an alert
+func buildFlakyAlertPayload(alert FlakyAlert, format string) interface{} {
+	title, text := flakyAlertText(alert)
+	switch format {
+	case "slack":
+		return slackMessage{
+			Text: title,
+			Blocks: []slackBlock{
+				{Type: "header", Text: &slackText{Type: "plain_text", Text: "⚠️ " + title}},
+				{Type: "section", Text: &slackText{Type: "mrkdwn", Text: text}},
+			},
+		}
+	case "teams":
+		// Teams needs two spaces before a line break within a paragraph
+		return teamsMessage{
+			Type: "MessageCard", Context: "https://schema.org/extensions",
+			Summary: title, Title: title, Text: strings.ReplaceAll(text, "\n", "  \n"),
+		}
+	}
+	return alert
+}
+
+// sendFlakyAlerts posts the alerts to their webhooks and returns the number
+// of messages sent
+func sendFlakyAlerts(alerts []FlakyAlert, webhooks map[string]string, format string) (int, error) {
+	sent := 0
+	for _, alert := range alerts {
+		for _, url := range flakyAlertURLs(alert, webhooks) {
+			if err := postWebhook(url, buildFlakyAlertPayload(alert, format)); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+	}
+	return sent, nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// statusPackage is the package statusRuns records a test in
+func statusPackage(name string) string {
+	return "example.com/m/" + strings.ToLower(name)
+}
+
+// statusRuns builds history runs from one status string per run, e.g. "PF"
+// for a test passing in the first run and failing in the second
+func statusRuns(tests map[string]string) []*RunRecord {
+	var runs []*RunRecord
+	for name, statuses := range tests {
+		for i, status := range statuses {
+			for len(runs) <= i {
+				runs = append(runs, &RunRecord{Timestamp: time.Unix(int64(len(runs)), 0), Tests: map[string]TestRecord{}})
+			}
+			full := map[rune]string{'P': "PASS", 'F': "FAIL", 'S': "SKIP"}[status]
+			runs[i].Tests[testKey(statusPackage(name), name)] = TestRecord{Package: statusPackage(name), Status: full}
+		}
+	}
+	return runs
+}
+
+func TestFlakinessScores(t *testing.T) {
+	scores := flakinessScores(statusRuns(map[string]string{
+		"TestStable":   "PPPP",
+		"TestBroken":   "PPFFF",
+		"TestFlipping": "PFPF",
+		"TestSkipped":  "PSSF",
+		"TestOnce":     "F",
+	}))
+
+	expected := map[string]float64{"TestStable": 0, "TestBroken": 25, "TestFlipping": 100, "TestSkipped": 100}
+	for name, score := range expected {
+		if got := scores[testKey(statusPackage(name), name)].Score; got != score {
+			t.Errorf("%s: expected score %.1f, got %.1f", name, score, got)
+		}
+	}
+	if skipped := scores[testKey(statusPackage("TestSkipped"), "TestSkipped")]; skipped.Runs != 2 || skipped.Name != "TestSkipped" {
+		t.Errorf("Skipped runs should not count, got %+v", skipped)
+	}
+	if _, ok := scores[testKey(statusPackage("TestOnce"), "TestOnce")]; ok {
+		t.Error("A test with a single run should not have a score")
+	}
+}
+
+func TestFlakyThresholdBreaches(t *testing.T) {
+	history := statusRuns(map[string]string{
+		"TestNewlyFlaky":   "PPPF",
+		"TestAlreadyFlaky": "PFPF",
+		"TestStable":       "PPPP",
+	})
+	data := &ReportData{Results: map[string]*TestResult{
+		"TestNewlyFlaky":   {Name: "TestNewlyFlaky", Package: statusPackage("TestNewlyFlaky"), Status: "PASS"},
+		"TestAlreadyFlaky": {Name: "TestAlreadyFlaky", Package: statusPackage("TestAlreadyFlaky"), Status: "PASS"},
+		"TestStable":       {Name: "TestStable", Package: statusPackage("TestStable"), Status: "PASS"},
+	}}
+
+	breaches := flakyThresholdBreaches(data, history, 40)
+	if len(breaches) != 1 || breaches[0].Name != "TestNewlyFlaky" || breaches[0].Score != 50 {
+		t.Fatalf("Expected only TestNewlyFlaky to cross the threshold, got %+v", breaches)
+	}
+	if got := flakyThresholdBreaches(data, nil, 40); got != nil {
+		t.Errorf("Expected no breaches without history, got %+v", got)
+	}
+}
+
+func TestGroupFlakyAlerts(t *testing.T) {
+	owners := parseCodeowners("* @org/core\n/payments/ @org/payments\n/legacy/\n")
+	resolver := sourceResolver{ModulePath: "example.com/m"}
+	breaches := []FlakyScore{
+		{Name: "TestCharge", Package: "example.com/m/payments"},
+		{Name: "TestParse", Package: "example.com/m"},
+		{Name: "TestRefund", Package: "example.com/m/payments/refunds"},
+		{Name: "TestOld", Package: "example.com/m/legacy"},
+	}
+
+	alerts := groupFlakyAlerts(breaches, 30, owners, resolver)
+	var got [][]string
+	for _, alert := range alerts {
+		group := append([]string{strings.Join(alert.Owners, " ") + ":"}, nil...)
+		for _, test := range alert.Tests {
+			group = append(group, test.Name)
+		}
+		got = append(got, group)
+	}
+	expected := [][]string{{"@org/payments:", "TestCharge", "TestRefund"}, {"@org/core:", "TestParse"}, {":", "TestOld"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected groups %v, got %v", expected, got)
+	}
+
+	webhooks := map[string]string{"@org/payments": "https://payments", "*": "https://default"}
+	if urls := flakyAlertURLs(alerts[0], webhooks); !reflect.DeepEqual(urls, []string{"https://payments"}) {
+		t.Errorf("Expected the owner webhook, got %v", urls)
+	}
+	if urls := flakyAlertURLs(alerts[2], webhooks); !reflect.DeepEqual(urls, []string{"https://default"}) {
+		t.Errorf("Expected the default webhook, got %v", urls)
+	}
+	if urls := flakyAlertURLs(alerts[1], map[string]string{"@org/payments": "https://payments"}); urls != nil {
+		t.Errorf("Expected no webhook, got %v", urls)
+	}
+}
+
+func TestSendFlakyAlerts(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		encoded, _ := json.Marshal(payload)
+		bodies = append(bodies, r.URL.Path+" "+string(encoded))
+	}))
+	defer server.Close()
+
+	alert := FlakyAlert{
+		Owners:    []string{"@org/payments"},
+		Threshold: 30,
+		Tests:     []FlakyScore{{Name: "TestCharge/<card>", Package: "example.com/m/payments", Score: 50, Runs: 5}},
+	}
+	webhooks := map[string]string{"@org/payments": server.URL + "/payments"}
+
+	for _, tt := range []struct {
+		format   string
+		expected []string
+	}{
+		{"slack", []string{"/payments ", `"text":"1 test(s) crossed the flakiness threshold of 30%"`, "• `TestCharge/\\u0026lt;card\\u0026gt;` (example.com/m/payments) flipped in 50% of 5 runs"}},
+		{"teams", []string{`"@type":"MessageCard"`, `runs  \nOwners: @org/payments`}},
+		{"json", []string{`"owners":["@org/payments"]`, `"score":50`}},
+	} {
+		bodies = nil
+		sent, err := sendFlakyAlerts([]FlakyAlert{alert}, webhooks, tt.format)
+		if err != nil || sent != 1 || len(bodies) != 1 {
+			t.Fatalf("%s: expected one alert sent, got %d, %v", tt.format, sent, err)
+		}
+		for _, expected := range tt.expected {
+			if !strings.Contains(bodies[0], expected) {
+				t.Errorf("%s: expected %q in %s", tt.format, expected, bodies[0])
+			}
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// formatOption is an option of a single output, given as -FORMAT.NAME on the
+// command line or under formats.FORMAT.NAME in the config file, so options of
+// different formats cannot clash
+type formatOption struct {
+	Format   string
+	Name     string
+	Default  string
+	Usage    string
+	Values   []string                 // Allowed values, any when empty
+	Validate func(value string) error // Optional check of the value
+}
+
+// Key is the option as named on the command line, without the dash
+func (o formatOption) Key() string {
+	return o.Format + "." + o.Name
+}
+
+// formatOptions are the namespaced options of every format
+var formatOptions = []formatOption{
+	{Format: "markdown", Name: "title", Default: "Test Summary Report", Usage: "Heading of the Markdown report"},
+	{Format: "html", Name: "title", Default: "Test Summary Report", Usage: "Heading and page title of the interactive HTML report"},
+	{Format: "html", Name: "theme", Default: "light", Usage: "Color theme of the interactive HTML report, auto follows the browser", Values: []string{"light", "dark", "auto"}},
+	{Format: "json", Name: "indent", Default: "2", Usage: "Spaces to indent the JSON report by, 0 writes it on a single line", Validate: func(value string) error {
+		if n, err := strconv.Atoi(value); err != nil || n < 0 || n > 8 {
+			return fmt.Errorf("expected a number of spaces from 0 to 8")
+		}
+		return nil
+	}},
+	{Format: "slack", Name: "channel", Usage: "Channel to post the Slack notification to instead of the webhook's default"},
+	{Format: "slack", Name: "username", Usage: "Sender name of the Slack notification instead of the webhook's default"},
+}
+
+// FormatOptions are the resolved values of the format options by key, e.g.
+// "html.theme"
+type FormatOptions map[string]string
+
+// Get returns the value of an option, or its default when it was not set
+func (f FormatOptions) Get(key string) string {
+	if value, ok := f[key]; ok {
+		return value
+	}
+	for _, opt := range formatOptions {
+		if opt.Key() == key {
+			return opt.Default
+		}
+	}
+	return ""
+}
+
+// formatNames returns the formats that have options, sorted
+func formatNames() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, opt := range formatOptions {
+		if !seen[opt.Format] {
+			seen[opt.Format] = true
+			names = append(names, opt.Format)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// optionNames returns the options of a format, sorted
+func optionNames(format string) []string {
+	var names []string
+	for _, opt := range formatOptions {
+		if opt.Format == format {
+			names = append(names, opt.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateFormatConfig checks that the formats section of the config file
+// only names known formats and options
+func validateFormatConfig(formats map[string]map[string]string) error {
+	for format, options := range formats {
+		names := optionNames(format)
+		if len(names) == 0 {
+			return fmt.Errorf("unknown format %q under formats in config file (supported: %s)", format, strings.Join(formatNames(), ", "))
+		}
+		for name := range options {
+			if !containsString(names, name) {
+				return fmt.Errorf("unknown option %q of format %s in config file (supported: %s)", name, format, strings.Join(names, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// registerFormatFlags defines a -FORMAT.NAME flag for every format option
+func registerFormatFlags(fs *flag.FlagSet) map[string]*string {
+	flags := make(map[string]*string, len(formatOptions))
+	for _, opt := range formatOptions {
+		usage := opt.Usage
+		if len(opt.Values) > 0 {
+			usage += " (supported: " + strings.Join(opt.Values, ", ") + ")"
+		}
+		flags[opt.Key()] = fs.String(opt.Key(), opt.Default, usage)
+	}
+	return flags
+}
+
+// resolveFormatOptions takes every option from its flag when given, else from
+// the config file, else its default, and validates the values
+func resolveFormatOptions(fs *flag.FlagSet, flags map[string]*string, config map[string]map[string]string) (FormatOptions, error) {
+	resolved := make(FormatOptions, len(formatOptions))
+	for _, opt := range formatOptions {
+		value, source := opt.Default, "-"+opt.Key()
+		if configured, ok := config[opt.Format][opt.Name]; ok {
+			value, source = configured, "formats."+opt.Key()+" in config file"
+		}
+		if flagSet(fs, opt.Key()) {
+			value, source = *flags[opt.Key()], "-"+opt.Key()
+		}
+		if len(opt.Values) > 0 && !containsString(opt.Values, value) {
+			return nil, fmt.Errorf("invalid %s value %q (supported: %s)", source, value, strings.Join(opt.Values, ", "))
+		}
+		if opt.Validate != nil {
+			if err := opt.Validate(value); err != nil {
+				return nil, fmt.Errorf("invalid %s value %q: %v", source, value, err)
+			}
+		}
+		resolved[opt.Key()] = value
+	}
+	return resolved, nil
+}
+
+// unusedFormatFlags returns the format options given on the command line for
+// formats the run does not produce
+func unusedFormatFlags(fs *flag.FlagSet, produced map[string]bool) []string {
+	var unused []string
+	for _, opt := range formatOptions {
+		if flagSet(fs, opt.Key()) && !produced[opt.Format] {
+			unused = append(unused, "-"+opt.Key())
+		}
+	}
+	return unused
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
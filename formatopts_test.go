@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveFormatOptions(t *testing.T) {
+	config := map[string]map[string]string{"html": {"theme": "dark", "title": "Nightly"}}
+	tests := []struct {
+		args    []string
+		key     string
+		want    string
+		wantErr string
+	}{
+		{nil, "json.indent", "2", ""},
+		{nil, "html.theme", "dark", ""},
+		{[]string{"-html.theme", "auto"}, "html.theme", "auto", ""},
+		{[]string{"-html.theme", "blue"}, "", "", `invalid -html.theme value "blue" (supported: light, dark, auto)`},
+		{[]string{"-json.indent", "-1"}, "", "", `invalid -json.indent value "-1": expected a number of spaces from 0 to 8`},
+	}
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags := registerFormatFlags(fs)
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		formats, err := resolveFormatOptions(fs, flags, config)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("%v: expected error %q, got %v", tt.args, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil || formats.Get(tt.key) != tt.want {
+			t.Errorf("%v: expected %s=%s, got %q, %v", tt.args, tt.key, tt.want, formats.Get(tt.key), err)
+		}
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := registerFormatFlags(fs)
+	_, err := resolveFormatOptions(fs, flags, map[string]map[string]string{"html": {"theme": "blue"}})
+	if err == nil || !strings.Contains(err.Error(), "formats.html.theme in config file") {
+		t.Errorf("Expected the config file to be named in the error, got %v", err)
+	}
+
+	fs.Parse([]string{"-slack.channel", "#ci", "-html.theme", "dark"})
+	if unused := unusedFormatFlags(fs, map[string]bool{"html": true}); strings.Join(unused, ",") != "-slack.channel" {
+		t.Errorf("Expected -slack.channel to be unused, got %v", unused)
+	}
+}
+
+func TestValidateFormatConfig(t *testing.T) {
+	if err := validateFormatConfig(map[string]map[string]string{"html": {"theme": "dark"}}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	err := validateFormatConfig(map[string]map[string]string{"junit": {"classname": "x"}})
+	if err == nil || !strings.Contains(err.Error(), `unknown format "junit"`) {
+		t.Errorf("Expected an unknown format error, got %v", err)
+	}
+	err = validateFormatConfig(map[string]map[string]string{"html": {"colour": "x"}})
+	if err == nil || !strings.Contains(err.Error(), `unknown option "colour" of format html in config file (supported: theme, title)`) {
+		t.Errorf("Expected an unknown option error, got %v", err)
+	}
+}
+
+func TestFormatOptionsInReports(t *testing.T) {
+	data, _ := sampleReport("small")
+	opts := ReportOptions{Formats: FormatOptions{"markdown.title": "Nightly | main", "html.title": "Nightly", "html.theme": "dark", "json.indent": "0"}}
+
+	if report := renderMarkdownReport(data, opts); !strings.HasPrefix(report, "# Nightly \\| main\n") {
+		t.Errorf("Expected the custom Markdown title, got:\n%s", report[:40])
+	}
+	if report, _ := renderJSONReport(data, opts, time.Now()); strings.Count(report, "\n") != 1 {
+		t.Errorf("Expected the JSON report on a single line with -json.indent 0")
+	}
+	page, err := renderInteractiveHTML(data, opts, time.Now())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, expected := range []string{`<html lang="en" data-theme="dark">`, "<title>Nightly - ", "<h1>Nightly <span"} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("Expected the page to contain %q", expected)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// defaultCoverageFunctions is the number of functions listed in the Least
+// Covered Functions section
+const defaultCoverageFunctions = 10
+
+// FuncCoverage is the statement coverage of a single function, as printed by
+// go tool cover -func
+type FuncCoverage struct {
+	File       string // File name from the coverprofile
+	Line       int
+	Name       string // Function name, prefixed with the receiver type for methods
+	Statements int
+	Covered    int
+}
+
+// Percent returns the covered statement percentage of the function
+func (f *FuncCoverage) Percent() float64 {
+	if f.Statements == 0 {
+		return 0
+	}
+	return float64(f.Covered) / float64(f.Statements) * 100
+}
+
+// FunctionCoverageReport lists the least covered functions of the run
+type FunctionCoverageReport struct {
+	Scope     string // Description of the packages the functions were taken from
+	Functions []*FuncCoverage
+}
+
+// functionCoverage attributes the blocks of a file to the functions declared
+// in its source
+func functionCoverage(file *FileCoverage, source []byte) ([]*FuncCoverage, error) {
+	fset := token.NewFileSet()
+	parsed, err := parser.ParseFile(fset, file.Name, source, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var funcs []*FuncCoverage
+	for _, decl := range parsed.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		start, end := fset.Position(fn.Pos()), fset.Position(fn.End())
+		f := &FuncCoverage{File: file.Name, Line: start.Line, Name: funcName(fn)}
+		for _, block := range file.Blocks {
+			inside := (block.StartLine > start.Line || block.StartLine == start.Line && block.StartCol >= start.Column) &&
+				(block.EndLine < end.Line || block.EndLine == end.Line && block.EndCol <= end.Column)
+			if !inside {
+				continue
+			}
+			f.Statements += block.Statements
+			if block.Count > 0 {
+				f.Covered += block.Statements
+			}
+		}
+		funcs = append(funcs, f)
+	}
+	return funcs, nil
+}
+
+// funcName returns the name of a function, e.g. "Parse" or "*Parser.Next"
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	var recv func(expr ast.Expr) string
+	recv = func(expr ast.Expr) string {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			return "*" + recv(t.X)
+		case *ast.IndexExpr:
+			return recv(t.X)
+		case *ast.IndexListExpr:
+			return recv(t.X)
+		case *ast.Ident:
+			return t.Name
+		}
+		return "?"
+	}
+	return recv(fn.Recv.List[0].Type) + "." + fn.Name.Name
+}
+
+// loadFunctionCoverage reads the sources of the profiled files within the
+// given packages, or all packages when nil, and returns the coverage of their
+// functions. Files that cannot be found in the repository are skipped.
+func loadFunctionCoverage(data *CoverageData, resolver sourceResolver, packages map[string]bool) []*FuncCoverage {
+	var funcs []*FuncCoverage
+	for _, file := range data.SortedFiles() {
+		pkg := path.Dir(file.Name)
+		if packages != nil && !packages[pkg] {
+			continue
+		}
+		name := filepath.FromSlash(resolver.testFile(pkg, path.Base(file.Name)))
+		if resolver.Root != "" {
+			name = filepath.Join(resolver.Root, name)
+		}
+		source, err := os.ReadFile(name)
+		if err != nil {
+			continue
+		}
+		found, err := functionCoverage(file, source)
+		if err != nil {
+			continue
+		}
+		funcs = append(funcs, found...)
+	}
+	return funcs
+}
+
+// leastCovered returns up to n functions that are not fully covered, least
+// covered first and the most uncovered statements breaking ties
+func leastCovered(funcs []*FuncCoverage, n int) []*FuncCoverage {
+	var candidates []*FuncCoverage
+	for _, f := range funcs {
+		if f.Statements > 0 && f.Covered < f.Statements {
+			candidates = append(candidates, f)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Percent() != b.Percent() {
+			return a.Percent() < b.Percent()
+		}
+		return a.Statements-a.Covered > b.Statements-b.Covered
+	})
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	return candidates
+}
+
+// changedPackages returns the import paths of the packages with Go files
+// changed since ref, as reported by git diff ref...HEAD
+func changedPackages(ref string, resolver sourceResolver) (map[string]bool, error) {
+	cmd := exec.Command("git", "diff", "--name-only", ref+"...HEAD")
+	cmd.Dir = resolver.Root
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error listing files changed since %s: %v", ref, err)
+	}
+	packages := make(map[string]bool)
+	for _, file := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if !strings.HasSuffix(file, ".go") {
+			continue
+		}
+		dir := path.Dir(file)
+		switch {
+		case resolver.ModulePath == "":
+			packages[dir] = true
+		case dir == ".":
+			packages[resolver.ModulePath] = true
+		default:
+			packages[resolver.ModulePath+"/"+dir] = true
+		}
+	}
+	return packages, nil
+}
+
+// writeFunctionCoverageSection renders the least covered functions
+func writeFunctionCoverageSection(sb *strings.Builder, report *FunctionCoverageReport) {
+	if report == nil || len(report.Functions) == 0 {
+		return
+	}
+
+	sb.WriteString("## Least Covered Functions\n\n")
+	sb.WriteString(fmt.Sprintf("The %d least covered functions %s.\n\n", len(report.Functions), report.Scope))
+	sb.WriteString("| Function | File | Coverage | Uncovered Statements |\n")
+	sb.WriteString("| -------- | ---- | -------- | -------------------- |\n")
+	for _, f := range report.Functions {
+		sb.WriteString(fmt.Sprintf("| %s | %s:%d | %.1f%% | %d of %d |\n",
+			codeSpan(f.Name), escapeMarkdown(f.File), f.Line, f.Percent(), f.Statements-f.Covered, f.Statements))
+	}
+	sb.WriteString("\n")
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadFunctionCoverage(t *testing.T) {
+	root := t.TempDir()
+	source := `package calc
+
+type Calc struct{}
+
+func Add(a, b int) int {
+	return a + b
+}
+
+func (c *Calc) Div(a, b int) int {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
+
+func Unused() {
+	println("never")
+}
+`
+	if err := os.MkdirAll(filepath.Join(root, "calc"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "calc", "calc.go"), []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	profile := `mode: set
+example.com/repo/calc/calc.go:5.24,7.2 1 1
+example.com/repo/calc/calc.go:9.34,10.12 1 1
+example.com/repo/calc/calc.go:10.12,12.3 1 0
+example.com/repo/calc/calc.go:13.2,13.14 1 1
+example.com/repo/calc/calc.go:16.15,18.2 1 0
+example.com/repo/other/missing.go:3.10,5.2 4 0
+`
+	coverage, err := parseCoverProfile(strings.NewReader(profile))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resolver := sourceResolver{ModulePath: "example.com/repo", Root: root}
+
+	funcs := loadFunctionCoverage(coverage, resolver, nil)
+	expected := []FuncCoverage{
+		{File: "example.com/repo/calc/calc.go", Line: 5, Name: "Add", Statements: 1, Covered: 1},
+		{File: "example.com/repo/calc/calc.go", Line: 9, Name: "*Calc.Div", Statements: 3, Covered: 2},
+		{File: "example.com/repo/calc/calc.go", Line: 16, Name: "Unused", Statements: 1, Covered: 0},
+	}
+	if len(funcs) != len(expected) {
+		t.Fatalf("Expected %d functions, got %d", len(expected), len(funcs))
+	}
+	for i := range expected {
+		if *funcs[i] != expected[i] {
+			t.Errorf("Function %d: expected %+v, got %+v", i, expected[i], *funcs[i])
+		}
+	}
+
+	least := leastCovered(funcs, 5)
+	if len(least) != 2 || least[0].Name != "Unused" || least[1].Name != "*Calc.Div" {
+		t.Errorf("Expected Unused and *Calc.Div as least covered, got %+v", least)
+	}
+
+	if funcs := loadFunctionCoverage(coverage, resolver, map[string]bool{"example.com/repo/other": true}); len(funcs) != 0 {
+		t.Errorf("Expected no functions outside the changed packages, got %+v", funcs)
+	}
+
+	var sb strings.Builder
+	writeFunctionCoverageSection(&sb, &FunctionCoverageReport{Scope: "in all packages", Functions: least})
+	if !strings.Contains(sb.String(), "| `*Calc.Div` | example.com/repo/calc/calc.go:9 | 66.7% | 1 of 3 |") {
+		t.Errorf("Unexpected section:\n%s", sb.String())
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FuzzResult summarizes a fuzz target run with go test -fuzz
+type FuzzResult struct {
+	Name           string       `json:"name"`
+	Package        string       `json:"package"`
+	Status         string       `json:"status"`  // "PASS" when fuzzing stopped without a crasher, "FAIL" otherwise
+	Elapsed        float64      `json:"elapsed"` // Seconds spent fuzzing, from the last progress line
+	Execs          int64        `json:"execs"`
+	ExecsPerSec    int64        `json:"execs_per_sec"`
+	NewInteresting int          `json:"new_interesting"` // Corpus entries added by this run
+	CorpusTotal    int          `json:"corpus_total"`
+	Workers        int          `json:"workers,omitempty"`
+	Crasher        *FuzzCrasher `json:"crasher,omitempty"`
+}
+
+// FuzzCrasher is a failing input found and minimized by the fuzzer
+type FuzzCrasher struct {
+	Message   string `json:"message"`         // The failure reported for the input
+	InputFile string `json:"input_file"`      // testdata/fuzz/<Target>/<hash>, relative to the package
+	Rerun     string `json:"rerun,omitempty"` // Command reproducing the failure
+	Input     string `json:"input,omitempty"` // Content of the input file when it could be read
+}
+
+var (
+	fuzzProgressLine = regexp.MustCompile(`^fuzz: elapsed: (\S+), execs: (\d+) \((\d+)/sec\), new interesting: (\d+) \(total: (\d+)\)`)
+	fuzzWorkersLine  = regexp.MustCompile(`^fuzz: elapsed: \S+, gathering baseline coverage: .* now fuzzing with (\d+) workers`)
+	fuzzInputLine    = regexp.MustCompile(`^Failing input written to (\S+)$`)
+)
+
+// parseFuzzOutput extracts fuzzing progress and crashers from the output of
+// a fuzz target. It returns nil when the output shows no fuzzing, e.g. when
+// only the seed corpus ran as ordinary tests.
+func parseFuzzOutput(name, pkg string, output []string) *FuzzResult {
+	var fuzz *FuzzResult
+	start := func() {
+		if fuzz == nil {
+			fuzz = &FuzzResult{Name: name, Package: pkg, Status: "PASS"}
+		}
+	}
+	message := ""
+
+	for i, line := range output {
+		trimmed := strings.TrimSpace(line)
+		if m := fuzzWorkersLine.FindStringSubmatch(trimmed); m != nil {
+			start()
+			fuzz.Workers, _ = strconv.Atoi(m[1])
+			continue
+		}
+		if m := fuzzProgressLine.FindStringSubmatch(trimmed); m != nil {
+			start()
+			if elapsed, err := time.ParseDuration(m[1]); err == nil {
+				fuzz.Elapsed = elapsed.Seconds()
+			}
+			fuzz.Execs, _ = strconv.ParseInt(m[2], 10, 64)
+			fuzz.ExecsPerSec, _ = strconv.ParseInt(m[3], 10, 64)
+			fuzz.NewInteresting, _ = strconv.Atoi(m[4])
+			fuzz.CorpusTotal, _ = strconv.Atoi(m[5])
+			continue
+		}
+		if strings.HasPrefix(trimmed, "fuzz: elapsed: ") {
+			// Baseline coverage progress before fuzzing starts
+			start()
+			continue
+		}
+		if m := fuzzInputLine.FindStringSubmatch(trimmed); m != nil {
+			start()
+			fuzz.Status = "FAIL"
+			// Windows prints the path with backslashes
+			fuzz.Crasher = &FuzzCrasher{Message: message, InputFile: strings.ReplaceAll(m[1], `\`, "/")}
+			// "To re-run:" is followed by the command
+			if i+2 < len(output) && strings.TrimSpace(output[i+1]) == "To re-run:" {
+				fuzz.Crasher.Rerun = strings.TrimSpace(output[i+2])
+			}
+			continue
+		}
+		// The last failure message before the input file describes the crash
+		if m := testLogLocation.FindStringSubmatch(line); m != nil && (fuzz == nil || fuzz.Crasher == nil) {
+			message = strings.TrimSpace(m[3])
+		}
+	}
+	return fuzz
+}
+
+// extractFuzzResults finds the fuzz targets that were fuzzed. A target
+// interrupted by -fuzztime or a signal never gets a terminal event, so its
+// UNKNOWN status is resolved from the fuzzing outcome.
+func extractFuzzResults(data *ReportData) []*FuzzResult {
+	var names []string
+	for name, result := range data.Results {
+		if !result.IsSubTest && strings.HasPrefix(result.Name, "Fuzz") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var results []*FuzzResult
+	for _, name := range names {
+		result := data.Results[name]
+		output := result.Output
+		if pkg, ok := data.Packages[result.Package]; ok {
+			// Progress lines can be attributed to the package instead of the target
+			output = append(append([]string(nil), output...), pkg.Output...)
+		}
+		fuzz := parseFuzzOutput(result.Name, result.Package, output)
+		if fuzz == nil {
+			continue
+		}
+		if result.Status == "UNKNOWN" {
+			result.Status = fuzz.Status
+			if fuzz.Elapsed > result.Duration {
+				result.Duration = fuzz.Elapsed
+			}
+		}
+		results = append(results, fuzz)
+	}
+	return results
+}
+
+// fuzzCrashers counts the fuzz targets that found a failing input
+func fuzzCrashers(results []*FuzzResult) int {
+	count := 0
+	for _, fuzz := range results {
+		if fuzz.Crasher != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// loadCrasherInputs reads the minimized inputs of crashers. Input files are
+// relative to the package directory, resolved with the module path.
+func loadCrasherInputs(results []*FuzzResult, resolver sourceResolver) {
+	for _, fuzz := range results {
+		if fuzz.Crasher == nil {
+			continue
+		}
+		file := filepath.FromSlash(resolver.testFile(fuzz.Package, fuzz.Crasher.InputFile))
+		if resolver.Root != "" {
+			file = filepath.Join(resolver.Root, file)
+		}
+		if content, err := os.ReadFile(file); err == nil {
+			fuzz.Crasher.Input = strings.TrimRight(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+		}
+	}
+}
+
+// writeFuzzingSection renders the fuzzing statistics and crashers
+func writeFuzzingSection(sb *strings.Builder, results []*FuzzResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	sb.WriteString("## Fuzzing\n\n")
+	sb.WriteString("| Target | Package | Status | Fuzzing Time | Execs | New Corpus Entries | Corpus Total |\n")
+	sb.WriteString("| ------ | ------- | ------ | ------------ | ----- | ------------------ | ------------ |\n")
+	for _, fuzz := range results {
+		sb.WriteString(fmt.Sprintf("| **%s** | %s | %s %s | %s | %d (%d/s) | %d | %d |\n",
+			escapeMarkdown(fuzz.Name), escapeMarkdown(fuzz.Package), statusEmoji(fuzz.Status), fuzz.Status, formatDuration(fuzz.Elapsed),
+			fuzz.Execs, fuzz.ExecsPerSec, fuzz.NewInteresting, fuzz.CorpusTotal))
+	}
+	sb.WriteString("\n")
+
+	for _, fuzz := range results {
+		if fuzz.Crasher == nil {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("### Crasher in %s\n\n", escapeMarkdown(fuzz.Name)))
+		if fuzz.Crasher.Message != "" {
+			sb.WriteString(fmt.Sprintf("%s\n\n", escapeMarkdown(fuzz.Crasher.Message)))
+		}
+		sb.WriteString(fmt.Sprintf("- **Input:** %s\n", codeSpan(fuzz.Crasher.InputFile)))
+		if fuzz.Crasher.Rerun != "" {
+			sb.WriteString(fmt.Sprintf("- **Re-run:** %s\n", codeSpan(fuzz.Crasher.Rerun)))
+		}
+		sb.WriteString("\n")
+		if fuzz.Crasher.Input != "" {
+			writeOutputBlock(sb, strings.Split(fuzz.Crasher.Input, "\n"))
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFuzzResults(t *testing.T) {
+	input := `{"Action":"run","Package":"example.com/repo/parser","Test":"FuzzParse"}
+{"Action":"output","Package":"example.com/repo/parser","Test":"FuzzParse","Output":"=== RUN   FuzzParse\n"}
+{"Action":"output","Package":"example.com/repo/parser","Test":"FuzzParse","Output":"fuzz: elapsed: 0s, gathering baseline coverage: 0/12 completed\n"}
+{"Action":"output","Package":"example.com/repo/parser","Test":"FuzzParse","Output":"fuzz: elapsed: 0s, gathering baseline coverage: 12/12 completed, now fuzzing with 8 workers\n"}
+{"Action":"output","Package":"example.com/repo/parser","Test":"FuzzParse","Output":"fuzz: elapsed: 3s, execs: 325017 (108336/sec), new interesting: 11 (total: 23)\n"}
+{"Action":"output","Package":"example.com/repo/parser","Test":"FuzzParse","Output":"fuzz: elapsed: 4s, execs: 400000 (100000/sec), new interesting: 12 (total: 24)\n"}
+{"Action":"output","Package":"example.com/repo/parser","Test":"FuzzParse","Output":"--- FAIL: FuzzParse (4.28s)\n"}
+{"Action":"output","Package":"example.com/repo/parser","Test":"FuzzParse","Output":"    --- FAIL: FuzzParse (0.00s)\n"}
+{"Action":"output","Package":"example.com/repo/parser","Test":"FuzzParse","Output":"        parser_test.go:20: unexpected token in \"\\x00\"\n"}
+{"Action":"output","Package":"example.com/repo/parser","Test":"FuzzParse","Output":"    \n"}
+{"Action":"output","Package":"example.com/repo/parser","Test":"FuzzParse","Output":"    Failing input written to testdata/fuzz/FuzzParse/771e938e4458e983\n"}
+{"Action":"output","Package":"example.com/repo/parser","Test":"FuzzParse","Output":"    To re-run:\n"}
+{"Action":"output","Package":"example.com/repo/parser","Test":"FuzzParse","Output":"    go test -run=FuzzParse/771e938e4458e983\n"}
+{"Action":"fail","Package":"example.com/repo/parser","Test":"FuzzParse","Elapsed":4.28}
+{"Action":"run","Package":"example.com/repo/lexer","Test":"FuzzLex"}
+{"Action":"output","Package":"example.com/repo/lexer","Test":"FuzzLex","Output":"fuzz: elapsed: 9s, execs: 900 (100/sec), new interesting: 0 (total: 5)\n"}
+{"Action":"run","Package":"example.com/repo/lexer","Test":"FuzzSeedOnly"}
+{"Action":"pass","Package":"example.com/repo/lexer","Test":"FuzzSeedOnly","Elapsed":0.01}
+`
+	data, err := processTestEvents(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(data.Fuzz) != 2 {
+		t.Fatalf("Expected 2 fuzzed targets, got %+v", data.Fuzz)
+	}
+
+	lex, parse := data.Fuzz[0], data.Fuzz[1]
+	if lex.Name != "FuzzLex" || lex.Status != "PASS" || lex.Elapsed != 9 || lex.Execs != 900 || lex.CorpusTotal != 5 {
+		t.Errorf("Unexpected FuzzLex result %+v", lex)
+	}
+	if status := data.Results[testKey("example.com/repo/lexer", "FuzzLex")].Status; status != "PASS" {
+		t.Errorf("An interrupted fuzz target without a crasher should pass, got %s", status)
+	}
+	if data.PassedTests != 2 || data.FailedTests != 1 {
+		t.Errorf("Expected 2 passed and 1 failed, got %d and %d", data.PassedTests, data.FailedTests)
+	}
+
+	if parse.Status != "FAIL" || parse.Workers != 8 || parse.NewInteresting != 12 || parse.ExecsPerSec != 100000 {
+		t.Errorf("Unexpected FuzzParse result %+v", parse)
+	}
+	crasher := parse.Crasher
+	if crasher == nil {
+		t.Fatal("Expected a crasher")
+	}
+	if crasher.Message != `unexpected token in "\x00"` || crasher.InputFile != "testdata/fuzz/FuzzParse/771e938e4458e983" ||
+		crasher.Rerun != "go test -run=FuzzParse/771e938e4458e983" {
+		t.Errorf("Unexpected crasher %+v", crasher)
+	}
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "parser", "testdata", "fuzz", "FuzzParse")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "771e938e4458e983"), []byte("go test fuzz v1\n[]byte(\"\\x00\")\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loadCrasherInputs(data.Fuzz, sourceResolver{ModulePath: "example.com/repo", Root: root})
+	if crasher.Input != "go test fuzz v1\n[]byte(\"\\x00\")" {
+		t.Errorf("Expected the minimized input to be loaded, got %q", crasher.Input)
+	}
+
+	report := generateMarkdownReport(data)
+	for _, expected := range []string{
+		"- **Fuzz Crashers:** 1",
+		"## Fuzzing",
+		"| **FuzzParse** | example.com/repo/parser | ❌ FAIL | 4.000s | 400000 (100000/s) | 12 | 24 |",
+		"### Crasher in FuzzParse",
+		"- **Re-run:** `go test -run=FuzzParse/771e938e4458e983`",
+		"[]byte(\"\\x00\")",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Expected the report to contain %q", expected)
+		}
+	}
+}
+
+func TestFuzzCrasherOnWindows(t *testing.T) {
+	input := `{"Action":"run","Package":"example.com/repo","Test":"FuzzParse"}
+{"Action":"output","Package":"example.com/repo","Test":"FuzzParse","Output":"    --- FAIL: FuzzParse (0.00s)\r\n"}
+{"Action":"output","Package":"example.com/repo","Test":"FuzzParse","Output":"        parser_test.go:20: unexpected token\r\n"}
+{"Action":"output","Package":"example.com/repo","Test":"FuzzParse","Output":"    Failing input written to testdata\\fuzz\\FuzzParse\\771e938e4458e983\r\n"}
+{"Action":"fail","Package":"example.com/repo","Test":"FuzzParse","Elapsed":1}
+`
+	data, err := processTestEvents(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(data.Fuzz) != 1 || data.Fuzz[0].Crasher == nil {
+		t.Fatalf("Expected a crasher, got %+v", data.Fuzz)
+	}
+	crasher := data.Fuzz[0].Crasher
+	if crasher.Message != "unexpected token" || crasher.InputFile != "testdata/fuzz/FuzzParse/771e938e4458e983" {
+		t.Errorf("Unexpected crasher %+v", crasher)
+	}
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "testdata", "fuzz", "FuzzParse")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "771e938e4458e983"), []byte("go test fuzz v1\r\nstring(\"a\")\r\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loadCrasherInputs(data.Fuzz, sourceResolver{ModulePath: "example.com/repo", Root: root})
+	if crasher.Input != "go test fuzz v1\nstring(\"a\")" {
+		t.Errorf("Expected the input without carriage returns, got %q", crasher.Input)
+	}
+}
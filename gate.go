@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ExitGates are the conditions that make the tool exit non-zero
+type ExitGates struct {
+	FailOnFailure bool    // Fail when a test or package failed
+	MinPassRate   float64 // Minimum pass rate in percent, 0 disables
+	MaxSkipped    int     // Maximum skipped tests, negative disables
+	MaxFlaky      int     // Maximum flaky tests across the history, negative disables
+
+	FailOnDurationRegression bool              // Fail when a test is slower than the baseline
+	Regressions              *RegressionReport // Result of -max-duration-regression
+
+	FailOnSuiteSlowdown bool           // Fail when the run is slower than the trailing average
+	Slowdown            *SuiteSlowdown // Result of -max-suite-slowdown
+}
+
+// GateResult is the evaluation of a single enabled gate
+type GateResult struct {
+	CriterionResult
+	Failure string `json:"-"` // Why the run violates the gate, for the exit message
+}
+
+// evaluateGates checks the run against every enabled gate
+func evaluateGates(data *ReportData, history []*RunRecord, gates ExitGates) []GateResult {
+	var results []GateResult
+	add := func(name, threshold, actual string, passed bool, failure string) {
+		results = append(results, GateResult{
+			CriterionResult: CriterionResult{Name: name, Threshold: threshold, Actual: actual, Evaluated: true, Passed: passed},
+			Failure:         failure,
+		})
+	}
+
+	if gates.FailOnFailure {
+		add("Failures", "0", fmt.Sprintf("%d test(s), %d package(s)", data.FailedTests, data.FailedPackages),
+			reportStatus(data) != "FAILED", fmt.Sprintf("%d test(s) and %d package(s) failed", data.FailedTests, data.FailedPackages))
+	}
+	if gates.MinPassRate > 0 {
+		rate := passRate(data)
+		threshold := strconv.FormatFloat(gates.MinPassRate, 'f', -1, 64) + "%"
+		add("Pass rate", "≥ "+threshold, fmt.Sprintf("%.1f%%", rate),
+			rate >= gates.MinPassRate, fmt.Sprintf("pass rate of %.1f%% is below %s", rate, threshold))
+	}
+	if gates.MaxSkipped >= 0 {
+		add("Skipped tests", fmt.Sprintf("≤ %d", gates.MaxSkipped), fmt.Sprint(data.SkippedTests),
+			data.SkippedTests <= gates.MaxSkipped, fmt.Sprintf("%d test(s) skipped, more than the allowed %d", data.SkippedTests, gates.MaxSkipped))
+	}
+	if gates.MaxFlaky >= 0 {
+		flaky := len(currentFlakyTests(data, history))
+		add("Flaky tests", fmt.Sprintf("≤ %d", gates.MaxFlaky), fmt.Sprint(flaky),
+			flaky <= gates.MaxFlaky, fmt.Sprintf("%d flaky test(s), more than the allowed %d", flaky, gates.MaxFlaky))
+	}
+	if gates.FailOnDurationRegression && gates.Regressions != nil {
+		threshold := strconv.FormatFloat(gates.Regressions.Threshold, 'f', -1, 64)
+		slower := len(gates.Regressions.Tests)
+		add("Duration regressions", "0 over "+threshold+"%", fmt.Sprint(slower),
+			slower == 0, fmt.Sprintf("%d test(s) slower than the baseline by more than %s%%", slower, threshold))
+	}
+	if gates.FailOnSuiteSlowdown {
+		actual, failure := "within threshold", ""
+		if gates.Slowdown != nil {
+			actual, failure = fmt.Sprintf("%.0f%% slower", gates.Slowdown.Increase()), "the "+gates.Slowdown.String()
+		}
+		add("Suite slowdown", "no slowdown", actual, gates.Slowdown == nil, failure)
+	}
+	return results
+}
+
+// gateFailures returns the failure descriptions of the violated gates
+func gateFailures(results []GateResult) []string {
+	var failed []string
+	for _, result := range results {
+		if !result.Passed {
+			failed = append(failed, result.Failure)
+		}
+	}
+	return failed
+}
+
+// gatedStatus returns the status of the run, FAILED when a gate failed
+func gatedStatus(data *ReportData, gates []GateResult) string {
+	if len(gateFailures(gates)) > 0 {
+		return "FAILED"
+	}
+	return reportStatus(data)
+}
+
+// writeQualityGatesSection renders a row per enabled gate with its result
+func writeQualityGatesSection(sb *strings.Builder, gates []GateResult) {
+	if len(gates) == 0 {
+		return
+	}
+	sb.WriteString("## Quality Gates\n\n")
+	sb.WriteString("| Gate | Threshold | Actual | Result |\n")
+	sb.WriteString("| ---- | --------- | ------ | ------ |\n")
+	for _, gate := range gates {
+		result := statusEmoji("PASS") + " Pass"
+		if !gate.Passed {
+			result = statusEmoji("FAIL") + " Fail"
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", gate.Name, gate.Threshold, gate.Actual, result))
+	}
+	sb.WriteString("\n")
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFailedGates(t *testing.T) {
+	previous := &RunRecord{
+		Timestamp: time.Date(2024, 3, 19, 10, 0, 0, 0, time.UTC),
+		Tests:     map[string]TestRecord{testKey("", "TestFlaky"): {Status: "PASS"}},
+	}
+	failing := &ReportData{
+		TotalTests:   3,
+		PassedTests:  1,
+		FailedTests:  1,
+		SkippedTests: 1,
+		Results: map[string]*TestResult{
+			"TestFlaky": {Name: "TestFlaky", Status: "FAIL"},
+		},
+	}
+	passing := &ReportData{TotalTests: 1, PassedTests: 1, Results: map[string]*TestResult{}}
+	disabled := ExitGates{MaxSkipped: -1, MaxFlaky: -1}
+
+	tests := []struct {
+		name     string
+		data     *ReportData
+		history  []*RunRecord
+		gates    ExitGates
+		expected []string
+	}{
+		{"disabled", failing, []*RunRecord{previous}, disabled, nil},
+		{"fail on failure", failing, nil, ExitGates{FailOnFailure: true, MaxSkipped: -1, MaxFlaky: -1}, []string{"1 test(s) and 0 package(s) failed"}},
+		{"passing run", passing, nil, ExitGates{FailOnFailure: true, MaxSkipped: 0, MaxFlaky: 0}, nil},
+		{"min pass rate", failing, nil, ExitGates{MinPassRate: 95, MaxSkipped: -1, MaxFlaky: -1}, []string{"pass rate of 33.3% is below 95%"}},
+		{"min pass rate met", passing, nil, ExitGates{MinPassRate: 95, MaxSkipped: -1, MaxFlaky: -1}, nil},
+		{"max skipped", failing, nil, ExitGates{MaxSkipped: 0, MaxFlaky: -1}, []string{"1 test(s) skipped"}},
+		{"max flaky", failing, []*RunRecord{previous}, ExitGates{MaxSkipped: -1, MaxFlaky: 0}, []string{"1 flaky test(s)"}},
+		{"max flaky without history", failing, nil, ExitGates{MaxSkipped: -1, MaxFlaky: 0}, nil},
+		{"duration regression", passing, nil, ExitGates{MaxSkipped: -1, MaxFlaky: -1, FailOnDurationRegression: true, Regressions: &RegressionReport{Threshold: 20, Tests: []DurationChange{{Name: "TestSlow"}}}}, []string{"1 test(s) slower than the baseline by more than 20%"}},
+		{"suite slowdown", passing, nil, ExitGates{MaxSkipped: -1, MaxFlaky: -1, FailOnSuiteSlowdown: true, Slowdown: &SuiteSlowdown{Metric: "wall clock", Current: 150, Average: 100, Runs: 5, Threshold: 20}}, []string{"the wall clock of 2m30.0s is 50% above"}},
+		{"duration regression not gating", passing, nil, ExitGates{MaxSkipped: -1, MaxFlaky: -1, Regressions: &RegressionReport{Threshold: 20, Tests: []DurationChange{{Name: "TestSlow"}}}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failed := gateFailures(evaluateGates(tt.data, tt.history, tt.gates))
+			if len(failed) != len(tt.expected) {
+				t.Fatalf("Expected %d failed gates, got %v", len(tt.expected), failed)
+			}
+			for i, expected := range tt.expected {
+				if !strings.HasPrefix(failed[i], expected) {
+					t.Errorf("Expected gate %q, got %q", expected, failed[i])
+				}
+			}
+		})
+	}
+}
+
+func TestQualityGatesSection(t *testing.T) {
+	data := &ReportData{TotalTests: 10, PassedTests: 9, SkippedTests: 1, Results: map[string]*TestResult{}}
+	gates := evaluateGates(data, nil, ExitGates{FailOnFailure: true, MinPassRate: 95, MaxSkipped: 1, MaxFlaky: -1})
+	if len(gates) != 3 {
+		t.Fatalf("Expected the failure, pass rate and skipped gates, got %+v", gates)
+	}
+	if status := gatedStatus(data, gates); status != "FAILED" {
+		t.Errorf("Expected a failed pass rate gate to fail the run, got %s", status)
+	}
+	if status := gatedStatus(data, nil); status != "PASSED" {
+		t.Errorf("Expected PASSED without gates, got %s", status)
+	}
+
+	report := renderMarkdownReport(data, ReportOptions{Gates: gates})
+	for _, expected := range []string{
+		"![Status](https://img.shields.io/badge/Status-FAILED-red)",
+		"## Quality Gates\n\n| Gate | Threshold | Actual | Result |",
+		"| Failures | 0 | 0 test(s), 0 package(s) | ✅ Pass |",
+		"| Pass rate | ≥ 95% | 90.0% | ❌ Fail |",
+		"| Skipped tests | ≤ 1 | 1 | ✅ Pass |",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Expected report to contain %q, got:\n%s", expected, report)
+		}
+	}
+	if strings.Contains(renderMarkdownReport(data, ReportOptions{}), "## Quality Gates") {
+		t.Error("Expected no Quality Gates section without enabled gates")
+	}
+}
+
+func TestGatedStatusReported(t *testing.T) {
+	// The tests pass but -max-skipped 0 fails on the skipped one
+	data := &ReportData{TotalTests: 2, PassedTests: 1, SkippedTests: 1, Results: map[string]*TestResult{}}
+	gates := evaluateGates(data, nil, ExitGates{MaxSkipped: 0, MaxFlaky: -1})
+	if status := reportStatus(data); status == "FAILED" {
+		t.Fatalf("Expected the tests alone not to fail the run, got %s", status)
+	}
+
+	report := newJSONReport(data, ReportOptions{Gates: gates}, time.Now())
+	if report.Status != "FAILED" {
+		t.Errorf("Expected the JSON status to be FAILED by the gate, got %s", report.Status)
+	}
+	if line := exitSummaryLine(data, gates, nil, "report.md"); !strings.Contains(line, "status=FAILED") {
+		t.Errorf("Expected the exit summary to be FAILED by the gate, got %q", line)
+	}
+	if entry := runFeedEntry(data, gates, "", time.Now()); !strings.HasPrefix(entry.Title, "FAILED:") {
+		t.Errorf("Expected the feed entry to be FAILED by the gate, got %q", entry.Title)
+	}
+	if msg := buildSlackMessage(data, gates, ""); !strings.Contains(msg.Text, "failed") {
+		t.Errorf("Expected the Slack message to be failed by the gate, got %q", msg.Text)
+	}
+}
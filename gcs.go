@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// gcsMetadataTokenURL returns an access token of the service account a
+// Cloud Build step or GCE instance runs as
+const gcsMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// CloudBuild identifies the Google Cloud Build build running the report
+type CloudBuild struct {
+	ProjectID string
+	BuildID   string
+	Location  string // Region of the build, empty for global builds
+}
+
+// detectCloudBuild returns the Cloud Build build from the BUILD_ID and
+// PROJECT_ID variables set in every build step, or nil outside of Cloud
+// Build. Jenkins sets BUILD_ID too, so PROJECT_ID is required.
+func detectCloudBuild() *CloudBuild {
+	build := &CloudBuild{ProjectID: os.Getenv("PROJECT_ID"), BuildID: os.Getenv("BUILD_ID"), Location: os.Getenv("LOCATION")}
+	if build.ProjectID == "" || build.BuildID == "" || os.Getenv("JENKINS_URL") != "" {
+		return nil
+	}
+	return build
+}
+
+// URL returns the page of the build in the Google Cloud console
+func (b *CloudBuild) URL() string {
+	region := ""
+	if b.Location != "" && b.Location != "global" {
+		region = ";region=" + b.Location
+	}
+	return fmt.Sprintf("https://console.cloud.google.com/cloud-build/builds%s/%s?project=%s", region, b.BuildID, url.QueryEscape(b.ProjectID))
+}
+
+// GCSUploader uploads files to a Google Cloud Storage bucket through the
+// JSON API
+type GCSUploader struct {
+	APIURL     string // https://storage.googleapis.com
+	Bucket     string
+	Prefix     string // Object name prefix without trailing slash, may be empty
+	Token      string // OAuth2 access token
+	HTTPClient *http.Client
+}
+
+// newGCSUploader parses a gs://BUCKET[/PREFIX] location
+func newGCSUploader(location, token string) (*GCSUploader, error) {
+	rest, ok := strings.CutPrefix(location, "gs://")
+	bucket, prefix, _ := strings.Cut(rest, "/")
+	if !ok || bucket == "" {
+		return nil, fmt.Errorf("invalid bucket %q, expected gs://BUCKET[/PREFIX]", location)
+	}
+	return &GCSUploader{
+		APIURL:     "https://storage.googleapis.com",
+		Bucket:     bucket,
+		Prefix:     strings.Trim(prefix, "/"),
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// gcsAccessToken returns the token for uploads: $GOOGLE_OAUTH_ACCESS_TOKEN
+// when set, e.g. from gcloud auth print-access-token, else the token of the
+// build's service account from the metadata server
+func gcsAccessToken(metadataURL string) (string, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+	req, err := http.NewRequest(http.MethodGet, metadataURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := (&http.Client{Timeout: 10 * time.Second}).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error calling metadata server, set GOOGLE_OAUTH_ACCESS_TOKEN outside of Google Cloud: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata server returned %s", resp.Status)
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("error decoding metadata server token: %v", err)
+	}
+	return token.AccessToken, nil
+}
+
+// objectName returns the object a file is stored as below the prefix
+func (u *GCSUploader) objectName(name string) string {
+	if u.Prefix == "" {
+		return name
+	}
+	return u.Prefix + "/" + name
+}
+
+// upload stores a local file as the object name
+func (u *GCSUploader) upload(file, name string) error {
+	content, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	endpoint := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s",
+		strings.TrimSuffix(u.APIURL, "/"), url.PathEscape(u.Bucket), url.QueryEscape(u.objectName(name)))
+	req, err := http.NewRequest(http.MethodPost, endpoint, content)
+	if err != nil {
+		return fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+u.Token)
+	contentType := mime.TypeByExtension(filepath.Ext(file))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := u.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error uploading %s: %v", name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("uploading %s returned %s: %s", name, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// objectURL returns the link to an object for signed-in console users
+func (u *GCSUploader) objectURL(name string) string {
+	return "https://storage.cloud.google.com/" + u.Bucket + "/" + (&url.URL{Path: u.objectName(name)}).EscapedPath()
+}
+
+// folderURL returns the console page listing the objects below name
+func (u *GCSUploader) folderURL(name string) string {
+	return "https://console.cloud.google.com/storage/browser/" + u.Bucket + "/" + (&url.URL{Path: u.objectName(name)}).EscapedPath()
+}
+
+// uploadArtifacts uploads the artifacts of a run below a folder named after
+// the build, keeping their paths relative to dir, and returns the uploaded
+// object names in order. Files that were not written are skipped.
+func uploadArtifacts(u *GCSUploader, dir, folder string, artifacts artifactList) ([]string, error) {
+	var names []string
+	for _, a := range artifacts {
+		if _, err := os.Stat(a.Path); err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(dir, a.Path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			rel = filepath.Base(a.Path)
+		}
+		name := path.Join(folder, filepath.ToSlash(rel))
+		if err := u.upload(a.Path, name); err != nil {
+			return names, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// writeCloudSummary prints the outcome of the run with links to the build
+// and the uploaded artifacts, for the plain text Cloud Build log
+func writeCloudSummary(w io.Writer, data *ReportData, gates []GateResult, build *CloudBuild, u *GCSUploader, folder string, names []string) {
+	fmt.Fprintf(w, "Tests %s: %d passed, %d failed, %d skipped in %.2fs\n",
+		strings.ToLower(gatedStatus(data, gates)), data.PassedTests, data.FailedTests, data.SkippedTests, data.TotalDuration)
+	if build != nil {
+		fmt.Fprintf(w, "Build:     %s\n", build.URL())
+	}
+	fmt.Fprintf(w, "Artifacts: %s\n", u.folderURL(folder))
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\n", u.objectURL(name))
+	}
+}
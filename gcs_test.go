@@ -0,0 +1,119 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectCloudBuild(t *testing.T) {
+	clearCIEnv(t)
+	if build := detectCloudBuild(); build != nil {
+		t.Errorf("Expected no build outside of Cloud Build, got %+v", build)
+	}
+	t.Setenv("PROJECT_ID", "acme-ci")
+	t.Setenv("BUILD_ID", "b2f1")
+	build := detectCloudBuild()
+	if build == nil || build.URL() != "https://console.cloud.google.com/cloud-build/builds/b2f1?project=acme-ci" {
+		t.Errorf("Unexpected build %+v", build)
+	}
+	t.Setenv("LOCATION", "europe-west1")
+	if got := detectCloudBuild().URL(); got != "https://console.cloud.google.com/cloud-build/builds;region=europe-west1/b2f1?project=acme-ci" {
+		t.Errorf("Expected the regional build URL, got %s", got)
+	}
+	t.Setenv("COMMIT_SHA", "0123456789abcdef0123456789abcdef01234567")
+	if info := detectGitInfo("", "", nil, t.TempDir()); info == nil || info.SHA != "0123456789abcdef0123456789abcdef01234567" || !strings.Contains(info.RunURL, "cloud-build") {
+		t.Errorf("Expected the Cloud Build commit and link, got %+v", info)
+	}
+	t.Setenv("JENKINS_URL", "https://jenkins.example.com/")
+	if build := detectCloudBuild(); build != nil {
+		t.Errorf("Expected the BUILD_ID of Jenkins to be ignored, got %+v", build)
+	}
+}
+
+func TestNewGCSUploader(t *testing.T) {
+	u, err := newGCSUploader("gs://acme-reports/go/tests/", "")
+	if err != nil || u.Bucket != "acme-reports" || u.Prefix != "go/tests" {
+		t.Errorf("Unexpected uploader %+v, %v", u, err)
+	}
+	for _, location := range []string{"acme-reports", "gs://", "s3://acme-reports"} {
+		if _, err := newGCSUploader(location, ""); err == nil {
+			t.Errorf("Expected an error for %q", location)
+		}
+	}
+}
+
+func TestUploadArtifacts(t *testing.T) {
+	uploads := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/upload/storage/v1/b/acme-reports/o" || r.URL.Query().Get("uploadType") != "media" {
+			t.Errorf("Unexpected request %s", r.URL)
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Unexpected authorization %q", r.Header.Get("Authorization"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		uploads[r.URL.Query().Get("name")] = r.Header.Get("Content-Type") + " " + string(body)
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "test-report.md"), "# Test Summary Report")
+	writeFile(t, filepath.Join(dir, "cards", "summary.svg"), "<svg/>")
+	var artifacts artifactList
+	artifacts.add(filepath.Join(dir, "test-report.md"), "Markdown test report")
+	artifacts.add(filepath.Join(dir, "cards", "summary.svg"), "Summary card")
+	artifacts.add(filepath.Join(dir, "missing.ics"), "Not written")
+
+	u, _ := newGCSUploader("gs://acme-reports/go", "token")
+	u.APIURL = server.URL
+	names, err := uploadArtifacts(u, dir, "b2f1", artifacts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[0] != "b2f1/test-report.md" || names[1] != "b2f1/cards/summary.svg" {
+		t.Errorf("Unexpected uploaded names %v", names)
+	}
+	if got := uploads["go/b2f1/test-report.md"]; !strings.HasSuffix(got, " # Test Summary Report") {
+		t.Errorf("Unexpected upload of the report %q", got)
+	}
+	if got := uploads["go/b2f1/cards/summary.svg"]; got != "image/svg+xml <svg/>" {
+		t.Errorf("Unexpected upload of the card %q", got)
+	}
+
+	var sb strings.Builder
+	data := &ReportData{TotalTests: 3, PassedTests: 2, FailedTests: 1, TotalDuration: 1.5}
+	writeCloudSummary(&sb, data, nil, &CloudBuild{ProjectID: "acme-ci", BuildID: "b2f1"}, u, "b2f1", names)
+	expected := "Tests failed: 2 passed, 1 failed, 0 skipped in 1.50s\n" +
+		"Build:     https://console.cloud.google.com/cloud-build/builds/b2f1?project=acme-ci\n" +
+		"Artifacts: https://console.cloud.google.com/storage/browser/acme-reports/go/b2f1\n" +
+		"  https://storage.cloud.google.com/acme-reports/go/b2f1/test-report.md\n" +
+		"  https://storage.cloud.google.com/acme-reports/go/b2f1/cards/summary.svg\n"
+	if sb.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, sb.String())
+	}
+}
+
+func TestGCSAccessToken(t *testing.T) {
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			http.Error(w, "missing header", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"access_token":"from-metadata","expires_in":3599,"token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+
+	if token, err := gcsAccessToken(server.URL); err != nil || token != "from-metadata" {
+		t.Errorf("Expected the metadata server token, got %q, %v", token, err)
+	}
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "from-env")
+	if token, _ := gcsAccessToken(server.URL); token != "from-env" {
+		t.Errorf("Expected the token of the environment, got %q", token)
+	}
+}
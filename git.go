@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// GitInfo describes the code a report was generated for
+type GitInfo struct {
+	SHA    string `json:"sha,omitempty"`
+	Branch string `json:"branch,omitempty"`
+	Author string `json:"author,omitempty"`  // Author of the commit, "Name <email>"
+	RunURL string `json:"run_url,omitempty"` // CI run that produced the report
+}
+
+// firstEnv returns the value of the first of the environment variables that is set
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// gitDir returns the git directory of the repository at root, following the
+// .git file of worktrees and submodules
+func gitDir(root string) string {
+	dir := filepath.Join(root, ".git")
+	content, err := os.ReadFile(dir)
+	if err != nil {
+		// A directory, or no repository at all
+		return dir
+	}
+	linked, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "gitdir: ")
+	if !ok {
+		return dir
+	}
+	if !filepath.IsAbs(linked) {
+		linked = filepath.Join(root, linked)
+	}
+	return linked
+}
+
+// readGitHead returns the commit and branch checked out in the repository at
+// root, read from .git without running git. The branch is empty for a
+// detached HEAD.
+func readGitHead(root string) (sha, branch string) {
+	dir := gitDir(root)
+	head, err := os.ReadFile(filepath.Join(dir, "HEAD"))
+	if err != nil {
+		return "", ""
+	}
+	ref, ok := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: ")
+	if !ok {
+		return strings.TrimSpace(string(head)), ""
+	}
+	branch = strings.TrimPrefix(ref, "refs/heads/")
+
+	// Worktrees keep their HEAD apart from the refs of the main repository
+	common := dir
+	if content, err := os.ReadFile(filepath.Join(dir, "commondir")); err == nil {
+		common = filepath.Join(dir, strings.TrimSpace(string(content)))
+	}
+	if content, err := os.ReadFile(filepath.Join(common, filepath.FromSlash(ref))); err == nil {
+		return strings.TrimSpace(string(content)), branch
+	}
+	packed, err := os.ReadFile(filepath.Join(common, "packed-refs"))
+	if err != nil {
+		// A branch without commits
+		return "", branch
+	}
+	for _, line := range strings.Split(string(packed), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[1] == ref {
+			return fields[0], branch
+		}
+	}
+	return "", branch
+}
+
+// commitAuthor returns the author of a commit, or an empty string when git
+// is not available
+func commitAuthor(root, sha string) string {
+	cmd := exec.Command("git", "log", "-1", "--format=%an <%ae>", sha)
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// ciRunURL returns the link to the CI run, for GitHub Actions, GitLab CI,
+// Jenkins, AWS CodeBuild and Google Cloud Build
+func ciRunURL() string {
+	if server, repo, run := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID"); server != "" && repo != "" && run != "" {
+		return fmt.Sprintf("%s/%s/actions/runs/%s", server, repo, run)
+	}
+	if build := detectCloudBuild(); build != nil {
+		return build.URL()
+	}
+	return firstEnv("CI_PIPELINE_URL", "BUILD_URL", "CODEBUILD_BUILD_URL")
+}
+
+// detectGitInfo returns the metadata of the tested code: -git-sha and
+// -git-branch when given, else the -ci preset, else the CI environment, else
+// the checkout at root. It returns nil when nothing is known.
+func detectGitInfo(sha, branch string, preset *CIPreset, root string) *GitInfo {
+	info := &GitInfo{SHA: sha, Branch: branch}
+	if preset != nil {
+		if info.SHA == "" {
+			info.SHA = preset.Git.SHA
+		}
+		if info.Branch == "" {
+			info.Branch = preset.Git.Branch
+		}
+		info.Author, info.RunURL = preset.Git.Author, preset.Git.RunURL
+	}
+	if info.Author == "" {
+		info.Author = os.Getenv("CI_COMMIT_AUTHOR")
+	}
+	if info.RunURL == "" {
+		info.RunURL = ciRunURL()
+	}
+	if info.SHA == "" {
+		info.SHA = firstEnv("GITHUB_SHA", "CI_COMMIT_SHA", "GIT_COMMIT", "CODEBUILD_RESOLVED_SOURCE_VERSION")
+	}
+	if info.Branch == "" {
+		// Pull requests check out a merge ref; GITHUB_HEAD_REF is their branch
+		info.Branch = firstEnv("GITHUB_HEAD_REF", "GITHUB_REF_NAME", "CI_COMMIT_REF_NAME", "GIT_BRANCH")
+	}
+	if info.Branch == "" {
+		info.Branch = strings.TrimPrefix(os.Getenv("CODEBUILD_WEBHOOK_HEAD_REF"), "refs/heads/")
+	}
+	if build := detectCloudBuild(); build != nil {
+		// Cloud Build substitutions are only visible when passed with env
+		if info.SHA == "" {
+			info.SHA = os.Getenv("COMMIT_SHA")
+		}
+		if info.Branch == "" {
+			info.Branch = os.Getenv("BRANCH_NAME")
+		}
+	}
+	if info.SHA == "" || info.Branch == "" {
+		headSHA, headBranch := readGitHead(root)
+		if info.SHA == "" {
+			info.SHA = headSHA
+		}
+		if info.Branch == "" {
+			info.Branch = headBranch
+		}
+	}
+	if info.Author == "" && info.SHA != "" {
+		info.Author = commitAuthor(root, info.SHA)
+	}
+	if *info == (GitInfo{}) {
+		return nil
+	}
+	return info
+}
+
+// shortSHA abbreviates a commit SHA like git log --oneline
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// writeGitInfo renders the commit, branch, author and CI run below the title
+func writeGitInfo(sb *strings.Builder, git *GitInfo, links *SourceLinks) {
+	if git == nil {
+		return
+	}
+	var parts []string
+	if git.SHA != "" {
+		commit := codeSpan(shortSHA(git.SHA))
+		if links != nil && links.RepoURL != "" {
+			commit = fmt.Sprintf("[%s](%s/commit/%s)", commit, strings.TrimSuffix(links.RepoURL, "/"), git.SHA)
+		}
+		parts = append(parts, "**Commit:** "+commit)
+	}
+	if git.Branch != "" {
+		parts = append(parts, "**Branch:** "+codeSpan(git.Branch))
+	}
+	if git.Author != "" {
+		parts = append(parts, "**Author:** "+escapeMarkdown(git.Author))
+	}
+	if git.RunURL != "" {
+		parts = append(parts, fmt.Sprintf("[CI run](%s)", git.RunURL))
+	}
+	sb.WriteString(strings.Join(parts, " · ") + "\n\n")
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// clearCIEnv unsets the CI variables detectGitInfo reads
+func clearCIEnv(t *testing.T) {
+	for _, name := range []string{"GITHUB_SHA", "GITHUB_HEAD_REF", "GITHUB_REF_NAME", "GITHUB_SERVER_URL", "GITHUB_REPOSITORY", "GITHUB_RUN_ID",
+		"CI_COMMIT_SHA", "CI_COMMIT_REF_NAME", "CI_COMMIT_AUTHOR", "CI_PIPELINE_URL", "GIT_COMMIT", "GIT_BRANCH", "BUILD_URL",
+		"CODEBUILD_RESOLVED_SOURCE_VERSION", "CODEBUILD_WEBHOOK_HEAD_REF", "CODEBUILD_BUILD_URL", "PROJECT_ID", "BUILD_ID", "LOCATION", "COMMIT_SHA", "BRANCH_NAME", "JENKINS_URL", "CI", "DRONE"} {
+		t.Setenv(name, "")
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadGitHead(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".git", "HEAD"), "ref: refs/heads/feature/cart\n")
+	writeFile(t, filepath.Join(root, ".git", "packed-refs"), "# pack-refs with: peeled fully-peeled sorted\n"+
+		"1111111111111111111111111111111111111111 refs/heads/main\n"+
+		"2222222222222222222222222222222222222222 refs/heads/feature/cart\n")
+	if sha, branch := readGitHead(root); sha != strings.Repeat("2", 40) || branch != "feature/cart" {
+		t.Errorf("Expected the packed ref of the branch, got %q on %q", sha, branch)
+	}
+
+	// Loose refs take precedence over packed ones
+	writeFile(t, filepath.Join(root, ".git", "refs", "heads", "feature", "cart"), strings.Repeat("3", 40)+"\n")
+	if sha, _ := readGitHead(root); sha != strings.Repeat("3", 40) {
+		t.Errorf("Expected the loose ref, got %q", sha)
+	}
+
+	// A worktree links to its git directory, which shares the refs
+	worktree := t.TempDir()
+	writeFile(t, filepath.Join(worktree, ".git"), "gitdir: "+filepath.Join(root, ".git", "worktrees", "wt")+"\n")
+	writeFile(t, filepath.Join(root, ".git", "worktrees", "wt", "HEAD"), "ref: refs/heads/main\n")
+	writeFile(t, filepath.Join(root, ".git", "worktrees", "wt", "commondir"), "../..\n")
+	if sha, branch := readGitHead(worktree); sha != strings.Repeat("1", 40) || branch != "main" {
+		t.Errorf("Expected the worktree's branch, got %q on %q", sha, branch)
+	}
+
+	// Detached HEAD
+	writeFile(t, filepath.Join(root, ".git", "HEAD"), strings.Repeat("4", 40)+"\n")
+	if sha, branch := readGitHead(root); sha != strings.Repeat("4", 40) || branch != "" {
+		t.Errorf("Expected a detached HEAD, got %q on %q", sha, branch)
+	}
+}
+
+func TestDetectGitInfo(t *testing.T) {
+	clearCIEnv(t)
+	if info := detectGitInfo("", "", nil, t.TempDir()); info != nil {
+		t.Errorf("Expected no metadata outside of a repository, got %+v", info)
+	}
+
+	t.Setenv("GITHUB_SHA", "0123456789abcdef0123456789abcdef01234567")
+	t.Setenv("GITHUB_REF_NAME", "42/merge")
+	t.Setenv("GITHUB_HEAD_REF", "fix-cart")
+	t.Setenv("GITHUB_SERVER_URL", "https://github.com")
+	t.Setenv("GITHUB_REPOSITORY", "acme/shop")
+	t.Setenv("GITHUB_RUN_ID", "99")
+	info := detectGitInfo("", "", nil, t.TempDir())
+	expected := GitInfo{SHA: "0123456789abcdef0123456789abcdef01234567", Branch: "fix-cart", RunURL: "https://github.com/acme/shop/actions/runs/99"}
+	if info == nil || *info != expected {
+		t.Errorf("Expected %+v, got %+v", expected, info)
+	}
+	if info := detectGitInfo("abc", "release", nil, t.TempDir()); info.SHA != "abc" || info.Branch != "release" {
+		t.Errorf("Expected the flags to take precedence, got %+v", info)
+	}
+
+	clearCIEnv(t)
+	t.Setenv("CODEBUILD_RESOLVED_SOURCE_VERSION", "fedcba9876543210fedcba9876543210fedcba98")
+	t.Setenv("CODEBUILD_WEBHOOK_HEAD_REF", "refs/heads/fix-cart")
+	t.Setenv("CODEBUILD_BUILD_URL", "https://console.aws.amazon.com/codesuite/codebuild/projects/shop/build/shop:1")
+	info = detectGitInfo("", "", nil, t.TempDir())
+	expected = GitInfo{SHA: "fedcba9876543210fedcba9876543210fedcba98", Branch: "fix-cart", RunURL: "https://console.aws.amazon.com/codesuite/codebuild/projects/shop/build/shop:1"}
+	if info == nil || *info != expected {
+		t.Errorf("Expected the CodeBuild metadata %+v, got %+v", expected, info)
+	}
+}
+
+func TestWriteGitInfo(t *testing.T) {
+	var sb strings.Builder
+	writeGitInfo(&sb, &GitInfo{
+		SHA:    "0123456789abcdef0123456789abcdef01234567",
+		Branch: "main",
+		Author: "Jo Doe <jo@example.com>",
+		RunURL: "https://ci.example.com/runs/7",
+	}, &SourceLinks{RepoURL: "https://github.com/acme/shop"})
+	expected := "**Commit:** [`0123456`](https://github.com/acme/shop/commit/0123456789abcdef0123456789abcdef01234567) · " +
+		"**Branch:** `main` · **Author:** Jo Doe &lt;jo@example.com&gt; · [CI run](https://ci.example.com/runs/7)\n\n"
+	if sb.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, sb.String())
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// detectGiteaContext builds a GitHubContext for a Gitea or Forgejo server.
+// Their Actions runners set the GITHUB_* variables like GitHub does, so the
+// repository and PR are detected the same way; PR is 0 outside of pull
+// requests. serverURL, repo and pr override the detected values when
+// non-empty.
+func detectGiteaContext(serverURL, repo string, pr int) (*GitHubContext, error) {
+	if serverURL == "" {
+		serverURL = firstEnv("FORGEJO_SERVER_URL", "GITHUB_SERVER_URL")
+	}
+	if serverURL == "" {
+		return nil, fmt.Errorf("server not set: use -gitea-url or run in Gitea or Forgejo Actions")
+	}
+	ctx := &GitHubContext{
+		APIURL: strings.TrimSuffix(serverURL, "/") + "/api/v1",
+		Token:  firstEnv("GITEA_TOKEN", "FORGEJO_TOKEN", "GITHUB_TOKEN"),
+		Repo:   repo,
+		PR:     pr,
+	}
+	if ctx.Token == "" {
+		return nil, fmt.Errorf("GITEA_TOKEN is not set")
+	}
+	if ctx.Repo == "" {
+		ctx.Repo = firstEnv("FORGEJO_REPOSITORY", "GITHUB_REPOSITORY")
+	}
+	if ctx.Repo == "" {
+		return nil, fmt.Errorf("repository not set: use -github-repo or GITHUB_REPOSITORY")
+	}
+	if ctx.PR == 0 {
+		ctx.PR = detectPRNumber(os.Getenv("GITHUB_EVENT_PATH"), os.Getenv("GITHUB_REF"))
+	}
+	return ctx, nil
+}
+
+// newGiteaClient returns a client for the API of a Gitea or Forgejo server
+func newGiteaClient(apiURL, token string) *GitHubClient {
+	client := newGitHubClient(apiURL, token)
+	client.Gitea = true
+	return client
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectGiteaContext(t *testing.T) {
+	clearCIEnv(t)
+	event := filepath.Join(t.TempDir(), "event.json")
+	writeFile(t, event, `{"pull_request":{"number":12}}`)
+	t.Setenv("GITHUB_SERVER_URL", "https://gitea.example.com/")
+	t.Setenv("GITHUB_REPOSITORY", "owner/repo")
+	t.Setenv("GITHUB_EVENT_PATH", event)
+	t.Setenv("GITEA_TOKEN", "")
+	t.Setenv("FORGEJO_TOKEN", "")
+	t.Setenv("FORGEJO_SERVER_URL", "")
+	t.Setenv("FORGEJO_REPOSITORY", "")
+	t.Setenv("GITHUB_TOKEN", "")
+
+	if _, err := detectGiteaContext("", "", 0); err == nil {
+		t.Error("Expected an error without a token")
+	}
+	t.Setenv("GITEA_TOKEN", "token")
+	ctx, err := detectGiteaContext("", "", 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := GitHubContext{APIURL: "https://gitea.example.com/api/v1", Token: "token", Repo: "owner/repo", PR: 12}
+	if *ctx != expected {
+		t.Errorf("Expected %+v, got %+v", expected, *ctx)
+	}
+	if ctx, _ := detectGiteaContext("https://forgejo.example.com", "team/app", 3); ctx.APIURL != "https://forgejo.example.com/api/v1" || ctx.Repo != "team/app" || ctx.PR != 3 {
+		t.Errorf("Expected the arguments to override the environment, got %+v", ctx)
+	}
+}
+
+func TestPostPRReportGitea(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "token secret" {
+			t.Errorf("Expected a token authorization header, got %q", auth)
+		}
+		requests = append(requests, r.Method+" "+r.URL.String())
+		if r.Method == http.MethodGet {
+			json.NewEncoder(w).Encode([]issueComment{{ID: 7, Body: stickyCommentMarker + "\nold report"}})
+			return
+		}
+		json.NewEncoder(w).Encode(issueComment{ID: 7})
+	}))
+	defer server.Close()
+
+	summary := "# Test Summary Report\n\n## Summary\n\n"
+	details := strings.Repeat("| **TestGenerated** | ✅ PASS | 0.010s | - |\n", 1500)
+	client := newGiteaClient(server.URL, "secret")
+	if err := client.postPRReport("owner/repo", 12, &ReportData{}, summary, details, overflowGist); err != nil {
+		t.Fatal(err)
+	}
+
+	// Comments are listed without paging, and without gists the report is
+	// split into follow-up comments
+	var parts int
+	for _, request := range requests {
+		switch {
+		case strings.HasPrefix(request, "GET ") && request != "GET /repos/owner/repo/issues/12/comments":
+			t.Errorf("Expected comments to be listed without paging, got %q", request)
+		case request == "POST /repos/owner/repo/issues/12/comments":
+			parts++
+		}
+	}
+	if parts == 0 || requests[len(requests)-parts-1] != "PATCH /repos/owner/repo/issues/comments/7" {
+		t.Errorf("Expected the sticky comment to be updated and followed by parts, got %v", requests)
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stickyCommentMarker identifies the PR comment owned by gotest-report so
+// re-runs update it instead of adding a new comment
+const stickyCommentMarker = "<!-- gotest-report -->"
+
+// GitHubContext holds everything needed to talk to the GitHub API for a PR
+type GitHubContext struct {
+	APIURL string // Base API URL, e.g. https://api.github.com
+	Token  string // Token used for authentication
+	Repo   string // Repository in owner/name form
+	PR     int    // Pull request number
+}
+
+// GitHubClient is a minimal GitHub REST API client. With Gitea set it talks
+// to the Gitea or Forgejo API instead, which has the same endpoints for
+// comments and statuses.
+type GitHubClient struct {
+	APIURL     string
+	Token      string
+	HTTPClient *http.Client
+	Gitea      bool
+}
+
+// issueComment is the subset of the GitHub issue comment payload we use
+type issueComment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+var pullRefPattern = regexp.MustCompile(`^refs/pull/(\d+)/`)
+
+// detectGitHubContext builds a GitHubContext from the GITHUB_* environment
+// variables. repo and pr override the detected values when non-empty.
+func detectGitHubContext(repo string, pr int) (*GitHubContext, error) {
+	ctx := &GitHubContext{
+		APIURL: os.Getenv("GITHUB_API_URL"),
+		Token:  os.Getenv("GITHUB_TOKEN"),
+		Repo:   repo,
+		PR:     pr,
+	}
+	if ctx.APIURL == "" {
+		ctx.APIURL = "https://api.github.com"
+	}
+	if ctx.Token == "" {
+		return nil, fmt.Errorf("GITHUB_TOKEN is not set")
+	}
+	if ctx.Repo == "" {
+		ctx.Repo = os.Getenv("GITHUB_REPOSITORY")
+	}
+	if ctx.Repo == "" {
+		return nil, fmt.Errorf("repository not set: use -github-repo or GITHUB_REPOSITORY")
+	}
+	if ctx.PR == 0 {
+		ctx.PR = detectPRNumber(os.Getenv("GITHUB_EVENT_PATH"), os.Getenv("GITHUB_REF"))
+	}
+	if ctx.PR == 0 {
+		return nil, fmt.Errorf("pull request number not found: use -github-pr-number or run on a pull_request event")
+	}
+	return ctx, nil
+}
+
+// detectPRNumber reads the PR number from the event payload, falling back
+// to parsing a refs/pull/<n>/merge style ref
+func detectPRNumber(eventPath, ref string) int {
+	if eventPath != "" {
+		if data, err := os.ReadFile(eventPath); err == nil {
+			var event struct {
+				Number      int `json:"number"`
+				PullRequest struct {
+					Number int `json:"number"`
+				} `json:"pull_request"`
+			}
+			if json.Unmarshal(data, &event) == nil {
+				if event.PullRequest.Number > 0 {
+					return event.PullRequest.Number
+				}
+				if event.Number > 0 {
+					return event.Number
+				}
+			}
+		}
+	}
+
+	if m := pullRefPattern.FindStringSubmatch(ref); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return n
+	}
+	return 0
+}
+
+// appendStepSummary appends markdown to the GitHub Actions job summary file
+func appendStepSummary(path, markdown string) error {
+	if path == "" {
+		return fmt.Errorf("GITHUB_STEP_SUMMARY is not set")
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if !strings.HasSuffix(markdown, "\n") {
+		markdown += "\n"
+	}
+	_, err = file.WriteString(markdown)
+	return err
+}
+
+func newGitHubClient(apiURL, token string) *GitHubClient {
+	return &GitHubClient{
+		APIURL:     strings.TrimSuffix(apiURL, "/"),
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// do sends a request to the GitHub API, encoding in as JSON and decoding the
+// response into out when they are non-nil
+func (c *GitHubClient) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("error encoding request: %v", err)
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, c.APIURL+path, body)
+	if err != nil {
+		return fmt.Errorf("error creating request: %v", err)
+	}
+	if c.Gitea {
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Authorization", "token "+c.Token)
+	} else {
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	forge := "GitHub"
+	if c.Gitea {
+		forge = "Gitea"
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling %s API: %v", forge, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s API %s %s returned %s: %s", forge, method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("error decoding %s API response: %v", forge, err)
+		}
+	}
+	return nil
+}
+
+// listPRComments returns all comments of the PR. Gitea returns them all at
+// once.
+func (c *GitHubClient) listPRComments(repo string, pr int) ([]issueComment, error) {
+	if c.Gitea {
+		var comments []issueComment
+		err := c.do(http.MethodGet, fmt.Sprintf("/repos/%s/issues/%d/comments", repo, pr), nil, &comments)
+		return comments, err
+	}
+	const perPage = 100
+	var all []issueComment
+	for page := 1; ; page++ {
+		var comments []issueComment
+		path := fmt.Sprintf("/repos/%s/issues/%d/comments?per_page=%d&page=%d", repo, pr, perPage, page)
+		if err := c.do(http.MethodGet, path, nil, &comments); err != nil {
+			return nil, err
+		}
+		all = append(all, comments...)
+		if len(comments) < perPage {
+			return all, nil
+		}
+	}
+}
+
+// findStickyComment returns the ID of the existing gotest-report comment on
+// the PR, or 0 when there is none
+func (c *GitHubClient) findStickyComment(repo string, pr int) (int64, error) {
+	comments, err := c.listPRComments(repo, pr)
+	if err != nil {
+		return 0, err
+	}
+	for _, comment := range comments {
+		if strings.Contains(comment.Body, stickyCommentMarker) {
+			return comment.ID, nil
+		}
+	}
+	return 0, nil
+}
+
+// upsertPRComment creates the sticky report comment, or updates it in place
+// when a previous run already posted one
+func (c *GitHubClient) upsertPRComment(repo string, pr int, body string) (int64, error) {
+	body = stickyCommentMarker + "\n" + body
+
+	id, err := c.findStickyComment(repo, pr)
+	if err != nil {
+		return 0, err
+	}
+
+	payload := map[string]string{"body": body}
+	var result issueComment
+	if id != 0 {
+		err = c.do(http.MethodPatch, fmt.Sprintf("/repos/%s/issues/comments/%d", repo, id), payload, &result)
+	} else {
+		err = c.do(http.MethodPost, fmt.Sprintf("/repos/%s/issues/%d/comments", repo, pr), payload, &result)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return result.ID, nil
+}
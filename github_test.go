@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectPRNumber(t *testing.T) {
+	dir := t.TempDir()
+	writeEvent := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name      string
+		eventPath string
+		ref       string
+		want      int
+	}{
+		{
+			name:      "pull_request event payload",
+			eventPath: writeEvent("pr.json", `{"number":7,"pull_request":{"number":7}}`),
+			want:      7,
+		},
+		{
+			name:      "issue_comment style payload",
+			eventPath: writeEvent("issue.json", `{"number":12}`),
+			want:      12,
+		},
+		{
+			name: "merge ref fallback",
+			ref:  "refs/pull/42/merge",
+			want: 42,
+		},
+		{
+			name:      "missing event file uses ref",
+			eventPath: filepath.Join(dir, "missing.json"),
+			ref:       "refs/pull/3/head",
+			want:      3,
+		},
+		{
+			name: "branch ref has no PR",
+			ref:  "refs/heads/main",
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectPRNumber(tt.eventPath, tt.ref); got != tt.want {
+				t.Errorf("detectPRNumber() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpsertPRComment(t *testing.T) {
+	tests := []struct {
+		name         string
+		existing     []issueComment
+		expectMethod string
+		expectPath   string
+	}{
+		{
+			name:         "creates comment when none exists",
+			existing:     []issueComment{{ID: 1, Body: "unrelated"}},
+			expectMethod: http.MethodPost,
+			expectPath:   "/repos/owner/repo/issues/5/comments",
+		},
+		{
+			name:         "updates existing sticky comment",
+			existing:     []issueComment{{ID: 1, Body: "unrelated"}, {ID: 99, Body: stickyCommentMarker + "\nold report"}},
+			expectMethod: http.MethodPatch,
+			expectPath:   "/repos/owner/repo/issues/comments/99",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotMethod, gotPath, gotBody string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer token" {
+					t.Errorf("missing authorization header")
+				}
+				if r.Method == http.MethodGet {
+					json.NewEncoder(w).Encode(tt.existing)
+					return
+				}
+				var payload map[string]string
+				json.NewDecoder(r.Body).Decode(&payload)
+				gotMethod, gotPath, gotBody = r.Method, r.URL.Path, payload["body"]
+				json.NewEncoder(w).Encode(issueComment{ID: 99})
+			}))
+			defer server.Close()
+
+			client := newGitHubClient(server.URL, "token")
+			if _, err := client.upsertPRComment("owner/repo", 5, "# Test Summary Report"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if gotMethod != tt.expectMethod || gotPath != tt.expectPath {
+				t.Errorf("got %s %s, want %s %s", gotMethod, gotPath, tt.expectMethod, tt.expectPath)
+			}
+			if !strings.HasPrefix(gotBody, stickyCommentMarker) {
+				t.Errorf("comment body should start with the sticky marker, got %q", gotBody)
+			}
+		})
+	}
+}
+
+func TestAppendStepSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "step_summary.md")
+	if err := os.WriteFile(path, []byte("existing\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := appendStepSummary(path, "# Test Summary Report"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	content, _ := os.ReadFile(path)
+	if string(content) != "existing\n# Test Summary Report\n" {
+		t.Errorf("Unexpected summary content: %q", content)
+	}
+
+	if err := appendStepSummary("", "report"); err == nil {
+		t.Error("Expected an error when GITHUB_STEP_SUMMARY is not set")
+	}
+}
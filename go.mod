@@ -1,3 +1,8 @@
 module github.com/dipjyotimetia/gotest-report
 
 go 1.23
+
+require (
+	github.com/lib/pq v1.10.9
+	gopkg.in/yaml.v3 v3.0.1
+)
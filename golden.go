@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	// goldenMention marks output of golden file and snapshot tests
+	goldenMention = regexp.MustCompile(`(?i)golden|snapshot`)
+	// goldenPath matches the file a golden test compares with
+	goldenPath = regexp.MustCompile(`[\w./\\-]+\.(?:golden|snap)\b|testdata[/\\][\w./\\-]+`)
+	// goldenLabel matches the line introducing the expected or actual content
+	goldenLabel = regexp.MustCompile(`(?i)^\s*(want|expected|golden|got|actual)\s*:\s*$`)
+)
+
+// goldenMaxDiffCells bounds the work of the line diff; larger contents are
+// shown as entirely replaced
+const goldenMaxDiffCells = 4_000_000
+
+// goldenContext is the number of unchanged lines around a change in the diff
+const goldenContext = 3
+
+// GoldenLinks links golden files to where they can be edited
+type GoldenLinks struct {
+	EditURL  string // Base URL files are appended to, e.g. https://github.com/acme/shop/edit/main/
+	Resolver sourceResolver
+}
+
+// goldenFailure is the expected and actual content of a failed golden test
+type goldenFailure struct {
+	File     string // As printed by the test, relative to its package
+	Expected []string
+	Actual   []string
+}
+
+// indentOf returns the number of leading spaces and tabs of a line
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// labeledBlock returns the lines indented below output[start], without
+// their common indentation, and the index after them
+func labeledBlock(output []string, start int) ([]string, int) {
+	indent := indentOf(output[start])
+	end := start + 1
+	for end < len(output) && (strings.TrimSpace(output[end]) == "" || indentOf(output[end]) > indent) {
+		end++
+	}
+	// Trailing blank lines separate the block from what follows
+	for end > start+1 && strings.TrimSpace(output[end-1]) == "" {
+		end--
+	}
+	block := output[start+1 : end]
+	common := -1
+	for _, line := range block {
+		if strings.TrimSpace(line) != "" && (common < 0 || indentOf(line) < common) {
+			common = indentOf(line)
+		}
+	}
+	var lines []string
+	for _, line := range block {
+		if len(line) >= common && common > 0 {
+			line = line[common:]
+		}
+		lines = append(lines, line)
+	}
+	return lines, end
+}
+
+// parseGoldenFailure finds the want/got (or expected/actual) blocks printed
+// by a golden file or snapshot test, or returns nil
+func parseGoldenFailure(output []string) *goldenFailure {
+	var failure goldenFailure
+	mentioned := false
+	found := 0
+	for i := 0; i < len(output); i++ {
+		line := output[i]
+		if goldenMention.MatchString(line) || goldenPath.MatchString(line) {
+			mentioned = true
+			if failure.File == "" {
+				failure.File = strings.ReplaceAll(goldenPath.FindString(line), `\`, "/")
+			}
+		}
+		m := goldenLabel.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		block, end := labeledBlock(output, i)
+		switch strings.ToLower(m[1]) {
+		case "want", "expected", "golden":
+			failure.Expected = block
+		default:
+			failure.Actual = block
+		}
+		found++
+		i = end - 1
+	}
+	if !mentioned || found < 2 {
+		return nil
+	}
+	return &failure
+}
+
+// diffOp is a line of a diff: ' ' unchanged, '-' expected only, '+' actual only
+type diffOp struct {
+	Kind byte
+	Line string
+}
+
+// diffLines computes a line diff from the longest common subsequence
+func diffLines(a, b []string) []diffOp {
+	var ops []diffOp
+	if len(a)*len(b) > goldenMaxDiffCells {
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+	// lcs[i][j] is the length of the common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i, j = i+1, j+1
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	return ops
+}
+
+// unifiedDiff renders the diff of the expected and actual lines with
+// goldenContext lines around each change
+func unifiedDiff(expectedName string, expected, actual []string) []string {
+	ops := diffLines(expected, actual)
+	lines := []string{"--- " + expectedName, "+++ actual"}
+	for start := 0; start < len(ops); {
+		// Find the next change and the end of its hunk
+		first := start
+		for first < len(ops) && ops[first].Kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		from := max(first-goldenContext, start)
+		to, unchanged := first, 0
+		for to < len(ops) && unchanged <= 2*goldenContext {
+			if ops[to].Kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+			to++
+		}
+		to -= max(unchanged-goldenContext, 0)
+
+		// Line numbers of the hunk in both files
+		oldLine, newLine := 1, 1
+		for _, op := range ops[:from] {
+			if op.Kind != '+' {
+				oldLine++
+			}
+			if op.Kind != '-' {
+				newLine++
+			}
+		}
+		oldCount, newCount := 0, 0
+		var body []string
+		for _, op := range ops[from:to] {
+			if op.Kind != '+' {
+				oldCount++
+			}
+			if op.Kind != '-' {
+				newCount++
+			}
+			body = append(body, string(op.Kind)+op.Line)
+		}
+		lines = append(lines, fmt.Sprintf("@@ -%d,%d +%d,%d @@", oldLine, oldCount, newLine, newCount))
+		lines = append(lines, body...)
+		start = to
+	}
+	return lines
+}
+
+// goldenFileLink returns the URL to edit a golden file printed by a test of
+// pkg, or an empty string when it cannot be resolved
+func (g *GoldenLinks) goldenFileLink(pkg, file string) string {
+	if g == nil || g.EditURL == "" || file == "" {
+		return ""
+	}
+	repoFile := g.Resolver.absoluteFile(file)
+	if !isWindowsPath(file) && !strings.HasPrefix(file, "/") {
+		// Tests run in their package directory
+		dir := g.Resolver.packageDir(pkg)
+		if dir == pkg {
+			return ""
+		}
+		repoFile = path.Join(dir, file)
+	}
+	if repoFile == "" || strings.HasPrefix(repoFile, "..") {
+		return ""
+	}
+	return strings.TrimSuffix(g.EditURL, "/") + "/" + (&url.URL{Path: repoFile}).EscapedPath()
+}
+
+// writeGoldenDiff renders the difference between the expected and actual
+// content of a failed golden file test as a unified diff
+func writeGoldenDiff(sb *strings.Builder, result *TestResult, opts ReportOptions) {
+	failure := parseGoldenFailure(result.Output)
+	if failure == nil {
+		return
+	}
+	name := "expected"
+	if failure.File != "" {
+		name = failure.File
+	}
+	sb.WriteString("**Golden file diff**")
+	if failure.File != "" {
+		sb.WriteString(" of " + codeSpan(failure.File))
+		if link := opts.GoldenLinks.goldenFileLink(result.Package, failure.File); link != "" {
+			sb.WriteString(fmt.Sprintf(" ([edit](%s))", link))
+		}
+	}
+	sb.WriteString(":\n\n")
+	writeCodeBlock(sb, "diff", unifiedDiff(name, failure.Expected, failure.Actual))
+}
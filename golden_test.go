@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseGoldenFailure(t *testing.T) {
+	output := []string{
+		"=== RUN   TestRender",
+		"    render_test.go:25: output does not match testdata\\render.golden",
+		"        want:",
+		"            <ul>",
+		"              <li>a</li>",
+		"            </ul>",
+		"        got:",
+		"            <ul>",
+		"              <li>b</li>",
+		"            </ul>",
+		"--- FAIL: TestRender (0.00s)",
+	}
+	failure := parseGoldenFailure(output)
+	if failure == nil {
+		t.Fatal("Expected a golden failure")
+	}
+	if failure.File != "testdata/render.golden" {
+		t.Errorf("Expected the golden file with slashes, got %q", failure.File)
+	}
+	if got := strings.Join(failure.Expected, "\n"); got != "<ul>\n  <li>a</li>\n</ul>" {
+		t.Errorf("Unexpected expected content:\n%s", got)
+	}
+	if got := strings.Join(failure.Actual, "\n"); got != "<ul>\n  <li>b</li>\n</ul>" {
+		t.Errorf("Unexpected actual content:\n%s", got)
+	}
+
+	// want/got output of other tests is left alone
+	if failure := parseGoldenFailure([]string{"    sum_test.go:5: sums differ", "        want:", "            3", "        got:", "            4"}); failure != nil {
+		t.Errorf("Expected no golden failure without a golden file, got %+v", failure)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	var expected, actual []string
+	for i := 1; i <= 20; i++ {
+		line := strings.Repeat("x", i)
+		expected = append(expected, line)
+		if i != 2 && i != 15 {
+			actual = append(actual, line)
+		}
+		if i == 15 {
+			actual = append(actual, "changed")
+		}
+	}
+	got := strings.Join(unifiedDiff("a.golden", expected, actual), "\n")
+	want := strings.Join([]string{
+		"--- a.golden",
+		"+++ actual",
+		"@@ -1,5 +1,4 @@",
+		" x",
+		"-xx",
+		" xxx",
+		" xxxx",
+		" xxxxx",
+		"@@ -12,7 +11,7 @@",
+		" " + strings.Repeat("x", 12),
+		" " + strings.Repeat("x", 13),
+		" " + strings.Repeat("x", 14),
+		"-" + strings.Repeat("x", 15),
+		"+changed",
+		" " + strings.Repeat("x", 16),
+		" " + strings.Repeat("x", 17),
+		" " + strings.Repeat("x", 18),
+	}, "\n")
+	if got != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestGoldenDiffReport(t *testing.T) {
+	input := `{"Action":"run","Package":"github.com/acme/shop/cart","Test":"TestRender"}
+{"Action":"output","Package":"github.com/acme/shop/cart","Test":"TestRender","Output":"    render_test.go:25: golden file testdata/cart.golden differs\n"}
+{"Action":"output","Package":"github.com/acme/shop/cart","Test":"TestRender","Output":"        expected:\n"}
+{"Action":"output","Package":"github.com/acme/shop/cart","Test":"TestRender","Output":"            total: 3\n"}
+{"Action":"output","Package":"github.com/acme/shop/cart","Test":"TestRender","Output":"        actual:\n"}
+{"Action":"output","Package":"github.com/acme/shop/cart","Test":"TestRender","Output":"            total: 4\n"}
+{"Action":"fail","Package":"github.com/acme/shop/cart","Test":"TestRender","Elapsed":0.1}
+`
+	data, err := processTestEvents(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	report := renderMarkdownReport(data, ReportOptions{GoldenLinks: &GoldenLinks{
+		EditURL:  "https://github.com/acme/shop/edit/main/",
+		Resolver: sourceResolver{ModulePath: "github.com/acme/shop", Root: "/src/shop"},
+	}})
+	for _, expected := range []string{
+		"**Golden file diff** of `testdata/cart.golden` ([edit](https://github.com/acme/shop/edit/main/cart/testdata/cart.golden)):",
+		"```diff\n--- testdata/cart.golden\n+++ actual\n@@ -1,1 +1,1 @@\n-total: 3\n+total: 4\n```",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Expected the report to contain %q, got:\n%s", expected, report)
+		}
+	}
+}
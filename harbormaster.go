@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// maxHarbormasterDetailLines is the number of output lines sent as the
+// details of a failed test
+const maxHarbormasterDetailLines = 50
+
+// harbormasterUnit is a unit test result of harbormaster.sendmessage
+type harbormasterUnit struct {
+	Name      string  `json:"name"`
+	Result    string  `json:"result"` // "pass", "fail", "skip", "broken" or "unsound"
+	Namespace string  `json:"namespace"`
+	Engine    string  `json:"engine"`
+	Duration  float64 `json:"duration"`
+	Details   string  `json:"details,omitempty"`
+	Format    string  `json:"format,omitempty"` // "remarkup" for the details
+}
+
+// harbormasterMessage is the harbormaster.sendmessage call reporting a build
+// target as passed or failed along with its unit test results
+type harbormasterMessage struct {
+	BuildTargetPHID string             `json:"buildTargetPHID"`
+	Type            string             `json:"type"` // "pass" or "fail"
+	Unit            []harbormasterUnit `json:"unit"`
+}
+
+// harbormasterResult maps a test to a Harbormaster unit result. Quarantined
+// and waived failures are "unsound", which does not fail the build target.
+func harbormasterResult(result *TestResult) string {
+	switch result.Status {
+	case "PASS":
+		return "pass"
+	case "SKIP":
+		return "skip"
+	case "FAIL":
+		if result.Quarantine != nil || result.Waiver != nil {
+			return "unsound"
+		}
+		return "fail"
+	}
+	return "broken"
+}
+
+// buildHarbormasterMessage lists every test, subtests included, and every
+// package failing outside of its tests as a unit result
+func buildHarbormasterMessage(data *ReportData, buildTarget string) harbormasterMessage {
+	msg := harbormasterMessage{BuildTargetPHID: buildTarget, Type: "pass", Unit: []harbormasterUnit{}}
+	if reportStatus(data) == "FAILED" {
+		msg.Type = "fail"
+	}
+	add := func(result *TestResult) {
+		unit := harbormasterUnit{
+			Name:      result.Name,
+			Result:    harbormasterResult(result),
+			Namespace: result.Package,
+			Engine:    "go test",
+			Duration:  result.Duration,
+		}
+		if unit.Result != "pass" && len(result.Output) > 0 {
+			lines := failureOutput(result.Output, ReportOptions{MaxOutputLines: maxHarbormasterDetailLines})
+			unit.Details, unit.Format = "```\n"+strings.Join(lines, "\n")+"\n```", "remarkup"
+		}
+		msg.Unit = append(msg.Unit, unit)
+	}
+	for _, name := range data.SortedTestNames {
+		result := data.Results[name]
+		add(result)
+		walkSubtests(data, result, add)
+	}
+	for _, pkg := range packageFailures(data) {
+		output := pkg.BuildOutput
+		if len(output) == 0 {
+			output = pkg.Output
+		}
+		msg.Unit = append(msg.Unit, harbormasterUnit{
+			Name:      "package",
+			Result:    "broken",
+			Namespace: pkg.Name,
+			Engine:    "go test",
+			Duration:  pkg.Duration,
+			Details:   "```\n" + strings.Join(failureOutput(output, ReportOptions{MaxOutputLines: maxHarbormasterDetailLines}), "\n") + "\n```",
+			Format:    "remarkup",
+		})
+	}
+	return msg
+}
+
+// sendHarbormasterMessage calls harbormaster.sendmessage on the Conduit API
+// of a Phabricator install
+func sendHarbormasterMessage(baseURL, token string, msg harbormasterMessage) error {
+	params, err := json.Marshal(struct {
+		harbormasterMessage
+		Conduit map[string]string `json:"__conduit__"`
+	}{msg, map[string]string{"token": token}})
+	if err != nil {
+		return fmt.Errorf("error encoding request: %v", err)
+	}
+	form := url.Values{"params": {string(params)}, "output": {"json"}, "__conduit__": {"1"}}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.PostForm(strings.TrimSuffix(baseURL, "/")+"/api/harbormaster.sendmessage", form)
+	if err != nil {
+		return fmt.Errorf("error calling Conduit API: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Conduit API returned %s", resp.Status)
+	}
+
+	// Conduit reports errors in the body of a 200 response
+	var result struct {
+		ErrorCode string `json:"error_code"`
+		ErrorInfo string `json:"error_info"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("error decoding Conduit API response: %v", err)
+	}
+	if result.ErrorCode != "" {
+		return fmt.Errorf("Conduit API returned %s: %s", result.ErrorCode, result.ErrorInfo)
+	}
+	return nil
+}
+
+// runPostHarbormaster reports the unit test results to a Harbormaster build
+// target, which shows them on the Differential revision
+func runPostHarbormaster(args []string) int {
+	fs := flag.NewFlagSet("post harbormaster", flag.ExitOnError)
+	logFlags := registerLogFlags(fs)
+	configFile := registerConfigFlag(fs)
+	var inputFiles stringList
+	fs.Var(&inputFiles, "input", "go test -json output file; repeat or use a glob to merge sharded runs (default is stdin)")
+	phabricatorURL := fs.String("phabricator-url", os.Getenv("PHABRICATOR_URL"), "Base URL of the Phabricator install (default is $PHABRICATOR_URL)")
+	buildTarget := fs.String("build-target", "", "PHID of the Harbormaster build target, ${target.phid} in the build step")
+	fs.Parse(args)
+	if err := logFlags.apply(); err != nil {
+		logger.Errorf("Error: %v", err)
+		return 2
+	}
+	if *phabricatorURL == "" || *buildTarget == "" {
+		logger.Errorf("Usage: gotest-report post harbormaster -phabricator-url URL -build-target PHID [-input FILE]")
+		return 2
+	}
+
+	_, normalize, err := commandConfig(*configFile)
+	if err != nil {
+		logger.Errorf("Error loading config: %v", err)
+		return 1
+	}
+	reportData, err := loadReports(inputFiles, ParseOptions{Normalize: normalize})
+	if err != nil {
+		logger.Errorf("Error %v", err)
+		return 1
+	}
+	token := os.Getenv("CONDUIT_TOKEN")
+	if token == "" {
+		logger.Errorf("Error posting to Harbormaster: CONDUIT_TOKEN is not set")
+		return 1
+	}
+	msg := buildHarbormasterMessage(reportData, *buildTarget)
+	if err := sendHarbormasterMessage(*phabricatorURL, token, msg); err != nil {
+		logger.Errorf("Error posting to Harbormaster: %v", err)
+		return 1
+	}
+	logger.Printf("%d unit result(s) sent to build target %s (%s)", len(msg.Unit), *buildTarget, msg.Type)
+	return 0
+}
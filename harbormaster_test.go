@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBuildHarbormasterMessage(t *testing.T) {
+	data, err := processTestEvents(strings.NewReader(`{"Action":"run","Package":"pkg","Test":"TestA"}
+{"Action":"run","Package":"pkg","Test":"TestA/sub"}
+{"Action":"output","Package":"pkg","Test":"TestA/sub","Output":"    a_test.go:7: boom\n"}
+{"Action":"fail","Package":"pkg","Test":"TestA/sub","Elapsed":0.5}
+{"Action":"fail","Package":"pkg","Test":"TestA","Elapsed":0.5}
+{"Action":"run","Package":"pkg","Test":"TestB"}
+{"Action":"skip","Package":"pkg","Test":"TestB"}
+{"Action":"fail","Package":"pkg","Elapsed":0.6}
+{"Action":"output","Package":"broken","Output":"FAIL\tbroken [build failed]\n"}
+{"Action":"fail","Package":"broken"}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := buildHarbormasterMessage(data, "PHID-HMBT-1")
+	if msg.Type != "fail" || msg.BuildTargetPHID != "PHID-HMBT-1" {
+		t.Errorf("Expected a failed message for the build target, got %+v", msg)
+	}
+	var results []string
+	for _, unit := range msg.Unit {
+		results = append(results, unit.Namespace+" "+unit.Name+" "+unit.Result)
+	}
+	expected := "pkg TestA fail, pkg TestA/sub fail, pkg TestB skip, broken package broken"
+	if got := strings.Join(results, ", "); got != expected {
+		t.Errorf("Expected units %q, got %q", expected, got)
+	}
+	if sub := msg.Unit[1]; sub.Details != "```\n    a_test.go:7: boom\n```" || sub.Format != "remarkup" || sub.Duration != 0.5 {
+		t.Errorf("Expected the output of the subtest as remarkup details, got %+v", sub)
+	}
+
+	data.Results[testKey("pkg", "TestA")].Quarantine = &QuarantineEntry{Test: "TestA"}
+	if got := harbormasterResult(data.Results[testKey("pkg", "TestA")]); got != "unsound" {
+		t.Errorf("Expected a quarantined failure to be unsound, got %q", got)
+	}
+}
+
+func TestSendHarbormasterMessage(t *testing.T) {
+	var params struct {
+		harbormasterMessage
+		Conduit map[string]string `json:"__conduit__"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/harbormaster.sendmessage" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		json.Unmarshal([]byte(r.FormValue("params")), &params)
+		if params.BuildTargetPHID == "PHID-HMBT-bad" {
+			w.Write([]byte(`{"result":null,"error_code":"ERR-CONDUIT-CORE","error_info":"No such build target."}`))
+			return
+		}
+		w.Write([]byte(`{"result":null,"error_code":null,"error_info":null}`))
+	}))
+	defer server.Close()
+
+	msg := harbormasterMessage{BuildTargetPHID: "PHID-HMBT-1", Type: "pass", Unit: []harbormasterUnit{{Name: "TestA", Result: "pass"}}}
+	if err := sendHarbormasterMessage(server.URL+"/", "api-token", msg); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if params.Conduit["token"] != "api-token" || params.Type != "pass" || len(params.Unit) != 1 {
+		t.Errorf("Unexpected params %+v", params)
+	}
+
+	msg.BuildTargetPHID = "PHID-HMBT-bad"
+	if err := sendHarbormasterMessage(server.URL, "api-token", msg); err == nil || !strings.Contains(err.Error(), "No such build target") {
+		t.Errorf("Expected the Conduit error, got %v", err)
+	}
+}
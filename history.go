@@ -0,0 +1,313 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RunRecord is the summary of a single run persisted in the history store
+type RunRecord struct {
+	Timestamp time.Time             `json:"timestamp"`
+	Total     int                   `json:"total"`
+	Passed    int                   `json:"passed"`
+	Failed    int                   `json:"failed"`
+	Skipped   int                   `json:"skipped"`
+	Duration  float64               `json:"duration"`
+	WallClock float64               `json:"wall_clock,omitempty"` // Zero for inputs without timestamps
+	Tests     map[string]TestRecord `json:"tests"`                // Keyed as ReportData.Results
+
+	FailedPackages int    `json:"failed_packages,omitempty"`
+	Environment    string `json:"environment,omitempty"` // Set with -environment, e.g. "staging"
+	RunID          string `json:"run_id,omitempty"`      // Set with -run-id or generated
+}
+
+// TestRecord is the outcome of a single test within a RunRecord
+type TestRecord struct {
+	Package     string  `json:"package,omitempty"`
+	Status      string  `json:"status"`
+	Duration    float64 `json:"duration"`
+	Fingerprint string  `json:"fingerprint,omitempty"` // Normalized failure, set for failed tests
+}
+
+// historyFilePrefix prefixes every run file in the history directory
+const historyFilePrefix = "run-"
+
+// newRunRecord summarizes a run for the history store
+func newRunRecord(data *ReportData, now time.Time) *RunRecord {
+	record := &RunRecord{
+		Timestamp: now.UTC(),
+		Total:     data.TotalTests,
+		Passed:    data.PassedTests,
+		Failed:    data.FailedTests,
+		Skipped:   data.SkippedTests,
+		Duration:  data.TotalDuration,
+		WallClock: data.WallClock(),
+		Tests:     make(map[string]TestRecord, len(data.Results)),
+
+		FailedPackages: data.FailedPackages,
+		RunID:          data.RunID,
+	}
+	for _, result := range data.Results {
+		record.Tests[testKey(result.Package, result.Name)] = TestRecord{
+			Package:     result.Package,
+			Status:      result.Status,
+			Duration:    result.Duration,
+			Fingerprint: result.Fingerprint,
+		}
+	}
+	return record
+}
+
+// UnmarshalJSON reads a run record, re-keying the tests of history files
+// written before tests were keyed by package
+func (r *RunRecord) UnmarshalJSON(content []byte) error {
+	type plain RunRecord
+	if err := json.Unmarshal(content, (*plain)(r)); err != nil {
+		return err
+	}
+	tests := make(map[string]TestRecord, len(r.Tests))
+	for key, test := range r.Tests {
+		if !strings.HasPrefix(key, test.Package+".") {
+			key = testKey(test.Package, key)
+		}
+		tests[key] = test
+	}
+	if r.Tests != nil {
+		r.Tests = tests
+	}
+	return nil
+}
+
+// recordTestName returns the name of the test stored under key
+func recordTestName(key string, test TestRecord) string {
+	return strings.TrimPrefix(key, test.Package+".")
+}
+
+// recordTestLabel renders the test stored under key with its package
+func recordTestLabel(key string, test TestRecord) string {
+	name := escapeMarkdown(recordTestName(key, test))
+	if test.Package == "" {
+		return name
+	}
+	return fmt.Sprintf("%s (%s)", name, escapeMarkdown(test.Package))
+}
+
+// PassRate returns the percentage of passed tests, or 0 for an empty run
+func (r *RunRecord) PassRate() float64 {
+	if r.Total == 0 {
+		return 0
+	}
+	return float64(r.Passed) / float64(r.Total) * 100
+}
+
+// Status returns the overall status of the run, as reportStatus does
+func (r *RunRecord) Status() string {
+	if r.FailedPackages > 0 {
+		return "FAILED"
+	}
+	return runStatus(r.Total, r.Failed, r.Skipped)
+}
+
+// saveRunRecord writes record into dir as a new run file
+func saveRunRecord(dir string, record *RunRecord) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, runFileName(record)), content, 0o644)
+}
+
+// runFileName is the name of the file storing record in a history directory
+func runFileName(record *RunRecord) string {
+	name := historyFilePrefix + record.Timestamp.UTC().Format("20060102T150405.000000000Z")
+	if record.RunID != "" {
+		// Keep IDs given with -run-id from escaping the directory
+		name += "-" + strings.NewReplacer("/", "_", "\\", "_").Replace(record.RunID)
+	}
+	return name + ".json"
+}
+
+// loadHistory returns up to limit of the most recent runs in dir, oldest
+// first. A missing directory is an empty history.
+func loadHistory(dir string, limit int) ([]*RunRecord, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var records []*RunRecord
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, historyFilePrefix) || filepath.Ext(name) != ".json" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		var record RunRecord
+		if err := json.Unmarshal(content, &record); err != nil {
+			return nil, fmt.Errorf("error parsing history file %s: %v", name, err)
+		}
+		records = append(records, &record)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+	if limit > 0 && len(records) > limit {
+		records = records[len(records)-limit:]
+	}
+	return records, nil
+}
+
+// sparkline renders values in the 0-100 range as a unicode sparkline
+func sparkline(values []float64) string {
+	levels := []rune("▁▂▃▄▅▆▇█")
+	var sb strings.Builder
+	for _, v := range values {
+		idx := int(v / 100 * float64(len(levels)-1))
+		if idx < 0 {
+			idx = 0
+		} else if idx >= len(levels) {
+			idx = len(levels) - 1
+		}
+		sb.WriteRune(levels[idx])
+	}
+	return sb.String()
+}
+
+// statusChanges compares the current run against the previous one and
+// returns the tests that started failing and the tests that were fixed
+func statusChanges(previous, current *RunRecord) (newlyFailing, newlyFixed []string) {
+	for name, test := range current.Tests {
+		before, existed := previous.Tests[name]
+		switch {
+		case test.Status == "FAIL" && (!existed || before.Status != "FAIL"):
+			newlyFailing = append(newlyFailing, name)
+		case test.Status == "PASS" && existed && before.Status == "FAIL":
+			newlyFixed = append(newlyFixed, name)
+		}
+	}
+	sort.Strings(newlyFailing)
+	sort.Strings(newlyFixed)
+	return newlyFailing, newlyFixed
+}
+
+// changedFailures returns the tests that failed in both runs with a
+// different normalized failure, e.g. a new assertion failing in a test that
+// was already broken
+func changedFailures(previous, current *RunRecord) []string {
+	var changed []string
+	for name, test := range current.Tests {
+		before, existed := previous.Tests[name]
+		if test.Status == "FAIL" && existed && before.Status == "FAIL" &&
+			test.Fingerprint != "" && before.Fingerprint != "" && test.Fingerprint != before.Fingerprint {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// flakyTests returns the tests that both passed and failed across runs
+func flakyTests(runs []*RunRecord) []string {
+	passed := make(map[string]bool)
+	failed := make(map[string]bool)
+	for _, run := range runs {
+		for name, test := range run.Tests {
+			switch test.Status {
+			case "PASS":
+				passed[name] = true
+			case "FAIL":
+				failed[name] = true
+			}
+		}
+	}
+
+	var flaky []string
+	for name := range failed {
+		if passed[name] {
+			flaky = append(flaky, name)
+		}
+	}
+	sort.Strings(flaky)
+	return flaky
+}
+
+// currentFlakyTests returns the tests flaky across history and the current run
+func currentFlakyTests(data *ReportData, history []*RunRecord) []string {
+	if len(history) == 0 {
+		return nil
+	}
+	runs := append(append([]*RunRecord(nil), history...), newRunRecord(data, history[len(history)-1].Timestamp))
+	return flakyTests(runs)
+}
+
+// writeTrendsSection renders how the current run compares with the stored
+// history. Nothing is rendered until there is at least one previous run.
+func writeTrendsSection(sb *strings.Builder, data *ReportData, history []*RunRecord) {
+	if len(history) == 0 {
+		return
+	}
+
+	current := newRunRecord(data, time.Now())
+	runs := append(append([]*RunRecord(nil), history...), current)
+
+	var rates []float64
+	for _, run := range runs {
+		rates = append(rates, run.PassRate())
+	}
+
+	sb.WriteString("## Trends\n\n")
+	sb.WriteString(fmt.Sprintf("**Pass rate over the last %d runs:** %s (%.1f%% → %.1f%%)\n\n",
+		len(runs), sparkline(rates), rates[0], rates[len(rates)-1]))
+
+	newlyFailing, newlyFixed := statusChanges(history[len(history)-1], current)
+	if len(newlyFailing) > 0 {
+		sb.WriteString("**Newly failing tests:**\n\n")
+		for _, key := range newlyFailing {
+			sb.WriteString(fmt.Sprintf("- %s %s\n", statusEmoji("FAIL"), recordTestLabel(key, current.Tests[key])))
+		}
+		sb.WriteString("\n")
+	}
+	if changed := changedFailures(history[len(history)-1], current); len(changed) > 0 {
+		sb.WriteString("**Failing differently than in the previous run:**\n\n")
+		for _, key := range changed {
+			sb.WriteString(fmt.Sprintf("- %s %s\n", statusEmoji("FAIL"), recordTestLabel(key, current.Tests[key])))
+		}
+		sb.WriteString("\n")
+	}
+	if len(newlyFixed) > 0 {
+		sb.WriteString("**Newly fixed tests:**\n\n")
+		for _, key := range newlyFixed {
+			sb.WriteString(fmt.Sprintf("- %s %s\n", statusEmoji("PASS"), recordTestLabel(key, current.Tests[key])))
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("<details>\n")
+	sb.WriteString("<summary>Click to expand run history</summary>\n\n")
+	sb.WriteString("| Run | Total | Failed | Pass Rate | Duration |\n")
+	sb.WriteString("| --- | ----- | ------ | --------- | -------- |\n")
+	for i := len(runs) - 1; i >= 0; i-- {
+		run := runs[i]
+		label := run.Timestamp.Format("2006-01-02 15:04")
+		if run == current {
+			label = "**current**"
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %d | %d | %.1f%% | %.2fs |\n",
+			label, run.Total, run.Failed, run.PassRate(), run.Duration))
+	}
+	sb.WriteString("\n</details>\n\n")
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistoryRoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "history")
+	start := time.Date(2024, 3, 20, 15, 30, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		data := &ReportData{
+			TotalTests:  2,
+			PassedTests: i,
+			Results: map[string]*TestResult{
+				"TestA": {Name: "TestA", Package: "pkg/a", Status: "PASS", Duration: float64(i)},
+			},
+		}
+		if err := saveRunRecord(dir, newRunRecord(data, start.Add(time.Duration(i)*time.Hour))); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644)
+
+	records, err := loadHistory(dir, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	if records[0].Passed != 1 || records[1].Passed != 2 {
+		t.Errorf("Expected the two most recent runs oldest first, got passed=%d,%d", records[0].Passed, records[1].Passed)
+	}
+	if records[1].Tests[testKey("pkg/a", "TestA")].Package != "pkg/a" {
+		t.Errorf("Test records not persisted: %+v", records[1].Tests)
+	}
+
+	missing, err := loadHistory(filepath.Join(dir, "missing"), 10)
+	if err != nil || len(missing) != 0 {
+		t.Errorf("Missing history dir should be empty, got %v, %v", missing, err)
+	}
+}
+
+func TestTrendsSection(t *testing.T) {
+	previous := &RunRecord{
+		Timestamp: time.Date(2024, 3, 19, 10, 0, 0, 0, time.UTC),
+		Total:     3,
+		Passed:    2,
+		Failed:    1,
+		Tests: map[string]TestRecord{
+			testKey("", "TestFixed"):  {Status: "FAIL"},
+			testKey("", "TestBroken"): {Status: "PASS"},
+			testKey("", "TestStable"): {Status: "PASS"},
+		},
+	}
+	data := &ReportData{
+		TotalTests:      3,
+		PassedTests:     2,
+		FailedTests:     1,
+		SortedTestNames: []string{"TestBroken", "TestFixed", "TestStable"},
+		Results: map[string]*TestResult{
+			"TestFixed":  {Name: "TestFixed", Status: "PASS"},
+			"TestBroken": {Name: "TestBroken", Status: "FAIL"},
+			"TestStable": {Name: "TestStable", Status: "PASS"},
+		},
+	}
+
+	markdown := renderMarkdownReport(data, ReportOptions{History: []*RunRecord{previous}})
+
+	for _, expected := range []string{
+		"## Trends",
+		"**Pass rate over the last 2 runs:**",
+		"- ❌ TestBroken",
+		"- ✅ TestFixed",
+		"| 2024-03-19 10:00 | 3 | 1 | 66.7% |",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected %q in trends section", expected)
+		}
+	}
+	if strings.Contains(markdown, "TestStable\n") && strings.Contains(markdown, "- ✅ TestStable") {
+		t.Error("Stable tests should not be listed as fixed")
+	}
+
+	if strings.Contains(generateMarkdownReport(data), "## Trends") {
+		t.Error("Trends section should be omitted without history")
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got := sparkline([]float64{0, 50, 100}); got != "▁▄█" {
+		t.Errorf("sparkline() = %q, want %q", got, "▁▄█")
+	}
+}
+
+func TestRunRecordSameNameInPackages(t *testing.T) {
+	data := &ReportData{Results: map[string]*TestResult{
+		testKey("ex/a", "TestNew"): {Name: "TestNew", Package: "ex/a", Status: "FAIL"},
+		testKey("ex/b", "TestNew"): {Name: "TestNew", Package: "ex/b", Status: "PASS"},
+	}}
+	current := newRunRecord(data, time.Now())
+	if len(current.Tests) != 2 {
+		t.Fatalf("Expected both tests to be recorded, got %+v", current.Tests)
+	}
+
+	previous := &RunRecord{Tests: map[string]TestRecord{
+		testKey("ex/a", "TestNew"): {Package: "ex/a", Status: "PASS"},
+		testKey("ex/b", "TestNew"): {Package: "ex/b", Status: "PASS"},
+	}}
+	failing, fixed := statusChanges(previous, current)
+	if len(failing) != 1 || failing[0] != testKey("ex/a", "TestNew") || len(fixed) != 0 {
+		t.Errorf("Expected only TestNew of ex/a to be newly failing, got %v and %v", failing, fixed)
+	}
+	if label := recordTestLabel(failing[0], current.Tests[failing[0]]); label != "TestNew (ex/a)" {
+		t.Errorf("Unexpected label %q", label)
+	}
+}
+
+func TestLoadNameKeyedHistory(t *testing.T) {
+	// History files written before tests were keyed by package
+	dir := t.TempDir()
+	content := `{"timestamp":"2024-03-20T15:30:00Z","total":2,"tests":{"TestA":{"package":"pkg/a","status":"PASS","duration":1},"TestB":{"status":"FAIL","duration":2}}}`
+	os.WriteFile(filepath.Join(dir, "run-20240320T153000.000000000Z.json"), []byte(content), 0o644)
+
+	records, err := loadHistory(dir, 0)
+	if err != nil || len(records) != 1 {
+		t.Fatalf("Expected one run, got %v, %v", records, err)
+	}
+	tests := records[0].Tests
+	if len(tests) != 2 || tests[testKey("pkg/a", "TestA")].Duration != 1 || tests[testKey("", "TestB")].Status != "FAIL" {
+		t.Errorf("Expected the tests to be re-keyed by package, got %+v", tests)
+	}
+
+	// Records in the current format are kept as they are
+	encoded, _ := json.Marshal(records[0])
+	var again RunRecord
+	if err := json.Unmarshal(encoded, &again); err != nil || !reflect.DeepEqual(again.Tests, tests) {
+		t.Errorf("Expected the keys to survive a round trip, got %+v, %v", again.Tests, err)
+	}
+}
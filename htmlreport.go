@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"time"
+)
+
+// interactiveTemplate is a self-contained page that renders the JSON report
+// embedded in it, with client-side search, filters and sorting
+var interactiveTemplate = template.Must(template.New("report").Funcs(template.FuncMap{"shortSHA": shortSHA}).Parse(`<!DOCTYPE html>
+<html lang="en" data-theme="{{.Theme}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} - {{.Status}}</title>
+<script>if (document.documentElement.dataset.theme === "auto") document.documentElement.dataset.theme = matchMedia("(prefers-color-scheme: dark)").matches ? "dark" : "light";</script>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0; color: #1f2328; background: #f6f8fa; }
+header { background: #fff; border-bottom: 1px solid #d0d7de; padding: 16px 24px; }
+h1 { font-size: 20px; margin: 0 0 8px; }
+main { padding: 16px 24px; }
+.status { display: inline-block; padding: 2px 8px; border-radius: 12px; color: #fff; font-size: 12px; font-weight: 600; vertical-align: middle; }
+.PASSED, .PASS { background: #1a7f37; } .FAILED, .FAIL { background: #cf222e; } .SKIPPED, .SKIP { background: #9a6700; } .UNKNOWN { background: #6e7781; }
+.summary span { margin-right: 16px; }
+.controls { display: flex; gap: 8px; flex-wrap: wrap; margin-bottom: 12px; }
+.controls input { flex: 1; min-width: 200px; }
+input, select { padding: 6px 8px; border: 1px solid #d0d7de; border-radius: 6px; font-size: 14px; }
+table { width: 100%; border-collapse: collapse; background: #fff; border: 1px solid #d0d7de; }
+th, td { text-align: left; padding: 6px 10px; border-bottom: 1px solid #eaeef2; font-size: 14px; vertical-align: top; }
+th { background: #f6f8fa; cursor: pointer; user-select: none; }
+td.duration, th.duration { text-align: right; white-space: nowrap; }
+tr.test { cursor: pointer; } tr.test:hover { background: #f6f8fa; }
+tr.subtest td.name { padding-left: 32px; }
+pre { margin: 0; padding: 8px; background: #f6f8fa; border-radius: 6px; overflow-x: auto; font-size: 12px; max-height: 480px; }
+.muted { color: #656d76; }
+.packages, .stats { margin-top: 24px; }
+.stats progress { width: 100%; }
+.coverage { margin-bottom: 24px; }
+.coverage svg { width: 100%; height: auto; background: #fff; border: 1px solid #d0d7de; }
+.coverage rect { stroke: #fff; stroke-width: 1; }
+.coverage rect.package { fill: none; stroke: #1f2328; stroke-width: 2; }
+.coverage text { font-size: 11px; fill: #fff; pointer-events: none; }
+.coverage text.package { fill: #1f2328; font-weight: 600; }
+[data-theme=dark] body { color: #e6edf3; background: #0d1117; }
+[data-theme=dark] header, [data-theme=dark] table, [data-theme=dark] .coverage svg { background: #161b22; border-color: #30363d; }
+[data-theme=dark] th, [data-theme=dark] pre, [data-theme=dark] tr.test:hover { background: #21262d; }
+[data-theme=dark] th, [data-theme=dark] td { border-color: #30363d; }
+[data-theme=dark] input, [data-theme=dark] select { color: #e6edf3; background: #0d1117; border-color: #30363d; }
+[data-theme=dark] .muted { color: #8d96a0; }
+[data-theme=dark] .coverage rect { stroke: #161b22; }
+[data-theme=dark] .coverage rect.package { stroke: #e6edf3; }
+[data-theme=dark] .coverage text.package { fill: #e6edf3; }
+{{.StatusCSS}}
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}} <span id="status" class="status"></span></h1>
+{{with .Git}}<p class="muted">{{with .SHA}}Commit <code>{{shortSHA .}}</code>{{end}}{{with .Branch}} on <code>{{.}}</code>{{end}}{{with .Author}} by {{.}}{{end}}{{with .RunURL}} · <a href="{{.}}">CI run</a>{{end}}</p>
+{{end}}<div class="summary" id="summary"></div>
+</header>
+<main>
+{{if .Treemap}}<section class="coverage">
+<h2>Coverage</h2>
+<p class="muted">Area is the number of statements of a file, color its coverage from red (0%) to green (100%). Hover a tile for details.</p>
+<svg viewBox="0 0 {{.TreemapWidth}} {{.TreemapHeight}}" role="img" aria-label="Coverage treemap">
+{{range .Treemap}}<g><title>{{.Title}}</title>{{if .Package}}<rect class="package" x="{{printf "%.1f" .X}}" y="{{printf "%.1f" .Y}}" width="{{printf "%.1f" .W}}" height="{{printf "%.1f" .H}}"></rect>{{if .ShowLabel}}<text class="package" x="{{printf "%.1f" .X}}" y="{{printf "%.1f" .Y}}" dx="4" dy="12">{{.Label}}</text>{{end}}{{else}}<rect x="{{printf "%.1f" .X}}" y="{{printf "%.1f" .Y}}" width="{{printf "%.1f" .W}}" height="{{printf "%.1f" .H}}" fill="{{.Fill}}"></rect>{{if .ShowLabel}}<text x="{{printf "%.1f" .X}}" y="{{printf "%.1f" .Y}}" dx="4" dy="13">{{.Label}}</text>{{end}}{{end}}</g>
+{{end}}</svg>
+</section>
+{{end}}<div class="controls">
+<input id="search" type="search" placeholder="Search tests and output">
+<select id="status-filter"><option value="">All statuses</option><option>FAIL</option><option>PASS</option><option>SKIP</option><option>UNKNOWN</option></select>
+<select id="package-filter"><option value="">All packages</option></select>
+<select id="sort"><option value="name">Sort by name</option><option value="duration-desc">Slowest first</option><option value="duration-asc">Fastest first</option></select>
+</div>
+<p class="muted" id="count"></p>
+<table>
+<thead><tr><th data-sort="name">Test</th><th>Package</th><th>Status</th><th class="duration" data-sort="duration">Duration</th></tr></thead>
+<tbody id="tests"></tbody>
+</table>
+<div class="packages" id="packages"></div>
+<div class="stats" id="stats"></div>
+<p class="muted">Report generated at {{.GeneratedAt}}{{with .RunID}} · Run ID <code>{{.}}</code>{{end}}</p>
+</main>
+<script id="report-data" type="application/json">{{.Data}}</script>
+<script>
+(function () {
+  var report = JSON.parse(document.getElementById("report-data").textContent);
+  var glyphs = {{.Glyphs}};
+  var expanded = {};
+
+  function el(tag, attrs, text) {
+    var node = document.createElement(tag);
+    for (var key in attrs || {}) { node.setAttribute(key, attrs[key]); }
+    if (text !== undefined) { node.textContent = text; }
+    return node;
+  }
+
+  var status = document.getElementById("status");
+  status.textContent = glyphs[report.status] + " " + report.status;
+  status.className = "status " + report.status;
+  var s = report.summary;
+  [["Total", s.total], ["Passed", s.passed + " (" + s.pass_rate.toFixed(1) + "%)"], ["Failed", s.failed],
+   ["Skipped", s.skipped], ["Duration", s.duration.toFixed(2) + "s"]].concat(s.wall_clock ? [["Wall Clock", s.wall_clock.toFixed(2) + "s"]] : [])
+    .forEach(function (item) {
+      var span = el("span");
+      span.appendChild(el("strong", {}, item[0] + ": "));
+      span.appendChild(document.createTextNode(item[1]));
+      document.getElementById("summary").appendChild(span);
+    });
+
+  var packages = {};
+  report.tests.forEach(function (t) { packages[t.package] = true; });
+  Object.keys(packages).sort().forEach(function (name) {
+    document.getElementById("package-filter").appendChild(el("option", {value: name}, name));
+  });
+
+  function matches(test, query, statusFilter) {
+    var self = (!statusFilter || test.status === statusFilter) &&
+      (!query || test.name.toLowerCase().indexOf(query) >= 0 || test.output.join("\n").toLowerCase().indexOf(query) >= 0);
+    return self || (test.subtests || []).some(function (sub) { return matches(sub, query, statusFilter); });
+  }
+
+  function sorted(tests, order) {
+    return tests.slice().sort(function (a, b) {
+      if (order === "duration-desc") { return b.duration - a.duration; }
+      if (order === "duration-asc") { return a.duration - b.duration; }
+      return a.name < b.name ? -1 : a.name > b.name ? 1 : 0;
+    });
+  }
+
+  function addRows(body, test, parent, depth, query, statusFilter, order) {
+    var row = el("tr", {"class": depth ? "test subtest" : "test"});
+    // Subtests are named below their parent, their names may contain slashes
+    var name = el("td", {"class": "name"}, depth ? test.name.slice(parent.length + 1) : test.name);
+    if (depth) { name.style.paddingLeft = (10 + depth * 22) + "px"; }
+    if (test.doc) { name.title = test.doc; }
+    row.appendChild(name);
+    row.appendChild(el("td", {"class": "muted"}, test.package));
+    var cell = el("td");
+    cell.appendChild(el("span", {"class": "status " + test.status}, glyphs[test.status] + " " + test.status));
+    row.appendChild(cell);
+    row.appendChild(el("td", {"class": "duration"}, test.duration.toFixed(3) + "s"));
+    body.appendChild(row);
+
+    // Failed tests start expanded so their output is visible right away
+    var open = expanded[test.name] !== undefined ? expanded[test.name] : test.status === "FAIL";
+    row.addEventListener("click", function () { expanded[test.name] = !open; render(); });
+    if (!open) { return; }
+    if (test.output.length) {
+      var outputRow = el("tr");
+      var outputCell = el("td", {colspan: "4"});
+      outputCell.appendChild(el("pre", {}, test.output.join("\n")));
+      outputRow.appendChild(outputCell);
+      body.appendChild(outputRow);
+    }
+    sorted(test.subtests || [], order).forEach(function (sub) {
+      if (matches(sub, query, statusFilter)) { addRows(body, sub, test.name, depth + 1, query, statusFilter, order); }
+    });
+  }
+
+  function render() {
+    var query = document.getElementById("search").value.toLowerCase();
+    var statusFilter = document.getElementById("status-filter").value;
+    var packageFilter = document.getElementById("package-filter").value;
+    var order = document.getElementById("sort").value;
+    var body = document.getElementById("tests");
+    body.textContent = "";
+    var shown = 0;
+    sorted(report.tests, order).forEach(function (test) {
+      if ((packageFilter && test.package !== packageFilter) || !matches(test, query, statusFilter)) { return; }
+      shown++;
+      addRows(body, test, "", 0, query, statusFilter, order);
+    });
+    document.getElementById("count").textContent = "Showing " + shown + " of " + report.tests.length + " tests";
+  }
+
+  var failedPackages = report.packages.filter(function (p) { return p.status === "FAIL" && (p.build_failed || (p.output || []).length); });
+  if (failedPackages.length) {
+    var section = document.getElementById("packages");
+    section.appendChild(el("h2", {}, "Package Failures"));
+    failedPackages.forEach(function (p) {
+      var details = el("details", {open: ""});
+      details.appendChild(el("summary", {}, p.name + (p.build_failed ? " (build failed)" : "")));
+      details.appendChild(el("pre", {}, (p.build_output || p.output || []).join("\n")));
+      section.appendChild(details);
+    });
+  }
+
+  var stats = report.duration_stats;
+  if (stats) {
+    var statsSection = document.getElementById("stats");
+    statsSection.appendChild(el("h2", {}, "Duration Statistics"));
+    function seconds(value) { return value < 1 ? (value * 1000).toFixed(1) + "ms" : value.toFixed(3) + "s"; }
+    var summaryTable = el("table");
+    var head = el("tr"), values = el("tr");
+    [["Tests", stats.count], ["Mean", seconds(stats.mean)], ["Std Dev", seconds(stats.std_dev)], ["p50", seconds(stats.p50)],
+     ["p90", seconds(stats.p90)], ["p99", seconds(stats.p99)], ["Max", seconds(stats.max)]].forEach(function (item) {
+      head.appendChild(el("th", {}, item[0]));
+      values.appendChild(el("td", {}, String(item[1])));
+    });
+    summaryTable.appendChild(head);
+    summaryTable.appendChild(values);
+    statsSection.appendChild(summaryTable);
+    var peak = Math.max.apply(null, stats.buckets.map(function (b) { return b.count; }));
+    var histogram = el("table");
+    stats.buckets.forEach(function (b) {
+      var row = el("tr");
+      row.appendChild(el("td", {}, b.label));
+      row.appendChild(el("td", {"class": "duration"}, String(b.count)));
+      var bar = el("td");
+      bar.appendChild(el("progress", {max: String(peak), value: String(b.count)}));
+      row.appendChild(bar);
+      histogram.appendChild(row);
+    });
+    statsSection.appendChild(histogram);
+  }
+
+  ["search", "status-filter", "package-filter", "sort"].forEach(function (id) {
+    document.getElementById(id).addEventListener("input", render);
+  });
+  document.querySelectorAll("th[data-sort]").forEach(function (th) {
+    th.addEventListener("click", function () {
+      var sort = document.getElementById("sort");
+      if (th.dataset.sort === "name") { sort.value = "name"; }
+      else { sort.value = sort.value === "duration-desc" ? "duration-asc" : "duration-desc"; }
+      render();
+    });
+  });
+  render();
+})();
+</script>
+</body>
+</html>
+`))
+
+// renderInteractiveHTML renders the report as a single self-contained HTML
+// page. The JSON report is embedded in the page and rendered by inline
+// JavaScript, so it works offline and from CI artifact storage.
+func renderInteractiveHTML(data *ReportData, opts ReportOptions, now time.Time) (string, error) {
+	report := newJSONReport(data, opts, now)
+	// encoding/json escapes <, > and &, so the data cannot close the script element
+	content, err := json.Marshal(report)
+	if err != nil {
+		return "", fmt.Errorf("error encoding report: %v", err)
+	}
+
+	var buf bytes.Buffer
+	err = interactiveTemplate.Execute(&buf, struct {
+		Status        string
+		Title         string
+		Theme         string
+		StatusCSS     template.CSS
+		Glyphs        template.JS
+		GeneratedAt   string
+		RunID         string
+		Git           *GitInfo
+		Data          template.JS
+		Treemap       []treemapTile
+		TreemapWidth  int
+		TreemapHeight int
+	}{
+		Status:        report.Status,
+		Title:         opts.Formats.Get("html.title"),
+		Theme:         opts.Formats.Get("html.theme"),
+		StatusCSS:     template.CSS(statusCSS()),
+		Glyphs:        template.JS(statusGlyphsJSON()),
+		GeneratedAt:   now.UTC().Format(time.RFC1123),
+		RunID:         data.RunID,
+		Git:           data.Git,
+		Data:          template.JS(content),
+		Treemap:       coverageTreemap(data.Coverage),
+		TreemapWidth:  treemapWidth,
+		TreemapHeight: treemapHeight,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error rendering HTML report: %v", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderInteractiveHTML(t *testing.T) {
+	data := &ReportData{
+		Results: map[string]*TestResult{
+			"TestA": {Name: "TestA", Package: "pkg/a", Status: "FAIL", Duration: 1, Output: []string{"got </script><img src=x onerror=alert(1)>"}},
+			"TestB": {Name: "TestB", Package: "pkg/b", Status: "PASS", Duration: 2},
+		},
+		Packages: map[string]*PackageResult{},
+	}
+	summarizeReport(data, nil)
+
+	page, err := renderInteractiveHTML(data, ReportOptions{}, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{"<title>Test Summary Report - FAILED</title>", `id="search"`, `id="status-filter"`, `id="package-filter"`} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("Expected the page to contain %q", expected)
+		}
+	}
+	if strings.Contains(page, "<img src=x") {
+		t.Error("Test output must not be able to inject markup")
+	}
+
+	// The embedded data is the JSON report
+	start := strings.Index(page, `<script id="report-data" type="application/json">`)
+	if start < 0 {
+		t.Fatal("Expected the report data to be embedded")
+	}
+	content := page[start+len(`<script id="report-data" type="application/json">`):]
+	content = content[:strings.Index(content, "</script>")]
+	var report JSONReport
+	if err := json.Unmarshal([]byte(content), &report); err != nil {
+		t.Fatalf("Embedded data is not valid JSON: %v", err)
+	}
+	if len(report.Tests) != 2 || report.Tests[0].Output[0] != "got </script><img src=x onerror=alert(1)>" {
+		t.Errorf("Unexpected embedded report %+v", report.Tests)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// hygieneSerialShare is the share of a package's test time spent in tests
+// without t.Parallel() from which the package is dominated by serial time
+const hygieneSerialShare = 0.5
+
+// hygieneMinSerialTime is the serial test time in seconds below which a
+// package is too fast for parallelism to matter
+const hygieneMinSerialTime = 1.0
+
+// serialPackage is a package whose tests mostly run one after another
+type serialPackage struct {
+	Package    string
+	SerialTime float64 // Seconds spent in top-level tests without t.Parallel()
+	TotalTime  float64
+	Tests      []*TestResult // Serial tests, slowest first
+}
+
+// unconditionalSkip is a test that always skips
+type unconditionalSkip struct {
+	Test    *TestResult
+	File    string
+	Line    int
+	Message string // Skip reason from the output, if any
+}
+
+// SuiteHygiene lists the problems found in the test sources, set with -src
+type SuiteHygiene struct {
+	SerialPackages []serialPackage
+	Skips          []unconditionalSkip
+}
+
+// suiteHygiene compares the test results with their sources to find the
+// serial tests of packages dominated by serial time, and the tests calling
+// t.Skip outside of any condition
+func suiteHygiene(data *ReportData, sources map[string]*TestSource) *SuiteHygiene {
+	hygiene := &SuiteHygiene{}
+	byPackage := make(map[string]*serialPackage)
+	for _, name := range data.SortedTestNames {
+		result := data.Results[name]
+		source, ok := sources[testSourceKey(result.Package, result.Name)]
+		if result.IsSubTest || !ok {
+			continue
+		}
+		if source.SkipLine > 0 {
+			hygiene.Skips = append(hygiene.Skips, unconditionalSkip{Test: result, File: source.File, Line: source.SkipLine, Message: skipReason(result.Output)})
+		}
+		if result.Status == "SKIP" {
+			continue
+		}
+		pkg := byPackage[result.Package]
+		if pkg == nil {
+			pkg = &serialPackage{Package: result.Package}
+			byPackage[result.Package] = pkg
+		}
+		pkg.TotalTime += result.Duration
+		if !source.Parallel {
+			pkg.SerialTime += result.Duration
+			pkg.Tests = append(pkg.Tests, result)
+		}
+	}
+	for _, pkg := range byPackage {
+		if pkg.SerialTime < hygieneMinSerialTime || pkg.SerialTime < hygieneSerialShare*pkg.TotalTime {
+			continue
+		}
+		sort.SliceStable(pkg.Tests, func(i, j int) bool { return pkg.Tests[i].Duration > pkg.Tests[j].Duration })
+		hygiene.SerialPackages = append(hygiene.SerialPackages, *pkg)
+	}
+	sort.Slice(hygiene.SerialPackages, func(i, j int) bool {
+		return hygiene.SerialPackages[i].SerialTime > hygiene.SerialPackages[j].SerialTime
+	})
+	return hygiene
+}
+
+// hygieneMaxTests is the number of serial tests listed per package
+const hygieneMaxTests = 10
+
+// writeSuiteHygieneSection renders the hygiene findings as an appendix
+func writeSuiteHygieneSection(sb *strings.Builder, hygiene *SuiteHygiene) {
+	if hygiene == nil || len(hygiene.SerialPackages) == 0 && len(hygiene.Skips) == 0 {
+		return
+	}
+	sb.WriteString("## Suite Hygiene\n\n")
+	sb.WriteString("<details>\n<summary>Click to expand suite hygiene</summary>\n\n")
+
+	if len(hygiene.SerialPackages) > 0 {
+		sb.WriteString("### Serial Tests\n\n")
+		sb.WriteString("These packages spend most of their test time in tests that do not call `t.Parallel()`:\n\n")
+		sb.WriteString("| Package | Serial Time | Share | Slowest Serial Tests |\n")
+		sb.WriteString("| ------- | ----------- | ----- | -------------------- |\n")
+		for _, pkg := range hygiene.SerialPackages {
+			var names []string
+			for i, test := range pkg.Tests {
+				if i == hygieneMaxTests {
+					names = append(names, fmt.Sprintf("+%d more", len(pkg.Tests)-hygieneMaxTests))
+					break
+				}
+				names = append(names, fmt.Sprintf("%s (%s)", escapeMarkdown(test.Name), formatDuration(test.Duration)))
+			}
+			sb.WriteString(fmt.Sprintf("| %s | %s | %.0f%% | %s |\n", escapeMarkdown(pkg.Package), formatDuration(pkg.SerialTime),
+				pkg.SerialTime/pkg.TotalTime*100, strings.Join(names, ", ")))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(hygiene.Skips) > 0 {
+		sb.WriteString("### Unconditional Skips\n\n")
+		sb.WriteString("These tests call `t.Skip` outside of any condition, so they never run:\n\n")
+		sb.WriteString("| Test | Package | Location | Reason |\n")
+		sb.WriteString("| ---- | ------- | -------- | ------ |\n")
+		for _, skip := range hygiene.Skips {
+			reason := "-"
+			if skip.Message != "" {
+				reason = escapeMarkdown(skip.Message)
+			}
+			sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", escapeMarkdown(skip.Test.Name), escapeMarkdown(skip.Test.Package),
+				codeSpan(fmt.Sprintf("%s:%d", skip.File, skip.Line)), reason))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("</details>\n\n")
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInspectTestFunc(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "store_test.go"), `package store
+
+func TestParallel(t *testing.T) {
+	t.Parallel()
+}
+
+func TestSubtestsParallel(t *testing.T) {
+	t.Run("a", func(t *testing.T) { t.Parallel() })
+}
+
+func TestSkipped(t *testing.T) {
+	t.Skip("flaky on CI")
+}
+
+func TestSkippedOnShort(tt *testing.T) {
+	if testing.Short() {
+		tt.Skip("slow")
+	}
+	tt.Parallel()
+}
+`)
+	sources, err := loadTestSources("./...", sourceResolver{ModulePath: "example.com/store", Root: root})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tests := []struct {
+		name     string
+		parallel bool
+		skipLine int
+	}{
+		{"TestParallel", true, 0},
+		{"TestSubtestsParallel", false, 0},
+		{"TestSkipped", false, 12},
+		{"TestSkippedOnShort", true, 0},
+	}
+	for _, tt := range tests {
+		source := sources["example.com/store."+tt.name]
+		if source == nil {
+			t.Errorf("%s: not found", tt.name)
+			continue
+		}
+		if source.Parallel != tt.parallel || source.SkipLine != tt.skipLine {
+			t.Errorf("%s: expected parallel %v and skip line %d, got %v and %d", tt.name, tt.parallel, tt.skipLine, source.Parallel, source.SkipLine)
+		}
+	}
+}
+
+func TestSuiteHygieneSection(t *testing.T) {
+	input := `{"Action":"run","Package":"example.com/store","Test":"TestSerialSlow"}
+{"Action":"pass","Package":"example.com/store","Test":"TestSerialSlow","Elapsed":3}
+{"Action":"run","Package":"example.com/store","Test":"TestParallel"}
+{"Action":"pass","Package":"example.com/store","Test":"TestParallel","Elapsed":1}
+{"Action":"run","Package":"example.com/store","Test":"TestSkipped"}
+{"Action":"output","Package":"example.com/store","Test":"TestSkipped","Output":"    store_test.go:12: flaky on CI\n"}
+{"Action":"skip","Package":"example.com/store","Test":"TestSkipped","Elapsed":0}
+{"Action":"run","Package":"example.com/api","Test":"TestFast"}
+{"Action":"pass","Package":"example.com/api","Test":"TestFast","Elapsed":0.2}
+`
+	data, err := processTestEvents(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sources := map[string]*TestSource{
+		"example.com/store.TestSerialSlow": {File: "store/store_test.go", Line: 3},
+		"example.com/store.TestParallel":   {File: "store/store_test.go", Line: 7, Parallel: true},
+		"example.com/store.TestSkipped":    {File: "store/store_test.go", Line: 11, SkipLine: 12},
+		"example.com/api.TestFast":         {File: "api/api_test.go", Line: 3},
+	}
+	hygiene := suiteHygiene(data, sources)
+	if len(hygiene.SerialPackages) != 1 || hygiene.SerialPackages[0].Package != "example.com/store" {
+		t.Errorf("Expected only the slow serial package, got %+v", hygiene.SerialPackages)
+	}
+
+	report := renderMarkdownReport(data, ReportOptions{Hygiene: hygiene})
+	for _, expected := range []string{
+		"## Suite Hygiene",
+		"| example.com/store | 3.000s | 75% | TestSerialSlow (3.000s) |",
+		"| TestSkipped | example.com/store | `store/store_test.go:12` | flaky on CI |",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Expected the report to contain %q, got:\n%s", expected, report)
+		}
+	}
+	if report := renderMarkdownReport(data, ReportOptions{Hygiene: &SuiteHygiene{}}); strings.Contains(report, "## Suite Hygiene") {
+		t.Error("Expected no section without findings")
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const icalTimeFormat = "20060102T150405Z"
+
+// icalEscape escapes text values as required by RFC 5545
+func icalEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// icalFold folds content lines longer than 75 octets
+func icalFold(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line
+	}
+	var sb strings.Builder
+	width := 0
+	for _, r := range line {
+		n := len(string(r))
+		if width+n > limit {
+			sb.WriteString("\r\n ")
+			width = 1
+		}
+		sb.WriteRune(r)
+		width += n
+	}
+	return sb.String()
+}
+
+// renderICalendar renders every stored run as a calendar event whose title
+// carries the run status, e.g. for tracking nightly suite health
+func renderICalendar(records []*RunRecord, reportURL string, now time.Time) string {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//gotest-report//Test Runs//EN",
+		"CALSCALE:GREGORIAN",
+		"X-WR-CALNAME:Go test runs",
+	}
+
+	for _, run := range records {
+		end := run.Timestamp.Add(time.Duration(run.Duration * float64(time.Second)))
+		if end.Sub(run.Timestamp) < time.Minute {
+			// Keep very short runs visible in calendar views
+			end = run.Timestamp.Add(time.Minute)
+		}
+
+		description := fmt.Sprintf("Total: %d\nPassed: %d\nFailed: %d\nSkipped: %d\nDuration: %.2fs",
+			run.Total, run.Passed, run.Failed, run.Skipped, run.Duration)
+		if run.RunID != "" {
+			description += "\nRun ID: " + run.RunID
+		}
+
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:run-"+run.Timestamp.UTC().Format("20060102T150405.000000000Z")+"@gotest-report",
+			"DTSTAMP:"+now.UTC().Format(icalTimeFormat),
+			"DTSTART:"+run.Timestamp.UTC().Format(icalTimeFormat),
+			"DTEND:"+end.UTC().Format(icalTimeFormat),
+			"SUMMARY:"+icalEscape(fmt.Sprintf("Tests %s (%d/%d passed)", run.Status(), run.Passed, run.Total)),
+			"DESCRIPTION:"+icalEscape(description),
+		)
+		if reportURL != "" {
+			lines = append(lines, "URL:"+reportURL)
+		}
+		lines = append(lines, "END:VEVENT")
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	var sb strings.Builder
+	for _, line := range lines {
+		sb.WriteString(icalFold(line))
+		sb.WriteString("\r\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderICalendar(t *testing.T) {
+	records := []*RunRecord{
+		{Timestamp: time.Date(2024, 3, 20, 2, 0, 0, 0, time.UTC), Total: 10, Passed: 10, Duration: 300},
+		{Timestamp: time.Date(2024, 3, 21, 2, 0, 0, 0, time.UTC), Total: 10, Passed: 8, Failed: 2, Duration: 5},
+	}
+
+	ics := renderICalendar(records, "https://example.com/report.md", time.Date(2024, 3, 22, 0, 0, 0, 0, time.UTC))
+
+	for _, expected := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"SUMMARY:Tests PASSED (10/10 passed)\r\n",
+		"DTSTART:20240320T020000Z\r\nDTEND:20240320T020500Z\r\n",
+		"SUMMARY:Tests FAILED (8/10 passed)\r\n",
+		// Short runs are stretched to a minute so they stay visible
+		"DTSTART:20240321T020000Z\r\nDTEND:20240321T020100Z\r\n",
+		`DESCRIPTION:Total: 10\nPassed: 8\nFailed: 2\nSkipped: 0\nDuration: 5.00s` + "\r\n",
+		"URL:https://example.com/report.md\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(ics, expected) {
+			t.Errorf("Expected %q in calendar", expected)
+		}
+	}
+	if strings.Count(ics, "BEGIN:VEVENT") != 2 {
+		t.Errorf("Expected one event per run")
+	}
+}
+
+func TestICalFold(t *testing.T) {
+	line := "SUMMARY:" + strings.Repeat("x", 100)
+	folded := icalFold(line)
+	for _, part := range strings.Split(folded, "\r\n") {
+		if len(part) > 75 {
+			t.Errorf("Folded line exceeds 75 octets: %d", len(part))
+		}
+	}
+	if strings.ReplaceAll(folded, "\r\n ", "") != line {
+		t.Error("Unfolding should restore the original line")
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// artifact is a single file produced by a run, listed in the artifact index
+type artifact struct {
+	Path        string
+	Description string
+}
+
+// artifactList collects the files written during a run
+type artifactList []artifact
+
+func (l *artifactList) add(path, description string) {
+	*l = append(*l, artifact{Path: path, Description: description})
+}
+
+// indexEntry is an artifact resolved relative to the index location
+type indexEntry struct {
+	Link        string
+	Description string
+	Size        int64
+}
+
+// indexEntries resolves artifacts relative to dir and reads their sizes,
+// skipping files that were not written
+func indexEntries(dir string, artifacts artifactList) []indexEntry {
+	var entries []indexEntry
+	for _, a := range artifacts {
+		info, err := os.Stat(a.Path)
+		if err != nil {
+			continue
+		}
+		link, err := filepath.Rel(dir, a.Path)
+		if err != nil {
+			link = a.Path
+		}
+		entries = append(entries, indexEntry{
+			Link:        filepath.ToSlash(link),
+			Description: a.Description,
+			Size:        info.Size(),
+		})
+	}
+	return entries
+}
+
+// formatSize renders a byte count in human readable units
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+func renderIndexMarkdown(entries []indexEntry) string {
+	var sb strings.Builder
+	sb.WriteString("# Test Report Artifacts\n\n")
+	sb.WriteString("| File | Description | Size |\n")
+	sb.WriteString("| ---- | ----------- | ---- |\n")
+	for _, e := range entries {
+		sb.WriteString(fmt.Sprintf("| [%s](%s) | %s | %s |\n", e.Link, e.Link, e.Description, formatSize(e.Size)))
+	}
+	return sb.String()
+}
+
+func renderIndexHTML(entries []indexEntry) string {
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Test Report Artifacts</title>\n</head>\n<body>\n")
+	sb.WriteString("<h1>Test Report Artifacts</h1>\n")
+	sb.WriteString("<table>\n<tr><th>File</th><th>Description</th><th>Size</th></tr>\n")
+	for _, e := range entries {
+		link := html.EscapeString(e.Link)
+		sb.WriteString(fmt.Sprintf("<tr><td><a href=\"%s\">%s</a></td><td>%s</td><td>%s</td></tr>\n",
+			link, link, html.EscapeString(e.Description), formatSize(e.Size)))
+	}
+	sb.WriteString("</table>\n</body>\n</html>\n")
+	return sb.String()
+}
+
+// writeArtifactIndex writes index.md and index.html into dir, linking every
+// artifact so CI artifact browsing can start from a single page
+func writeArtifactIndex(dir string, artifacts artifactList) error {
+	entries := indexEntries(dir, artifacts)
+	if err := os.WriteFile(filepath.Join(dir, "index.md"), []byte(renderIndexMarkdown(entries)), 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "index.html"), []byte(renderIndexHTML(entries)), 0o644)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteArtifactIndex(t *testing.T) {
+	dir := t.TempDir()
+	report := filepath.Join(dir, "test-report.md")
+	card := filepath.Join(dir, "test-report-cards", "total.svg")
+	os.MkdirAll(filepath.Dir(card), 0o755)
+	os.WriteFile(report, []byte(strings.Repeat("x", 2048)), 0o644)
+	os.WriteFile(card, []byte("<svg/>"), 0o644)
+
+	var artifacts artifactList
+	artifacts.add(report, "Markdown test report")
+	artifacts.add(card, "Total Tests summary card")
+	artifacts.add(filepath.Join(dir, "missing.html"), "Never written")
+
+	if err := writeArtifactIndex(dir, artifacts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	md, err := os.ReadFile(filepath.Join(dir, "index.md"))
+	if err != nil {
+		t.Fatalf("index.md not written: %v", err)
+	}
+	for _, expected := range []string{
+		"| [test-report.md](test-report.md) | Markdown test report | 2.0 KB |",
+		"| [test-report-cards/total.svg](test-report-cards/total.svg) | Total Tests summary card | 6 B |",
+	} {
+		if !strings.Contains(string(md), expected) {
+			t.Errorf("Expected index.md row not found: %s", expected)
+		}
+	}
+	if strings.Contains(string(md), "missing.html") {
+		t.Error("Artifacts that were not written should not be listed")
+	}
+
+	page, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatalf("index.html not written: %v", err)
+	}
+	if !strings.Contains(string(page), `<a href="test-report.md">`) {
+		t.Error("index.html should link the report")
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// jenkinsCSS is the stylesheet of the Jenkins HTML report, written to the
+// assets folder since the default Content Security Policy of Jenkins blocks
+// inline styles and scripts
+const jenkinsCSS = `body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0; color: #1f2328; background: #f6f8fa; }
+header { background: #fff; border-bottom: 1px solid #d0d7de; padding: 16px 24px; }
+h1 { font-size: 20px; margin: 0 0 8px; }
+h2 { font-size: 16px; }
+main { padding: 16px 24px; }
+.status { display: inline-block; padding: 2px 8px; border-radius: 12px; color: #fff; font-size: 12px; font-weight: 600; vertical-align: middle; }
+.PASSED, .PASS { background: #1a7f37; } .FAILED, .FAIL { background: #cf222e; } .SKIPPED, .SKIP { background: #9a6700; } .UNKNOWN { background: #6e7781; }
+.summary span { margin-right: 16px; }
+table { width: 100%; border-collapse: collapse; background: #fff; border: 1px solid #d0d7de; margin-bottom: 24px; }
+th, td { text-align: left; padding: 6px 10px; border-bottom: 1px solid #eaeef2; font-size: 14px; vertical-align: top; }
+th { background: #f6f8fa; }
+td.duration, th.duration { text-align: right; white-space: nowrap; }
+td.depth-1 { padding-left: 32px; } td.depth-2 { padding-left: 54px; } td.depth-3 { padding-left: 76px; } td.depth-4 { padding-left: 98px; }
+pre { margin: 0; padding: 8px; background: #f6f8fa; border-radius: 6px; overflow-x: auto; font-size: 12px; max-height: 480px; }
+summary { cursor: pointer; }
+.histogram progress { width: 100%; }
+.muted { color: #656d76; }
+[data-theme=dark] body { color: #e6edf3; background: #0d1117; }
+[data-theme=dark] header, [data-theme=dark] table { background: #161b22; border-color: #30363d; }
+[data-theme=dark] th, [data-theme=dark] pre { background: #21262d; }
+[data-theme=dark] th, [data-theme=dark] td { border-color: #30363d; }
+[data-theme=dark] .muted { color: #8d96a0; }
+@media (prefers-color-scheme: dark) {
+[data-theme=auto] body { color: #e6edf3; background: #0d1117; }
+[data-theme=auto] header, [data-theme=auto] table { background: #161b22; border-color: #30363d; }
+[data-theme=auto] th, [data-theme=auto] pre { background: #21262d; }
+[data-theme=auto] th, [data-theme=auto] td { border-color: #30363d; }
+[data-theme=auto] .muted { color: #8d96a0; }
+}
+`
+
+// jenkinsTemplate renders the whole report on the server side, so the page
+// works without JavaScript under the HTML Publisher plugin
+var jenkinsTemplate = template.Must(template.New("report").Funcs(template.FuncMap{"shortSHA": shortSHA, "glyph": statusEmoji, "duration": formatDuration}).Parse(`<!DOCTYPE html>
+<html lang="en" data-theme="{{.Theme}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} - {{.Status}}</title>
+<link rel="stylesheet" href="{{.AssetsDir}}/report.css">
+</head>
+<body>
+<header>
+<h1>{{.Title}} <span class="status {{.Status}}">{{glyph .Status}} {{.Status}}</span></h1>
+{{with .Git}}<p class="muted">{{with .SHA}}Commit <code>{{shortSHA .}}</code>{{end}}{{with .Branch}} on <code>{{.}}</code>{{end}}{{with .Author}} by {{.}}{{end}}{{with .RunURL}} · <a href="{{.}}">CI run</a>{{end}}</p>
+{{end}}<div class="summary">{{range .Summary}}<span><strong>{{index . 0}}:</strong> {{index . 1}}</span>{{end}}</div>
+</header>
+<main>
+{{with .Gates}}<h2>Quality Gates</h2>
+<table>
+<thead><tr><th>Gate</th><th>Threshold</th><th>Actual</th><th>Result</th></tr></thead>
+<tbody>
+{{range .}}<tr><td>{{.Name}}</td><td>{{.Threshold}}</td><td>{{.Actual}}</td><td>{{if .Passed}}<span class="status PASS">{{glyph "PASS"}} Pass</span>{{else}}<span class="status FAIL">{{glyph "FAIL"}} Fail</span>{{end}}</td></tr>
+{{end}}</tbody>
+</table>
+{{end}}{{with .PackageFailures}}<h2>Package Failures</h2>
+{{range .}}<details open><summary>{{.Name}}{{if .BuildFailed}} (build failed){{end}}</summary><pre>{{.Output}}</pre></details>
+{{end}}{{end}}<h2>Test Results</h2>
+<table>
+<thead><tr><th>Test</th><th>Package</th><th>Status</th><th class="duration">Duration</th></tr></thead>
+<tbody>
+{{range .Rows}}<tr><td class="depth-{{.Depth}}">{{.Name}}</td><td class="muted">{{.Package}}</td><td><span class="status {{.Status}}">{{glyph .Status}} {{.Status}}</span></td><td class="duration">{{printf "%.3f" .Duration}}s</td></tr>
+{{with .Output}}<tr><td colspan="4"><details{{if eq .Status "FAIL"}} open{{end}}><summary>Output</summary><pre>{{.Text}}</pre></details></td></tr>
+{{end}}{{end}}</tbody>
+</table>
+{{with .Stats}}<h2>Duration Statistics</h2>
+<table>
+<thead><tr><th>Tests</th><th class="duration">Mean</th><th class="duration">Std Dev</th><th class="duration">p50</th><th class="duration">p90</th><th class="duration">p99</th><th class="duration">Max</th></tr></thead>
+<tbody><tr><td>{{.Count}}</td><td class="duration">{{duration .Mean}}</td><td class="duration">{{duration .StdDev}}</td><td class="duration">{{duration .P50}}</td><td class="duration">{{duration .P90}}</td><td class="duration">{{duration .P99}}</td><td class="duration">{{duration .Max}}</td></tr></tbody>
+</table>
+{{$peak := .PeakBucket}}<table class="histogram">
+<thead><tr><th>Duration</th><th class="duration">Tests</th><th></th></tr></thead>
+<tbody>
+{{range .Buckets}}<tr><td>{{.Label}}</td><td class="duration">{{.Count}}</td><td><progress max="{{$peak}}" value="{{.Count}}"></progress></td></tr>
+{{end}}</tbody>
+</table>
+{{end}}<p class="muted">Report generated at {{.GeneratedAt}}{{with .RunID}} · Run ID <code>{{.}}</code>{{end}}</p>
+</main>
+</body>
+</html>
+`))
+
+// jenkinsRow is a test or subtest of the Jenkins HTML report
+type jenkinsRow struct {
+	Name     string // Relative to the parent test for subtests
+	Package  string
+	Status   string
+	Duration float64
+	Depth    int // Nesting level of subtests, capped at 4
+	Output   *jenkinsOutput
+}
+
+// jenkinsOutput is the captured output of a row with its status, so failed
+// output starts expanded
+type jenkinsOutput struct {
+	Status string
+	Text   string
+}
+
+// jenkinsAssetsDirFor returns the assets folder of an HTML report, named after
+// the report file so several reports can share a directory
+func jenkinsAssetsDirFor(outputFile string) string {
+	base := filepath.Base(outputFile)
+	return strings.TrimSuffix(base, filepath.Ext(base)) + "-assets"
+}
+
+// writeJenkinsAssets writes the stylesheet of the report into its assets
+// folder and returns the file written
+func writeJenkinsAssets(outputFile string) (string, error) {
+	dir := filepath.Join(filepath.Dir(outputFile), jenkinsAssetsDirFor(outputFile))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("error creating assets directory: %v", err)
+	}
+	file := filepath.Join(dir, "report.css")
+	if err := os.WriteFile(file, []byte(jenkinsCSS+statusCSS()+"\n"), 0o644); err != nil {
+		return "", fmt.Errorf("error writing stylesheet: %v", err)
+	}
+	return file, nil
+}
+
+// renderJenkinsHTML renders the report as a static HTML page for the Jenkins
+// HTML Publisher plugin. Unlike the interactive report it has no inline
+// scripts or styles, which the Content Security Policy of Jenkins blocks,
+// and links its stylesheet from the assets folder of writeJenkinsAssets.
+func renderJenkinsHTML(data *ReportData, opts ReportOptions, outputFile string, now time.Time) (string, error) {
+	var rows []jenkinsRow
+	var add func(name, parent string, depth int)
+	add = func(name, parent string, depth int) {
+		result, ok := data.Results[name]
+		if !ok {
+			return
+		}
+		row := jenkinsRow{Name: result.Name, Package: result.Package, Status: result.Status, Duration: result.Duration, Depth: min(depth, 4)}
+		if parent != "" {
+			row.Name = strings.TrimPrefix(result.Name, parent+"/")
+		}
+		if len(result.Output) > 0 {
+			row.Output = &jenkinsOutput{Status: result.Status, Text: strings.Join(result.Output, "\n")}
+		}
+		rows = append(rows, row)
+		for _, sub := range result.SubTests {
+			add(testKey(result.Package, sub), result.Name, depth+1)
+		}
+	}
+	for _, name := range data.SortedTestNames {
+		add(name, "", 0)
+	}
+
+	type packageFailure struct {
+		Name        string
+		BuildFailed bool
+		Output      string
+	}
+	var failures []packageFailure
+	for _, pkg := range packageFailures(data) {
+		output := pkg.BuildOutput
+		if len(output) == 0 {
+			output = pkg.Output
+		}
+		failures = append(failures, packageFailure{Name: pkg.Name, BuildFailed: pkg.BuildFailed, Output: strings.Join(output, "\n")})
+	}
+
+	summary := [][2]string{
+		{"Total", fmt.Sprint(data.TotalTests)},
+		{"Passed", fmt.Sprintf("%d (%.1f%%)", data.PassedTests, passRate(data))},
+		{"Failed", fmt.Sprint(data.FailedTests)},
+		{"Skipped", fmt.Sprint(data.SkippedTests)},
+		{"Duration", fmt.Sprintf("%.2fs", data.TotalDuration)},
+	}
+	if wallClock := data.WallClock(); wallClock > 0 {
+		summary = append(summary, [2]string{"Wall Clock", fmt.Sprintf("%.2fs", wallClock)})
+	}
+
+	var buf bytes.Buffer
+	err := jenkinsTemplate.Execute(&buf, struct {
+		Status          string
+		Title           string
+		Theme           string
+		AssetsDir       string
+		Git             *GitInfo
+		Summary         [][2]string
+		Gates           []GateResult
+		PackageFailures []packageFailure
+		Rows            []jenkinsRow
+		Stats           *DurationStats
+		GeneratedAt     string
+		RunID           string
+	}{
+		Status:          gatedStatus(data, opts.Gates),
+		Title:           opts.Formats.Get("html.title"),
+		Theme:           opts.Formats.Get("html.theme"),
+		AssetsDir:       jenkinsAssetsDirFor(outputFile),
+		Git:             data.Git,
+		Summary:         summary,
+		Gates:           opts.Gates,
+		PackageFailures: failures,
+		Rows:            rows,
+		Stats:           durationStats(data),
+		GeneratedAt:     now.UTC().Format(time.RFC1123),
+		RunID:           data.RunID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error rendering HTML report: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// Checkstyle XML elements, as read by the Warnings Next Generation plugin
+type checkstyleXML struct {
+	XMLName xml.Name            `xml:"checkstyle"`
+	Version string              `xml:"version,attr"`
+	Files   []checkstyleXMLFile `xml:"file"`
+}
+
+type checkstyleXMLFile struct {
+	Name   string               `xml:"name,attr"`
+	Errors []checkstyleXMLError `xml:"error"`
+}
+
+type checkstyleXMLError struct {
+	Line     int    `xml:"line,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+// renderCheckstyle writes the failure annotations as checkstyle XML, so
+// Jenkins' Warnings NG plugin shows test failures next to the source lines
+// and tracks new and fixed ones across builds. Failures without a location
+// are listed under the directory of their package, and the failure category
+// becomes the issue type.
+func renderCheckstyle(data *ReportData, annotations []Annotation, resolver sourceResolver) (string, error) {
+	report := checkstyleXML{Version: "4.3"}
+	files := make(map[string]int)
+	for _, a := range annotations {
+		file, source := a.location(resolver), "gotest."+otherCategory
+		if result, ok := data.Results[testKey(a.Package, a.Test)]; ok && result.Category != "" {
+			source = "gotest." + result.Category
+		}
+		i, ok := files[file]
+		if !ok {
+			i = len(report.Files)
+			files[file] = i
+			report.Files = append(report.Files, checkstyleXMLFile{Name: file})
+		}
+		report.Files[i].Errors = append(report.Files[i].Errors, checkstyleXMLError{
+			Line:     a.Line,
+			Severity: a.Severity,
+			Message:  a.Title + ": " + a.Message,
+			Source:   source,
+		})
+	}
+
+	content, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(content) + "\n", nil
+}
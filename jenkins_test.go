@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderJenkinsHTML(t *testing.T) {
+	data := &ReportData{
+		SortedTestNames: []string{"TestA", "TestB"},
+		Results: map[string]*TestResult{
+			testKey("pkg/a", "TestA"):      {Name: "TestA", Package: "pkg/a", Status: "FAIL", Duration: 1, SubTests: []string{"TestA/case"}},
+			testKey("pkg/a", "TestA/case"): {Name: "TestA/case", Package: "pkg/a", Status: "FAIL", Duration: 1, ParentTest: "TestA", IsSubTest: true, Output: []string{"got <script>alert(1)</script>"}},
+			testKey("pkg/b", "TestB"):      {Name: "TestB", Package: "pkg/b", Status: "PASS", Duration: 2, Output: []string{"log line"}},
+		},
+		Packages: map[string]*PackageResult{
+			"pkg/c": {Name: "pkg/c", Status: "FAIL", BuildFailed: true, BuildOutput: []string{"c.go:1: undefined: x"}},
+		},
+	}
+	summarizeReport(data, nil)
+	opts := ReportOptions{Gates: evaluateGates(data, nil, ExitGates{MinPassRate: 50, MaxSkipped: -1, MaxFlaky: -1})}
+
+	page, err := renderJenkinsHTML(data, opts, "out/report.html", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, expected := range []string{
+		"<title>Test Summary Report - FAILED</title>",
+		`<link rel="stylesheet" href="report-assets/report.css">`,
+		`<td class="depth-1">case</td>`,
+		`<details open><summary>Output</summary><pre>got &lt;script&gt;alert(1)&lt;/script&gt;</pre></details>`,
+		`<details><summary>Output</summary><pre>log line</pre></details>`,
+		"<summary>pkg/c (build failed)</summary><pre>c.go:1: undefined: x</pre>",
+		"<td>Pass rate</td><td>≥ 50%</td><td>50.0%</td>",
+		`<td>1s-10s</td><td class="duration">2</td><td><progress max="2" value="2"></progress></td>`,
+	} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("Expected the page to contain %q, got:\n%s", expected, page)
+		}
+	}
+	// The default Content Security Policy of Jenkins blocks these
+	for _, blocked := range []string{"<script", "<style", "style=", "onclick"} {
+		if strings.Contains(page, blocked) {
+			t.Errorf("Expected no %q in the page", blocked)
+		}
+	}
+}
+
+func TestWriteJenkinsAssets(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "test-report.html")
+	file, err := writeJenkinsAssets(output)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if file != filepath.Join(filepath.Dir(output), "test-report-assets", "report.css") {
+		t.Errorf("Unexpected stylesheet %s", file)
+	}
+	content, err := os.ReadFile(file)
+	if err != nil || !strings.Contains(string(content), ".FAILED, .FAIL { background: #cf222e; }") {
+		t.Errorf("Expected the stylesheet to contain the status colors, got %s (%v)", content, err)
+	}
+}
+
+func TestRenderCheckstyle(t *testing.T) {
+	data := &ReportData{
+		Results: map[string]*TestResult{
+			testKey("example.com/app/parser", "TestParse"):  {Name: "TestParse", Package: "example.com/app/parser", Status: "FAIL", Category: "assertion"},
+			testKey("example.com/app/server", "TestServer"): {Name: "TestServer", Package: "example.com/app/server", Status: "FAIL"},
+		},
+	}
+	resolver := sourceResolver{ModulePath: "example.com/app"}
+	annotations := []Annotation{
+		{File: "parser/parse_test.go", Line: 12, Severity: annotationError, Test: "TestParse", Package: "example.com/app/parser", Title: "TestParse", Message: "got 1, want 2"},
+		{File: "parser/parse_test.go", Line: 20, Severity: annotationError, Test: "TestParse", Package: "example.com/app/parser", Title: "TestParse", Message: "got \"a\" & <b>"},
+		{Severity: annotationError, Test: "TestServer", Package: "example.com/app/server", Title: "TestServer", Message: "TestServer failed in example.com/app/server"},
+	}
+	content, err := renderCheckstyle(data, annotations, resolver)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="4.3">
+  <file name="parser/parse_test.go">
+    <error line="12" severity="error" message="TestParse: got 1, want 2" source="gotest.assertion"></error>
+    <error line="20" severity="error" message="TestParse: got &#34;a&#34; &amp; &lt;b&gt;" source="gotest.assertion"></error>
+  </file>
+  <file name="server">
+    <error severity="error" message="TestServer: TestServer failed in example.com/app/server" source="gotest.other"></error>
+  </file>
+</checkstyle>
+`
+	if content != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, content)
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jsonSchemaVersion is bumped on any incompatible change to the JSON report.
+// Fields may be added without bumping it.
+const jsonSchemaVersion = 1
+
+// JSONReport is the machine readable report written by -format json
+type JSONReport struct {
+	SchemaVersion int                 `json:"schema_version"`
+	GeneratedAt   time.Time           `json:"generated_at"`
+	RunID         string              `json:"run_id,omitempty"`
+	Git           *GitInfo            `json:"git,omitempty"`
+	Env           []EnvEntry          `json:"env,omitempty"`    // Go version, platform, CPUs, CI provider and hostname
+	Suites        []*SuiteSummary     `json:"suites,omitempty"` // Merged inputs, when there are several
+	Status        string              `json:"status"`           // "PASSED", "FAILED" or "SKIPPED"
+	Summary       JSONSummary         `json:"summary"`
+	Tests         []*JSONTest         `json:"tests"` // Top-level tests, subtests nested
+	Packages      []*JSONPackage      `json:"packages"`
+	Benchmarks    []*JSONBenchmark    `json:"benchmarks,omitempty"`
+	Coverage      *JSONCoverage       `json:"coverage,omitempty"`
+	DataRaces     []*DataRace         `json:"data_races,omitempty"`
+	Fuzzing       []*FuzzResult       `json:"fuzzing,omitempty"`
+	Verification  *StreamVerification `json:"stream_verification,omitempty"`
+	Diagnostics   *InputDiagnostics   `json:"input_diagnostics,omitempty"`
+	Release       *ReleaseEvaluation  `json:"release,omitempty"`
+	QualityGates  []GateResult        `json:"quality_gates,omitempty"`
+	DurationStats *DurationStats      `json:"duration_stats,omitempty"`
+}
+
+// JSONSummary holds the run totals, counting top-level tests only
+type JSONSummary struct {
+	Total          int     `json:"total"`
+	Passed         int     `json:"passed"`
+	Failed         int     `json:"failed"`
+	Skipped        int     `json:"skipped"`
+	FailedPackages int     `json:"failed_packages"`
+	PassRate       float64 `json:"pass_rate"`  // Percent
+	Duration       float64 `json:"duration"`   // Seconds, summed over the root tests
+	WallClock      float64 `json:"wall_clock"` // Seconds from the first test start to the last test end
+}
+
+// JSONTest is a test and its subtests
+type JSONTest struct {
+	Name        string           `json:"name"` // Full name, e.g. "TestA/case_1"
+	Package     string           `json:"package"`
+	Status      string           `json:"status"` // "PASS", "FAIL", "SKIP" or "UNKNOWN"
+	Duration    float64          `json:"duration"`
+	Start       *time.Time       `json:"start,omitempty"` // Set when the input has timestamps
+	End         *time.Time       `json:"end,omitempty"`
+	Output      []string         `json:"output"`
+	Severity    string           `json:"severity,omitempty"`
+	Fingerprint string           `json:"fingerprint,omitempty"`
+	Waiver      *Waiver          `json:"waiver,omitempty"`
+	Quarantine  *QuarantineEntry `json:"quarantine,omitempty"`
+	Category    string           `json:"category,omitempty"`    // Cause of the failure, set for failed tests
+	SkipReason  string           `json:"skip_reason,omitempty"` // Message of the t.Skip call, set for skipped tests
+	Doc         string           `json:"doc,omitempty"`         // Doc comment of the test function, with -src
+	Attachments []Attachment     `json:"attachments,omitempty"`
+	Attempts    []Attempt        `json:"attempts,omitempty"` // Earlier runs of a re-run test, oldest first
+	Subtests    []*JSONTest      `json:"subtests,omitempty"`
+}
+
+// JSONPackage is the package-level outcome
+type JSONPackage struct {
+	Name        string   `json:"name"`
+	Status      string   `json:"status"`
+	Duration    float64  `json:"duration"`
+	BuildFailed bool     `json:"build_failed,omitempty"`
+	Output      []string `json:"output,omitempty"`
+	BuildOutput []string `json:"build_output,omitempty"`
+	Severity    string   `json:"severity,omitempty"`
+	ShuffleSeed string   `json:"shuffle_seed,omitempty"`
+	Flags       []string `json:"flags,omitempty"`
+}
+
+// JSONBenchmark is a single benchmark result
+type JSONBenchmark struct {
+	Name        string   `json:"name"`
+	Package     string   `json:"package"`
+	Procs       int      `json:"procs,omitempty"`
+	Iterations  int64    `json:"iterations"`
+	NsPerOp     float64  `json:"ns_per_op"`
+	BytesPerOp  *float64 `json:"bytes_per_op,omitempty"`
+	AllocsPerOp *float64 `json:"allocs_per_op,omitempty"`
+
+	Metrics map[string]float64 `json:"metrics,omitempty"` // Custom metrics by unit, e.g. "MB/s"
+}
+
+// JSONCoverage is the statement coverage of the run
+type JSONCoverage struct {
+	Mode    string              `json:"mode"`
+	Percent float64             `json:"percent"`
+	Files   []*JSONFileCoverage `json:"files"`
+}
+
+// JSONFileCoverage is the statement coverage of a single file
+type JSONFileCoverage struct {
+	Name       string  `json:"name"`
+	Statements int     `json:"statements"`
+	Covered    int     `json:"covered"`
+	Percent    float64 `json:"percent"`
+}
+
+// newJSONReport converts the report data to the stable JSON schema
+func newJSONReport(data *ReportData, opts ReportOptions, now time.Time) *JSONReport {
+	report := &JSONReport{
+		SchemaVersion: jsonSchemaVersion,
+		GeneratedAt:   now.UTC(),
+		RunID:         data.RunID,
+		Git:           data.Git,
+		Env:           data.Env,
+		Suites:        data.Suites,
+		Status:        gatedStatus(data, opts.Gates),
+		Summary: JSONSummary{
+			Total:          data.TotalTests,
+			Passed:         data.PassedTests,
+			Failed:         data.FailedTests,
+			Skipped:        data.SkippedTests,
+			FailedPackages: data.FailedPackages,
+			PassRate:       passRate(data),
+			Duration:       data.TotalDuration,
+			WallClock:      data.WallClock(),
+		},
+		Tests:         []*JSONTest{},
+		Packages:      []*JSONPackage{},
+		Release:       opts.Release,
+		QualityGates:  opts.Gates,
+		DurationStats: durationStats(data),
+		DataRaces:     data.DataRaces,
+		Fuzzing:       data.Fuzz,
+		Verification:  data.Verification,
+		Diagnostics:   data.Diagnostics,
+	}
+
+	var convert func(name string) *JSONTest
+	convert = func(name string) *JSONTest {
+		result := data.Results[name]
+		test := &JSONTest{
+			Name:        result.Name,
+			Package:     result.Package,
+			Status:      result.Status,
+			Duration:    result.Duration,
+			Output:      result.Output,
+			Severity:    result.Severity,
+			Fingerprint: result.Fingerprint,
+			Waiver:      result.Waiver,
+			Quarantine:  result.Quarantine,
+			Category:    result.Category,
+			Doc:         result.Doc,
+			Attachments: result.Attachments,
+			Attempts:    result.Attempts,
+		}
+		if test.Output == nil {
+			test.Output = []string{}
+		}
+		if result.Status == "SKIP" {
+			test.SkipReason = skipReason(result.Output)
+		}
+		if !result.Start.IsZero() {
+			start := result.Start.UTC()
+			test.Start = &start
+		}
+		if !result.End.IsZero() {
+			end := result.End.UTC()
+			test.End = &end
+		}
+		subtests := append([]string(nil), result.SubTests...)
+		sort.Strings(subtests)
+		for _, sub := range subtests {
+			if key := testKey(result.Package, sub); data.Results[key] != nil {
+				test.Subtests = append(test.Subtests, convert(key))
+			}
+		}
+		return test
+	}
+	for _, name := range data.SortedTestNames {
+		report.Tests = append(report.Tests, convert(name))
+	}
+
+	var packageNames []string
+	for name := range data.Packages {
+		packageNames = append(packageNames, name)
+	}
+	sort.Strings(packageNames)
+	for _, name := range packageNames {
+		pkg := data.Packages[name]
+		report.Packages = append(report.Packages, &JSONPackage{
+			Name:        pkg.Name,
+			Status:      pkg.Status,
+			Duration:    pkg.Duration,
+			BuildFailed: pkg.BuildFailed,
+			Output:      pkg.Output,
+			BuildOutput: pkg.BuildOutput,
+			Severity:    pkg.Severity,
+			ShuffleSeed: pkg.ShuffleSeed,
+			Flags:       pkg.Flags,
+		})
+	}
+
+	for _, bench := range data.Benchmarks {
+		b := &JSONBenchmark{
+			Name:       bench.Name,
+			Package:    bench.Package,
+			Procs:      bench.Procs,
+			Iterations: bench.Iterations,
+			NsPerOp:    bench.NsPerOp,
+		}
+		if bench.HasMemStats {
+			bytes, allocs := bench.BytesPerOp, bench.AllocsPerOp
+			b.BytesPerOp, b.AllocsPerOp = &bytes, &allocs
+		}
+		if len(bench.Metrics) > 0 {
+			b.Metrics = make(map[string]float64, len(bench.Metrics))
+			for _, m := range bench.Metrics {
+				b.Metrics[m.Unit] = m.Value
+			}
+		}
+		report.Benchmarks = append(report.Benchmarks, b)
+	}
+
+	if data.Coverage != nil {
+		report.Coverage = &JSONCoverage{Mode: data.Coverage.Mode, Percent: data.Coverage.Percent(), Files: []*JSONFileCoverage{}}
+		for _, f := range data.Coverage.SortedFiles() {
+			report.Coverage.Files = append(report.Coverage.Files, &JSONFileCoverage{
+				Name:       f.Name,
+				Statements: f.Statements,
+				Covered:    f.Covered,
+				Percent:    f.Percent(),
+			})
+		}
+	}
+	return report
+}
+
+// renderJSONReport renders the report as indented JSON
+func renderJSONReport(data *ReportData, opts ReportOptions, now time.Time) (string, error) {
+	indent, _ := strconv.Atoi(opts.Formats.Get("json.indent"))
+	var content []byte
+	var err error
+	if indent == 0 {
+		content, err = json.Marshal(newJSONReport(data, opts, now))
+	} else {
+		content, err = json.MarshalIndent(newJSONReport(data, opts, now), "", strings.Repeat(" ", indent))
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(content) + "\n", nil
+}
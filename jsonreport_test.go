@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderJSONReport(t *testing.T) {
+	input := `{"Time":"2024-03-20T15:30:00Z","Action":"run","Package":"pkg/a","Test":"TestA"}
+{"Time":"2024-03-20T15:30:00Z","Action":"run","Package":"pkg/a","Test":"TestA/case"}
+{"Time":"2024-03-20T15:30:00Z","Action":"output","Package":"pkg/a","Test":"TestA/case","Output":"    a_test.go:10: Error: boom\n"}
+{"Time":"2024-03-20T15:30:01Z","Action":"fail","Package":"pkg/a","Test":"TestA/case","Elapsed":0.5}
+{"Time":"2024-03-20T15:30:01Z","Action":"fail","Package":"pkg/a","Test":"TestA","Elapsed":0.6}
+{"Time":"2024-03-20T15:30:01Z","Action":"run","Package":"pkg/a","Test":"TestB"}
+{"Time":"2024-03-20T15:30:01Z","Action":"pass","Package":"pkg/a","Test":"TestB","Elapsed":0.1}
+{"Time":"2024-03-20T15:30:02Z","Action":"fail","Package":"pkg/a","Elapsed":0.7}
+`
+	data, err := processTestEvents(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	content, err := renderJSONReport(data, ReportOptions{}, time.Date(2024, 3, 20, 16, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var report JSONReport
+	if err := json.Unmarshal([]byte(content), &report); err != nil {
+		t.Fatalf("Report is not valid JSON: %v", err)
+	}
+	if report.SchemaVersion != jsonSchemaVersion || report.Status != "FAILED" {
+		t.Errorf("Unexpected header: version=%d status=%s", report.SchemaVersion, report.Status)
+	}
+	if report.Summary.Total != 2 || report.Summary.Failed != 1 || report.Summary.PassRate != 50 {
+		t.Errorf("Unexpected summary: %+v", report.Summary)
+	}
+	if len(report.Tests) != 2 || report.Tests[0].Name != "TestA" || report.Tests[1].Name != "TestB" {
+		t.Fatalf("Expected top-level tests in order, got %+v", report.Tests)
+	}
+	sub := report.Tests[0].Subtests
+	if len(sub) != 1 || sub[0].Name != "TestA/case" || sub[0].Fingerprint == "" {
+		t.Errorf("Expected the failed subtest nested with a fingerprint, got %+v", sub)
+	}
+	if len(sub) == 1 && (len(sub[0].Output) != 1 || !strings.Contains(sub[0].Output[0], "boom")) {
+		t.Errorf("Expected subtest output, got %v", sub[0].Output)
+	}
+	if len(report.Packages) != 1 || report.Packages[0].Status != "FAIL" {
+		t.Errorf("Unexpected packages: %+v", report.Packages)
+	}
+
+	for _, field := range []string{`"schema_version": 1`, `"generated_at": "2024-03-20T16:00:00Z"`, `"pass_rate": 50`, `"output": []`} {
+		if !strings.Contains(content, field) {
+			t.Errorf("Expected %s in JSON report", field)
+		}
+	}
+	if strings.Contains(content, `"coverage"`) || strings.Contains(content, `"benchmarks"`) {
+		t.Error("Absent coverage and benchmarks should be omitted")
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// JUnit XML elements of the report, in the layout read by AWS CodeBuild
+// report groups, Jenkins and most CI servers
+type junitXMLSuites struct {
+	XMLName  xml.Name        `xml:"testsuites"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Suites   []junitXMLSuite `xml:"testsuite"`
+}
+
+type junitXMLSuite struct {
+	Name      string         `xml:"name,attr"`
+	Tests     int            `xml:"tests,attr"`
+	Failures  int            `xml:"failures,attr"`
+	Errors    int            `xml:"errors,attr"`
+	Skipped   int            `xml:"skipped,attr"`
+	Time      string         `xml:"time,attr"`
+	Timestamp string         `xml:"timestamp,attr,omitempty"`
+	Cases     []junitXMLCase `xml:"testcase"`
+}
+
+type junitXMLCase struct {
+	ClassName string          `xml:"classname,attr"`
+	Name      string          `xml:"name,attr"`
+	Time      string          `xml:"time,attr"`
+	Failure   *junitXMLResult `xml:"failure"`
+	Error     *junitXMLResult `xml:"error"`
+	Skipped   *junitXMLResult `xml:"skipped"`
+}
+
+type junitXMLResult struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func junitTime(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
+
+// renderJUnitReport writes a test suite per package with a test case per
+// test and subtest. Failed tests carry their output, quarantined failures
+// are skipped as they do not fail the run, and a package failing outside of
+// its tests, e.g. on a build error, gets an errored test case.
+func renderJUnitReport(data *ReportData) (string, error) {
+	byPackage := make(map[string][]*TestResult)
+	for _, result := range data.Results {
+		byPackage[result.Package] = append(byPackage[result.Package], result)
+	}
+	broken := make(map[string]*PackageResult)
+	for _, pkg := range packageFailures(data) {
+		broken[pkg.Name] = pkg
+		if _, ok := byPackage[pkg.Name]; !ok {
+			byPackage[pkg.Name] = nil
+		}
+	}
+	names := make([]string, 0, len(byPackage))
+	for name := range byPackage {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	report := junitXMLSuites{Time: junitTime(data.TotalDuration)}
+	for _, name := range names {
+		results := byPackage[name]
+		sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+		suite := junitXMLSuite{Name: name}
+		if pkg := data.Packages[name]; pkg != nil {
+			suite.Time = junitTime(pkg.Duration)
+		} else {
+			suite.Time = junitTime(0)
+		}
+		var start time.Time
+		for _, result := range results {
+			c := junitXMLCase{ClassName: name, Name: result.Name, Time: junitTime(result.Duration)}
+			switch {
+			case result.Status == "FAIL" && result.Quarantine != nil:
+				c.Skipped = &junitXMLResult{Message: "quarantined: " + failureMessage(result.Output)}
+				suite.Skipped++
+			case result.Status == "FAIL":
+				c.Failure = &junitXMLResult{Message: failureMessage(result.Output), Text: strings.Join(result.Output, "\n")}
+				suite.Failures++
+			case result.Status == "SKIP":
+				c.Skipped = &junitXMLResult{Text: strings.Join(result.Output, "\n")}
+				suite.Skipped++
+			case result.Status != "PASS":
+				c.Error = &junitXMLResult{Message: "test did not finish", Text: strings.Join(result.Output, "\n")}
+				suite.Errors++
+			}
+			if !result.Start.IsZero() && (start.IsZero() || result.Start.Before(start)) {
+				start = result.Start
+			}
+			suite.Cases = append(suite.Cases, c)
+		}
+		if pkg := broken[name]; pkg != nil {
+			output := pkg.BuildOutput
+			if len(output) == 0 {
+				output = pkg.Output
+			}
+			suite.Cases = append(suite.Cases, junitXMLCase{
+				ClassName: name, Name: "package", Time: suite.Time,
+				Error: &junitXMLResult{Message: failureMessage(output), Text: strings.Join(output, "\n")},
+			})
+			suite.Errors++
+		}
+		if !start.IsZero() {
+			suite.Timestamp = start.UTC().Format("2006-01-02T15:04:05")
+		}
+		suite.Tests = len(suite.Cases)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		report.Skipped += suite.Skipped
+		report.Suites = append(report.Suites, suite)
+	}
+
+	content, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(content) + "\n", nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestRenderJUnitReport(t *testing.T) {
+	data, err := processTestEvents(strings.NewReader(`{"Action":"run","Package":"pkg","Test":"TestA"}
+{"Action":"pass","Package":"pkg","Test":"TestA","Elapsed":0.25}
+{"Action":"run","Package":"pkg","Test":"TestB"}
+{"Action":"run","Package":"pkg","Test":"TestB/sub"}
+{"Action":"output","Package":"pkg","Test":"TestB/sub","Output":"    b_test.go:9: Error: <nil> != 1\n"}
+{"Action":"fail","Package":"pkg","Test":"TestB/sub","Elapsed":0.1}
+{"Action":"fail","Package":"pkg","Test":"TestB","Elapsed":0.1}
+{"Action":"run","Package":"pkg","Test":"TestC"}
+{"Action":"skip","Package":"pkg","Test":"TestC"}
+{"Action":"fail","Package":"pkg","Elapsed":0.5}
+{"Action":"output","Package":"broken","Output":"broken/x.go:3:1: syntax error\n"}
+{"Action":"output","Package":"broken","Output":"FAIL\tbroken [build failed]\n"}
+{"Action":"fail","Package":"broken"}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data.Results[testKey("pkg", "TestB/sub")].Quarantine = &QuarantineEntry{Test: "TestB/sub"}
+
+	report, err := renderJUnitReport(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(report, xml.Header) {
+		t.Error("Expected an XML declaration")
+	}
+	var parsed junitXMLSuites
+	if err := xml.Unmarshal([]byte(report), &parsed); err != nil {
+		t.Fatalf("Expected valid XML, got %v:\n%s", err, report)
+	}
+	if parsed.Tests != 5 || parsed.Failures != 1 || parsed.Skipped != 2 || parsed.Errors != 1 {
+		t.Errorf("Unexpected totals tests=%d failures=%d skipped=%d errors=%d", parsed.Tests, parsed.Failures, parsed.Skipped, parsed.Errors)
+	}
+	if len(parsed.Suites) != 2 || parsed.Suites[0].Name != "broken" || parsed.Suites[1].Name != "pkg" {
+		t.Fatalf("Expected a suite per package sorted by name, got %+v", parsed.Suites)
+	}
+	if c := parsed.Suites[0].Cases[0]; c.Name != "package" || c.Error == nil || !strings.Contains(c.Error.Text, "syntax error") {
+		t.Errorf("Expected an errored package case with the build output, got %+v", c)
+	}
+	cases := parsed.Suites[1].Cases
+	if cases[0].Name != "TestA" || cases[0].Time != "0.250" || cases[0].Failure != nil {
+		t.Errorf("Expected TestA to pass in 0.250s, got %+v", cases[0])
+	}
+	if cases[1].Name != "TestB" || cases[1].Failure == nil {
+		t.Errorf("Expected TestB to fail, got %+v", cases[1])
+	}
+	if cases[2].Name != "TestB/sub" || cases[2].Skipped == nil || cases[2].Skipped.Message != "quarantined: b_test.go:9: Error: <nil> != 1" {
+		t.Errorf("Expected the quarantined subtest to be skipped, got %+v", cases[2])
+	}
+}
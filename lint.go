@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+// Size limits of the places a rendered report is commonly published to
+const (
+	githubCommentLimit = 65536   // Characters in a GitHub issue or PR comment
+	stepSummaryLimit   = 1 << 20 // Bytes in a GitHub Actions job summary
+)
+
+// lintFixture is sample report data of a particular shape
+type lintFixture struct {
+	Name string
+	Data *ReportData
+}
+
+// lintFixtures returns report data covering the shapes a template has to
+// cope with
+func lintFixtures() []lintFixture {
+	build := func(results []*TestResult, packages []*PackageResult) *ReportData {
+		data := &ReportData{Results: make(map[string]*TestResult), Packages: make(map[string]*PackageResult)}
+		for _, r := range results {
+			data.Results[testKey(r.Package, r.Name)] = r
+		}
+		for _, p := range packages {
+			data.Packages[p.Name] = p
+		}
+		summarizeReport(data, nil)
+		return data
+	}
+
+	empty := build(nil, nil)
+
+	passing := build([]*TestResult{
+		{Name: "TestAdd", Package: "example.com/calc", Status: "PASS", Duration: 0.01, Output: []string{}},
+		{Name: "TestSub", Package: "example.com/calc", Status: "PASS", Duration: 0.02, Output: []string{}},
+		{Name: "TestSkipped", Package: "example.com/calc", Status: "SKIP", Output: []string{"    calc_test.go:30: needs a database"}},
+	}, []*PackageResult{{Name: "example.com/calc", Status: "PASS", Duration: 0.05}})
+	passing.Benchmarks = []*BenchmarkResult{{Name: "BenchmarkAdd", Package: "example.com/calc", Procs: 8, Iterations: 1000000, NsPerOp: 12.5}}
+	passing.Coverage = &CoverageData{Mode: "set", Files: map[string]*FileCoverage{
+		"example.com/calc/calc.go": {Name: "example.com/calc/calc.go", Statements: 10, Covered: 8},
+	}}
+
+	failing := build([]*TestResult{
+		{Name: "TestParse", Package: "example.com/parser", Status: "FAIL", Duration: 0.3, SubTests: []string{"TestParse/empty", "TestParse/nested"}, Output: []string{"=== RUN   TestParse"}},
+		{Name: "TestParse/empty", Package: "example.com/parser", Status: "PASS", Duration: 0.1, IsSubTest: true, ParentTest: "TestParse", Output: []string{}},
+		{Name: "TestParse/nested", Package: "example.com/parser", Status: "FAIL", Duration: 0.2, IsSubTest: true, ParentTest: "TestParse", Output: []string{
+			"    parser_test.go:42: unexpected result:", "        got:  1", "        want: 2", "--- FAIL: TestParse/nested (0.20s)",
+		}},
+		{Name: "TestPanic", Package: "example.com/parser", Status: "FAIL", Duration: 0, Output: []string{"panic: runtime error: index out of range [3] with length 3"}},
+	}, []*PackageResult{
+		{Name: "example.com/parser", Status: "FAIL", Duration: 0.5},
+		{Name: "example.com/broken", Status: "FAIL", BuildFailed: true, BuildOutput: []string{"broken.go:3:2: undefined: missing"}},
+	})
+
+	var huge []*TestResult
+	for i := 0; i < 5000; i++ {
+		result := &TestResult{Name: fmt.Sprintf("TestGenerated%04d", i), Package: fmt.Sprintf("example.com/big/pkg%02d", i%50), Status: "PASS", Duration: float64(i%97) / 100, Output: []string{}}
+		if i%100 == 0 {
+			result.Status = "FAIL"
+			for line := 0; line < 40; line++ {
+				result.Output = append(result.Output, fmt.Sprintf("    big_test.go:%d: assertion %d failed with a reasonably long message", line+10, line))
+			}
+		}
+		huge = append(huge, result)
+	}
+
+	unicode := build([]*TestResult{
+		{Name: "TestÜnïcødé/名前_テスト", Package: "example.com/i18n", Status: "FAIL", Duration: 0.1, IsSubTest: true, ParentTest: "TestÜnïcødé", Output: []string{"    i18n_test.go:7: got \"🚀\", want \"✅\" | <b>html</b> `code`"}},
+		{Name: "TestÜnïcødé", Package: "example.com/i18n", Status: "FAIL", Duration: 0.1, SubTests: []string{"TestÜnïcødé/名前_テスト"}, Output: []string{}},
+		{Name: "Test_pipe|and<angle>", Package: "example.com/i18n", Status: "PASS", Duration: 0.01, Output: []string{}},
+	}, []*PackageResult{{Name: "example.com/i18n", Status: "FAIL", Duration: 0.2}})
+
+	return []lintFixture{
+		{Name: "empty", Data: empty},
+		{Name: "passing", Data: passing},
+		{Name: "failing", Data: failing},
+		{Name: "huge-suite", Data: build(huge, nil)},
+		{Name: "unicode-names", Data: unicode},
+	}
+}
+
+// lintResult is the outcome of rendering a template with one fixture
+type lintResult struct {
+	Fixture string
+	Err     error
+	Bytes   int
+	Chars   int
+}
+
+// lintTemplate renders a template file against every fixture
+func lintTemplate(file string) ([]lintResult, error) {
+	tmpl, err := parseReportTemplate(file)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template: %v", err)
+	}
+
+	var results []lintResult
+	for _, fixture := range lintFixtures() {
+		var sb strings.Builder
+		err := tmpl.Execute(&sb, fixture.Data)
+		results = append(results, lintResult{
+			Fixture: fixture.Name,
+			Err:     err,
+			Bytes:   sb.Len(),
+			Chars:   utf8.RuneCountInString(sb.String()),
+		})
+	}
+	return results, nil
+}
+
+// lintWarnings describes the publishing limits a rendered report exceeds
+func lintWarnings(r lintResult) []string {
+	var warnings []string
+	if r.Chars > githubCommentLimit {
+		warnings = append(warnings, fmt.Sprintf("exceeds the %d character PR comment limit", githubCommentLimit))
+	}
+	if r.Bytes > stepSummaryLimit {
+		warnings = append(warnings, "exceeds the 1 MiB job summary limit")
+	}
+	if r.Err == nil && r.Bytes == 0 {
+		warnings = append(warnings, "renders nothing")
+	}
+	return warnings
+}
+
+// runLintTemplate implements `gotest-report lint-template FILE`, which
+// renders a custom template against bundled report data of varying shapes
+// and reports template errors and output sizes
+func runLintTemplate(args []string) int {
+	if len(args) != 1 {
+		logger.Errorf("Usage: gotest-report lint-template TEMPLATE")
+		return 2
+	}
+
+	results, err := lintTemplate(args[0])
+	if err != nil {
+		logger.Errorf("%s: %v", args[0], err)
+		return 1
+	}
+
+	failed := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FIXTURE\tRESULT\tSIZE\tNOTES")
+	for _, r := range results {
+		result := "ok"
+		notes := strings.Join(lintWarnings(r), "; ")
+		if r.Err != nil {
+			result, notes = "error", r.Err.Error()
+			failed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Fixture, result, formatSize(int64(r.Bytes)), notes)
+	}
+	w.Flush()
+
+	if failed > 0 {
+		logger.Errorf("%d of %d fixtures failed to render", failed, len(results))
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLintTemplate(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+
+	// The bundled example must work for every fixture
+	results, err := lintTemplate(filepath.Join("examples", "report.md.tmpl"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != len(lintFixtures()) {
+		t.Fatalf("Expected a result per fixture, got %d", len(results))
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			t.Errorf("Example template failed on fixture %s: %v", r.Fixture, r.Err)
+		}
+	}
+
+	// Indexing the first test breaks on an empty suite
+	results, err = lintTemplate(write("first.tmpl", `First: {{ (index .RootResults 0).Name }}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, r := range results {
+		if (r.Err != nil) != (r.Fixture == "empty") {
+			t.Errorf("Fixture %s: unexpected error %v", r.Fixture, r.Err)
+		}
+	}
+
+	if _, err := lintTemplate(write("broken.tmpl", `{{ range .RootResults }}`)); err == nil || !strings.Contains(err.Error(), "error parsing template") {
+		t.Errorf("Expected a parse error, got %v", err)
+	}
+}
+
+func TestLintWarnings(t *testing.T) {
+	tests := []struct {
+		result   lintResult
+		expected string
+	}{
+		{lintResult{Bytes: 100, Chars: 100}, ""},
+		{lintResult{Bytes: 70000, Chars: 70000}, "exceeds the 65536 character PR comment limit"},
+		{lintResult{Bytes: 2 << 20, Chars: 2 << 20}, "exceeds the 65536 character PR comment limit; exceeds the 1 MiB job summary limit"},
+		{lintResult{}, "renders nothing"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(lintWarnings(tt.result), "; "); got != tt.expected {
+			t.Errorf("lintWarnings(%+v) = %q, expected %q", tt.result, got, tt.expected)
+		}
+	}
+}
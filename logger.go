@@ -0,0 +1,183 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// logLevel selects the messages the logger prints
+type logLevel int
+
+const (
+	levelQuiet   logLevel = iota // Errors only
+	levelNormal                  // Warnings and status messages too
+	levelVerbose                 // Each step of the run too
+	levelDebug                   // Parser diagnostics too
+)
+
+// Logger prints status messages to stdout and errors, warnings and
+// diagnostics to stderr, leaving out those below its level
+type Logger struct {
+	stdout io.Writer
+	stderr io.Writer
+	level  logLevel
+}
+
+// logger is the logger of the running command, set up by its -quiet,
+// -verbose and -debug flags
+var logger = &Logger{stdout: os.Stdout, stderr: os.Stderr, level: levelNormal}
+
+func (l *Logger) logf(level logLevel, w io.Writer, prefix, format string, args ...any) {
+	if l.level < level {
+		return
+	}
+	fmt.Fprintf(w, prefix+format+"\n", args...)
+}
+
+// Errorf prints an error, whatever the level
+func (l *Logger) Errorf(format string, args ...any) {
+	l.logf(levelQuiet, l.stderr, "", format, args...)
+}
+
+// Warnf prints a warning, hidden by -quiet
+func (l *Logger) Warnf(format string, args ...any) {
+	l.logf(levelNormal, l.stderr, "Warning: ", format, args...)
+}
+
+// Infof prints a status message to stderr, hidden by -quiet
+func (l *Logger) Infof(format string, args ...any) {
+	l.logf(levelNormal, l.stderr, "", format, args...)
+}
+
+// Printf prints the outcome of a step to stdout, hidden by -quiet
+func (l *Logger) Printf(format string, args ...any) {
+	l.logf(levelNormal, l.stdout, "", format, args...)
+}
+
+// Verbosef prints a step of the run with -verbose or -debug
+func (l *Logger) Verbosef(format string, args ...any) {
+	l.logf(levelVerbose, l.stderr, "", format, args...)
+}
+
+// Debugf prints a diagnostic with -debug
+func (l *Logger) Debugf(format string, args ...any) {
+	l.logf(levelDebug, l.stderr, "debug: ", format, args...)
+}
+
+// logFlags are the verbosity flags every command accepts
+type logFlags struct {
+	quiet, verbose, debug *bool
+}
+
+func registerLogFlags(fs *flag.FlagSet) *logFlags {
+	return &logFlags{
+		quiet:   fs.Bool("quiet", false, "Only print errors"),
+		verbose: fs.Bool("verbose", false, "Also print each step of the run, such as the inputs read and the history loaded"),
+		debug:   fs.Bool("debug", false, "Also print parser diagnostics: events by action, unrecognized actions and malformed lines with their line numbers"),
+	}
+}
+
+// apply sets the level of the logger from the flags
+func (f *logFlags) apply() error {
+	if *f.quiet && (*f.verbose || *f.debug) {
+		return fmt.Errorf("-quiet cannot be combined with -verbose or -debug")
+	}
+	switch {
+	case *f.debug:
+		logger.level = levelDebug
+	case *f.verbose:
+		logger.level = levelVerbose
+	case *f.quiet:
+		logger.level = levelQuiet
+	default:
+		logger.level = levelNormal
+	}
+	return nil
+}
+
+// knownActions are the actions of test2json events
+var knownActions = map[string]bool{
+	"start": true, "run": true, "pause": true, "cont": true, "pass": true, "bench": true, "fail": true,
+	"output": true, "skip": true, "build-output": true, "build-fail": true, "attr": true,
+}
+
+// parseStats counts what the parser read, to explain runs that produce an
+// empty or incomplete report
+type parseStats struct {
+	lines        int
+	blank        int
+	actions      map[string]int
+	unrecognized map[string][]int // Line numbers of events by unrecognized action
+	malformed    []int            // Line numbers of lines that are not JSON events
+	testEvents   int              // Events of a test rather than a package
+}
+
+func newParseStats() *parseStats {
+	return &parseStats{actions: make(map[string]int), unrecognized: make(map[string][]int)}
+}
+
+// record counts an event read from a line
+func (s *parseStats) record(lineNo int, event TestEvent) {
+	s.actions[event.Action]++
+	if !knownActions[event.Action] {
+		s.unrecognized[event.Action] = append(s.unrecognized[event.Action], lineNo)
+	}
+	if event.Test != "" {
+		s.testEvents++
+	}
+}
+
+func (s *parseStats) events() int {
+	events := 0
+	for _, count := range s.actions {
+		events += count
+	}
+	return events
+}
+
+// lineList renders line numbers, eliding all but the first few
+func lineList(lines []int) string {
+	const shown = 10
+	var parts []string
+	for i, line := range lines {
+		if i == shown {
+			parts = append(parts, fmt.Sprintf("and %d more", len(lines)-shown))
+			break
+		}
+		parts = append(parts, fmt.Sprint(line))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// log prints the counts with -debug
+func (s *parseStats) log(l *Logger) {
+	if l.level < levelDebug {
+		return
+	}
+	l.Debugf("read %d line(s): %d event(s), %d blank, %d malformed", s.lines, s.events(), s.blank, len(s.malformed))
+	var actions []string
+	for action := range s.actions {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	var counts []string
+	for _, action := range actions {
+		counts = append(counts, fmt.Sprintf("%s=%d", action, s.actions[action]))
+	}
+	if len(counts) > 0 {
+		l.Debugf("events by action: %s", strings.Join(counts, " "))
+	}
+	l.Debugf("%d event(s) of tests, %d of packages and builds", s.testEvents, s.events()-s.testEvents)
+	for _, action := range actions {
+		if lines := s.unrecognized[action]; len(lines) > 0 {
+			l.Debugf("unrecognized action %q on line(s) %s", action, lineList(lines))
+		}
+	}
+	if len(s.malformed) > 0 {
+		l.Debugf("malformed line(s) %s", lineList(s.malformed))
+	}
+}
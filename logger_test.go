@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+// captureLogger replaces the logger for the test and returns its outputs
+func captureLogger(t *testing.T, level logLevel) (stdout, stderr *strings.Builder) {
+	t.Helper()
+	stdout, stderr = &strings.Builder{}, &strings.Builder{}
+	previous := logger
+	logger = &Logger{stdout: stdout, stderr: stderr, level: level}
+	t.Cleanup(func() { logger = previous })
+	return stdout, stderr
+}
+
+func TestLoggerLevels(t *testing.T) {
+	tests := []struct {
+		level          logLevel
+		expectedStdout string
+		expectedStderr string
+	}{
+		{levelQuiet, "", "Error: failed\n"},
+		{levelNormal, "Report generated\n", "Error: failed\nWarning: careful\nsummary\n"},
+		{levelVerbose, "Report generated\n", "Error: failed\nWarning: careful\nsummary\nReading input\n"},
+		{levelDebug, "Report generated\n", "Error: failed\nWarning: careful\nsummary\nReading input\ndebug: 3 events\n"},
+	}
+	for _, tt := range tests {
+		stdout, stderr := captureLogger(t, tt.level)
+		logger.Errorf("Error: %s", "failed")
+		logger.Warnf("careful")
+		logger.Infof("summary")
+		logger.Printf("Report generated")
+		logger.Verbosef("Reading input")
+		logger.Debugf("%d events", 3)
+		if stdout.String() != tt.expectedStdout || stderr.String() != tt.expectedStderr {
+			t.Errorf("Level %d: expected stdout %q and stderr %q, got %q and %q", tt.level, tt.expectedStdout, tt.expectedStderr, stdout, stderr)
+		}
+	}
+}
+
+func TestLogFlags(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected logLevel
+		wantErr  bool
+	}{
+		{nil, levelNormal, false},
+		{[]string{"-quiet"}, levelQuiet, false},
+		{[]string{"-verbose"}, levelVerbose, false},
+		{[]string{"-verbose", "-debug"}, levelDebug, false},
+		{[]string{"-quiet", "-debug"}, 0, true},
+	}
+	for _, tt := range tests {
+		captureLogger(t, levelNormal)
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags := registerLogFlags(fs)
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		err := flags.apply()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: expected error %v, got %v", tt.args, tt.wantErr, err)
+		} else if err == nil && logger.level != tt.expected {
+			t.Errorf("%v: expected level %d, got %d", tt.args, tt.expected, logger.level)
+		}
+	}
+}
+
+func TestParseStatsDebug(t *testing.T) {
+	input := `{"Action":"start","Package":"pkg/example"}
+{"Action":"run","Package":"pkg/example","Test":"TestA"}
+
+{"Action":"finish","Package":"pkg/example","Test":"TestA"}
+{"Action":"pass","Package":"pkg/example","Test":"TestA","Elapsed":0.1}
+{"Action":"pass","Package":"pkg/example","Elapsed":0.2}
+not json
+`
+	_, stderr := captureLogger(t, levelDebug)
+	if _, err := parseTestEvents(strings.NewReader(input), ParseOptions{}); err != nil {
+		t.Fatalf("Failed to parse test events: %v", err)
+	}
+	for _, expected := range []string{
+		"debug: read 7 line(s): 5 event(s), 1 blank, 1 malformed\n",
+		"debug: events by action: finish=1 pass=2 run=1 start=1\n",
+		"debug: 3 event(s) of tests, 2 of packages and builds\n",
+		"debug: unrecognized action \"finish\" on line(s) 4\n",
+		"debug: malformed line(s) 7\n",
+	} {
+		if !strings.Contains(stderr.String(), expected) {
+			t.Errorf("Expected debug output to contain %q, got:\n%s", expected, stderr)
+		}
+	}
+
+	_, stderr = captureLogger(t, levelNormal)
+	parseTestEvents(strings.NewReader(input), ParseOptions{})
+	if stderr.Len() != 0 {
+		t.Errorf("Expected no diagnostics without -debug, got %q", stderr)
+	}
+}
+
+func TestLineList(t *testing.T) {
+	lines := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	if got := lineList(lines); got != "1, 2, 3, 4, 5, 6, 7, 8, 9, 10, and 2 more" {
+		t.Errorf("Unexpected line list %q", got)
+	}
+}
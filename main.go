@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -17,24 +18,76 @@ var version = "dev"
 
 // TestEvent represents a single event from go test -json output
 type TestEvent struct {
-	Time    time.Time // Time when the event occurred
-	Action  string    // Action: "run", "pause", "cont", "pass", "bench", "fail", "skip", "output"
-	Test    string    // Test name
-	Package string    // Package being tested
-	Output  string    // Output text (for "output" action)
-	Elapsed float64   // Elapsed time in seconds for "pass" or "fail" events
+	Time        time.Time `json:",omitempty"` // Time when the event occurred
+	Action      string    // Action: "run", "pause", "cont", "pass", "bench", "fail", "skip", "output", "build-output", "build-fail"
+	Test        string    `json:",omitempty"` // Test name
+	Package     string    `json:",omitempty"` // Package being tested
+	Output      string    `json:",omitempty"` // Output text (for "output" action)
+	Elapsed     float64   `json:",omitempty"` // Elapsed time in seconds for "pass" or "fail" events
+	ImportPath  string    `json:",omitempty"` // Package being built (for "build-output" and "build-fail" actions)
+	FailedBuild string    `json:",omitempty"` // ImportPath of the build that caused a package to fail
 }
 
 // TestResult holds the aggregated result for a single test
 type TestResult struct {
-	Name       string
-	Package    string
-	Status     string // "PASS", "FAIL", "SKIP"
-	Duration   float64
-	Output     []string
-	ParentTest string // For subtests
-	SubTests   []string
-	IsSubTest  bool
+	Name        string
+	Package     string
+	Status      string // "PASS", "FAIL", "SKIP"
+	Duration    float64
+	Output      []string
+	ParentTest  string // For subtests
+	SubTests    []string
+	IsSubTest   bool
+	Severity    string           // "P0"-"P3" when a severity file is used
+	Fingerprint string           // Identifies the failure across runs, set for failed tests
+	Waiver      *Waiver          // Set when the failure was accepted
+	Quarantine  *QuarantineEntry // Set when the test is on the -quarantine list
+	Category    string           // Cause of the failure, e.g. "timeout", set for failed tests
+	Doc         string           // Doc comment of the test function, set with -src
+	Start       time.Time        // Time of the run event
+	End         time.Time        // Time of the pass, fail or skip event
+	Attachments []Attachment     // Files referenced with ::attach directives in the output
+	Pauses      []TimeSpan       // From pause to cont events, while a parallel test waited for a slot
+	Attempts    []Attempt        // Earlier runs when the test was run again, oldest first
+}
+
+// testKey returns the key of a test in ReportData.Results. Tests are keyed
+// by package too, since tests of different packages often share a name.
+func testKey(pkg, name string) string {
+	return pkg + "." + name
+}
+
+// ReportOptions controls optional parts of the generated report
+type ReportOptions struct {
+	CardsDir    string             // Directory (relative to the report) holding SVG summary cards
+	SummaryOnly bool               // Stop after the summary and status sections
+	History     []*RunRecord       // Previous runs, oldest first, for the Trends section
+	BenchSort   string             // Benchmark table order: "name", "ns", "bytes" or "allocs"
+	Release     *ReleaseEvaluation // Go/no-go verdict of the release profile
+	Regressions *RegressionReport  // Tests slower than the baseline, set with -max-duration-regression
+	Hygiene     *SuiteHygiene      // Serial tests and unconditional skips, set with -src
+	Slowdown    *SuiteSlowdown     // Run slower than the trailing average, set with -max-suite-slowdown
+	Gates       []GateResult       // Enabled exit code gates, shown in the Quality Gates section
+
+	LeastCovered *FunctionCoverageReport // Least covered functions, set with a coverprofile
+
+	FilterOutput   bool // Only show FAIL/Error/panic lines of failed tests
+	MaxOutputLines int  // Truncate failure output to this many lines, 0 for no limit
+
+	StackFrames *StackFrameConfig // Stack frames hidden or collapsed in failure output, nil shows them all
+	GoldenLinks *GoldenLinks      // Links golden files in failure diffs to an editor, nil for no links
+	SourceLinks *SourceLinks      // Resolves and links panic frames to the repository, nil leaves them unresolved
+
+	ClusterSimilarity float64         // Group similar failed subtests from this similarity (0-1), 0 disables
+	Normalize         []normalizeRule // Rules of the config file applied to failures before clustering
+
+	HiddenSections    map[string]bool // Sections left out of the report, see reportSections
+	GroupByPackage    bool            // Split the Test Results table by package
+	FailedResultsOnly bool            // Leave passed and skipped tests out of Test Results, set to fit -max-bytes
+	TopDurations      int             // Tests listed in Test Durations, 0 for the default
+	SlowThreshold     float64         // Seconds below which tests are left out of Test Durations
+
+	Formats FormatOptions // Namespaced format options such as "html.theme", defaults when nil
 }
 
 // ReportData contains all data needed for the report
@@ -43,93 +96,1053 @@ type ReportData struct {
 	PassedTests     int
 	FailedTests     int
 	SkippedTests    int
-	TotalDuration   float64
+	TotalDuration   float64   // Sum of the root test durations
+	Start           time.Time // Earliest test start
+	End             time.Time // Latest test end
+	FailedPackages  int       // Packages that failed without a failing test, e.g. build failures
 	Results         map[string]*TestResult
 	SortedTestNames []string
+	Packages        map[string]*PackageResult
+	Benchmarks      []*BenchmarkResult
+	Coverage        *CoverageData // Set when a coverprofile is given
+	DataRaces       []*DataRace
+	Fuzz            []*FuzzResult       // Fuzz targets run with -fuzz
+	Verification    *StreamVerification // Set with -verify-stream
+	Diagnostics     *InputDiagnostics   // Input lines that were not events
+	RunID           string              // Correlates the outputs of the run, see -run-id
+	Git             *GitInfo            // Tested commit, see -git-sha
+	Env             []EnvEntry          // Machine the tests ran on, see -env
+	Suites          []*SuiteSummary     // Merged inputs, set when there are several
 }
 
-func main() {
-	inputFile := flag.String("input", "", "go test -json output file (default is stdin)")
-	outputFile := flag.String("output", "test-report.md", "Output markdown file")
-	showVersion := flag.Bool("version", false, "Show version information")
-	flag.Parse()
+// runGenerate implements the generate and merge commands, and the legacy
+// invocation with flags only, which render a report from go test -json runs.
+// minInputs is the number of inputs the command requires.
+func runGenerate(name string, args []string, minInputs int) int {
+	return generate(name, args, minInputs, false)
+}
+
+// generate renders the report. Regenerations of -watch only rewrite the
+// report files: recording the run, publishing and notifying are skipped, as
+// they would repeat on every change of the inputs.
+func generate(name string, args []string, minInputs int, watching bool) int {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: gotest-report %s [flags] [FILE...]\n\n", name)
+		fs.PrintDefaults()
+	}
+	var inputFiles stringList
+	fs.Var(&inputFiles, "input", "go test -json output file; repeat or use a glob to merge sharded runs (default is stdin)")
+	outputFile := fs.String("output", "test-report.md", "Output report file (default is test-report.json, .html, .csv, .tsv or .xml with -format json, html-interactive, html-jenkins, csv, tsv or junit)")
+	format := fs.String("format", "markdown", "Format of the output file: markdown, json, html-interactive, html-jenkins (static HTML with its stylesheet in an assets folder, for the Jenkins HTML Publisher), csv, tsv or junit")
+	maxLineSize := fs.Int("max-line-size", defaultMaxLineSize, "Maximum size in bytes of a single go test -json input line")
+	verifyStream := fs.Bool("verify-stream", false, "Check the event stream invariants, report violations and exit non-zero when there are any")
+	normalizeTime := fs.Bool("normalize-time", false, "Anchor the timestamps of each -input to a common start before merging, for shards from machines with skewed clocks")
+	spoolOutput := fs.Bool("spool-output", false, "Keep test output in a temporary file while parsing and only report the output of failed tests, for very large inputs")
+	configFile := fs.String("config", "", "YAML config file selecting report sections and limits (default is "+defaultConfigFile+" when present)")
+	hideSections := fs.String("hide-sections", "", "Comma separated report sections to leave out: "+strings.Join(reportSections, ", "))
+	groupByPackage := fs.Bool("group-by-package", false, "Split the Test Results table by package")
+	sample := fs.String("sample", "", "Render built-in synthetic data instead of an input to preview formatting: small, large or failures")
+	topDurations := fs.Int("top-durations", defaultTopDurations, "Number of tests listed in the Test Durations section")
+	fs.IntVar(topDurations, "slow-top", defaultTopDurations, "Alias of -top-durations")
+	slowThreshold := fs.Duration("slow-threshold", 0, "Only list tests taking at least this long in the Test Durations section, e.g. 1s")
+	showVersion := fs.Bool("version", false, "Show version information")
+	stepSummary := fs.Bool("summary", false, "Append the report to the GitHub Actions job summary ($GITHUB_STEP_SUMMARY)")
+	writeIndex := fs.Bool("index", false, "Also write index.md and index.html linking every generated artifact")
+	gcsBucket := fs.String("gcs-bucket", "", "Upload the generated artifacts to gs://BUCKET[/PREFIX]/BUILD_ID/ and print links to them (the run ID outside of Cloud Build)")
+	benchSort := fs.String("bench-sort", "ns", "Sort order of the benchmark table: name, ns, bytes or allocs")
+	atomFeed := fs.String("atom-feed", "", "Add this run to an Atom feed file, creating it if needed")
+	reportURL := fs.String("report-url", "", "Public URL of the published report, used for links in feeds and notifications")
+	historyDir := fs.String("history-dir", "", "Directory storing run history; enables the Trends section")
+	historyURL := fs.String("history-url", "", "Shared remote history used instead of -history-dir: an http(s) document URL or a postgres:// DSN")
+	historyRuns := fs.Int("history-runs", 10, "Number of previous runs compared in the Trends section")
+	environment := fs.String("environment", "", "Environment the suite ran against, e.g. staging; the run is stored with it and only compared with runs of the same environment")
+	checkstyleFile := fs.String("checkstyle", "", "Also write the failure locations as checkstyle XML to this file, for the Jenkins Warnings NG plugin")
+	icalFile := fs.String("ical", "", "Export the run history as an iCalendar (.ics) file (requires -history-dir or -history-url)")
+	var envPairs stringList
+	fs.Var(&envPairs, "env", "Environment property shown in the Environment section as key=value, replacing a detected one such as \"Go version\"; can be repeated")
+	srcPattern := fs.String("src", "", "Parse the test files of this package pattern, e.g. ./..., to show the doc comments of tests as descriptions and add a Suite Hygiene appendix")
+	quarantineFile := fs.String("quarantine", "", "YAML file listing tests allowed to fail; their failures are reported separately and do not fail the run")
+	severityFile := fs.String("severity-file", "", "YAML file assigning P0-P3 severities to tests and packages")
+	failOnSeverity := fs.String("fail-on-severity", "", "Exit non-zero when a failure at this severity or higher exists, e.g. P1 (requires -severity-file)")
+	failOnFailure := fs.Bool("fail-on-failure", false, "Exit non-zero when any test or package failed")
+	minPassRate := fs.Float64("min-pass-rate", 0, "Exit non-zero when the pass rate in percent is lower, e.g. 95 (0 disables)")
+	maxSkipped := fs.Int("max-skipped", -1, "Exit non-zero when more tests are skipped (-1 disables)")
+	maxFlaky := fs.Int("max-flaky", -1, "Exit non-zero when more tests are flaky across the history (-1 disables)")
+	maxDurationRegression := fs.String("max-duration-regression", "", "List tests slower than the baseline by more than this percentage, e.g. 20%, in a Performance Regressions section")
+	baselineFile := fs.String("baseline", "", "go test -json output of a baseline run for -max-duration-regression (default is the median of the stored history)")
+	failOnDurationRegression := fs.Bool("fail-on-duration-regression", false, "Exit non-zero when -max-duration-regression finds regressed tests")
+	maxSuiteSlowdown := fs.String("max-suite-slowdown", "", "Warn when the wall clock of the run exceeds the average of the last -suite-slowdown-runs runs of the history by more than this percentage, e.g. 15%")
+	suiteSlowdownRuns := fs.Int("suite-slowdown-runs", defaultSuiteSlowdownRuns, "Number of trailing runs -max-suite-slowdown averages")
+	failOnSuiteSlowdown := fs.Bool("fail-on-suite-slowdown", false, "Exit non-zero when -max-suite-slowdown is exceeded")
+	templateFile := fs.String("template", "", "Render the report with a custom text/template file instead of the built-in layout")
+	coverProfile := fs.String("coverprofile", "", "Coverage profile written by go test -coverprofile, adds coverage to the summary")
+	coverageFunctions := fs.Int("coverage-functions", defaultCoverageFunctions, "Number of least covered functions listed with -coverprofile (0 disables)")
+	coverageChangedSince := fs.String("coverage-changed-since", "", "Only list least covered functions of packages changed since this git ref (default is origin/$GITHUB_BASE_REF in pull requests)")
+	profile := fs.String("profile", "", "Report profile (supported: release)")
+	releaseMinPassRate := fs.Float64("release-min-pass-rate", 100, "Release profile: minimum pass rate in percent")
+	releaseMinCoverage := fs.Float64("release-min-coverage", 0, "Release profile: minimum statement coverage in percent (0 disables)")
+	releaseMaxFlaky := fs.Int("release-max-flaky", 0, "Release profile: maximum flaky tests across the history (-1 disables)")
+	failureOutputMode := fs.String("failure-output", "full", "Output shown for failed tests: full, or filtered to FAIL/Error/panic lines")
+	clusterSimilarity := fs.Float64("cluster-similarity", 1, "Show failed subtests of a test once per group when their output is at least this similar, from 0 to 1 where 1 groups identical output apart from numbers (0 disables)")
+	repoURL := fs.String("repo-url", "", "Link panic stack frames to the repository at this URL, e.g. https://github.com/OWNER/REPO")
+	ciName := fs.String("ci", "auto", "CI service whose variables provide the commit, build link and PR: auto, drone, woodpecker or none; those of GitHub Actions, GitLab CI, Jenkins and others are always read")
+	gitSHA := fs.String("git-sha", "", "Commit the tests ran on, shown in the report header (default $GITHUB_SHA or the checked out commit)")
+	gitBranch := fs.String("git-branch", "", "Branch the tests ran on, shown in the report header (default from the CI environment or the checkout)")
+	goldenEditURL := fs.String("golden-edit-url", "", "Link golden files in failure diffs to this URL followed by their repository path, e.g. https://github.com/OWNER/REPO/edit/BRANCH")
+	stackFrames := fs.String("stack-frames", "filtered", "Stack frames of panics in failure output: filtered hides runtime and testing frames and collapses those configured under stack_frames, full shows all")
+	maxOutputLines := fs.Int("max-output-lines", 0, "Truncate the output of each failed test to this many lines (0 for no limit)")
+	maxBytes := fs.Int("max-bytes", 0, "Shrink the Markdown report to this many bytes, e.g. 65536 for a GitHub comment; the full report is kept next to it (0 for no limit)")
+	slackWebhook := fs.String("slack-webhook", "", "Slack incoming webhook URL to send a run summary to")
+	teamsWebhook := fs.String("teams-webhook", "", "Microsoft Teams webhook URL to send a run summary card to")
+	flakyAlertThreshold := fs.Float64("flaky-alert-threshold", 0, "Alert when a test's flip rate between pass and fail across the history crosses this percentage (0 disables)")
+	flakyAlertWebhook := fs.String("flaky-alert-webhook", "", "Webhook URL for flaky test alerts, for tests whose CODEOWNERS have no webhook in the config file")
+	flakyAlertFormat := fs.String("flaky-alert-format", "slack", "Payload of flaky test alerts (supported: "+strings.Join(flakyAlertFormats, ", ")+")")
+	runID := fs.String("run-id", "", "ID correlating the report, history record and notifications of this run; "+runIDPlaceholder+" in -output and -attachments-dir is replaced with it (default is $"+runIDEnv+" or a new UUID)")
+	tee := fs.String("tee", "", "Print progress to stderr while reading the input (supported: "+strings.Join(teeFormats, ", ")+")")
+	watch := fs.Bool("watch", false, "Keep running and regenerate the report whenever the input files change")
+	watchInterval := fs.Duration("watch-interval", time.Second, "How often -watch checks the input files for changes")
+	exitSummary := fs.Bool("exit-summary", true, "Print a final key=value summary line to stderr for CI systems that scrape logs")
+	attachmentsDir := fs.String("attachments-dir", "", "Copy the files attached to failed tests with ::attach directives into this directory and link them relative to the report")
+	cards := fs.String("cards", "", "Render summary cards as images written beside the report (supported: svg)")
+	badgeOut := fs.String("badge-out", "", "Write shields.io endpoint badges (tests, pass rate and coverage) as JSON files into this directory")
+	githubAnnotations := fs.Bool("github-annotations", false, "Alias of -annotations github")
+	annotationsFormat := fs.String("annotations", "", "Write the failures at their source locations for a CI provider to show them inline: github, gitlab (code quality report), buildkite, azure, or auto to detect it")
+	annotationsOutput := fs.String("annotations-output", "", "File -annotations are written to (default is stdout, gl-code-quality-report.json for gitlab, and buildkite-agent annotate for buildkite when it is available)")
+	githubPR := fs.Bool("github-pr", false, "Post or update a sticky PR comment with the report (requires GITHUB_TOKEN)")
+	githubRepo := fs.String("github-repo", "", "Repository for -github-pr and -gitea-pr in owner/name form (default is $GITHUB_REPOSITORY)")
+	githubPRNumber := fs.Int("github-pr-number", 0, "Pull request number for -github-pr and -gitea-pr (default is detected from the event)")
+	githubReview := fs.Bool("github-review", false, "Post failures located on lines changed by the PR as review comments on those lines (requires GITHUB_TOKEN)")
+	giteaPR := fs.Bool("gitea-pr", false, "Post or update a sticky comment with the report on a Gitea or Forgejo PR (requires GITEA_TOKEN)")
+	giteaURL := fs.String("gitea-url", "", "Base URL of the Gitea or Forgejo server for -gitea-pr (default is $GITHUB_SERVER_URL)")
+	commentOverflow := fs.String("comment-overflow", overflowSplit, "How -github-pr posts a report over the comment size limit: split into follow-up comments, or gist to link it from the summary")
+	formatFlags := registerFormatFlags(fs)
+	logFlags := registerLogFlags(fs)
+	// Positional arguments are inputs too, and flags may follow them, e.g.
+	// `gotest-report merge shard-*.json -output report.md`
+	for rest := args; ; {
+		fs.Parse(rest)
+		if fs.NArg() == 0 {
+			break
+		}
+		inputFiles = append(inputFiles, fs.Arg(0))
+		rest = fs.Args()[1:]
+	}
+	if len(inputFiles) < minInputs {
+		fs.Usage()
+		return 2
+	}
+	if err := logFlags.apply(); err != nil {
+		logger.Errorf("Error: %v", err)
+		return 2
+	}
 
 	if *showVersion {
 		fmt.Printf("gotest-report version %s\n", version)
-		os.Exit(0)
+		return 0
+	}
+	if *watch && !watching {
+		return runWatch(name, args, minInputs, inputFiles, *watchInterval)
 	}
 
-	var reader io.Reader = os.Stdin
-	if *inputFile != "" {
-		file, err := os.Open(*inputFile)
+	configPath, configRequired := *configFile, true
+	if configPath == "" {
+		configPath, configRequired = defaultConfigFile, false
+	}
+	config, err := loadConfig(configPath, configRequired)
+	if err != nil {
+		logger.Errorf("Error loading config: %v", err)
+		return 1
+	}
+	hidden, err := hiddenSections(config, *hideSections)
+	if err != nil {
+		logger.Errorf("Error: %v", err)
+		return 1
+	}
+	if err := applyStatusStyles(config.Statuses); err != nil {
+		logger.Errorf("Error loading config: %v", err)
+		return 1
+	}
+	normalize, err := compileNormalizeRules(config.Normalize)
+	if err != nil {
+		logger.Errorf("Error loading config: %v", err)
+		return 1
+	}
+	classifiers, err := failureClassifiers(config.Classifiers)
+	if err != nil {
+		logger.Errorf("Error loading config: %v", err)
+		return 1
+	}
+	formats, err := resolveFormatOptions(fs, formatFlags, config.Formats)
+	if err != nil {
+		logger.Errorf("Error: %v", err)
+		return 1
+	}
+	// Markdown is also rendered for the job summary and PR comment
+	produced := map[string]bool{"markdown": true, "html": *format == "html-interactive" || *format == "html-jenkins", "json": *format == "json", "slack": *slackWebhook != ""}
+	for _, name := range unusedFormatFlags(fs, produced) {
+		logger.Warnf("%s has no effect, the run does not produce that format", name)
+	}
+	if config.FailureOutput != "" && !flagSet(fs, "failure-output") {
+		*failureOutputMode = config.FailureOutput
+	}
+	if config.MaxOutputLines > 0 && !flagSet(fs, "max-output-lines") {
+		*maxOutputLines = config.MaxOutputLines
+	}
+	if config.TopDurations > 0 && !flagSet(fs, "top-durations") && !flagSet(fs, "slow-top") {
+		*topDurations = config.TopDurations
+	}
+	if config.SlowThreshold > 0 && !flagSet(fs, "slow-threshold") {
+		*slowThreshold = config.SlowThreshold
+	}
+	if config.FlakyAlerts.Threshold > 0 && !flagSet(fs, "flaky-alert-threshold") {
+		*flakyAlertThreshold = config.FlakyAlerts.Threshold
+	}
+	if config.FlakyAlerts.Format != "" && !flagSet(fs, "flaky-alert-format") {
+		*flakyAlertFormat = config.FlakyAlerts.Format
+	}
+	if watching {
+		*githubPR, *githubReview, *giteaPR, *stepSummary = false, false, false, false
+		*slackWebhook, *teamsWebhook, *gcsBucket, *atomFeed = "", "", "", ""
+		*githubAnnotations, *annotationsFormat, *icalFile = false, "", ""
+		*flakyAlertThreshold = 0
+	}
+
+	if *githubAnnotations && *annotationsFormat == "" {
+		*annotationsFormat = "github"
+	}
+	var annotations AnnotationWriter
+	if *annotationsFormat != "" {
+		if annotations, err = annotationWriter(*annotationsFormat); err != nil {
+			logger.Errorf("Error: %v", err)
+			return 1
+		}
+		if annotations == nil {
+			logger.Warnf("-annotations auto detected no supported CI provider, no annotations are written")
+		}
+	}
+
+	switch *benchSort {
+	case "name", "ns", "bytes", "allocs":
+	default:
+		logger.Errorf("Unsupported -bench-sort value %q (supported: name, ns, bytes, allocs)", *benchSort)
+		return 1
+	}
+
+	switch *flakyAlertFormat {
+	case "slack", "teams", "json":
+	default:
+		logger.Errorf("Unsupported -flaky-alert-format value %q (supported: %s)", *flakyAlertFormat, strings.Join(flakyAlertFormats, ", "))
+		return 1
+	}
+
+	switch *stackFrames {
+	case "filtered", "full":
+	default:
+		logger.Errorf("Unsupported -stack-frames value %q (supported: filtered, full)", *stackFrames)
+		return 1
+	}
+
+	if *clusterSimilarity < 0 || *clusterSimilarity > 1 {
+		logger.Errorf("Invalid -cluster-similarity value %v (expected 0 to 1)", *clusterSimilarity)
+		return 1
+	}
+
+	switch *failureOutputMode {
+	case "full", "filtered":
+	default:
+		logger.Errorf("Unsupported -failure-output value %q (supported: full, filtered)", *failureOutputMode)
+		return 1
+	}
+	if *commentOverflow != overflowSplit && *commentOverflow != overflowGist {
+		logger.Errorf("Unsupported -comment-overflow value %q (supported: split, gist)", *commentOverflow)
+		return 1
+	}
+
+	ciPreset, err := loadCIPreset(*ciName)
+	if err != nil {
+		logger.Errorf("Error: %v", err)
+		return 1
+	}
+
+	var uploader *GCSUploader
+	if *gcsBucket != "" {
+		if uploader, err = newGCSUploader(*gcsBucket, ""); err != nil {
+			logger.Errorf("Error: %v", err)
+			return 1
+		}
+	}
+
+	switch *format {
+	case "markdown":
+	case "json", "html-interactive", "html-jenkins", "csv", "tsv", "junit":
+		if *templateFile != "" {
+			logger.Errorf("Error: -template cannot be combined with -format %s", *format)
+			return 1
+		}
+		if !flagSet(fs, "output") {
+			*outputFile = map[string]string{"json": "test-report.json", "html-interactive": "test-report.html", "html-jenkins": "test-report.html", "csv": "test-report.csv", "tsv": "test-report.tsv", "junit": "test-report.xml"}[*format]
+		}
+	default:
+		logger.Errorf("Unsupported -format value %q (supported: markdown, json, html-interactive, html-jenkins, csv, tsv, junit)", *format)
+		return 1
+	}
+
+	parseOpts := ParseOptions{MaxLineSize: *maxLineSize, SpoolOutput: *spoolOutput, VerifyStream: *verifyStream, NormalizeTime: *normalizeTime, Normalize: normalize}
+	var progress *progressPrinter
+	switch *tee {
+	case "":
+	case "dots", "pkgname", "testname":
+		progress = newProgressPrinter(logger.stderr, *tee)
+		parseOpts.OnEvent = progress.handle
+	default:
+		logger.Errorf("Unsupported -tee value %q (supported: %s)", *tee, strings.Join(teeFormats, ", "))
+		return 1
+	}
+
+	labeled, err := labeledInputs(inputFiles)
+	if err != nil {
+		logger.Errorf("Error: %v", err)
+		return 1
+	}
+	if labeled != nil {
+		// Runs of the suite on several platforms, e.g. linux=linux.json
+		if *format != "markdown" || *templateFile != "" {
+			logger.Errorf("Error: labeled inputs render a Markdown matrix report and cannot be combined with -format or -template")
+			return 1
+		}
+		return runMatrixMerge(labeled, *outputFile, parseOpts, *failOnFailure)
+	}
+
+	var reportData *ReportData
+	if *sample != "" {
+		if len(inputFiles) > 0 {
+			logger.Errorf("Error: -sample cannot be combined with -input")
+			return 1
+		}
+		reportData, err = sampleReport(*sample)
+	} else {
+		reportData, err = loadReports(inputFiles, parseOpts)
+	}
+	if progress != nil {
+		progress.finish()
+	}
+	if err != nil {
+		logger.Errorf("Error %v", err)
+		return 1
+	}
+
+	loadCrasherInputs(reportData.Fuzz, workspaceResolver())
+	classifyFailures(reportData, classifiers)
+
+	reportData.RunID = resolveRunID(*runID)
+	reportData.Git = detectGitInfo(*gitSHA, *gitBranch, ciPreset, workspaceResolver().Root)
+	reportData.Env, err = applyEnvFlags(detectEnvironment(workspaceResolver().Root), envPairs)
+	if err != nil {
+		logger.Errorf("Error: %v", err)
+		return 1
+	}
+	*outputFile = expandRunID(*outputFile, reportData.RunID)
+	*attachmentsDir = expandRunID(*attachmentsDir, reportData.RunID)
+
+	if *failOnSeverity != "" && (*severityFile == "" || severityRank(*failOnSeverity) < 0) {
+		logger.Errorf("Error: -fail-on-severity requires -severity-file and one of %s", strings.Join(severityLevels, ", "))
+		return 1
+	}
+	if *severityFile != "" {
+		severities, err := loadSeverityMap(*severityFile)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error opening input file: %v\n", err)
-			os.Exit(1)
+			logger.Errorf("Error loading severity file: %v", err)
+			return 1
 		}
-		defer file.Close()
-		reader = file
+		applySeverities(reportData, severities)
+	}
+	var sources map[string]*TestSource
+	if *srcPattern != "" {
+		sources, err = loadTestSources(*srcPattern, workspaceResolver())
+		if err != nil {
+			logger.Errorf("Error %v", err)
+			return 1
+		}
+		applyTestDocs(reportData, sources)
+	}
+	if *quarantineFile != "" {
+		list, err := loadQuarantine(*quarantineFile)
+		if err != nil {
+			logger.Errorf("Error loading quarantine file: %v", err)
+			return 1
+		}
+		applyQuarantine(reportData, list)
+	}
+
+	opts := ReportOptions{
+		BenchSort:      *benchSort,
+		FilterOutput:   *failureOutputMode == "filtered",
+		MaxOutputLines: *maxOutputLines,
+		HiddenSections: hidden,
+		GroupByPackage: *groupByPackage || config.GroupByPackage,
+		TopDurations:   *topDurations,
+		SlowThreshold:  slowThreshold.Seconds(),
+		Formats:        formats,
+
+		ClusterSimilarity: *clusterSimilarity,
+		Normalize:         normalize,
+	}
+	if *stackFrames == "filtered" {
+		opts.StackFrames = stackFilter(config.StackFrames)
 	}
+	opts.SourceLinks = &SourceLinks{RepoURL: *repoURL, Commit: "HEAD", Resolver: workspaceResolver()}
+	if reportData.Git != nil && reportData.Git.SHA != "" {
+		opts.SourceLinks.Commit = reportData.Git.SHA
+	}
+	if sources != nil {
+		opts.Hygiene = suiteHygiene(reportData, sources)
+	}
+	if *goldenEditURL != "" {
+		opts.GoldenLinks = &GoldenLinks{EditURL: *goldenEditURL, Resolver: workspaceResolver()}
+	}
+	var artifacts artifactList
 
-	reportData, err := processTestEvents(reader)
+	store, err := openHistoryStore(*historyDir, *historyURL)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error processing test events: %v\n", err)
-		os.Exit(1)
+		logger.Errorf("Error opening history: %v", err)
+		return 1
+	}
+	if store != nil {
+		defer store.Close()
+		opts.History, err = store.Query(HistoryQuery{Limit: *historyRuns, Environment: *environment})
+		if err != nil {
+			logger.Errorf("Error loading history: %v", err)
+			return 1
+		}
+		logger.Verbosef("Loaded %d previous run(s) from the history", len(opts.History))
+	}
+
+	if store != nil {
+		waivers, err := store.Waivers()
+		if err != nil {
+			logger.Errorf("Error loading waivers: %v", err)
+			return 1
+		}
+		applyWaivers(reportData, waivers)
+	}
+
+	if *coverProfile != "" {
+		reportData.Coverage, err = loadCoverProfile(*coverProfile)
+		if err != nil {
+			logger.Errorf("Error loading coverage profile: %v", err)
+			return 1
+		}
+		if *coverageFunctions > 0 {
+			resolver := workspaceResolver()
+			ref := *coverageChangedSince
+			if ref == "" && os.Getenv("GITHUB_BASE_REF") != "" {
+				ref = "origin/" + os.Getenv("GITHUB_BASE_REF")
+			}
+			report := &FunctionCoverageReport{Scope: "in all packages"}
+			var packages map[string]bool
+			if ref != "" {
+				changed, err := changedPackages(ref, resolver)
+				if err != nil {
+					// A shallow clone lacks the base, fall back to every package
+					logger.Warnf("%v, listing functions of all packages", err)
+				} else {
+					packages, report.Scope = changed, "in packages changed since "+ref
+				}
+			}
+			report.Functions = leastCovered(loadFunctionCoverage(reportData.Coverage, resolver, packages), *coverageFunctions)
+			opts.LeastCovered = report
+		}
+	}
+
+	if *maxDurationRegression != "" {
+		threshold, err := parseRegressionThreshold(*maxDurationRegression)
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return 1
+		}
+		var baseline map[string]float64
+		report := &RegressionReport{Threshold: threshold}
+		switch {
+		case *baselineFile != "":
+			baselineData, err := loadReport(*baselineFile, ParseOptions{MaxLineSize: *maxLineSize, Normalize: normalize})
+			if err != nil {
+				logger.Errorf("Error loading baseline: %v", err)
+				return 1
+			}
+			baseline, report.Baseline = reportBaseline(baselineData), "the baseline run"
+		case store != nil:
+			baseline = historyBaseline(opts.History)
+			report.Baseline = fmt.Sprintf("their median over the last %d runs", len(opts.History))
+		default:
+			logger.Errorf("Error: -max-duration-regression requires -baseline, -history-dir or -history-url")
+			return 1
+		}
+		report.Tests = durationRegressions(baseline, reportData, threshold, defaultRegressionMinDuration)
+		opts.Regressions = report
+	} else if *failOnDurationRegression {
+		logger.Errorf("Error: -fail-on-duration-regression requires -max-duration-regression")
+		return 1
+	}
+
+	if *maxSuiteSlowdown != "" {
+		threshold, err := parseRegressionThreshold(*maxSuiteSlowdown)
+		switch {
+		case err != nil:
+			logger.Errorf("Error: %v", err)
+			return 1
+		case store == nil:
+			logger.Errorf("Error: -max-suite-slowdown requires -history-dir or -history-url")
+			return 1
+		case *suiteSlowdownRuns < 1:
+			logger.Errorf("Error: -suite-slowdown-runs must be at least 1")
+			return 1
+		}
+		if opts.Slowdown = suiteSlowdown(reportData, opts.History, *suiteSlowdownRuns, threshold); opts.Slowdown != nil {
+			logger.Warnf("the %s", opts.Slowdown)
+		}
+	} else if *failOnSuiteSlowdown {
+		logger.Errorf("Error: -fail-on-suite-slowdown requires -max-suite-slowdown")
+		return 1
+	}
+
+	opts.Gates = evaluateGates(reportData, opts.History, ExitGates{
+		FailOnFailure: *failOnFailure,
+		MinPassRate:   *minPassRate,
+		MaxSkipped:    *maxSkipped,
+		MaxFlaky:      *maxFlaky,
+
+		FailOnDurationRegression: *failOnDurationRegression,
+		Regressions:              opts.Regressions,
+
+		FailOnSuiteSlowdown: *failOnSuiteSlowdown,
+		Slowdown:            opts.Slowdown,
+	})
+
+	switch *profile {
+	case "":
+	case "release":
+		threshold := *failOnSeverity
+		if threshold == "" {
+			threshold = "P1"
+		}
+		opts.Release = evaluateRelease(reportData, opts.History, ReleaseCriteria{
+			MinPassRate:       *releaseMinPassRate,
+			MinCoverage:       *releaseMinCoverage,
+			MaxFlaky:          *releaseMaxFlaky,
+			SeverityThreshold: threshold,
+		})
+	default:
+		logger.Errorf("Unsupported -profile value %q (supported: release)", *profile)
+		return 1
+	}
+	if *attachmentsDir != "" {
+		copied, err := copyAttachments(reportData, *attachmentsDir, filepath.Dir(*outputFile))
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return 1
+		}
+		for _, file := range copied {
+			artifacts.add(file, "Attachment of a failed test")
+		}
+	}
+	switch *cards {
+	case "":
+	case "svg":
+		dir := cardsDirFor(*outputFile)
+		if err := writeSVGCards(reportData, filepath.Join(filepath.Dir(*outputFile), dir)); err != nil {
+			logger.Errorf("Error writing summary cards: %v", err)
+			return 1
+		}
+		opts.CardsDir = dir
+		for _, card := range summaryCards(reportData) {
+			artifacts.add(filepath.Join(filepath.Dir(*outputFile), dir, card.File), card.Title+" summary card")
+		}
+	default:
+		logger.Errorf("Unsupported -cards value %q (supported: svg)", *cards)
+		return 1
+	}
+	if *badgeOut != "" {
+		files, err := writeBadges(reportData, opts.Gates, *badgeOut)
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return 1
+		}
+		for _, file := range files {
+			artifacts.add(file, "shields.io endpoint badge")
+		}
+	}
+
+	markdown := renderMarkdownReport(reportData, opts)
+	// PR comments over the size limit keep the summary and split the details
+	summaryOpts := opts
+	summaryOpts.SummaryOnly = true
+	commentSummary, commentDetails := renderMarkdownReport(reportData, summaryOpts), renderMarkdownDetails(reportData, opts)
+	if *maxBytes > 0 && *templateFile == "" {
+		fullFile := strings.TrimSuffix(*outputFile, filepath.Ext(*outputFile)) + ".full.md"
+		note := truncationNote(*maxBytes, "`"+filepath.Base(fullFile)+"`")
+		if *reportURL != "" {
+			note = truncationNote(*maxBytes, fmt.Sprintf("[the published report](%s)", *reportURL))
+		}
+		if fitted, shrunk := fitReport(reportData, opts, *maxBytes, note); shrunk {
+			if err := os.WriteFile(fullFile, []byte(markdown), 0o644); err != nil {
+				logger.Errorf("Error writing report: %v", err)
+				return 1
+			}
+			artifacts.add(fullFile, "Full Markdown test report")
+			logger.Printf("Report truncated to %d bytes; full report written to %s", *maxBytes, fullFile)
+			markdown = fitted
+			commentSummary, commentDetails = "", fitted
+		}
+	}
+	if *templateFile != "" {
+		markdown, err = renderTemplateReport(*templateFile, reportData)
+		if err != nil {
+			logger.Errorf("Error rendering template: %v", err)
+			return 1
+		}
+		commentSummary, commentDetails = "", markdown
+	}
+
+	report, description := markdown, "Markdown test report"
+	if *format == "json" {
+		report, err = renderJSONReport(reportData, opts, time.Now())
+		if err != nil {
+			logger.Errorf("Error rendering JSON report: %v", err)
+			return 1
+		}
+		description = "JSON test report"
+	}
+	if *format == "html-interactive" {
+		report, err = renderInteractiveHTML(reportData, opts, time.Now())
+		if err != nil {
+			logger.Errorf("Error rendering HTML report: %v", err)
+			return 1
+		}
+		description = "Interactive HTML test report"
+	}
+	if *format == "html-jenkins" {
+		report, err = renderJenkinsHTML(reportData, opts, *outputFile, time.Now())
+		if err != nil {
+			logger.Errorf("Error rendering HTML report: %v", err)
+			return 1
+		}
+		stylesheet, err := writeJenkinsAssets(*outputFile)
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			return 1
+		}
+		artifacts.add(stylesheet, "Stylesheet of the HTML report")
+		description = "HTML test report for the Jenkins HTML Publisher"
+	}
+	if *format == "csv" || *format == "tsv" {
+		comma := map[string]rune{"csv": ',', "tsv": '\t'}[*format]
+		report, err = renderCSVReport(reportData, comma)
+		if err != nil {
+			logger.Errorf("Error rendering %s export: %v", strings.ToUpper(*format), err)
+			return 1
+		}
+		description = strings.ToUpper(*format) + " export of the test results"
+	}
+	if *format == "junit" {
+		report, err = renderJUnitReport(reportData)
+		if err != nil {
+			logger.Errorf("Error rendering JUnit XML report: %v", err)
+			return 1
+		}
+		description = "JUnit XML test report"
+	}
+	if err := os.WriteFile(*outputFile, []byte(report), 0o644); err != nil {
+		logger.Errorf("Error writing report: %v", err)
+		return 1
+	}
+
+	logger.Printf("Report generated successfully: %s", *outputFile)
+	artifacts.add(*outputFile, description)
+	if *exitSummary {
+		// Deferred so it stays the last line whichever gate ends the run
+		defer func() { logger.Infof("%s", exitSummaryLine(reportData, opts.Gates, opts.History, *outputFile)) }()
+	}
+
+	if store != nil && *sample == "" && !watching {
+		record := newRunRecord(reportData, time.Now())
+		record.Environment = *environment
+		if err := store.Put(record); err != nil {
+			logger.Errorf("Error saving history: %v", err)
+			return 1
+		}
+	}
+
+	if *checkstyleFile != "" {
+		content, err := renderCheckstyle(reportData, failureAnnotations(reportData, workspaceResolver()), workspaceResolver())
+		if err == nil {
+			err = os.WriteFile(*checkstyleFile, []byte(content), 0o644)
+		}
+		if err != nil {
+			logger.Errorf("Error writing checkstyle report: %v", err)
+			return 1
+		}
+		artifacts.add(*checkstyleFile, "Checkstyle XML of the test failures")
+	}
+
+	if annotations != nil {
+		resolver := workspaceResolver()
+		file, err := writeAnnotations(annotations, *annotationsOutput, runAnnotations(reportData, opts.Slowdown, resolver), resolver)
+		if err != nil {
+			logger.Errorf("Error writing annotations: %v", err)
+			return 1
+		}
+		if file != "" {
+			artifacts.add(file, "Failure annotations for "+annotations.Name())
+		}
+	}
+
+	if *icalFile != "" {
+		if store == nil {
+			logger.Errorf("Error: -ical requires -history-dir or -history-url")
+			return 1
+		}
+		records, err := store.Query(HistoryQuery{})
+		if err != nil {
+			logger.Errorf("Error loading history: %v", err)
+			return 1
+		}
+		if err := os.WriteFile(*icalFile, []byte(renderICalendar(records, *reportURL, time.Now())), 0o644); err != nil {
+			logger.Errorf("Error writing iCalendar file: %v", err)
+			return 1
+		}
+		artifacts.add(*icalFile, "iCalendar export of test runs")
+	}
+
+	if *atomFeed != "" {
+		if err := updateAtomFeed(*atomFeed, reportData, opts.Gates, *reportURL, time.Now()); err != nil {
+			logger.Errorf("Error updating Atom feed: %v", err)
+			return 1
+		}
+		artifacts.add(*atomFeed, "Atom feed of test runs")
+	}
+
+	if *writeIndex {
+		if err := writeArtifactIndex(filepath.Dir(*outputFile), artifacts); err != nil {
+			logger.Errorf("Error writing artifact index: %v", err)
+			return 1
+		}
+		artifacts.add(filepath.Join(filepath.Dir(*outputFile), "index.md"), "Artifact index")
+		artifacts.add(filepath.Join(filepath.Dir(*outputFile), "index.html"), "Artifact index")
+	}
+
+	if uploader != nil {
+		if uploader.Token, err = gcsAccessToken(gcsMetadataTokenURL); err != nil {
+			logger.Errorf("Error uploading artifacts: %v", err)
+			return 1
+		}
+		build := detectCloudBuild()
+		folder := reportData.RunID
+		if build != nil {
+			folder = build.BuildID
+		}
+		names, err := uploadArtifacts(uploader, filepath.Dir(*outputFile), folder, artifacts)
+		if err != nil {
+			logger.Errorf("Error uploading artifacts: %v", err)
+			return 1
+		}
+		writeCloudSummary(os.Stdout, reportData, opts.Gates, build, uploader, folder, names)
+	}
+
+	if *stepSummary {
+		// Relative card images cannot be resolved from the job summary page
+		summaryOpts := opts
+		summaryOpts.CardsDir = ""
+		summary := renderMarkdownReport(reportData, summaryOpts)
+		if *templateFile != "" {
+			summary = markdown
+		}
+		if err := appendStepSummary(os.Getenv("GITHUB_STEP_SUMMARY"), summary); err != nil {
+			logger.Errorf("Error writing job summary: %v", err)
+			return 1
+		}
+	}
+
+	if *githubPR || *githubReview {
+		ghCtx, err := detectGitHubContext(*githubRepo, *githubPRNumber)
+		if err != nil {
+			logger.Errorf("Error detecting GitHub context: %v", err)
+			return 1
+		}
+		client := newGitHubClient(ghCtx.APIURL, ghCtx.Token)
+		if *githubPR {
+			if err := client.postPRReport(ghCtx.Repo, ghCtx.PR, reportData, commentSummary, commentDetails, *commentOverflow); err != nil {
+				logger.Errorf("Error posting PR comment: %v", err)
+				return 1
+			}
+			logger.Printf("Report posted to %s#%d", ghCtx.Repo, ghCtx.PR)
+		}
+		if *githubReview {
+			posted, err := client.postReview(ghCtx.Repo, ghCtx.PR, failureAnnotations(reportData, workspaceResolver()))
+			if err != nil {
+				logger.Errorf("Error posting review comments: %v", err)
+				return 1
+			}
+			logger.Printf("%d review comment(s) posted to %s#%d", posted, ghCtx.Repo, ghCtx.PR)
+		}
 	}
 
-	markdown := generateMarkdownReport(reportData)
+	if *giteaPR {
+		giteaCtx, err := detectGiteaContext(ciPreset.forgeDefaults(*giteaURL, *githubRepo, *githubPRNumber))
+		if err == nil && giteaCtx.PR == 0 {
+			err = fmt.Errorf("pull request number not found: use -github-pr-number or run on a pull_request event")
+		}
+		if err != nil {
+			logger.Errorf("Error detecting Gitea context: %v", err)
+			return 1
+		}
+		client := newGiteaClient(giteaCtx.APIURL, giteaCtx.Token)
+		if err := client.postPRReport(giteaCtx.Repo, giteaCtx.PR, reportData, commentSummary, commentDetails, *commentOverflow); err != nil {
+			logger.Errorf("Error posting PR comment: %v", err)
+			return 1
+		}
+		logger.Printf("Report posted to %s#%d", giteaCtx.Repo, giteaCtx.PR)
+	}
 
-	if err := os.WriteFile(*outputFile, []byte(markdown), 0o644); err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
-		os.Exit(1)
+	if *slackWebhook != "" {
+		msg := buildSlackMessage(reportData, opts.Gates, *reportURL)
+		msg.Channel, msg.Username = formats.Get("slack.channel"), formats.Get("slack.username")
+		if err := postWebhook(*slackWebhook, msg); err != nil {
+			logger.Errorf("Error sending Slack notification: %v", err)
+			return 1
+		}
+		logger.Printf("Slack notification sent")
 	}
 
-	fmt.Printf("Report generated successfully: %s\n", *outputFile)
+	if *teamsWebhook != "" {
+		if err := postWebhook(*teamsWebhook, buildTeamsMessage(reportData, opts.Gates, *reportURL)); err != nil {
+			logger.Errorf("Error sending Teams notification: %v", err)
+			return 1
+		}
+		logger.Printf("Teams notification sent")
+	}
+
+	if *flakyAlertThreshold > 0 {
+		webhooks := make(map[string]string)
+		for owner, url := range config.FlakyAlerts.Webhooks {
+			webhooks[owner] = url
+		}
+		if *flakyAlertWebhook != "" {
+			webhooks["*"] = *flakyAlertWebhook
+		}
+		switch {
+		case store == nil:
+			logger.Errorf("Error: -flaky-alert-threshold requires -history-dir or -history-url")
+			return 1
+		case len(webhooks) == 0:
+			logger.Errorf("Error: -flaky-alert-threshold requires -flaky-alert-webhook or flaky_alerts.webhooks in the config file")
+			return 1
+		}
+		if breaches := flakyThresholdBreaches(reportData, opts.History, *flakyAlertThreshold); len(breaches) > 0 {
+			resolver := workspaceResolver()
+			owners, err := loadCodeowners(resolver.Root)
+			if err != nil {
+				logger.Errorf("Error reading CODEOWNERS: %v", err)
+				return 1
+			}
+			alerts := groupFlakyAlerts(breaches, *flakyAlertThreshold, owners, resolver)
+			for i := range alerts {
+				alerts[i].RunID = reportData.RunID
+			}
+			sent, err := sendFlakyAlerts(alerts, webhooks, *flakyAlertFormat)
+			if err != nil {
+				logger.Errorf("Error sending flaky test alert: %v", err)
+				return 1
+			}
+			logger.Printf("%d flaky test alert(s) sent for %d test(s)", sent, len(breaches))
+		}
+	}
+
+	if *failOnSeverity != "" {
+		if blocking := blockingFailures(reportData, *failOnSeverity); len(blocking) > 0 {
+			logger.Errorf("%d failure(s) at severity %s or higher: %s", len(blocking), *failOnSeverity, strings.Join(blocking, ", "))
+			return 1
+		}
+	}
+
+	if v := reportData.Verification; v != nil && len(v.Violations) > 0 {
+		logger.Errorf("Event stream verification found %d violation(s), see the Stream Verification section", len(v.Violations))
+		return 1
+	}
+
+	if failed := gateFailures(opts.Gates); len(failed) > 0 {
+		logger.Errorf("Failing: %s", strings.Join(failed, "; "))
+		return 1
+	}
+	return 0
+}
+
+// flagSet reports whether the named flag was given on the command line
+func flagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
 }
 
+// loadReport reads go test -json events from inputFile, or stdin when empty
+func loadReport(inputFile string, opts ParseOptions) (*ReportData, error) {
+	var reader io.Reader = os.Stdin
+	name := "stdin"
+	if inputFile != "" {
+		name = inputFile
+		file, err := os.Open(inputFile)
+		if err != nil {
+			return nil, fmt.Errorf("opening input file: %v", err)
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	// Results of other test runners are converted to go test -json events
+	buffered := bufio.NewReader(reader)
+	reader = buffered
+	if adapter := detectInputAdapter(buffered); adapter != nil {
+		logger.Verbosef("Converting %s results of %s to go test -json events", adapter.Format(), name)
+		converted, err := adaptInput(buffered, adapter, workspaceResolver().Root)
+		if err != nil {
+			return nil, err
+		}
+		reader = converted
+	}
+
+	logger.Verbosef("Reading %s", name)
+	reportData, err := parseTestEvents(reader, opts)
+	if err != nil {
+		return nil, fmt.Errorf("processing test events: %v", err)
+	}
+	logger.Verbosef("Read %d test(s) in %d package(s) from %s", reportData.TotalTests, len(reportData.Packages), name)
+	return reportData, nil
+}
+
+// processTestEvents parses go test -json events with the default options
 func processTestEvents(reader io.Reader) (*ReportData, error) {
-	// Use a Scanner with an increased buffer to safely handle long JSON lines from `go test -json`.
-	scanner := bufio.NewScanner(reader)
-	// Set the initial and maximum token size to allow large outputs (up to ~10MB per line).
-	buf := make([]byte, 0, 1024*1024)
-	scanner.Buffer(buf, 10*1024*1024)
+	return parseTestEvents(reader, ParseOptions{})
+}
+
+// parseTestEvents parses go test -json events. Lines are read without a
+// fixed token size, up to opts.MaxLineSize, so very long output lines work.
+func parseTestEvents(reader io.Reader, opts ParseOptions) (*ReportData, error) {
+	lines := newLineReader(reader, opts.MaxLineSize)
 	results := make(map[string]*TestResult)
 	testOutputMap := make(map[string][]string)
 
-	testStartTime := make(map[string]time.Time)
+	var spool *outputSpool
+	if opts.SpoolOutput {
+		var err error
+		if spool, err = newOutputSpool(); err != nil {
+			return nil, err
+		}
+		defer spool.close()
+	}
+
+	var verifier *streamVerifier
+	if opts.VerifyStream {
+		verifier = newStreamVerifier()
+	}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.TrimSpace(line) == "" {
+	testStartTime := make(map[string]time.Time)
+	testPackages := make(map[string]string) // Package of each test name, for events without one
+	var benchmarks []*BenchmarkResult
+	packages := newPackageTracker()
+	stats := newParseStats()
+	defer stats.log(logger)
+	raw := &rawLineTracker{}
+	var rawErr error
+
+	for {
+		line, err := lines.next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("error reading input: %v", err)
+		}
+		stats.lines++
+		if len(bytes.TrimSpace(line)) == 0 {
 			// Skip blank lines that can occur in piped or concatenated outputs
+			stats.blank++
 			continue
 		}
 		var event TestEvent
-		if err := json.Unmarshal([]byte(line), &event); err != nil {
-			return nil, fmt.Errorf("error unmarshalling JSON: %v", err)
+		err = json.Unmarshal(line, &event)
+		if err == nil && event.Action == "" {
+			err = fmt.Errorf("no Action")
+		}
+		if err != nil {
+			// Output written straight to stdout, e.g. by TestMain, is
+			// interleaved with the events rather than failing the parse
+			stats.malformed = append(stats.malformed, lines.lineNo)
+			if rawErr == nil {
+				rawErr = fmt.Errorf("error unmarshalling JSON on line %d: %v", lines.lineNo, err)
+			}
+			synthetic, ok := raw.attach(lines.lineNo, trimLineEnding(string(line)), func(key string) bool {
+				result, exists := results[key]
+				return exists && result.Status == "UNKNOWN"
+			})
+			if !ok {
+				continue
+			}
+			event = synthetic
+		} else {
+			stats.record(lines.lineNo, event)
+			if verifier != nil {
+				verifier.check(lines.lineNo, line, event)
+			}
+			raw.observe(event)
+		}
+		if opts.OnEvent != nil {
+			opts.OnEvent(event)
 		}
 
-		testFullName := event.Test
-		if testFullName == "" {
-			// Skip package-level events
+		if event.Action == "output" || event.Action == "bench" {
+			// Benchmark results may be attributed to the benchmark or, on
+			// older Go versions, to the package
+			if bench := parseBenchmarkLine(event.Package, event.Output); bench != nil {
+				benchmarks = append(benchmarks, bench)
+			}
+		}
+
+		if event.Test == "" {
+			// Package-level and build events are tracked per package
+			packages.handleEvent(event)
 			continue
 		}
+		if event.Package == "" {
+			// test2json run on a test binary leaves out the package
+			event.Package = testPackages[event.Test]
+		} else {
+			testPackages[event.Test] = event.Package
+		}
+		testFullName := testKey(event.Package, event.Test)
 
 		if _, exists := results[testFullName]; !exists && (event.Action == "run" || event.Action == "pass" || event.Action == "fail" || event.Action == "skip") {
 			results[testFullName] = &TestResult{
-				Name:      testFullName,
+				Name:      event.Test,
 				Package:   event.Package,
 				Status:    "UNKNOWN",
 				Duration:  0,
 				Output:    []string{},
-				IsSubTest: strings.Contains(testFullName, "/"),
+				IsSubTest: strings.Contains(event.Test, "/"),
 			}
 
-			if results[testFullName].IsSubTest {
-				parentName := testFullName[:strings.LastIndex(testFullName, "/")]
-				results[testFullName].ParentTest = parentName
+			// Link the test to its parent, creating ancestors without events
+			// of their own, so subtests of subtests form a tree of any depth
+			for name := event.Test; strings.Contains(name, "/"); {
+				parentName := name[:strings.LastIndex(name, "/")]
+				results[testKey(event.Package, name)].ParentTest = parentName
 
-				if _, exists := results[parentName]; !exists {
-					results[parentName] = &TestResult{
+				parentKey := testKey(event.Package, parentName)
+				_, exists := results[parentKey]
+				if !exists {
+					results[parentKey] = &TestResult{
 						Name:      parentName,
 						Package:   event.Package,
 						Status:    "UNKNOWN",
@@ -140,16 +1153,40 @@ func processTestEvents(reader io.Reader) (*ReportData, error) {
 					}
 				}
 
-				results[parentName].SubTests = append(results[parentName].SubTests, testFullName)
+				results[parentKey].SubTests = append(results[parentKey].SubTests, name)
+				if exists {
+					break
+				}
+				name = parentName
 			}
 		}
 
 		switch event.Action {
 		case "run":
+			// A test of the same package run again, e.g. by gotestsum
+			// --rerun-fails, keeps its earlier attempt instead of mixing the
+			// outputs
+			if result := results[testFullName]; result.Status != "UNKNOWN" {
+				result.Attempts = append(result.Attempts, Attempt{Status: result.Status, Duration: result.Duration, Output: testOutputMap[testFullName]})
+				delete(testOutputMap, testFullName)
+				result.Status, result.Duration = "UNKNOWN", 0
+			}
 			testStartTime[testFullName] = event.Time
+			results[testFullName].Start = event.Time
+
+		case "pause":
+			if !event.Time.IsZero() {
+				results[testFullName].Pauses = append(results[testFullName].Pauses, TimeSpan{Start: event.Time})
+			}
+
+		case "cont":
+			if pauses := results[testFullName].Pauses; len(pauses) > 0 && pauses[len(pauses)-1].End.IsZero() {
+				pauses[len(pauses)-1].End = event.Time
+			}
 
 		case "pass":
 			results[testFullName].Status = "PASS"
+			results[testFullName].End = event.Time
 			if event.Elapsed > 0 {
 				results[testFullName].Duration = event.Elapsed
 			} else if !testStartTime[testFullName].IsZero() {
@@ -158,6 +1195,7 @@ func processTestEvents(reader io.Reader) (*ReportData, error) {
 
 		case "fail":
 			results[testFullName].Status = "FAIL"
+			results[testFullName].End = event.Time
 			if event.Elapsed > 0 {
 				results[testFullName].Duration = event.Elapsed
 			} else if !testStartTime[testFullName].IsZero() {
@@ -166,65 +1204,105 @@ func processTestEvents(reader io.Reader) (*ReportData, error) {
 
 		case "skip":
 			results[testFullName].Status = "SKIP"
+			results[testFullName].End = event.Time
 
 		case "output":
-			// Collect test output lines
-			if _, exists := testOutputMap[testFullName]; !exists {
-				testOutputMap[testFullName] = []string{}
-			}
 			// Clean output (remove trailing newlines)
-			output := strings.TrimSuffix(event.Output, "\n")
-			if output != "" {
-				testOutputMap[testFullName] = append(testOutputMap[testFullName], output)
+			output := trimLineEnding(event.Output)
+			if output == "" {
+				continue
+			}
+			owner := outputOwner(results, event.Package, event.Test, output)
+			if spool != nil {
+				if err := spool.add(owner, output); err != nil {
+					return nil, err
+				}
+				continue
 			}
+			testOutputMap[owner] = append(testOutputMap[owner], output)
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading input: %v", err)
-	}
-
 	// Add collected output to each test
 	for testName, output := range testOutputMap {
 		if result, exists := results[testName]; exists {
 			result.Output = output
 		}
 	}
-
-	reportData := &ReportData{
-		Results: results,
-	}
-
-	var sortedNames []string
-	for name, result := range results {
-		// Only count root tests in summary (not subtests)
-		if !result.IsSubTest {
-			sortedNames = append(sortedNames, name)
-			reportData.TotalTests++
-			reportData.TotalDuration += result.Duration
-
-			switch result.Status {
-			case "PASS":
-				reportData.PassedTests++
-			case "FAIL":
-				reportData.FailedTests++
-			case "SKIP":
-				reportData.SkippedTests++
+	if spool != nil {
+		// Only the output of tests that did not pass or skip is reported
+		for testName, result := range results {
+			if result.Status == "PASS" || result.Status == "SKIP" {
+				continue
 			}
+			output, err := spool.lines(testName)
+			if err != nil {
+				return nil, err
+			}
+			result.Output = output
 		}
 	}
 
-	sort.Strings(sortedNames)
-	reportData.SortedTestNames = sortedNames
+	if stats.events() == 0 && rawErr != nil {
+		// Nothing was an event, so this is not go test -json output at all
+		return nil, rawErr
+	}
+
+	reportData := &ReportData{
+		Results:     results,
+		Packages:    packages.results,
+		Benchmarks:  benchmarks,
+		Diagnostics: raw.finish(),
+	}
+	if verifier != nil {
+		reportData.Verification = verifier.finish()
+	}
+
+	summarizeReport(reportData, opts.Normalize)
 
 	return reportData, nil
 }
 
+// reportStatus returns the overall status of a run: "FAILED", "SKIPPED" or "PASSED"
+func reportStatus(data *ReportData) string {
+	if data.FailedPackages > 0 {
+		return "FAILED"
+	}
+	// Quarantined failures are expected and do not fail the run
+	return runStatus(data.TotalTests, data.FailedTests-quarantinedFailures(data), data.SkippedTests)
+}
+
+func runStatus(total, failed, skipped int) string {
+	if failed > 0 {
+		return "FAILED"
+	} else if skipped == total {
+		return "SKIPPED"
+	}
+	return "PASSED"
+}
+
 func generateMarkdownReport(data *ReportData) string {
+	return renderMarkdownReport(data, ReportOptions{})
+}
+
+func renderMarkdownReport(data *ReportData, opts ReportOptions) string {
+	summary := renderMarkdownSummary(data, opts)
+	if opts.SummaryOnly {
+		return summary
+	}
+	return summary + renderMarkdownDetails(data, opts)
+}
+
+// renderMarkdownSummary renders the head of the report, the summary and
+// status sections
+func renderMarkdownSummary(data *ReportData, opts ReportOptions) string {
 	var sb strings.Builder
 
 	// Generate header
-	sb.WriteString("# Test Summary Report\n\n")
+	sb.WriteString("# " + escapeMarkdown(opts.Formats.Get("markdown.title")) + "\n\n")
+	writeGitInfo(&sb, data.Git, opts.SourceLinks)
+
+	writeReleaseSection(&sb, opts.Release)
 
 	// Generate summary
 	passPercentage := 0.0
@@ -235,93 +1313,90 @@ func generateMarkdownReport(data *ReportData) string {
 	}
 
 	sb.WriteString("## Summary\n\n")
+	if opts.CardsDir != "" && opts.showSection("cards") {
+		sb.WriteString(summaryCardImages(opts.CardsDir))
+	}
 	sb.WriteString(fmt.Sprintf("- **Total Tests:** %d\n", data.TotalTests))
 	sb.WriteString(fmt.Sprintf("- **Passed:** %d (%s)\n", data.PassedTests, passPercentageDisplay))
 	sb.WriteString(fmt.Sprintf("- **Failed:** %d\n", data.FailedTests))
 	sb.WriteString(fmt.Sprintf("- **Skipped:** %d\n", data.SkippedTests))
-	sb.WriteString(fmt.Sprintf("- **Total Duration:** %.2fs\n\n", data.TotalDuration))
+	if data.FailedPackages > 0 {
+		sb.WriteString(fmt.Sprintf("- **Package Failures:** %d\n", data.FailedPackages))
+	}
+	if len(data.DataRaces) > 0 {
+		sb.WriteString(fmt.Sprintf("- **Data Races:** %d\n", len(data.DataRaces)))
+	}
+	if crashers := fuzzCrashers(data.Fuzz); crashers > 0 {
+		sb.WriteString(fmt.Sprintf("- **Fuzz Crashers:** %d\n", crashers))
+	}
+	if waived := waivedFailures(data); waived > 0 {
+		sb.WriteString(fmt.Sprintf("- **Waived Failures:** %d\n", waived))
+	}
+	if quarantined := quarantinedFailures(data); quarantined > 0 {
+		sb.WriteString(fmt.Sprintf("- **Quarantined Failures:** %d\n", quarantined))
+	}
+	if retried := len(retriedTests(data)); retried > 0 {
+		sb.WriteString(fmt.Sprintf("- **Retried:** %d\n", retried))
+	}
+	sb.WriteString(fmt.Sprintf("- **Total Duration:** %.2fs\n", data.TotalDuration))
+	if wallClock := data.WallClock(); wallClock > 0 {
+		sb.WriteString(fmt.Sprintf("- **Wall Clock:** %.2fs\n", wallClock))
+	}
+	if data.Coverage != nil {
+		sb.WriteString(fmt.Sprintf("- **Coverage:** %.1f%%\n", data.Coverage.Percent()))
+	}
+	sb.WriteString("\n")
+	writeSeveritySection(&sb, data)
+	if opts.showSection("suites") {
+		writeSuitesSection(&sb, data)
+	}
 
 	// Visual pass/fail indicator
 	sb.WriteString("## Test Status\n\n")
 
-	// Create status badges
-	if data.FailedTests > 0 {
-		sb.WriteString("![Status](https://img.shields.io/badge/Status-FAILED-red)\n\n")
-	} else if data.SkippedTests == data.TotalTests {
-		sb.WriteString("![Status](https://img.shields.io/badge/Status-SKIPPED-yellow)\n\n")
-	} else {
-		sb.WriteString("![Status](https://img.shields.io/badge/Status-PASSED-brightgreen)\n\n")
-	}
-
-	// Create a table of test results
-	sb.WriteString("## Test Results\n\n")
-	sb.WriteString("| Test | Status | Duration | Details |\n")
-	sb.WriteString("| ---- | ------ | -------- | ------- |\n")
+	// Create status badges, failed gates fail the run even when all tests passed
+	status := gatedStatus(data, opts.Gates)
+	sb.WriteString(fmt.Sprintf("![Status](https://img.shields.io/badge/Status-%s-%s)\n\n", status, badgeColor(status)))
+	writeQualityGatesSection(&sb, opts.Gates)
 
-	// Sort tests by package and name for a more organized report
-	for _, testName := range data.SortedTestNames {
-		result := data.Results[testName]
+	// Build failures are shown even in summary-only reports since they
+	// explain why tests are missing
+	writePackageFailuresSection(&sb, data)
 
-		// Skip subtests here - we'll show them nested
-		if result.IsSubTest {
-			continue
-		}
-
-		// Determine status emoji
-		statusEmoji := "⏺️"
-		switch result.Status {
-		case "PASS":
-			statusEmoji = "✅"
-		case "FAIL":
-			statusEmoji = "❌"
-		case "SKIP":
-			statusEmoji = "⏭️"
-		}
-
-		// Format test name to be more readable (remove package prefix if present)
-		displayName := result.Name
-		if strings.Contains(displayName, "/") && !result.IsSubTest {
-			displayName = filepath.Base(displayName)
-		}
+	return sb.String()
+}
 
-		// Prepare details column content
-		detailsColumn := ""
-		if len(result.SubTests) > 0 {
-			detailsColumn = fmt.Sprintf("<details><summary>%d subtests</summary>", len(result.SubTests))
-
-			// Add a nested table for subtests
-			detailsColumn += "<table><tr><th>Subtest</th><th>Status</th><th>Duration</th></tr>"
-
-			sort.Strings(result.SubTests)
-			for _, subTestName := range result.SubTests {
-				subTest := data.Results[subTestName]
-				subTestDisplayName := subTestName[strings.LastIndex(subTestName, "/")+1:]
-
-				statusEmoji := "⏺️"
-				switch subTest.Status {
-				case "PASS":
-					statusEmoji = "✅"
-				case "FAIL":
-					statusEmoji = "❌"
-				case "SKIP":
-					statusEmoji = "⏭️"
-				}
+// renderMarkdownDetails renders the sections of the report following the
+// summary
+func renderMarkdownDetails(data *ReportData, opts ReportOptions) string {
+	var sb strings.Builder
 
-				detailsColumn += fmt.Sprintf("<tr><td>%s</td><td>%s %s</td><td>%.3fs</td></tr>",
-					subTestDisplayName, statusEmoji, subTest.Status, subTest.Duration)
-			}
+	if opts.showSection("trends") {
+		writeTrendsSection(&sb, data, opts.History)
+	}
 
-			detailsColumn += "</table></details>"
-		} else {
-			detailsColumn = "-"
-		}
+	if opts.showSection("regressions") {
+		writeSuiteSlowdownNote(&sb, opts.Slowdown)
+		writeRegressionsSection(&sb, opts.Regressions)
+	}
 
-		sb.WriteString(fmt.Sprintf("| **%s** | %s %s | %.3fs | %s |\n",
-			displayName, statusEmoji, result.Status, result.Duration, detailsColumn))
+	if opts.showSection("results") {
+		writeTestResultsSection(&sb, data, opts)
+	}
+	if opts.showSection("quarantine") {
+		writeQuarantineSection(&sb, data)
+	}
+	if opts.showSection("retries") {
+		writeRetriedTestsSection(&sb, data)
+	}
+	if opts.showSection("skipped") {
+		writeSkippedTestsSection(&sb, data)
+	}
+	if opts.showSection("failure-categories") {
+		writeFailureCategoriesSection(&sb, data)
 	}
-	sb.WriteString("\n")
 
-	if data.FailedTests > 0 {
+	if data.FailedTests > 0 && opts.showSection("failed-details") {
 		sb.WriteString("## Failed Tests Details\n\n")
 		sb.WriteString("<details>\n")
 		sb.WriteString("<summary>Click to expand failed test details</summary>\n\n")
@@ -330,53 +1405,60 @@ func generateMarkdownReport(data *ReportData) string {
 			result := data.Results[testName]
 
 			// Check if this test or any of its subtests failed
-			testFailed := result.Status == "FAIL"
-
-			// Check subtests for failures
-			for _, subTestName := range result.SubTests {
-				if data.Results[subTestName].Status == "FAIL" {
-					testFailed = true
-					break
+			var failedSubtests []*TestResult
+			walkSubtests(data, result, func(subTest *TestResult) {
+				if subTest.Status == "FAIL" {
+					failedSubtests = append(failedSubtests, subTest)
 				}
-			}
+			})
 
-			if testFailed {
-				displayName := testName
+			if result.Status == "FAIL" || len(failedSubtests) > 0 {
+				displayName := result.Name
 				if strings.Contains(displayName, "/") && !result.IsSubTest {
 					displayName = filepath.Base(displayName)
 				}
 
-				sb.WriteString(fmt.Sprintf("### %s\n\n", displayName))
+				sb.WriteString(fmt.Sprintf("### %s\n\n", escapeMarkdown(displayName)))
+				writeShuffleNote(&sb, data.Packages[result.Package])
+				if result.Status == "FAIL" {
+					writeFailureFingerprint(&sb, result)
+				}
 
 				// Output for the main test
 				if result.Status == "FAIL" && len(result.Output) > 0 {
-					sb.WriteString("```go\n")
-					for _, line := range result.Output {
-						if strings.Contains(line, "FAIL") || strings.Contains(line, "Error") ||
-							strings.Contains(line, "panic:") || strings.Contains(line, "--- FAIL") {
-							sb.WriteString(fmt.Sprintf("%s\n", line))
-						}
-					}
-					sb.WriteString("```\n\n")
+					writeFailureOutput(&sb, result.Output, opts)
+					writeGoldenDiff(&sb, result, opts)
+				}
+				if result.Status == "FAIL" {
+					writeAttachments(&sb, result)
 				}
 
-				// Output for failed subtests
-				for _, subTestName := range result.SubTests {
-					subTest := data.Results[subTestName]
-					if subTest.Status == "FAIL" {
-						subTestDisplayName := subTestName[strings.LastIndex(subTestName, "/")+1:]
-						sb.WriteString(fmt.Sprintf("#### %s\n\n", subTestDisplayName))
-
-						if len(subTest.Output) > 0 {
-							sb.WriteString("```go\n")
-							for _, line := range subTest.Output {
-								if strings.Contains(line, "FAIL") || strings.Contains(line, "Error") ||
-									strings.Contains(line, "panic:") || strings.Contains(line, "--- FAIL") {
-									sb.WriteString(fmt.Sprintf("%s\n", line))
-								}
-							}
-							sb.WriteString("```\n\n")
-						}
+				// Output for failed subtests at any depth, named by their path
+				// below the test
+				writeSubtest := func(subTest *TestResult) {
+					sb.WriteString(fmt.Sprintf("#### %s\n\n", escapeMarkdown(strings.TrimPrefix(subTest.Name, result.Name+"/"))))
+					writeFailureFingerprint(&sb, subTest)
+
+					if len(subTest.Output) > 0 {
+						writeFailureOutput(&sb, subTest.Output, opts)
+						writeGoldenDiff(&sb, subTest, opts)
+					}
+					writeAttachments(&sb, subTest)
+				}
+				if opts.ClusterSimilarity <= 0 {
+					for _, subTest := range failedSubtests {
+						writeSubtest(subTest)
+					}
+					continue
+				}
+				// Table-driven cases failing the same way are shown once
+				for _, cluster := range clusterFailures(failedSubtests, opts.ClusterSimilarity, opts.Normalize) {
+					if len(cluster.Tests) >= clusterMinSize {
+						writeFailureCluster(&sb, result.Name, cluster, opts)
+						continue
+					}
+					for _, subTest := range cluster.Tests {
+						writeSubtest(subTest)
 					}
 				}
 			}
@@ -386,24 +1468,198 @@ func generateMarkdownReport(data *ReportData) string {
 		sb.WriteString("</details>\n\n")
 	}
 
-	// Add duration metrics
+	if opts.showSection("data-races") {
+		writeDataRacesSection(&sb, data.DataRaces)
+	}
+	if opts.showSection("fuzzing") {
+		writeFuzzingSection(&sb, data.Fuzz)
+	}
+	writeStreamVerificationSection(&sb, data.Verification)
+	if opts.showSection("diagnostics") {
+		writeDiagnosticsSection(&sb, data.Diagnostics)
+	}
+
+	if opts.showSection("benchmarks") {
+		writeBenchmarkSection(&sb, data.Benchmarks, opts.BenchSort)
+	}
+
+	if opts.showSection("function-coverage") {
+		writeFunctionCoverageSection(&sb, opts.LeastCovered)
+	}
+
+	if opts.showSection("throughput") {
+		writeThroughputSection(&sb, data)
+	}
+	if opts.showSection("timeline") {
+		writeTimelineSection(&sb, data)
+	}
+	if opts.showSection("parallelism") {
+		writeParallelismSection(&sb, data)
+	}
+	if opts.showSection("duration-stats") {
+		writeDurationStatsSection(&sb, data)
+	}
+	if opts.showSection("durations") {
+		writeDurationsSection(&sb, data, opts.TopDurations, opts.SlowThreshold)
+	}
+	if opts.showSection("environment") {
+		writeEnvironmentSection(&sb, data.Env)
+	}
+	if opts.showSection("hygiene") {
+		writeSuiteHygieneSection(&sb, opts.Hygiene)
+	}
+	sb.WriteString(fmt.Sprintf("Report generated at: %s\n", time.Now().Format("02/01/06-15:04:05")))
+	if data.RunID != "" {
+		sb.WriteString(fmt.Sprintf("\nRun ID: `%s`\n", data.RunID))
+	}
+
+	return sb.String()
+}
+
+// writeTestResultsSection renders the table of root tests with their
+// subtests nested, with a table per suite when several inputs were merged
+func writeTestResultsSection(sb *strings.Builder, data *ReportData, opts ReportOptions) {
+	sb.WriteString("## Test Results\n\n")
+	if len(data.Suites) < 2 {
+		writeTestResultsTable(sb, data, data.SortedTestNames, opts, "###")
+		return
+	}
+	for _, suite := range data.Suites {
+		if len(suite.Tests) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("### %s\n\n", escapeMarkdown(suite.Name)))
+		writeTestResultsTable(sb, data, suite.Tests, opts, "####")
+	}
+}
+
+// writeTestResultsTable renders the named root tests, optionally split by
+// package under headings of the given level
+func writeTestResultsTable(sb *strings.Builder, data *ReportData, testNames []string, opts ReportOptions, heading string) {
+	header := "| Test | Status | Duration | Details |\n| ---- | ------ | -------- | ------- |\n"
+	docs := hasTestDocs(data)
+	if docs {
+		header = "| Test | Description | Status | Duration | Details |\n| ---- | ----------- | ------ | -------- | ------- |\n"
+	}
+	if !opts.GroupByPackage {
+		sb.WriteString(header)
+	}
+
+	// Sort tests by package and name for a more organized report
+	names := append([]string(nil), testNames...)
+	if opts.GroupByPackage {
+		sort.SliceStable(names, func(i, j int) bool {
+			return data.Results[names[i]].Package < data.Results[names[j]].Package
+		})
+	}
+	pkg := ""
+	leftOut := 0
+	for i, testName := range names {
+		result := data.Results[testName]
+
+		// Skip subtests here - we'll show them nested
+		if result.IsSubTest {
+			continue
+		}
+		if opts.FailedResultsOnly && result.Status != "FAIL" {
+			leftOut++
+			continue
+		}
+
+		if opts.GroupByPackage && (i == 0 || result.Package != pkg) {
+			if i > 0 {
+				sb.WriteString("\n")
+			}
+			pkg = result.Package
+			sb.WriteString(fmt.Sprintf("%s %s\n\n", heading, escapeMarkdown(pkg)))
+			sb.WriteString(header)
+		}
+
+		// Determine status emoji
+		emoji := statusEmoji(result.Status)
+
+		// Format test name to be more readable (remove package prefix if present)
+		displayName := result.Name
+		if strings.Contains(displayName, "/") && !result.IsSubTest {
+			displayName = filepath.Base(displayName)
+		}
+
+		// Prepare details column content
+		detailsColumn := "-"
+		if len(result.SubTests) > 0 {
+			detailsColumn = subtestTreeHTML(data, result)
+		}
+
+		name := fmt.Sprintf("**%s**", escapeMarkdown(displayName))
+		if docs {
+			name += " | " + escapeMarkdown(result.Doc)
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s %s | %.3fs | %s |\n",
+			name, emoji, result.Status, result.Duration, detailsColumn))
+	}
+	sb.WriteString("\n")
+	if leftOut > 0 {
+		sb.WriteString(fmt.Sprintf("_%d passed or skipped tests left out._\n\n", leftOut))
+	}
+}
+
+// walkSubtests calls fn for the subtests of result and their subtests in
+// depth-first order, sorted by name on every level
+func walkSubtests(data *ReportData, result *TestResult, fn func(subTest *TestResult)) {
+	names := append([]string(nil), result.SubTests...)
+	sort.Strings(names)
+	for _, name := range names {
+		if subTest, ok := data.Results[testKey(result.Package, name)]; ok {
+			fn(subTest)
+			walkSubtests(data, subTest, fn)
+		}
+	}
+}
+
+// subtestTreeHTML renders the subtests of result as nested collapsible
+// lists for the Details column, on a single line as table cells require
+func subtestTreeHTML(data *ReportData, result *TestResult) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("<details><summary>%d subtests</summary><ul>", len(result.SubTests)))
+	names := append([]string(nil), result.SubTests...)
+	sort.Strings(names)
+	for _, name := range names {
+		subTest, ok := data.Results[testKey(result.Package, name)]
+		if !ok {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("<li>%s %s %s (%.3fs)", statusEmoji(subTest.Status), subTest.Status,
+			escapeHTMLCell(subtestName(subTest)), subTest.Duration))
+		if len(subTest.SubTests) > 0 {
+			sb.WriteString(subtestTreeHTML(data, subTest))
+		}
+		sb.WriteString("</li>")
+	}
+	sb.WriteString("</ul></details>")
+	return sb.String()
+}
+
+// writeDurationsSection charts the top longest-running tests and subtests
+// taking at least threshold seconds
+func writeDurationsSection(sb *strings.Builder, data *ReportData, top int, threshold float64) {
 	sb.WriteString("## Test Durations\n\n")
 	sb.WriteString("<details>\n")
 	sb.WriteString("<summary>Click to expand test durations</summary>\n\n")
-	sb.WriteString("| Test | Duration |\n")
-	sb.WriteString("| ---- | -------- |\n")
 
 	// Sort tests by duration (descending)
 	type testDuration struct {
-		name     string
+		result   *TestResult
 		duration float64
 		isRoot   bool
 	}
 
 	var durations []testDuration
-	for testName, result := range data.Results {
+	for _, result := range data.Results {
+		if threshold > 0 && result.Duration < threshold {
+			continue
+		}
 		durations = append(durations, testDuration{
-			name:     testName,
+			result:   result,
 			duration: result.Duration,
 			isRoot:   !result.IsSubTest,
 		})
@@ -413,61 +1669,49 @@ func generateMarkdownReport(data *ReportData) string {
 		return durations[i].duration > durations[j].duration
 	})
 
-	// Scale factor for bar chart - handle outliers better and empty datasets safely
-	maxDuration := 0.0
-	if len(durations) > 0 {
-		maxDuration = durations[0].duration
-		if len(durations) > 1 && maxDuration > durations[1].duration*3 {
-			// If top test is 3x longer than second, use second test as scale to prevent skewed visualization
-			maxDuration = durations[1].duration * 1.5
-		}
-		if maxDuration <= 0 {
-			// Avoid division by zero; fallback to 1s scale when all durations are zero
-			maxDuration = 1.0
+	// Take the longest tests
+	if top <= 0 {
+		top = defaultTopDurations
+	}
+	if len(durations) > top {
+		durations = durations[:top]
+	}
+	if threshold > 0 {
+		if len(durations) == 0 {
+			sb.WriteString(fmt.Sprintf("No test took %s or longer.\n\n</details>\n", formatDuration(threshold)))
+			return
 		}
+		sb.WriteString(fmt.Sprintf("Tests taking %s or longer.\n\n", formatDuration(threshold)))
 	}
-
-	// Take top 15 longest tests (increased from 10)
-	count := 0
+	// Identically named tests of different packages are told apart by the
+	// package column
+	sb.WriteString("| Test | Package | Duration |\n")
+	sb.WriteString("| ---- | ------- | -------- |\n")
+	var charted []float64
 	for _, d := range durations {
-		if count >= 15 {
-			break
-		}
+		charted = append(charted, d.duration)
+	}
+	scale := newDurationScale(charted)
 
+	for _, d := range durations {
 		// Format test name to be more readable
-		displayName := d.name
+		displayName := d.result.Name
 		if d.isRoot {
 			if strings.Contains(displayName, "/") {
 				displayName = filepath.Base(displayName)
 			}
 		} else {
 			// For subtests, show parent/child relationship
-			displayName = "↳ " + d.name[strings.LastIndex(d.name, "/")+1:]
-		}
-
-		// Add bar chart using unicode block characters
-		durationBar := ""
-		scaleFactor := 25.0
-		if maxDuration <= 0 {
-			// Nothing to chart
-			durationBar = ""
-		} else {
-			barLength := int(d.duration * scaleFactor / maxDuration)
-			if barLength < 1 && d.duration > 0 {
-				barLength = 1
-			}
-			if barLength > 0 {
-				durationBar = strings.Repeat("█", barLength)
-			}
+			displayName = "↳ " + subtestName(d.result)
 		}
 
-		sb.WriteString(fmt.Sprintf("| %s | %.3fs %s |\n", displayName, d.duration, durationBar))
-		count++
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s %s |\n", escapeMarkdown(displayName), escapeMarkdown(d.result.Package),
+			formatDuration(d.duration), scale.bar(d.duration)))
+	}
+	if legend := scale.legend(); legend != "" {
+		sb.WriteString("\n" + legend + "\n")
 	}
 
 	// Close the details tag
-	sb.WriteString("\n</details>\n")
-	sb.WriteString(fmt.Sprintf("Report generated at: %s\n", time.Now().Format("02/01/06-15:04:05")))
-
-	return sb.String()
+	sb.WriteString("\n</details>\n\n")
 }
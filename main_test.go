@@ -102,7 +102,7 @@ func TestProcessTestEvents(t *testing.T) {
 			name: "invalid json input",
 			jsonInput: `
 {"Time":"2023-04-01T10:00:00Z","Action":"run","Test":"TestExample"
-{"Time":"2023-04-01T10:00:01Z","Action":"output","Test":"TestExample","Output":"running test\n"}
+{"Time":"2023-04-01T10:00:01Z","Action":"output","Test":"TestExample","Output":"running test\n"
 `,
 			expectedReport: nil,
 			expectError:    true,
@@ -184,11 +184,12 @@ func TestProcessTestEvents(t *testing.T) {
 					len(reportData.SortedTestNames), len(tt.expectedReport.SortedTestNames))
 			}
 
-			// Check if expected test names exist in the report
+			// Check if expected test names exist in the report, keyed by
+			// the package of the fixtures
 			for _, expectedName := range tt.expectedReport.SortedTestNames {
 				found := false
 				for _, actualName := range reportData.SortedTestNames {
-					if actualName == expectedName {
+					if actualName == testKey("pkg/example", expectedName) {
 						found = true
 						break
 					}
@@ -200,7 +201,7 @@ func TestProcessTestEvents(t *testing.T) {
 
 			// Verify test results map has entries for each test
 			for _, testName := range tt.expectedReport.SortedTestNames {
-				if _, exists := reportData.Results[testName]; !exists {
+				if _, exists := reportData.Results[testKey("pkg/example", testName)]; !exists {
 					t.Errorf("Expected test %s not found in results map", testName)
 				}
 			}
@@ -431,12 +432,12 @@ func TestFormattingInMarkdownReport(t *testing.T) {
 					if strings.Contains(line, "LongTest") && strings.Contains(line, "1.000s") {
 						longBar = strings.Count(line, "█")
 					}
-					if strings.Contains(line, "ShortTest") && strings.Contains(line, "0.100s") {
+					if strings.Contains(line, "ShortTest") && strings.Contains(line, "100ms") {
 						shortBar = strings.Count(line, "█")
 					}
 				}
 
-				if longBar <= shortBar {
+				if shortBar == 0 || longBar <= shortBar {
 					t.Errorf("LongTest bar (%d blocks) should be longer than ShortTest bar (%d blocks)",
 						longBar, shortBar)
 				}
@@ -451,3 +452,44 @@ func TestFormattingInMarkdownReport(t *testing.T) {
 		})
 	}
 }
+
+func TestDeepSubtestNesting(t *testing.T) {
+	// TestA/L1 has no events of its own, e.g. when the input was filtered
+	input := `{"Action":"run","Package":"pkg","Test":"TestA"}
+{"Action":"run","Package":"pkg","Test":"TestA/L1/L2a"}
+{"Action":"output","Package":"pkg","Test":"TestA/L1/L2a","Output":"    a_test.go:7: deep failure\n"}
+{"Action":"fail","Package":"pkg","Test":"TestA/L1/L2a","Elapsed":0.2}
+{"Action":"run","Package":"pkg","Test":"TestA/L1/L2b"}
+{"Action":"pass","Package":"pkg","Test":"TestA/L1/L2b","Elapsed":0.1}
+{"Action":"run","Package":"pkg","Test":"TestA/Other"}
+{"Action":"pass","Package":"pkg","Test":"TestA/Other","Elapsed":0.1}
+{"Action":"fail","Package":"pkg","Test":"TestA","Elapsed":0.5}
+`
+	data, err := processTestEvents(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	root := data.Results[testKey("pkg", "TestA")]
+	if got := strings.Join(root.SubTests, ","); got != "TestA/L1,TestA/Other" {
+		t.Errorf("Expected the direct subtests of TestA only, got %s", got)
+	}
+	if l1 := data.Results[testKey("pkg", "TestA/L1")]; l1 == nil || l1.ParentTest != "TestA" || len(l1.SubTests) != 2 {
+		t.Fatalf("Expected TestA/L1 below TestA with two subtests, got %+v", l1)
+	}
+	if data.TotalTests != 1 {
+		t.Errorf("Expected one root test, got %d", data.TotalTests)
+	}
+
+	report := generateMarkdownReport(data)
+	for _, expected := range []string{
+		"<details><summary>2 subtests</summary><ul><li>⏺️ UNKNOWN L1 (0.000s)<details><summary>2 subtests</summary><ul>" +
+			"<li>❌ FAIL L2a (0.200s)</li><li>✅ PASS L2b (0.100s)</li></ul></details></li><li>✅ PASS Other (0.100s)</li></ul></details>",
+		"#### L1/L2a\n\n",
+		"a_test.go:7: deep failure",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Expected report to contain %q, got:\n%s", expected, report)
+		}
+	}
+}
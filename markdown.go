@@ -0,0 +1,82 @@
+package main
+
+import (
+	"html"
+	"strings"
+)
+
+// markdownEscaper escapes the characters that start Markdown formatting,
+// autolinks or HTML, and the pipe that ends a table cell. GitHub Flavored
+// Markdown accepts a backslash before any ASCII punctuation, so the result is
+// safe both in running text and in table cells.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	"*", `\*`,
+	"_", `\_`,
+	"~", `\~`,
+	"[", `\[`,
+	"]", `\]`,
+	"#", `\#`,
+	"|", `\|`,
+	"<", "&lt;",
+	">", "&gt;",
+	"&", "&amp;",
+	"\r", "",
+	"\n", " ",
+)
+
+// escapeMarkdown escapes text such as test, package and file names so it is
+// shown literally in Markdown, including inside table cells
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// escapeHTMLCell escapes text for raw HTML embedded in a Markdown table cell,
+// where Markdown escapes are not processed but a pipe still ends the cell
+func escapeHTMLCell(s string) string {
+	return strings.ReplaceAll(html.EscapeString(s), "|", "&#124;")
+}
+
+// slackEscaper escapes the control characters of Slack mrkdwn. Slack has no
+// escape for backticks, so they are replaced to keep code spans intact.
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "`", "'")
+
+// escapeSlack escapes text for Slack mrkdwn
+func escapeSlack(s string) string {
+	return slackEscaper.Replace(s)
+}
+
+// codeSpan wraps text in a Markdown code span, using a backtick fence longer
+// than any backtick run in the text. Code spans are not valid in table cells
+// containing pipes, use escapeMarkdown there.
+func codeSpan(s string) string {
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+// subtestName returns the name of a subtest below its parent. Subtest names
+// can contain slashes themselves, e.g. table-driven tests named after URLs.
+func subtestName(result *TestResult) string {
+	if result.ParentTest != "" {
+		if name, ok := strings.CutPrefix(result.Name, result.ParentTest+"/"); ok {
+			return name
+		}
+	}
+	return result.Name[strings.LastIndex(result.Name, "/")+1:]
+}
+
+// mermaidEscaper replaces the characters that end a Mermaid Gantt task name
+// or start a comment with entity codes, which Mermaid decodes when rendering
+var mermaidEscaper = strings.NewReplacer(":", "#58;", ";", "#59;", "#", "#35;", "%", "#37;", "\r", "", "\n", " ")
+
+// escapeMermaid escapes a task or section name of a Mermaid chart
+func escapeMermaid(s string) string {
+	return mermaidEscaper.Replace(s)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEscapeMarkdown(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"TestPlain", "TestPlain"},
+		{"Test_snake_case", `Test\_snake\_case`},
+		{"a|b", `a\|b`},
+		{"*bold* `code` [link](x)", "\\*bold\\* \\`code\\` \\[link\\](x)"},
+		{"<b>html</b> & more", "&lt;b&gt;html&lt;/b&gt; &amp; more"},
+		{`back\slash #1 ~x~`, `back\\slash \#1 \~x\~`},
+		{"line\nbreak", "line break"},
+	}
+	for _, tt := range tests {
+		if got := escapeMarkdown(tt.input); got != tt.expected {
+			t.Errorf("escapeMarkdown(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestCodeSpan(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"plain", "`plain`"},
+		{"has `tick`", "`` has `tick` ``"},
+		{"a `b` c", "``a `b` c``"},
+		{"`edge", "`` `edge ``"},
+	}
+	for _, tt := range tests {
+		if got := codeSpan(tt.input); got != tt.expected {
+			t.Errorf("codeSpan(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestEscapeMermaid(t *testing.T) {
+	if got, want := escapeMermaid("TestURL/http://x;y#1 100%\nz"), "TestURL/http#58;//x#59;y#35;1 100#37; z"; got != want {
+		t.Errorf("escapeMermaid() = %q, want %q", got, want)
+	}
+}
+
+func TestSubtestName(t *testing.T) {
+	result := &TestResult{Name: "TestFetch/https://example.com/a|b", ParentTest: "TestFetch", IsSubTest: true}
+	if got := subtestName(result); got != "https://example.com/a|b" {
+		t.Errorf("Expected the full subtest name, got %q", got)
+	}
+}
+
+func TestReportEscapesNames(t *testing.T) {
+	data := &ReportData{
+		Results: map[string]*TestResult{
+			testKey("pkg", "TestFetch"): {Name: "TestFetch", Package: "pkg", Status: "FAIL", SubTests: []string{"TestFetch/https://x.io/a|b_<i>"}},
+			testKey("pkg", "TestFetch/https://x.io/a|b_<i>"): {Name: "TestFetch/https://x.io/a|b_<i>", Package: "pkg", Status: "FAIL",
+				IsSubTest: true, ParentTest: "TestFetch", Output: []string{"    fetch_test.go:9: ``` broke"}},
+			testKey("pkg", "Test_under|pipe"): {Name: "Test_under|pipe", Package: "pkg", Status: "PASS"},
+		},
+		Packages: map[string]*PackageResult{},
+	}
+	summarizeReport(data, nil)
+	report := renderMarkdownReport(data, ReportOptions{})
+
+	for _, expected := range []string{
+		`| **Test\_under\|pipe** | ✅ PASS |`,
+		"<li>❌ FAIL https://x.io/a&#124;b_&lt;i&gt; (0.000s)</li>",
+		`#### https://x.io/a\|b\_&lt;i&gt;`,
+		"````go\n    fetch_test.go:9: ``` broke\n````",
+		`| ↳ https://x.io/a\|b\_&lt;i&gt; |`,
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Expected report to contain %q, got:\n%s", expected, report)
+		}
+	}
+	// Every row of the results table keeps its column count
+	for _, line := range strings.Split(report, "\n") {
+		if strings.HasPrefix(line, "| **") {
+			if cells := strings.Count(strings.ReplaceAll(line, `\|`, ""), "|"); cells != 5 {
+				t.Errorf("Expected 4 cells in %q", line)
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// matrixLabel matches the platform label of a LABEL=FILE input
+var matrixLabel = regexp.MustCompile(`^[\w.-]+$`)
+
+// labeledInput is an input of a matrix merge, e.g. linux=linux.json
+type labeledInput struct {
+	Label string
+	File  string
+}
+
+// labeledInputs returns the inputs as LABEL=FILE pairs, or nil when none is
+// labeled. Labeled and unlabeled inputs cannot be mixed.
+func labeledInputs(inputs []string) ([]labeledInput, error) {
+	var labeled []labeledInput
+	unlabeled := ""
+	for _, input := range inputs {
+		label, file, ok := strings.Cut(input, "=")
+		if !ok || !matrixLabel.MatchString(label) || file == "" {
+			if unlabeled == "" {
+				unlabeled = input
+			}
+			continue
+		}
+		labeled = append(labeled, labeledInput{Label: label, File: file})
+	}
+	if len(labeled) > 0 && unlabeled != "" {
+		return nil, fmt.Errorf("input %q has no LABEL= prefix; label every input for a matrix report", unlabeled)
+	}
+	return labeled, nil
+}
+
+// matrixRow is a test with its status on every platform
+type matrixRow struct {
+	Name     string
+	Package  string
+	Statuses []string // Per platform in input order, empty when the test did not run
+	Specific bool     // Failed on some platforms and passed on others
+}
+
+// testMatrix returns a row for every test of the runs, sorted by package and
+// name
+func testMatrix(runs []EnvironmentRun) []matrixRow {
+	specific := make(map[string]bool)
+	for _, diff := range compareEnvironments(runs) {
+		specific[testKey(diff.Package, diff.Name)] = true
+	}
+	names := make(map[string]bool)
+	for _, env := range runs {
+		for name := range env.Run.Tests {
+			names[name] = true
+		}
+	}
+	var rows []matrixRow
+	for name := range names {
+		row := matrixRow{Name: name, Statuses: make([]string, len(runs)), Specific: specific[name]}
+		for i, env := range runs {
+			if test, ok := env.Run.Tests[name]; ok {
+				row.Name, row.Package, row.Statuses[i] = recordTestName(name, test), test.Package, test.Status
+			}
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Package != rows[j].Package {
+			return rows[i].Package < rows[j].Package
+		}
+		return rows[i].Name < rows[j].Name
+	})
+	return rows
+}
+
+// writeMatrixTable renders rows with a status column per platform. Failures
+// are in bold, and failures specific to some platforms are flagged.
+func writeMatrixTable(sb *strings.Builder, runs []EnvironmentRun, rows []matrixRow) {
+	sb.WriteString("| Test | Package |")
+	separator := "| ---- | ------- |"
+	for _, env := range runs {
+		sb.WriteString(" " + escapeMarkdown(env.Name) + " |")
+		separator += " --- |"
+	}
+	sb.WriteString("\n" + separator + "\n")
+	for _, row := range rows {
+		name := escapeMarkdown(row.Name)
+		if row.Specific {
+			name = "⚠️ " + name
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s |", name, escapeMarkdown(row.Package)))
+		for _, status := range row.Statuses {
+			switch status {
+			case "":
+				sb.WriteString(" - |")
+			case "FAIL":
+				sb.WriteString(fmt.Sprintf(" %s **FAIL** |", statusEmoji(status)))
+			default:
+				sb.WriteString(fmt.Sprintf(" %s %s |", statusEmoji(status), status))
+			}
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+}
+
+// renderMatrixReport renders the runs of the suite on several platforms as
+// one report with a status column per platform
+func renderMatrixReport(runs []EnvironmentRun) string {
+	var sb strings.Builder
+	sb.WriteString("# Test Matrix\n\n")
+	sb.WriteString("| Platform | Tests | Passed | Failed | Skipped | Pass Rate |\n")
+	sb.WriteString("| -------- | ----- | ------ | ------ | ------- | --------- |\n")
+	for _, env := range runs {
+		sb.WriteString(fmt.Sprintf("| %s | %d | %d | %d | %d | %.1f%% |\n",
+			escapeMarkdown(env.Name), env.Run.Total, env.Run.Passed, env.Run.Failed, env.Run.Skipped, env.Run.PassRate()))
+	}
+	sb.WriteString("\n")
+
+	rows := testMatrix(runs)
+	var specific []matrixRow
+	for _, row := range rows {
+		if row.Specific {
+			specific = append(specific, row)
+		}
+	}
+	sb.WriteString("## Platform-Specific Failures\n\n")
+	if len(specific) == 0 {
+		sb.WriteString("No test passed on one platform and failed on another.\n\n")
+	} else {
+		writeMatrixTable(&sb, runs, specific)
+	}
+
+	sb.WriteString("## All Tests\n\n")
+	sb.WriteString("<details>\n<summary>Click to expand all tests</summary>\n\n")
+	writeMatrixTable(&sb, runs, rows)
+	sb.WriteString("</details>\n")
+	return sb.String()
+}
+
+// runMatrixMerge writes the matrix report of labeled inputs, exiting non-zero
+// with failOnFailure when a test or package failed on any platform
+func runMatrixMerge(inputs []labeledInput, output string, opts ParseOptions, failOnFailure bool) int {
+	var runs []EnvironmentRun
+	failed := false
+	for _, input := range inputs {
+		data, err := loadReport(input.File, opts)
+		if err != nil {
+			logger.Errorf("Error reading %s: %v", input.File, err)
+			return 1
+		}
+		failed = failed || data.FailedTests > 0 || data.FailedPackages > 0
+		runs = append(runs, EnvironmentRun{Name: input.Label, Run: newRunRecord(data, data.Start)})
+	}
+	if err := os.WriteFile(output, []byte(renderMatrixReport(runs)), 0o644); err != nil {
+		logger.Errorf("Error writing report: %v", err)
+		return 1
+	}
+	logger.Printf("Report generated successfully: %s", output)
+	if failOnFailure && failed {
+		return 1
+	}
+	return 0
+}
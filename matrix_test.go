@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLabeledInputs(t *testing.T) {
+	labeled, err := labeledInputs([]string{"linux=out/linux.json", "windows=C:/out/windows.json"})
+	if err != nil || len(labeled) != 2 || labeled[1] != (labeledInput{"windows", "C:/out/windows.json"}) {
+		t.Errorf("Unexpected labeled inputs %+v (%v)", labeled, err)
+	}
+	if labeled, err := labeledInputs([]string{"shard-1.json", "shard-2.json"}); labeled != nil || err != nil {
+		t.Errorf("Expected unlabeled inputs to be left alone, got %+v (%v)", labeled, err)
+	}
+	if _, err := labeledInputs([]string{"linux=linux.json", "windows.json"}); err == nil {
+		t.Error("Expected an error for mixed inputs")
+	}
+}
+
+func TestMatrixMerge(t *testing.T) {
+	dir := t.TempDir()
+	runs := map[string]string{
+		"linux":   `{"Action":"pass","Package":"fs","Test":"TestPath","Elapsed":1}` + "\n" + `{"Action":"pass","Package":"fs","Test":"TestLink","Elapsed":1}`,
+		"windows": `{"Action":"fail","Package":"fs","Test":"TestPath","Elapsed":1}` + "\n" + `{"Action":"skip","Package":"fs","Test":"TestLink","Elapsed":0}`,
+		"darwin":  `{"Action":"pass","Package":"fs","Test":"TestPath","Elapsed":1}`,
+	}
+	var args []string
+	for _, label := range []string{"linux", "windows", "darwin"} {
+		file := filepath.Join(dir, label+".json")
+		os.WriteFile(file, []byte(runs[label]+"\n"), 0644)
+		args = append(args, "-input", label+"="+file)
+	}
+	output := filepath.Join(dir, "matrix.md")
+
+	if code := runCLI(append([]string{"merge", "-output", output, "-fail-on-failure"}, args...)); code != 1 {
+		t.Errorf("Expected exit code 1 for a failure on a platform, got %d", code)
+	}
+	report, _ := os.ReadFile(output)
+	for _, expected := range []string{
+		"| windows | 2 | 0 | 1 | 1 | 0.0% |",
+		"## Platform-Specific Failures\n\n| Test | Package | linux | windows | darwin |\n| ---- | ------- | --- | --- | --- |\n| ⚠️ TestPath | fs | ✅ PASS | ❌ **FAIL** | ✅ PASS |\n",
+		"| TestLink | fs | ✅ PASS | ⏭️ SKIP | - |",
+	} {
+		if !strings.Contains(string(report), expected) {
+			t.Errorf("Expected the report to contain %q, got:\n%s", expected, report)
+		}
+	}
+}
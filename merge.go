@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// stringList is a flag that can be repeated
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// expandInputs resolves glob patterns among the input arguments. Arguments
+// without glob characters are kept as is so a missing file is reported.
+func expandInputs(patterns []string) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+	for _, pattern := range patterns {
+		matches := []string{pattern}
+		if strings.ContainsAny(pattern, "*?[") {
+			var err error
+			if matches, err = filepath.Glob(pattern); err != nil {
+				return nil, fmt.Errorf("invalid input pattern %q: %v", pattern, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no input files match %q", pattern)
+			}
+		}
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				files = append(files, match)
+			}
+		}
+	}
+	return files, nil
+}
+
+// loadReports reads and merges the inputs, or stdin when there are none
+func loadReports(inputs []string, opts ParseOptions) (*ReportData, error) {
+	files, err := expandInputs(inputs)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) <= 1 {
+		file := ""
+		if len(files) == 1 {
+			file = files[0]
+		}
+		return loadReport(file, opts)
+	}
+
+	reports := make([]*ReportData, 0, len(files))
+	for _, file := range files {
+		data, err := loadReport(file, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		if data.Verification != nil {
+			for i := range data.Verification.Violations {
+				data.Verification.Violations[i].Input = file
+			}
+		}
+		if data.Diagnostics != nil {
+			for i := range data.Diagnostics.Samples {
+				data.Diagnostics.Samples[i].Input = file
+			}
+		}
+		reports = append(reports, data)
+	}
+	if opts.NormalizeTime {
+		normalizeTimes(reports)
+	}
+	merged := mergeReports(reports, opts.Normalize)
+	merged.Suites = suiteSummaries(files, reports)
+	return merged, nil
+}
+
+// mergeReports combines reports from sharded runs into one. A test of the
+// same package and name present in several reports is taken, with its
+// subtests, from the last one, so re-running a shard replaces its earlier
+// results.
+func mergeReports(reports []*ReportData, rules []normalizeRule) *ReportData {
+	merged := &ReportData{
+		Results:  make(map[string]*TestResult),
+		Packages: make(map[string]*PackageResult),
+	}
+
+	var remove func(name string)
+	remove = func(name string) {
+		if result, ok := merged.Results[name]; ok {
+			for _, sub := range result.SubTests {
+				remove(testKey(result.Package, sub))
+			}
+			delete(merged.Results, name)
+		}
+	}
+	var copyTree func(data *ReportData, name string, previous map[string]*TestResult)
+	copyTree = func(data *ReportData, name string, previous map[string]*TestResult) {
+		result, ok := data.Results[name]
+		if !ok {
+			return
+		}
+		// The replaced runs are kept as earlier attempts
+		if earlier, ok := previous[name]; ok {
+			carryAttempts(result, earlier)
+		}
+		merged.Results[name] = result
+		for _, sub := range result.SubTests {
+			copyTree(data, testKey(result.Package, sub), previous)
+		}
+	}
+
+	for _, data := range reports {
+		for _, name := range data.SortedTestNames {
+			previous := make(map[string]*TestResult)
+			if result, ok := merged.Results[name]; ok {
+				previous[name] = result
+				walkSubtests(merged, result, func(subTest *TestResult) { previous[testKey(subTest.Package, subTest.Name)] = subTest })
+			}
+			remove(name)
+			copyTree(data, name, previous)
+		}
+		for name, pkg := range data.Packages {
+			merged.Packages[name] = mergePackage(merged.Packages[name], pkg)
+		}
+		merged.Benchmarks = append(merged.Benchmarks, data.Benchmarks...)
+		if data.Verification != nil {
+			if merged.Verification == nil {
+				merged.Verification = &StreamVerification{Violations: []StreamViolation{}}
+			}
+			merged.Verification.Violations = append(merged.Verification.Violations, data.Verification.Violations...)
+		}
+		merged.Diagnostics = mergeDiagnostics(merged.Diagnostics, data.Diagnostics)
+	}
+
+	summarizeReport(merged, rules)
+	return merged
+}
+
+// mergePackage combines the outcome of a package that ran in several shards.
+// A failure in any shard fails the package.
+func mergePackage(existing, pkg *PackageResult) *PackageResult {
+	if existing == nil {
+		return pkg
+	}
+	combined := *existing
+	switch {
+	case combined.Status == "FAIL":
+	case pkg.Status == "FAIL", combined.Status == "UNKNOWN", combined.Status == "SKIP" && pkg.Status != "UNKNOWN":
+		combined.Status = pkg.Status
+	}
+	combined.Duration += pkg.Duration
+	combined.Output = append(append([]string(nil), combined.Output...), pkg.Output...)
+	combined.BuildFailed = combined.BuildFailed || pkg.BuildFailed
+	combined.BuildOutput = append(append([]string(nil), combined.BuildOutput...), pkg.BuildOutput...)
+	// Each shard shuffles with its own seed; the failing one is worth reproducing
+	if pkg.ShuffleSeed != "" && (combined.ShuffleSeed == "" || pkg.Status == "FAIL" && existing.Status != "FAIL") {
+		combined.ShuffleSeed, combined.Flags = pkg.ShuffleSeed, pkg.Flags
+	}
+	return &combined
+}
+
+// summarizeReport computes the totals and test order from the results, and
+// fingerprints the failures with the normalize rules
+func summarizeReport(data *ReportData, rules []normalizeRule) {
+	// Fuzz targets resolve their status before anything is counted
+	data.Fuzz = extractFuzzResults(data)
+	// Attach directives are not part of the failure or its fingerprint
+	extractAttachments(data)
+
+	data.TotalTests, data.PassedTests, data.FailedTests, data.SkippedTests = 0, 0, 0, 0
+	data.TotalDuration = 0
+
+	var sortedNames []string
+	for name, result := range data.Results {
+		// Only count root tests in summary (not subtests)
+		if !result.IsSubTest {
+			sortedNames = append(sortedNames, name)
+			data.TotalTests++
+			data.TotalDuration += result.Duration
+
+			switch result.Status {
+			case "PASS":
+				data.PassedTests++
+			case "FAIL":
+				data.FailedTests++
+			case "SKIP":
+				data.SkippedTests++
+			}
+		}
+	}
+
+	sort.Slice(sortedNames, func(i, j int) bool {
+		a, b := data.Results[sortedNames[i]], data.Results[sortedNames[j]]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Package < b.Package
+	})
+	data.SortedTestNames = sortedNames
+	data.Start, data.End = timeRange(data)
+	data.FailedPackages = len(packageFailures(data))
+	data.DataRaces = extractDataRaces(data)
+	assignFingerprints(data, rules)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergeReports(t *testing.T) {
+	shard1 := `{"Action":"run","Package":"pkg/a","Test":"TestA"}
+{"Action":"run","Package":"pkg/a","Test":"TestA/old"}
+{"Action":"fail","Package":"pkg/a","Test":"TestA/old","Elapsed":0.1}
+{"Action":"fail","Package":"pkg/a","Test":"TestA","Elapsed":0.2}
+{"Action":"fail","Package":"pkg/a","Elapsed":0.3}
+`
+	shard2 := `{"Action":"run","Package":"pkg/b","Test":"TestB"}
+{"Action":"pass","Package":"pkg/b","Test":"TestB","Elapsed":1}
+{"Action":"pass","Package":"pkg/b","Elapsed":1}
+`
+	rerun := `{"Action":"run","Package":"pkg/a","Test":"TestA"}
+{"Action":"run","Package":"pkg/a","Test":"TestA/new"}
+{"Action":"pass","Package":"pkg/a","Test":"TestA/new","Elapsed":0.1}
+{"Action":"pass","Package":"pkg/a","Test":"TestA","Elapsed":0.2}
+{"Action":"pass","Package":"pkg/a","Elapsed":0.3}
+`
+	var reports []*ReportData
+	for _, input := range []string{shard1, shard2, rerun} {
+		data, err := processTestEvents(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		reports = append(reports, data)
+	}
+
+	merged := mergeReports(reports, nil)
+	if merged.TotalTests != 2 || merged.PassedTests != 2 || merged.FailedTests != 0 {
+		t.Errorf("Expected 2 passed tests after the re-run, got total=%d passed=%d failed=%d", merged.TotalTests, merged.PassedTests, merged.FailedTests)
+	}
+	if _, ok := merged.Results[testKey("pkg/a", "TestA/old")]; ok {
+		t.Error("Subtests of a replaced test should be removed")
+	}
+	if _, ok := merged.Results[testKey("pkg/a", "TestA/new")]; !ok {
+		t.Error("Subtests of the latest run should be kept")
+	}
+	if pkg := merged.Packages["pkg/a"]; pkg.Status != "FAIL" {
+		t.Errorf("A package failing in any shard should stay failed, got %s", pkg.Status)
+	}
+	if strings.Join(merged.SortedTestNames, ",") != "pkg/a.TestA,pkg/b.TestB" {
+		t.Errorf("Unexpected test order %v", merged.SortedTestNames)
+	}
+}
+
+func TestMergeSameNameInPackages(t *testing.T) {
+	shard1 := `{"Action":"run","Package":"ex/a","Test":"TestNew"}
+{"Action":"output","Package":"ex/a","Test":"TestNew","Output":"    a_test.go:5: Error: boom\n"}
+{"Action":"fail","Package":"ex/a","Test":"TestNew","Elapsed":0.1}
+{"Action":"fail","Package":"ex/a","Elapsed":0.2}
+`
+	shard2 := `{"Action":"run","Package":"ex/b","Test":"TestNew"}
+{"Action":"pass","Package":"ex/b","Test":"TestNew","Elapsed":0.1}
+{"Action":"pass","Package":"ex/b","Elapsed":0.2}
+`
+	var reports []*ReportData
+	for _, input := range []string{shard1, shard2} {
+		data, err := processTestEvents(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		reports = append(reports, data)
+	}
+
+	merged := mergeReports(reports, nil)
+	if merged.TotalTests != 2 || merged.PassedTests != 1 || merged.FailedTests != 1 {
+		t.Errorf("Expected the tests of both packages, got total=%d passed=%d failed=%d", merged.TotalTests, merged.PassedTests, merged.FailedTests)
+	}
+	for _, pkg := range []string{"ex/a", "ex/b"} {
+		if result := merged.Results[testKey(pkg, "TestNew")]; result == nil || result.Package != pkg {
+			t.Errorf("Expected TestNew of %s, got %+v", pkg, result)
+		}
+	}
+	if strings.Join(merged.SortedTestNames, ",") != "ex/a.TestNew,ex/b.TestNew" {
+		t.Errorf("Unexpected test order %v", merged.SortedTestNames)
+	}
+}
+
+func TestLoadReportsGlob(t *testing.T) {
+	dir := t.TempDir()
+	for i, name := range []string{"shard-1.json", "shard-2.json"} {
+		test := []string{"TestOne", "TestTwo"}[i]
+		content := `{"Action":"run","Package":"pkg","Test":"` + test + `"}
+{"Action":"pass","Package":"pkg","Test":"` + test + `","Elapsed":0.1}
+`
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+	}
+
+	data, err := loadReports([]string{filepath.Join(dir, "shard-*.json"), filepath.Join(dir, "shard-1.json")}, ParseOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data.TotalTests != 2 || data.PassedTests != 2 {
+		t.Errorf("Expected both shards merged, got %d tests", data.TotalTests)
+	}
+
+	if _, err := loadReports([]string{filepath.Join(dir, "missing-*.json")}, ParseOptions{}); err == nil {
+		t.Error("Expected an error when a glob matches nothing")
+	}
+}
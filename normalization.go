@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// NormalizeRule rewrites the part of failure messages that differs between
+// occurrences of the same failure, such as timestamps, ports or UUIDs. Rules
+// are given under normalize in the config file.
+type NormalizeRule struct {
+	Pattern string `yaml:"pattern"` // Regular expression matched against each output line
+	Replace string `yaml:"replace"` // Canonical text; $1 or ${name} insert a capture
+}
+
+type normalizeRule struct {
+	pattern *regexp.Regexp
+	replace string
+}
+
+var (
+	// fingerprintDigits collapses numbers (line numbers, durations, ports)
+	// so the same failure keeps its fingerprint across code changes
+	fingerprintDigits = regexp.MustCompile(`\d+`)
+	// fingerprintHex collapses pointers and other hex identifiers
+	fingerprintHex = regexp.MustCompile(`0x[0-9a-fA-F]+`)
+)
+
+// compileNormalizeRules compiles the normalization rules of the config file
+func compileNormalizeRules(rules []NormalizeRule) ([]normalizeRule, error) {
+	compiled := make([]normalizeRule, 0, len(rules))
+	for i, rule := range rules {
+		if rule.Pattern == "" {
+			return nil, fmt.Errorf("normalize rule %d in config file needs a pattern", i+1)
+		}
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern of normalize rule %d: %v", i+1, err)
+		}
+		compiled = append(compiled, normalizeRule{pattern: pattern, replace: rule.Replace})
+	}
+	return compiled, nil
+}
+
+// normalizeMessage returns the canonical form of a line of failure output,
+// which clustering, fingerprints and the history compare. The rules of the
+// config file run first, then numbers and hex values are collapsed.
+func normalizeMessage(line string, rules []normalizeRule) string {
+	for _, rule := range rules {
+		line = rule.pattern.ReplaceAllString(line, rule.replace)
+	}
+	line = fingerprintHex.ReplaceAllString(line, "0x")
+	return fingerprintDigits.ReplaceAllString(line, "N")
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// mustCompileNormalizeRules compiles rules or fails the test
+func mustCompileNormalizeRules(t *testing.T, rules []NormalizeRule) []normalizeRule {
+	t.Helper()
+	compiled, err := compileNormalizeRules(rules)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return compiled
+}
+
+var exampleNormalizeRules = []NormalizeRule{
+	{Pattern: `\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`, Replace: "<uuid>"},
+	{Pattern: `\d{4}-\d{2}-\d{2}T[\d:.]+(Z|[+-]\d{2}:\d{2})`, Replace: "<time>"},
+	{Pattern: `(127\.0\.0\.1|localhost):\d+`, Replace: "$1:<port>"},
+}
+
+func TestNormalizeMessage(t *testing.T) {
+	rules := mustCompileNormalizeRules(t, exampleNormalizeRules)
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{"order 3f2a9c1e-8b7d-4e6f-a5c4-1d2e3f4a5b6c not found", "order <uuid> not found"},
+		{"dial tcp 127.0.0.1:53124: connection refused", "dial tcp N.N.N.N:<port>: connection refused"},
+		{"at 2024-05-01T10:00:00.123Z: lock held", "at <time>: lock held"},
+		{"pointer 0xc000123 after 3 retries", "pointer Nx after N retries"},
+	}
+	for _, tt := range tests {
+		if got := normalizeMessage(tt.line, rules); got != tt.expected {
+			t.Errorf("normalizeMessage(%q) = %q, expected %q", tt.line, got, tt.expected)
+		}
+	}
+}
+
+func TestCompileNormalizeRulesErrors(t *testing.T) {
+	if _, err := compileNormalizeRules([]NormalizeRule{{Pattern: "("}}); err == nil || !strings.Contains(err.Error(), "normalize rule 1") {
+		t.Errorf("Expected an invalid pattern error, got %v", err)
+	}
+	if _, err := compileNormalizeRules([]NormalizeRule{{Replace: "x"}}); err == nil {
+		t.Error("Expected an error for a rule without a pattern")
+	}
+}
+
+func TestNormalizedFingerprints(t *testing.T) {
+	first := &TestResult{Name: "TestOrder", Package: "shop", Status: "FAIL", Output: []string{"    order_test.go:12: Error: order 3f2a9c1e-8b7d-4e6f-a5c4-1d2e3f4a5b6c not found"}}
+	second := &TestResult{Name: "TestOrder", Package: "shop", Status: "FAIL", Output: []string{"    order_test.go:12: Error: order a1b2c3d4-e5f6-4a7b-8c9d-0e1f2a3b4c5d not found"}}
+	if failureFingerprint(first, nil) == failureFingerprint(second, nil) {
+		t.Fatal("Expected different fingerprints without rules")
+	}
+
+	rules := mustCompileNormalizeRules(t, exampleNormalizeRules)
+	if failureFingerprint(first, rules) != failureFingerprint(second, rules) {
+		t.Error("Expected the same fingerprint once the UUIDs are normalized")
+	}
+	if normalizedFailure(first, rules) != normalizedFailure(second, rules) {
+		t.Errorf("Expected failures to cluster, got %q and %q", normalizedFailure(first, rules), normalizedFailure(second, rules))
+	}
+
+	// Every command parsing the same input with the rules agrees
+	input := `{"Action":"run","Package":"shop","Test":"TestOrder"}
+{"Action":"output","Package":"shop","Test":"TestOrder","Output":"    order_test.go:12: Error: order 3f2a9c1e-8b7d-4e6f-a5c4-1d2e3f4a5b6c not found\n"}
+{"Action":"fail","Package":"shop","Test":"TestOrder","Elapsed":0.1}
+`
+	data, err := parseTestEvents(strings.NewReader(input), ParseOptions{Normalize: rules})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := data.Results[testKey("shop", "TestOrder")].Fingerprint; got != failureFingerprint(second, rules) {
+		t.Errorf("Expected the parsed failure to be fingerprinted with the rules, got %s", got)
+	}
+}
+
+func TestChangedFailures(t *testing.T) {
+	previous := &RunRecord{Tests: map[string]TestRecord{
+		"TestSame":    {Status: "FAIL", Fingerprint: "aaa"},
+		"TestChanged": {Status: "FAIL", Fingerprint: "bbb"},
+		"TestOld":     {Status: "FAIL"},
+	}}
+	current := &RunRecord{Tests: map[string]TestRecord{
+		"TestSame":    {Status: "FAIL", Fingerprint: "aaa"},
+		"TestChanged": {Status: "FAIL", Fingerprint: "ccc"},
+		"TestOld":     {Status: "FAIL", Fingerprint: "ddd"},
+		"TestNew":     {Status: "FAIL", Fingerprint: "eee"},
+	}}
+	if got := changedFailures(previous, current); len(got) != 1 || got[0] != "TestChanged" {
+		t.Errorf("Expected only TestChanged, got %v", got)
+	}
+
+	data := &ReportData{Results: map[string]*TestResult{
+		"TestChanged": {Name: "TestChanged", Status: "FAIL", Output: []string{"    a_test.go:3: Error: new failure"}},
+	}}
+	summarizeReport(data, nil)
+	var sb strings.Builder
+	writeTrendsSection(&sb, data, []*RunRecord{{Timestamp: time.Now(), Tests: map[string]TestRecord{testKey("", "TestChanged"): {Status: "FAIL", Fingerprint: "bbb"}}}})
+	if !strings.Contains(sb.String(), "**Failing differently than in the previous run:**\n\n- ❌ TestChanged") {
+		t.Errorf("Expected the changed failure in the Trends section, got:\n%s", sb.String())
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Suite is a file, module or class of test cases of another test runner,
+// normalized by its input adapter and reported as a package
+type Suite struct {
+	Package  string // Tagged with the source, e.g. "jest:e2e/login.test.js"
+	Start    time.Time
+	Duration float64  // Seconds
+	Failed   bool     // Also set when the suite failed without a failing case
+	Output   []string // Suite-level failure, e.g. a syntax error
+	Cases    []Case
+}
+
+// Case is a normalized test case of a suite
+type Case struct {
+	Path        []string // Names from the root test down, e.g. file, describe blocks and title
+	Status      string   // "PASS", "FAIL" or "SKIP"
+	Duration    float64  // Seconds
+	Output      []string // Failure or skip message and captured output
+	Attachments []Attachment
+}
+
+// InputAdapter reads the results of a test runner other than go test into
+// suites. Everything after the adapter works on the go test -json events of
+// the suites, so renderers need no source-specific code.
+type InputAdapter interface {
+	// Format names the input format, e.g. "jest"
+	Format() string
+	// Detect reports whether the input starting with head is of the format
+	Detect(head []byte) bool
+	// Suites reads the input, naming files relative to root
+	Suites(r io.Reader, root string) ([]*Suite, error)
+}
+
+// inputAdapters are tried in order on every input that is not go test -json
+var inputAdapters = []InputAdapter{junitAdapter{}, jestAdapter{}}
+
+// detectInputAdapter returns the adapter of the input, or nil for go test
+// -json output
+func detectInputAdapter(r *bufio.Reader) InputAdapter {
+	head, _ := r.Peek(4096)
+	head = bytes.TrimLeft(head, " \t\r\n\ufeff")
+	firstLine, _, _ := bytes.Cut(head, []byte("\n"))
+	if bytes.Contains(firstLine, []byte(`"Action"`)) {
+		return nil
+	}
+	for _, adapter := range inputAdapters {
+		if adapter.Detect(head) {
+			return adapter
+		}
+	}
+	return nil
+}
+
+// suiteEvents converts a suite to the go test -json events of equivalent Go
+// tests, with an event for every parent of a case so describe blocks and
+// classes become tests with subtests
+func suiteEvents(suite *Suite) []TestEvent {
+	var events []TestEvent
+	type parent struct {
+		status   string
+		duration float64
+	}
+	parents := make(map[string]*parent)
+	var order []string
+	for _, c := range suite.Cases {
+		name := strings.Join(c.Path, "/")
+		events = append(events, TestEvent{Time: suite.Start, Action: "run", Package: suite.Package, Test: name})
+		for _, line := range c.Output {
+			events = append(events, TestEvent{Action: "output", Package: suite.Package, Test: name, Output: line + "\n"})
+		}
+		// Attachments are picked up from the output like those of Go tests
+		for _, a := range c.Attachments {
+			directive := fmt.Sprintf("::attach name=%s,file=%s::\n", a.Name, a.Path)
+			events = append(events, TestEvent{Action: "output", Package: suite.Package, Test: name, Output: directive})
+		}
+		events = append(events, TestEvent{Action: strings.ToLower(c.Status), Package: suite.Package, Test: name, Elapsed: c.Duration})
+
+		for i := 1; i < len(c.Path); i++ {
+			parentName := strings.Join(c.Path[:i], "/")
+			p, ok := parents[parentName]
+			if !ok {
+				p = &parent{status: "SKIP"}
+				parents[parentName] = p
+				order = append(order, parentName)
+			}
+			p.duration += c.Duration
+			if c.Status == "FAIL" || c.Status == "PASS" && p.status == "SKIP" {
+				p.status = c.Status
+			}
+		}
+	}
+	// Parents end after their subtests, the deepest first
+	for i := len(order) - 1; i >= 0; i-- {
+		p := parents[order[i]]
+		events = append(events, TestEvent{Action: strings.ToLower(p.status), Package: suite.Package, Test: order[i], Elapsed: p.duration})
+	}
+
+	for _, line := range suite.Output {
+		events = append(events, TestEvent{Action: "output", Package: suite.Package, Output: line + "\n"})
+	}
+	action := "pass"
+	if suite.Failed {
+		action = "fail"
+	}
+	for _, c := range suite.Cases {
+		if c.Status == "FAIL" {
+			action = "fail"
+		}
+	}
+	return append(events, TestEvent{Action: action, Package: suite.Package, Elapsed: suite.Duration})
+}
+
+// adaptInput reads the input with the adapter and returns the events of its
+// suites as go test -json output
+func adaptInput(r io.Reader, adapter InputAdapter, root string) (io.Reader, error) {
+	suites, err := adapter.Suites(r, root)
+	if err != nil {
+		return nil, err
+	}
+	var converted bytes.Buffer
+	encoder := json.NewEncoder(&converted)
+	for _, suite := range suites {
+		for _, event := range suiteEvents(suite) {
+			if err := encoder.Encode(event); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &converted, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestDetectInputAdapter(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"Action":"run","Package":"p","Test":"TestA"}` + "\n" + `{"Action":"output","Output":"\"testResults\""}`, ""},
+		{jestSample, "jest"},
+		{"\ufeff" + junitSample, "junit"},
+		{"=== RUN   TestA\n--- PASS: TestA (0.00s)\n", ""},
+	}
+	for _, tt := range tests {
+		format := ""
+		if adapter := detectInputAdapter(bufio.NewReader(strings.NewReader(tt.input))); adapter != nil {
+			format = adapter.Format()
+		}
+		if format != tt.expected {
+			t.Errorf("detectInputAdapter(%.30q) = %q, want %q", tt.input, format, tt.expected)
+		}
+	}
+}
+
+func TestSuiteEvents(t *testing.T) {
+	suite := &Suite{
+		Package:  "jest:login.test.js",
+		Duration: 2,
+		Cases: []Case{
+			{Path: []string{"login.test.js", "Login", "form", "submits"}, Status: "PASS", Duration: 0.5},
+			{Path: []string{"login.test.js", "Login", "form", "validates"}, Status: "FAIL", Duration: 1, Output: []string{"expected true"},
+				Attachments: []Attachment{{Name: "form", Path: "shots/form.png"}}},
+			{Path: []string{"login.test.js", "Login", "remembers"}, Status: "SKIP"},
+		},
+	}
+	var got []string
+	for _, event := range suiteEvents(suite) {
+		got = append(got, strings.TrimSpace(event.Action+" "+event.Test+" "+event.Output))
+	}
+	expected := []string{
+		"run login.test.js/Login/form/submits",
+		"pass login.test.js/Login/form/submits",
+		"run login.test.js/Login/form/validates",
+		"output login.test.js/Login/form/validates expected true",
+		"output login.test.js/Login/form/validates ::attach name=form,file=shots/form.png::",
+		"fail login.test.js/Login/form/validates",
+		"run login.test.js/Login/remembers",
+		"skip login.test.js/Login/remembers",
+		"fail login.test.js/Login/form",
+		"fail login.test.js/Login",
+		"fail login.test.js",
+		"fail",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected events:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+
+	converted, err := adaptInput(strings.NewReader(junitSample), junitAdapter{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := parseTestEvents(converted, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := data.Results[testKey("pytest:tests.test_api.TestUsers", "TestUsers/test_delete")]; result == nil || len(result.Attachments) != 1 || result.Attachments[0].Name != "response.json" {
+		t.Errorf("Expected the JUnit attachment on the test result, got %+v", result)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// isFailureLine reports whether a line is kept by the filtered output mode
+func isFailureLine(line string) bool {
+	return strings.Contains(line, "FAIL") || strings.Contains(line, "Error") ||
+		strings.Contains(line, "panic:")
+}
+
+// failureOutput returns the output lines shown for a failed test. The full
+// output keeps t.Logf context and multi-line diffs; long output keeps its
+// beginning and end, where the failure and its summary usually are.
+func failureOutput(output []string, opts ReportOptions) []string {
+	lines := output
+	if opts.FilterOutput {
+		lines = nil
+		for i, line := range output {
+			if strings.HasPrefix(strings.TrimSpace(line), "panic: ") {
+				// The stack trace below a panic is kept whole
+				lines = append(lines, output[i:]...)
+				break
+			}
+			if isFailureLine(line) {
+				lines = append(lines, line)
+			}
+		}
+	}
+
+	max := opts.MaxOutputLines
+	if max <= 0 || len(lines) <= max {
+		return lines
+	}
+	head := (max + 1) / 2
+	tail := max - head
+	truncated := append([]string(nil), lines[:head]...)
+	truncated = append(truncated, fmt.Sprintf("... %d lines omitted (-max-output-lines %d) ...", len(lines)-max, max))
+	return append(truncated, lines[len(lines)-tail:]...)
+}
+
+// writeOutputBlock renders test output as a Go code block
+func writeOutputBlock(sb *strings.Builder, lines []string) {
+	writeCodeBlock(sb, "go", lines)
+}
+
+// writeCodeBlock renders lines as a code block, using a fence longer than any
+// backtick run in the lines so they cannot break out of the block
+func writeCodeBlock(sb *strings.Builder, language string, lines []string) {
+	fence := "```"
+	for _, line := range lines {
+		for strings.Contains(line, fence) {
+			fence += "`"
+		}
+	}
+	sb.WriteString(fence + language + "\n")
+	for _, line := range lines {
+		sb.WriteString(line + "\n")
+	}
+	sb.WriteString(fence + "\n\n")
+}
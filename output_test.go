@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFailureOutput(t *testing.T) {
+	output := []string{
+		"=== RUN   TestDiff",
+		"    diff_test.go:10: setting up fixture",
+		"    diff_test.go:20: Error: Not equal:",
+		"        expected: 1",
+		"        actual  : 2",
+		"--- FAIL: TestDiff (0.00s)",
+	}
+
+	tests := []struct {
+		name     string
+		opts     ReportOptions
+		expected []string
+	}{
+		{"full", ReportOptions{}, output},
+		{"filtered", ReportOptions{FilterOutput: true}, []string{output[2], output[5]}},
+		{"truncated", ReportOptions{MaxOutputLines: 3}, []string{output[0], output[1], "... 3 lines omitted (-max-output-lines 3) ...", output[5]}},
+		{"limit not reached", ReportOptions{MaxOutputLines: 10}, output},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := failureOutput(output, tt.opts)
+			if strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+				t.Errorf("Expected:\n%s\nGot:\n%s", strings.Join(tt.expected, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestWriteOutputBlock(t *testing.T) {
+	var sb strings.Builder
+	writeOutputBlock(&sb, []string{"markdown in output:", "```go", "x := 1", "```"})
+	if !strings.HasPrefix(sb.String(), "````go\n") || !strings.HasSuffix(sb.String(), "\n````\n\n") {
+		t.Errorf("Expected a longer fence around output containing backticks, got:\n%s", sb.String())
+	}
+}
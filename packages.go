@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PackageResult holds the package-level outcome of a test binary
+type PackageResult struct {
+	Name        string
+	Status      string // "PASS", "FAIL", "SKIP"
+	Duration    float64
+	Output      []string // Package-level output, e.g. TestMain output or panics
+	BuildFailed bool
+	BuildOutput []string // Compiler output when the build failed
+	Severity    string   // "P0"-"P3" when a severity file is used
+	ShuffleSeed string   // Seed of -shuffle, empty when the tests ran in order
+	Flags       []string // go test flags the output reveals, e.g. "-shuffle=42"
+}
+
+// packageTracker collects package-level and build events while parsing
+type packageTracker struct {
+	results     map[string]*PackageResult
+	buildOutput map[string][]string // keyed by build ImportPath
+}
+
+func newPackageTracker() *packageTracker {
+	return &packageTracker{
+		results:     make(map[string]*PackageResult),
+		buildOutput: make(map[string][]string),
+	}
+}
+
+func (t *packageTracker) get(name string) *PackageResult {
+	pkg, exists := t.results[name]
+	if !exists {
+		pkg = &PackageResult{Name: name, Status: "UNKNOWN", Output: []string{}}
+		t.results[name] = pkg
+	}
+	return pkg
+}
+
+// handleEvent records an event that is not attributed to a test
+func (t *packageTracker) handleEvent(event TestEvent) {
+	switch event.Action {
+	case "build-output":
+		// Go 1.24+ reports compiler output as separate build events
+		output := trimLineEnding(event.Output)
+		t.buildOutput[event.ImportPath] = append(t.buildOutput[event.ImportPath], output)
+		return
+	case "build-fail":
+		return
+	}
+
+	if event.Package == "" {
+		return
+	}
+	pkg := t.get(event.Package)
+
+	switch event.Action {
+	case "pass":
+		pkg.Status = "PASS"
+		pkg.Duration = event.Elapsed
+	case "fail":
+		pkg.Status = "FAIL"
+		pkg.Duration = event.Elapsed
+		if event.FailedBuild != "" {
+			pkg.BuildFailed = true
+			pkg.BuildOutput = t.buildOutput[event.FailedBuild]
+		}
+	case "skip":
+		pkg.Status = "SKIP"
+		pkg.Duration = event.Elapsed
+	case "output":
+		output := trimLineEnding(event.Output)
+		if output == "" {
+			return
+		}
+		pkg.Output = append(pkg.Output, output)
+		pkg.recordBinaryFlags(output)
+		// Older Go versions only report build failures in the summary line
+		if strings.HasSuffix(output, "[build failed]") || strings.HasSuffix(output, "[setup failed]") {
+			pkg.BuildFailed = true
+		}
+	}
+}
+
+// packageFailures returns the failed packages that are not explained by a
+// failing test, such as build failures, TestMain panics or timeouts, sorted
+// by package name
+func packageFailures(data *ReportData) []*PackageResult {
+	failedTestPackages := make(map[string]bool)
+	for _, result := range data.Results {
+		if result.Status == "FAIL" {
+			failedTestPackages[result.Package] = true
+		}
+	}
+
+	var failures []*PackageResult
+	for _, pkg := range data.Packages {
+		if pkg.Status != "FAIL" {
+			continue
+		}
+		if pkg.BuildFailed || !failedTestPackages[pkg.Name] {
+			failures = append(failures, pkg)
+		}
+	}
+	sort.Slice(failures, func(i, j int) bool {
+		return failures[i].Name < failures[j].Name
+	})
+	return failures
+}
+
+// writePackageFailuresSection renders packages that failed outside of any
+// test, so a broken build does not look like a run with zero tests
+func writePackageFailuresSection(sb *strings.Builder, data *ReportData) {
+	failures := packageFailures(data)
+	if len(failures) == 0 {
+		return
+	}
+
+	sb.WriteString("## Package Failures\n\n")
+	for _, pkg := range failures {
+		reason := "Package failed"
+		output := pkg.Output
+		if pkg.BuildFailed {
+			reason = "Build failed"
+			if len(pkg.BuildOutput) > 0 {
+				output = pkg.BuildOutput
+			}
+		}
+
+		sb.WriteString(fmt.Sprintf("### %s %s\n\n", statusEmoji("FAIL"), escapeMarkdown(pkg.Name)))
+		sb.WriteString(fmt.Sprintf("**%s** after %.2fs\n\n", reason, pkg.Duration))
+		writeShuffleNote(sb, pkg)
+		if len(output) > 0 {
+			writeCodeBlock(sb, "", output)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPackageFailures(t *testing.T) {
+	tests := []struct {
+		name             string
+		jsonInput        string
+		expectedFailures []string
+		expectedSections []string
+	}{
+		{
+			name: "go 1.24 build failure",
+			jsonInput: `
+{"ImportPath":"pkg/broken [pkg/broken.test]","Action":"build-output","Output":"# pkg/broken [pkg/broken.test]\n"}
+{"ImportPath":"pkg/broken [pkg/broken.test]","Action":"build-output","Output":"broken/broken.go:5:2: undefined: foo\n"}
+{"ImportPath":"pkg/broken [pkg/broken.test]","Action":"build-fail"}
+{"Time":"2023-04-01T10:00:00Z","Action":"start","Package":"pkg/broken"}
+{"Time":"2023-04-01T10:00:00Z","Action":"output","Package":"pkg/broken","Output":"FAIL\tpkg/broken [build failed]\n"}
+{"Time":"2023-04-01T10:00:00Z","Action":"fail","Package":"pkg/broken","Elapsed":0,"FailedBuild":"pkg/broken [pkg/broken.test]"}
+`,
+			expectedFailures: []string{"pkg/broken"},
+			expectedSections: []string{
+				"## Package Failures",
+				"### ❌ pkg/broken",
+				"**Build failed**",
+				"broken/broken.go:5:2: undefined: foo",
+				"- **Package Failures:** 1",
+				"Status-FAILED-red",
+			},
+		},
+		{
+			name: "older go build failure summary line",
+			jsonInput: `
+{"Time":"2023-04-01T10:00:00Z","Action":"output","Package":"pkg/old","Output":"FAIL\tpkg/old [build failed]\n"}
+{"Time":"2023-04-01T10:00:00Z","Action":"fail","Package":"pkg/old","Elapsed":0}
+`,
+			expectedFailures: []string{"pkg/old"},
+			expectedSections: []string{"**Build failed**", "FAIL\tpkg/old [build failed]"},
+		},
+		{
+			name: "TestMain panic without failing tests",
+			jsonInput: `
+{"Time":"2023-04-01T10:00:00Z","Action":"output","Package":"pkg/main","Output":"panic: database unavailable\n"}
+{"Time":"2023-04-01T10:00:01Z","Action":"fail","Package":"pkg/main","Elapsed":1.25}
+`,
+			expectedFailures: []string{"pkg/main"},
+			expectedSections: []string{"**Package failed** after 1.25s", "panic: database unavailable"},
+		},
+		{
+			name: "package failing because of a test is not a package failure",
+			jsonInput: `
+{"Time":"2023-04-01T10:00:00Z","Action":"run","Test":"TestFailing","Package":"pkg/example"}
+{"Time":"2023-04-01T10:00:01Z","Action":"fail","Test":"TestFailing","Package":"pkg/example","Elapsed":0.1}
+{"Time":"2023-04-01T10:00:01Z","Action":"fail","Package":"pkg/example","Elapsed":0.2}
+{"Time":"2023-04-01T10:00:01Z","Action":"pass","Package":"pkg/other","Elapsed":0.2}
+`,
+			expectedFailures: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reportData, err := processTestEvents(strings.NewReader(tt.jsonInput))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var names []string
+			for _, pkg := range packageFailures(reportData) {
+				names = append(names, pkg.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.expectedFailures, ",") {
+				t.Errorf("package failures: got %v, want %v", names, tt.expectedFailures)
+			}
+			if reportData.FailedPackages != len(tt.expectedFailures) {
+				t.Errorf("FailedPackages: got %d, want %d", reportData.FailedPackages, len(tt.expectedFailures))
+			}
+
+			markdown := generateMarkdownReport(reportData)
+			for _, section := range tt.expectedSections {
+				if !strings.Contains(markdown, section) {
+					t.Errorf("Expected %q in report", section)
+				}
+			}
+			if len(tt.expectedFailures) == 0 && strings.Contains(markdown, "## Package Failures") {
+				t.Error("Unexpected Package Failures section")
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SourceLinks links repository files to a repository browser, set with -repo-url
+type SourceLinks struct {
+	RepoURL  string // e.g. https://github.com/acme/shop
+	Commit   string // Revision files are shown at
+	Resolver sourceResolver
+}
+
+// fileURL returns the URL of a line of a repository file
+func (s *SourceLinks) fileURL(file string, line int) string {
+	if s == nil || s.RepoURL == "" || file == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/blob/%s/%s#L%d", strings.TrimSuffix(s.RepoURL, "/"), s.Commit, file, line)
+}
+
+// panicFrame is a function of a panicking goroutine's stack
+type panicFrame struct {
+	Function string
+	File     string // Path as printed, absolute on the machine that ran the test
+	Line     int
+}
+
+// panicTrace is a panic with the stack of the goroutine that panicked
+type panicTrace struct {
+	Message string
+	Frames  []panicFrame // Innermost first
+}
+
+// parsePanic returns the first panic in the output with the frames of its
+// goroutine, or nil when the test did not panic
+func parsePanic(output []string) *panicTrace {
+	var trace *panicTrace
+	inStack := false
+	for i := 0; i < len(output); i++ {
+		line := output[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trace == nil:
+			if message, ok := strings.CutPrefix(trimmed, "panic: "); ok {
+				trace = &panicTrace{Message: strings.TrimSuffix(message, " [recovered]")}
+			}
+		case strings.HasPrefix(line, "goroutine "):
+			if inStack {
+				// Only the goroutine that panicked
+				return trace
+			}
+			inStack = true
+		case inStack && line == "":
+			return trace
+		case inStack && i+1 < len(output) && stackFileLine.MatchString(output[i+1]):
+			frame := panicFrame{Function: line}
+			if m := frameLocation.FindStringSubmatch(output[i+1]); m != nil {
+				frame.File = m[1]
+				frame.Line, _ = strconv.Atoi(m[2])
+			} else if file, lineNo, ok := strings.Cut(strings.Fields(output[i+1])[0], ":"); ok {
+				frame.File = file
+				frame.Line, _ = strconv.Atoi(lineNo)
+			}
+			trace.Frames = append(trace.Frames, frame)
+			i++
+		}
+	}
+	return trace
+}
+
+// writePanicSummary renders the panic of a failed test above its output:
+// the message, then the frames outside the hidden packages with the
+// repository's own frames in bold and linked to their source
+func writePanicSummary(sb *strings.Builder, output []string, opts ReportOptions) {
+	trace := parsePanic(output)
+	if trace == nil || len(trace.Frames) == 0 {
+		return
+	}
+	var hide []string
+	if opts.StackFrames != nil {
+		hide = opts.StackFrames.Hide
+	}
+	var resolver sourceResolver
+	if opts.SourceLinks != nil {
+		resolver = opts.SourceLinks.Resolver
+	}
+
+	sb.WriteString(fmt.Sprintf("**Panic:** %s\n\n", codeSpan(trace.Message)))
+	hidden := 0
+	for _, frame := range trace.Frames {
+		if matchFramePackage(hide, framePackage(frame.Function)) != "" {
+			hidden++
+			continue
+		}
+		function := codeSpan(frame.Function)
+		location := codeSpan(fmt.Sprintf("%s:%d", frame.File, frame.Line))
+		if file := resolver.absoluteFile(frame.File); file != "" {
+			function = "**" + function + "**"
+			location = codeSpan(fmt.Sprintf("%s:%d", file, frame.Line))
+			if url := opts.SourceLinks.fileURL(file, frame.Line); url != "" {
+				location = fmt.Sprintf("[%s](%s)", location, url)
+			}
+		}
+		sb.WriteString(fmt.Sprintf("1. %s at %s\n", function, location))
+	}
+	if hidden > 0 {
+		sb.WriteString(fmt.Sprintf("\n<sub>%d frame(s) of hidden packages left out</sub>\n", hidden))
+	}
+	sb.WriteString("\n")
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+var panicOutput = []string{
+	"=== RUN   TestCheckout",
+	"    cart_test.go:5: adding items",
+	"--- FAIL: TestCheckout (0.00s)",
+	"panic: runtime error: index out of range [3] with length 3 [recovered]",
+	"\tpanic: runtime error: index out of range [3] with length 3",
+	"",
+	"goroutine 7 [running]:",
+	"testing.tRunner.func1.2({0x5f3e20, 0xc000018150})",
+	"\t/usr/local/go/src/testing/testing.go:1631 +0x24a",
+	"panic({0x5f3e20?, 0xc000018150?})",
+	"\t/usr/local/go/src/runtime/panic.go:770 +0x132",
+	"github.com/acme/shop/cart.(*Cart).Add(...)",
+	"\t/src/shop/cart/cart.go:12",
+	"github.com/acme/shop/cart.TestCheckout(0xc0001036c0)",
+	"\t/src/shop/cart/cart_test.go:8 +0x18",
+	"testing.tRunner(0xc0001036c0, 0x6a2b18)",
+	"\t/usr/local/go/src/testing/testing.go:1689 +0xfb",
+	"created by testing.(*T).Run in goroutine 1",
+	"\t/usr/local/go/src/testing/testing.go:1742 +0x390",
+	"",
+	"goroutine 1 [chan receive]:",
+	"testing.(*T).Run(0xc000103520, {0x65e1d8?, 0x0?}, 0x6a2b18)",
+	"\t/usr/local/go/src/testing/testing.go:1750 +0x3ab",
+	"exit status 2",
+}
+
+func TestParsePanic(t *testing.T) {
+	trace := parsePanic(panicOutput)
+	if trace == nil {
+		t.Fatal("Expected a panic")
+	}
+	if trace.Message != "runtime error: index out of range [3] with length 3" {
+		t.Errorf("Unexpected message %q", trace.Message)
+	}
+	if len(trace.Frames) != 6 {
+		t.Fatalf("Expected the 6 frames of the panicking goroutine, got %+v", trace.Frames)
+	}
+	if frame := trace.Frames[2]; frame.Function != "github.com/acme/shop/cart.(*Cart).Add(...)" || frame.File != "/src/shop/cart/cart.go" || frame.Line != 12 {
+		t.Errorf("Unexpected frame %+v", frame)
+	}
+	if trace := parsePanic([]string{"    cart_test.go:5: got 1, want 2"}); trace != nil {
+		t.Errorf("Expected no panic, got %+v", trace)
+	}
+}
+
+func TestWritePanicSummary(t *testing.T) {
+	var sb strings.Builder
+	writePanicSummary(&sb, panicOutput, ReportOptions{
+		StackFrames: stackFilter(StackFrameConfig{}),
+		SourceLinks: &SourceLinks{
+			RepoURL:  "https://github.com/acme/shop/",
+			Commit:   "abc123",
+			Resolver: sourceResolver{ModulePath: "github.com/acme/shop", Root: "/src/shop"},
+		},
+	})
+	expected := "**Panic:** `runtime error: index out of range [3] with length 3`\n\n" +
+		"1. **`github.com/acme/shop/cart.(*Cart).Add(...)`** at [`cart/cart.go:12`](https://github.com/acme/shop/blob/abc123/cart/cart.go#L12)\n" +
+		"1. **`github.com/acme/shop/cart.TestCheckout(0xc0001036c0)`** at [`cart/cart_test.go:8`](https://github.com/acme/shop/blob/abc123/cart/cart_test.go#L8)\n" +
+		"\n<sub>4 frame(s) of hidden packages left out</sub>\n\n"
+	if sb.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, sb.String())
+	}
+
+	// Without a repository the frames are shown as printed
+	sb.Reset()
+	writePanicSummary(&sb, panicOutput, ReportOptions{})
+	if !strings.Contains(sb.String(), "1. `testing.tRunner(0xc0001036c0, 0x6a2b18)` at `/usr/local/go/src/testing/testing.go:1689`\n") {
+		t.Errorf("Expected unresolved frames, got:\n%s", sb.String())
+	}
+}
+
+func TestFilteredOutputKeepsPanic(t *testing.T) {
+	got := failureOutput(panicOutput, ReportOptions{FilterOutput: true})
+	expected := append([]string{panicOutput[2]}, panicOutput[3:]...)
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected the stack trace to be kept whole, got:\n%s", strings.Join(got, "\n"))
+	}
+}
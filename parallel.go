@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TimeSpan is a stretch of wall-clock time. An open span has a zero End.
+type TimeSpan struct {
+	Start time.Time
+	End   time.Time
+}
+
+// parallelMaxPaused is the number of tests listed as paused the longest
+const parallelMaxPaused = 10
+
+// PausedSeconds returns how long the test waited for a parallel slot. A
+// pause without a cont event lasts until the test ended.
+func (r *TestResult) PausedSeconds() float64 {
+	var paused time.Duration
+	for _, pause := range r.Pauses {
+		end := pause.End
+		if end.IsZero() {
+			end = r.End
+		}
+		if end.After(pause.Start) {
+			paused += end.Sub(pause.Start)
+		}
+	}
+	return paused.Seconds()
+}
+
+// runningSpans returns when the test actually ran: from its run event to its
+// end, leaving out the time it was paused
+func runningSpans(r *TestResult) []TimeSpan {
+	if r.Start.IsZero() || r.End.Before(r.Start) {
+		return nil
+	}
+	var spans []TimeSpan
+	from := r.Start
+	for _, pause := range r.Pauses {
+		if pause.Start.After(from) {
+			spans = append(spans, TimeSpan{Start: from, End: pause.Start})
+		}
+		if pause.End.IsZero() {
+			return spans
+		}
+		from = pause.End
+	}
+	if r.End.After(from) {
+		spans = append(spans, TimeSpan{Start: from, End: r.End})
+	}
+	return spans
+}
+
+// laneSpan is a stretch of a test running in a swimlane
+type laneSpan struct {
+	Test *TestResult
+	TimeSpan
+}
+
+// assignLanes places the running spans of the tests in as many swimlanes as
+// tests ran at once, each span in the first lane free at its start. With
+// every test parallel, a lane is a slot of go test -parallel, which defaults
+// to GOMAXPROCS.
+func assignLanes(tests []*TestResult) [][]laneSpan {
+	var spans []laneSpan
+	for _, test := range tests {
+		for _, span := range runningSpans(test) {
+			spans = append(spans, laneSpan{Test: test, TimeSpan: span})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		if !spans[i].Start.Equal(spans[j].Start) {
+			return spans[i].Start.Before(spans[j].Start)
+		}
+		return spans[i].Test.Name < spans[j].Test.Name
+	})
+
+	var lanes [][]laneSpan
+	for _, span := range spans {
+		placed := false
+		for i, lane := range lanes {
+			if !lane[len(lane)-1].End.After(span.Start) {
+				lanes[i] = append(lane, span)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			lanes = append(lanes, []laneSpan{span})
+		}
+	}
+	return lanes
+}
+
+// writeParallelismSection shows how much the tests that called t.Parallel
+// overlapped: the most that actually ran at once, the tests that waited the
+// longest for a slot, and a swimlane chart of which tests ran side by side.
+// Runs where no test paused have nothing to show beyond the Timeline.
+func writeParallelismSection(sb *strings.Builder, data *ReportData) {
+	var paused []*TestResult
+	for _, result := range data.Results {
+		if len(result.Pauses) > 0 {
+			paused = append(paused, result)
+		}
+	}
+	if len(paused) == 0 {
+		return
+	}
+	tests, _ := timelineTests(data, len(data.Results))
+	var running []TimeSpan
+	for _, test := range tests {
+		running = append(running, runningSpans(test)...)
+	}
+
+	sort.Slice(paused, func(i, j int) bool {
+		if a, b := paused[i].PausedSeconds(), paused[j].PausedSeconds(); a != b {
+			return a > b
+		}
+		return paused[i].Name < paused[j].Name
+	})
+	var totalPaused float64
+	for _, result := range paused {
+		totalPaused += result.PausedSeconds()
+	}
+
+	sb.WriteString("## Parallelism\n\n")
+	sb.WriteString("<details>\n")
+	sb.WriteString("<summary>Click to expand how the parallel tests overlapped</summary>\n\n")
+	sb.WriteString(fmt.Sprintf("At most %d tests ran at the same time, not counting paused tests. %d tests waited %.2fs in total for a parallel slot.\n\n",
+		peakOverlap(running), len(paused), totalPaused))
+
+	sb.WriteString("| Test | Package | Paused | Duration |\n")
+	sb.WriteString("| ---- | ------- | ------ | -------- |\n")
+	for i, result := range paused {
+		if i == parallelMaxPaused {
+			break
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %.2fs | %.2fs |\n",
+			escapeMarkdown(result.Name), escapeMarkdown(result.Package), result.PausedSeconds(), result.Duration))
+	}
+	sb.WriteString("\n")
+
+	// Beyond timelineMaxTests only the start of the run is drawn, in every slot
+	cutoff := time.Time{}
+	if len(running) > timelineMaxTests {
+		starts := make([]time.Time, len(running))
+		for i, span := range running {
+			starts[i] = span.Start
+		}
+		sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+		cutoff = starts[timelineMaxTests-1]
+	}
+	lines := []string{"gantt", "    dateFormat x", "    axisFormat %H:%M:%S"}
+	drawn := 0
+	for i, lane := range assignLanes(tests) {
+		if !cutoff.IsZero() && lane[0].Start.After(cutoff) {
+			// Lanes open in order, so the later ones start after it too
+			break
+		}
+		lines = append(lines, fmt.Sprintf("    section Slot %d", i+1))
+		for _, span := range lane {
+			if !cutoff.IsZero() && span.Start.After(cutoff) {
+				break
+			}
+			tag := map[string]string{"PASS": "done, ", "FAIL": "crit, "}[span.Test.Status]
+			// Mermaid drops tasks without a length
+			start, end := span.Start.UnixMilli(), max(span.End.UnixMilli(), span.Start.UnixMilli()+1)
+			lines = append(lines, fmt.Sprintf("    %s :%s%d, %d", escapeMermaid(span.Test.Name), tag, start, end))
+			drawn++
+		}
+	}
+	if drawn < len(running) {
+		sb.WriteString(fmt.Sprintf("Showing the first %d of %d running stretches.\n\n", drawn, len(running)))
+	}
+	writeCodeBlock(sb, "mermaid", lines)
+	sb.WriteString("</details>\n\n")
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParallelismSection(t *testing.T) {
+	// TestA runs serially, then TestB and TestC continue in parallel after
+	// pausing at t.Parallel
+	input := `{"Time":"2024-01-01T10:00:00Z","Action":"run","Package":"pkg","Test":"TestB"}
+{"Time":"2024-01-01T10:00:00Z","Action":"pause","Package":"pkg","Test":"TestB"}
+{"Time":"2024-01-01T10:00:00Z","Action":"run","Package":"pkg","Test":"TestC"}
+{"Time":"2024-01-01T10:00:00Z","Action":"pause","Package":"pkg","Test":"TestC"}
+{"Time":"2024-01-01T10:00:00Z","Action":"run","Package":"pkg","Test":"TestA"}
+{"Time":"2024-01-01T10:00:04Z","Action":"pass","Package":"pkg","Test":"TestA","Elapsed":4}
+{"Time":"2024-01-01T10:00:04Z","Action":"cont","Package":"pkg","Test":"TestB"}
+{"Time":"2024-01-01T10:00:04Z","Action":"cont","Package":"pkg","Test":"TestC"}
+{"Time":"2024-01-01T10:00:06Z","Action":"pass","Package":"pkg","Test":"TestB","Elapsed":2}
+{"Time":"2024-01-01T10:00:07Z","Action":"fail","Package":"pkg","Test":"TestC","Elapsed":3}
+`
+	data, err := processTestEvents(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if paused := data.Results[testKey("pkg", "TestB")].PausedSeconds(); paused != 4 {
+		t.Errorf("Expected TestB to be paused for 4s, got %.2f", paused)
+	}
+	if spans := runningSpans(data.Results[testKey("pkg", "TestC")]); len(spans) != 1 || spans[0].End.Sub(spans[0].Start).Seconds() != 3 {
+		t.Errorf("Expected TestC to run for a single 3s stretch, got %v", spans)
+	}
+
+	tests, _ := timelineTests(data, len(data.Results))
+	lanes := assignLanes(tests)
+	if len(lanes) != 2 {
+		t.Fatalf("Expected 2 swimlanes, got %d", len(lanes))
+	}
+	if names := []string{lanes[0][0].Test.Name, lanes[0][1].Test.Name, lanes[1][0].Test.Name}; names[0] != "TestA" || names[1] != "TestB" || names[2] != "TestC" {
+		t.Errorf("Expected TestA then TestB in the first slot and TestC in the second, got %v", names)
+	}
+
+	report := generateMarkdownReport(data)
+	for _, expected := range []string{
+		"## Parallelism",
+		"At most 2 tests ran at the same time, not counting paused tests. 2 tests waited 8.00s in total for a parallel slot.",
+		"| TestB | pkg | 4.00s | 2.00s |",
+		"    section Slot 2\n    TestC :crit, 1704103204000, 1704103207000",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Expected the report to contain %q, got:\n%s", expected, report)
+		}
+	}
+
+	// Without t.Parallel there is nothing to show
+	for _, result := range data.Results {
+		result.Pauses = nil
+	}
+	if report := generateMarkdownReport(data); strings.Contains(report, "## Parallelism") {
+		t.Errorf("Expected no Parallelism section without paused tests, got:\n%s", report)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// gist is the subset of the GitHub gist payload we use
+type gist struct {
+	ID      string `json:"id"`
+	HTMLURL string `json:"html_url"`
+}
+
+// runPost implements `gotest-report post <target>`, which publishes a report
+// somewhere other than a local file
+func runPost(args []string) int {
+	if len(args) == 0 {
+		logger.Errorf("Usage: gotest-report post gist|github-status|gitea-status|harbormaster [flags]")
+		return 2
+	}
+
+	switch args[0] {
+	case "gist":
+		return runPostGist(args[1:])
+	case "github-status":
+		return runPostStatus(args[1:], false)
+	case "gitea-status":
+		return runPostStatus(args[1:], true)
+	case "harbormaster":
+		return runPostHarbormaster(args[1:])
+	default:
+		logger.Errorf("Unknown post target %q (supported: gist, github-status, gitea-status, harbormaster)", args[0])
+		return 2
+	}
+}
+
+// runPostGist uploads the full report to a secret gist and posts the summary
+// with a link to it as the sticky PR comment. This keeps the comment small
+// no matter how large the suite is.
+func runPostGist(args []string) int {
+	fs := flag.NewFlagSet("post gist", flag.ExitOnError)
+	logFlags := registerLogFlags(fs)
+	configFile := registerConfigFlag(fs)
+	var inputFiles stringList
+	fs.Var(&inputFiles, "input", "go test -json output file; repeat or use a glob to merge sharded runs (default is stdin)")
+	fileName := fs.String("gist-file", "test-report.md", "File name of the report inside the gist")
+	description := fs.String("description", "Go test report", "Gist description")
+	githubRepo := fs.String("github-repo", "", "Repository in owner/name form (default is $GITHUB_REPOSITORY)")
+	githubPRNumber := fs.Int("github-pr-number", 0, "Pull request number (default is detected from the GitHub event)")
+	noComment := fs.Bool("no-comment", false, "Only create the gist, do not comment on the PR")
+	fs.Parse(args)
+	if err := logFlags.apply(); err != nil {
+		logger.Errorf("Error: %v", err)
+		return 2
+	}
+
+	_, normalize, err := commandConfig(*configFile)
+	if err != nil {
+		logger.Errorf("Error loading config: %v", err)
+		return 1
+	}
+	reportData, err := loadReports(inputFiles, ParseOptions{Normalize: normalize})
+	if err != nil {
+		logger.Errorf("Error %v", err)
+		return 1
+	}
+
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		logger.Errorf("Error posting gist: GITHUB_TOKEN is not set")
+		return 1
+	}
+	apiURL := os.Getenv("GITHUB_API_URL")
+	if apiURL == "" {
+		apiURL = "https://api.github.com"
+	}
+	client := newGitHubClient(apiURL, token)
+
+	created, err := client.createGist(*description, filepath.Base(*fileName), generateMarkdownReport(reportData))
+	if err != nil {
+		logger.Errorf("Error posting gist: %v", err)
+		return 1
+	}
+	logger.Printf("Report uploaded to %s", created.HTMLURL)
+
+	if *noComment {
+		return 0
+	}
+
+	ghCtx, err := detectGitHubContext(*githubRepo, *githubPRNumber)
+	if err != nil {
+		logger.Errorf("Error detecting GitHub context: %v", err)
+		return 1
+	}
+	if _, err := client.upsertPRComment(ghCtx.Repo, ghCtx.PR, gistCommentBody(reportData, created.HTMLURL)); err != nil {
+		logger.Errorf("Error posting PR comment: %v", err)
+		return 1
+	}
+	logger.Printf("Summary posted to %s#%d", ghCtx.Repo, ghCtx.PR)
+	return 0
+}
+
+// gistCommentBody is the PR comment used when the full report lives in a gist
+func gistCommentBody(data *ReportData, gistURL string) string {
+	body := renderMarkdownReport(data, ReportOptions{SummaryOnly: true})
+	return body + fmt.Sprintf("📄 [View the full report](%s)\n", gistURL)
+}
+
+// createGist uploads a single markdown file as a secret gist
+func (c *GitHubClient) createGist(description, fileName, content string) (*gist, error) {
+	payload := map[string]interface{}{
+		"description": description,
+		"public":      false,
+		"files": map[string]interface{}{
+			fileName: map[string]string{"content": content},
+		},
+	}
+
+	var created gist
+	if err := c.do(http.MethodPost, "/gists", payload, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
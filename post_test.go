@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCreateGist(t *testing.T) {
+	var payload struct {
+		Public bool `json:"public"`
+		Files  map[string]struct {
+			Content string `json:"content"`
+		} `json:"files"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/gists" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		json.NewEncoder(w).Encode(gist{ID: "abc", HTMLURL: "https://gist.github.com/abc"})
+	}))
+	defer server.Close()
+
+	created, err := newGitHubClient(server.URL, "token").createGist("report", "test-report.md", "# Test Summary Report")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if created.HTMLURL != "https://gist.github.com/abc" {
+		t.Errorf("HTMLURL: got %q", created.HTMLURL)
+	}
+	if payload.Public {
+		t.Error("Gist should be secret")
+	}
+	if payload.Files["test-report.md"].Content != "# Test Summary Report" {
+		t.Errorf("Unexpected gist files: %+v", payload.Files)
+	}
+}
+
+func TestGistCommentBody(t *testing.T) {
+	data := &ReportData{
+		TotalTests:      1,
+		FailedTests:     1,
+		SortedTestNames: []string{"TestFailing"},
+		Results: map[string]*TestResult{
+			"TestFailing": {Name: "TestFailing", Status: "FAIL", Output: []string{"--- FAIL: TestFailing"}},
+		},
+	}
+
+	body := gistCommentBody(data, "https://gist.github.com/abc")
+
+	for _, expected := range []string{"## Summary", "Status-FAILED-red", "(https://gist.github.com/abc)"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected %q in comment body", expected)
+		}
+	}
+	for _, unexpected := range []string{"## Test Results", "## Failed Tests Details", "## Test Durations"} {
+		if strings.Contains(body, unexpected) {
+			t.Errorf("Comment body should only contain the summary, found %q", unexpected)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// postgresMigrations are applied in order; each entry is one schema version.
+// Never edit an applied migration, append a new one instead.
+var postgresMigrations = []string{
+	`CREATE TABLE gotest_report_runs (
+		id              BIGSERIAL PRIMARY KEY,
+		timestamp       TIMESTAMPTZ NOT NULL,
+		total           INTEGER NOT NULL,
+		passed          INTEGER NOT NULL,
+		failed          INTEGER NOT NULL,
+		skipped         INTEGER NOT NULL,
+		duration        DOUBLE PRECISION NOT NULL,
+		failed_packages INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX gotest_report_runs_timestamp ON gotest_report_runs (timestamp);
+	CREATE TABLE gotest_report_tests (
+		run_id   BIGINT NOT NULL REFERENCES gotest_report_runs (id) ON DELETE CASCADE,
+		name     TEXT NOT NULL,
+		package  TEXT NOT NULL,
+		status   TEXT NOT NULL,
+		duration DOUBLE PRECISION NOT NULL,
+		PRIMARY KEY (run_id, name)
+	);`,
+	`ALTER TABLE gotest_report_runs ADD COLUMN wall_clock DOUBLE PRECISION NOT NULL DEFAULT 0;`,
+	`ALTER TABLE gotest_report_runs ADD COLUMN environment TEXT NOT NULL DEFAULT '';`,
+	// id is the row key, external_id the -run-id of the run
+	`ALTER TABLE gotest_report_runs ADD COLUMN external_id TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE gotest_report_tests ADD COLUMN fingerprint TEXT NOT NULL DEFAULT '';`,
+	`CREATE TABLE gotest_report_waivers (
+		fingerprint TEXT PRIMARY KEY,
+		test        TEXT NOT NULL DEFAULT '',
+		package     TEXT NOT NULL DEFAULT '',
+		reason      TEXT NOT NULL,
+		accepted_by TEXT NOT NULL,
+		created     TIMESTAMPTZ NOT NULL
+	);`,
+	// Tests of different packages may share a name
+	`ALTER TABLE gotest_report_tests DROP CONSTRAINT gotest_report_tests_pkey, ADD PRIMARY KEY (run_id, package, name);`,
+}
+
+// postgresMigrationLock is the advisory lock key serializing migrations
+// between concurrent pipelines
+const postgresMigrationLock = 0x676f74657374
+
+// PostgresHistory is a history store in PostgreSQL. Every run is a row in
+// gotest_report_runs with its tests in gotest_report_tests, so many
+// pipelines can write concurrently and the results can be queried directly.
+type PostgresHistory struct {
+	db *sql.DB
+}
+
+// openPostgresHistory connects to dsn and applies pending migrations
+func openPostgresHistory(dsn string) (*PostgresHistory, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error opening PostgreSQL history: %v", err)
+	}
+	if err := migratePostgres(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &PostgresHistory{db: db}, nil
+}
+
+// migratePostgres brings the schema up to date, recording applied versions
+// in gotest_report_schema
+func migratePostgres(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("error connecting to PostgreSQL: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, postgresMigrationLock); err != nil {
+		return fmt.Errorf("error locking schema: %v", err)
+	}
+	if _, err := tx.Exec(`CREATE TABLE IF NOT EXISTS gotest_report_schema (version INTEGER PRIMARY KEY)`); err != nil {
+		return fmt.Errorf("error creating schema table: %v", err)
+	}
+	var current int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM gotest_report_schema`).Scan(&current); err != nil {
+		return fmt.Errorf("error reading schema version: %v", err)
+	}
+	for version := current + 1; version <= len(postgresMigrations); version++ {
+		if _, err := tx.Exec(postgresMigrations[version-1]); err != nil {
+			return fmt.Errorf("error applying migration %d: %v", version, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO gotest_report_schema (version) VALUES ($1)`, version); err != nil {
+			return fmt.Errorf("error recording migration %d: %v", version, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Query returns the matching runs, oldest first
+func (p *PostgresHistory) Query(q HistoryQuery) ([]*RunRecord, error) {
+	var conditions []string
+	var args []interface{}
+	if !q.Since.IsZero() {
+		args = append(args, q.Since.UTC())
+		conditions = append(conditions, fmt.Sprintf("timestamp >= $%d", len(args)))
+	}
+	if q.Environment != "" {
+		args = append(args, q.Environment)
+		conditions = append(conditions, fmt.Sprintf("environment = $%d", len(args)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	limit := ""
+	if q.Limit > 0 {
+		args = append(args, q.Limit)
+		limit = fmt.Sprintf("LIMIT $%d", len(args))
+	}
+	return p.queryRuns(where+" ORDER BY timestamp DESC "+limit, args...)
+}
+
+// Get returns the run recorded at timestamp, or nil
+func (p *PostgresHistory) Get(timestamp time.Time) (*RunRecord, error) {
+	runs, err := p.queryRuns("WHERE timestamp = $1 ORDER BY id DESC LIMIT 1", timestamp.UTC().Truncate(time.Microsecond))
+	if err != nil || len(runs) == 0 {
+		return nil, err
+	}
+	return runs[0], nil
+}
+
+// Prune deletes the runs recorded before the given time with their tests
+func (p *PostgresHistory) Prune(before time.Time) (int, error) {
+	result, err := p.db.Exec(`DELETE FROM gotest_report_runs WHERE timestamp < $1`, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("error pruning runs: %v", err)
+	}
+	pruned, err := result.RowsAffected()
+	return int(pruned), err
+}
+
+// queryRuns loads the runs selected by clause, which must order them newest
+// first, and returns them oldest first with their tests
+func (p *PostgresHistory) queryRuns(clause string, args ...interface{}) ([]*RunRecord, error) {
+	rows, err := p.db.Query(`SELECT id, timestamp, total, passed, failed, skipped, duration, wall_clock, environment, external_id, failed_packages
+		FROM gotest_report_runs `+clause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying runs: %v", err)
+	}
+	defer rows.Close()
+
+	var records []*RunRecord
+	byID := make(map[int64]*RunRecord)
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		record := &RunRecord{Tests: make(map[string]TestRecord)}
+		if err := rows.Scan(&id, &record.Timestamp, &record.Total, &record.Passed, &record.Failed,
+			&record.Skipped, &record.Duration, &record.WallClock, &record.Environment, &record.RunID, &record.FailedPackages); err != nil {
+			return nil, fmt.Errorf("error reading run: %v", err)
+		}
+		record.Timestamp = record.Timestamp.UTC()
+		records = append([]*RunRecord{record}, records...)
+		byID[id] = record
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading runs: %v", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	tests, err := p.db.Query(`SELECT run_id, name, package, status, duration, fingerprint
+		FROM gotest_report_tests WHERE run_id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("error querying tests: %v", err)
+	}
+	defer tests.Close()
+	for tests.Next() {
+		var id int64
+		var name string
+		var test TestRecord
+		if err := tests.Scan(&id, &name, &test.Package, &test.Status, &test.Duration, &test.Fingerprint); err != nil {
+			return nil, fmt.Errorf("error reading test: %v", err)
+		}
+		byID[id].Tests[testKey(test.Package, name)] = test
+	}
+	if err := tests.Err(); err != nil {
+		return nil, fmt.Errorf("error reading tests: %v", err)
+	}
+	return records, nil
+}
+
+// Put stores record and its tests in one transaction
+func (p *PostgresHistory) Put(record *RunRecord) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRow(`INSERT INTO gotest_report_runs
+		(timestamp, total, passed, failed, skipped, duration, wall_clock, environment, external_id, failed_packages)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`,
+		record.Timestamp.UTC().Truncate(time.Microsecond), record.Total, record.Passed, record.Failed,
+		record.Skipped, record.Duration, record.WallClock, record.Environment, record.RunID, record.FailedPackages).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("error inserting run: %v", err)
+	}
+
+	// COPY keeps large suites fast
+	stmt, err := tx.Prepare(pq.CopyIn("gotest_report_tests", "run_id", "name", "package", "status", "duration", "fingerprint"))
+	if err != nil {
+		return fmt.Errorf("error preparing test insert: %v", err)
+	}
+	for key, test := range record.Tests {
+		name := recordTestName(key, test)
+		if _, err := stmt.Exec(id, name, test.Package, test.Status, test.Duration, test.Fingerprint); err != nil {
+			stmt.Close()
+			return fmt.Errorf("error inserting test %s: %v", name, err)
+		}
+	}
+	if _, err := stmt.Exec(); err != nil {
+		stmt.Close()
+		return fmt.Errorf("error inserting tests: %v", err)
+	}
+	if err := stmt.Close(); err != nil {
+		return fmt.Errorf("error inserting tests: %v", err)
+	}
+	return tx.Commit()
+}
+
+// Waivers returns the stored waivers, oldest first
+func (p *PostgresHistory) Waivers() ([]Waiver, error) {
+	rows, err := p.db.Query(`SELECT fingerprint, test, package, reason, accepted_by, created
+		FROM gotest_report_waivers ORDER BY created`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying waivers: %v", err)
+	}
+	defer rows.Close()
+	var waivers []Waiver
+	for rows.Next() {
+		var w Waiver
+		if err := rows.Scan(&w.Fingerprint, &w.Test, &w.Package, &w.Reason, &w.By, &w.Created); err != nil {
+			return nil, fmt.Errorf("error reading waiver: %v", err)
+		}
+		w.Created = w.Created.UTC()
+		waivers = append(waivers, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading waivers: %v", err)
+	}
+	return waivers, nil
+}
+
+// PutWaiver stores waiver, replacing one for the same fingerprint
+func (p *PostgresHistory) PutWaiver(waiver Waiver) error {
+	_, err := p.db.Exec(`INSERT INTO gotest_report_waivers (fingerprint, test, package, reason, accepted_by, created)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (fingerprint) DO UPDATE SET test = $2, package = $3, reason = $4, accepted_by = $5, created = $6`,
+		waiver.Fingerprint, waiver.Test, waiver.Package, waiver.Reason, waiver.By, waiver.Created.UTC())
+	if err != nil {
+		return fmt.Errorf("error storing waiver: %v", err)
+	}
+	return nil
+}
+
+// Close releases the database connection
+func (p *PostgresHistory) Close() error {
+	return p.db.Close()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestPostgresHistory runs against a real database when
+// GOTEST_REPORT_POSTGRES_DSN is set, e.g. in a CI service container
+func TestPostgresHistory(t *testing.T) {
+	dsn := os.Getenv("GOTEST_REPORT_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("GOTEST_REPORT_POSTGRES_DSN not set")
+	}
+
+	history, err := openPostgresHistory(dsn)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer history.Close()
+	history.db.Exec(`TRUNCATE gotest_report_runs CASCADE`)
+	history.db.Exec(`TRUNCATE gotest_report_waivers`)
+
+	// Migrating an up-to-date schema is a no-op
+	if err := migratePostgres(history.db); err != nil {
+		t.Fatalf("Unexpected error re-running migrations: %v", err)
+	}
+
+	start := time.Date(2024, 3, 20, 15, 30, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		data := &ReportData{
+			TotalTests:  2,
+			PassedTests: i,
+			Results: map[string]*TestResult{
+				"TestA": {Name: "TestA", Package: "pkg/a", Status: "PASS", Duration: float64(i)},
+			},
+		}
+		if err := history.Put(newRunRecord(data, start.Add(time.Duration(i)*time.Hour))); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	records, err := history.Query(HistoryQuery{Limit: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(records) != 2 || records[0].Passed != 1 || records[1].Passed != 2 {
+		t.Fatalf("Expected the two most recent runs oldest first, got %+v", records)
+	}
+	if test := records[1].Tests["TestA"]; test.Package != "pkg/a" || test.Duration != 2 {
+		t.Errorf("Test records not persisted: %+v", records[1].Tests)
+	}
+	if !records[1].Timestamp.Equal(start.Add(2 * time.Hour)) {
+		t.Errorf("Unexpected timestamp %v", records[1].Timestamp)
+	}
+
+	waiver := Waiver{Fingerprint: "3f2a9c1d0b7e", Test: "TestA", Reason: "upstream outage", By: "alice", Created: start}
+	if err := history.PutWaiver(waiver); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if waivers, err := history.Waivers(); err != nil || len(waivers) != 1 || waivers[0] != waiver {
+		t.Errorf("Waiver not persisted: %+v, %v", waivers, err)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// QuarantineEntry allows a known broken test to fail without failing the run
+type QuarantineEntry struct {
+	Test    string `yaml:"test" json:"test"`                           // Test name or path.Match pattern, e.g. TestFlaky/*
+	Package string `yaml:"package,omitempty" json:"package,omitempty"` // Package pattern, any package when empty
+	Reason  string `yaml:"reason,omitempty" json:"reason,omitempty"`
+	Issue   string `yaml:"issue,omitempty" json:"issue,omitempty"` // Tracking issue or URL
+}
+
+// QuarantineList is the file given with -quarantine
+type QuarantineList struct {
+	Tests []QuarantineEntry `yaml:"tests"`
+}
+
+// loadQuarantine reads and validates a quarantine file
+func loadQuarantine(file string) (*QuarantineList, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var list QuarantineList
+	if err := yaml.Unmarshal(content, &list); err != nil {
+		return nil, fmt.Errorf("error parsing quarantine file: %v", err)
+	}
+	for i, entry := range list.Tests {
+		if entry.Test == "" {
+			return nil, fmt.Errorf("entry %d of the quarantine file has no test", i+1)
+		}
+		for _, pattern := range []string{entry.Test, strings.TrimSuffix(entry.Package, "/...")} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid pattern %q in quarantine file: %v", pattern, err)
+			}
+		}
+	}
+	return &list, nil
+}
+
+// match returns the first entry covering the test, or nil
+func (l *QuarantineList) match(result *TestResult) *QuarantineEntry {
+	for i, entry := range l.Tests {
+		if entry.Package != "" && !matchPackagePattern(entry.Package, result.Package) {
+			continue
+		}
+		if matched, _ := path.Match(entry.Test, result.Name); matched {
+			return &l.Tests[i]
+		}
+	}
+	return nil
+}
+
+// applyQuarantine marks the tests covered by the quarantine list. Subtests
+// of a quarantined test are quarantined with it, and a test failing only
+// through quarantined subtests is quarantined too.
+func applyQuarantine(data *ReportData, list *QuarantineList) {
+	var mark func(result *TestResult, inherited *QuarantineEntry)
+	mark = func(result *TestResult, inherited *QuarantineEntry) {
+		entry := list.match(result)
+		if entry == nil {
+			entry = inherited
+		}
+		result.Quarantine = entry
+		covered := true
+		for _, name := range result.SubTests {
+			sub, ok := data.Results[testKey(result.Package, name)]
+			if !ok {
+				continue
+			}
+			mark(sub, entry)
+			if sub.Status == "FAIL" && sub.Quarantine == nil {
+				covered = false
+			}
+		}
+		if result.Quarantine == nil && result.Status == "FAIL" && covered && ownFailure(result) == "" {
+			for _, name := range result.SubTests {
+				if sub, ok := data.Results[testKey(result.Package, name)]; ok && sub.Status == "FAIL" {
+					result.Quarantine = sub.Quarantine
+					break
+				}
+			}
+		}
+	}
+	for _, name := range data.SortedTestNames {
+		mark(data.Results[name], nil)
+	}
+}
+
+// ownFailure returns the failure message of the test itself, as opposed to
+// the FAIL lines it prints for its failing subtests
+func ownFailure(result *TestResult) string {
+	for _, line := range result.Output {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "=== ") || strings.HasPrefix(trimmed, "--- ") {
+			continue
+		}
+		if strings.Contains(line, "Error") || strings.Contains(line, "panic:") {
+			return trimmed
+		}
+	}
+	return ""
+}
+
+// quarantinedFailures counts failed root tests covered by the quarantine list
+func quarantinedFailures(data *ReportData) int {
+	count := 0
+	for _, result := range data.Results {
+		if !result.IsSubTest && result.Status == "FAIL" && result.Quarantine != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// writeQuarantineSection lists the quarantined tests of the run. Failures
+// are expected; a quarantined test that passed may be ready to leave the
+// list.
+func writeQuarantineSection(sb *strings.Builder, data *ReportData) {
+	var tests []*TestResult
+	for _, result := range data.Results {
+		if result.Quarantine != nil && (result.Status == "FAIL" || !result.IsSubTest) {
+			tests = append(tests, result)
+		}
+	}
+	if len(tests) == 0 {
+		return
+	}
+	sort.Slice(tests, func(i, j int) bool {
+		if tests[i].Package != tests[j].Package {
+			return tests[i].Package < tests[j].Package
+		}
+		return tests[i].Name < tests[j].Name
+	})
+
+	sb.WriteString("## Quarantined Tests\n\n")
+	sb.WriteString(fmt.Sprintf("%d quarantined failure(s) do not fail the run.\n\n", quarantinedFailures(data)))
+	sb.WriteString("| Test | Package | Status | Reason |\n")
+	sb.WriteString("| ---- | ------- | ------ | ------ |\n")
+	for _, result := range tests {
+		reason := escapeMarkdown(result.Quarantine.Reason)
+		if issue := result.Quarantine.Issue; issue != "" {
+			if reason != "" {
+				reason += " "
+			}
+			reason += "(" + escapeMarkdown(issue) + ")"
+		}
+		status := statusEmoji(result.Status) + " " + result.Status
+		if result.Status == "PASS" {
+			status += ", may leave quarantine"
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n",
+			escapeMarkdown(result.Name), escapeMarkdown(result.Package), status, reason))
+	}
+	sb.WriteString("\n")
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const quarantineInput = `{"Action":"run","Package":"pkg/a","Test":"TestFlaky"}
+{"Action":"output","Package":"pkg/a","Test":"TestFlaky","Output":"    a_test.go:10: Error: timed out\n"}
+{"Action":"fail","Package":"pkg/a","Test":"TestFlaky","Elapsed":1}
+{"Action":"run","Package":"pkg/a","Test":"TestTable"}
+{"Action":"run","Package":"pkg/a","Test":"TestTable/known_bug"}
+{"Action":"output","Package":"pkg/a","Test":"TestTable/known_bug","Output":"    a_test.go:20: Error: off by one\n"}
+{"Action":"fail","Package":"pkg/a","Test":"TestTable/known_bug","Elapsed":0.1}
+{"Action":"run","Package":"pkg/a","Test":"TestTable/ok"}
+{"Action":"pass","Package":"pkg/a","Test":"TestTable/ok","Elapsed":0.1}
+{"Action":"output","Package":"pkg/a","Test":"TestTable","Output":"--- FAIL: TestTable (0.20s)\n"}
+{"Action":"fail","Package":"pkg/a","Test":"TestTable","Elapsed":0.2}
+{"Action":"run","Package":"pkg/b","Test":"TestSlow"}
+{"Action":"output","Package":"pkg/b","Test":"TestSlow","Output":"    b_test.go:10: Error: timed out\n"}
+{"Action":"fail","Package":"pkg/b","Test":"TestSlow","Elapsed":1}
+{"Action":"run","Package":"pkg/b","Test":"TestRecovered"}
+{"Action":"pass","Package":"pkg/b","Test":"TestRecovered","Elapsed":0.1}
+`
+
+func writeQuarantineFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "quarantine.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestQuarantine(t *testing.T) {
+	list, err := loadQuarantine(writeQuarantineFile(t, `tests:
+  - test: TestFlaky
+    package: pkg/a
+    reason: Times out on shared runners
+    issue: "#42"
+  - test: TestSlow
+    package: pkg/a
+  - test: TestTable/known_*
+  - test: TestRecovered
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	data, err := processTestEvents(strings.NewReader(quarantineInput))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if reportStatus(data) != "FAILED" {
+		t.Fatalf("Expected the run to fail before applying the quarantine list")
+	}
+	applyQuarantine(data, list)
+
+	tests := []struct {
+		pkg, name   string
+		quarantined bool
+	}{
+		{"pkg/a", "TestFlaky", true},
+		{"pkg/b", "TestSlow", false}, // Quarantined in another package
+		{"pkg/a", "TestTable/known_bug", true},
+		{"pkg/a", "TestTable/ok", false},
+		{"pkg/a", "TestTable", true}, // Fails only through the quarantined subtest
+		{"pkg/b", "TestRecovered", true},
+	}
+	for _, tt := range tests {
+		if got := data.Results[testKey(tt.pkg, tt.name)].Quarantine != nil; got != tt.quarantined {
+			t.Errorf("%s: expected quarantined %v, got %v", tt.name, tt.quarantined, got)
+		}
+	}
+
+	if got := quarantinedFailures(data); got != 2 {
+		t.Errorf("Expected 2 quarantined failures, got %d", got)
+	}
+	if status := reportStatus(data); status != "FAILED" {
+		t.Errorf("Expected the unquarantined failure to fail the run, got %s", status)
+	}
+	data.Results[testKey("pkg/b", "TestSlow")].Quarantine = &QuarantineEntry{Test: "TestSlow"}
+	if status := reportStatus(data); status != "PASSED" {
+		t.Errorf("Expected quarantined failures not to fail the run, got %s", status)
+	}
+	if failed := gateFailures(evaluateGates(data, nil, ExitGates{FailOnFailure: true, MaxSkipped: -1, MaxFlaky: -1})); len(failed) > 0 {
+		t.Errorf("Expected no failed gates, got %v", failed)
+	}
+
+	report := generateMarkdownReport(data)
+	for _, expected := range []string{
+		"- **Quarantined Failures:** 3",
+		"![Status](https://img.shields.io/badge/Status-PASSED-brightgreen)",
+		"## Quarantined Tests",
+		"| TestFlaky | pkg/a | ❌ FAIL | Times out on shared runners (\\#42) |",
+		"| TestRecovered | pkg/b | ✅ PASS, may leave quarantine |  |",
+		"> 🔒 **Quarantined**, does not fail the run: Times out on shared runners",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Expected the report to contain %q, got:\n%s", expected, report)
+		}
+	}
+}
+
+func TestQuarantineReasonEscaped(t *testing.T) {
+	result := &TestResult{Name: "TestA", Fingerprint: "3f2a9c1d0b7e", Quarantine: &QuarantineEntry{Test: "TestA", Reason: "flaky on <b>arm</b> [see](x)"}}
+	var sb strings.Builder
+	writeFailureFingerprint(&sb, result)
+	if expected := "does not fail the run: flaky on &lt;b&gt;arm&lt;/b&gt; \\[see\\](x)\n"; !strings.Contains(sb.String(), expected) {
+		t.Errorf("Expected %q, got:\n%s", expected, sb.String())
+	}
+}
+
+func TestQuarantineOwnFailure(t *testing.T) {
+	// The parent fails on its own too, so only the subtest is quarantined
+	input := `{"Action":"run","Package":"pkg","Test":"TestA"}
+{"Action":"output","Package":"pkg","Test":"TestA","Output":"    a_test.go:5: Error: setup failed\n"}
+{"Action":"run","Package":"pkg","Test":"TestA/sub"}
+{"Action":"fail","Package":"pkg","Test":"TestA/sub","Elapsed":0.1}
+{"Action":"fail","Package":"pkg","Test":"TestA","Elapsed":0.2}
+`
+	data, err := processTestEvents(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	applyQuarantine(data, &QuarantineList{Tests: []QuarantineEntry{{Test: "TestA/sub"}}})
+	if data.Results[testKey("pkg", "TestA")].Quarantine != nil {
+		t.Error("Expected a test with its own failure not to be quarantined")
+	}
+	if reportStatus(data) != "FAILED" {
+		t.Error("Expected the run to still fail")
+	}
+}
+
+func TestLoadQuarantineErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"missing test": "tests:\n  - reason: no test\n",
+		"bad pattern":  "tests:\n  - test: \"Test[\"\n",
+		"invalid yaml": "tests: [",
+	} {
+		if _, err := loadQuarantine(writeQuarantineFile(t, content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
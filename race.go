@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DataRace is a race detector report found in the output of a test
+type DataRace struct {
+	Test     string       `json:"test,omitempty"` // Empty when the race was reported outside a test
+	Package  string       `json:"package"`
+	Accesses []RaceAccess `json:"accesses"` // The racing accesses followed by goroutine creation sites
+	Lines    []string     `json:"report"`   // The full report
+	Count    int          `json:"count"`    // Number of identical reports
+}
+
+// RaceAccess is one section of a race report, e.g. "Previous write at ..."
+type RaceAccess struct {
+	Kind      string `json:"kind"`                // "Read", "Previous write", "Goroutine 7 created", ...
+	Goroutine string `json:"goroutine,omitempty"` // "goroutine 8" or "main goroutine", empty for creation sites
+	Function  string `json:"function"`            // Top stack frame
+	Location  string `json:"location"`            // file:line of the top stack frame
+}
+
+// raceStart opens every race detector report
+const raceStart = "WARNING: DATA RACE"
+
+var (
+	raceSeparator   = regexp.MustCompile(`^={10,}$`)
+	raceAccessLine  = regexp.MustCompile(`^((?:Previous )?(?:[Aa]tomic )?(?:[Rr]ead|[Ww]rite))(?: of size \d+)? at 0x[0-9a-f]+ by (goroutine \d+|main goroutine):$`)
+	raceCreatedLine = regexp.MustCompile(`^Goroutine (\d+) \((?:running|finished)\) created at:$`)
+	raceFrameFile   = regexp.MustCompile(`^(\S+\.(?:go|s)):(\d+)(?: \+0x[0-9a-f]+)?$`)
+)
+
+// parseDataRaces extracts the race reports from output lines
+func parseDataRaces(pkg, test string, output []string) []*DataRace {
+	var races []*DataRace
+	var race *DataRace
+	var access *RaceAccess
+	function := ""
+
+	for _, line := range output {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == raceStart {
+			race = &DataRace{Test: test, Package: pkg, Lines: []string{trimmed}, Count: 1}
+			access, function = nil, ""
+			continue
+		}
+		if race == nil {
+			continue
+		}
+		if raceSeparator.MatchString(trimmed) {
+			races = append(races, race)
+			race = nil
+			continue
+		}
+		race.Lines = append(race.Lines, line)
+
+		if m := raceAccessLine.FindStringSubmatch(trimmed); m != nil {
+			race.Accesses = append(race.Accesses, RaceAccess{Kind: m[1], Goroutine: m[2]})
+			access, function = &race.Accesses[len(race.Accesses)-1], ""
+			continue
+		}
+		if m := raceCreatedLine.FindStringSubmatch(trimmed); m != nil {
+			race.Accesses = append(race.Accesses, RaceAccess{Kind: "Goroutine " + m[1] + " created"})
+			access, function = &race.Accesses[len(race.Accesses)-1], ""
+			continue
+		}
+		if access == nil || trimmed == "" {
+			continue
+		}
+		if m := raceFrameFile.FindStringSubmatch(trimmed); m != nil {
+			if access.Location == "" {
+				access.Function = function
+				access.Location = m[1] + ":" + m[2]
+			}
+			continue
+		}
+		function = trimmed
+	}
+	// A report cut off by the end of the output is still worth showing
+	if race != nil {
+		races = append(races, race)
+	}
+	return races
+}
+
+// key identifies a race by its access locations
+func (r *DataRace) key() string {
+	var parts []string
+	for _, a := range r.Accesses {
+		parts = append(parts, a.Kind+"@"+a.Location)
+	}
+	return strings.Join(parts, "|")
+}
+
+// extractDataRaces finds the race reports of every test and package,
+// merging identical reports
+func extractDataRaces(data *ReportData) []*DataRace {
+	var races []*DataRace
+	byKey := make(map[string]*DataRace)
+	add := func(found []*DataRace) {
+		for _, race := range found {
+			if existing, ok := byKey[race.key()]; ok {
+				existing.Count++
+				continue
+			}
+			byKey[race.key()] = race
+			races = append(races, race)
+		}
+	}
+
+	var names []string
+	for name := range data.Results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result := data.Results[name]
+		add(parseDataRaces(result.Package, result.Name, result.Output))
+	}
+
+	var packages []string
+	for name := range data.Packages {
+		packages = append(packages, name)
+	}
+	sort.Strings(packages)
+	for _, name := range packages {
+		add(parseDataRaces(name, "", data.Packages[name].Output))
+	}
+	return races
+}
+
+// writeDataRacesSection renders every detected data race with the racing
+// accesses up front and the full report collapsed
+func writeDataRacesSection(sb *strings.Builder, races []*DataRace) {
+	if len(races) == 0 {
+		return
+	}
+
+	sb.WriteString("## Data Races\n\n")
+	sb.WriteString(fmt.Sprintf("⚠️ The race detector reported %d distinct data race(s).\n\n", len(races)))
+
+	for i, race := range races {
+		where := "package " + codeSpan(race.Package)
+		if race.Test != "" {
+			where = fmt.Sprintf("%s (%s)", codeSpan(race.Test), escapeMarkdown(race.Package))
+		}
+		sb.WriteString(fmt.Sprintf("### Race %d in %s\n\n", i+1, where))
+		if race.Count > 1 {
+			sb.WriteString(fmt.Sprintf("Reported %d times.\n\n", race.Count))
+		}
+
+		sb.WriteString("| Access | Goroutine | Location | Function |\n")
+		sb.WriteString("| ------ | --------- | -------- | -------- |\n")
+		for _, a := range race.Accesses {
+			kind := a.Kind
+			if a.Goroutine != "" {
+				// Highlight the racing accesses over the creation sites
+				kind = "**" + kind + "**"
+			}
+			location := ""
+			if a.Location != "" {
+				location = codeSpan(filepath.Base(a.Location))
+			}
+			function := ""
+			if a.Function != "" {
+				function = codeSpan(a.Function)
+			}
+			sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", kind, a.Goroutine, location, function))
+		}
+		sb.WriteString("\n")
+
+		sb.WriteString("<details>\n<summary>Full race report</summary>\n\n")
+		writeCodeBlock(sb, "text", race.Lines)
+		sb.WriteString("</details>\n\n")
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+var raceOutput = []string{
+	"=== RUN   TestCounter",
+	"==================",
+	"WARNING: DATA RACE",
+	"Read at 0x00c000014128 by goroutine 8:",
+	"  example.com/counter.(*Counter).Inc()",
+	"      /src/counter/counter.go:12 +0x44",
+	"  example.com/counter.TestCounter.func1()",
+	"      /src/counter/counter_test.go:15 +0x38",
+	"",
+	"Previous write at 0x00c000014128 by goroutine 7:",
+	"  example.com/counter.(*Counter).Inc()",
+	"      /src/counter/counter.go:12 +0x5a",
+	"",
+	"Goroutine 8 (running) created at:",
+	"  example.com/counter.TestCounter()",
+	"      /src/counter/counter_test.go:14 +0x7c",
+	"==================",
+	"    testing.go:1465: race detected during execution of test",
+	"--- FAIL: TestCounter (0.00s)",
+}
+
+func TestParseDataRaces(t *testing.T) {
+	races := parseDataRaces("example.com/counter", "TestCounter", raceOutput)
+	if len(races) != 1 {
+		t.Fatalf("Expected 1 race, got %d", len(races))
+	}
+
+	expected := []RaceAccess{
+		{Kind: "Read", Goroutine: "goroutine 8", Function: "example.com/counter.(*Counter).Inc()", Location: "/src/counter/counter.go:12"},
+		{Kind: "Previous write", Goroutine: "goroutine 7", Function: "example.com/counter.(*Counter).Inc()", Location: "/src/counter/counter.go:12"},
+		{Kind: "Goroutine 8 created", Function: "example.com/counter.TestCounter()", Location: "/src/counter/counter_test.go:14"},
+	}
+	if len(races[0].Accesses) != len(expected) {
+		t.Fatalf("Expected %d accesses, got %+v", len(expected), races[0].Accesses)
+	}
+	for i, access := range expected {
+		if races[0].Accesses[i] != access {
+			t.Errorf("Access %d: expected %+v, got %+v", i, access, races[0].Accesses[i])
+		}
+	}
+	if len(races[0].Lines) != 14 {
+		t.Errorf("Expected the full report to be kept, got %d lines", len(races[0].Lines))
+	}
+}
+
+func TestDataRacesSection(t *testing.T) {
+	data := &ReportData{
+		Results: map[string]*TestResult{
+			"TestCounter":  {Name: "TestCounter", Package: "example.com/counter", Status: "FAIL", Output: raceOutput},
+			"TestCounter2": {Name: "TestCounter2", Package: "example.com/counter", Status: "FAIL", Output: raceOutput},
+			"TestClean":    {Name: "TestClean", Package: "example.com/counter", Status: "PASS", Output: []string{"ok"}},
+		},
+		Packages: map[string]*PackageResult{},
+	}
+	summarizeReport(data, nil)
+	if len(data.DataRaces) != 1 || data.DataRaces[0].Count != 2 {
+		t.Fatalf("Expected identical races to be merged, got %+v", data.DataRaces)
+	}
+
+	report := generateMarkdownReport(data)
+	for _, expected := range []string{
+		"- **Data Races:** 1",
+		"## Data Races",
+		"### Race 1 in `TestCounter` (example.com/counter)",
+		"Reported 2 times.",
+		"| **Read** | goroutine 8 | `counter.go:12` | `example.com/counter.(*Counter).Inc()` |",
+		"| Goroutine 8 created |  | `counter_test.go:14` | `example.com/counter.TestCounter()` |",
+		"Previous write at 0x00c000014128 by goroutine 7:",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Expected %q in report", expected)
+		}
+	}
+}
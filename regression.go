@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultRegressionMinDuration is the duration in seconds below which a
+// slowdown is considered noise
+const defaultRegressionMinDuration = 0.1
+
+// RegressionReport lists the tests that got slower than the baseline
+type RegressionReport struct {
+	Threshold float64          // Allowed slowdown in percent
+	Baseline  string           // Description of what the durations were compared with
+	Tests     []DurationChange // Sorted by slowdown, largest first
+}
+
+// defaultSuiteSlowdownRuns is the number of trailing runs the suite duration
+// is compared with
+const defaultSuiteSlowdownRuns = 10
+
+// SuiteSlowdown is a run whose duration grew beyond the trailing average
+type SuiteSlowdown struct {
+	Metric    string  // "wall clock", or "total test time" for inputs without timestamps
+	Current   float64 // Seconds
+	Average   float64 // Seconds, over the trailing runs
+	Runs      int
+	Threshold float64 // Allowed growth in percent
+}
+
+// Increase returns the growth over the average in percent
+func (s *SuiteSlowdown) Increase() float64 {
+	return (s.Current - s.Average) / s.Average * 100
+}
+
+// String describes the slowdown in one line
+func (s *SuiteSlowdown) String() string {
+	return fmt.Sprintf("%s of %s is %.0f%% above the average of %s over the last %d runs (threshold %s%%)",
+		s.Metric, formatDuration(s.Current), s.Increase(), formatDuration(s.Average), s.Runs,
+		strconv.FormatFloat(s.Threshold, 'f', -1, 64))
+}
+
+// suiteSlowdown compares the wall clock of the run with the average of the
+// last runs of the history, or the summed test durations when the input has
+// no timestamps, and returns nil unless it grew by more than threshold
+// percent. Gradual slowdowns stay below per-test thresholds but add up here.
+func suiteSlowdown(data *ReportData, history []*RunRecord, runs int, threshold float64) *SuiteSlowdown {
+	metric, current := "wall clock", data.WallClock()
+	duration := func(r *RunRecord) float64 { return r.WallClock }
+	if current <= 0 {
+		metric, current = "total test time", data.TotalDuration
+		duration = func(r *RunRecord) float64 { return r.Duration }
+	}
+
+	// Runs recorded before wall clocks were stored have none
+	sum, count := 0.0, 0
+	for i := len(history) - 1; i >= 0 && count < runs; i-- {
+		if d := duration(history[i]); d > 0 {
+			sum += d
+			count++
+		}
+	}
+	if count == 0 || current <= 0 {
+		return nil
+	}
+	slowdown := &SuiteSlowdown{Metric: metric, Current: current, Average: sum / float64(count), Runs: count, Threshold: threshold}
+	if slowdown.Increase() <= threshold {
+		return nil
+	}
+	return slowdown
+}
+
+// writeSuiteSlowdownNote calls out a suite slowdown at the top of the
+// performance sections
+func writeSuiteSlowdownNote(sb *strings.Builder, slowdown *SuiteSlowdown) {
+	if slowdown == nil {
+		return
+	}
+	sb.WriteString("## Suite Slowdown\n\n")
+	sb.WriteString(fmt.Sprintf("> [!WARNING]\n> The %s.\n\n", slowdown))
+}
+
+// parseRegressionThreshold parses a percentage such as "20%" or "20"
+func parseRegressionThreshold(s string) (float64, error) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "%")), 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid duration regression threshold %q, expected a percentage such as 20%%", s)
+	}
+	return value, nil
+}
+
+// reportBaseline returns the durations of the passed tests of a run, keyed
+// as the results are
+func reportBaseline(data *ReportData) map[string]float64 {
+	baseline := make(map[string]float64)
+	for name, result := range data.Results {
+		if result.Status == "PASS" {
+			baseline[name] = result.Duration
+		}
+	}
+	return baseline
+}
+
+// historyBaseline returns the median duration of every test across the
+// passed runs of the history, so a single slow run does not move it
+func historyBaseline(history []*RunRecord) map[string]float64 {
+	durations := make(map[string][]float64)
+	for _, run := range history {
+		for key, test := range run.Tests {
+			if test.Status == "PASS" {
+				durations[key] = append(durations[key], test.Duration)
+			}
+		}
+	}
+
+	baseline := make(map[string]float64, len(durations))
+	for name, values := range durations {
+		sort.Float64s(values)
+		middle := len(values) / 2
+		if len(values)%2 == 0 {
+			baseline[name] = (values[middle-1] + values[middle]) / 2
+		} else {
+			baseline[name] = values[middle]
+		}
+	}
+	return baseline
+}
+
+// durationRegressions returns the passed tests that take at least
+// minDuration seconds and got slower than their baseline by more than
+// threshold percent
+func durationRegressions(baseline map[string]float64, data *ReportData, threshold, minDuration float64) []DurationChange {
+	var regressions []DurationChange
+	for _, name := range sortedResultNames(data) {
+		result := data.Results[name]
+		before, ok := baseline[name]
+		if !ok || before <= 0 || result.Status != "PASS" || result.Duration < minDuration {
+			continue
+		}
+		if result.Duration > before*(1+threshold/100) {
+			regressions = append(regressions, DurationChange{
+				Name: result.Name, Package: result.Package, Old: before, New: result.Duration,
+			})
+		}
+	}
+	sort.SliceStable(regressions, func(i, j int) bool {
+		return regressions[i].Increase() > regressions[j].Increase()
+	})
+	return regressions
+}
+
+// writeRegressionsSection renders the tests that got slower than the baseline
+func writeRegressionsSection(sb *strings.Builder, report *RegressionReport) {
+	if report == nil || len(report.Tests) == 0 {
+		return
+	}
+
+	sb.WriteString("## Performance Regressions\n\n")
+	sb.WriteString(fmt.Sprintf("%d test(s) slower than %s by more than %s%%.\n\n",
+		len(report.Tests), report.Baseline, strconv.FormatFloat(report.Threshold, 'f', -1, 64)))
+	sb.WriteString("| Test | Package | Baseline | Now | Change |\n")
+	sb.WriteString("| ---- | ------- | -------- | --- | ------ |\n")
+	for _, c := range report.Tests {
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | +%.0f%% |\n",
+			escapeMarkdown(c.Name), escapeMarkdown(c.Package), formatDuration(c.Old), formatDuration(c.New), c.Increase()))
+	}
+	sb.WriteString("\n")
+}
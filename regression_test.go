@@ -0,0 +1,123 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRegressionThreshold(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+		err      bool
+	}{
+		{"20%", 20, false},
+		{"12.5", 12.5, false},
+		{" 5 % ", 5, false},
+		{"-1%", 0, true},
+		{"fast", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseRegressionThreshold(tt.input)
+		if (err != nil) != tt.err {
+			t.Errorf("%q: expected error %v, got %v", tt.input, tt.err, err)
+		}
+		if got != tt.expected {
+			t.Errorf("%q: expected %v, got %v", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestHistoryBaseline(t *testing.T) {
+	run := func(tests map[string]TestRecord) *RunRecord { return &RunRecord{Tests: tests} }
+	history := []*RunRecord{
+		run(map[string]TestRecord{testKey("pkg", "TestA"): {Package: "pkg", Status: "PASS", Duration: 1}, testKey("pkg", "TestB"): {Package: "pkg", Status: "PASS", Duration: 2}}),
+		run(map[string]TestRecord{testKey("pkg", "TestA"): {Package: "pkg", Status: "PASS", Duration: 9}, testKey("pkg", "TestB"): {Package: "pkg", Status: "FAIL", Duration: 50}}),
+		run(map[string]TestRecord{testKey("pkg", "TestA"): {Package: "pkg", Status: "PASS", Duration: 2}, testKey("pkg", "TestB"): {Package: "pkg", Status: "PASS", Duration: 4}}),
+	}
+	baseline := historyBaseline(history)
+	if baseline[testKey("pkg", "TestA")] != 2 {
+		t.Errorf("Expected the median of TestA to be 2, got %v", baseline[testKey("pkg", "TestA")])
+	}
+	if baseline[testKey("pkg", "TestB")] != 3 {
+		t.Errorf("Expected failed runs to be ignored and TestB to be 3, got %v", baseline[testKey("pkg", "TestB")])
+	}
+}
+
+func TestDurationRegressions(t *testing.T) {
+	data := &ReportData{Results: map[string]*TestResult{
+		"TestSlow":   {Name: "TestSlow", Package: "pkg", Status: "PASS", Duration: 1.5},
+		"TestSteady": {Name: "TestSteady", Package: "pkg", Status: "PASS", Duration: 1.1},
+		"TestTiny":   {Name: "TestTiny", Package: "pkg", Status: "PASS", Duration: 0.05},
+		"TestFailed": {Name: "TestFailed", Package: "pkg", Status: "FAIL", Duration: 5},
+		"TestNew":    {Name: "TestNew", Package: "pkg", Status: "PASS", Duration: 5},
+	}}
+	summarizeReport(data, nil)
+	baseline := map[string]float64{"TestSlow": 1, "TestSteady": 1, "TestTiny": 0.01, "TestFailed": 1}
+
+	regressions := durationRegressions(baseline, data, 20, defaultRegressionMinDuration)
+	if len(regressions) != 1 || regressions[0].Name != "TestSlow" {
+		t.Fatalf("Expected only TestSlow to regress, got %+v", regressions)
+	}
+
+	var sb strings.Builder
+	writeRegressionsSection(&sb, &RegressionReport{Threshold: 20, Baseline: "the baseline run", Tests: regressions})
+	for _, want := range []string{
+		"## Performance Regressions",
+		"1 test(s) slower than the baseline run by more than 20%.",
+		"| TestSlow | pkg | 1.000s | 1.500s | +50% |",
+	} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("Expected section to contain %q, got:\n%s", want, sb.String())
+		}
+	}
+
+	sb.Reset()
+	writeRegressionsSection(&sb, &RegressionReport{Threshold: 20})
+	if sb.Len() != 0 {
+		t.Errorf("Expected no section without regressions, got:\n%s", sb.String())
+	}
+}
+
+func TestSuiteSlowdown(t *testing.T) {
+	history := []*RunRecord{
+		{Duration: 500, WallClock: 0}, // Recorded before wall clocks were stored
+		{Duration: 400, WallClock: 90},
+		{Duration: 400, WallClock: 100},
+		{Duration: 400, WallClock: 110},
+	}
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	run := func(wallClock float64) *ReportData {
+		return &ReportData{TotalDuration: 480, Start: start, End: start.Add(time.Duration(wallClock * float64(time.Second)))}
+	}
+
+	slowdown := suiteSlowdown(run(125), history, 10, 20)
+	if slowdown == nil || slowdown.Metric != "wall clock" || slowdown.Average != 100 || slowdown.Runs != 3 {
+		t.Fatalf("Expected a wall clock slowdown over 3 runs, got %+v", slowdown)
+	}
+	if got, want := slowdown.String(), "wall clock of 2m05.0s is 25% above the average of 1m40.0s over the last 3 runs (threshold 20%)"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if slowdown := suiteSlowdown(run(115), history, 10, 20); slowdown != nil {
+		t.Errorf("Expected no slowdown within the threshold, got %+v", slowdown)
+	}
+	if slowdown := suiteSlowdown(run(125), history, 1, 20); slowdown != nil {
+		t.Errorf("Expected no slowdown against the last run only, got %+v", slowdown)
+	}
+
+	// Without timestamps the summed test durations are compared
+	slowdown = suiteSlowdown(&ReportData{TotalDuration: 560}, history, 2, 20)
+	if slowdown == nil || slowdown.Metric != "total test time" || slowdown.Average != 400 {
+		t.Errorf("Expected a total test time slowdown, got %+v", slowdown)
+	}
+	if slowdown := suiteSlowdown(run(500), nil, 10, 20); slowdown != nil {
+		t.Errorf("Expected no slowdown without history, got %+v", slowdown)
+	}
+
+	var sb strings.Builder
+	writeSuiteSlowdownNote(&sb, suiteSlowdown(run(125), history, 10, 20))
+	if !strings.Contains(sb.String(), "> [!WARNING]\n> The wall clock of 2m05.0s is 25% above") {
+		t.Errorf("Expected a warning callout, got:\n%s", sb.String())
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ReleaseCriteria are the thresholds of the release readiness profile
+type ReleaseCriteria struct {
+	MinPassRate       float64 // Minimum pass rate in percent
+	MinCoverage       float64 // Minimum statement coverage in percent, 0 disables
+	MaxFlaky          int     // Maximum flaky tests across the history, negative disables
+	SeverityThreshold string  // Failures at or above this severity block the release
+}
+
+// CriterionResult is the evaluation of a single release criterion
+type CriterionResult struct {
+	Name      string `json:"name"`
+	Threshold string `json:"threshold"`
+	Actual    string `json:"actual"`
+	Evaluated bool   `json:"evaluated"` // False when the data needed for the criterion is missing
+	Passed    bool   `json:"passed"`
+}
+
+// ReleaseEvaluation is the go/no-go verdict of the release profile
+type ReleaseEvaluation struct {
+	Criteria []CriterionResult `json:"criteria"`
+	Go       bool              `json:"go"`
+}
+
+// evaluateRelease checks the run against the release criteria. Criteria
+// without the data to evaluate them are reported but do not block.
+func evaluateRelease(data *ReportData, history []*RunRecord, c ReleaseCriteria) *ReleaseEvaluation {
+	eval := &ReleaseEvaluation{}
+	add := func(result CriterionResult) {
+		eval.Criteria = append(eval.Criteria, result)
+	}
+
+	rate := passRate(data)
+	add(CriterionResult{
+		Name:      "Pass rate",
+		Threshold: fmt.Sprintf("≥ %.1f%%", c.MinPassRate),
+		Actual:    fmt.Sprintf("%.1f%%", rate),
+		Evaluated: data.TotalTests > 0,
+		Passed:    rate >= c.MinPassRate,
+	})
+
+	add(CriterionResult{
+		Name:      "Package and build failures",
+		Threshold: "0",
+		Actual:    fmt.Sprintf("%d", data.FailedPackages),
+		Evaluated: true,
+		Passed:    data.FailedPackages == 0,
+	})
+
+	severity := CriterionResult{
+		Name:      "Blocking failures",
+		Threshold: fmt.Sprintf("0 at %s or higher", c.SeverityThreshold),
+		Actual:    "no -severity-file",
+	}
+	if failuresBySeverity(data) != nil {
+		blocking := blockingFailures(data, c.SeverityThreshold)
+		severity.Evaluated = true
+		severity.Actual = fmt.Sprintf("%d", len(blocking))
+		severity.Passed = len(blocking) == 0
+	}
+	add(severity)
+
+	coverage := CriterionResult{
+		Name:      "Statement coverage",
+		Threshold: fmt.Sprintf("≥ %.1f%%", c.MinCoverage),
+		Actual:    "no -coverprofile",
+	}
+	if data.Coverage != nil {
+		coverage.Actual = fmt.Sprintf("%.1f%%", data.Coverage.Percent())
+		coverage.Evaluated = c.MinCoverage > 0
+		coverage.Passed = data.Coverage.Percent() >= c.MinCoverage
+	}
+	if c.MinCoverage <= 0 {
+		coverage.Threshold = "not set"
+	}
+	add(coverage)
+
+	flaky := CriterionResult{
+		Name:      "Flaky tests",
+		Threshold: fmt.Sprintf("≤ %d", c.MaxFlaky),
+		Actual:    "no -history-dir",
+	}
+	if len(history) > 0 {
+		count := len(currentFlakyTests(data, history))
+		flaky.Actual = fmt.Sprintf("%d", count)
+		flaky.Evaluated = c.MaxFlaky >= 0
+		flaky.Passed = count <= c.MaxFlaky
+	}
+	if c.MaxFlaky < 0 {
+		flaky.Threshold = "not set"
+	}
+	add(flaky)
+
+	eval.Go = true
+	for _, criterion := range eval.Criteria {
+		if criterion.Evaluated && !criterion.Passed {
+			eval.Go = false
+		}
+	}
+	return eval
+}
+
+// writeReleaseSection renders the go/no-go verdict and criteria table
+func writeReleaseSection(sb *strings.Builder, eval *ReleaseEvaluation) {
+	if eval == nil {
+		return
+	}
+
+	sb.WriteString("## Release Readiness\n\n")
+	if eval.Go {
+		sb.WriteString("### " + statusEmoji("PASS") + " GO\n\n")
+	} else {
+		sb.WriteString("### " + statusEmoji("FAIL") + " NO-GO\n\n")
+	}
+
+	sb.WriteString("| Criterion | Threshold | Actual | Result |\n")
+	sb.WriteString("| --------- | --------- | ------ | ------ |\n")
+	for _, c := range eval.Criteria {
+		result := "⚪ Not evaluated"
+		if c.Evaluated && c.Passed {
+			result = statusEmoji("PASS") + " Pass"
+		} else if c.Evaluated {
+			result = statusEmoji("FAIL") + " Fail"
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", c.Name, c.Threshold, c.Actual, result))
+	}
+	sb.WriteString("\n")
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvaluateRelease(t *testing.T) {
+	newData := func() *ReportData {
+		return &ReportData{
+			TotalTests:      2,
+			PassedTests:     2,
+			SortedTestNames: []string{"TestA", "TestB"},
+			Results: map[string]*TestResult{
+				"TestA": {Name: "TestA", Status: "PASS"},
+				"TestB": {Name: "TestB", Status: "PASS"},
+			},
+		}
+	}
+	criteria := ReleaseCriteria{MinPassRate: 100, MinCoverage: 80, MaxFlaky: 0, SeverityThreshold: "P1"}
+
+	t.Run("go when every evaluated criterion passes", func(t *testing.T) {
+		eval := evaluateRelease(newData(), nil, criteria)
+		if !eval.Go {
+			t.Errorf("Expected GO, got %+v", eval.Criteria)
+		}
+		for _, c := range eval.Criteria {
+			if c.Name == "Statement coverage" && c.Evaluated {
+				t.Error("Coverage should not be evaluated without a coverprofile")
+			}
+		}
+	})
+
+	t.Run("no-go on low coverage", func(t *testing.T) {
+		data := newData()
+		data.Coverage = &CoverageData{Files: map[string]*FileCoverage{"a.go": {Statements: 10, Covered: 5}}}
+		if evaluateRelease(data, nil, criteria).Go {
+			t.Error("Expected NO-GO with 50% coverage")
+		}
+	})
+
+	t.Run("no-go on flaky tests from history", func(t *testing.T) {
+		history := []*RunRecord{{
+			Timestamp: time.Now(),
+			Total:     2,
+			Tests:     map[string]TestRecord{testKey("", "TestA"): {Status: "FAIL"}, testKey("", "TestB"): {Status: "PASS"}},
+		}}
+		eval := evaluateRelease(newData(), history, criteria)
+		if eval.Go {
+			t.Error("Expected NO-GO with a flaky test")
+		}
+
+		var sb strings.Builder
+		writeReleaseSection(&sb, eval)
+		for _, expected := range []string{"## Release Readiness", "### ❌ NO-GO", "| Flaky tests | ≤ 0 | 1 | ❌ Fail |", "| Statement coverage | ≥ 80.0% | no -coverprofile | ⚪ Not evaluated |"} {
+			if !strings.Contains(sb.String(), expected) {
+				t.Errorf("Expected %q in release section", expected)
+			}
+		}
+	})
+
+	t.Run("no-go on blocking severity failures only", func(t *testing.T) {
+		data := newData()
+		data.Results["TestB"].Status = "FAIL"
+		data.PassedTests, data.FailedTests = 1, 1
+		applySeverities(data, &SeverityMap{Default: "P3", Tests: map[string]string{"TestB": "P0"}})
+
+		relaxed := criteria
+		relaxed.MinPassRate = 50
+		if evaluateRelease(data, nil, relaxed).Go {
+			t.Error("Expected NO-GO with a P0 failure")
+		}
+
+		data.Results["TestB"].Severity = "P3"
+		if !evaluateRelease(data, nil, relaxed).Go {
+			t.Error("Expected GO when only P3 tests fail and the pass rate is met")
+		}
+	})
+}
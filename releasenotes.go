@@ -0,0 +1,182 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// releaseNotesMaxFixed is the number of fixed tests named in release notes
+const releaseNotesMaxFixed = 10
+
+// ReleaseHealth summarizes the test runs recorded between two releases
+type ReleaseHealth struct {
+	From, To string     // Tags or refs the runs are between
+	Runs     int        // Runs recorded after From and up to To
+	Latest   *RunRecord // Last run up to To
+	Baseline *RunRecord // Last run at or before From, nil when there is none
+	Fixed    []string   // Tests failing at From or in between that pass in Latest
+	Coverage *float64   // Statement coverage percent, set with -coverprofile
+}
+
+// gitRefDate returns the committer date of a tag or other git ref
+func gitRefDate(root, ref string) (time.Time, error) {
+	cmd := exec.Command("git", "log", "-1", "--format=%cI", ref)
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error resolving %s: %v", ref, err)
+	}
+	return time.Parse(time.RFC3339, strings.TrimSpace(string(out)))
+}
+
+// releaseHealth returns the health of the runs recorded after from and up to
+// to, or nil when no run was recorded in between. History is oldest first.
+func releaseHealth(history []*RunRecord, from, to time.Time) *ReleaseHealth {
+	health := &ReleaseHealth{}
+	failed := make(map[string]bool)
+	for _, run := range history {
+		switch {
+		case !run.Timestamp.After(from):
+			health.Baseline = run
+		case !run.Timestamp.After(to):
+			health.Runs++
+			health.Latest = run
+			for name, test := range run.Tests {
+				if test.Status == "FAIL" {
+					failed[name] = true
+				}
+			}
+		}
+	}
+	if health.Latest == nil {
+		return nil
+	}
+	if health.Baseline != nil {
+		for name, test := range health.Baseline.Tests {
+			if test.Status == "FAIL" {
+				failed[name] = true
+			}
+		}
+	}
+	for key := range failed {
+		if test := health.Latest.Tests[key]; test.Status == "PASS" {
+			health.Fixed = append(health.Fixed, recordTestName(key, test))
+		}
+	}
+	sort.Strings(health.Fixed)
+	return health
+}
+
+// renderReleaseNotes renders the health as a Markdown fragment for release
+// notes, linking the full report when reportURL is set
+func renderReleaseNotes(health *ReleaseHealth, reportURL string) string {
+	var sb strings.Builder
+	sb.WriteString("### Test Health\n\n")
+	latest := health.Latest
+	rate := fmt.Sprintf("- **Pass rate:** %.1f%% of %d tests", latest.PassRate(), latest.Total)
+	if health.Baseline != nil {
+		rate += fmt.Sprintf(" (%+.1f points since %s)", latest.PassRate()-health.Baseline.PassRate(), health.From)
+	}
+	sb.WriteString(rate + "\n")
+	if health.Coverage != nil {
+		sb.WriteString(fmt.Sprintf("- **Coverage:** %.1f%%\n", *health.Coverage))
+	}
+	sb.WriteString(fmt.Sprintf("- **Test runs since %s:** %d\n", health.From, health.Runs))
+	if len(health.Fixed) > 0 {
+		var names []string
+		for i, name := range health.Fixed {
+			if i == releaseNotesMaxFixed {
+				names = append(names, fmt.Sprintf("and %d more", len(health.Fixed)-releaseNotesMaxFixed))
+				break
+			}
+			names = append(names, codeSpan(name))
+		}
+		sb.WriteString(fmt.Sprintf("- **Fixed failures:** %s\n", strings.Join(names, ", ")))
+	}
+	if latest.Failed > 0 {
+		sb.WriteString(fmt.Sprintf("- **Known failures:** %d\n", latest.Failed))
+	}
+	if reportURL != "" {
+		sb.WriteString(fmt.Sprintf("\n[Full test report](%s)\n", reportURL))
+	}
+	return sb.String()
+}
+
+// runReleaseNotes implements `gotest-report release-notes`, summarizing the
+// test health between two git tags from the run history
+func runReleaseNotes(args []string) int {
+	fs := flag.NewFlagSet("release-notes", flag.ExitOnError)
+	logFlags := registerLogFlags(fs)
+	from := fs.String("from", "", "Tag of the previous release (required)")
+	to := fs.String("to", "HEAD", "Tag or ref of the release")
+	historyDir := fs.String("history-dir", "", "History directory the runs are read from")
+	historyURL := fs.String("history-url", "", "Remote history the runs are read from")
+	environment := fs.String("environment", "", "Only use runs of this environment")
+	coverProfile := fs.String("coverprofile", "", "Coverage profile of the release, for its statement coverage")
+	reportURL := fs.String("report-url", "", "Link to the full report of the release")
+	output := fs.String("output", "", "Write the fragment to this file instead of stdout")
+	fs.Parse(args)
+	if err := logFlags.apply(); err != nil {
+		logger.Errorf("Error: %v", err)
+		return 2
+	}
+
+	store, err := openHistoryStore(*historyDir, *historyURL)
+	if err != nil {
+		logger.Errorf("Error opening history: %v", err)
+		return 1
+	}
+	if store == nil || *from == "" {
+		logger.Errorf("Usage: gotest-report release-notes -from TAG [-to TAG] -history-dir DIR | -history-url URL [-coverprofile FILE] [-report-url URL] [-output FILE]")
+		return 2
+	}
+	defer store.Close()
+
+	root := workspaceResolver().Root
+	fromDate, err := gitRefDate(root, *from)
+	if err != nil {
+		logger.Errorf("Error %v", err)
+		return 1
+	}
+	toDate := time.Now()
+	if *to != "HEAD" {
+		if toDate, err = gitRefDate(root, *to); err != nil {
+			logger.Errorf("Error %v", err)
+			return 1
+		}
+	}
+	history, err := store.Query(HistoryQuery{Environment: *environment})
+	if err != nil {
+		logger.Errorf("Error loading history: %v", err)
+		return 1
+	}
+	health := releaseHealth(history, fromDate, toDate)
+	if health == nil {
+		logger.Errorf("Error: no run recorded between %s and %s", *from, *to)
+		return 1
+	}
+	health.From, health.To = *from, *to
+	if *coverProfile != "" {
+		coverage, err := loadCoverProfile(*coverProfile)
+		if err != nil {
+			logger.Errorf("Error loading coverage profile: %v", err)
+			return 1
+		}
+		percent := coverage.Percent()
+		health.Coverage = &percent
+	}
+
+	notes := renderReleaseNotes(health, *reportURL)
+	if *output == "" {
+		fmt.Print(notes)
+	} else if err := os.WriteFile(*output, []byte(notes), 0o644); err != nil {
+		logger.Errorf("Error writing release notes: %v", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReleaseHealth(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 12, 0, 0, 0, time.UTC) }
+	run := func(d int, passed, failed int, tests map[string]string) *RunRecord {
+		record := &RunRecord{Timestamp: day(d), Total: passed + failed, Passed: passed, Failed: failed, Tests: map[string]TestRecord{}}
+		for name, status := range tests {
+			record.Tests[testKey("", name)] = TestRecord{Status: status}
+		}
+		return record
+	}
+	history := []*RunRecord{
+		run(1, 3, 1, map[string]string{"TestA": "FAIL", "TestB": "PASS"}),
+		run(3, 3, 1, map[string]string{"TestA": "PASS", "TestB": "FAIL"}),
+		run(5, 4, 0, map[string]string{"TestA": "PASS", "TestB": "PASS", "TestC": "PASS"}),
+		run(9, 2, 2, map[string]string{"TestA": "FAIL", "TestB": "FAIL"}),
+	}
+
+	health := releaseHealth(history, day(2), day(6))
+	if health == nil {
+		t.Fatal("Expected runs between the releases")
+	}
+	if health.Runs != 2 || health.Latest != history[2] || health.Baseline != history[0] {
+		t.Errorf("Expected the 2 runs after the baseline, got %+v", health)
+	}
+	if len(health.Fixed) != 2 || health.Fixed[0] != "TestA" || health.Fixed[1] != "TestB" {
+		t.Errorf("Expected TestA and TestB to be fixed, got %v", health.Fixed)
+	}
+	if health := releaseHealth(history, day(10), day(12)); health != nil {
+		t.Errorf("Expected nil without runs in between, got %+v", health)
+	}
+
+	health.From, health.To = "v1.2.0", "v1.3.0"
+	coverage := 81.25
+	health.Coverage = &coverage
+	expected := "### Test Health\n\n" +
+		"- **Pass rate:** 100.0% of 4 tests (+25.0 points since v1.2.0)\n" +
+		"- **Coverage:** 81.2%\n" +
+		"- **Test runs since v1.2.0:** 2\n" +
+		"- **Fixed failures:** `TestA`, `TestB`\n" +
+		"\n[Full test report](https://ci.example.com/report)\n"
+	if got := renderReleaseNotes(health, "https://ci.example.com/report"); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestRunReleaseNotesUsage(t *testing.T) {
+	if code := runReleaseNotes([]string{"-history-dir", t.TempDir()}); code != 2 {
+		t.Errorf("Expected a usage error without -from, got %d", code)
+	}
+}
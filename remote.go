@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// remoteHistoryMaxRuns bounds the size of the remote history document
+	remoteHistoryMaxRuns = 500
+	// remoteHistoryRetries is how often a conflicting write is retried
+	remoteHistoryRetries = 5
+)
+
+// remoteHistoryBackoff is the base delay between conflicting writes
+var remoteHistoryBackoff = 200 * time.Millisecond
+
+// errHistoryConflict is returned when another runner updated the remote
+// history between our read and write
+var errHistoryConflict = errors.New("remote history was modified concurrently")
+
+// remoteHistory is the document stored at the history URL
+type remoteHistory struct {
+	Runs    []*RunRecord `json:"runs"`
+	Waivers []Waiver     `json:"waivers,omitempty"`
+}
+
+// HTTPHistory is a history store kept as a single JSON document behind a
+// URL. Writes use optimistic locking: the document is replaced with a
+// conditional PUT (If-Match on the ETag that was read, or If-None-Match: *
+// when creating it), so any server or object store honoring conditional
+// requests can be shared by concurrent CI runners. A document served without
+// an ETag is replaced unconditionally, and concurrent writers may then lose
+// each other's runs.
+type HTTPHistory struct {
+	URL        string
+	Token      string // Sent as a bearer token when set
+	HTTPClient *http.Client
+
+	warnedUnlocked bool
+}
+
+// newHTTPHistory creates a remote history store, authenticating with
+// $GOTEST_REPORT_HISTORY_TOKEN when it is set
+func newHTTPHistory(url string) *HTTPHistory {
+	return &HTTPHistory{
+		URL:        url,
+		Token:      os.Getenv("GOTEST_REPORT_HISTORY_TOKEN"),
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (h *HTTPHistory) request(method string, body []byte) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, h.URL, reader)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	if h.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.Token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// fetch returns the stored document and its ETag. A missing document is an
+// empty history with the ETag "*", so it is only created if no other writer
+// created it first.
+func (h *HTTPHistory) fetch() (*remoteHistory, string, error) {
+	req, err := h.request(http.MethodGet, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := h.HTTPClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("error fetching remote history: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return &remoteHistory{}, "*", nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, "", fmt.Errorf("remote history returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var doc remoteHistory
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, "", fmt.Errorf("error parsing remote history: %v", err)
+	}
+	return &doc, resp.Header.Get("ETag"), nil
+}
+
+// store replaces the document if it still has the given ETag, or
+// unconditionally without one
+func (h *HTTPHistory) store(doc *remoteHistory, etag string) error {
+	content, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	req, err := h.request(http.MethodPut, content)
+	if err != nil {
+		return err
+	}
+	switch etag {
+	case "*":
+		req.Header.Set("If-None-Match", "*")
+	case "":
+		if !h.warnedUnlocked {
+			logger.Warnf("Remote history at %s has no ETag, writes are not protected against concurrent runners", h.URL)
+			h.warnedUnlocked = true
+		}
+	default:
+		req.Header.Set("If-Match", etag)
+	}
+
+	resp, err := h.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error storing remote history: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusPreconditionFailed || resp.StatusCode == http.StatusConflict {
+		return errHistoryConflict
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("remote history returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// Put appends record
+func (h *HTTPHistory) Put(record *RunRecord) error {
+	return h.update(func(doc *remoteHistory) {
+		doc.Runs = append(doc.Runs, record)
+		if len(doc.Runs) > remoteHistoryMaxRuns {
+			doc.Runs = doc.Runs[len(doc.Runs)-remoteHistoryMaxRuns:]
+		}
+	})
+}
+
+// Get returns the run recorded at timestamp, or nil
+func (h *HTTPHistory) Get(timestamp time.Time) (*RunRecord, error) {
+	doc, _, err := h.fetch()
+	if err != nil {
+		return nil, err
+	}
+	for _, run := range doc.Runs {
+		if run.Timestamp.Equal(timestamp) {
+			return run, nil
+		}
+	}
+	return nil, nil
+}
+
+// Query returns the matching runs, oldest first
+func (h *HTTPHistory) Query(q HistoryQuery) ([]*RunRecord, error) {
+	doc, _, err := h.fetch()
+	if err != nil {
+		return nil, err
+	}
+	return filterRuns(doc.Runs, q), nil
+}
+
+// Prune removes the runs recorded before the given time
+func (h *HTTPHistory) Prune(before time.Time) (int, error) {
+	pruned := 0
+	err := h.update(func(doc *remoteHistory) {
+		var kept []*RunRecord
+		for _, run := range doc.Runs {
+			if run.Timestamp.Before(before) {
+				continue
+			}
+			kept = append(kept, run)
+		}
+		pruned = len(doc.Runs) - len(kept)
+		doc.Runs = kept
+	})
+	return pruned, err
+}
+
+// Waivers returns the waivers stored in the document
+func (h *HTTPHistory) Waivers() ([]Waiver, error) {
+	doc, _, err := h.fetch()
+	if err != nil {
+		return nil, err
+	}
+	return doc.Waivers, nil
+}
+
+// PutWaiver adds waiver to the document, replacing one for the same fingerprint
+func (h *HTTPHistory) PutWaiver(waiver Waiver) error {
+	return h.update(func(doc *remoteHistory) {
+		doc.Waivers = replaceWaiver(doc.Waivers, waiver)
+	})
+}
+
+// Close is a no-op, HTTP history holds no connection
+func (h *HTTPHistory) Close() error {
+	return nil
+}
+
+// update applies change to the stored document, retrying when another
+// runner wins the race
+func (h *HTTPHistory) update(change func(doc *remoteHistory)) error {
+	for attempt := 0; attempt < remoteHistoryRetries; attempt++ {
+		doc, etag, err := h.fetch()
+		if err != nil {
+			return err
+		}
+		change(doc)
+		if err := h.store(doc, etag); err != errHistoryConflict {
+			return err
+		}
+		time.Sleep(time.Duration(attempt+1) * remoteHistoryBackoff)
+	}
+	return errHistoryConflict
+}
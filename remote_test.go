@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// etagServer is an in-memory document store honoring conditional PUTs
+type etagServer struct {
+	mu        sync.Mutex
+	body      []byte
+	version   int
+	conflicts int  // Number of upcoming PUTs to reject as if another writer won
+	noETags   bool // Serve the document without ETags and reject conditional PUTs
+}
+
+func (s *etagServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	etag := fmt.Sprintf(`"v%d"`, s.version)
+
+	switch r.Method {
+	case http.MethodGet:
+		if s.body == nil {
+			http.NotFound(w, r)
+			return
+		}
+		if !s.noETags {
+			w.Header().Set("ETag", etag)
+		}
+		w.Write(s.body)
+	case http.MethodPut:
+		if s.conflicts > 0 {
+			s.conflicts--
+			s.version++
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		if s.noETags && (r.Header.Get("If-Match") != "" || r.Header.Get("If-None-Match") != "") && s.body != nil {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		if match := r.Header.Get("If-Match"); (match != "" && match != etag) || (r.Header.Get("If-None-Match") == "*" && s.body != nil) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		s.body, _ = io.ReadAll(r.Body)
+		s.version++
+	}
+}
+
+func TestHTTPHistory(t *testing.T) {
+	backend := &etagServer{}
+	server := httptest.NewServer(backend)
+	defer server.Close()
+
+	history := newHTTPHistory(server.URL)
+	runs, err := history.Query(HistoryQuery{Limit: 10})
+	if err != nil || len(runs) != 0 {
+		t.Fatalf("Expected an empty history, got %v, %v", runs, err)
+	}
+
+	start := time.Date(2024, 3, 20, 15, 30, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		if i == 2 {
+			backend.conflicts = 1
+		}
+		data := &ReportData{TotalTests: 2, PassedTests: i, Results: map[string]*TestResult{}}
+		if err := history.Put(newRunRecord(data, start.Add(time.Duration(i)*time.Hour))); err != nil {
+			t.Fatalf("Unexpected error saving run %d: %v", i, err)
+		}
+	}
+
+	runs, err = history.Query(HistoryQuery{Limit: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(runs) != 2 || runs[0].Passed != 1 || runs[1].Passed != 2 {
+		t.Errorf("Expected the two most recent runs oldest first, got %+v", runs)
+	}
+}
+
+func TestHTTPHistoryConflict(t *testing.T) {
+	defer func(backoff time.Duration) { remoteHistoryBackoff = backoff }(remoteHistoryBackoff)
+	remoteHistoryBackoff = 0
+
+	backend := &etagServer{conflicts: remoteHistoryRetries}
+	server := httptest.NewServer(backend)
+	defer server.Close()
+
+	err := newHTTPHistory(server.URL).Put(&RunRecord{})
+	if err != errHistoryConflict {
+		t.Errorf("Expected a conflict error after retries, got %v", err)
+	}
+}
+
+func TestHTTPHistoryWithoutETags(t *testing.T) {
+	backend := &etagServer{noETags: true}
+	server := httptest.NewServer(backend)
+	defer server.Close()
+
+	history := newHTTPHistory(server.URL)
+	for i := 0; i < 2; i++ {
+		if err := history.Put(&RunRecord{Passed: i}); err != nil {
+			t.Fatalf("Unexpected error saving run %d: %v", i, err)
+		}
+	}
+	runs, err := history.Query(HistoryQuery{Limit: 10})
+	if err != nil || len(runs) != 2 {
+		t.Errorf("Expected both runs to be stored, got %v, %v", runs, err)
+	}
+	if !history.warnedUnlocked {
+		t.Error("Expected a warning about the unprotected writes")
+	}
+}
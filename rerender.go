@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// loadEnrichedReport reads a report written with -format json
+func loadEnrichedReport(path string) (*JSONReport, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var report JSONReport
+	if err := json.Unmarshal(content, &report); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	switch {
+	case report.SchemaVersion == 0:
+		return nil, fmt.Errorf("%s is not a gotest-report JSON report", path)
+	case report.SchemaVersion > jsonSchemaVersion:
+		return nil, fmt.Errorf("%s has schema version %d, this version reads up to %d", path, report.SchemaVersion, jsonSchemaVersion)
+	}
+	return &report, nil
+}
+
+// reportFromJSON rebuilds the report data from a JSON report, keeping what
+// was added after parsing such as severities, fingerprints and waivers.
+// Coverage blocks are not part of the JSON report, so function coverage
+// cannot be rebuilt.
+func reportFromJSON(report *JSONReport) *ReportData {
+	data := &ReportData{
+		TotalTests:     report.Summary.Total,
+		PassedTests:    report.Summary.Passed,
+		FailedTests:    report.Summary.Failed,
+		SkippedTests:   report.Summary.Skipped,
+		TotalDuration:  report.Summary.Duration,
+		FailedPackages: report.Summary.FailedPackages,
+		Results:        make(map[string]*TestResult),
+		Packages:       make(map[string]*PackageResult),
+		DataRaces:      report.DataRaces,
+		Fuzz:           report.Fuzzing,
+		Verification:   report.Verification,
+		Diagnostics:    report.Diagnostics,
+		RunID:          report.RunID,
+		Git:            report.Git,
+		Env:            report.Env,
+		Suites:         report.Suites,
+	}
+
+	var add func(test *JSONTest, parent string)
+	add = func(test *JSONTest, parent string) {
+		result := &TestResult{
+			Name:        test.Name,
+			Package:     test.Package,
+			Status:      test.Status,
+			Duration:    test.Duration,
+			Output:      test.Output,
+			ParentTest:  parent,
+			IsSubTest:   parent != "",
+			Severity:    test.Severity,
+			Fingerprint: test.Fingerprint,
+			Waiver:      test.Waiver,
+			Quarantine:  test.Quarantine,
+			Category:    test.Category,
+			Doc:         test.Doc,
+			Attachments: test.Attachments,
+			Attempts:    test.Attempts,
+		}
+		if test.Start != nil {
+			result.Start = *test.Start
+		}
+		if test.End != nil {
+			result.End = *test.End
+		}
+		data.Results[testKey(test.Package, test.Name)] = result
+		for _, sub := range test.Subtests {
+			result.SubTests = append(result.SubTests, sub.Name)
+			add(sub, test.Name)
+		}
+	}
+	for _, test := range report.Tests {
+		add(test, "")
+		data.SortedTestNames = append(data.SortedTestNames, testKey(test.Package, test.Name))
+	}
+	data.Start, data.End = timeRange(data)
+
+	for _, pkg := range report.Packages {
+		data.Packages[pkg.Name] = &PackageResult{
+			Name:        pkg.Name,
+			Status:      pkg.Status,
+			Duration:    pkg.Duration,
+			BuildFailed: pkg.BuildFailed,
+			Output:      pkg.Output,
+			BuildOutput: pkg.BuildOutput,
+			Severity:    pkg.Severity,
+			ShuffleSeed: pkg.ShuffleSeed,
+			Flags:       pkg.Flags,
+		}
+	}
+
+	for _, b := range report.Benchmarks {
+		bench := &BenchmarkResult{
+			Name:       b.Name,
+			Package:    b.Package,
+			Procs:      b.Procs,
+			Iterations: b.Iterations,
+			NsPerOp:    b.NsPerOp,
+		}
+		if b.BytesPerOp != nil && b.AllocsPerOp != nil {
+			bench.BytesPerOp, bench.AllocsPerOp, bench.HasMemStats = *b.BytesPerOp, *b.AllocsPerOp, true
+		}
+		// The JSON report keys metrics by unit, so their order is lost
+		for unit, value := range b.Metrics {
+			bench.Metrics = append(bench.Metrics, BenchMetric{Unit: unit, Value: value})
+		}
+		sort.Slice(bench.Metrics, func(i, j int) bool { return bench.Metrics[i].Unit < bench.Metrics[j].Unit })
+		data.Benchmarks = append(data.Benchmarks, bench)
+	}
+
+	if report.Coverage != nil {
+		data.Coverage = &CoverageData{Mode: report.Coverage.Mode, Files: make(map[string]*FileCoverage)}
+		for _, f := range report.Coverage.Files {
+			data.Coverage.Files[f.Name] = &FileCoverage{Name: f.Name, Statements: f.Statements, Covered: f.Covered}
+		}
+	}
+	return data
+}
+
+// runRerender implements `gotest-report rerender`, rendering a report in
+// another format from a stored JSON report without the raw test output
+func runRerender(args []string) int {
+	fs := flag.NewFlagSet("rerender", flag.ExitOnError)
+	logFlags := registerLogFlags(fs)
+	from := fs.String("from", "", "JSON report written with -format json to render again")
+	format := fs.String("format", "markdown", "Format of the output file: markdown, json, html-interactive, html-jenkins, csv, tsv or junit")
+	outputFile := fs.String("output", "", "Output report file (default is test-report.md, .json, .html, .csv, .tsv or .xml by format)")
+	hideSections := fs.String("hide-sections", "", "Comma separated report sections to leave out of the Markdown report")
+	groupByPackage := fs.Bool("group-by-package", false, "Split the Test Results table by package")
+	topDurations := fs.Int("top-durations", defaultTopDurations, "Number of tests listed in the Test Durations section")
+	formatFlags := registerFormatFlags(fs)
+	fs.Parse(args)
+	if err := logFlags.apply(); err != nil {
+		logger.Errorf("Error: %v", err)
+		return 2
+	}
+	if *from == "" || fs.NArg() > 0 {
+		logger.Errorf("Usage: gotest-report rerender -from REPORT.json [-format markdown|json|html-interactive|html-jenkins|csv|tsv|junit] [-output FILE]")
+		return 2
+	}
+
+	switch *format {
+	case "markdown", "json", "html-interactive", "html-jenkins", "csv", "tsv", "junit":
+		if *outputFile == "" {
+			*outputFile = map[string]string{"markdown": "test-report.md", "json": "test-report.json", "html-interactive": "test-report.html", "html-jenkins": "test-report.html", "csv": "test-report.csv", "tsv": "test-report.tsv", "junit": "test-report.xml"}[*format]
+		}
+	default:
+		logger.Errorf("Unsupported -format value %q (supported: markdown, json, html-interactive, html-jenkins, csv, tsv, junit)", *format)
+		return 2
+	}
+	hidden, err := hiddenSections(&Config{}, *hideSections)
+	if err != nil {
+		logger.Errorf("Error: %v", err)
+		return 2
+	}
+	formats, err := resolveFormatOptions(fs, formatFlags, nil)
+	if err != nil {
+		logger.Errorf("Error: %v", err)
+		return 2
+	}
+
+	stored, err := loadEnrichedReport(*from)
+	if err != nil {
+		logger.Errorf("Error reading report: %v", err)
+		return 1
+	}
+	data := reportFromJSON(stored)
+	opts := ReportOptions{
+		BenchSort:      "ns",
+		HiddenSections: hidden,
+		GroupByPackage: *groupByPackage,
+		TopDurations:   *topDurations,
+		Release:        stored.Release,
+		Gates:          stored.QualityGates,
+		Formats:        formats,
+	}
+
+	var report string
+	switch *format {
+	case "markdown":
+		report = renderMarkdownReport(data, opts)
+	case "json":
+		report, err = renderJSONReport(data, opts, time.Now())
+	case "html-interactive":
+		report, err = renderInteractiveHTML(data, opts, time.Now())
+	case "html-jenkins":
+		report, err = renderJenkinsHTML(data, opts, *outputFile, time.Now())
+		if err == nil {
+			_, err = writeJenkinsAssets(*outputFile)
+		}
+	case "csv":
+		report, err = renderCSVReport(data, ',')
+	case "tsv":
+		report, err = renderCSVReport(data, '\t')
+	case "junit":
+		report, err = renderJUnitReport(data)
+	}
+	if err != nil {
+		logger.Errorf("Error rendering report: %v", err)
+		return 1
+	}
+	if err := os.WriteFile(*outputFile, []byte(report), 0o644); err != nil {
+		logger.Errorf("Error writing report: %v", err)
+		return 1
+	}
+	logger.Printf("Report generated successfully: %s", *outputFile)
+	return 0
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportFromJSONRoundTrip(t *testing.T) {
+	input := `{"Time":"2024-03-20T15:30:00Z","Action":"run","Package":"pkg/a","Test":"TestA"}
+{"Time":"2024-03-20T15:30:00Z","Action":"run","Package":"pkg/a","Test":"TestA/case"}
+{"Time":"2024-03-20T15:30:00Z","Action":"output","Package":"pkg/a","Test":"TestA/case","Output":"    a_test.go:10: ::attach file=/tmp/shot.png::\n"}
+{"Time":"2024-03-20T15:30:00Z","Action":"output","Package":"pkg/a","Test":"TestA/case","Output":"    a_test.go:11: Error: boom\n"}
+{"Time":"2024-03-20T15:30:01Z","Action":"fail","Package":"pkg/a","Test":"TestA/case","Elapsed":0.5}
+{"Time":"2024-03-20T15:30:01Z","Action":"fail","Package":"pkg/a","Test":"TestA","Elapsed":0.6}
+{"Time":"2024-03-20T15:30:01Z","Action":"run","Package":"pkg/a","Test":"TestB"}
+{"Time":"2024-03-20T15:30:01Z","Action":"pass","Package":"pkg/a","Test":"TestB","Elapsed":0.1}
+{"Time":"2024-03-20T15:30:02Z","Action":"output","Package":"pkg/a","Output":"BenchmarkParse-8   \t  1000\t  1052 ns/op\t  12.5 MB/s\t  64 B/op\t  2 allocs/op\n"}
+{"Time":"2024-03-20T15:30:02Z","Action":"fail","Package":"pkg/a","Elapsed":0.7}
+`
+	data, err := processTestEvents(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	applySeverities(data, &SeverityMap{Default: "P2"})
+
+	now := time.Date(2024, 3, 20, 16, 0, 0, 0, time.UTC)
+	original, err := renderJSONReport(data, ReportOptions{}, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := loadEnrichedReport(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rebuilt := reportFromJSON(stored)
+	rendered, err := renderJSONReport(rebuilt, ReportOptions{}, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rendered != original {
+		t.Errorf("Expected the rebuilt report to render the same JSON\noriginal:\n%s\nrebuilt:\n%s", original, rendered)
+	}
+
+	markdown := renderMarkdownReport(rebuilt, ReportOptions{BenchSort: "ns", TopDurations: defaultTopDurations})
+	for _, expected := range []string{"#### case", "a_test.go:11: Error: boom", "![shot.png](</tmp/shot.png>)", "BenchmarkParse"} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected the re-rendered Markdown to contain %q, got:\n%s", expected, markdown)
+		}
+	}
+}
+
+func TestLoadEnrichedReportRejectsOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"events.json": `{"Action":"run","Package":"pkg","Test":"TestA"}`,
+		"newer.json":  `{"schema_version": 99}`,
+		"broken.json": `{`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadEnrichedReport(path); err == nil {
+			t.Errorf("Expected %s to be rejected", name)
+		}
+	}
+}
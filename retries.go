@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Attempt is an earlier run of a test that was run again, e.g. by gotestsum
+// --rerun-fails or a re-run shard
+type Attempt struct {
+	Status   string   `json:"status"`
+	Duration float64  `json:"duration"`
+	Output   []string `json:"output,omitempty"`
+}
+
+// ordinal returns 1st, 2nd, 3rd, 4th and so on
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+// retryOutcome describes the attempts of a retried test, e.g. "failed 1st
+// attempt, passed on retry"
+func retryOutcome(result *TestResult) string {
+	attempts := len(result.Attempts) + 1
+	var failed []string
+	for i, attempt := range result.Attempts {
+		if attempt.Status == "FAIL" {
+			failed = append(failed, ordinal(i+1))
+		}
+	}
+	switch {
+	case result.Status == "FAIL" && len(failed) == len(result.Attempts):
+		return fmt.Sprintf("failed all %d attempts", attempts)
+	case result.Status == "PASS" && len(failed) > 0:
+		return fmt.Sprintf("failed %s attempt, passed on retry", strings.Join(failed, ", "))
+	default:
+		return fmt.Sprintf("%s after %d attempts", strings.ToLower(result.Status), attempts)
+	}
+}
+
+// retriedTests returns the tests run more than once, sorted by package and
+// name. A parent only run again for its retried subtests is left out.
+func retriedTests(data *ReportData) []*TestResult {
+	var tests []*TestResult
+	for _, result := range data.Results {
+		if len(result.Attempts) == 0 {
+			continue
+		}
+		retriedSubtest := false
+		walkSubtests(data, result, func(subTest *TestResult) {
+			retriedSubtest = retriedSubtest || len(subTest.Attempts) > 0
+		})
+		if !retriedSubtest {
+			tests = append(tests, result)
+		}
+	}
+	sort.Slice(tests, func(i, j int) bool {
+		if tests[i].Package != tests[j].Package {
+			return tests[i].Package < tests[j].Package
+		}
+		return tests[i].Name < tests[j].Name
+	})
+	return tests
+}
+
+// carryAttempts keeps the runs of previous, which result replaces, as its
+// earliest attempts. Only a run of the same test in the same package is an
+// attempt; a test of another package with the same name is a different test.
+func carryAttempts(result, previous *TestResult) {
+	if result.Package != previous.Package || result.Name != previous.Name {
+		return
+	}
+	attempts := append([]Attempt(nil), previous.Attempts...)
+	attempts = append(attempts, Attempt{Status: previous.Status, Duration: previous.Duration, Output: previous.Output})
+	result.Attempts = append(attempts, result.Attempts...)
+}
+
+// writeRetriedTestsSection lists the tests that were run more than once with
+// the outcome of every attempt and the output of failed attempts
+func writeRetriedTestsSection(sb *strings.Builder, data *ReportData) {
+	tests := retriedTests(data)
+	if len(tests) == 0 {
+		return
+	}
+	sb.WriteString("## Retried Tests\n\n")
+	sb.WriteString("| Test | Package | Attempts | Outcome |\n")
+	sb.WriteString("| ---- | ------- | -------- | ------- |\n")
+	for _, result := range tests {
+		var statuses []string
+		for _, attempt := range result.Attempts {
+			statuses = append(statuses, statusEmoji(attempt.Status)+" "+attempt.Status)
+		}
+		statuses = append(statuses, statusEmoji(result.Status)+" "+result.Status)
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n",
+			escapeMarkdown(result.Name), escapeMarkdown(result.Package), strings.Join(statuses, " → "), retryOutcome(result)))
+	}
+	sb.WriteString("\n")
+
+	var details strings.Builder
+	for _, result := range tests {
+		for i, attempt := range result.Attempts {
+			if attempt.Status != "FAIL" || len(attempt.Output) == 0 {
+				continue
+			}
+			details.WriteString(fmt.Sprintf("**%s**, %s attempt:\n\n", escapeMarkdown(result.Name), ordinal(i+1)))
+			writeOutputBlock(&details, attempt.Output)
+		}
+	}
+	if details.Len() > 0 {
+		sb.WriteString("<details>\n<summary>Output of failed attempts</summary>\n\n")
+		sb.WriteString(details.String())
+		sb.WriteString("</details>\n\n")
+	}
+}
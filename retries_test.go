@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRetriedTests(t *testing.T) {
+	// gotestsum --rerun-fails appends the re-run to the same output
+	input := `{"Action":"run","Package":"api","Test":"TestLogin"}
+{"Action":"run","Package":"api","Test":"TestLogin/expired"}
+{"Action":"output","Package":"api","Test":"TestLogin/expired","Output":"    login_test.go:12: connection reset\n"}
+{"Action":"fail","Package":"api","Test":"TestLogin/expired","Elapsed":0.2}
+{"Action":"fail","Package":"api","Test":"TestLogin","Elapsed":0.3}
+{"Action":"run","Package":"api","Test":"TestLogin"}
+{"Action":"run","Package":"api","Test":"TestLogin/expired"}
+{"Action":"output","Package":"api","Test":"TestLogin/expired","Output":"    login_test.go:14: retrying\n"}
+{"Action":"pass","Package":"api","Test":"TestLogin/expired","Elapsed":0.1}
+{"Action":"pass","Package":"api","Test":"TestLogin","Elapsed":0.1}
+`
+	data, err := processTestEvents(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := data.Results[testKey("api", "TestLogin/expired")]
+	if sub.Status != "PASS" || len(sub.Attempts) != 1 || sub.Attempts[0].Status != "FAIL" || sub.Attempts[0].Duration != 0.2 {
+		t.Fatalf("Expected a failed attempt before the pass, got %+v", sub)
+	}
+	if strings.Join(sub.Output, "\n") != "    login_test.go:14: retrying" || strings.Join(sub.Attempts[0].Output, "\n") != "    login_test.go:12: connection reset" {
+		t.Errorf("Expected the output to be split by attempt, got %q and %q", sub.Output, sub.Attempts[0].Output)
+	}
+	if data.PassedTests != 1 || data.FailedTests != 0 {
+		t.Errorf("Expected the final status to count, got %d passed, %d failed", data.PassedTests, data.FailedTests)
+	}
+	if retried := retriedTests(data); len(retried) != 1 || retried[0] != sub {
+		t.Errorf("Expected only the retried subtest to be listed, got %+v", retried)
+	}
+
+	var sb strings.Builder
+	writeRetriedTestsSection(&sb, data)
+	for _, expected := range []string{
+		"| TestLogin/expired | api | ❌ FAIL → ✅ PASS | failed 1st attempt, passed on retry |\n",
+		"**TestLogin/expired**, 1st attempt:\n\n```go\n    login_test.go:12: connection reset\n```",
+	} {
+		if !strings.Contains(sb.String(), expected) {
+			t.Errorf("Expected the section to contain %q, got:\n%s", expected, sb.String())
+		}
+	}
+}
+
+func TestSameNameInPackagesNotRetried(t *testing.T) {
+	// go test ./... runs the packages in parallel, interleaving their events
+	input := `{"Action":"run","Package":"ex/a","Test":"TestNew"}
+{"Action":"run","Package":"ex/b","Test":"TestNew"}
+{"Action":"output","Package":"ex/a","Test":"TestNew","Output":"    a_test.go:5: Error: boom\n"}
+{"Action":"fail","Package":"ex/a","Test":"TestNew","Elapsed":0.1}
+{"Action":"pass","Package":"ex/b","Test":"TestNew","Elapsed":0.1}
+{"Action":"run","Package":"ex/b","Test":"TestOther"}
+{"Action":"pass","Package":"ex/b","Test":"TestOther","Elapsed":0.1}
+`
+	data, err := processTestEvents(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retried := retriedTests(data); len(retried) != 0 {
+		t.Errorf("Expected no retried tests, got %+v", retried[0])
+	}
+	if failed := data.Results[testKey("ex/a", "TestNew")]; failed.Status != "FAIL" || len(failed.Output) != 1 {
+		t.Errorf("Expected TestNew of ex/a to fail with its output, got %+v", failed)
+	}
+	if data.TotalTests != 3 || data.FailedTests != 1 {
+		t.Errorf("Expected 3 tests with 1 failure, got %d with %d failures", data.TotalTests, data.FailedTests)
+	}
+
+	previous := &TestResult{Name: "TestNew", Package: "ex/a", Status: "FAIL"}
+	result := &TestResult{Name: "TestNew", Package: "ex/b", Status: "PASS"}
+	if carryAttempts(result, previous); len(result.Attempts) != 0 {
+		t.Errorf("Expected a test of another package not to be an attempt, got %+v", result.Attempts)
+	}
+}
+
+func TestRetriedShards(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "shard.json")
+	rerun := filepath.Join(dir, "shard-rerun.json")
+	os.WriteFile(first, []byte(`{"Action":"fail","Package":"db","Test":"TestMigrate","Elapsed":1}`+"\n"), 0644)
+	os.WriteFile(rerun, []byte(`{"Action":"fail","Package":"db","Test":"TestMigrate","Elapsed":2}`+"\n"), 0644)
+
+	data, err := loadReports([]string{first, rerun}, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := data.Results[testKey("db", "TestMigrate")]
+	if len(result.Attempts) != 1 || result.Duration != 2 {
+		t.Fatalf("Expected the first shard's run as an attempt, got %+v", result)
+	}
+	if outcome := retryOutcome(result); outcome != "failed all 2 attempts" {
+		t.Errorf("Unexpected outcome %q", outcome)
+	}
+}
+
+func TestOrdinal(t *testing.T) {
+	for n, expected := range map[int]string{1: "1st", 2: "2nd", 3: "3rd", 4: "4th", 11: "11th", 12: "12th", 21: "21st", 102: "102nd"} {
+		if got := ordinal(n); got != expected {
+			t.Errorf("ordinal(%d) = %q, want %q", n, got, expected)
+		}
+	}
+}
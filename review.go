@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// reviewCommentMarker identifies the review comments posted by gotest-report
+// so re-runs do not post the same failure twice
+const reviewCommentMarker = "<!-- gotest-report review -->"
+
+// hunkHeader matches a hunk of a unified diff, capturing the first line and
+// line count on the new side, e.g. "@@ -10,6 +12,8 @@ func TestA"
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// prFile is the subset of a file of the PR diff we use
+type prFile struct {
+	Filename string `json:"filename"`
+	Patch    string `json:"patch"` // Missing for binary and very large diffs
+}
+
+// reviewComment is a comment of a PR review on a line of the new version of
+// a file
+type reviewComment struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Side string `json:"side"`
+	Body string `json:"body"`
+}
+
+// patchLines returns the lines of the new version of a file shown in its
+// diff, the only lines review comments can be placed on
+func patchLines(patch string) map[int]bool {
+	lines := make(map[int]bool)
+	next := 0
+	for _, line := range strings.Split(patch, "\n") {
+		if m := hunkHeader.FindStringSubmatch(line); m != nil {
+			next, _ = strconv.Atoi(m[1])
+			continue
+		}
+		if next == 0 || line == "" {
+			continue
+		}
+		switch line[0] {
+		case '+', ' ':
+			lines[next] = true
+			next++
+		}
+	}
+	return lines
+}
+
+// listPRFiles returns the files changed by the PR
+func (c *GitHubClient) listPRFiles(repo string, pr int) ([]prFile, error) {
+	const perPage = 100
+	var all []prFile
+	for page := 1; ; page++ {
+		var files []prFile
+		path := fmt.Sprintf("/repos/%s/pulls/%d/files?per_page=%d&page=%d", repo, pr, perPage, page)
+		if err := c.do(http.MethodGet, path, nil, &files); err != nil {
+			return nil, err
+		}
+		all = append(all, files...)
+		if len(files) < perPage {
+			return all, nil
+		}
+	}
+}
+
+// listReviewComments returns the review comments on the PR diff
+func (c *GitHubClient) listReviewComments(repo string, pr int) ([]reviewComment, error) {
+	const perPage = 100
+	var all []reviewComment
+	for page := 1; ; page++ {
+		var comments []reviewComment
+		path := fmt.Sprintf("/repos/%s/pulls/%d/comments?per_page=%d&page=%d", repo, pr, perPage, page)
+		if err := c.do(http.MethodGet, path, nil, &comments); err != nil {
+			return nil, err
+		}
+		all = append(all, comments...)
+		if len(comments) < perPage {
+			return all, nil
+		}
+	}
+}
+
+// reviewComments returns a comment for every failure located on a line of
+// the PR diff. Failures elsewhere are left to the summary comment.
+func reviewComments(annotations []Annotation, files []prFile) []reviewComment {
+	changed := make(map[string]map[int]bool)
+	for _, file := range files {
+		changed[file.Filename] = patchLines(file.Patch)
+	}
+	var comments []reviewComment
+	for _, a := range annotations {
+		if a.File == "" || !changed[a.File][a.Line] {
+			continue
+		}
+		var body strings.Builder
+		body.WriteString(reviewCommentMarker + "\n")
+		body.WriteString(fmt.Sprintf("%s %s failed here:\n\n", statusEmoji("FAIL"), codeSpan(a.Title)))
+		writeCodeBlock(&body, "", strings.Split(a.Message, "\n"))
+		comments = append(comments, reviewComment{Path: a.File, Line: a.Line, Side: "RIGHT", Body: strings.TrimSuffix(body.String(), "\n")})
+	}
+	return comments
+}
+
+// postReview posts the failures on lines of the PR diff as a review, leaving
+// out comments an earlier run already posted. It returns the number of
+// comments posted.
+func (c *GitHubClient) postReview(repo string, pr int, annotations []Annotation) (int, error) {
+	files, err := c.listPRFiles(repo, pr)
+	if err != nil {
+		return 0, err
+	}
+	existing, err := c.listReviewComments(repo, pr)
+	if err != nil {
+		return 0, err
+	}
+	posted := make(map[reviewComment]bool)
+	for _, comment := range existing {
+		if strings.HasPrefix(comment.Body, reviewCommentMarker) {
+			posted[reviewComment{Path: comment.Path, Line: comment.Line, Side: "RIGHT", Body: comment.Body}] = true
+		}
+	}
+	var comments []reviewComment
+	for _, comment := range reviewComments(annotations, files) {
+		if !posted[comment] {
+			comments = append(comments, comment)
+		}
+	}
+	if len(comments) == 0 {
+		return 0, nil
+	}
+	payload := map[string]interface{}{
+		"event":    "COMMENT",
+		"body":     fmt.Sprintf("%d test failure(s) on lines changed by this PR.", len(comments)),
+		"comments": comments,
+	}
+	if err := c.do(http.MethodPost, fmt.Sprintf("/repos/%s/pulls/%d/reviews", repo, pr), payload, nil); err != nil {
+		return 0, err
+	}
+	return len(comments), nil
+}
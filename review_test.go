@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const reviewPatch = "@@ -10,4 +10,5 @@ func TestLogin(t *testing.T) {\n" +
+	" \tresp := login(t)\n" +
+	"-\tif resp.Code != 200 {\n" +
+	"+\tif resp.Code != http.StatusOK {\n" +
+	"+\t\tt.Log(resp.Body)\n" +
+	" \t\tt.Fatalf(\"got %d\", resp.Code)\n" +
+	" \t}\n" +
+	"@@ -40,2 +41,3 @@\n" +
+	" func helper() {}\n" +
+	"+// added\n"
+
+func TestPatchLines(t *testing.T) {
+	lines := patchLines(reviewPatch)
+	for _, line := range []int{10, 11, 12, 13, 14, 41, 42} {
+		if !lines[line] {
+			t.Errorf("Expected line %d to be part of the diff", line)
+		}
+	}
+	if lines[15] || lines[40] || len(lines) != 7 {
+		t.Errorf("Unexpected diff lines %v", lines)
+	}
+}
+
+func TestPostReview(t *testing.T) {
+	annotations := []Annotation{
+		{File: "auth/login_test.go", Line: 13, Title: "TestLogin", Message: "got 500"},
+		{File: "auth/login_test.go", Line: 12, Title: "TestLogin/admin", Message: "got 403"},
+		{File: "auth/login_test.go", Line: 80, Title: "TestLogout", Message: "outside the diff"},
+		{File: "db/db_test.go", Line: 5, Title: "TestQuery", Message: "file not changed"},
+		{Title: "TestNoLocation", Message: "no file"},
+	}
+	files := []prFile{{Filename: "auth/login_test.go", Patch: reviewPatch}, {Filename: "README.md", Patch: "@@ -1 +1 @@\n-a\n+b"}}
+	already := reviewComments(annotations[1:2], files)
+
+	var posted map[string]json.RawMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/files"):
+			json.NewEncoder(w).Encode(files)
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/comments"):
+			json.NewEncoder(w).Encode(already)
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/owner/repo/pulls/5/reviews":
+			json.NewDecoder(r.Body).Decode(&posted)
+			w.Write([]byte("{}"))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	count, err := newGitHubClient(server.URL, "token").postReview("owner/repo", 5, annotations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var comments []reviewComment
+	json.Unmarshal(posted["comments"], &comments)
+	if count != 1 || len(comments) != 1 {
+		t.Fatalf("Expected only the new failure on a diff line to be posted, got %d: %+v", count, comments)
+	}
+	expected := reviewComment{Path: "auth/login_test.go", Line: 13, Side: "RIGHT",
+		Body: reviewCommentMarker + "\n❌ `TestLogin` failed here:\n\n```\ngot 500\n```\n"}
+	if comments[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, comments[0])
+	}
+	if string(posted["event"]) != `"COMMENT"` {
+		t.Errorf("Expected a comment-only review, got %s", posted["event"])
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// runIDEnv passes a run ID to every gotest-report invocation of a pipeline
+const runIDEnv = "GOTEST_REPORT_RUN_ID"
+
+// runIDPlaceholder is replaced with the run ID in output paths
+const runIDPlaceholder = "{run_id}"
+
+// newRunID returns a random version 4 UUID
+func newRunID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("error generating run ID: %v", err))
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// resolveRunID returns the run ID given with -run-id, else the one in
+// $GOTEST_REPORT_RUN_ID, else a new one
+func resolveRunID(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if id := os.Getenv(runIDEnv); id != "" {
+		return id
+	}
+	return newRunID()
+}
+
+// expandRunID replaces the {run_id} placeholder in an output path
+func expandRunID(path, runID string) string {
+	return strings.ReplaceAll(path, runIDPlaceholder, runID)
+}
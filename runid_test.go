@@ -0,0 +1,69 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveRunID(t *testing.T) {
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	t.Setenv(runIDEnv, "")
+	first, second := resolveRunID(""), resolveRunID("")
+	if !uuid.MatchString(first) || first == second {
+		t.Errorf("Expected distinct version 4 UUIDs, got %s and %s", first, second)
+	}
+
+	t.Setenv(runIDEnv, "pipeline-42")
+	if id := resolveRunID(""); id != "pipeline-42" {
+		t.Errorf("Expected the run ID from $%s, got %s", runIDEnv, id)
+	}
+	if id := resolveRunID("given"); id != "given" {
+		t.Errorf("Expected -run-id to take precedence, got %s", id)
+	}
+
+	if path := expandRunID("reports/test-report-{run_id}.md", "abc"); path != "reports/test-report-abc.md" {
+		t.Errorf("Unexpected expanded path %s", path)
+	}
+}
+
+func TestRunIDInOutputs(t *testing.T) {
+	data, err := processTestEvents(strings.NewReader(`{"Action":"run","Package":"pkg","Test":"TestA"}
+{"Action":"fail","Package":"pkg","Test":"TestA","Elapsed":0.1}
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data.RunID = "run/7"
+
+	if report := generateMarkdownReport(data); !strings.Contains(report, "Run ID: `run/7`") {
+		t.Errorf("Expected the run ID in the Markdown report, got:\n%s", report)
+	}
+	if report, _ := renderJSONReport(data, ReportOptions{}, time.Now()); !strings.Contains(report, `"run_id": "run/7"`) {
+		t.Errorf("Expected the run ID in the JSON report, got:\n%s", report)
+	}
+	if entry := runFeedEntry(data, nil, "", time.Now()); entry.ID != "urn:gotest-report:run:run/7" {
+		t.Errorf("Expected the feed entry to be identified by the run ID, got %s", entry.ID)
+	}
+	if text := buildSlackMessage(data, nil, "").Blocks[1].Fields; !strings.Contains(text[len(text)-1].Text, "run/7") {
+		t.Errorf("Expected the run ID in the Slack message, got %+v", text)
+	}
+
+	dir := t.TempDir()
+	record := newRunRecord(data, time.Date(2024, 3, 20, 10, 0, 0, 0, time.UTC))
+	if name := runFileName(record); name != "run-20240320T100000.000000000Z-run_7.json" {
+		t.Errorf("Expected the run ID in the history file name, got %s", name)
+	}
+	if err := saveRunRecord(dir, record); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	runs, err := loadHistory(dir, 0)
+	if err != nil || len(runs) != 1 || runs[0].RunID != "run/7" {
+		t.Errorf("Expected the run ID in the stored record, got %+v, %v", runs, err)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*")); len(matches) != 1 {
+		t.Errorf("Expected a single run file, got %v", matches)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// sampleFixtures maps -sample names to the lint fixture rendered for them
+var sampleFixtures = map[string]string{
+	"small":    "passing",
+	"large":    "huge-suite",
+	"failures": "failing",
+}
+
+// sampleReport returns built-in synthetic report data, so formatting changes
+// can be previewed without a real test run
+func sampleReport(name string) (*ReportData, error) {
+	fixture, ok := sampleFixtures[name]
+	if !ok {
+		var names []string
+		for n := range sampleFixtures {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unsupported sample %q (supported: %s)", name, strings.Join(names, ", "))
+	}
+	for _, f := range lintFixtures() {
+		if f.Name == fixture {
+			return f.Data, nil
+		}
+	}
+	return nil, fmt.Errorf("missing fixture %q", fixture)
+}
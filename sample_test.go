@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestSampleReport(t *testing.T) {
+	tests := []struct {
+		name   string
+		failed bool
+	}{
+		{"small", false},
+		{"large", true},
+		{"failures", true},
+	}
+	for _, tt := range tests {
+		data, err := sampleReport(tt.name)
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", tt.name, err)
+		}
+		if data.TotalTests == 0 {
+			t.Errorf("Expected sample %s to have tests", tt.name)
+		}
+		if (data.FailedTests > 0) != tt.failed {
+			t.Errorf("Expected sample %s failed=%v, got %d failures", tt.name, tt.failed, data.FailedTests)
+		}
+	}
+
+	if _, err := sampleReport("medium"); err == nil {
+		t.Error("Expected an error for an unknown sample")
+	}
+}
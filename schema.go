@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// schemaDocuments are the machine readable formats described by the schema
+// subcommand, keyed by name
+var schemaDocuments = map[string]struct {
+	title string
+	value interface{}
+}{
+	"report":  {"gotest-report JSON report (-format json)", JSONReport{}},
+	"event":   {"go test -json event read from the input", TestEvent{}},
+	"history": {"History run record (-history-dir run files and remote history)", RunRecord{}},
+	"waivers": {"Waivers stored in the history (<history-dir>/waivers.json and remote history)", []Waiver{}},
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaGenerator builds a JSON Schema, placing named structs in $defs so
+// recursive types such as nested subtests can be described
+type schemaGenerator struct {
+	defs map[string]interface{}
+}
+
+// schema describes t following encoding/json rules for field names and
+// omitempty
+func (g *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schema(t.Elem())
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		ref := map[string]interface{}{"$ref": "#/$defs/" + t.Name()}
+		if _, ok := g.defs[t.Name()]; !ok {
+			g.defs[t.Name()] = nil // Reserve the name before recursing
+			g.defs[t.Name()] = g.object(t)
+		}
+		return ref
+	}
+	return map[string]interface{}{}
+}
+
+// object describes the exported fields of a struct
+func (g *schemaGenerator) object(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = g.schema(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+	sort.Strings(required)
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// documentSchema returns the complete schema of a named document
+func documentSchema(name string) (map[string]interface{}, bool) {
+	doc, ok := schemaDocuments[name]
+	if !ok {
+		return nil, false
+	}
+	g := &schemaGenerator{defs: make(map[string]interface{})}
+	schema := g.schema(reflect.TypeOf(doc.value))
+	schema["$defs"] = g.defs
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = doc.title
+	schema["$comment"] = "Generated by gotest-report " + version
+	return schema, true
+}
+
+// runSchema implements `gotest-report schema [name]`, printing the JSON
+// schemas of the machine readable inputs and outputs of this version
+func runSchema(args []string) int {
+	var names []string
+	for name := range schemaDocuments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out interface{}
+	switch len(args) {
+	case 0:
+		all := make(map[string]interface{}, len(names))
+		for _, name := range names {
+			all[name], _ = documentSchema(name)
+		}
+		out = all
+	case 1:
+		schema, ok := documentSchema(args[0])
+		if !ok {
+			logger.Errorf("Unknown schema %q (supported: %s)", args[0], strings.Join(names, ", "))
+			return 2
+		}
+		out = schema
+	default:
+		logger.Errorf("Usage: gotest-report schema [%s]", strings.Join(names, "|"))
+		return 2
+	}
+
+	content, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		logger.Errorf("Error encoding schema: %v", err)
+		return 1
+	}
+	fmt.Println(string(content))
+	return 0
+}
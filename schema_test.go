@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDocumentSchema(t *testing.T) {
+	for name := range schemaDocuments {
+		schema, ok := documentSchema(name)
+		if !ok {
+			t.Fatalf("Missing schema %s", name)
+		}
+		if _, err := json.Marshal(schema); err != nil {
+			t.Errorf("Schema %s is not serializable: %v", name, err)
+		}
+	}
+	if _, ok := documentSchema("unknown"); ok {
+		t.Error("Expected no schema for an unknown name")
+	}
+
+	event, _ := documentSchema("event")
+	defs := event["$defs"].(map[string]interface{})
+	required := defs["TestEvent"].(map[string]interface{})["required"].([]string)
+	if !reflect.DeepEqual(required, []string{"Action"}) {
+		t.Errorf("Expected only Action to be required in events, got %v", required)
+	}
+
+	report, _ := documentSchema("report")
+	defs = report["$defs"].(map[string]interface{})
+	test := defs["JSONTest"].(map[string]interface{})
+	subtests := test["properties"].(map[string]interface{})["subtests"].(map[string]interface{})
+	if subtests["items"].(map[string]interface{})["$ref"] != "#/$defs/JSONTest" {
+		t.Errorf("Expected nested subtests to reference JSONTest, got %v", subtests)
+	}
+	generated := defs["JSONReport"].(map[string]interface{})["properties"].(map[string]interface{})["generated_at"]
+	if !reflect.DeepEqual(generated, map[string]interface{}{"type": "string", "format": "date-time"}) {
+		t.Errorf("Expected times as date-time strings, got %v", generated)
+	}
+}
+
+// TestReportSchemaMatchesOutput guards against the schema drifting from the
+// actual -format json output
+func TestReportSchemaMatchesOutput(t *testing.T) {
+	data, _ := processTestEvents(strings.NewReader(`{"Action":"run","Package":"pkg","Test":"TestA"}
+{"Action":"pass","Package":"pkg","Test":"TestA","Elapsed":0.1}
+{"Action":"pass","Package":"pkg","Elapsed":0.1}
+`))
+	content, _ := renderJSONReport(data, ReportOptions{}, time.Now())
+	var output map[string]interface{}
+	json.Unmarshal([]byte(content), &output)
+
+	schema, _ := documentSchema("report")
+	root := schema["$defs"].(map[string]interface{})["JSONReport"].(map[string]interface{})
+	properties := root["properties"].(map[string]interface{})
+	for field := range output {
+		if _, ok := properties[field]; !ok {
+			t.Errorf("Field %s of the JSON report is missing from the schema", field)
+		}
+	}
+	for _, field := range root["required"].([]string) {
+		if _, ok := output[field]; !ok {
+			t.Errorf("Required field %s is missing from the JSON report", field)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// liveInput is the go test -json output of a run in progress, read from a
+// file that is still being written or collected from stdin
+type liveInput struct {
+	path string // Re-read on every request, empty for stdin
+
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	done bool // Set when stdin was closed
+}
+
+// fileQuietPeriod is how long a watched file has to stay unchanged, with
+// every package finished, for its run to be over. go test may still be
+// building the next package when the started ones are finished.
+const fileQuietPeriod = 5 * time.Second
+
+// Write collects streamed input
+func (l *liveInput) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.Write(p)
+}
+
+// finish marks the streamed input as complete
+func (l *liveInput) finish() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.done = true
+}
+
+// snapshot returns the complete lines read so far and whether the run is
+// over. A file that does not exist yet is an empty run in progress.
+func (l *liveInput) snapshot() ([]byte, bool, error) {
+	var content []byte
+	done := false
+	if l.path != "" {
+		var err error
+		content, err = os.ReadFile(l.path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, false, err
+		}
+	} else {
+		l.mu.Lock()
+		content, done = append([]byte(nil), l.buf.Bytes()...), l.done
+		l.mu.Unlock()
+	}
+	if !done {
+		// The last line may still be being written
+		content = content[:bytes.LastIndexByte(content, '\n')+1]
+	}
+	return content, done, nil
+}
+
+// finished reports whether the run written to the watched file is over: the
+// file reached its end, with every package of data finished, and has not
+// been written to for fileQuietPeriod
+func (l *liveInput) finished(data *ReportData) bool {
+	if l.path == "" || len(data.Packages) == 0 {
+		return false
+	}
+	for _, pkg := range data.Packages {
+		if pkg.Status == "UNKNOWN" {
+			return false
+		}
+	}
+	info, err := os.Stat(l.path)
+	return err == nil && time.Since(info.ModTime()) >= fileQuietPeriod
+}
+
+// waiverEndpoint records waivers posted to serve mode in the history store.
+// Requests must carry one of the tokens as a bearer token, and the waiver is
+// recorded as given by the name of that token; without tokens no waiver is
+// accepted.
+type waiverEndpoint struct {
+	store  HistoryStore
+	tokens map[string]string // Name of the person by token
+}
+
+// parseWaiverTokens reads NAME=TOKEN pairs separated by commas, as given in
+// $GOTEST_REPORT_WAIVER_TOKENS
+func parseWaiverTokens(value string) (map[string]string, error) {
+	tokens := make(map[string]string)
+	for i, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		// Errors leave the pair out, it may be a token
+		name, token, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || name == "" || token == "" {
+			return nil, fmt.Errorf("entry %d is not NAME=TOKEN", i+1)
+		}
+		if _, exists := tokens[token]; exists {
+			return nil, fmt.Errorf("waiver token of %s is also given to another name", name)
+		}
+		tokens[token] = name
+	}
+	return tokens, nil
+}
+
+// authorize returns the name of the token the request carries, comparing
+// against every token in constant time
+func (e *waiverEndpoint) authorize(r *http.Request) (string, bool) {
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return "", false
+	}
+	name := ""
+	for token, owner := range e.tokens {
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1 {
+			name = owner
+		}
+	}
+	return name, name != ""
+}
+
+// ServeHTTP stores the waiver in the JSON body of a POST request
+func (e *waiverEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if len(e.tokens) == 0 {
+		http.Error(w, "Adding waivers is disabled, set GOTEST_REPORT_WAIVER_TOKENS", http.StatusForbidden)
+		return
+	}
+	by, ok := e.authorize(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var waiver Waiver
+	if err := json.NewDecoder(io.LimitReader(r.Body, 64<<10)).Decode(&waiver); err != nil {
+		http.Error(w, fmt.Sprintf("Error parsing waiver: %v", err), http.StatusBadRequest)
+		return
+	}
+	if waiver.Fingerprint == "" || waiver.Reason == "" {
+		http.Error(w, "A waiver needs a fingerprint and a reason", http.StatusBadRequest)
+		return
+	}
+	// Who waives is the owner of the token, not what the body claims
+	waiver.By, waiver.Created = by, time.Now().UTC()
+	if err := e.store.PutWaiver(waiver); err != nil {
+		http.Error(w, fmt.Sprintf("Error saving waiver: %v", err), http.StatusInternalServerError)
+		return
+	}
+	logger.Printf("Waived failure %s by %s", waiver.Fingerprint, waiver.By)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(waiver)
+}
+
+// serveHandler serves the interactive HTML report of the live input at / and
+// the JSON report at /report.json. The page reloads itself every refresh
+// seconds until the run is over, or never when refresh is 0. With waivers,
+// the report shows the stored waivers and new ones are accepted at /waivers.
+func serveHandler(input *liveInput, opts ParseOptions, refresh int, waivers *waiverEndpoint) http.Handler {
+	load := func(w http.ResponseWriter) (*ReportData, bool) {
+		content, done, err := input.snapshot()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error reading input: %v", err), http.StatusInternalServerError)
+			return nil, false
+		}
+		data, err := parseTestEvents(bytes.NewReader(content), opts)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error %v", err), http.StatusInternalServerError)
+			return nil, false
+		}
+		if waivers != nil {
+			stored, err := waivers.store.Waivers()
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Error loading waivers: %v", err), http.StatusInternalServerError)
+				return nil, false
+			}
+			applyWaivers(data, stored)
+		}
+		return data, done || input.finished(data)
+	}
+	reportOpts := ReportOptions{BenchSort: "ns", TopDurations: defaultTopDurations}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		data, done := load(w)
+		if data == nil {
+			return
+		}
+		page, err := renderInteractiveHTML(data, reportOpts, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if refresh > 0 && !done {
+			page = strings.Replace(page, "<head>\n", fmt.Sprintf("<head>\n<meta http-equiv=\"refresh\" content=\"%d\">\n", refresh), 1)
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		io.WriteString(w, page)
+	})
+	mux.HandleFunc("/report.json", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := load(w)
+		if data == nil {
+			return
+		}
+		report, err := renderJSONReport(data, reportOpts, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		io.WriteString(w, report)
+	})
+	if waivers != nil {
+		mux.Handle("/waivers", waivers)
+	}
+	return mux
+}
+
+// runServe implements `gotest-report serve`, serving a continuously updated
+// report of a run in progress
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	logFlags := registerLogFlags(fs)
+	configFile := registerConfigFlag(fs)
+	host := fs.String("host", "127.0.0.1", "Address to serve the report on; the report shows the test output, so only use 0.0.0.0 on a trusted network")
+	port := fs.Int("port", 8080, "Port to serve the report on")
+	input := fs.String("input", "", "go test -json output file to watch while it is written (default is stdin)")
+	refresh := fs.Int("refresh", 2, "Seconds between reloads of the page while the run is in progress (0 disables)")
+	maxLineSize := fs.Int("max-line-size", defaultMaxLineSize, "Maximum size in bytes of a single go test -json input line")
+	historyDir := fs.String("history-dir", "", "History directory to show and store waivers in")
+	historyURL := fs.String("history-url", "", "Shared remote history to show and store waivers in instead of -history-dir: an http(s) document URL or a postgres:// DSN")
+	fs.Parse(args)
+	if err := logFlags.apply(); err != nil {
+		logger.Errorf("Error: %v", err)
+		return 2
+	}
+	if fs.NArg() > 0 {
+		logger.Errorf("Usage: gotest-report serve [-host ADDRESS] [-port PORT] [-input FILE] [-refresh SECONDS] [-history-dir DIR|-history-url URL]")
+		return 2
+	}
+
+	_, normalize, err := commandConfig(*configFile)
+	if err != nil {
+		logger.Errorf("Error loading config: %v", err)
+		return 1
+	}
+	store, err := openHistoryStore(*historyDir, *historyURL)
+	if err != nil {
+		logger.Errorf("Error opening history: %v", err)
+		return 1
+	}
+	var waivers *waiverEndpoint
+	if store != nil {
+		defer store.Close()
+		tokens, err := parseWaiverTokens(os.Getenv("GOTEST_REPORT_WAIVER_TOKENS"))
+		if err != nil {
+			logger.Errorf("Error: $GOTEST_REPORT_WAIVER_TOKENS: %v", err)
+			return 2
+		}
+		if len(tokens) == 0 {
+			logger.Warnf("GOTEST_REPORT_WAIVER_TOKENS is not set, waivers are shown but cannot be added")
+		}
+		waivers = &waiverEndpoint{store: store, tokens: tokens}
+	}
+
+	live := &liveInput{path: *input}
+	if *input == "" {
+		go func() {
+			if _, err := io.Copy(live, os.Stdin); err != nil {
+				logger.Errorf("Error reading stdin: %v", err)
+			}
+			live.finish()
+			logger.Printf("Input complete, the report is final")
+		}()
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(*host, strconv.Itoa(*port)))
+	if err != nil {
+		logger.Errorf("Error listening: %v", err)
+		return 1
+	}
+	logger.Printf("Serving the live report on http://%s/", listener.Addr())
+	if err := http.Serve(listener, serveHandler(live, ParseOptions{MaxLineSize: *maxLineSize, Normalize: normalize}, *refresh, waivers)); err != nil {
+		logger.Errorf("Error serving report: %v", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServeHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.json")
+	live := &liveInput{path: path}
+	server := httptest.NewServer(serveHandler(live, ParseOptions{}, 3, nil))
+	defer server.Close()
+
+	get := func(url string) string {
+		t.Helper()
+		resp, err := http.Get(server.URL + url)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET %s returned %s: %s", url, resp.Status, body)
+		}
+		return string(body)
+	}
+
+	// The file does not exist before go test starts writing it
+	if page := get("/"); !strings.Contains(page, `<meta http-equiv="refresh" content="3">`) {
+		t.Errorf("Expected the page to reload while the run is in progress, got:\n%s", page)
+	}
+
+	// The last line is incomplete while it is being written
+	events := `{"Action":"run","Package":"pkg","Test":"TestA"}
+{"Action":"pass","Package":"pkg","Test":"TestA","Elapsed":0.1}
+{"Action":"run","Package":"pkg","Te`
+	if err := os.WriteFile(path, []byte(events), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if report := get("/report.json"); !strings.Contains(report, `"total": 1`) || !strings.Contains(report, `"passed": 1`) {
+		t.Errorf("Expected the completed test in the live report, got:\n%s", report)
+	}
+}
+
+func TestServeHandlerStopsRefreshingWhenStdinCloses(t *testing.T) {
+	live := &liveInput{}
+	io.WriteString(live, `{"Action":"run","Package":"pkg","Test":"TestA"}`+"\n")
+	handler := serveHandler(live, ParseOptions{}, 2, nil)
+
+	render := func() string {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+		return recorder.Body.String()
+	}
+	if !strings.Contains(render(), `http-equiv="refresh"`) {
+		t.Error("Expected the page to reload while stdin is open")
+	}
+	live.finish()
+	if strings.Contains(render(), `http-equiv="refresh"`) {
+		t.Error("Expected the page to stop reloading once stdin is closed")
+	}
+}
+
+func TestServeHandlerStopsRefreshingWhenFileIsComplete(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.json")
+	handler := serveHandler(&liveInput{path: path}, ParseOptions{}, 2, nil)
+	render := func() string {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+		return recorder.Body.String()
+	}
+
+	running := `{"Action":"start","Package":"pkg"}
+{"Action":"run","Package":"pkg","Test":"TestA"}
+{"Action":"pass","Package":"pkg","Test":"TestA","Elapsed":0.1}
+`
+	os.WriteFile(path, []byte(running), 0644)
+	old := time.Now().Add(-time.Minute)
+	os.Chtimes(path, old, old)
+	if !strings.Contains(render(), `http-equiv="refresh"`) {
+		t.Error("Expected the page to reload while a package is running")
+	}
+
+	os.WriteFile(path, []byte(running+`{"Action":"pass","Package":"pkg","Elapsed":0.2}`+"\n"), 0644)
+	if !strings.Contains(render(), `http-equiv="refresh"`) {
+		t.Error("Expected the page to reload while the file was just written")
+	}
+	os.Chtimes(path, old, old)
+	if strings.Contains(render(), `http-equiv="refresh"`) {
+		t.Error("Expected the page to stop reloading once every package finished and the file is unchanged")
+	}
+}
+
+func TestServeWaivers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.json")
+	os.WriteFile(path, []byte(`{"Action":"run","Package":"pkg","Test":"TestA"}
+{"Action":"output","Package":"pkg","Test":"TestA","Output":"    a_test.go:3: Error: upstream unavailable\n"}
+{"Action":"fail","Package":"pkg","Test":"TestA","Elapsed":0.1}
+`), 0o644)
+	store := &DirHistory{Dir: t.TempDir()}
+	server := httptest.NewServer(serveHandler(&liveInput{path: path}, ParseOptions{}, 0, &waiverEndpoint{store: store, tokens: map[string]string{"secret": "alice", "other": "bob"}}))
+	defer server.Close()
+
+	var report JSONReport
+	getReport := func() {
+		t.Helper()
+		resp, err := http.Get(server.URL + "/report.json")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		defer resp.Body.Close()
+		report = JSONReport{}
+		if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	getReport()
+	fingerprint := report.Tests[0].Fingerprint
+	if fingerprint == "" || report.Tests[0].Waiver != nil {
+		t.Fatalf("Expected an unwaived failure with a fingerprint, got %+v", report.Tests[0])
+	}
+
+	post := func(token, body string) int {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/waivers", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	// The body cannot claim someone else accepted the failure
+	waiver := `{"fingerprint":"` + fingerprint + `","reason":"upstream <b>outage</b>","by":"bob"}`
+	tests := []struct {
+		name, token, body string
+		expected          int
+	}{
+		{"no token", "", waiver, http.StatusUnauthorized},
+		{"wrong token", "guess", waiver, http.StatusUnauthorized},
+		{"no reason", "secret", `{"fingerprint":"` + fingerprint + `"}`, http.StatusBadRequest},
+		{"authorized", "secret", waiver, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		if status := post(tt.token, tt.body); status != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expected, status)
+		}
+	}
+
+	getReport()
+	if w := report.Tests[0].Waiver; w == nil || w.By != "alice" || w.Reason != "upstream <b>outage</b>" {
+		t.Errorf("Expected the posted waiver in the report, got %+v", w)
+	}
+	if waivers, _ := store.Waivers(); len(waivers) != 1 {
+		t.Errorf("Expected the waiver in the history store, got %+v", waivers)
+	}
+}
+
+func TestServeWaiversWithoutToken(t *testing.T) {
+	handler := serveHandler(&liveInput{}, ParseOptions{}, 0, &waiverEndpoint{store: &DirHistory{Dir: t.TempDir()}})
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/waivers", strings.NewReader(`{"fingerprint":"3f2a9c1d0b7e","reason":"x"}`))
+	req.Header.Set("Authorization", "Bearer ")
+	handler.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("Expected waivers to be rejected without a configured token, got %d", recorder.Code)
+	}
+}
+
+func TestParseWaiverTokens(t *testing.T) {
+	tokens, err := parseWaiverTokens("alice=s3cret, bob=dG9rZW4=,")
+	if err != nil || len(tokens) != 2 || tokens["s3cret"] != "alice" || tokens["dG9rZW4="] != "bob" {
+		t.Errorf("Unexpected tokens %v, %v", tokens, err)
+	}
+	for _, value := range []string{"s3cret", "alice=", "=s3cret", "alice=s3cret,bob=s3cret"} {
+		if _, err := parseWaiverTokens(value); err == nil {
+			t.Errorf("%q: expected an error", value)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// severityLevels lists the supported severities from most to least critical
+var severityLevels = []string{"P0", "P1", "P2", "P3"}
+
+// SeverityMap assigns a severity to tests and packages. Test patterns take
+// precedence over package patterns; anything unmatched gets Default.
+type SeverityMap struct {
+	Default  string            `yaml:"default"`
+	Packages map[string]string `yaml:"packages"`
+	Tests    map[string]string `yaml:"tests"`
+}
+
+// severityRank returns the position of a severity in severityLevels, or -1
+// for unknown values
+func severityRank(severity string) int {
+	for i, level := range severityLevels {
+		if level == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// loadSeverityMap reads and validates a severity mapping file
+func loadSeverityMap(file string) (*SeverityMap, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var m SeverityMap
+	if err := yaml.Unmarshal(content, &m); err != nil {
+		return nil, fmt.Errorf("error parsing severity file: %v", err)
+	}
+	if m.Default == "" {
+		m.Default = "P3"
+	}
+
+	check := func(where, severity string) error {
+		if severityRank(severity) < 0 {
+			return fmt.Errorf("invalid severity %q for %s (supported: %s)", severity, where, strings.Join(severityLevels, ", "))
+		}
+		return nil
+	}
+	if err := check("default", m.Default); err != nil {
+		return nil, err
+	}
+	for pattern, severity := range m.Packages {
+		if err := check("package "+pattern, severity); err != nil {
+			return nil, err
+		}
+	}
+	for pattern, severity := range m.Tests {
+		if err := check("test "+pattern, severity); err != nil {
+			return nil, err
+		}
+	}
+	return &m, nil
+}
+
+// matchPackagePattern reports whether pkg matches pattern. Like go list, a
+// trailing "/..." also matches all subpackages.
+func matchPackagePattern(pattern, pkg string) bool {
+	if base, ok := strings.CutSuffix(pattern, "/..."); ok {
+		if pkg == base || strings.HasPrefix(pkg, base+"/") {
+			return true
+		}
+	}
+	matched, _ := path.Match(pattern, pkg)
+	return matched
+}
+
+// matchTestPattern reports whether a test, or the root test of a subtest,
+// matches pattern
+func matchTestPattern(pattern, name string) bool {
+	if matched, _ := path.Match(pattern, name); matched {
+		return true
+	}
+	root, _, _ := strings.Cut(name, "/")
+	matched, _ := path.Match(pattern, root)
+	return matched
+}
+
+// bestMatch returns the severity of the most specific (longest) matching
+// pattern, preferring the more critical severity on ties
+func bestMatch(patterns map[string]string, match func(pattern string) bool) (string, bool) {
+	best, severity := "", ""
+	for pattern, s := range patterns {
+		if !match(pattern) {
+			continue
+		}
+		if severity == "" || len(pattern) > len(best) ||
+			(len(pattern) == len(best) && severityRank(s) < severityRank(severity)) {
+			best, severity = pattern, s
+		}
+	}
+	return severity, severity != ""
+}
+
+// forPackage returns the severity of a package
+func (m *SeverityMap) forPackage(pkg string) string {
+	if severity, ok := bestMatch(m.Packages, func(p string) bool { return matchPackagePattern(p, pkg) }); ok {
+		return severity
+	}
+	return m.Default
+}
+
+// forTest returns the severity of a test in a package
+func (m *SeverityMap) forTest(pkg, name string) string {
+	if severity, ok := bestMatch(m.Tests, func(p string) bool { return matchTestPattern(p, name) }); ok {
+		return severity
+	}
+	return m.forPackage(pkg)
+}
+
+// applySeverities assigns a severity to every test and package in data
+func applySeverities(data *ReportData, m *SeverityMap) {
+	for _, result := range data.Results {
+		result.Severity = m.forTest(result.Package, result.Name)
+	}
+	for _, pkg := range data.Packages {
+		pkg.Severity = m.forPackage(pkg.Name)
+	}
+}
+
+// failuresBySeverity counts failed root tests and package failures per
+// severity. It returns nil when no severities were assigned.
+func failuresBySeverity(data *ReportData) map[string]int {
+	counts := make(map[string]int)
+	assigned := false
+	for _, result := range data.Results {
+		if result.Severity == "" {
+			continue
+		}
+		assigned = true
+		if !result.IsSubTest && result.Status == "FAIL" {
+			counts[result.Severity]++
+		}
+	}
+	for _, pkg := range packageFailures(data) {
+		if pkg.Severity != "" {
+			counts[pkg.Severity]++
+		}
+	}
+	if !assigned && len(counts) == 0 {
+		return nil
+	}
+	return counts
+}
+
+// blockingFailures returns the names of failed root tests and packages with
+// a severity at or above threshold (e.g. P1 includes P0 and P1). Quarantined
+// tests never block.
+func blockingFailures(data *ReportData, threshold string) []string {
+	limit := severityRank(threshold)
+	var names []string
+	for _, result := range data.Results {
+		if !result.IsSubTest && result.Status == "FAIL" && result.Quarantine == nil && result.Severity != "" && severityRank(result.Severity) <= limit {
+			names = append(names, fmt.Sprintf("%s (%s)", result.Name, result.Severity))
+		}
+	}
+	for _, pkg := range packageFailures(data) {
+		if pkg.Severity != "" && severityRank(pkg.Severity) <= limit {
+			names = append(names, fmt.Sprintf("%s (%s)", pkg.Name, pkg.Severity))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writeSeveritySection renders the failure counts per severity
+func writeSeveritySection(sb *strings.Builder, data *ReportData) {
+	counts := failuresBySeverity(data)
+	if counts == nil {
+		return
+	}
+
+	sb.WriteString("### Failures by Severity\n\n")
+	sb.WriteString("| Severity | Failed |\n")
+	sb.WriteString("| -------- | ------ |\n")
+	for _, level := range severityLevels {
+		marker := ""
+		if counts[level] > 0 && severityRank(level) <= 1 {
+			marker = " 🚨"
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %d%s |\n", level, counts[level], marker))
+	}
+	sb.WriteString("\n")
+}
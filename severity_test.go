@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSeverityMapMatching(t *testing.T) {
+	m := &SeverityMap{
+		Default: "P3",
+		Packages: map[string]string{
+			"example.com/app/payments/...": "P1",
+			"example.com/app/payments/api": "P0",
+		},
+		Tests: map[string]string{
+			"TestCheckout*": "P0",
+			"TestFlaky":     "P2",
+		},
+	}
+
+	tests := []struct {
+		pkg, name string
+		want      string
+	}{
+		{"example.com/app/payments", "TestRefund", "P1"},
+		{"example.com/app/payments/ledger", "TestRefund", "P1"},
+		{"example.com/app/payments/api", "TestRefund", "P0"},
+		{"example.com/app/other", "TestCheckoutFlow", "P0"},
+		{"example.com/app/other", "TestCheckoutFlow/empty cart", "P0"},
+		{"example.com/app/payments", "TestFlaky", "P2"},
+		{"example.com/app/other", "TestSomething", "P3"},
+	}
+	for _, tt := range tests {
+		if got := m.forTest(tt.pkg, tt.name); got != tt.want {
+			t.Errorf("forTest(%q, %q) = %s, want %s", tt.pkg, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoadSeverityMap(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "severity.yaml")
+	os.WriteFile(valid, []byte("packages:\n  example.com/app/...: P1\ntests:\n  TestCritical: P0\n"), 0o644)
+	invalid := filepath.Join(dir, "invalid.yaml")
+	os.WriteFile(invalid, []byte("tests:\n  TestCritical: P9\n"), 0o644)
+
+	m, err := loadSeverityMap(valid)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if m.Default != "P3" {
+		t.Errorf("Default should fall back to P3, got %q", m.Default)
+	}
+	if m.Tests["TestCritical"] != "P0" || m.Packages["example.com/app/..."] != "P1" {
+		t.Errorf("Unexpected mapping: %+v", m)
+	}
+
+	if _, err := loadSeverityMap(invalid); err == nil {
+		t.Error("Expected an error for an unknown severity")
+	}
+}
+
+func TestSeverityReportingAndGate(t *testing.T) {
+	data := &ReportData{
+		TotalTests:      3,
+		PassedTests:     1,
+		FailedTests:     2,
+		SortedTestNames: []string{"TestCritical", "TestMinor", "TestOK"},
+		Results: map[string]*TestResult{
+			"TestCritical": {Name: "TestCritical", Package: "pkg", Status: "FAIL"},
+			"TestMinor":    {Name: "TestMinor", Package: "pkg", Status: "FAIL"},
+			"TestOK":       {Name: "TestOK", Package: "pkg", Status: "PASS"},
+		},
+	}
+	applySeverities(data, &SeverityMap{Default: "P3", Tests: map[string]string{"TestCritical": "P0"}})
+
+	markdown := generateMarkdownReport(data)
+	for _, expected := range []string{"### Failures by Severity", "| P0 | 1 🚨 |", "| P1 | 0 |", "| P3 | 1 |"} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected %q in report", expected)
+		}
+	}
+
+	if got := blockingFailures(data, "P1"); len(got) != 1 || got[0] != "TestCritical (P0)" {
+		t.Errorf("blockingFailures(P1) = %v", got)
+	}
+	if got := blockingFailures(data, "P3"); len(got) != 2 {
+		t.Errorf("blockingFailures(P3) should include every failure, got %v", got)
+	}
+
+	data.Results["TestCritical"].Status = "PASS"
+	if got := blockingFailures(data, "P1"); len(got) != 0 {
+		t.Errorf("Only P3 failures remain, gate should pass, got %v", got)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// shuffleSeed matches the line -shuffle makes the test binary print before
+	// running the tests, e.g. "-test.shuffle 1700000000000000000"
+	shuffleSeed = regexp.MustCompile(`^-test\.shuffle (-?\d+)$`)
+	// timeoutPanic matches the panic of a test binary reaching -timeout
+	timeoutPanic = regexp.MustCompile(`^panic: test timed out after (\S+)$`)
+	// coverageLine matches the coverage summary of a binary run with -cover
+	coverageLine = regexp.MustCompile(`^coverage: (\d+(\.\d+)?% of statements|\[no statements\])`)
+)
+
+// recordBinaryFlags notes the test binary flags a package-level output line
+// reveals, as the go test flags to pass again. The shuffle seed is kept
+// apart since it is what reproduces a failing randomized order.
+func (pkg *PackageResult) recordBinaryFlags(line string) {
+	var flag string
+	if m := shuffleSeed.FindStringSubmatch(line); m != nil {
+		pkg.ShuffleSeed = m[1]
+		flag = "-shuffle=" + m[1]
+	} else if m := timeoutPanic.FindStringSubmatch(line); m != nil {
+		flag = "-timeout=" + m[1]
+	} else if coverageLine.MatchString(line) {
+		flag = "-cover"
+	}
+	if flag == "" {
+		return
+	}
+	for _, f := range pkg.Flags {
+		if f == flag {
+			return
+		}
+	}
+	pkg.Flags = append(pkg.Flags, flag)
+}
+
+// reproduceCommand returns the go test command running the package again in
+// the same shuffled order, or an empty string when it was not shuffled. The
+// order depends on the set of tests that run, so the whole package is run
+// rather than -run selecting the failed test.
+func reproduceCommand(pkg *PackageResult) string {
+	if pkg == nil || pkg.ShuffleSeed == "" {
+		return ""
+	}
+	return fmt.Sprintf("go test -count=1 %s %s", strings.Join(pkg.Flags, " "), pkg.Name)
+}
+
+// writeShuffleNote tells how to reproduce the order a failed test ran in
+func writeShuffleNote(sb *strings.Builder, pkg *PackageResult) {
+	if command := reproduceCommand(pkg); command != "" {
+		sb.WriteString(fmt.Sprintf("> 🔀 Ran in shuffled order with seed %s, re-run in the same order with %s\n\n", pkg.ShuffleSeed, codeSpan(command)))
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordBinaryFlags(t *testing.T) {
+	tests := []struct {
+		name          string
+		output        []string
+		expectedSeed  string
+		expectedFlags []string
+	}{
+		{"in order", []string{"PASS", "ok  \tpkg/example\t0.01s"}, "", nil},
+		{"shuffled", []string{"-test.shuffle 1700000000000000000", "PASS"}, "1700000000000000000", []string{"-shuffle=1700000000000000000"}},
+		{"timed out with coverage", []string{
+			"-test.shuffle 42",
+			"panic: test timed out after 30s",
+			"coverage: 71.4% of statements",
+			"coverage: 71.4% of statements",
+		}, "42", []string{"-shuffle=42", "-timeout=30s", "-cover"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := &PackageResult{Name: "pkg/example"}
+			for _, line := range tt.output {
+				pkg.recordBinaryFlags(line)
+			}
+			if pkg.ShuffleSeed != tt.expectedSeed || strings.Join(pkg.Flags, " ") != strings.Join(tt.expectedFlags, " ") {
+				t.Errorf("Expected seed %q and flags %v, got %q and %v", tt.expectedSeed, tt.expectedFlags, pkg.ShuffleSeed, pkg.Flags)
+			}
+		})
+	}
+}
+
+func TestShuffleReport(t *testing.T) {
+	input := `
+{"Time":"2023-04-01T10:00:00Z","Action":"start","Package":"pkg/example"}
+{"Time":"2023-04-01T10:00:00Z","Action":"output","Package":"pkg/example","Output":"-test.shuffle 42\n"}
+{"Time":"2023-04-01T10:00:00Z","Action":"run","Test":"TestOrder","Package":"pkg/example"}
+{"Time":"2023-04-01T10:00:01Z","Action":"output","Test":"TestOrder","Package":"pkg/example","Output":"    order_test.go:12: state leaked from another test\n"}
+{"Time":"2023-04-01T10:00:01Z","Action":"fail","Test":"TestOrder","Package":"pkg/example","Elapsed":0.1}
+{"Time":"2023-04-01T10:00:01Z","Action":"fail","Package":"pkg/example","Elapsed":0.2}
+{"Time":"2023-04-01T10:00:01Z","Action":"pass","Package":"pkg/ordered","Elapsed":0.2}
+`
+	data, err := parseTestEvents(strings.NewReader(input), ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse test events: %v", err)
+	}
+	summarizeReport(data, nil)
+
+	if cmd := reproduceCommand(data.Packages["pkg/example"]); cmd != "go test -count=1 -shuffle=42 pkg/example" {
+		t.Errorf("Unexpected reproduce command %q", cmd)
+	}
+	if cmd := reproduceCommand(data.Packages["pkg/ordered"]); cmd != "" {
+		t.Errorf("Expected no reproduce command for a package run in order, got %q", cmd)
+	}
+
+	report := renderMarkdownReport(data, ReportOptions{})
+	expected := "> 🔀 Ran in shuffled order with seed 42, re-run in the same order with `go test -count=1 -shuffle=42 pkg/example`"
+	if !strings.Contains(report, expected) {
+		t.Errorf("Expected report to contain %q, got:\n%s", expected, report)
+	}
+
+	encoded, err := json.Marshal(newJSONReport(data, ReportOptions{}, time.Now()))
+	if err != nil {
+		t.Fatalf("Failed to encode report: %v", err)
+	}
+	if !strings.Contains(string(encoded), `"shuffle_seed":"42","flags":["-shuffle=42"]`) {
+		t.Errorf("Expected the seed and flags in the JSON report, got %s", encoded)
+	}
+}
+
+func TestMergePackageShuffleSeed(t *testing.T) {
+	passed := &PackageResult{Name: "pkg/example", Status: "PASS", ShuffleSeed: "1", Flags: []string{"-shuffle=1"}}
+	failed := &PackageResult{Name: "pkg/example", Status: "FAIL", ShuffleSeed: "2", Flags: []string{"-shuffle=2"}}
+	if merged := mergePackage(passed, failed); merged.ShuffleSeed != "2" {
+		t.Errorf("Expected the seed of the failing shard, got %q", merged.ShuffleSeed)
+	}
+	if merged := mergePackage(failed, passed); merged.ShuffleSeed != "2" {
+		t.Errorf("Expected the seed of the failing shard, got %q", merged.ShuffleSeed)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// skipMessage returns the location and message of the t.Skip call of a
+// skipped test, e.g. "store_test.go:12" and "flaky on CI" from
+// "    store_test.go:12: flaky on CI". t.Skip logs its message last, before
+// the "--- SKIP" line in verbose runs and below it otherwise; the indented
+// lines of a multi-line message are joined to it.
+func skipMessage(output []string) (string, string) {
+	var location string
+	var message []string
+	indent := 0
+	for _, line := range output {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "=== ") || strings.HasPrefix(trimmed, "--- ") {
+			continue
+		}
+		if m := testLogLocation.FindStringSubmatch(line); m != nil {
+			location, message = m[1]+":"+m[2], []string{strings.TrimSpace(m[3])}
+			indent = len(line) - len(strings.TrimLeft(line, " \t"))
+			continue
+		}
+		if location != "" && len(line)-len(strings.TrimLeft(line, " \t")) > indent {
+			message = append(message, trimmed)
+		}
+	}
+	return location, strings.Join(message, " ")
+}
+
+// skipReason returns the message a skipped test logged, e.g. "flaky on CI"
+func skipReason(output []string) string {
+	_, reason := skipMessage(output)
+	return reason
+}
+
+// skippedTests returns the skipped tests and subtests in report order
+func skippedTests(data *ReportData) []*TestResult {
+	var skipped []*TestResult
+	add := func(result *TestResult) {
+		if result.Status == "SKIP" {
+			skipped = append(skipped, result)
+		}
+	}
+	for _, name := range data.SortedTestNames {
+		result := data.Results[name]
+		add(result)
+		walkSubtests(data, result, add)
+	}
+	return skipped
+}
+
+// writeSkippedTestsSection lists the skipped tests with the reason and
+// location of their t.Skip call
+func writeSkippedTestsSection(sb *strings.Builder, data *ReportData) {
+	skipped := skippedTests(data)
+	if len(skipped) == 0 {
+		return
+	}
+	sb.WriteString("## Skipped Tests\n\n")
+	sb.WriteString("| Test | Package | Reason | Location |\n")
+	sb.WriteString("| ---- | ------- | ------ | -------- |\n")
+	for _, result := range skipped {
+		location, reason := skipMessage(result.Output)
+		reasonCell, locationCell := "_No reason given_", "-"
+		if reason != "" {
+			reasonCell = escapeMarkdown(reason)
+		}
+		if location != "" {
+			locationCell = codeSpan(location)
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n",
+			escapeMarkdown(result.Name), escapeMarkdown(result.Package), reasonCell, locationCell))
+	}
+	sb.WriteString("\n")
+}
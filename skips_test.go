@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSkipMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   []string
+		location string
+		reason   string
+	}{
+		{
+			name:     "verbose",
+			output:   []string{"=== RUN   TestDocker", "    docker_test.go:10: connecting", "    docker_test.go:12: docker not available", "--- SKIP: TestDocker (0.00s)"},
+			location: "docker_test.go:12",
+			reason:   "docker not available",
+		},
+		{
+			name:     "non-verbose",
+			output:   []string{"--- SKIP: TestDocker (0.00s)", "    docker_test.go:12: docker not available"},
+			location: "docker_test.go:12",
+			reason:   "docker not available",
+		},
+		{
+			name:     "multi-line message",
+			output:   []string{"=== RUN   TestCloud", "    cloud_test.go:8: needs credentials:", "        set CLOUD_TOKEN to run", "--- SKIP: TestCloud (0.00s)"},
+			location: "cloud_test.go:8",
+			reason:   "needs credentials: set CLOUD_TOKEN to run",
+		},
+		{
+			name:   "SkipNow",
+			output: []string{"=== RUN   TestLater", "--- SKIP: TestLater (0.00s)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location, reason := skipMessage(tt.output)
+			if location != tt.location || reason != tt.reason {
+				t.Errorf("Expected %q %q, got %q %q", tt.location, tt.reason, location, reason)
+			}
+		})
+	}
+}
+
+func TestWriteSkippedTestsSection(t *testing.T) {
+	data, err := processTestEvents(strings.NewReader(`{"Action":"run","Package":"pkg","Test":"TestA"}
+{"Action":"pass","Package":"pkg","Test":"TestA"}
+{"Action":"run","Package":"pkg","Test":"TestB"}
+{"Action":"run","Package":"pkg","Test":"TestB/short"}
+{"Action":"output","Package":"pkg","Test":"TestB/short","Output":"    b_test.go:20: skipped in -short mode\n"}
+{"Action":"output","Package":"pkg","Test":"TestB/short","Output":"--- SKIP: TestB/short (0.00s)\n"}
+{"Action":"skip","Package":"pkg","Test":"TestB/short"}
+{"Action":"pass","Package":"pkg","Test":"TestB"}
+{"Action":"run","Package":"pkg","Test":"TestC"}
+{"Action":"skip","Package":"pkg","Test":"TestC"}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sb strings.Builder
+	writeSkippedTestsSection(&sb, data)
+	expected := "## Skipped Tests\n\n" +
+		"| Test | Package | Reason | Location |\n" +
+		"| ---- | ------- | ------ | -------- |\n" +
+		"| TestB/short | pkg | skipped in -short mode | `b_test.go:20` |\n" +
+		"| TestC | pkg | _No reason given_ | - |\n\n"
+	if sb.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, sb.String())
+	}
+
+	sb.Reset()
+	writeSkippedTestsSection(&sb, &ReportData{Results: map[string]*TestResult{}})
+	if sb.Len() != 0 {
+		t.Errorf("Expected no section without skipped tests, got %q", sb.String())
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxSlackFailures is the number of failures listed in a Slack message
+const maxSlackFailures = 5
+
+// slackMessage is a Slack incoming webhook payload using Block Kit
+type slackMessage struct {
+	Text     string       `json:"text"` // Fallback for notifications
+	Blocks   []slackBlock `json:"blocks"`
+	Channel  string       `json:"channel,omitempty"`  // Overrides the webhook's channel, -slack.channel
+	Username string       `json:"username,omitempty"` // Overrides the webhook's name, -slack.username
+}
+
+type slackBlock struct {
+	Type     string       `json:"type"`
+	Text     *slackText   `json:"text,omitempty"`
+	Fields   []slackText  `json:"fields,omitempty"`
+	Elements []slackBlock `json:"elements,omitempty"`
+	URL      string       `json:"url,omitempty"` // For button elements
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func mrkdwn(text string) slackText {
+	return slackText{Type: "mrkdwn", Text: text}
+}
+
+// failedRootTests returns the failed top-level tests in report order
+func failedRootTests(data *ReportData) []*TestResult {
+	var failed []*TestResult
+	for _, name := range data.SortedTestNames {
+		if result := data.Results[name]; result != nil && result.Status == "FAIL" {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// buildSlackMessage condenses the run into a Slack Block Kit message
+func buildSlackMessage(data *ReportData, gates []GateResult, reportURL string) slackMessage {
+	status := gatedStatus(data, gates)
+	emoji := statusEmoji(testStatusOf(status))
+	title := fmt.Sprintf("%s Go tests %s", emoji, strings.ToLower(status))
+
+	rate := "N/A"
+	if data.TotalTests > 0 {
+		rate = fmt.Sprintf("%.1f%%", passRate(data))
+	}
+
+	msg := slackMessage{
+		Text: fmt.Sprintf("%s: %d/%d passed", title, data.PassedTests, data.TotalTests),
+		Blocks: []slackBlock{
+			{Type: "header", Text: &slackText{Type: "plain_text", Text: title}},
+			{Type: "section", Fields: []slackText{
+				mrkdwn(fmt.Sprintf("*Total:*\n%d", data.TotalTests)),
+				mrkdwn(fmt.Sprintf("*Pass rate:*\n%s", rate)),
+				mrkdwn(fmt.Sprintf("*Failed:*\n%d", data.FailedTests)),
+				mrkdwn(fmt.Sprintf("*Skipped:*\n%d", data.SkippedTests)),
+				mrkdwn(fmt.Sprintf("*Duration:*\n%.2fs", data.TotalDuration)),
+			}},
+		},
+	}
+	if data.RunID != "" {
+		msg.Blocks[1].Fields = append(msg.Blocks[1].Fields, mrkdwn(fmt.Sprintf("*Run ID:*\n`%s`", escapeSlack(data.RunID))))
+	}
+
+	if data.FailedPackages > 0 {
+		msg.Blocks = append(msg.Blocks, slackBlock{
+			Type: "section",
+			Text: &slackText{Type: "mrkdwn", Text: fmt.Sprintf(":rotating_light: *%d package(s) failed to build or run*", data.FailedPackages)},
+		})
+	}
+
+	if failed := failedRootTests(data); len(failed) > 0 {
+		var lines []string
+		for i, result := range failed {
+			if i == maxSlackFailures {
+				lines = append(lines, fmt.Sprintf("_…and %d more_", len(failed)-maxSlackFailures))
+				break
+			}
+			line := fmt.Sprintf("• `%s`", escapeSlack(result.Name))
+			if message := failureMessage(result.Output); message != "" {
+				line += " — " + escapeSlack(message)
+			}
+			lines = append(lines, line)
+		}
+		msg.Blocks = append(msg.Blocks, slackBlock{
+			Type: "section",
+			Text: &slackText{Type: "mrkdwn", Text: "*Top failures:*\n" + strings.Join(lines, "\n")},
+		})
+	}
+
+	if reportURL != "" {
+		msg.Blocks = append(msg.Blocks, slackBlock{
+			Type: "actions",
+			Elements: []slackBlock{{
+				Type: "button",
+				Text: &slackText{Type: "plain_text", Text: "View full report"},
+				URL:  reportURL,
+			}},
+		})
+	}
+	return msg
+}
+
+// postWebhook sends payload as JSON to an incoming webhook URL
+func postWebhook(url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error encoding webhook payload: %v", err)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error calling webhook: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
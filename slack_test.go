@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBuildSlackMessage(t *testing.T) {
+	data := &ReportData{
+		TotalTests:  8,
+		PassedTests: 2,
+		FailedTests: 6,
+		Results:     map[string]*TestResult{},
+	}
+	for i := 0; i < 6; i++ {
+		name := fmt.Sprintf("TestFail%d", i)
+		data.SortedTestNames = append(data.SortedTestNames, name)
+		data.Results[name] = &TestResult{Name: name, Status: "FAIL", Output: []string{"x_test.go:1: Error: boom"}}
+	}
+
+	msg := buildSlackMessage(data, nil, "https://example.com/report")
+	payload, _ := json.Marshal(msg)
+	body := string(payload)
+
+	for _, expected := range []string{
+		`"text":"❌ Go tests failed: 2/8 passed"`,
+		`*Pass rate:*\n25.0%`,
+		"• `TestFail0` — x_test.go:1: Error: boom",
+		"_…and 1 more_",
+		`"url":"https://example.com/report"`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected %q in Slack payload", expected)
+		}
+	}
+	if strings.Contains(body, "TestFail5") {
+		t.Error("Only the top failures should be listed")
+	}
+
+	passing := buildSlackMessage(&ReportData{TotalTests: 1, PassedTests: 1, Results: map[string]*TestResult{}}, nil, "")
+	for _, block := range passing.Blocks {
+		if block.Type == "actions" {
+			t.Error("No button should be added without a report URL")
+		}
+	}
+}
+
+func TestPostWebhook(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected content type %q", r.Header.Get("Content-Type"))
+		}
+		if r.URL.Path == "/fail" {
+			http.Error(w, "invalid_payload", http.StatusBadRequest)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	if err := postWebhook(server.URL+"/ok", map[string]string{"text": "hello"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if received["text"] != "hello" {
+		t.Errorf("Unexpected payload: %v", received)
+	}
+
+	err := postWebhook(server.URL+"/fail", map[string]string{"text": "hello"})
+	if err == nil || !strings.Contains(err.Error(), "invalid_payload") {
+		t.Errorf("Expected webhook error with response body, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// StackFrameConfig selects the stack frames of panics shown in the failure
+// details, given under stack_frames in the config file
+type StackFrameConfig struct {
+	Hide     []string `yaml:"hide"`     // Packages whose frames are left out, default runtime and testing
+	Collapse []string `yaml:"collapse"` // Packages whose consecutive frames are shown as a single line
+}
+
+// defaultHiddenFrames are the packages whose frames never explain a failure
+var defaultHiddenFrames = []string{"runtime", "testing"}
+
+// stackFileLine matches the location line below a stack frame's function,
+// e.g. "\t/src/repo/pkg/foo.go:42 +0x1d"
+var stackFileLine = regexp.MustCompile(`^\t\S+\.(?:go|s):\d+(?: \+0x[0-9a-f]+)?$`)
+
+// stackFilter returns the frame filter of the config, hiding runtime and
+// testing frames unless hide is set
+func stackFilter(config StackFrameConfig) *StackFrameConfig {
+	filter := config
+	if filter.Hide == nil {
+		filter.Hide = defaultHiddenFrames
+	}
+	return &filter
+}
+
+// framePackage returns the import path of the function of a stack frame,
+// e.g. "github.com/acme/shop" for "github.com/acme/shop.(*Cart).Add(0x1)".
+// Builtins such as panic belong to the runtime.
+func framePackage(function string) string {
+	function = strings.TrimPrefix(function, "created by ")
+	if i := strings.Index(function, " in goroutine "); i >= 0 {
+		function = function[:i]
+	}
+	if strings.HasSuffix(function, ")") {
+		if i := strings.LastIndex(function, "("); i > 0 {
+			function = function[:i]
+		}
+	}
+	slash := strings.LastIndex(function, "/")
+	dot := strings.Index(function[slash+1:], ".")
+	if dot < 0 {
+		return "runtime"
+	}
+	return function[:slash+1+dot]
+}
+
+// matchFramePackage returns the first of the packages pkg is or is inside of
+func matchFramePackage(packages []string, pkg string) string {
+	for _, p := range packages {
+		if pkg == p || strings.HasPrefix(pkg, p+"/") {
+			return p
+		}
+	}
+	return ""
+}
+
+// filterStackFrames leaves the hidden frames out of the stack traces in the
+// output and replaces each run of collapsed frames with a single line. It
+// reports whether any frame was removed.
+func filterStackFrames(lines []string, filter *StackFrameConfig) ([]string, bool) {
+	var filtered []string
+	changed := false
+	collapsing, collapsed := "", 0
+	flush := func() {
+		if collapsed > 0 {
+			filtered = append(filtered, fmt.Sprintf("... %d frame(s) in %s", collapsed, collapsing))
+			collapsing, collapsed = "", 0
+		}
+	}
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		isFrame := line != "" && line[0] != ' ' && line[0] != '\t' && i+1 < len(lines) && stackFileLine.MatchString(lines[i+1])
+		if !isFrame {
+			flush()
+			filtered = append(filtered, line)
+			continue
+		}
+		pkg := framePackage(line)
+		if matchFramePackage(filter.Hide, pkg) != "" {
+			i++
+			changed = true
+			continue
+		}
+		if group := matchFramePackage(filter.Collapse, pkg); group != "" {
+			if group != collapsing {
+				flush()
+				collapsing = group
+			}
+			collapsed++
+			i++
+			changed = true
+			continue
+		}
+		flush()
+		filtered = append(filtered, line, lines[i+1])
+		i++
+	}
+	flush()
+	return filtered, changed
+}
+
+// writeFailureOutput renders the output of a failed test, preceded by the
+// summary of its panic. When stack frames were filtered, the complete output
+// follows in a collapsed block.
+func writeFailureOutput(sb *strings.Builder, output []string, opts ReportOptions) {
+	writePanicSummary(sb, output, opts)
+	if opts.StackFrames == nil {
+		writeOutputBlock(sb, failureOutput(output, opts))
+		return
+	}
+	filtered, changed := filterStackFrames(output, opts.StackFrames)
+	writeOutputBlock(sb, failureOutput(filtered, opts))
+	if changed {
+		sb.WriteString("<details>\n<summary>Full stack trace</summary>\n\n")
+		writeOutputBlock(sb, failureOutput(output, opts))
+		sb.WriteString("</details>\n\n")
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFramePackage(t *testing.T) {
+	tests := []struct {
+		function, expected string
+	}{
+		{"github.com/acme/shop.(*Cart).Add(0xc0001036c0)", "github.com/acme/shop"},
+		{"github.com/acme/shop/store.load(...)", "github.com/acme/shop/store"},
+		{"testing.tRunner.func1.2({0x5f3e20, 0xc000018150})", "testing"},
+		{"panic({0x5f3e20?, 0xc000018150?})", "runtime"},
+		{"created by testing.(*T).Run in goroutine 1", "testing"},
+		{"main.main()", "main"},
+	}
+	for _, tt := range tests {
+		if got := framePackage(tt.function); got != tt.expected {
+			t.Errorf("framePackage(%q): expected %q, got %q", tt.function, tt.expected, got)
+		}
+	}
+}
+
+func TestFilterStackFrames(t *testing.T) {
+	output := []string{
+		"--- FAIL: TestCheckout (0.00s)",
+		"panic: nil cart [recovered]",
+		"\tpanic: nil cart",
+		"",
+		"goroutine 7 [running]:",
+		"testing.tRunner.func1.2({0x5f3e20, 0xc000018150})",
+		"\t/usr/local/go/src/testing/testing.go:1631 +0x24a",
+		"panic({0x5f3e20?, 0xc000018150?})",
+		"\t/usr/local/go/src/runtime/panic.go:770 +0x132",
+		"github.com/acme/shop.(*Cart).Add(...)",
+		"\t/src/shop/cart.go:12",
+		"github.com/stretchr/testify/assert.ObjectsAreEqual(...)",
+		"\t/go/pkg/mod/github.com/stretchr/testify@v1.9.0/assert/assertions.go:60 +0x1d",
+		"github.com/stretchr/testify/require.Equal(...)",
+		"\t/go/pkg/mod/github.com/stretchr/testify@v1.9.0/require/require.go:100 +0x1d",
+		"github.com/acme/shop.TestCheckout(0xc0001036c0)",
+		"\t/src/shop/cart_test.go:8 +0x18",
+		"testing.tRunner(0xc0001036c0, 0x6a2b18)",
+		"\t/usr/local/go/src/testing/testing.go:1689 +0xfb",
+		"created by testing.(*T).Run in goroutine 1",
+		"\t/usr/local/go/src/testing/testing.go:1742 +0x390",
+	}
+	filter := stackFilter(StackFrameConfig{Collapse: []string{"github.com/stretchr/testify"}})
+	filtered, changed := filterStackFrames(output, filter)
+	expected := []string{
+		"--- FAIL: TestCheckout (0.00s)",
+		"panic: nil cart [recovered]",
+		"\tpanic: nil cart",
+		"",
+		"goroutine 7 [running]:",
+		"github.com/acme/shop.(*Cart).Add(...)",
+		"\t/src/shop/cart.go:12",
+		"... 2 frame(s) in github.com/stretchr/testify",
+		"github.com/acme/shop.TestCheckout(0xc0001036c0)",
+		"\t/src/shop/cart_test.go:8 +0x18",
+	}
+	if !changed || strings.Join(filtered, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected:\n%s\ngot (changed %v):\n%s", strings.Join(expected, "\n"), changed, strings.Join(filtered, "\n"))
+	}
+
+	// Output without stack traces is left alone
+	plain := []string{"    cart_test.go:8: Error: expected 1", "--- FAIL: TestCart (0.00s)"}
+	if filtered, changed := filterStackFrames(plain, filter); changed || len(filtered) != len(plain) {
+		t.Errorf("Expected output without frames to be unchanged, got %q", filtered)
+	}
+
+	var sb strings.Builder
+	writeFailureOutput(&sb, output, ReportOptions{StackFrames: filter})
+	if rendered := sb.String(); !strings.Contains(rendered, "<summary>Full stack trace</summary>") || strings.Count(rendered, "testing.tRunner(") != 1 {
+		t.Errorf("Expected the full trace in a collapsed block, got:\n%s", rendered)
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// StatusGroup is a commit status context covering the tests of some packages
+type StatusGroup struct {
+	Context  string   `yaml:"context"`  // Status context, e.g. "tests/unit"
+	Packages []string `yaml:"packages"` // Package patterns, "/..." matching subpackages; all packages when empty
+}
+
+// commitStatus is a GitHub commit status, see the statuses API
+type commitStatus struct {
+	State       string `json:"state"` // "success" or "failure"
+	TargetURL   string `json:"target_url,omitempty"`
+	Description string `json:"description"`
+	Context     string `json:"context"`
+}
+
+// parseStatusGroup parses a -group flag value, CONTEXT=PATTERN[,PATTERN...]
+func parseStatusGroup(value string) (StatusGroup, error) {
+	context, patterns, ok := strings.Cut(value, "=")
+	if !ok || strings.TrimSpace(context) == "" || strings.TrimSpace(patterns) == "" {
+		return StatusGroup{}, fmt.Errorf("invalid -group %q, expected CONTEXT=PATTERN[,PATTERN...]", value)
+	}
+	group := StatusGroup{Context: strings.TrimSpace(context)}
+	for _, pattern := range strings.Split(patterns, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			group.Packages = append(group.Packages, pattern)
+		}
+	}
+	return group, nil
+}
+
+// matches reports whether the group covers pkg
+func (g StatusGroup) matches(pkg string) bool {
+	if len(g.Packages) == 0 {
+		return true
+	}
+	for _, pattern := range g.Packages {
+		if matchPackagePattern(pattern, pkg) {
+			return true
+		}
+	}
+	return false
+}
+
+// groupStatus returns the commit status of the group's top-level tests and
+// build failures. Quarantined and waived failures do not fail it. The target URL
+// points to the failure details of a failed group and to the results of a
+// passed one.
+func groupStatus(data *ReportData, group StatusGroup, reportURL string) commitStatus {
+	passed, failed, skipped := 0, 0, 0
+	for _, name := range data.SortedTestNames {
+		result := data.Results[name]
+		if !group.matches(result.Package) {
+			continue
+		}
+		switch {
+		case result.Status == "PASS":
+			passed++
+		case result.Status == "FAIL" && result.Quarantine == nil && result.Waiver == nil:
+			failed++
+		case result.Status == "SKIP":
+			skipped++
+		}
+	}
+	broken := 0
+	for name, pkg := range data.Packages {
+		if pkg.BuildFailed && group.matches(name) {
+			broken++
+		}
+	}
+
+	status := commitStatus{State: "success", Context: group.Context, Description: "No tests ran"}
+	if failed > 0 || broken > 0 {
+		status.State = "failure"
+	}
+	var parts []string
+	for _, count := range []struct {
+		n    int
+		text string
+	}{{passed, "passed"}, {failed, "failed"}, {skipped, "skipped"}, {broken, "build failed"}} {
+		if count.n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", count.n, count.text))
+		}
+	}
+	if len(parts) > 0 {
+		status.Description = strings.Join(parts, ", ")
+	}
+	if reportURL != "" {
+		anchor := "test-results"
+		if status.State == "failure" {
+			anchor = "failed-tests-details"
+			if failed == 0 {
+				anchor = "test-status"
+			}
+		}
+		status.TargetURL = reportURL + "#" + anchor
+	}
+	return status
+}
+
+// eventHeadSHA returns the head commit of the PR from the event payload.
+// GITHUB_SHA of a pull_request event is the merge commit instead, whose
+// statuses are not shown on the PR.
+func eventHeadSHA(eventPath string) string {
+	content, err := os.ReadFile(eventPath)
+	if err != nil {
+		return ""
+	}
+	var event struct {
+		PullRequest struct {
+			Head struct {
+				SHA string `json:"sha"`
+			} `json:"head"`
+		} `json:"pull_request"`
+	}
+	if json.Unmarshal(content, &event) != nil {
+		return ""
+	}
+	return event.PullRequest.Head.SHA
+}
+
+// createCommitStatus sets a status of the commit
+func (c *GitHubClient) createCommitStatus(repo, sha string, status commitStatus) error {
+	return c.do(http.MethodPost, fmt.Sprintf("/repos/%s/statuses/%s", repo, sha), status, nil)
+}
+
+// runPostStatus implements `post github-status` and `post gitea-status`,
+// setting a commit status per group of packages, e.g. tests/unit and
+// tests/integration, each passing or failing on its own
+func runPostStatus(args []string, gitea bool) int {
+	name := "post github-status"
+	if gitea {
+		name = "post gitea-status"
+	}
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	logFlags := registerLogFlags(fs)
+	var inputFiles, groupFlags stringList
+	fs.Var(&inputFiles, "input", "go test -json output file; repeat or use a glob to merge sharded runs (default is stdin)")
+	fs.Var(&groupFlags, "group", "Status context and the package patterns it covers, CONTEXT=PATTERN[,PATTERN...]; repeat for several contexts (default is status_groups of the config file, or one gotest-report context)")
+	configFile := fs.String("config", "", "YAML config file with status_groups (default is "+defaultConfigFile+" when present)")
+	sha := fs.String("sha", "", "Commit to set the statuses of (default is the PR head or $GITHUB_SHA)")
+	githubRepo := fs.String("github-repo", "", "Repository in owner/name form (default is $GITHUB_REPOSITORY)")
+	reportURL := fs.String("report-url", "", "URL of the published Markdown or HTML report the statuses link to")
+	giteaURL := new(string)
+	if gitea {
+		giteaURL = fs.String("gitea-url", "", "Base URL of the Gitea or Forgejo server (default is $GITHUB_SERVER_URL)")
+	}
+	fs.Parse(args)
+	if err := logFlags.apply(); err != nil {
+		logger.Errorf("Error: %v", err)
+		return 2
+	}
+
+	config, normalize, err := commandConfig(*configFile)
+	if err != nil {
+		logger.Errorf("Error loading config: %v", err)
+		return 1
+	}
+	groups := config.StatusGroups
+	if len(groupFlags) > 0 {
+		groups = nil
+		for _, value := range groupFlags {
+			group, err := parseStatusGroup(value)
+			if err != nil {
+				logger.Errorf("Error: %v", err)
+				return 2
+			}
+			groups = append(groups, group)
+		}
+	}
+	if len(groups) == 0 {
+		groups = []StatusGroup{{Context: "gotest-report"}}
+	}
+
+	reportData, err := loadReports(inputFiles, ParseOptions{Normalize: normalize})
+	if err != nil {
+		logger.Errorf("Error %v", err)
+		return 1
+	}
+	ctx := &GitHubContext{APIURL: os.Getenv("GITHUB_API_URL"), Token: os.Getenv("GITHUB_TOKEN"), Repo: *githubRepo}
+	if gitea {
+		ctx, err = detectGiteaContext(*giteaURL, *githubRepo, 0)
+		if err != nil {
+			logger.Errorf("Error posting statuses: %v", err)
+			return 1
+		}
+	}
+	if ctx.APIURL == "" {
+		ctx.APIURL = "https://api.github.com"
+	}
+	if ctx.Repo == "" {
+		ctx.Repo = os.Getenv("GITHUB_REPOSITORY")
+	}
+	if ctx.Token == "" {
+		logger.Errorf("Error posting statuses: GITHUB_TOKEN is not set")
+		return 1
+	}
+	if ctx.Repo == "" {
+		logger.Errorf("Error posting statuses: use -github-repo outside of CI")
+		return 1
+	}
+	commit := *sha
+	if commit == "" {
+		commit = eventHeadSHA(os.Getenv("GITHUB_EVENT_PATH"))
+	}
+	if commit == "" {
+		commit = os.Getenv("GITHUB_SHA")
+	}
+	if commit == "" {
+		logger.Errorf("Error posting statuses: use -sha outside of CI")
+		return 1
+	}
+	client := newGitHubClient(ctx.APIURL, ctx.Token)
+	if gitea {
+		client = newGiteaClient(ctx.APIURL, ctx.Token)
+	}
+
+	for _, group := range groups {
+		status := groupStatus(reportData, group, *reportURL)
+		if err := client.createCommitStatus(ctx.Repo, commit, status); err != nil {
+			logger.Errorf("Error posting status %s: %v", group.Context, err)
+			return 1
+		}
+		logger.Printf("%s: %s (%s)", status.Context, status.State, status.Description)
+	}
+	return 0
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseStatusGroup(t *testing.T) {
+	group, err := parseStatusGroup("tests/unit=example.com/app/internal/..., example.com/app/cmd")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if group.Context != "tests/unit" || len(group.Packages) != 2 || group.Packages[1] != "example.com/app/cmd" {
+		t.Errorf("Unexpected group %+v", group)
+	}
+	for _, value := range []string{"tests/unit", "=example.com/app", "tests/unit="} {
+		if _, err := parseStatusGroup(value); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+func TestGroupStatus(t *testing.T) {
+	data := &ReportData{
+		SortedTestNames: []string{"TestUnit", "TestUnitFlaky", "TestIntegration", "TestIntegrationSkip"},
+		Results: map[string]*TestResult{
+			"TestUnit":            {Name: "TestUnit", Package: "app/internal/auth", Status: "PASS"},
+			"TestUnitFlaky":       {Name: "TestUnitFlaky", Package: "app/internal/auth", Status: "FAIL", Quarantine: &QuarantineEntry{Test: "TestUnitFlaky"}},
+			"TestIntegration":     {Name: "TestIntegration", Package: "app/integration", Status: "FAIL"},
+			"TestIntegrationSkip": {Name: "TestIntegrationSkip", Package: "app/integration", Status: "SKIP"},
+		},
+		Packages: map[string]*PackageResult{
+			"app/internal/auth": {Name: "app/internal/auth", Status: "FAIL"},
+			"app/integration":   {Name: "app/integration", Status: "FAIL"},
+			"app/internal/db":   {Name: "app/internal/db", Status: "FAIL", BuildFailed: true},
+		},
+	}
+
+	tests := []struct {
+		group    StatusGroup
+		expected commitStatus
+	}{
+		{
+			StatusGroup{Context: "tests/unit", Packages: []string{"app/internal/auth"}},
+			commitStatus{State: "success", Context: "tests/unit", Description: "1 passed", TargetURL: "https://ci.example.com/report.html#test-results"},
+		},
+		{
+			StatusGroup{Context: "tests/integration", Packages: []string{"app/integration"}},
+			commitStatus{State: "failure", Context: "tests/integration", Description: "1 failed, 1 skipped", TargetURL: "https://ci.example.com/report.html#failed-tests-details"},
+		},
+		{
+			StatusGroup{Context: "tests/internal", Packages: []string{"app/internal/..."}},
+			commitStatus{State: "failure", Context: "tests/internal", Description: "1 passed, 1 build failed", TargetURL: "https://ci.example.com/report.html#test-status"},
+		},
+		{
+			StatusGroup{Context: "tests/e2e", Packages: []string{"app/e2e/..."}},
+			commitStatus{State: "success", Context: "tests/e2e", Description: "No tests ran", TargetURL: "https://ci.example.com/report.html#test-results"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.group.Context, func(t *testing.T) {
+			if got := groupStatus(data, tt.group, "https://ci.example.com/report.html"); got != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRunPostGitHubStatus(t *testing.T) {
+	clearCIEnv(t)
+	var posted []commitStatus
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/repos/owner/repo/statuses/abc123" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var status commitStatus
+		json.NewDecoder(r.Body).Decode(&status)
+		posted = append(posted, status)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "test.json")
+	writeFile(t, input, `{"Action":"run","Package":"app/unit","Test":"TestA"}
+{"Action":"pass","Package":"app/unit","Test":"TestA","Elapsed":0.1}
+{"Action":"run","Package":"app/integration","Test":"TestB"}
+{"Action":"fail","Package":"app/integration","Test":"TestB","Elapsed":0.2}
+`)
+	event := filepath.Join(dir, "event.json")
+	writeFile(t, event, `{"pull_request":{"head":{"sha":"abc123"}}}`)
+	t.Setenv("GITHUB_TOKEN", "token")
+	t.Setenv("GITHUB_API_URL", server.URL)
+	t.Setenv("GITHUB_REPOSITORY", "owner/repo")
+	t.Setenv("GITHUB_EVENT_PATH", event)
+	t.Setenv("GITHUB_SHA", "merge456")
+
+	code := runPostStatus([]string{"-input", input, "-config", filepath.Join(dir, "missing.yaml"),
+		"-group", "tests/unit=app/unit", "-group", "tests/integration=app/integration"}, false)
+	if code == 0 {
+		t.Fatal("Expected an error for a missing -config file")
+	}
+	code = runPostStatus([]string{"-input", input, "-group", "tests/unit=app/unit", "-group", "tests/integration=app/integration"}, false)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	if len(posted) != 2 || posted[0].Context != "tests/unit" || posted[0].State != "success" ||
+		posted[1].Context != "tests/integration" || posted[1].State != "failure" {
+		t.Errorf("Unexpected statuses %+v", posted)
+	}
+}
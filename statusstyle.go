@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// StatusStyle is how a test status is shown, set under statuses in the
+// config file
+type StatusStyle struct {
+	Glyph string `yaml:"glyph"` // Shown before the status, e.g. "✔" where emoji are stripped
+	Color string `yaml:"color"` // Hex color of badges and cards, empty for each output's own palette
+}
+
+// defaultStatusStyles are the glyphs used without a config file
+var defaultStatusStyles = map[string]StatusStyle{
+	"PASS":    {Glyph: "✅"},
+	"FAIL":    {Glyph: "❌"},
+	"SKIP":    {Glyph: "⏭️"},
+	"UNKNOWN": {Glyph: "⏺️"},
+}
+
+// statusStyles are the styles in effect, the defaults with the overrides of
+// the config file applied at startup
+var statusStyles = defaultStatusStyles
+
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{3}([0-9a-fA-F]{3})?$`)
+
+// applyStatusStyles overrides the glyphs and colors of the given statuses.
+// Unset fields keep their defaults.
+func applyStatusStyles(overrides map[string]StatusStyle) error {
+	styles := make(map[string]StatusStyle, len(defaultStatusStyles))
+	for status, style := range defaultStatusStyles {
+		styles[status] = style
+	}
+	for name, override := range overrides {
+		status := strings.ToUpper(name)
+		style, ok := styles[status]
+		if !ok {
+			return fmt.Errorf("unknown status %q under statuses in config file (supported: PASS, FAIL, SKIP, UNKNOWN)", name)
+		}
+		if override.Color != "" && !hexColor.MatchString(override.Color) {
+			return fmt.Errorf("invalid color %q of status %s in config file, expected a hex color such as #1a7f37", override.Color, status)
+		}
+		if override.Glyph != "" {
+			style.Glyph = override.Glyph
+		}
+		if override.Color != "" {
+			style.Color = override.Color
+		}
+		styles[status] = style
+	}
+	statusStyles = styles
+	return nil
+}
+
+// statusEmoji returns the glyph of a test status
+func statusEmoji(status string) string {
+	if style, ok := statusStyles[status]; ok {
+		return style.Glyph
+	}
+	return statusStyles["UNKNOWN"].Glyph
+}
+
+// statusColor returns the configured color of a status, or fallback
+func statusColor(status, fallback string) string {
+	if color := statusStyles[status].Color; color != "" {
+		return color
+	}
+	return fallback
+}
+
+// testStatusOf maps a run status such as "PASSED" to the test status it is
+// styled as
+func testStatusOf(status string) string {
+	switch status {
+	case "PASSED":
+		return "PASS"
+	case "FAILED":
+		return "FAIL"
+	case "SKIPPED":
+		return "SKIP"
+	}
+	return "UNKNOWN"
+}
+
+// statusCSS returns the style rules overriding the badge colors of the
+// interactive HTML report
+func statusCSS() string {
+	var statuses []string
+	for status := range statusStyles {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	var rules []string
+	for _, status := range statuses {
+		if color := statusStyles[status].Color; color != "" {
+			selector := "." + status
+			if status != "UNKNOWN" {
+				selector += ", ." + status + map[string]string{"PASS": "ED", "FAIL": "ED", "SKIP": "PED"}[status]
+			}
+			rules = append(rules, fmt.Sprintf("%s { background: %s; }", selector, color))
+		}
+	}
+	return strings.Join(rules, "\n")
+}
+
+// statusGlyphsJSON returns the glyphs by test and run status for the
+// interactive HTML report
+func statusGlyphsJSON() string {
+	glyphs := make(map[string]string)
+	for status, style := range statusStyles {
+		glyphs[status] = style.Glyph
+	}
+	for _, status := range []string{"PASSED", "FAILED", "SKIPPED"} {
+		glyphs[status] = statusEmoji(testStatusOf(status))
+	}
+	content, _ := json.Marshal(glyphs)
+	return string(content)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyStatusStyles(t *testing.T) {
+	t.Cleanup(func() { statusStyles = defaultStatusStyles })
+
+	for _, tt := range []struct {
+		overrides map[string]StatusStyle
+		wantErr   string
+	}{
+		{map[string]StatusStyle{"BROKEN": {Glyph: "!"}}, `unknown status "BROKEN"`},
+		{map[string]StatusStyle{"fail": {Color: "red"}}, `invalid color "red" of status FAIL`},
+	} {
+		if err := applyStatusStyles(tt.overrides); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+		}
+	}
+
+	if err := applyStatusStyles(map[string]StatusStyle{"pass": {Glyph: "✔"}, "FAIL": {Glyph: "✖", Color: "#d00"}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if statusEmoji("PASS") != "✔" || statusEmoji("FAIL") != "✖" || statusEmoji("SKIP") != "⏭️" || statusEmoji("OTHER") != "⏺️" {
+		t.Errorf("Unexpected glyphs %+v", statusStyles)
+	}
+
+	data, err := processTestEvents(strings.NewReader(`{"Action":"run","Package":"pkg","Test":"TestA"}
+{"Action":"fail","Package":"pkg","Test":"TestA","Elapsed":0.1}
+{"Action":"run","Package":"pkg","Test":"TestB"}
+{"Action":"pass","Package":"pkg","Test":"TestB","Elapsed":0.1}
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	report := generateMarkdownReport(data)
+	for _, expected := range []string{"https://img.shields.io/badge/Status-FAILED-d00", "| **TestA** | ✖ FAIL |", "| **TestB** | ✔ PASS |"} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Expected the Markdown report to contain %q, got:\n%s", expected, report)
+		}
+	}
+	if msg := buildSlackMessage(data, nil, ""); !strings.HasPrefix(msg.Text, "✖ Go tests failed") {
+		t.Errorf("Expected the glyph in the Slack message, got %s", msg.Text)
+	}
+	page, err := renderInteractiveHTML(data, ReportOptions{}, time.Now())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, expected := range []string{".FAIL, .FAILED { background: #d00; }", `"FAIL":"✖"`} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("Expected the HTML report to contain %q", expected)
+		}
+	}
+	if glyph := tuiStatus("FAIL"); glyph != "\x1b[38;2;221;0;0m✖\x1b[0m" {
+		t.Errorf("Expected the TUI glyph in the configured color, got %q", glyph)
+	}
+	if glyph := tuiStatus("PASS"); glyph != "✔" {
+		t.Errorf("Expected the configured TUI glyph, got %q", glyph)
+	}
+}
+
+func TestCommandConfigAppliesStatusStyles(t *testing.T) {
+	t.Cleanup(func() { statusStyles = defaultStatusStyles })
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte("statuses:\n  skip:\n    glyph: \"~\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := commandConfig(file); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tuiStatus("SKIP") != "~" {
+		t.Errorf("Expected the configured glyph, got %q", tuiStatus("SKIP"))
+	}
+}
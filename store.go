@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// HistoryStore persists run records. Trend, flaky and release features only
+// use this interface, so a new backend only needs an implementation and an
+// entry in historyStoreOpeners.
+type HistoryStore interface {
+	// Put stores a run
+	Put(record *RunRecord) error
+	// Get returns the run recorded at timestamp, or nil when there is none
+	Get(timestamp time.Time) (*RunRecord, error)
+	// Query returns the matching runs, oldest first
+	Query(q HistoryQuery) ([]*RunRecord, error)
+	// Prune deletes runs recorded before the given time and returns how many
+	Prune(before time.Time) (int, error)
+	// Waivers returns the accepted failures
+	Waivers() ([]Waiver, error)
+	// PutWaiver stores a waiver, replacing one for the same fingerprint
+	PutWaiver(waiver Waiver) error
+	Close() error
+}
+
+// HistoryQuery selects runs from a HistoryStore
+type HistoryQuery struct {
+	Since time.Time // Only runs at or after this time, zero for all
+	Limit int       // Only the most recent runs, 0 for all
+
+	Environment string // Only runs of this environment, empty for all
+}
+
+// historyStoreOpeners maps -history-url schemes to backends. URLs with any
+// other scheme use the HTTP document store.
+var historyStoreOpeners = map[string]func(url string) (HistoryStore, error){
+	"postgres":   func(url string) (HistoryStore, error) { return openPostgresHistory(url) },
+	"postgresql": func(url string) (HistoryStore, error) { return openPostgresHistory(url) },
+}
+
+// openHistoryStore opens the store for -history-dir or -history-url
+func openHistoryStore(dir, url string) (HistoryStore, error) {
+	switch {
+	case dir != "" && url != "":
+		return nil, fmt.Errorf("-history-dir and -history-url cannot be combined")
+	case dir != "":
+		return &DirHistory{Dir: dir}, nil
+	case url == "":
+		return nil, nil
+	}
+	if scheme, _, ok := strings.Cut(url, "://"); ok {
+		if open, ok := historyStoreOpeners[scheme]; ok {
+			return open(url)
+		}
+	}
+	return newHTTPHistory(url), nil
+}
+
+// filterRuns applies q to runs sorted oldest first
+func filterRuns(runs []*RunRecord, q HistoryQuery) []*RunRecord {
+	if q.Environment != "" {
+		var matching []*RunRecord
+		for _, run := range runs {
+			if run.Environment == q.Environment {
+				matching = append(matching, run)
+			}
+		}
+		runs = matching
+	}
+	if !q.Since.IsZero() {
+		var since []*RunRecord
+		for _, run := range runs {
+			if !run.Timestamp.Before(q.Since) {
+				since = append(since, run)
+			}
+		}
+		runs = since
+	}
+	if q.Limit > 0 && len(runs) > q.Limit {
+		runs = runs[len(runs)-q.Limit:]
+	}
+	return runs
+}
+
+// DirHistory is the default store: one JSON file per run in a directory
+type DirHistory struct {
+	Dir string
+}
+
+func (d *DirHistory) Put(record *RunRecord) error {
+	return saveRunRecord(d.Dir, record)
+}
+
+func (d *DirHistory) Get(timestamp time.Time) (*RunRecord, error) {
+	runs, err := loadHistory(d.Dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, run := range runs {
+		if run.Timestamp.Equal(timestamp) {
+			return run, nil
+		}
+	}
+	return nil, nil
+}
+
+func (d *DirHistory) Query(q HistoryQuery) ([]*RunRecord, error) {
+	runs, err := loadHistory(d.Dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	return filterRuns(runs, q), nil
+}
+
+func (d *DirHistory) Prune(before time.Time) (int, error) {
+	runs, err := loadHistory(d.Dir, 0)
+	if err != nil {
+		return 0, err
+	}
+	pruned := 0
+	for _, run := range runs {
+		if !run.Timestamp.Before(before) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(d.Dir, runFileName(run))); err != nil && !os.IsNotExist(err) {
+			return pruned, err
+		}
+		pruned++
+	}
+	return pruned, nil
+}
+
+func (d *DirHistory) Waivers() ([]Waiver, error) {
+	return loadWaivers(d.Dir)
+}
+
+func (d *DirHistory) PutWaiver(waiver Waiver) error {
+	return addWaiver(d.Dir, waiver)
+}
+
+func (d *DirHistory) Close() error {
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDirHistoryStore(t *testing.T) {
+	store := &DirHistory{Dir: t.TempDir()}
+	testHistoryStore(t, store)
+}
+
+func TestHTTPHistoryStore(t *testing.T) {
+	server := httptest.NewServer(&etagServer{})
+	defer server.Close()
+	testHistoryStore(t, newHTTPHistory(server.URL))
+}
+
+// testHistoryStore checks the HistoryStore contract against an empty store
+func testHistoryStore(t *testing.T, store HistoryStore) {
+	t.Helper()
+	start := time.Date(2024, 3, 20, 15, 30, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		data := &ReportData{TotalTests: 3, PassedTests: i, Results: map[string]*TestResult{}}
+		if err := store.Put(newRunRecord(data, start.Add(time.Duration(i)*time.Hour))); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	run, err := store.Get(start.Add(time.Hour))
+	if err != nil || run == nil || run.Passed != 1 {
+		t.Errorf("Expected the second run, got %+v, %v", run, err)
+	}
+	if run, _ := store.Get(start.Add(time.Minute)); run != nil {
+		t.Errorf("Expected no run at an unknown time, got %+v", run)
+	}
+
+	runs, err := store.Query(HistoryQuery{Since: start.Add(time.Hour), Limit: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(runs) != 2 || runs[0].Passed != 2 || runs[1].Passed != 3 {
+		t.Errorf("Expected the two most recent runs oldest first, got %+v", runs)
+	}
+
+	pruned, err := store.Prune(start.Add(2 * time.Hour))
+	if err != nil || pruned != 2 {
+		t.Errorf("Expected 2 runs pruned, got %d, %v", pruned, err)
+	}
+	runs, _ = store.Query(HistoryQuery{})
+	if len(runs) != 2 || runs[0].Passed != 2 {
+		t.Errorf("Expected the recent runs to survive pruning, got %+v", runs)
+	}
+
+	if waivers, err := store.Waivers(); err != nil || len(waivers) != 0 {
+		t.Errorf("Expected no waivers, got %+v, %v", waivers, err)
+	}
+	for _, reason := range []string{"upstream outage", "upstream outage, OPS-1"} {
+		if err := store.PutWaiver(Waiver{Fingerprint: "3f2a9c1d0b7e", Reason: reason, By: "alice", Created: start}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	waivers, err := store.Waivers()
+	if err != nil || len(waivers) != 1 || waivers[0].Reason != "upstream outage, OPS-1" || !waivers[0].Created.Equal(start) {
+		t.Errorf("Expected the waiver to be replaced, got %+v, %v", waivers, err)
+	}
+}
+
+func TestOpenHistoryStore(t *testing.T) {
+	tests := []struct {
+		name     string
+		dir, url string
+		expected string
+		wantErr  bool
+	}{
+		{"none", "", "", "<nil>", false},
+		{"directory", "history", "", "*main.DirHistory", false},
+		{"http", "", "https://example.com/history.json", "*main.HTTPHistory", false},
+		{"both", "history", "https://example.com/history.json", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := openHistoryStore(tt.dir, tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !tt.wantErr && typeName(store) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, typeName(store))
+			}
+		})
+	}
+}
+
+func typeName(v interface{}) string {
+	if v == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func TestFilterRunsByEnvironment(t *testing.T) {
+	var runs []*RunRecord
+	for i, env := range []string{"staging", "prod", "", "staging", "prod"} {
+		runs = append(runs, &RunRecord{Passed: i, Environment: env})
+	}
+
+	staging := filterRuns(runs, HistoryQuery{Environment: "staging", Limit: 1})
+	if len(staging) != 1 || staging[0].Passed != 3 {
+		t.Errorf("Expected the latest staging run, got %+v", staging)
+	}
+	if all := filterRuns(runs, HistoryQuery{}); len(all) != 5 {
+		t.Errorf("Expected every run without an environment filter, got %d", len(all))
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// defaultMaxLineSize is the default limit of a single go test -json line
+const defaultMaxLineSize = 10 * 1024 * 1024
+
+// ParseOptions control how go test -json input is read
+type ParseOptions struct {
+	MaxLineSize  int  // Maximum size of a single input line in bytes, 0 for the default
+	SpoolOutput  bool // Keep test output in a temporary file instead of memory
+	VerifyStream bool // Check the invariants of the event stream
+
+	NormalizeTime bool // Anchor each merged input to a common start time
+
+	Normalize []normalizeRule // Rules of the config file applied to failures before fingerprinting
+
+	OnEvent func(TestEvent) // Called with every event as it is read, e.g. to print progress
+}
+
+// trimLineEnding removes the line ending of an output line, which is "\r\n"
+// for output written by tests on Windows
+func trimLineEnding(s string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(s, "\n"), "\r")
+}
+
+// lineReader reads newline delimited input without a fixed token size,
+// growing the line buffer up to a maximum
+type lineReader struct {
+	reader  *bufio.Reader
+	max     int
+	line    []byte
+	lineNo  int
+	discard bool
+}
+
+func newLineReader(r io.Reader, max int) *lineReader {
+	if max <= 0 {
+		max = defaultMaxLineSize
+	}
+	return &lineReader{reader: bufio.NewReaderSize(r, 64*1024), max: max}
+}
+
+// next returns the next line without its line ending. The returned slice is
+// only valid until the following call. io.EOF is returned after the last line.
+func (l *lineReader) next() ([]byte, error) {
+	l.line = l.line[:0]
+	for {
+		chunk, err := l.reader.ReadSlice('\n')
+		if len(l.line)+len(chunk) > l.max {
+			// Drain the rest of the line so the error points at one line
+			for err == bufio.ErrBufferFull {
+				_, err = l.reader.ReadSlice('\n')
+			}
+			l.lineNo++
+			return nil, fmt.Errorf("line %d exceeds the maximum line size of %d bytes (see -max-line-size)", l.lineNo, l.max)
+		}
+		l.line = append(l.line, chunk...)
+
+		switch err {
+		case bufio.ErrBufferFull:
+			continue
+		case nil:
+		case io.EOF:
+			if len(l.line) == 0 {
+				return nil, io.EOF
+			}
+		default:
+			return nil, err
+		}
+		l.lineNo++
+		return bytes.TrimRight(l.line, "\r\n"), nil
+	}
+}
+
+// spoolRange locates one output line inside the spool file
+type spoolRange struct {
+	offset int64
+	length int
+}
+
+// outputSpool stores test output in a temporary file so only the index is
+// held in memory while parsing
+type outputSpool struct {
+	file   *os.File
+	offset int64
+	index  map[string][]spoolRange
+}
+
+func newOutputSpool() (*outputSpool, error) {
+	file, err := os.CreateTemp("", "gotest-report-output-*")
+	if err != nil {
+		return nil, fmt.Errorf("error creating output spool: %v", err)
+	}
+	return &outputSpool{file: file, index: make(map[string][]spoolRange)}, nil
+}
+
+// add appends an output line of test
+func (s *outputSpool) add(test, line string) error {
+	n, err := s.file.WriteString(line)
+	if err != nil {
+		return fmt.Errorf("error writing output spool: %v", err)
+	}
+	s.index[test] = append(s.index[test], spoolRange{offset: s.offset, length: n})
+	s.offset += int64(n)
+	return nil
+}
+
+// lines reads back the output of test
+func (s *outputSpool) lines(test string) ([]string, error) {
+	ranges := s.index[test]
+	lines := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		buf := make([]byte, r.length)
+		if _, err := s.file.ReadAt(buf, r.offset); err != nil {
+			return nil, fmt.Errorf("error reading output spool: %v", err)
+		}
+		lines = append(lines, string(buf))
+	}
+	return lines, nil
+}
+
+// close removes the spool file
+func (s *outputSpool) close() {
+	s.file.Close()
+	os.Remove(s.file.Name())
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLineReader(t *testing.T) {
+	long := strings.Repeat("x", 200*1024)
+	reader := newLineReader(strings.NewReader("first\r\n"+long+"\n\nlast"), 0)
+
+	var lines []string
+	for {
+		line, err := reader.next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		lines = append(lines, string(line))
+	}
+	if len(lines) != 4 || lines[0] != "first" || lines[1] != long || lines[2] != "" || lines[3] != "last" {
+		t.Errorf("Unexpected lines: %d lines, first=%q", len(lines), lines[0])
+	}
+
+	reader = newLineReader(strings.NewReader("ok\n"+long+"\nafter\n"), 100*1024)
+	reader.next()
+	if _, err := reader.next(); err == nil || !strings.Contains(err.Error(), "line 2 exceeds") {
+		t.Errorf("Expected a line size error for line 2, got %v", err)
+	}
+}
+
+func TestParseTestEventsLongLines(t *testing.T) {
+	output := strings.Repeat("y", 256*1024)
+	input := fmt.Sprintf(`{"Action":"run","Package":"pkg","Test":"TestBig"}
+{"Action":"output","Package":"pkg","Test":"TestBig","Output":"%s\n"}
+{"Action":"fail","Package":"pkg","Test":"TestBig","Elapsed":1}
+`, output)
+
+	data, err := parseTestEvents(strings.NewReader(input), ParseOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := data.Results[testKey("pkg", "TestBig")].Output; len(got) != 1 || got[0] != output {
+		t.Errorf("Expected the long output line to be kept")
+	}
+
+	if _, err := parseTestEvents(strings.NewReader(input), ParseOptions{MaxLineSize: 1024}); err == nil {
+		t.Error("Expected an error when a line exceeds -max-line-size")
+	}
+}
+
+func TestParseTestEventsSpoolOutput(t *testing.T) {
+	input := `{"Action":"run","Package":"pkg","Test":"TestPass"}
+{"Action":"output","Package":"pkg","Test":"TestPass","Output":"=== RUN   TestPass\n"}
+{"Action":"pass","Package":"pkg","Test":"TestPass","Elapsed":0.1}
+{"Action":"run","Package":"pkg","Test":"TestFail"}
+{"Action":"output","Package":"pkg","Test":"TestFail","Output":"=== RUN   TestFail\n"}
+{"Action":"output","Package":"pkg","Test":"TestFail","Output":"    x_test.go:3: Error: boom\n"}
+{"Action":"fail","Package":"pkg","Test":"TestFail","Elapsed":0.1}
+`
+	data, err := parseTestEvents(strings.NewReader(input), ParseOptions{SpoolOutput: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := data.Results[testKey("pkg", "TestFail")].Output; len(got) != 2 || got[1] != "    x_test.go:3: Error: boom" {
+		t.Errorf("Expected failed test output read back from the spool, got %q", got)
+	}
+	if got := data.Results[testKey("pkg", "TestPass")].Output; len(got) != 0 {
+		t.Errorf("Expected passing test output to be dropped, got %q", got)
+	}
+	if data.Results[testKey("pkg", "TestFail")].Fingerprint == "" {
+		t.Error("Expected fingerprints computed from spooled output")
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SuiteSummary is the outcome of one of several merged inputs, such as a
+// shard or the results of another language's test runner
+type SuiteSummary struct {
+	Name     string   `json:"name"` // Input file as given
+	Total    int      `json:"total"`
+	Passed   int      `json:"passed"`
+	Failed   int      `json:"failed"`
+	Skipped  int      `json:"skipped"`
+	Duration float64  `json:"duration"` // Seconds, summed over the root tests
+	Tests    []string `json:"tests"`    // Root tests reported from this input, as package.name
+}
+
+// suiteSummaries summarizes every input of a merge. A test in several inputs
+// belongs to the last one, which its result is taken from.
+func suiteSummaries(files []string, reports []*ReportData) []*SuiteSummary {
+	suites := make([]*SuiteSummary, len(reports))
+	claimed := make(map[string]bool)
+	for i := len(reports) - 1; i >= 0; i-- {
+		data := reports[i]
+		suite := &SuiteSummary{
+			Name:     files[i],
+			Total:    data.TotalTests,
+			Passed:   data.PassedTests,
+			Failed:   data.FailedTests,
+			Skipped:  data.SkippedTests,
+			Duration: data.TotalDuration,
+			Tests:    []string{},
+		}
+		for _, name := range data.SortedTestNames {
+			if !claimed[name] {
+				claimed[name] = true
+				suite.Tests = append(suite.Tests, name)
+			}
+		}
+		suites[i] = suite
+	}
+	return suites
+}
+
+// writeSuitesSection renders a table comparing the merged inputs. It is left
+// out for a single input.
+func writeSuitesSection(sb *strings.Builder, data *ReportData) {
+	if len(data.Suites) < 2 {
+		return
+	}
+	sb.WriteString("## Suites\n\n")
+	sb.WriteString("| Suite | Tests | Passed | Failed | Skipped | Pass Rate | Duration |\n")
+	sb.WriteString("| ----- | ----- | ------ | ------ | ------- | --------- | -------- |\n")
+	for _, suite := range data.Suites {
+		status := "PASS"
+		if suite.Failed > 0 {
+			status = "FAIL"
+		}
+		rate := "N/A"
+		if suite.Total > 0 {
+			rate = fmt.Sprintf("%.1f%%", float64(suite.Passed)/float64(suite.Total)*100)
+		}
+		sb.WriteString(fmt.Sprintf("| %s %s | %d | %d | %d | %d | %s | %.2fs |\n",
+			statusEmoji(status), escapeMarkdown(suite.Name), suite.Total, suite.Passed, suite.Failed, suite.Skipped, rate, suite.Duration))
+	}
+	sb.WriteString("\n")
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSuiteSections(t *testing.T) {
+	dir := t.TempDir()
+	shard1 := filepath.Join(dir, "shard-1.json")
+	shard2 := filepath.Join(dir, "shard-2.json")
+	os.WriteFile(shard1, []byte(`{"Action":"pass","Package":"api","Test":"TestServe","Elapsed":1}`+"\n"+
+		`{"Action":"fail","Package":"api","Test":"TestRetry","Elapsed":2}`+"\n"), 0644)
+	os.WriteFile(shard2, []byte(`{"Action":"pass","Package":"db","Test":"TestQuery","Elapsed":0.5}`+"\n"+
+		`{"Action":"pass","Package":"api","Test":"TestRetry","Elapsed":1}`+"\n"), 0644)
+
+	data, err := loadReports([]string{shard1, shard2}, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Suites) != 2 || strings.Join(data.Suites[0].Tests, ",") != "api.TestServe" || strings.Join(data.Suites[1].Tests, ",") != "db.TestQuery,api.TestRetry" {
+		t.Fatalf("Expected the re-run TestRetry to belong to the second shard, got %+v %+v", data.Suites[0], data.Suites[1])
+	}
+
+	report := renderMarkdownReport(data, ReportOptions{})
+	for _, expected := range []string{
+		"## Suites\n\n| Suite | Tests | Passed | Failed | Skipped | Pass Rate | Duration |\n" +
+			"| ----- | ----- | ------ | ------ | ------- | --------- | -------- |\n" +
+			"| ❌ " + escapeMarkdown(shard1) + " | 2 | 1 | 1 | 0 | 50.0% | 3.00s |\n" +
+			"| ✅ " + escapeMarkdown(shard2) + " | 2 | 2 | 0 | 0 | 100.0% | 1.50s |\n",
+		"## Test Results\n\n### " + escapeMarkdown(shard1) + "\n\n| Test |",
+		"### " + escapeMarkdown(shard2) + "\n\n| Test |",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Expected the report to contain %q, got:\n%s", expected, report)
+		}
+	}
+
+	single, err := loadReports([]string{shard1}, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report := renderMarkdownReport(single, ReportOptions{}); strings.Contains(report, "## Suites") || !strings.Contains(report, "## Test Results\n\n| Test |") {
+		t.Errorf("Expected the single-input layout, got:\n%s", report)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// teamsCardMessage is a Microsoft Teams webhook payload carrying an
+// Adaptive Card, as accepted by Workflows and incoming webhooks
+type teamsCardMessage struct {
+	Type        string            `json:"type"` // Always "message"
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+type teamsAttachment struct {
+	ContentType string       `json:"contentType"` // Always "application/vnd.microsoft.card.adaptive"
+	Content     adaptiveCard `json:"content"`
+}
+
+// adaptiveCard is the subset of the Adaptive Card schema we use
+type adaptiveCard struct {
+	Schema  string            `json:"$schema"`
+	Type    string            `json:"type"` // Always "AdaptiveCard"
+	Version string            `json:"version"`
+	Body    []adaptiveElement `json:"body"`
+	Actions []adaptiveAction  `json:"actions,omitempty"`
+	MSTeams map[string]string `json:"msteams,omitempty"` // {"width": "Full"} uses the whole message width
+}
+
+// adaptiveElement is a TextBlock or FactSet element
+type adaptiveElement struct {
+	Type   string         `json:"type"`
+	Text   string         `json:"text,omitempty"`
+	Size   string         `json:"size,omitempty"`
+	Weight string         `json:"weight,omitempty"`
+	Color  string         `json:"color,omitempty"` // "good", "attention" or "warning"
+	Wrap   bool           `json:"wrap,omitempty"`
+	Facts  []adaptiveFact `json:"facts,omitempty"`
+}
+
+type adaptiveFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+type adaptiveAction struct {
+	Type  string `json:"type"` // Always "Action.OpenUrl"
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// buildTeamsMessage condenses the run into an Adaptive Card with a facts
+// table, the top failures and a button linking to the full report
+func buildTeamsMessage(data *ReportData, gates []GateResult, reportURL string) teamsCardMessage {
+	status := gatedStatus(data, gates)
+	color := map[string]string{"PASSED": "good", "FAILED": "attention", "SKIPPED": "warning"}[status]
+
+	rate := "N/A"
+	if data.TotalTests > 0 {
+		rate = fmt.Sprintf("%.1f%%", passRate(data))
+	}
+	facts := []adaptiveFact{
+		{Title: "Total", Value: fmt.Sprint(data.TotalTests)},
+		{Title: "Pass rate", Value: rate},
+		{Title: "Failed", Value: fmt.Sprint(data.FailedTests)},
+		{Title: "Skipped", Value: fmt.Sprint(data.SkippedTests)},
+		{Title: "Duration", Value: fmt.Sprintf("%.2fs", data.TotalDuration)},
+	}
+	if data.FailedPackages > 0 {
+		facts = append(facts, adaptiveFact{Title: "Failed packages", Value: fmt.Sprint(data.FailedPackages)})
+	}
+	if data.Git != nil && data.Git.Branch != "" {
+		facts = append(facts, adaptiveFact{Title: "Branch", Value: data.Git.Branch})
+	}
+	if data.RunID != "" {
+		facts = append(facts, adaptiveFact{Title: "Run ID", Value: data.RunID})
+	}
+
+	card := adaptiveCard{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+		Body: []adaptiveElement{
+			{Type: "TextBlock", Text: fmt.Sprintf("%s Go tests %s", statusEmoji(testStatusOf(status)), strings.ToLower(status)),
+				Size: "Large", Weight: "Bolder", Color: color, Wrap: true},
+			{Type: "FactSet", Facts: facts},
+		},
+		MSTeams: map[string]string{"width": "Full"},
+	}
+
+	if failed := failedRootTests(data); len(failed) > 0 {
+		var lines []string
+		for i, result := range failed {
+			if i == maxSlackFailures {
+				lines = append(lines, fmt.Sprintf("_…and %d more_", len(failed)-maxSlackFailures))
+				break
+			}
+			line := "- **" + escapeTeams(result.Name) + "**"
+			if message := failureMessage(result.Output); message != "" {
+				line += " — " + escapeTeams(message)
+			}
+			lines = append(lines, line)
+		}
+		card.Body = append(card.Body,
+			adaptiveElement{Type: "TextBlock", Text: "Top failures", Weight: "Bolder", Wrap: true},
+			adaptiveElement{Type: "TextBlock", Text: strings.Join(lines, "\n"), Wrap: true})
+	}
+
+	if reportURL != "" {
+		card.Actions = []adaptiveAction{{Type: "Action.OpenUrl", Title: "View full report", URL: reportURL}}
+	}
+	return teamsCardMessage{
+		Type:        "message",
+		Attachments: []teamsAttachment{{ContentType: "application/vnd.microsoft.card.adaptive", Content: card}},
+	}
+}
+
+// escapeTeams keeps test names and messages from being read as the Markdown
+// of Adaptive Card text blocks
+func escapeTeams(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "*", "\\*", "_", "\\_", "[", "\\[", "]", "\\]", "\n", " ").Replace(s)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestBuildTeamsMessage(t *testing.T) {
+	data := &ReportData{
+		TotalTests:  8,
+		PassedTests: 2,
+		FailedTests: 6,
+		RunID:       "run-42",
+		Results:     map[string]*TestResult{},
+	}
+	for i := 0; i < 6; i++ {
+		name := fmt.Sprintf("TestFail_%d", i)
+		data.SortedTestNames = append(data.SortedTestNames, name)
+		data.Results[name] = &TestResult{Name: name, Status: "FAIL", Output: []string{"x_test.go:1: Error: boom"}}
+	}
+
+	msg := buildTeamsMessage(data, nil, "https://example.com/report")
+	payload, _ := json.Marshal(msg)
+	body := string(payload)
+
+	for _, expected := range []string{
+		`"type":"message"`,
+		`"contentType":"application/vnd.microsoft.card.adaptive"`,
+		`"type":"AdaptiveCard"`,
+		`"text":"❌ Go tests failed","size":"Large","weight":"Bolder","color":"attention"`,
+		`{"title":"Pass rate","value":"25.0%"}`,
+		`{"title":"Run ID","value":"run-42"}`,
+		`- **TestFail\\_0** — x\\_test.go:1: Error: boom`,
+		"_…and 1 more_",
+		`{"type":"Action.OpenUrl","title":"View full report","url":"https://example.com/report"}`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected %q in Teams payload:\n%s", expected, body)
+		}
+	}
+	if strings.Contains(body, "TestFail\\\\_5") {
+		t.Error("Only the top failures should be listed")
+	}
+
+	passing := buildTeamsMessage(&ReportData{TotalTests: 1, PassedTests: 1, Results: map[string]*TestResult{}}, nil, "")
+	card := passing.Attachments[0].Content
+	if len(card.Actions) != 0 || len(card.Body) != 2 || card.Body[0].Color != "good" {
+		t.Errorf("Expected a green card without failures or button, got %+v", card)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// teeFormats are the console progress formats of -tee
+var teeFormats = []string{"dots", "pkgname", "testname"}
+
+// dotsPerLine wraps the dots format like a terminal of 80 columns
+const dotsPerLine = 80
+
+// progressPrinter prints human readable progress while the events are read,
+// so piping go test into the tool does not leave the terminal silent
+type progressPrinter struct {
+	w      io.Writer
+	format string
+	dots   int                 // Dots printed on the current line
+	output map[string][]string // Output of running tests, printed when they fail
+	failed []string            // Failed tests and packages, summarized at the end
+}
+
+func newProgressPrinter(w io.Writer, format string) *progressPrinter {
+	return &progressPrinter{w: w, format: format, output: make(map[string][]string)}
+}
+
+// handle prints the progress of a single event
+func (p *progressPrinter) handle(event TestEvent) {
+	if event.Test == "" {
+		p.handlePackage(event)
+		return
+	}
+	key := event.Package + " " + event.Test
+	switch event.Action {
+	case "output":
+		if p.format == "testname" {
+			p.output[key] = append(p.output[key], event.Output)
+		}
+		return
+	case "pass", "fail", "skip":
+	default:
+		return
+	}
+	output := p.output[key]
+	delete(p.output, key)
+	if event.Action == "fail" {
+		p.failed = append(p.failed, event.Test+" ("+event.Package+")")
+	}
+
+	switch p.format {
+	case "dots":
+		p.dot(map[string]string{"pass": ".", "fail": "F", "skip": "s"}[event.Action])
+	case "testname":
+		fmt.Fprintf(p.w, "%s %s %s (%.2fs)\n", statusEmoji(strings.ToUpper(event.Action)), strings.ToUpper(event.Action), event.Test, event.Elapsed)
+		if event.Action == "fail" {
+			for _, line := range output {
+				// go test's own run and result markers repeat the progress
+				if trimmed := strings.TrimSpace(line); !strings.HasPrefix(trimmed, "=== ") && !strings.HasPrefix(trimmed, "--- ") {
+					fmt.Fprint(p.w, "    "+line)
+				}
+			}
+		}
+	}
+}
+
+// handlePackage prints the result of a package in the pkgname format
+func (p *progressPrinter) handlePackage(event TestEvent) {
+	switch event.Action {
+	case "pass", "fail", "skip":
+	default:
+		return
+	}
+	if event.Action == "fail" {
+		p.failed = append(p.failed, event.Package)
+	}
+	if p.format != "pkgname" {
+		return
+	}
+	status := strings.ToUpper(event.Action)
+	if event.Action == "skip" {
+		fmt.Fprintf(p.w, "%s %s (no tests)\n", statusEmoji(status), event.Package)
+		return
+	}
+	fmt.Fprintf(p.w, "%s %s (%.2fs)\n", statusEmoji(status), event.Package, event.Elapsed)
+}
+
+func (p *progressPrinter) dot(s string) {
+	if p.dots == dotsPerLine {
+		fmt.Fprintln(p.w)
+		p.dots = 0
+	}
+	fmt.Fprint(p.w, s)
+	p.dots++
+}
+
+// finish ends the progress once all events were read
+func (p *progressPrinter) finish() {
+	if p.dots > 0 {
+		fmt.Fprintln(p.w)
+		p.dots = 0
+	}
+	if len(p.failed) > 0 {
+		fmt.Fprintf(p.w, "\n%d failed:\n", len(p.failed))
+		for _, name := range p.failed {
+			fmt.Fprintf(p.w, "  %s %s\n", statusEmoji("FAIL"), name)
+		}
+	}
+}
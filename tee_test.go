@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestProgressPrinter(t *testing.T) {
+	input := `{"Action":"run","Package":"pkg/a","Test":"TestA"}
+{"Action":"output","Package":"pkg/a","Test":"TestA","Output":"=== RUN   TestA\n"}
+{"Action":"output","Package":"pkg/a","Test":"TestA","Output":"    a_test.go:7: boom\n"}
+{"Action":"output","Package":"pkg/a","Test":"TestA","Output":"--- FAIL: TestA (0.20s)\n"}
+{"Action":"fail","Package":"pkg/a","Test":"TestA","Elapsed":0.2}
+{"Action":"run","Package":"pkg/a","Test":"TestB"}
+{"Action":"pass","Package":"pkg/a","Test":"TestB","Elapsed":0.1}
+{"Action":"run","Package":"pkg/a","Test":"TestC"}
+{"Action":"skip","Package":"pkg/a","Test":"TestC"}
+{"Action":"fail","Package":"pkg/a","Elapsed":0.5}
+{"Action":"skip","Package":"pkg/b"}
+`
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"dots", "F.s\n\n2 failed:\n  ❌ TestA (pkg/a)\n  ❌ pkg/a\n"},
+		{"pkgname", "❌ pkg/a (0.50s)\n⏭️ pkg/b (no tests)\n\n2 failed:\n  ❌ TestA (pkg/a)\n  ❌ pkg/a\n"},
+		{"testname", "❌ FAIL TestA (0.20s)\n        a_test.go:7: boom\n✅ PASS TestB (0.10s)\n⏭️ SKIP TestC (0.00s)\n\n2 failed:\n  ❌ TestA (pkg/a)\n  ❌ pkg/a\n"},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		progress := newProgressPrinter(&out, tt.format)
+		if _, err := parseTestEvents(strings.NewReader(input), ParseOptions{OnEvent: progress.handle}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		progress.finish()
+		if out.String() != tt.expected {
+			t.Errorf("%s: unexpected progress\nexpected:\n%s\ngot:\n%s", tt.format, tt.expected, out.String())
+		}
+	}
+}
+
+func TestProgressPrinterWrapsDots(t *testing.T) {
+	var out bytes.Buffer
+	progress := newProgressPrinter(&out, "dots")
+	for i := 0; i < dotsPerLine+1; i++ {
+		progress.handle(TestEvent{Action: "pass", Package: "pkg", Test: "TestA"})
+	}
+	progress.finish()
+	if expected := strings.Repeat(".", dotsPerLine) + "\n.\n"; out.String() != expected {
+		t.Errorf("Expected the dots to wrap, got %q", out.String())
+	}
+}
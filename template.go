@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are the helpers available to custom report templates
+var templateFuncs = template.FuncMap{
+	"emoji":        statusEmoji,
+	"durationFmt":  durationFmt,
+	"durationAuto": formatDuration,
+	"barChart":     barChart,
+	"status":       reportStatus,
+	"passRate":     passRate,
+	"join":         strings.Join,
+	"lower":        strings.ToLower,
+	"upper":        strings.ToUpper,
+	"md":           escapeMarkdown,
+	"code":         codeSpan,
+}
+
+// durationFmt formats a duration in seconds the way the built-in report does
+func durationFmt(seconds float64) string {
+	return fmt.Sprintf("%.3fs", seconds)
+}
+
+// barChart renders value relative to max as a bar of up to width blocks
+func barChart(value, max float64, width int) string {
+	if max <= 0 || value <= 0 || width <= 0 {
+		return ""
+	}
+	length := int(value * float64(width) / max)
+	if length < 1 {
+		length = 1
+	} else if length > width {
+		length = width
+	}
+	return strings.Repeat("█", length)
+}
+
+// passRate returns the percentage of passed tests, or 0 when no tests ran
+func passRate(data *ReportData) float64 {
+	if data.TotalTests == 0 {
+		return 0
+	}
+	return float64(data.PassedTests) / float64(data.TotalTests) * 100
+}
+
+// RootResults returns the top-level tests in report order, for templates
+func (d *ReportData) RootResults() []*TestResult {
+	var roots []*TestResult
+	for _, name := range d.SortedTestNames {
+		if result, ok := d.Results[name]; ok && !result.IsSubTest {
+			roots = append(roots, result)
+		}
+	}
+	return roots
+}
+
+// FailedResults returns every failed test, including subtests, in report order
+func (d *ReportData) FailedResults() []*TestResult {
+	var failed []*TestResult
+	var walk func(result *TestResult)
+	walk = func(result *TestResult) {
+		if result.Status == "FAIL" {
+			failed = append(failed, result)
+		}
+		for _, sub := range result.SubTests {
+			if subTest, ok := d.Results[testKey(result.Package, sub)]; ok {
+				walk(subTest)
+			}
+		}
+	}
+	for _, name := range d.SortedTestNames {
+		if result, ok := d.Results[name]; ok {
+			walk(result)
+		}
+	}
+	return failed
+}
+
+// parseReportTemplate parses a template file with the report helpers
+func parseReportTemplate(file string) (*template.Template, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return template.New(filepath.Base(file)).Funcs(templateFuncs).Parse(string(content))
+}
+
+// renderTemplateReport renders data using a user supplied text/template
+func renderTemplateReport(file string, data *ReportData) (string, error) {
+	tmpl, err := parseReportTemplate(file)
+	if err != nil {
+		return "", fmt.Errorf("error parsing template: %v", err)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("error executing template: %v", err)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplateReport(t *testing.T) {
+	data := &ReportData{
+		TotalTests:      2,
+		PassedTests:     1,
+		FailedTests:     1,
+		TotalDuration:   1.5,
+		SortedTestNames: []string{"TestA", "TestB"},
+		Results: map[string]*TestResult{
+			"TestA":                  {Name: "TestA", Status: "PASS", Duration: 0.5},
+			"TestB":                  {Name: "TestB", Status: "PASS", Duration: 1.0, SubTests: []string{"TestB/sub"}},
+			testKey("", "TestB/sub"): {Name: "TestB/sub", Status: "FAIL", IsSubTest: true, Output: []string{"boom"}},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		template    string
+		expected    []string
+		expectError bool
+	}{
+		{
+			name:     "helpers and root results",
+			template: `{{ status . }} {{ printf "%.0f" (passRate .) }}%{{ range .RootResults }}|{{ .Name }} {{ emoji .Status }} {{ durationFmt .Duration }} {{ barChart .Duration 1.0 4 }}{{ end }}`,
+			expected: []string{"FAILED 50%", "|TestA ✅ 0.500s ██", "|TestB ✅ 1.000s ████"},
+		},
+		{
+			name:     "failed results include subtests",
+			template: `{{ range .FailedResults }}{{ .Name }}: {{ join .Output "," }}{{ end }}`,
+			expected: []string{"TestB/sub: boom"},
+		},
+		{
+			name:        "parse error",
+			template:    `{{ range .RootResults }}`,
+			expectError: true,
+		},
+		{
+			name:        "execution error",
+			template:    `{{ .DoesNotExist }}`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "report.md.tmpl")
+			os.WriteFile(file, []byte(tt.template), 0o644)
+
+			out, err := renderTemplateReport(file, data)
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("Expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(out, expected) {
+					t.Errorf("Expected %q in %q", expected, out)
+				}
+			}
+		})
+	}
+}
+
+func TestExampleTemplate(t *testing.T) {
+	data, err := processTestEvents(strings.NewReader(`{"Action":"run","Test":"TestA","Package":"pkg"}
+{"Action":"fail","Test":"TestA","Package":"pkg","Elapsed":0.2}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := renderTemplateReport("examples/report.md.tmpl", data)
+	if err != nil {
+		t.Fatalf("Example template should render: %v", err)
+	}
+	if !strings.Contains(out, "# FAILED: Go Test Results") {
+		t.Errorf("Unexpected example output: %s", out)
+	}
+}
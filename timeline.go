@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// timeRange returns the earliest start and latest end of the tests in data
+func timeRange(data *ReportData) (start, end time.Time) {
+	for _, result := range data.Results {
+		if !result.Start.IsZero() && (start.IsZero() || result.Start.Before(start)) {
+			start = result.Start
+		}
+		if result.End.After(end) {
+			end = result.End
+		}
+	}
+	return start, end
+}
+
+// WallClock returns the elapsed real time between the first test starting
+// and the last test finishing, in seconds
+func (d *ReportData) WallClock() float64 {
+	if d.Start.IsZero() || d.End.Before(d.Start) {
+		return 0
+	}
+	return d.End.Sub(d.Start).Seconds()
+}
+
+// normalizeTimes anchors every report to the earliest start among them, as if
+// all shards began at the same instant. Clocks of different CI machines can
+// be minutes apart, which otherwise stretches or overlaps the merged timeline.
+func normalizeTimes(reports []*ReportData) {
+	var anchor time.Time
+	for _, data := range reports {
+		if !data.Start.IsZero() && (anchor.IsZero() || data.Start.Before(anchor)) {
+			anchor = data.Start
+		}
+	}
+	for _, data := range reports {
+		if data.Start.IsZero() {
+			continue
+		}
+		shift := anchor.Sub(data.Start)
+		for _, result := range data.Results {
+			if !result.Start.IsZero() {
+				result.Start = result.Start.Add(shift)
+			}
+			if !result.End.IsZero() {
+				result.End = result.End.Add(shift)
+			}
+			for i := range result.Pauses {
+				result.Pauses[i].Start = result.Pauses[i].Start.Add(shift)
+				if !result.Pauses[i].End.IsZero() {
+					result.Pauses[i].End = result.Pauses[i].End.Add(shift)
+				}
+			}
+		}
+		data.Start, data.End = data.Start.Add(shift), data.End.Add(shift)
+	}
+}
+
+// timelineMaxTests is the number of tests drawn in the Timeline chart, which
+// gets unreadable and slow to render beyond that
+const timelineMaxTests = 50
+
+// timelineTests returns the top-level tests with recorded start and end
+// times, the n longest when there are more, ordered by start
+func timelineTests(data *ReportData, n int) (tests []*TestResult, total int) {
+	for _, result := range data.Results {
+		if result.ParentTest == "" && !result.Start.IsZero() && !result.End.Before(result.Start) {
+			tests = append(tests, result)
+		}
+	}
+	total = len(tests)
+	if len(tests) > n {
+		sort.Slice(tests, func(i, j int) bool {
+			if a, b := tests[i].End.Sub(tests[i].Start), tests[j].End.Sub(tests[j].Start); a != b {
+				return a > b
+			}
+			return tests[i].Name < tests[j].Name
+		})
+		tests = tests[:n]
+	}
+	sort.Slice(tests, func(i, j int) bool {
+		if !tests[i].Start.Equal(tests[j].Start) {
+			return tests[i].Start.Before(tests[j].Start)
+		}
+		return tests[i].Name < tests[j].Name
+	})
+	return tests, total
+}
+
+// peakParallelism returns the largest number of tests running at the same
+// instant. A test ending when another starts does not overlap with it.
+func peakParallelism(tests []*TestResult) int {
+	spans := make([]TimeSpan, len(tests))
+	for i, test := range tests {
+		spans[i] = TimeSpan{Start: test.Start, End: test.End}
+	}
+	return peakOverlap(spans)
+}
+
+// peakOverlap returns the largest number of spans covering the same instant
+func peakOverlap(spans []TimeSpan) int {
+	type edge struct {
+		at    time.Time
+		delta int
+	}
+	var edges []edge
+	for _, span := range spans {
+		edges = append(edges, edge{span.Start, 1}, edge{span.End, -1})
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if !edges[i].at.Equal(edges[j].at) {
+			return edges[i].at.Before(edges[j].at)
+		}
+		return edges[i].delta < edges[j].delta
+	})
+	running, peak := 0, 0
+	for _, e := range edges {
+		running += e.delta
+		peak = max(peak, running)
+	}
+	return peak
+}
+
+// writeTimelineSection draws when each top-level test ran as a Mermaid Gantt
+// chart, from the timestamps of its run and pass, fail or skip events, so
+// tests running in parallel overlap and the order is the wall-clock order
+func writeTimelineSection(sb *strings.Builder, data *ReportData) {
+	tests, total := timelineTests(data, timelineMaxTests)
+	if len(tests) == 0 {
+		return
+	}
+
+	// Tasks are grouped by package, in the order the packages started
+	var packages []string
+	byPackage := make(map[string][]*TestResult)
+	for _, test := range tests {
+		if _, ok := byPackage[test.Package]; !ok {
+			packages = append(packages, test.Package)
+		}
+		byPackage[test.Package] = append(byPackage[test.Package], test)
+	}
+
+	lines := []string{"gantt", "    dateFormat x", "    axisFormat %H:%M:%S"}
+	for _, pkg := range packages {
+		if pkg != "" {
+			lines = append(lines, "    section "+escapeMermaid(pkg))
+		}
+		for _, test := range byPackage[pkg] {
+			tag := map[string]string{"PASS": "done, ", "FAIL": "crit, "}[test.Status]
+			// Mermaid drops tasks without a length
+			start, end := test.Start.UnixMilli(), max(test.End.UnixMilli(), test.Start.UnixMilli()+1)
+			lines = append(lines, fmt.Sprintf("    %s :%s%d, %d", escapeMermaid(test.Name), tag, start, end))
+		}
+	}
+
+	sb.WriteString("## Timeline\n\n")
+	sb.WriteString("<details>\n")
+	sb.WriteString("<summary>Click to expand when each test ran</summary>\n\n")
+	shown := fmt.Sprintf("%d tests", total)
+	if total > len(tests) {
+		shown = fmt.Sprintf("the %d longest of %d tests", len(tests), total)
+	}
+	sb.WriteString(fmt.Sprintf("Up to %d tests ran at the same time. Showing %s.\n\n", peakParallelism(tests), shown))
+	writeCodeBlock(sb, "mermaid", lines)
+	sb.WriteString("</details>\n\n")
+}
+
+// throughputBuckets is the maximum number of rows in the Throughput chart
+const throughputBuckets = 30
+
+// bucketWidth picks a round bucket size in seconds so the run fits in at
+// most throughputBuckets buckets
+func bucketWidth(wallClock float64) float64 {
+	for _, width := range []float64{1, 2, 5, 10, 15, 30, 60, 120, 300, 600, 900, 1800, 3600} {
+		if wallClock/width <= throughputBuckets {
+			return width
+		}
+	}
+	return math.Ceil(wallClock/throughputBuckets/3600) * 3600
+}
+
+// throughput counts the tests and subtests completed in each bucket of width
+// seconds since the start of the run
+func throughput(data *ReportData, width float64) []int {
+	buckets := make([]int, int(data.WallClock()/width)+1)
+	for _, result := range data.Results {
+		if result.End.IsZero() {
+			continue
+		}
+		i := int(result.End.Sub(data.Start).Seconds() / width)
+		if i >= 0 && i < len(buckets) {
+			buckets[i]++
+		}
+	}
+	return buckets
+}
+
+// writeThroughputSection charts the tests completed per time bucket, showing
+// the ramp-up, plateau and tail of the run
+func writeThroughputSection(sb *strings.Builder, data *ReportData) {
+	wallClock := data.WallClock()
+	if wallClock <= 0 {
+		return
+	}
+	width := bucketWidth(wallClock)
+	buckets := throughput(data, width)
+
+	total, peak := 0, 0
+	for _, count := range buckets {
+		total += count
+		if count > peak {
+			peak = count
+		}
+	}
+	if peak == 0 {
+		return
+	}
+
+	sb.WriteString("## Throughput\n\n")
+	sb.WriteString("<details>\n")
+	sb.WriteString("<summary>Click to expand tests completed over time</summary>\n\n")
+	sb.WriteString(fmt.Sprintf("%.1f tests/s on average, peak %.1f tests/s over %s buckets.\n\n",
+		float64(total)/wallClock, float64(peak)/width, formatSeconds(width)))
+	sb.WriteString("| Time | Completed | |\n")
+	sb.WriteString("| ---- | --------- | - |\n")
+	for i, count := range buckets {
+		bar := strings.Repeat("█", int(math.Ceil(float64(count)*25/float64(peak))))
+		sb.WriteString(fmt.Sprintf("| +%s | %d | %s |\n", formatSeconds(float64(i)*width), count, bar))
+	}
+	sb.WriteString("\n</details>\n\n")
+}
+
+// formatSeconds formats a whole number of seconds as "45s", "2m" or "1h30m"
+func formatSeconds(seconds float64) string {
+	d := time.Duration(seconds) * time.Second
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d < time.Hour && d%time.Minute == 0:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	if s := d.String(); strings.HasSuffix(s, "m0s") {
+		return strings.TrimSuffix(s, "0s")
+	}
+	return d.String()
+}
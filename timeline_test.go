@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWallClock(t *testing.T) {
+	input := `{"Time":"2024-01-01T10:00:00Z","Action":"run","Package":"pkg","Test":"TestA"}
+{"Time":"2024-01-01T10:00:01Z","Action":"run","Package":"pkg","Test":"TestB"}
+{"Time":"2024-01-01T10:00:03Z","Action":"pass","Package":"pkg","Test":"TestA","Elapsed":3}
+{"Time":"2024-01-01T10:00:05Z","Action":"pass","Package":"pkg","Test":"TestB","Elapsed":4}
+`
+	data, err := processTestEvents(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data.TotalDuration != 7 {
+		t.Errorf("Expected a total duration of 7s, got %.2f", data.TotalDuration)
+	}
+	if wallClock := data.WallClock(); wallClock != 5 {
+		t.Errorf("Expected a wall clock of 5s, got %.2f", wallClock)
+	}
+	if report := generateMarkdownReport(data); !strings.Contains(report, "- **Wall Clock:** 5.00s") {
+		t.Errorf("Expected the wall clock in the summary, got:\n%s", report)
+	}
+
+	// Streams without timestamps have no wall clock
+	data, err = processTestEvents(strings.NewReader(`{"Action":"run","Package":"pkg","Test":"TestA"}
+{"Action":"pass","Package":"pkg","Test":"TestA","Elapsed":1}
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if wallClock := data.WallClock(); wallClock != 0 {
+		t.Errorf("Expected no wall clock without timestamps, got %.2f", wallClock)
+	}
+}
+
+func TestNormalizeTimes(t *testing.T) {
+	// The second shard's clock runs an hour ahead
+	shard1 := `{"Time":"2024-01-01T10:00:00Z","Action":"run","Package":"pkg/a","Test":"TestA"}
+{"Time":"2024-01-01T10:00:10Z","Action":"pass","Package":"pkg/a","Test":"TestA","Elapsed":10}
+`
+	shard2 := `{"Time":"2024-01-01T11:00:02Z","Action":"run","Package":"pkg/b","Test":"TestB"}
+{"Time":"2024-01-01T11:00:06Z","Action":"pass","Package":"pkg/b","Test":"TestB","Elapsed":4}
+`
+	parse := func() []*ReportData {
+		var reports []*ReportData
+		for _, input := range []string{shard1, shard2} {
+			data, err := processTestEvents(strings.NewReader(input))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			reports = append(reports, data)
+		}
+		return reports
+	}
+
+	skewed := mergeReports(parse(), nil)
+	if wallClock := skewed.WallClock(); wallClock != 3606 {
+		t.Errorf("Expected the skew to stretch the wall clock to 3606s, got %.2f", wallClock)
+	}
+
+	reports := parse()
+	normalizeTimes(reports)
+	merged := mergeReports(reports, nil)
+	if wallClock := merged.WallClock(); wallClock != 10 {
+		t.Errorf("Expected a normalized wall clock of 10s, got %.2f", wallClock)
+	}
+
+	anchor := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	testB := merged.Results[testKey("pkg/b", "TestB")]
+	if !testB.Start.Equal(anchor) || !testB.End.Equal(anchor.Add(4*time.Second)) {
+		t.Errorf("Expected TestB to be anchored to %s, got %s - %s", anchor, testB.Start, testB.End)
+	}
+}
+
+func TestThroughput(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	data := &ReportData{Results: map[string]*TestResult{}, Packages: map[string]*PackageResult{}}
+	// Six quick tests, then a single slow test at 59s
+	for i, offset := range []float64{0.5, 1.2, 1.5, 2.1, 2.2, 2.9, 59} {
+		name := fmt.Sprintf("Test%d", i)
+		data.Results[name] = &TestResult{
+			Name:   name,
+			Status: "PASS",
+			Start:  start,
+			End:    start.Add(time.Duration(offset * float64(time.Second))),
+		}
+	}
+	summarizeReport(data, nil)
+
+	width := bucketWidth(data.WallClock())
+	if width != 2 {
+		t.Fatalf("Expected 2s buckets for a 59s run, got %v", width)
+	}
+	buckets := throughput(data, width)
+	if len(buckets) != 30 || buckets[0] != 3 || buckets[1] != 3 || buckets[29] != 1 {
+		t.Errorf("Unexpected buckets %v", buckets)
+	}
+
+	var sb strings.Builder
+	writeThroughputSection(&sb, data)
+	for _, expected := range []string{"## Throughput", "peak 1.5 tests/s over 2s buckets", "| +0s | 3 |", "| +58s | 1 |"} {
+		if !strings.Contains(sb.String(), expected) {
+			t.Errorf("Expected the section to contain %q, got:\n%s", expected, sb.String())
+		}
+	}
+}
+
+func TestFormatSeconds(t *testing.T) {
+	tests := map[float64]string{0: "0s", 45: "45s", 90: "1m30s", 120: "2m", 3600: "1h", 5400: "1h30m"}
+	for seconds, expected := range tests {
+		if got := formatSeconds(seconds); got != expected {
+			t.Errorf("formatSeconds(%v) = %q, expected %q", seconds, got, expected)
+		}
+	}
+}
+
+func TestWriteTimelineSection(t *testing.T) {
+	input := `{"Time":"2024-01-01T10:00:00Z","Action":"run","Package":"pkg/a","Test":"TestA"}
+{"Time":"2024-01-01T10:00:01Z","Action":"run","Package":"pkg/b","Test":"TestB: with colon"}
+{"Time":"2024-01-01T10:00:01.5Z","Action":"run","Package":"pkg/b","Test":"TestB: with colon/sub"}
+{"Time":"2024-01-01T10:00:02Z","Action":"pass","Package":"pkg/b","Test":"TestB: with colon/sub","Elapsed":0.5}
+{"Time":"2024-01-01T10:00:03Z","Action":"pass","Package":"pkg/a","Test":"TestA","Elapsed":3}
+{"Time":"2024-01-01T10:00:03Z","Action":"run","Package":"pkg/a","Test":"TestC"}
+{"Time":"2024-01-01T10:00:04Z","Action":"fail","Package":"pkg/a","Test":"TestC","Elapsed":1}
+{"Time":"2024-01-01T10:00:05Z","Action":"fail","Package":"pkg/b","Test":"TestB: with colon","Elapsed":4}
+`
+	data, err := processTestEvents(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var sb strings.Builder
+	writeTimelineSection(&sb, data)
+	section := sb.String()
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC).UnixMilli()
+	expected := fmt.Sprintf("```mermaid\ngantt\n    dateFormat x\n    axisFormat %%H:%%M:%%S\n"+
+		"    section pkg/a\n    TestA :done, %d, %d\n    TestC :crit, %d, %d\n"+
+		"    section pkg/b\n    TestB#58; with colon :crit, %d, %d\n```\n",
+		start, start+3000, start+3000, start+4000, start+1000, start+5000)
+	if !strings.Contains(section, expected) {
+		t.Errorf("Expected the chart\n%s\ngot:\n%s", expected, section)
+	}
+	if !strings.Contains(section, "Up to 2 tests ran at the same time. Showing 3 tests.") {
+		t.Errorf("Expected the peak parallelism, got:\n%s", section)
+	}
+
+	tests, total := timelineTests(data, 1)
+	if total != 3 || len(tests) != 1 || tests[0].Name != "TestB: with colon" {
+		t.Errorf("Expected the longest test only, got %d of %d", len(tests), total)
+	}
+
+	sb.Reset()
+	writeTimelineSection(&sb, &ReportData{Results: map[string]*TestResult{"TestA": {Name: "TestA", Status: "PASS"}}})
+	if sb.Len() != 0 {
+		t.Errorf("Expected no timeline without timestamps, got:\n%s", sb.String())
+	}
+}
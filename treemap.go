@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+)
+
+// Size of the coverage treemap in SVG user units. The SVG scales to the page
+// width.
+const (
+	treemapWidth  = 1000
+	treemapHeight = 500
+	treemapHeader = 16 // Height of the package label above its files
+)
+
+// treemapTile is a rectangle of the coverage treemap
+type treemapTile struct {
+	X, Y, W, H float64
+	Label      string
+	Title      string // Tooltip
+	Fill       string
+	Package    bool // A package outline around its file tiles
+	ShowLabel  bool // Whether the label fits into the tile
+}
+
+// squarify lays out rectangles with areas proportional to sizes within the
+// given bounds, keeping them as close to squares as possible (Bruls et al.,
+// "Squarified Treemaps"). Sizes must be positive and sorted in descending
+// order. The rectangles are returned in the order of the sizes.
+func squarify(sizes []float64, x, y, w, h float64) [][4]float64 {
+	total := 0.0
+	for _, s := range sizes {
+		total += s
+	}
+	rects := make([][4]float64, len(sizes))
+	if total <= 0 || w <= 0 || h <= 0 {
+		return rects
+	}
+	areas := make([]float64, len(sizes))
+	for i, s := range sizes {
+		areas[i] = s * w * h / total
+	}
+
+	// worst returns the largest aspect ratio of a row laid along side
+	worst := func(row []float64, side float64) float64 {
+		sum, largest, smallest := 0.0, row[0], row[0]
+		for _, a := range row {
+			sum += a
+			largest, smallest = max(largest, a), min(smallest, a)
+		}
+		return max(side*side*largest/(sum*sum), sum*sum/(side*side*smallest))
+	}
+
+	for i := 0; i < len(areas); {
+		side := min(w, h)
+		j := i + 1
+		for j < len(areas) && worst(areas[i:j+1], side) <= worst(areas[i:j], side) {
+			j++
+		}
+		sum := 0.0
+		for _, a := range areas[i:j] {
+			sum += a
+		}
+		if w >= h {
+			// A column along the left edge
+			width, top := sum/h, y
+			for k := i; k < j; k++ {
+				rects[k] = [4]float64{x, top, width, areas[k] / width}
+				top += areas[k] / width
+			}
+			x, w = x+width, w-width
+		} else {
+			// A row along the top edge
+			height, left := sum/w, x
+			for k := i; k < j; k++ {
+				rects[k] = [4]float64{left, y, areas[k] / height, height}
+				left += areas[k] / height
+			}
+			y, h = y+height, h-height
+		}
+		i = j
+	}
+	return rects
+}
+
+// coverageColor maps a coverage percentage from red at 0% to green at 100%
+func coverageColor(percent float64) string {
+	return fmt.Sprintf("hsl(%.0f, 65%%, 45%%)", percent*1.2)
+}
+
+// labelFits estimates whether a label fits into a tile at the treemap font size
+func labelFits(label string, w, h float64) bool {
+	return float64(len([]rune(label)))*6.5+8 <= w && h >= 16
+}
+
+// coverageTreemap lays out the coverage of every package and its files,
+// sized by statement counts and colored by coverage
+func coverageTreemap(coverage *CoverageData) []treemapTile {
+	if coverage == nil {
+		return nil
+	}
+
+	type pkgFiles struct {
+		name       string
+		statements int
+		covered    int
+		files      []*FileCoverage
+	}
+	byName := make(map[string]*pkgFiles)
+	var packages []*pkgFiles
+	for _, file := range coverage.SortedFiles() {
+		if file.Statements == 0 {
+			continue
+		}
+		name := path.Dir(file.Name)
+		pkg, ok := byName[name]
+		if !ok {
+			pkg = &pkgFiles{name: name}
+			byName[name] = pkg
+			packages = append(packages, pkg)
+		}
+		pkg.statements += file.Statements
+		pkg.covered += file.Covered
+		pkg.files = append(pkg.files, file)
+	}
+	sort.SliceStable(packages, func(i, j int) bool { return packages[i].statements > packages[j].statements })
+
+	var sizes []float64
+	for _, pkg := range packages {
+		sizes = append(sizes, float64(pkg.statements))
+	}
+
+	var tiles []treemapTile
+	for i, r := range squarify(sizes, 0, 0, treemapWidth, treemapHeight) {
+		pkg := packages[i]
+		percent := float64(pkg.covered) / float64(pkg.statements) * 100
+		tile := treemapTile{
+			X: r[0], Y: r[1], W: r[2], H: r[3], Label: pkg.name, Package: true, Fill: "none",
+			Title: fmt.Sprintf("%s: %.1f%% of %d statements", pkg.name, percent, pkg.statements),
+		}
+		// Files go below the package label when there is room for it
+		top := 0.0
+		if tile.H > treemapHeader*3 && tile.W > 40 {
+			top = treemapHeader
+			tile.ShowLabel = labelFits(pkg.name, tile.W, treemapHeader)
+		}
+		tiles = append(tiles, tile)
+
+		files := append([]*FileCoverage(nil), pkg.files...)
+		sort.SliceStable(files, func(a, b int) bool { return files[a].Statements > files[b].Statements })
+		var fileSizes []float64
+		for _, f := range files {
+			fileSizes = append(fileSizes, float64(f.Statements))
+		}
+		for k, fr := range squarify(fileSizes, tile.X, tile.Y+top, tile.W, tile.H-top) {
+			f := files[k]
+			label := path.Base(f.Name)
+			tiles = append(tiles, treemapTile{
+				X: fr[0], Y: fr[1], W: fr[2], H: fr[3], Label: label, Fill: coverageColor(f.Percent()),
+				Title:     fmt.Sprintf("%s: %.1f%% of %d statements", f.Name, f.Percent(), f.Statements),
+				ShowLabel: labelFits(label, fr[2], fr[3]),
+			})
+		}
+	}
+
+	// Package outlines are drawn over their files
+	sort.SliceStable(tiles, func(i, j int) bool { return !tiles[i].Package && tiles[j].Package })
+	return tiles
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSquarify(t *testing.T) {
+	sizes := []float64{6, 6, 4, 3, 2, 2, 1}
+	rects := squarify(sizes, 0, 0, 6, 4)
+	if len(rects) != len(sizes) {
+		t.Fatalf("Expected %d rectangles, got %d", len(sizes), len(rects))
+	}
+	for i, r := range rects {
+		if area := r[2] * r[3]; math.Abs(area-sizes[i]) > 1e-9 {
+			t.Errorf("Rectangle %d: expected area %v, got %v", i, sizes[i], area)
+		}
+		if r[0] < -1e-9 || r[1] < -1e-9 || r[0]+r[2] > 6+1e-9 || r[1]+r[3] > 4+1e-9 {
+			t.Errorf("Rectangle %d %v is outside the bounds", i, r)
+		}
+	}
+	// The example of the paper starts with two 6s side by side in a column
+	if rects[0] != [4]float64{0, 0, 3, 2} || rects[1] != [4]float64{0, 2, 3, 2} {
+		t.Errorf("Unexpected first column %v %v", rects[0], rects[1])
+	}
+}
+
+func TestCoverageTreemap(t *testing.T) {
+	coverage := &CoverageData{Files: map[string]*FileCoverage{
+		"example.com/app/a.go":     {Name: "example.com/app/a.go", Statements: 300, Covered: 300},
+		"example.com/app/b.go":     {Name: "example.com/app/b.go", Statements: 100, Covered: 0},
+		"example.com/app/cli/c.go": {Name: "example.com/app/cli/c.go", Statements: 100, Covered: 50},
+		"example.com/app/empty.go": {Name: "example.com/app/empty.go"},
+	}}
+
+	tiles := coverageTreemap(coverage)
+	var packages, files []treemapTile
+	area := 0.0
+	for _, tile := range tiles {
+		if tile.Package {
+			packages = append(packages, tile)
+			area += tile.W * tile.H
+		} else {
+			files = append(files, tile)
+		}
+	}
+	if len(packages) != 2 || len(files) != 3 {
+		t.Fatalf("Expected 2 packages and 3 files, got %+v", tiles)
+	}
+	if !tiles[len(tiles)-1].Package {
+		t.Error("Expected package outlines to be drawn last")
+	}
+	if math.Abs(area-treemapWidth*treemapHeight) > 1e-6 {
+		t.Errorf("Expected the packages to fill the treemap, got area %v", area)
+	}
+	if packages[0].Label != "example.com/app" || math.Abs(packages[0].W*packages[0].H-0.8*treemapWidth*treemapHeight) > 1e-6 {
+		t.Errorf("Expected example.com/app to take 80%% of the area, got %+v", packages[0])
+	}
+	if files[0].Label != "a.go" || files[0].Fill != coverageColor(100) || files[1].Fill != coverageColor(0) {
+		t.Errorf("Unexpected file tiles %+v", files)
+	}
+	if coverageTreemap(nil) != nil {
+		t.Error("Expected no treemap without coverage")
+	}
+
+	data := &ReportData{Results: map[string]*TestResult{}, Packages: map[string]*PackageResult{}, Coverage: coverage}
+	summarizeReport(data, nil)
+	page, err := renderInteractiveHTML(data, ReportOptions{}, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(page, `<svg viewBox="0 0 1000 500"`) || strings.Count(page, "<rect") != len(tiles) {
+		t.Errorf("Expected a treemap with %d tiles in the page", len(tiles))
+	}
+}
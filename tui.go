@@ -0,0 +1,469 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tuiFilters are the status filters cycled through with the f key
+var tuiFilters = []string{"all", "failed", "skipped", "passed"}
+
+// tuiNode is a package, test or subtest row of the test tree
+type tuiNode struct {
+	Key      string // Unique within the tree
+	Label    string
+	Status   string
+	Duration float64
+	Depth    int
+	Output   []string
+	Parent   *tuiNode
+	Children []*tuiNode
+}
+
+// matches reports whether the node has the status selected by a filter
+func (n *tuiNode) matches(filter string) bool {
+	switch filter {
+	case "failed":
+		return n.Status == "FAIL"
+	case "skipped":
+		return n.Status == "SKIP"
+	case "passed":
+		return n.Status == "PASS"
+	}
+	return true
+}
+
+// visible reports whether the node or one of its descendants matches a filter
+func (n *tuiNode) visible(filter string) bool {
+	if n.matches(filter) {
+		return true
+	}
+	for _, child := range n.Children {
+		if child.visible(filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// tuiModel is the state of the interactive viewer. It is kept apart from the
+// terminal so key handling and rendering can be tested.
+type tuiModel struct {
+	roots    []*tuiNode
+	expanded map[string]bool
+	filter   int // Index into tuiFilters
+	cursor   int // Selected row of the tree
+	offset   int // First tree row on screen
+	viewing  *tuiNode
+	scroll   int // First output line on screen
+	width    int
+	height   int
+	summary  string
+}
+
+// newTUIModel builds the package and test tree of a report. Packages with
+// failures start expanded.
+func newTUIModel(data *ReportData) *tuiModel {
+	m := &tuiModel{expanded: make(map[string]bool), width: 80, height: 24}
+	m.summary = fmt.Sprintf("%d tests, %d passed, %d failed, %d skipped",
+		data.TotalTests, data.PassedTests, data.FailedTests, data.SkippedTests)
+
+	packages := make(map[string]*tuiNode)
+	packageNode := func(name string) *tuiNode {
+		if node, ok := packages[name]; ok {
+			return node
+		}
+		node := &tuiNode{Key: name, Label: name, Status: "PASS"}
+		if pkg, ok := data.Packages[name]; ok {
+			node.Status, node.Duration = pkg.Status, pkg.Duration
+			node.Output = append(append([]string(nil), pkg.BuildOutput...), pkg.Output...)
+		}
+		packages[name] = node
+		return node
+	}
+	for name := range data.Packages {
+		packageNode(name)
+	}
+
+	var addTest func(parent *tuiNode, name string)
+	addTest = func(parent *tuiNode, name string) {
+		result, ok := data.Results[name]
+		if !ok {
+			return
+		}
+		label := result.Name
+		if result.IsSubTest {
+			label = strings.TrimPrefix(result.Name, result.ParentTest+"/")
+		}
+		node := &tuiNode{
+			Key: result.Package + "\x00" + result.Name, Label: label, Status: result.Status, Duration: result.Duration,
+			Depth: parent.Depth + 1, Output: result.Output, Parent: parent,
+		}
+		parent.Children = append(parent.Children, node)
+		for _, sub := range result.SubTests {
+			addTest(node, testKey(result.Package, sub))
+		}
+	}
+	for _, name := range data.SortedTestNames {
+		pkg := packageNode(data.Results[name].Package)
+		addTest(pkg, name)
+	}
+
+	var names []string
+	for name := range packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		node := packages[name]
+		if _, ok := data.Packages[name]; !ok && node.visible("failed") {
+			node.Status = "FAIL"
+		}
+		if node.Status == "FAIL" {
+			m.expanded[node.Key] = true
+		}
+		m.roots = append(m.roots, node)
+	}
+	return m
+}
+
+// rows returns the tree rows shown with the current filter and expansion
+func (m *tuiModel) rows() []*tuiNode {
+	filter := tuiFilters[m.filter]
+	var rows []*tuiNode
+	var walk func(nodes []*tuiNode)
+	walk = func(nodes []*tuiNode) {
+		for _, node := range nodes {
+			if !node.visible(filter) {
+				continue
+			}
+			rows = append(rows, node)
+			if m.expanded[node.Key] {
+				walk(node.Children)
+			}
+		}
+	}
+	walk(m.roots)
+	return rows
+}
+
+// pageSize is the number of tree rows or output lines that fit on screen
+// below the header and above the footer
+func (m *tuiModel) pageSize() int {
+	if m.height > 4 {
+		return m.height - 3
+	}
+	return 1
+}
+
+// handleKey applies a key press and reports whether the viewer should quit
+func (m *tuiModel) handleKey(key string) bool {
+	if key == "ctrl-c" {
+		return true
+	}
+	if m.viewing != nil {
+		lines := len(m.viewing.Output)
+		switch key {
+		case "q", "esc", "left", "h", "backspace":
+			m.viewing = nil
+		case "up", "k":
+			m.scroll--
+		case "down", "j":
+			m.scroll++
+		case "pgup":
+			m.scroll -= m.pageSize()
+		case "pgdown", " ":
+			m.scroll += m.pageSize()
+		case "g":
+			m.scroll = 0
+		case "G":
+			m.scroll = lines
+		}
+		m.scroll = max(0, min(m.scroll, lines-m.pageSize()))
+		return false
+	}
+
+	rows := m.rows()
+	var selected *tuiNode
+	if m.cursor < len(rows) {
+		selected = rows[m.cursor]
+	}
+	switch key {
+	case "q":
+		return true
+	case "up", "k":
+		m.cursor--
+	case "down", "j":
+		m.cursor++
+	case "pgup":
+		m.cursor -= m.pageSize()
+	case "pgdown":
+		m.cursor += m.pageSize()
+	case "g":
+		m.cursor = 0
+	case "G":
+		m.cursor = len(rows) - 1
+	case "right", "l":
+		if selected != nil && len(selected.Children) > 0 {
+			m.expanded[selected.Key] = true
+		}
+	case "left", "h":
+		if selected == nil {
+			break
+		}
+		if m.expanded[selected.Key] {
+			delete(m.expanded, selected.Key)
+		} else if selected.Parent != nil {
+			for i, row := range rows {
+				if row == selected.Parent {
+					m.cursor = i
+				}
+			}
+		}
+	case "enter", " ":
+		if selected == nil {
+			break
+		}
+		if len(selected.Children) > 0 {
+			m.expanded[selected.Key] = !m.expanded[selected.Key]
+		} else {
+			m.viewing, m.scroll = selected, 0
+		}
+	case "o":
+		if selected != nil {
+			m.viewing, m.scroll = selected, 0
+		}
+	case "f":
+		m.filter = (m.filter + 1) % len(tuiFilters)
+		m.cursor, m.offset = 0, 0
+	}
+
+	count := len(m.rows())
+	m.cursor = max(0, min(m.cursor, count-1))
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+m.pageSize() {
+		m.offset = m.cursor - m.pageSize() + 1
+	}
+	return false
+}
+
+// tuiStatus returns the status glyph of a row, in the configured color of
+// the status when there is one
+func tuiStatus(status string) string {
+	glyph := statusEmoji(status)
+	color := strings.TrimPrefix(statusColor(status, ""), "#")
+	if color == "" {
+		return glyph
+	}
+	if len(color) == 3 {
+		color = string([]byte{color[0], color[0], color[1], color[1], color[2], color[2]})
+	}
+	var r, g, b int
+	fmt.Sscanf(color, "%02x%02x%02x", &r, &g, &b)
+	return fmt.Sprintf("\x1b[38;2;%d;%d;%dm%s\x1b[0m", r, g, b, glyph)
+}
+
+// truncate shortens a line to width runes
+func truncate(line string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(line) <= width {
+		return line
+	}
+	runes := []rune(line)
+	return string(runes[:width-1]) + "…"
+}
+
+// render draws the screen. Lines end with \r\n since the terminal is in raw
+// mode.
+func (m *tuiModel) render() string {
+	var lines []string
+	if m.viewing != nil {
+		node := m.viewing
+		lines = append(lines, "\x1b[1m"+truncate(fmt.Sprintf("%s %s (%s)", node.Label, node.Status, formatDuration(node.Duration)), m.width)+"\x1b[0m")
+		output := node.Output
+		if len(output) == 0 {
+			output = []string{"(no output)"}
+		}
+		end := min(len(output), m.scroll+m.pageSize())
+		for _, line := range output[m.scroll:end] {
+			lines = append(lines, truncate(strings.ReplaceAll(line, "\t", "    "), m.width))
+		}
+		for len(lines) < m.pageSize()+1 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "", truncate(fmt.Sprintf("↑/↓ scroll  PgUp/PgDn page  q back  (lines %d-%d of %d)", m.scroll+1, end, len(output)), m.width))
+		return strings.Join(lines, "\r\n")
+	}
+
+	lines = append(lines, "\x1b[1m"+truncate(fmt.Sprintf("gotest-report  %s  [filter: %s]", m.summary, tuiFilters[m.filter]), m.width)+"\x1b[0m")
+	rows := m.rows()
+	if len(rows) == 0 {
+		lines = append(lines, "No tests match the filter")
+	}
+	end := min(len(rows), m.offset+m.pageSize())
+	for i := m.offset; i < end; i++ {
+		node := rows[i]
+		marker := " "
+		if len(node.Children) > 0 {
+			marker = "▸"
+			if m.expanded[node.Key] {
+				marker = "▾"
+			}
+		}
+		text := fmt.Sprintf("%s%s %s", strings.Repeat("  ", node.Depth), marker, node.Label)
+		duration := formatDuration(node.Duration)
+		text = truncate(text, m.width-len(duration)-4)
+		padding := max(1, m.width-utf8.RuneCountInString(text)-len(duration)-3)
+		line := text + strings.Repeat(" ", padding) + duration
+		if i == m.cursor {
+			line = "\x1b[7m" + line + "\x1b[0m"
+		}
+		lines = append(lines, tuiStatus(node.Status)+" "+line)
+	}
+	for len(lines) < m.pageSize()+1 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, "", truncate("↑/↓ move  ←/→ collapse/expand  enter open  f filter  q quit", m.width))
+	return strings.Join(lines, "\r\n")
+}
+
+// parseKeys splits terminal input into key names
+func parseKeys(input []byte) []string {
+	sequences := map[string]string{
+		"\x1b[A": "up", "\x1b[B": "down", "\x1b[C": "right", "\x1b[D": "left",
+		"\x1bOA": "up", "\x1bOB": "down", "\x1bOC": "right", "\x1bOD": "left",
+		"\x1b[5~": "pgup", "\x1b[6~": "pgdown", "\x1b[H": "g", "\x1b[F": "G",
+	}
+	var keys []string
+	s := string(input)
+	for len(s) > 0 {
+		matched := false
+		for seq, key := range sequences {
+			if strings.HasPrefix(s, seq) {
+				keys, s, matched = append(keys, key), s[len(seq):], true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		switch s[0] {
+		case '\r', '\n':
+			keys = append(keys, "enter")
+		case 0x03:
+			keys = append(keys, "ctrl-c")
+		case 0x1b:
+			keys = append(keys, "esc")
+		case 0x7f, 0x08:
+			keys = append(keys, "backspace")
+		default:
+			r, size := utf8.DecodeRuneInString(s)
+			keys = append(keys, string(r))
+			s = s[size:]
+			continue
+		}
+		s = s[1:]
+	}
+	return keys
+}
+
+// stty runs stty on the terminal
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// terminalSize returns the rows and columns of the terminal, or 24x80 when
+// they cannot be determined
+func terminalSize(tty *os.File) (int, int) {
+	out, err := stty(tty, "size")
+	if err == nil {
+		if fields := strings.Fields(out); len(fields) == 2 {
+			rows, err1 := strconv.Atoi(fields[0])
+			cols, err2 := strconv.Atoi(fields[1])
+			if err1 == nil && err2 == nil && rows > 0 && cols > 0 {
+				return rows, cols
+			}
+		}
+	}
+	return 24, 80
+}
+
+// runTUI implements `gotest-report tui -input FILE`, an interactive terminal
+// viewer for triaging a run without generating a report
+func runTUI(args []string) int {
+	fs := flag.NewFlagSet("tui", flag.ExitOnError)
+	logFlags := registerLogFlags(fs)
+	configFile := registerConfigFlag(fs)
+	var inputs stringList
+	fs.Var(&inputs, "input", "go test -json output file; repeat or use a glob to merge sharded runs")
+	maxLineSize := fs.Int("max-line-size", defaultMaxLineSize, "Maximum size in bytes of a single go test -json input line")
+	fs.Parse(args)
+	if err := logFlags.apply(); err != nil {
+		logger.Errorf("Error: %v", err)
+		return 2
+	}
+	if len(inputs) == 0 {
+		// The run is only read from -input files; key presses come from
+		// /dev/tty, which is opened below, not from standard input
+		logger.Errorf("Usage: gotest-report tui -input FILE [-input FILE...]")
+		return 2
+	}
+
+	_, normalize, err := commandConfig(*configFile)
+	if err != nil {
+		logger.Errorf("Error loading config: %v", err)
+		return 1
+	}
+	data, err := loadReports(inputs, ParseOptions{MaxLineSize: *maxLineSize, Normalize: normalize})
+	if err != nil {
+		logger.Errorf("Error %v", err)
+		return 1
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		logger.Errorf("Error opening terminal: %v", err)
+		return 1
+	}
+	defer tty.Close()
+	saved, err := stty(tty, "-g")
+	if err != nil {
+		logger.Errorf("Error reading terminal settings: %v", err)
+		return 1
+	}
+	if _, err := stty(tty, "raw", "-echo"); err != nil {
+		logger.Errorf("Error switching terminal to raw mode: %v", err)
+		return 1
+	}
+	defer stty(tty, saved)
+
+	// Alternate screen with a hidden cursor, restored on exit
+	fmt.Fprint(tty, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(tty, "\x1b[?25h\x1b[?1049l")
+
+	model := newTUIModel(data)
+	buf := make([]byte, 64)
+	for {
+		// The size is read before every frame so resizing takes effect on the next key
+		model.height, model.width = terminalSize(tty)
+		fmt.Fprint(tty, "\x1b[H\x1b[2J"+model.render())
+		n, err := tty.Read(buf)
+		if err != nil {
+			return 0
+		}
+		for _, key := range parseKeys(buf[:n]) {
+			if model.handleKey(key) {
+				return 0
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTUIModel(t *testing.T) {
+	data, err := sampleReport("failures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newTUIModel(data)
+	m.width, m.height = 100, 20
+
+	labels := func() []string {
+		var list []string
+		for _, row := range m.rows() {
+			list = append(list, row.Label)
+		}
+		return list
+	}
+
+	// Failed packages start expanded
+	expected := []string{"example.com/broken", "example.com/parser", "TestPanic", "TestParse"}
+	if got := labels(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected rows %v, got %v", expected, got)
+	}
+
+	// Expanding TestParse shows its subtests by their short names
+	for _, key := range []string{"G", "right"} {
+		m.handleKey(key)
+	}
+	expected = append(expected, "empty", "nested")
+	if got := labels(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected rows %v, got %v", expected, got)
+	}
+
+	// The failed filter hides the passing subtest
+	m.handleKey("f")
+	expected = []string{"example.com/broken", "example.com/parser", "TestPanic", "TestParse", "nested"}
+	if got := labels(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected filtered rows %v, got %v", expected, got)
+	}
+
+	// Enter on a leaf opens its output, q goes back to the tree
+	m.handleKey("G")
+	m.handleKey("enter")
+	if m.viewing == nil || m.viewing.Label != "nested" {
+		t.Fatalf("Expected the output of nested to be shown, got %+v", m.viewing)
+	}
+	if screen := m.render(); !strings.Contains(screen, "parser_test.go:42: unexpected result:") {
+		t.Errorf("Expected the failure output on screen, got:\n%s", screen)
+	}
+	if m.handleKey("q") || m.viewing != nil {
+		t.Error("Expected q to leave the output view without quitting")
+	}
+
+	// Left on a leaf moves to its parent
+	m.handleKey("left")
+	if row := m.rows()[m.cursor]; row.Label != "TestParse" {
+		t.Errorf("Expected the cursor on TestParse, got %s", row.Label)
+	}
+	if !m.handleKey("q") {
+		t.Error("Expected q to quit from the tree")
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	got := parseKeys([]byte("\x1b[Aj\r\x1b[6~\x1bq\x03é"))
+	expected := []string{"up", "j", "enter", "pgdown", "esc", "q", "ctrl-c", "é"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
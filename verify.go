@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxReportedViolations bounds the Stream Verification table
+const maxReportedViolations = 50
+
+// StreamViolation is a broken invariant of the go test -json event stream
+type StreamViolation struct {
+	Input   string `json:"input,omitempty"` // Input file when several were merged
+	Line    int    `json:"line"`            // Input line, 0 for violations found at the end of the stream
+	Package string `json:"package,omitempty"`
+	Test    string `json:"test,omitempty"`
+	Message string `json:"message"`
+}
+
+// StreamVerification is the result of -verify-stream
+type StreamVerification struct {
+	Violations []StreamViolation `json:"violations"`
+}
+
+// streamVerifier checks the events of a stream as they are parsed
+type streamVerifier struct {
+	violations []StreamViolation
+	lastTime   map[string]time.Time // Keyed by package and test
+	running    map[string]int       // Line of the run event of unfinished tests
+}
+
+func newStreamVerifier() *streamVerifier {
+	return &streamVerifier{
+		lastTime: make(map[string]time.Time),
+		running:  make(map[string]int),
+	}
+}
+
+func (v *streamVerifier) report(line int, event TestEvent, format string, args ...interface{}) {
+	v.violations = append(v.violations, StreamViolation{
+		Line:    line,
+		Package: event.Package,
+		Test:    event.Test,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// check verifies a single event; raw is the JSON line it was decoded from
+func (v *streamVerifier) check(line int, raw []byte, event TestEvent) {
+	if event.Action == "build-output" || event.Action == "build-fail" {
+		return
+	}
+	key := event.Package + "\x00" + event.Test
+
+	if !event.Time.IsZero() {
+		if last, ok := v.lastTime[key]; ok && event.Time.Before(last) {
+			v.report(line, event, "timestamp %s is before the previous event at %s",
+				event.Time.Format(time.RFC3339Nano), last.Format(time.RFC3339Nano))
+		} else {
+			v.lastTime[key] = event.Time
+		}
+	}
+
+	switch event.Action {
+	case "run":
+		if event.Test == "" {
+			v.report(line, event, "run event without a test name")
+		} else if started, ok := v.running[key]; ok {
+			v.report(line, event, "run event while the run started on line %d has no terminal event", started)
+		}
+		v.running[key] = line
+	case "pass", "fail", "skip":
+		if event.Test != "" {
+			if _, ok := v.running[key]; !ok {
+				v.report(line, event, "%s event without a preceding run event", event.Action)
+			}
+			delete(v.running, key)
+		}
+		// Elapsed is a pointer in test2json, so 0 is still present
+		var elapsed struct{ Elapsed *float64 }
+		if json.Unmarshal(raw, &elapsed) == nil && elapsed.Elapsed == nil && event.Action != "skip" {
+			v.report(line, event, "%s event without Elapsed", event.Action)
+		}
+	}
+}
+
+// finish reports the tests that never completed and returns the result
+func (v *streamVerifier) finish() *StreamVerification {
+	var keys []string
+	for key := range v.running {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		pkg, test, _ := strings.Cut(key, "\x00")
+		v.report(0, TestEvent{Package: pkg, Test: test}, "no terminal event for the run started on line %d", v.running[key])
+	}
+	return &StreamVerification{Violations: append([]StreamViolation{}, v.violations...)}
+}
+
+// writeStreamVerificationSection renders the violations found by -verify-stream
+func writeStreamVerificationSection(sb *strings.Builder, verification *StreamVerification) {
+	if verification == nil {
+		return
+	}
+	violations := verification.Violations
+
+	sb.WriteString("## Stream Verification\n\n")
+	if len(violations) == 0 {
+		sb.WriteString(statusEmoji("PASS") + " The event stream satisfies all invariants.\n\n")
+		return
+	}
+	sb.WriteString(fmt.Sprintf("%s %d invariant violation(s) in the event stream.\n\n", statusEmoji("FAIL"), len(violations)))
+	sb.WriteString("| Line | Package | Test | Violation |\n")
+	sb.WriteString("| ---- | ------- | ---- | --------- |\n")
+	for i, v := range violations {
+		if i == maxReportedViolations {
+			sb.WriteString(fmt.Sprintf("\n_%d more violation(s) not shown._\n", len(violations)-maxReportedViolations))
+			break
+		}
+		line := "end"
+		if v.Line > 0 {
+			line = fmt.Sprintf("%d", v.Line)
+		}
+		if v.Input != "" {
+			line = v.Input + ":" + line
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", escapeMarkdown(line), escapeMarkdown(v.Package), escapeMarkdown(v.Test), escapeMarkdown(v.Message)))
+	}
+	sb.WriteString("\n")
+}
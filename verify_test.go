@@ -0,0 +1,112 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerifyStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string // Expected violation messages, in order
+	}{
+		{
+			name: "valid stream",
+			input: `{"Time":"2024-01-01T00:00:00Z","Action":"run","Package":"pkg","Test":"TestA"}
+{"Time":"2024-01-01T00:00:01Z","Action":"output","Package":"pkg","Test":"TestA","Output":"ok\n"}
+{"Time":"2024-01-01T00:00:01Z","Action":"pass","Package":"pkg","Test":"TestA","Elapsed":0}
+{"Time":"2024-01-01T00:00:01Z","Action":"run","Package":"pkg","Test":"TestB"}
+{"Time":"2024-01-01T00:00:01Z","Action":"skip","Package":"pkg","Test":"TestB"}
+{"Time":"2024-01-01T00:00:02Z","Action":"pass","Package":"pkg","Elapsed":2}`,
+		},
+		{
+			name: "missing terminal event",
+			input: `{"Time":"2024-01-01T00:00:00Z","Action":"run","Package":"pkg","Test":"TestA"}
+{"Time":"2024-01-01T00:00:01Z","Action":"pass","Package":"pkg","Elapsed":1}`,
+			expected: []string{"no terminal event for the run started on line 1"},
+		},
+		{
+			name: "missing elapsed",
+			input: `{"Time":"2024-01-01T00:00:00Z","Action":"run","Package":"pkg","Test":"TestA"}
+{"Time":"2024-01-01T00:00:01Z","Action":"fail","Package":"pkg","Test":"TestA"}`,
+			expected: []string{"fail event without Elapsed"},
+		},
+		{
+			name: "timestamp going backwards",
+			input: `{"Time":"2024-01-01T00:00:05Z","Action":"run","Package":"pkg","Test":"TestA"}
+{"Time":"2024-01-01T00:00:01Z","Action":"pass","Package":"pkg","Test":"TestA","Elapsed":0}`,
+			expected: []string{"timestamp 2024-01-01T00:00:01Z is before the previous event at 2024-01-01T00:00:05Z"},
+		},
+		{
+			name:     "terminal event without run",
+			input:    `{"Time":"2024-01-01T00:00:00Z","Action":"pass","Package":"pkg","Test":"TestA","Elapsed":0}`,
+			expected: []string{"pass event without a preceding run event"},
+		},
+		{
+			name: "run twice",
+			input: `{"Time":"2024-01-01T00:00:00Z","Action":"run","Package":"pkg","Test":"TestA"}
+{"Time":"2024-01-01T00:00:00Z","Action":"run","Package":"pkg","Test":"TestA"}
+{"Time":"2024-01-01T00:00:01Z","Action":"pass","Package":"pkg","Test":"TestA","Elapsed":1}`,
+			expected: []string{"run event while the run started on line 1 has no terminal event"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := parseTestEvents(strings.NewReader(tt.input), ParseOptions{VerifyStream: true})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if data.Verification == nil {
+				t.Fatal("Expected a verification result")
+			}
+			violations := data.Verification.Violations
+			if len(violations) != len(tt.expected) {
+				t.Fatalf("Expected %d violations, got %+v", len(tt.expected), violations)
+			}
+			for i, message := range tt.expected {
+				if violations[i].Message != message {
+					t.Errorf("Violation %d: expected %q, got %q", i, message, violations[i].Message)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyStreamDisabled(t *testing.T) {
+	input := `{"Time":"2024-01-01T00:00:00Z","Action":"run","Package":"pkg","Test":"TestA"}`
+	data, err := parseTestEvents(strings.NewReader(input), ParseOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data.Verification != nil {
+		t.Errorf("Expected no verification without -verify-stream, got %+v", data.Verification)
+	}
+}
+
+func TestStreamVerificationSection(t *testing.T) {
+	var sb strings.Builder
+	writeStreamVerificationSection(&sb, &StreamVerification{Violations: []StreamViolation{
+		{Input: "shard1.json", Line: 3, Package: "pkg", Test: "TestA", Message: "fail event without Elapsed"},
+		{Package: "pkg", Test: "TestB", Message: "no terminal event for the run started on line 7"},
+	}})
+	report := sb.String()
+
+	for _, expected := range []string{
+		"## Stream Verification",
+		"2 invariant violation(s)",
+		"| shard1.json:3 | pkg | TestA | fail event without Elapsed |",
+		"| end | pkg | TestB |",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Expected section to contain %q, got:\n%s", expected, report)
+		}
+	}
+
+	sb.Reset()
+	writeStreamVerificationSection(&sb, &StreamVerification{})
+	if !strings.Contains(sb.String(), "satisfies all invariants") {
+		t.Errorf("Expected a clean stream to be reported, got:\n%s", sb.String())
+	}
+}
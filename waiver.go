@@ -0,0 +1,205 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// waiversFile is the name of the waiver store inside the history directory
+const waiversFile = "waivers.json"
+
+// Waiver records the decision to accept a known failure, e.g. to ship anyway
+type Waiver struct {
+	Fingerprint string    `json:"fingerprint"`
+	Test        string    `json:"test,omitempty"`
+	Package     string    `json:"package,omitempty"`
+	Reason      string    `json:"reason"`
+	By          string    `json:"by"`
+	Created     time.Time `json:"created"`
+}
+
+// failureMessage returns the first line of output describing a failure,
+// ignoring the test framing lines
+func failureMessage(output []string) string {
+	for _, line := range output {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "=== ") || strings.HasPrefix(trimmed, "--- ") {
+			continue
+		}
+		if strings.Contains(line, "FAIL") || strings.Contains(line, "Error") || strings.Contains(line, "panic:") {
+			return trimmed
+		}
+	}
+	return ""
+}
+
+// failureFingerprint identifies a failure by test and message, normalized
+// with rules, so it can be matched across runs
+func failureFingerprint(result *TestResult, rules []normalizeRule) string {
+	message := normalizeMessage(failureMessage(result.Output), rules)
+	sum := sha256.Sum256([]byte(result.Package + "\x00" + result.Name + "\x00" + message))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// assignFingerprints sets the fingerprint of every failed test
+func assignFingerprints(data *ReportData, rules []normalizeRule) {
+	for _, result := range data.Results {
+		if result.Status == "FAIL" {
+			result.Fingerprint = failureFingerprint(result, rules)
+		}
+	}
+}
+
+// loadWaivers reads the waivers stored in the history directory
+func loadWaivers(historyDir string) ([]Waiver, error) {
+	content, err := os.ReadFile(filepath.Join(historyDir, waiversFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var waivers []Waiver
+	if err := json.Unmarshal(content, &waivers); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", waiversFile, err)
+	}
+	return waivers, nil
+}
+
+// saveWaivers writes the waivers into the history directory
+func saveWaivers(historyDir string, waivers []Waiver) error {
+	if err := os.MkdirAll(historyDir, 0o755); err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(waivers, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(historyDir, waiversFile), content, 0o644)
+}
+
+// addWaiver stores a waiver, replacing an existing one for the same fingerprint
+func addWaiver(historyDir string, waiver Waiver) error {
+	waivers, err := loadWaivers(historyDir)
+	if err != nil {
+		return err
+	}
+	return saveWaivers(historyDir, replaceWaiver(waivers, waiver))
+}
+
+// replaceWaiver returns waivers with waiver added or, if its fingerprint is
+// already waived, in place of the existing waiver
+func replaceWaiver(waivers []Waiver, waiver Waiver) []Waiver {
+	for i, existing := range waivers {
+		if existing.Fingerprint == waiver.Fingerprint {
+			waivers[i] = waiver
+			return waivers
+		}
+	}
+	return append(waivers, waiver)
+}
+
+// applyWaivers attaches matching waivers to failed tests
+func applyWaivers(data *ReportData, waivers []Waiver) {
+	byFingerprint := make(map[string]*Waiver, len(waivers))
+	for i := range waivers {
+		byFingerprint[waivers[i].Fingerprint] = &waivers[i]
+	}
+	for _, result := range data.Results {
+		if result.Fingerprint != "" {
+			result.Waiver = byFingerprint[result.Fingerprint]
+		}
+	}
+}
+
+// waivedFailures counts failed root tests covered by a waiver
+func waivedFailures(data *ReportData) int {
+	count := 0
+	for _, result := range data.Results {
+		if !result.IsSubTest && result.Status == "FAIL" && result.Waiver != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// writeFailureFingerprint renders the fingerprint of a failed test and, if
+// the failure was quarantined or waived, why it is accepted
+func writeFailureFingerprint(sb *strings.Builder, result *TestResult) {
+	if result.Fingerprint == "" {
+		return
+	}
+	if q := result.Quarantine; q != nil {
+		sb.WriteString("> 🔒 **Quarantined**, does not fail the run")
+		if q.Reason != "" {
+			sb.WriteString(": " + escapeMarkdown(q.Reason))
+		}
+		sb.WriteString("\n\n")
+	}
+	if w := result.Waiver; w != nil {
+		sb.WriteString(fmt.Sprintf("> 🛡️ **Waived** by %s on %s: %s\n\n", escapeMarkdown(w.By), w.Created.Format("2006-01-02"), escapeMarkdown(w.Reason)))
+	}
+	category := ""
+	if result.Category != "" {
+		category = fmt.Sprintf("Category: **%s** · ", escapeMarkdown(result.Category))
+	}
+	sb.WriteString(fmt.Sprintf("<sub>%sFingerprint: `%s`</sub>\n\n", category, result.Fingerprint))
+}
+
+// runWaive implements `gotest-report waive`, recording a known failure as
+// accepted in the history store
+func runWaive(args []string) int {
+	fs := flag.NewFlagSet("waive", flag.ExitOnError)
+	logFlags := registerLogFlags(fs)
+	historyDir := fs.String("history-dir", "", "History directory the waiver is stored in")
+	historyURL := fs.String("history-url", "", "Shared remote history the waiver is stored in instead of -history-dir: an http(s) document URL or a postgres:// DSN")
+	fingerprint := fs.String("fingerprint", "", "Fingerprint of the failure, as shown in the report")
+	test := fs.String("test", "", "Name of the failing test (informational)")
+	pkg := fs.String("package", "", "Package of the failing test (informational)")
+	reason := fs.String("reason", "", "Why the failure is accepted")
+	by := fs.String("by", "", "Who accepts the failure (default is $GITHUB_ACTOR or $USER)")
+	fs.Parse(args)
+	if err := logFlags.apply(); err != nil {
+		logger.Errorf("Error: %v", err)
+		return 2
+	}
+
+	if (*historyDir == "" && *historyURL == "") || *fingerprint == "" || *reason == "" {
+		logger.Errorf("Usage: gotest-report waive -history-dir DIR|-history-url URL -fingerprint FP -reason TEXT [-by NAME]")
+		return 2
+	}
+	author := *by
+	if author == "" {
+		author = os.Getenv("GITHUB_ACTOR")
+	}
+	if author == "" {
+		author = os.Getenv("USER")
+	}
+	store, err := openHistoryStore(*historyDir, *historyURL)
+	if err != nil {
+		logger.Errorf("Error opening history: %v", err)
+		return 1
+	}
+	defer store.Close()
+
+	waiver := Waiver{
+		Fingerprint: *fingerprint,
+		Test:        *test,
+		Package:     *pkg,
+		Reason:      *reason,
+		By:          author,
+		Created:     time.Now().UTC(),
+	}
+	if err := store.PutWaiver(waiver); err != nil {
+		logger.Errorf("Error saving waiver: %v", err)
+		return 1
+	}
+	logger.Printf("Waived failure %s", *fingerprint)
+	return 0
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFailureFingerprint(t *testing.T) {
+	base := &TestResult{
+		Name:    "TestCheckout",
+		Package: "pkg/shop",
+		Output: []string{
+			"=== RUN   TestCheckout",
+			"    checkout_test.go:42: Error: expected 200, got 500",
+			"--- FAIL: TestCheckout (0.12s)",
+		},
+	}
+	moved := &TestResult{
+		Name:    "TestCheckout",
+		Package: "pkg/shop",
+		Output: []string{
+			"=== RUN   TestCheckout",
+			"    checkout_test.go:57: Error: expected 200, got 500",
+			"--- FAIL: TestCheckout (0.31s)",
+		},
+	}
+	different := &TestResult{
+		Name:    "TestCheckout",
+		Package: "pkg/shop",
+		Output:  []string{"    checkout_test.go:42: Error: connection refused"},
+	}
+
+	fp := failureFingerprint(base, nil)
+	if len(fp) != 12 {
+		t.Errorf("Fingerprint should be 12 characters, got %q", fp)
+	}
+	if failureFingerprint(moved, nil) != fp {
+		t.Error("Line numbers and durations should not change the fingerprint")
+	}
+	if failureFingerprint(different, nil) == fp {
+		t.Error("A different failure message should change the fingerprint")
+	}
+}
+
+func TestWaiversInReport(t *testing.T) {
+	dir := t.TempDir()
+	data := &ReportData{
+		TotalTests:      1,
+		FailedTests:     1,
+		SortedTestNames: []string{"TestKnown"},
+		Results: map[string]*TestResult{
+			"TestKnown": {Name: "TestKnown", Status: "FAIL", Output: []string{"known_test.go:3: Error: flaky upstream"}},
+		},
+	}
+	assignFingerprints(data, nil)
+	fp := data.Results["TestKnown"].Fingerprint
+
+	markdown := generateMarkdownReport(data)
+	if !strings.Contains(markdown, "Fingerprint: `"+fp+"`") {
+		t.Error("Failed tests should show their fingerprint")
+	}
+	if strings.Contains(markdown, "Waived") {
+		t.Error("Nothing is waived yet")
+	}
+
+	created := time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC)
+	if err := addWaiver(dir, Waiver{Fingerprint: fp, Reason: "upstream outage", By: "alice", Created: created}); err != nil {
+		t.Fatal(err)
+	}
+	if err := addWaiver(dir, Waiver{Fingerprint: fp, Reason: "upstream outage, ticket OPS-1", By: "alice", Created: created}); err != nil {
+		t.Fatal(err)
+	}
+
+	waivers, err := loadWaivers(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(waivers) != 1 {
+		t.Fatalf("Waiving the same fingerprint twice should replace the waiver, got %d", len(waivers))
+	}
+
+	applyWaivers(data, waivers)
+	markdown = generateMarkdownReport(data)
+	for _, expected := range []string{
+		"- **Waived Failures:** 1",
+		"> 🛡️ **Waived** by alice on 2024-03-20: upstream outage, ticket OPS-1",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected %q in report", expected)
+		}
+	}
+
+	data.Results["TestKnown"].Waiver = &Waiver{Fingerprint: fp, Reason: "see [runbook](http://evil) <img src=x>", By: "**mallory**", Created: created}
+	if markdown := generateMarkdownReport(data); !strings.Contains(markdown, "by \\*\\*mallory\\*\\* on 2024-03-20: see \\[runbook\\](http://evil) &lt;img src=x&gt;") {
+		t.Errorf("Expected the waiver to be escaped, got:\n%s", markdown)
+	}
+
+	if missing, err := loadWaivers(filepath.Join(dir, "missing")); err != nil || missing != nil {
+		t.Errorf("Missing waiver store should be empty, got %v, %v", missing, err)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// inputState describes the size and modification time of every input, or is
+// empty while an input is missing, e.g. before go test first wrote it
+func inputState(inputs []string) string {
+	files, err := expandInputs(inputs)
+	if err != nil {
+		return ""
+	}
+	var state strings.Builder
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return ""
+		}
+		fmt.Fprintf(&state, "%s:%d:%d\n", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return state.String()
+}
+
+// watchInputs calls regenerate once the inputs exist and again whenever they
+// change, until stop is closed. A change is only picked up once the inputs
+// stayed the same for an interval, so a file still being written by
+// `go test -json ./... > out.json` is not rendered half way.
+func watchInputs(inputs []string, interval time.Duration, regenerate func(), stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var rendered, pending string
+	for {
+		state := inputState(inputs)
+		switch {
+		case state == "" || state == rendered:
+			pending = ""
+		case state == pending:
+			rendered = state
+			regenerate()
+		default:
+			pending = state
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runWatch regenerates the report of runGenerate whenever its inputs change.
+// Only the report files are rewritten, see generate.
+func runWatch(name string, args []string, minInputs int, inputs []string, interval time.Duration) int {
+	if len(inputs) == 0 {
+		logger.Errorf("Error: -watch requires input files, it cannot watch stdin")
+		return 2
+	}
+	if interval <= 0 {
+		logger.Errorf("Error: -watch-interval must be positive")
+		return 2
+	}
+	logger.Printf("Watching %s for changes, press Ctrl+C to stop", strings.Join(inputs, ", "))
+	logger.Printf("The history, PR comments, notifications and uploads are skipped while watching")
+	watchInputs(inputs, interval, func() {
+		logger.Printf("[%s] Regenerating report", time.Now().Format("15:04:05"))
+		generate(name, args, minInputs, true)
+	}, nil)
+	return 0
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatchInputs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.json")
+	var generated atomic.Int32
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		watchInputs([]string{path}, 5*time.Millisecond, func() { generated.Add(1) }, stop)
+		close(done)
+	}()
+
+	waitFor := func(count int32) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for generated.Load() < count {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected %d regenerations, got %d", count, generated.Load())
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// Nothing is generated before the input exists
+	time.Sleep(30 * time.Millisecond)
+	if generated.Load() != 0 {
+		t.Fatalf("Expected no regeneration without input, got %d", generated.Load())
+	}
+
+	if err := os.WriteFile(path, []byte(`{"Action":"run"}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(1)
+
+	// Unchanged inputs are not regenerated again
+	time.Sleep(30 * time.Millisecond)
+	if generated.Load() != 1 {
+		t.Fatalf("Expected a single regeneration for unchanged input, got %d", generated.Load())
+	}
+
+	if err := os.WriteFile(path, []byte(`{"Action":"run"}`+"\n"+`{"Action":"pass"}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(2)
+
+	close(stop)
+	<-done
+}
+
+func TestWatchRegenerationSkipsSideEffects(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "out.json")
+	os.WriteFile(input, []byte(`{"Action":"pass","Package":"pkg","Test":"TestA","Elapsed":0.1}`+"\n"), 0o644)
+	history := filepath.Join(dir, "history")
+	feed := filepath.Join(dir, "runs.xml")
+	calendar := filepath.Join(dir, "runs.ics")
+	annotations := filepath.Join(dir, "annotations.txt")
+	output := filepath.Join(dir, "report.md")
+
+	args := []string{"-input", input, "-output", output, "-history-dir", history, "-atom-feed", feed, "-slack-webhook", "http://127.0.0.1:1/hook",
+		"-ical", calendar, "-annotations", "buildkite", "-annotations-output", annotations}
+	if code := generate("generate", args, 0, true); code != 0 {
+		t.Fatalf("Expected the regeneration to succeed, got exit code %d", code)
+	}
+	if report, err := os.ReadFile(output); err != nil || !strings.Contains(string(report), "TestA") {
+		t.Errorf("Expected the report to be written, got %v", err)
+	}
+	if runs, _ := loadHistory(history, 0); len(runs) != 0 {
+		t.Errorf("Expected the run not to be recorded, got %d run(s)", len(runs))
+	}
+	if _, err := os.Stat(feed); !os.IsNotExist(err) {
+		t.Errorf("Expected no Atom feed, got %v", err)
+	}
+	for _, file := range []string{calendar, annotations} {
+		if _, err := os.Stat(file); !os.IsNotExist(err) {
+			t.Errorf("Expected no %s, got %v", filepath.Base(file), err)
+		}
+	}
+}